@@ -19,10 +19,17 @@ import (
 	"github.com/ybds/internal/api/handlers"
 	"github.com/ybds/internal/database"
 	"github.com/ybds/internal/middleware"
+	"github.com/ybds/internal/models/account"
+	"github.com/ybds/internal/models/order"
+	"github.com/ybds/internal/models/product"
 	"github.com/ybds/internal/services"
 	"github.com/ybds/pkg/config"
+	"github.com/ybds/pkg/currency"
 	pkgdb "github.com/ybds/pkg/database"
+	pkgemail "github.com/ybds/pkg/email"
 	pkgjwt "github.com/ybds/pkg/jwt"
+	"github.com/ybds/pkg/shipping/ghn"
+	pkgsms "github.com/ybds/pkg/sms"
 	pkgtelegram "github.com/ybds/pkg/telegram"
 	pkgupload "github.com/ybds/pkg/upload"
 	pkgws "github.com/ybds/pkg/websocket"
@@ -93,16 +100,23 @@ func main() {
 		log.Fatalf("Failed to initialize JWT service: %v", err)
 	}
 
-	// Initialize websocket hub
-	hub := pkgws.NewHub()
+	// Initialize websocket hub; notification topics are only open to staff
+	hub := pkgws.NewHub().WithTopicAuth(func(client *pkgws.Client, topic string) bool {
+		return client.HasAnyRole(string(account.RoleAdmin), string(account.RoleAgent), string(account.RoleStaff))
+	})
 	go hub.Run()
 
 	// Initialize upload service
 	uploadConfig := pkgupload.NewConfig(cfg.Upload.Dir)
 	uploadConfig.WithSubDir("products")
+	if cfg.Upload.MaxSizeMB > 0 {
+		uploadConfig.WithMaxSize(int64(cfg.Upload.MaxSizeMB))
+	}
 
-	// Check if S3 is configured
-	if cfg.AWS.AccessKey != "" && cfg.AWS.SecretKey != "" && cfg.AWS.Region != "" && cfg.AWS.Bucket != "" {
+	// Store uploads in S3 when selected, or when no storage is selected but S3 is configured
+	awsConfigured := cfg.AWS.AccessKey != "" && cfg.AWS.SecretKey != "" && cfg.AWS.Region != "" && cfg.AWS.Bucket != ""
+	storageType := pkgupload.StorageType(cfg.Upload.Storage)
+	if storageType == pkgupload.StorageTypeS3 || (storageType == "" && awsConfigured) {
 		uploadConfig.WithS3(
 			cfg.AWS.AccessKey,
 			cfg.AWS.SecretKey,
@@ -110,6 +124,10 @@ func main() {
 			cfg.AWS.Bucket,
 			cfg.AWS.Prefix,
 		)
+		uploadConfig.S3Config.Endpoint = cfg.AWS.Endpoint
+		uploadConfig.S3Config.BaseURL = cfg.AWS.BaseURL
+	} else if storageType != "" && storageType != pkgupload.StorageTypeLocal {
+		log.Printf("Warning: invalid upload storage %q, using %q", cfg.Upload.Storage, pkgupload.StorageTypeLocal)
 	}
 
 	uploadService, err := pkgupload.NewService(uploadConfig)
@@ -128,15 +146,179 @@ func main() {
 
 	// Initialize services in the correct order to respect dependencies
 	notificationService := services.NewNotificationService(dbConnections.NotificationDB, dbConnections.AccountDB, hub, telegramClient)
+	notificationService.TelegramChatDiscovery = cfg.Telegram.ChatDiscovery
+
+	// Initialize SMS client
+	if cfg.SMS.TwilioAccountSID != "" {
+		notificationService.SMSClient = pkgsms.NewTwilioClient(cfg.SMS.TwilioAccountSID, cfg.SMS.TwilioAuthToken, cfg.SMS.FromNumber)
+		log.Println("SMS client initialized successfully")
+	} else {
+		log.Println("Warning: SMS account not provided, SMS notifications will be disabled")
+	}
+
+	// Initialize email client
+	if cfg.Email.Enabled {
+		notificationService.EmailClient = pkgemail.NewSMTPClient(cfg.Email.SMTPHost, cfg.Email.SMTPPort,
+			cfg.Email.SMTPUsername, cfg.Email.SMTPPassword, cfg.Email.From)
+		log.Println("Email client initialized successfully")
+	} else {
+		log.Println("Warning: Email is disabled, email notifications will be marked as failed")
+	}
 	userService := services.NewUserService(dbConnections.AccountDB, notificationService)
 	productService := services.NewProductService(dbConnections.ProductDB, notificationService, uploadService)
 
+	priceSelection := product.PriceSelection(cfg.Pricing.MultipleCurrentPrices)
+	if priceSelection.IsValid() {
+		productService.PriceSelection = priceSelection
+	} else {
+		log.Printf("Warning: invalid multiple current prices selection %q, using %q", cfg.Pricing.MultipleCurrentPrices, product.PriceSelectionLatestStart)
+	}
+
+	// Exchange rates convert item prices to the order currency: fetched from an API when a URL is
+	// configured, otherwise fixed rates quoted in the order currency
+	orderCurrency := services.OrderSettings{Currency: cfg.Order.Currency}.WithDefaults().Currency
+	var rateProvider currency.ExchangeRateProvider
+	if cfg.Pricing.ExchangeRatesURL != "" {
+		rateProvider = currency.NewHTTPProvider(cfg.Pricing.ExchangeRatesURL)
+	} else {
+		staticRates, err := currency.ParseRates(orderCurrency, cfg.Pricing.ExchangeRates)
+		if err != nil {
+			log.Printf("Warning: invalid exchange rates %q, only same-currency prices can be ordered: %v", cfg.Pricing.ExchangeRates, err)
+			staticRates = &currency.StaticRates{Base: orderCurrency}
+		}
+		rateProvider = staticRates
+	}
+	exchangeRatesTTL, err := time.ParseDuration(cfg.Pricing.ExchangeRatesTTL)
+	if err != nil {
+		log.Printf("Warning: invalid exchange rates TTL %q, rates will not be cached: %v", cfg.Pricing.ExchangeRatesTTL, err)
+	}
+	currencyService := services.NewCurrencyService(currency.NewCachedProvider(rateProvider, exchangeRatesTTL))
+
+	// Start background jobs; they stop when the server shuts down
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	cleanupInterval, err := time.ParseDuration(cfg.Notification.CleanupInterval)
+	if err != nil {
+		log.Printf("Warning: invalid notification cleanup interval %q, cleanup disabled: %v", cfg.Notification.CleanupInterval, err)
+	}
+	retention := time.Duration(cfg.Notification.RetentionDays) * 24 * time.Hour
+	go notificationService.RunRetentionCleanup(jobsCtx, cleanupInterval, retention)
+
+	notificationRetryBackoff, err := time.ParseDuration(cfg.Notification.RetryBackoff)
+	if err != nil {
+		log.Printf("Warning: invalid notification retry backoff %q, using %s: %v", cfg.Notification.RetryBackoff, services.DefaultNotificationRetryBackoff, err)
+	}
+	notificationRetryInterval, err := time.ParseDuration(cfg.Notification.RetryInterval)
+	if err != nil {
+		log.Printf("Warning: invalid notification retry interval %q, retries disabled: %v", cfg.Notification.RetryInterval, err)
+	}
+	notificationService.Retry = services.NotificationRetrySettings{
+		MaxAttempts: cfg.Notification.RetryMaxAttempts,
+		Backoff:     notificationRetryBackoff,
+		Interval:    notificationRetryInterval,
+	}
+	go notificationService.StartRetryWorker(jobsCtx)
+
+	holdSweepInterval, err := time.ParseDuration(cfg.Order.HoldSweepInterval)
+	if err != nil {
+		log.Printf("Warning: invalid inventory hold sweep interval %q, sweeper disabled: %v", cfg.Order.HoldSweepInterval, err)
+	}
+	holdSweeper := services.NewOrderService(dbConnections.OrderDB, productService, userService, notificationService)
+	go holdSweeper.RunHoldExpirySweeper(jobsCtx, holdSweepInterval)
+
+	webhookRetryBackoff, err := time.ParseDuration(cfg.Webhook.RetryBackoff)
+	if err != nil {
+		log.Printf("Warning: invalid webhook retry backoff %q, using %s: %v", cfg.Webhook.RetryBackoff, services.DefaultWebhookRetryBackoff, err)
+	}
+	webhookTimeout, err := time.ParseDuration(cfg.Webhook.Timeout)
+	if err != nil {
+		log.Printf("Warning: invalid webhook timeout %q, using the default: %v", cfg.Webhook.Timeout, err)
+	}
+	webhookService := services.NewWebhookService(dbConnections.OrderDB, services.WebhookSettings{
+		MaxAttempts:  cfg.Webhook.MaxAttempts,
+		RetryBackoff: webhookRetryBackoff,
+		Timeout:      webhookTimeout,
+	})
+	webhookRetryInterval, err := time.ParseDuration(cfg.Webhook.RetryInterval)
+	if err != nil {
+		log.Printf("Warning: invalid webhook retry interval %q, retries disabled: %v", cfg.Webhook.RetryInterval, err)
+	}
+	go webhookService.RunRetryWorker(jobsCtx, webhookRetryInterval)
+
+	orderNumberReset := order.OrderNumberReset(cfg.Order.NumberReset)
+	if !orderNumberReset.IsValid() {
+		log.Printf("Warning: invalid order number reset %q, using %q", cfg.Order.NumberReset, order.OrderNumberResetDaily)
+	}
+
+	agentTargetStatuses, err := order.ParseOrderStatuses(cfg.Order.AgentTargetStatuses)
+	if err != nil {
+		log.Printf("Warning: invalid agent target statuses %q, using the defaults: %v", cfg.Order.AgentTargetStatuses, err)
+	}
+
+	ghnStatusMapper, err := ghn.NewStatusMapper().WithOverrides(cfg.Shipping.GHNStatusOverrides)
+	if err != nil {
+		log.Printf("Warning: invalid GHN status overrides %q, using the default mapping: %v", cfg.Shipping.GHNStatusOverrides, err)
+		ghnStatusMapper = ghn.NewStatusMapper()
+	}
+
+	requestTimeout, err := time.ParseDuration(cfg.Server.RequestTimeout)
+	if err != nil {
+		log.Printf("Warning: invalid request timeout %q, database queries will not time out: %v", cfg.Server.RequestTimeout, err)
+		requestTimeout = 0
+	}
+
+	passwordResetExpiry, err := time.ParseDuration(cfg.Auth.PasswordResetExpiry)
+	if err != nil {
+		log.Printf("Warning: invalid password reset expiry %q, using %s: %v", cfg.Auth.PasswordResetExpiry, services.DefaultPasswordResetExpiry, err)
+	}
+
+	// API keys of external systems are validated by the same service that manages them
+	apiKeyService := services.NewAPIKeyService(dbConnections.AccountDB)
+
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(dbConnections.AccountDB, jwtService, userService)
-	userHandler := handlers.NewUserHandler(dbConnections.AccountDB, notificationService)
-	productHandler := handlers.NewProductHandler(dbConnections.ProductDB, notificationService, uploadService)
-	orderHandler := handlers.NewOrderHandler(dbConnections.OrderDB, productService, userService, notificationService)
+	authHandler := handlers.NewAuthHandler(dbConnections.AccountDB, jwtService, userService, services.AuthSettings{
+		PasswordResetExpiry: passwordResetExpiry,
+	})
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	userHandler := handlers.NewUserHandler(dbConnections.AccountDB, dbConnections.OrderDB, notificationService)
+	productHandler := handlers.NewProductHandler(dbConnections.ProductDB, notificationService, uploadService, priceSelection)
+	orderHandler := handlers.NewOrderHandler(dbConnections.OrderDB, productService, userService, notificationService, webhookService, uploadService, currencyService, services.OrderSettings{
+		VolumetricDivisor:       cfg.Shipping.VolumetricDivisor,
+		DiscountApprovalAmount:  cfg.Order.DiscountApprovalAmount,
+		DiscountApprovalPercent: cfg.Order.DiscountApprovalPercent,
+		OrderNumberPrefix:       cfg.Order.NumberPrefix,
+		OrderNumberReset:        orderNumberReset,
+		AgentTargetStatuses:     agentTargetStatuses,
+		CheckAvailableToPromise: cfg.Order.CheckAvailableToPromise,
+		ReserveOnCreate:         cfg.Order.ReserveOnCreate,
+		BulkStatusMaxOrders:     cfg.Order.BulkStatusMaxOrders,
+		RequireDeliveryProof:    cfg.Order.RequireDeliveryProof,
+		DiscountReasonRequired:  cfg.Order.DiscountReasonRequired,
+		DiscountReasonMaxLength: cfg.Order.DiscountReasonMaxLength,
+		AdminEditHandedOver:     cfg.Order.AdminEditHandedOver,
+		Currency:                cfg.Order.Currency,
+		CurrencyLocale:          cfg.Order.CurrencyLocale,
+		ShippingSender: services.ShippingParty{
+			Name:     cfg.Shipping.SenderName,
+			Phone:    cfg.Shipping.SenderPhone,
+			Address:  cfg.Shipping.SenderAddress,
+			Ward:     cfg.Shipping.SenderWard,
+			District: cfg.Shipping.SenderDistrict,
+			City:     cfg.Shipping.SenderCity,
+			Country:  cfg.Shipping.SenderCountry,
+		},
+		GHNWebhookSecret: cfg.Shipping.GHNWebhookSecret,
+		GHNStatusMapper:  ghnStatusMapper,
+	})
+	reportHandler := handlers.NewReportHandler(dbConnections.OrderDB, dbConnections.ProductDB, cfg.Inventory.ReorderMultiplier)
+	searchHandler := handlers.NewSearchHandler(dbConnections.OrderDB, dbConnections.ProductDB)
+	recommendationHandler := handlers.NewRecommendationHandler(dbConnections.OrderDB, dbConnections.ProductDB)
+	customerHandler := handlers.NewCustomerHandler(dbConnections.OrderDB)
 	notificationHandler := handlers.NewNotificationHandler(dbConnections.NotificationDB, notificationService, hub)
+	auditHandler := handlers.NewAuditHandler(dbConnections.AccountDB)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	promoHandler := handlers.NewPromoHandler(dbConnections.OrderDB)
 
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
@@ -152,13 +334,8 @@ func main() {
 		}))
 	}
 
-	// Register static routes for serving uploaded files
-	if uploadConfig.StorageType == pkgupload.StorageTypeLocal {
-		// Create a filesystem that serves files from the upload directory
-		app.Static("/uploads", uploadConfig.GetUploadDir(), fiber.Static{
-			Browse: false,
-		})
-	}
+	// Register static routes for serving uploaded files; files in S3 are served by the bucket
+	uploadService.RegisterStaticRoutes(app)
 
 	// Register middleware
 	app.Use(recover.New())
@@ -173,6 +350,7 @@ func main() {
 
 	// Create API routes
 	api := app.Group("/api")
+	api.Use(middleware.RequestTimeout(requestTimeout))
 
 	// Create webhook routes
 	webhook := app.Group("/webhook")
@@ -188,6 +366,13 @@ func main() {
 	// Public routes that don't require authentication
 	api.Post("/auth/login", authHandler.Login)
 	api.Post("/auth/register", authHandler.Register)
+	api.Post("/auth/refresh", authHandler.Refresh)
+	api.Post("/auth/logout", authHandler.Logout)
+	api.Post("/auth/password-reset/request", authHandler.RequestPasswordReset)
+	api.Post("/auth/password-reset/confirm", authHandler.ConfirmPasswordReset)
+
+	// Public order tracking for customers
+	orderHandler.RegisterPublicRoutes(api)
 
 	// Register websocket route with its own middleware
 	wsHandler := pkgws.NewHandler(hub, pkgws.JWTAuthFunc(
@@ -221,8 +406,12 @@ func main() {
 
 	// Protected routes that require authentication
 	// Create authenticated routes group
+	// External systems authenticate with an API key instead of a user token
+	apiKeyOrJWTAuth := middleware.JWTOrAPIKeyAuth(jwtService, apiKeyService)
+
 	authenticated := api.Group("/")
-	authenticated.Use(middleware.JWTAuth(jwtService))
+	authenticated.Use(apiKeyOrJWTAuth)
+	authenticated.Use(middleware.Audit(services.NewAuditService(dbConnections.AccountDB)))
 
 	// Create admin-only routes
 	adminRoutes := authenticated.Group("/admin")
@@ -232,17 +421,41 @@ func main() {
 	adminOrAgentRoutes := authenticated.Group("/")
 	adminOrAgentRoutes.Use(middleware.AdminOrAgentGuard())
 
+	// Register API key routes - Admin only, with a user token
+	apiKeyHandler.RegisterRoutes(authenticated, middleware.JWTAuth(jwtService))
+
 	// Register user routes - Admin only
 	userHandler.RegisterRoutes(adminRoutes, middleware.JWTAuth(jwtService))
 
 	// Register notification routes - Admin only
 	notificationHandler.RegisterRoutes(adminRoutes, middleware.JWTAuth(jwtService))
 
+	// Register audit log routes - Admin only
+	auditHandler.RegisterRoutes(adminRoutes, middleware.JWTAuth(jwtService))
+
+	// Register outbound webhook routes - Admin only
+	webhookHandler.RegisterRoutes(adminRoutes, middleware.JWTAuth(jwtService))
+
+	// Register promo code routes - Admin only
+	promoHandler.RegisterRoutes(adminRoutes, middleware.JWTAuth(jwtService))
+
 	// Register product routes using the RegisterRoutes method
-	productHandler.RegisterRoutes(adminOrAgentRoutes, middleware.JWTAuth(jwtService))
+	productHandler.RegisterRoutes(adminOrAgentRoutes, apiKeyOrJWTAuth)
+
+	// Register product recommendation routes using the RegisterRoutes method
+	recommendationHandler.RegisterRoutes(adminOrAgentRoutes, apiKeyOrJWTAuth)
 
 	// Register order routes using the RegisterRoutes method
-	orderHandler.RegisterRoutes(adminOrAgentRoutes, middleware.JWTAuth(jwtService))
+	orderHandler.RegisterRoutes(adminOrAgentRoutes, apiKeyOrJWTAuth)
+
+	// Register report routes using the RegisterRoutes method
+	reportHandler.RegisterRoutes(adminOrAgentRoutes, apiKeyOrJWTAuth)
+
+	// Register global search routes using the RegisterRoutes method
+	searchHandler.RegisterRoutes(adminOrAgentRoutes, apiKeyOrJWTAuth)
+
+	// Register customer routes using the RegisterRoutes method
+	customerHandler.RegisterRoutes(adminOrAgentRoutes, apiKeyOrJWTAuth)
 
 	// Register GHN webhook route
 	webhook.Post("/ghn/order_status", orderHandler.HandleGHNOrderStatusWebhook)
@@ -264,6 +477,9 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down server...")
+	stopJobs()
+	webhookService.Wait()
+	notificationService.Wait()
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := app.ShutdownWithContext(ctx); err != nil {
@@ -274,13 +490,8 @@ func main() {
 
 // customErrorHandler handles errors returned from routes
 func customErrorHandler(c *fiber.Ctx, err error) error {
-	// Default status code
-	code := fiber.StatusInternalServerError
-
-	// Check if it's a Fiber error
-	if e, ok := err.(*fiber.Error); ok {
-		code = e.Code
-	}
+	// Map Fiber errors and classified service errors to their status code
+	code := handlers.ErrorStatus(err)
 
 	// Set Content-Type: application/json
 	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ybds/internal/api/requests"
+	"github.com/ybds/internal/api/responses"
+	"github.com/ybds/internal/middleware"
+	"github.com/ybds/internal/models/account"
+	"github.com/ybds/internal/services"
+)
+
+// APIKeyHandler handles HTTP requests related to the API keys of external systems
+type APIKeyHandler struct {
+	apiKeyService *services.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new instance of APIKeyHandler. It shares the API key service that
+// authenticates requests so revocations take effect right away.
+func NewAPIKeyHandler(apiKeyService *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// RegisterRoutes registers all routes related to API keys. API keys are managed by admins
+// authenticated with a user token; API keys cannot manage API keys.
+func (h *APIKeyHandler) RegisterRoutes(router fiber.Router, authMiddleware fiber.Handler) {
+	apiKeys := router.Group("/api-keys")
+	apiKeys.Use(authMiddleware)
+	apiKeys.Use(middleware.AdminGuard())
+
+	apiKeys.Post("/", h.CreateAPIKey)
+	apiKeys.Get("/", h.GetAPIKeys)
+	apiKeys.Get("/:id", h.GetAPIKeyByID)
+	apiKeys.Put("/:id", h.UpdateAPIKey)
+	apiKeys.Post("/:id/revoke", h.RevokeAPIKey)
+	apiKeys.Delete("/:id", h.DeleteAPIKey)
+}
+
+// apiKeyScopes converts the scopes of a request to permissions
+func apiKeyScopes(scopes []string) []account.Permission {
+	if scopes == nil {
+		return nil
+	}
+
+	permissions := make([]account.Permission, len(scopes))
+	for i, scope := range scopes {
+		permissions[i] = account.Permission(scope)
+	}
+	return permissions
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Create an API key for an external system, e.g. a POS, to call the API with in the X-API-Key header instead of a user token. The scopes are the permissions granted to the key; backoffice:access is needed for the routes shared by admins and agents, admin:access cannot be granted. The key is only returned in this response and stored hashed.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param api_key body requests.CreateAPIKeyRequest true "API key"
+// @Success 201 {object} responses.SingleAPIKeyResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/api-keys [post]
+// @Security ApiKeyAuth
+func (h *APIKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	var req requests.CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	var createdBy *uuid.UUID
+	if userID, ok := c.Locals("userID").(uuid.UUID); ok {
+		createdBy = &userID
+	}
+
+	apiKey, key, err := h.apiKeyService.CreateAPIKey(req.Label, apiKeyScopes(req.Scopes), createdBy)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to create API key",
+			Error:   err.Error(),
+		})
+	}
+
+	data := responses.ConvertToAPIKeyResponse(*apiKey)
+	data.Key = key
+
+	return c.Status(fiber.StatusCreated).JSON(responses.SingleAPIKeyResponse{
+		Success: true,
+		Message: "API key created successfully",
+		Data:    data,
+	})
+}
+
+// GetAPIKeys godoc
+// @Summary List API keys
+// @Description List the API keys, newest first, with their scopes and when they were last used
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Success 200 {object} responses.APIKeysResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/api-keys [get]
+// @Security ApiKeyAuth
+func (h *APIKeyHandler) GetAPIKeys(c *fiber.Ctx) error {
+	apiKeys, err := h.apiKeyService.GetAPIKeys()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to get API keys",
+			Error:   err.Error(),
+		})
+	}
+
+	data := make([]responses.APIKeyResponse, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		data[i] = responses.ConvertToAPIKeyResponse(apiKey)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.APIKeysResponse{
+		Success: true,
+		Message: "API keys retrieved successfully",
+		Data:    data,
+	})
+}
+
+// GetAPIKeyByID godoc
+// @Summary Get an API key
+// @Description Get an API key by ID. The key itself is not returned.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} responses.SingleAPIKeyResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/api-keys/{id} [get]
+// @Security ApiKeyAuth
+func (h *APIKeyHandler) GetAPIKeyByID(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid API key ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	apiKey, err := h.apiKeyService.GetAPIKeyByID(id)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to get API key",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.SingleAPIKeyResponse{
+		Success: true,
+		Message: "API key retrieved successfully",
+		Data:    responses.ConvertToAPIKeyResponse(*apiKey),
+	})
+}
+
+// UpdateAPIKey godoc
+// @Summary Update an API key
+// @Description Change the label or the scopes of an API key. Fields left out keep their current value.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param id path string true "API key ID"
+// @Param api_key body requests.UpdateAPIKeyRequest true "API key changes"
+// @Success 200 {object} responses.SingleAPIKeyResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/api-keys/{id} [put]
+// @Security ApiKeyAuth
+func (h *APIKeyHandler) UpdateAPIKey(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid API key ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	var req requests.UpdateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	var updatedBy *uuid.UUID
+	if userID, ok := c.Locals("userID").(uuid.UUID); ok {
+		updatedBy = &userID
+	}
+
+	apiKey, err := h.apiKeyService.UpdateAPIKey(id, req.Label, apiKeyScopes(req.Scopes), updatedBy)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to update API key",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.SingleAPIKeyResponse{
+		Success: true,
+		Message: "API key updated successfully",
+		Data:    responses.ConvertToAPIKeyResponse(*apiKey),
+	})
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Revoke an API key so it can no longer authenticate requests. The key stays listed.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} responses.SingleAPIKeyResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/api-keys/{id}/revoke [post]
+// @Security ApiKeyAuth
+func (h *APIKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid API key ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	var revokedBy *uuid.UUID
+	if userID, ok := c.Locals("userID").(uuid.UUID); ok {
+		revokedBy = &userID
+	}
+
+	apiKey, err := h.apiKeyService.RevokeAPIKey(id, revokedBy)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to revoke API key",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.SingleAPIKeyResponse{
+		Success: true,
+		Message: "API key revoked successfully",
+		Data:    responses.ConvertToAPIKeyResponse(*apiKey),
+	})
+}
+
+// DeleteAPIKey godoc
+// @Summary Delete an API key
+// @Description Delete an API key; requests authenticated with it are rejected from then on
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/api-keys/{id} [delete]
+// @Security ApiKeyAuth
+func (h *APIKeyHandler) DeleteAPIKey(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid API key ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := h.apiKeyService.DeleteAPIKey(id); err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to delete API key",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.SuccessResponse{
+		Success: true,
+		Message: "API key deleted successfully",
+	})
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ybds/internal/api/responses"
+	"github.com/ybds/internal/services"
+	"gorm.io/gorm"
+)
+
+// AuditHandler handles HTTP requests related to the audit log
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+// NewAuditHandler creates a new instance of AuditHandler
+func NewAuditHandler(accountDB *gorm.DB) *AuditHandler {
+	return &AuditHandler{
+		auditService: services.NewAuditService(accountDB),
+	}
+}
+
+// RegisterRoutes registers all routes related to the audit log
+func (h *AuditHandler) RegisterRoutes(router fiber.Router, authMiddleware fiber.Handler) {
+	auditLogs := router.Group("/audit-logs")
+	auditLogs.Use(authMiddleware)
+
+	auditLogs.Get("/", h.GetAuditLogs)
+}
+
+// GetAuditLogs godoc
+// @Summary Get the audit log
+// @Description Get the changes made to products, prices, inventories, orders and users, newest first, with pagination and filtering. Each entry records the actor, the request and its body with secrets redacted.
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param actor_id query string false "Filter by actor ID"
+// @Param resource query string false "Filter by resource (product, price, inventory, order or user)"
+// @Param target_id query string false "Filter by the ID of the changed record"
+// @Param method query string false "Filter by HTTP method (POST, PUT, PATCH or DELETE)"
+// @Param from_date query string false "Filter by start date (YYYY-MM-DD)"
+// @Param to_date query string false "Filter by end date (YYYY-MM-DD)"
+// @Success 200 {object} responses.AuditLogsResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/admin/audit-logs [get]
+// @Security ApiKeyAuth
+func (h *AuditHandler) GetAuditLogs(c *fiber.Ctx) error {
+	auditService := h.auditService.WithContext(c.UserContext())
+
+	// Parse pagination parameters
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(c.Query("page_size", "10"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10
+	}
+
+	filters := make(map[string]interface{})
+
+	if actorID := c.Query("actor_id"); actorID != "" {
+		id, err := uuid.Parse(actorID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+				Success: false,
+				Message: "Invalid actor ID format",
+				Error:   err.Error(),
+			})
+		}
+		filters["actor_id"] = id
+	}
+	if resource := c.Query("resource"); resource != "" {
+		filters["resource"] = strings.ToLower(resource)
+	}
+	if targetID := c.Query("target_id"); targetID != "" {
+		filters["target_id"] = targetID
+	}
+	if method := c.Query("method"); method != "" {
+		filters["method"] = strings.ToUpper(method)
+	}
+
+	// Apply the date range; the end date includes the whole day
+	if fromDate := c.Query("from_date"); fromDate != "" {
+		date, err := time.Parse("2006-01-02", fromDate)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+				Success: false,
+				Message: "Invalid from_date format, expected YYYY-MM-DD",
+				Error:   err.Error(),
+			})
+		}
+		filters["from_date"] = date
+	}
+	if toDate := c.Query("to_date"); toDate != "" {
+		date, err := time.Parse("2006-01-02", toDate)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+				Success: false,
+				Message: "Invalid to_date format, expected YYYY-MM-DD",
+				Error:   err.Error(),
+			})
+		}
+		filters["to_date"] = date.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	entries, total, err := auditService.GetAuditLogs(page, pageSize, filters)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to get audit logs",
+			Error:   err.Error(),
+		})
+	}
+
+	data := make([]responses.AuditLogResponse, len(entries))
+	for i, entry := range entries {
+		data[i] = responses.ConvertToAuditLogResponse(entry)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.AuditLogsResponse{
+		Success:    true,
+		Message:    "Audit logs retrieved successfully",
+		Data:       data,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int64(math.Ceil(float64(total) / float64(pageSize))),
+	})
+}
@@ -0,0 +1,121 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/api/handlers"
+	"github.com/ybds/internal/middleware"
+	"github.com/ybds/internal/models/account"
+	"github.com/ybds/internal/models/product"
+	"github.com/ybds/internal/services"
+	"github.com/ybds/internal/testutil"
+)
+
+// TestAuditLogProductUpdate tests that a product update is recorded in the audit log with its actor
+func TestAuditLogProductUpdate(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	p := &product.Product{Name: "Audit Shirt", SKU: "AUDIT-001", Category: "Shirts"}
+	assert.NoError(t, db.Create(p).Error)
+
+	actorID := uuid.New()
+	auth := func(c *fiber.Ctx) error {
+		c.Locals("userID", actorID)
+		c.Locals("roles", []string{"admin"})
+		return c.Next()
+	}
+
+	app := fiber.New()
+	api := app.Group("/api")
+	api.Use(auth, middleware.Audit(services.NewAuditService(db)))
+	handlers.NewProductHandler(db, nil, nil, product.PriceSelectionLatestStart).RegisterRoutes(api, auth)
+	handlers.NewAuditHandler(db).RegisterRoutes(api.Group("/admin"), auth)
+
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	assert.NoError(t, writer.WriteField("name", "Audited Shirt"))
+	assert.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPut, "/api/products/"+p.ID.String(), &form)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Reads are not recorded
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/products/"+p.ID.String(), nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var entries []account.AuditLog
+	assert.NoError(t, db.Find(&entries).Error)
+	if assert.Len(t, entries, 1) {
+		entry := entries[0]
+		if assert.NotNil(t, entry.ActorID) {
+			assert.Equal(t, actorID, *entry.ActorID)
+		}
+		assert.Equal(t, "admin", entry.ActorRoles)
+		assert.Equal(t, http.MethodPut, entry.Method)
+		assert.Equal(t, "product", entry.Resource)
+		assert.Equal(t, p.ID.String(), entry.TargetID)
+		assert.Equal(t, http.StatusOK, entry.StatusCode)
+		assert.JSONEq(t, `{"name":"Audited Shirt"}`, entry.Body)
+	}
+
+	// The entry is listed by the audit log endpoint
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/admin/audit-logs?resource=product&actor_id="+actorID.String(), nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.Equal(t, float64(1), response["total"])
+	if items, ok := response["data"].([]interface{}); assert.True(t, ok) && assert.Len(t, items, 1) {
+		item := items[0].(map[string]interface{})
+		assert.Equal(t, actorID.String(), item["actor_id"])
+		assert.Equal(t, "Audited Shirt", item["body"].(map[string]interface{})["name"])
+	}
+}
+
+// TestAuditLogListLongBody tests that entries whose body was truncated are listed
+func TestAuditLogListLongBody(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	auditService := services.NewAuditService(db)
+	long := `{"description":"` + strings.Repeat("Áo sơ mi ", 1000) + `"}`
+	assert.NoError(t, auditService.RecordRequest(services.AuditRequest{
+		Method:      http.MethodPut,
+		Path:        "/api/products/" + uuid.New().String(),
+		StatusCode:  http.StatusOK,
+		ContentType: "application/json",
+		Body:        []byte(long),
+	}))
+
+	auth := func(c *fiber.Ctx) error {
+		c.Locals("userID", uuid.New())
+		c.Locals("roles", []string{"admin"})
+		return c.Next()
+	}
+	app := fiber.New()
+	handlers.NewAuditHandler(db).RegisterRoutes(app.Group("/api/admin"), auth)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/admin/audit-logs", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	if items, ok := response["data"].([]interface{}); assert.True(t, ok) && assert.Len(t, items, 1) {
+		body := items[0].(map[string]interface{})["body"].(map[string]interface{})
+		assert.Equal(t, true, body["truncated"])
+	}
+}
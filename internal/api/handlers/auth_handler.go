@@ -15,9 +15,12 @@ type AuthHandler struct {
 }
 
 // NewAuthHandler creates a new instance of AuthHandler
-func NewAuthHandler(db *gorm.DB, jwtService *jwt.JWTService, userService *services.UserService) *AuthHandler {
+func NewAuthHandler(db *gorm.DB, jwtService *jwt.JWTService, userService *services.UserService, settings services.AuthSettings) *AuthHandler {
+	authService := services.NewAuthService(db, jwtService, userService)
+	authService.Settings = settings.WithDefaults()
+
 	return &AuthHandler{
-		authService: services.NewAuthService(db, jwtService, userService),
+		authService: authService,
 	}
 }
 
@@ -25,6 +28,10 @@ func NewAuthHandler(db *gorm.DB, jwtService *jwt.JWTService, userService *servic
 func (h *AuthHandler) RegisterRoutes(router fiber.Router) {
 	router.Post("/login", h.Login)
 	router.Post("/register", h.Register)
+	router.Post("/refresh", h.Refresh)
+	router.Post("/logout", h.Logout)
+	router.Post("/password-reset/request", h.RequestPasswordReset)
+	router.Post("/password-reset/confirm", h.ConfirmPasswordReset)
 }
 
 // Login godoc
@@ -85,9 +92,11 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 
 	// Return response
 	return c.Status(fiber.StatusOK).JSON(responses.LoginResponse{
-		Success: true,
-		Message: result.Message,
-		Token:   result.Token,
+		Success:          true,
+		Message:          result.Message,
+		Token:            result.Token,
+		RefreshToken:     result.RefreshToken,
+		RefreshExpiresAt: result.RefreshExpiresAt,
 		User: responses.UserResponse{
 			ID:       result.UserID,
 			Username: result.Username,
@@ -159,3 +168,181 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		Email:    result.Email,
 	})
 }
+
+// Refresh godoc
+// @Summary Refresh an access token
+// @Description Exchange a refresh token issued at login for a new access token. Expired and revoked refresh tokens are rejected.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param refreshRequest body requests.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} responses.RefreshTokenResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 401 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/auth/refresh [post]
+func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var req requests.RefreshTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+	}
+
+	result, err := h.authService.Refresh(req.RefreshToken)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to refresh token",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.RefreshTokenResponse{
+		Success:          true,
+		Message:          result.Message,
+		Token:            result.Token,
+		RefreshToken:     result.RefreshToken,
+		RefreshExpiresAt: result.RefreshExpiresAt,
+	})
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description Revoke a refresh token so it can no longer be exchanged for access tokens
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param logoutRequest body requests.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 401 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/auth/logout [post]
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	var req requests.RefreshTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := h.authService.Logout(req.RefreshToken); err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to logout",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.SuccessResponse{
+		Success: true,
+		Message: "Logged out successfully",
+	})
+}
+
+// RequestPasswordReset godoc
+// @Summary Request a password reset
+// @Description Email a single-use, time-limited password reset token to the user with the given email address. The response is the same whether or not the address is registered.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param passwordResetRequest body requests.PasswordResetRequest true "Email address"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/auth/password-reset/request [post]
+func (h *AuthHandler) RequestPasswordReset(c *fiber.Ctx) error {
+	var req requests.PasswordResetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := h.authService.RequestPasswordReset(req.Email); err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to request password reset",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.SuccessResponse{
+		Success: true,
+		Message: "If the email address is registered, a password reset code has been sent to it",
+	})
+}
+
+// ConfirmPasswordReset godoc
+// @Summary Reset a password
+// @Description Choose a new password with a password reset token. The token can only be used once; the new password must be at least 8 characters long and contain a letter and a digit. All refresh tokens of the user are revoked.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param passwordResetConfirmRequest body requests.PasswordResetConfirmRequest true "Reset token and new password"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/auth/password-reset/confirm [post]
+func (h *AuthHandler) ConfirmPasswordReset(c *fiber.Ctx) error {
+	var req requests.PasswordResetConfirmRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := h.authService.ResetPassword(req.Token, req.NewPassword); err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to reset password",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.SuccessResponse{
+		Success: true,
+		Message: "Password reset successfully",
+	})
+}
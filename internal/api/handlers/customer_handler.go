@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ybds/internal/api/responses"
+	"github.com/ybds/internal/models/order"
+	"github.com/ybds/internal/services"
+	"gorm.io/gorm"
+)
+
+// CustomerHandler handles HTTP requests related to customers
+type CustomerHandler struct {
+	customerService *services.CustomerService
+}
+
+// NewCustomerHandler creates a new instance of CustomerHandler
+func NewCustomerHandler(orderDB *gorm.DB) *CustomerHandler {
+	return &CustomerHandler{
+		customerService: services.NewCustomerService(orderDB),
+	}
+}
+
+// RegisterRoutes registers all routes related to customers
+func (h *CustomerHandler) RegisterRoutes(router fiber.Router, authMiddleware fiber.Handler) {
+	customers := router.Group("/customers")
+	customers.Use(authMiddleware)
+
+	customers.Get("/", h.GetCustomers)
+	customers.Get("/:id", h.GetCustomerByID)
+}
+
+// GetCustomers godoc
+// @Summary Get all customers
+// @Description Get a list of customer profiles, newest first, with pagination and search. Customers are created automatically from the phone number of their orders.
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param search query string false "Search by name, email or phone"
+// @Success 200 {object} responses.CustomersResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/customers [get]
+// @Security ApiKeyAuth
+func (h *CustomerHandler) GetCustomers(c *fiber.Ctx) error {
+	// Parse pagination parameters
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(c.Query("page_size", "10"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10
+	}
+
+	customers, total, err := h.customerService.GetCustomers(page, pageSize, c.Query("search"))
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to get customers",
+			Error:   err.Error(),
+		})
+	}
+
+	data := make([]responses.CustomerResponse, len(customers))
+	for i, customer := range customers {
+		data[i] = convertToCustomerResponse(customer)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.CustomersResponse{
+		Success:    true,
+		Message:    "Customers retrieved successfully",
+		Data:       data,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int64(math.Ceil(float64(total) / float64(pageSize))),
+	})
+}
+
+// GetCustomerByID godoc
+// @Summary Get a customer by ID
+// @Description Get a customer profile with the number of orders, the total spent on orders that were not canceled and the date of the last order. The orders themselves are listed by GET /api/customers/{id}/orders
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param id path string true "Customer ID"
+// @Success 200 {object} responses.CustomerDetailResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/customers/{id} [get]
+// @Security ApiKeyAuth
+func (h *CustomerHandler) GetCustomerByID(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid customer ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	profile, err := h.customerService.GetCustomerProfile(id)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to get customer",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.CustomerDetailResponse{
+		Success: true,
+		Message: "Customer retrieved successfully",
+		Data: responses.CustomerProfileResponse{
+			CustomerResponse: convertToCustomerResponse(profile.Customer),
+			OrderCount:       profile.OrderCount,
+			TotalSpent:       profile.TotalSpent,
+			LastOrderAt:      profile.LastOrderAt,
+		},
+	})
+}
+
+// convertToCustomerResponse converts an order.Customer to a CustomerResponse
+func convertToCustomerResponse(customer order.Customer) responses.CustomerResponse {
+	return responses.CustomerResponse{
+		ID:        customer.ID,
+		Phone:     customer.Phone,
+		Name:      customer.Name,
+		Email:     customer.Email,
+		CreatedAt: customer.CreatedAt,
+		UpdatedAt: customer.UpdatedAt,
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ybds/internal/services"
+	"github.com/ybds/pkg/upload"
+)
+
+// ErrorStatus maps an error returned by the service layer to an HTTP status code.
+// Unclassified errors map to 500 Internal Server Error.
+func ErrorStatus(err error) int {
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(err, &fiberErr):
+		return fiberErr.Code
+	case errors.Is(err, services.ErrNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrConflict):
+		return fiber.StatusConflict
+	case errors.Is(err, services.ErrValidation),
+		errors.Is(err, upload.ErrFileTooLarge),
+		errors.Is(err, upload.ErrFileTypeNotAllowed):
+		return fiber.StatusBadRequest
+	case errors.Is(err, services.ErrForbidden):
+		return fiber.StatusForbidden
+	case errors.Is(err, services.ErrUnauthorized):
+		return fiber.StatusUnauthorized
+	case errors.Is(err, context.DeadlineExceeded):
+		return fiber.StatusGatewayTimeout
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
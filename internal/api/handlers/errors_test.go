@@ -0,0 +1,41 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/api/handlers"
+	"github.com/ybds/internal/services"
+	"github.com/ybds/pkg/upload"
+)
+
+// TestErrorStatus tests the mapping of service errors to HTTP status codes
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"NotFound", fmt.Errorf("shipment %w", services.ErrNotFound), http.StatusNotFound},
+		{"Conflict", fmt.Errorf("%w: shipment already exists", services.ErrConflict), http.StatusConflict},
+		{"Validation", fmt.Errorf("%w: invalid status", services.ErrValidation), http.StatusBadRequest},
+		{"FileTooLarge", fmt.Errorf("%w: photo.jpg exceeds the limit of 10 MB", upload.ErrFileTooLarge), http.StatusBadRequest},
+		{"FileTypeNotAllowed", fmt.Errorf("%w: photo.jpg is application/pdf", upload.ErrFileTypeNotAllowed), http.StatusBadRequest},
+		{"Forbidden", fmt.Errorf("%w: discount requires admin approval", services.ErrForbidden), http.StatusForbidden},
+		{"Unauthorized", fmt.Errorf("%w: refresh token has expired", services.ErrUnauthorized), http.StatusUnauthorized},
+		{"DeadlineExceeded", fmt.Errorf("failed to get order: %w", context.DeadlineExceeded), http.StatusGatewayTimeout},
+		{"FiberError", fiber.NewError(fiber.StatusUnauthorized, "unauthorized"), http.StatusUnauthorized},
+		{"Unclassified", errors.New("connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, handlers.ErrorStatus(tt.err))
+		})
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/ybds/internal/api/requests"
 	"github.com/ybds/internal/api/responses"
+	"github.com/ybds/internal/middleware"
+	"github.com/ybds/internal/models/account"
 	"github.com/ybds/internal/models/notification"
 	"github.com/ybds/internal/services"
 	"github.com/ybds/pkg/websocket"
@@ -33,9 +35,12 @@ func (h *NotificationHandler) RegisterRoutes(router fiber.Router, authMiddleware
 	notifications.Use(authMiddleware)
 
 	notifications.Get("/", h.GetNotifications)
+	notifications.Get("/all", h.GetAllNotifications)
 	notifications.Get("/unread", h.GetUnreadNotifications)
+	notifications.Get("/summary", h.GetNotificationSummary)
 	notifications.Put("/:id/read", h.MarkAsRead)
 	notifications.Put("/read-all", h.MarkAllAsRead)
+	notifications.Put("/read-by-event", h.MarkAsReadByEvent)
 }
 
 // GetNotifications godoc
@@ -181,6 +186,56 @@ func (h *NotificationHandler) GetNotifications(c *fiber.Ctx) error {
 	})
 }
 
+// GetNotificationSummary godoc
+// @Summary Get the unread notification summary for the current user
+// @Description Count the unread notifications of the current user grouped by the resource they are about (order, product or other) and their event, e.g. created or low_stock
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Success 200 {object} responses.NotificationSummaryResponse
+// @Failure 401 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/admin/notifications/summary [get]
+// @Security ApiKeyAuth
+func (h *NotificationHandler) GetNotificationSummary(c *fiber.Ctx) error {
+	// Get user ID from context (set by auth middleware)
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Unauthorized",
+			Error:   "Invalid user ID",
+		})
+	}
+
+	summary, err := h.notificationService.GetUnreadSummary(userID, notification.RecipientUser)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to get notification summary",
+			Error:   err.Error(),
+		})
+	}
+
+	events := make([]responses.NotificationEventCountResponse, len(summary.Events))
+	for i, count := range summary.Events {
+		events[i] = responses.NotificationEventCountResponse{
+			Resource: count.Resource,
+			Event:    count.Event,
+			Count:    count.Count,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.NotificationSummaryResponse{
+		Success: true,
+		Message: "Notification summary retrieved successfully",
+		Data: responses.NotificationSummaryData{
+			TotalUnread: summary.TotalUnread,
+			Events:      events,
+		},
+	})
+}
+
 // GetUnreadNotifications godoc
 // @Summary Get unread notifications for the current user
 // @Description Get a list of unread notifications for the current user
@@ -398,3 +453,176 @@ func (h *NotificationHandler) MarkAllAsRead(c *fiber.Ctx) error {
 		Message: "All notifications marked as read successfully",
 	})
 }
+
+// MarkAsReadByEvent godoc
+// @Summary Mark notifications of an event as read
+// @Description Mark the current user's unread notifications with the given event as read, e.g. all created notifications. An optional resource (order or product) limits the update to notifications of that resource.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param request body requests.MarkReadByEventRequest true "Event and optional resource"
+// @Success 200 {object} responses.NotificationsMarkedReadResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 401 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/admin/notifications/read-by-event [put]
+// @Security ApiKeyAuth
+func (h *NotificationHandler) MarkAsReadByEvent(c *fiber.Ctx) error {
+	// Get user ID from context (set by auth middleware)
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Unauthorized",
+			Error:   "Invalid user ID",
+		})
+	}
+
+	var req requests.MarkReadByEventRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+	}
+
+	updated, err := h.notificationService.MarkNotificationsAsReadByEvent(userID, notification.RecipientUser, req.Event, req.Resource)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to mark notifications as read",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.NotificationsMarkedReadResponse{
+		Success: true,
+		Message: "Notifications marked as read successfully",
+		Updated: updated,
+	})
+}
+
+// GetAllNotifications godoc
+// @Summary Get notifications of all recipients
+// @Description Get a paginated list of notifications of all recipients, newest first, optionally filtered by recipient type and recipient ID. Admin only.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param recipient_type query string false "Recipient type (user, guest, potential_customer, partner)"
+// @Param recipient_id query string false "Recipient ID"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size (max 100)"
+// @Success 200 {object} responses.NotificationsResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 401 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/admin/notifications/all [get]
+// @Security ApiKeyAuth
+func (h *NotificationHandler) GetAllNotifications(c *fiber.Ctx) error {
+	// Get user roles from context (set by auth middleware)
+	if _, ok := c.Locals("roles").([]string); !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Unauthorized",
+			Error:   "Invalid user roles",
+		})
+	}
+
+	if !middleware.HasPermission(c, account.PermissionNotificationViewAll) {
+		return c.Status(fiber.StatusForbidden).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Forbidden",
+			Error:   "Only admins can view notifications of all recipients",
+		})
+	}
+
+	// Parse request parameters
+	req := requests.ListNotificationsRequest{
+		Page:          c.QueryInt("page", 1),
+		PageSize:      c.QueryInt("page_size", 10),
+		RecipientType: c.Query("recipient_type"),
+	}
+
+	if recipientIDStr := c.Query("recipient_id"); recipientIDStr != "" {
+		recipientID, err := uuid.Parse(recipientIDStr)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+				Success: false,
+				Message: "Invalid recipient ID format",
+				Error:   err.Error(),
+			})
+		}
+		req.RecipientID = &recipientID
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request parameters",
+			Error:   err.Error(),
+		})
+	}
+
+	// Build filters
+	filters := make(map[string]interface{})
+	if req.RecipientType != "" {
+		filters["recipient_type"] = req.RecipientType
+	}
+	if req.RecipientID != nil {
+		filters["recipient_id"] = *req.RecipientID
+	}
+
+	notifications, total, err := h.notificationService.GetAllNotifications(req.Page, req.PageSize, filters)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve notifications",
+			Error:   err.Error(),
+		})
+	}
+
+	// Convert to response format
+	notificationResponses := make([]responses.NotificationResponse, len(notifications))
+	for i, n := range notifications {
+		var recipientID uuid.UUID
+		if n.RecipientID != nil {
+			recipientID = *n.RecipientID
+		}
+
+		notificationResponses[i] = responses.NotificationResponse{
+			ID:        n.ID,
+			UserID:    recipientID,
+			Type:      string(n.RecipientType),
+			Title:     n.Title,
+			Message:   n.Message,
+			IsRead:    n.IsRead,
+			CreatedAt: n.CreatedAt,
+			UpdatedAt: n.UpdatedAt,
+		}
+	}
+
+	// Calculate total pages
+	totalPages := (total + int64(req.PageSize) - 1) / int64(req.PageSize)
+
+	// Return response
+	return c.Status(fiber.StatusOK).JSON(responses.NotificationsResponse{
+		Success:    true,
+		Message:    "Notifications retrieved successfully",
+		Data:       notificationResponses,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: int(totalPages),
+	})
+}
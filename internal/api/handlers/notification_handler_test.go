@@ -11,7 +11,10 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/api/handlers"
+	"github.com/ybds/internal/api/responses"
 	"github.com/ybds/internal/models/notification"
+	"github.com/ybds/internal/services"
 	"github.com/ybds/internal/testutil"
 )
 
@@ -406,3 +409,73 @@ func TestNotificationHandler(t *testing.T) {
 		mockNotificationService.AssertExpectations(t)
 	})
 }
+
+// TestGetAllNotifications tests the admin-only listing of notifications of all recipients
+func TestGetAllNotifications(t *testing.T) {
+	newApp := func(handler *handlers.NotificationHandler, roles []string) *fiber.App {
+		app := fiber.New()
+		handler.RegisterRoutes(app.Group("/api/admin"), func(c *fiber.Ctx) error {
+			c.Locals("userID", uuid.New())
+			c.Locals("roles", roles)
+			return c.Next()
+		})
+		return app
+	}
+
+	t.Run("NonAdminForbidden", func(t *testing.T) {
+		app := newApp(handlers.NewNotificationHandler(nil, nil, nil), []string{"agent"})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/notifications/all", nil)
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("InvalidRecipientType", func(t *testing.T) {
+		app := newApp(handlers.NewNotificationHandler(nil, nil, nil), []string{"admin"})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/notifications/all?recipient_type=robot", nil)
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("AdminFiltersByRecipientType", func(t *testing.T) {
+		db := testutil.SetupTestDB(t)
+		notificationService := services.NewNotificationService(db, db, nil, nil)
+		app := newApp(handlers.NewNotificationHandler(db, notificationService, nil), []string{"admin"})
+
+		userID := uuid.New()
+		for _, n := range []notification.Notification{
+			{RecipientID: &userID, RecipientType: notification.RecipientUser, Title: "User 1", Message: "User 1"},
+			{RecipientID: &userID, RecipientType: notification.RecipientUser, Title: "User 2", Message: "User 2"},
+			{RecipientType: notification.RecipientPartner, Title: "Partner", Message: "Partner"},
+		} {
+			n := n
+			assert.NoError(t, db.Create(&n).Error)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/notifications/all?recipient_type=partner", nil)
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var body responses.NotificationsResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, int64(1), body.Total)
+		if assert.Len(t, body.Data, 1) {
+			assert.Equal(t, "Partner", body.Data[0].Title)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/api/admin/notifications/all?recipient_type=user&recipient_id="+userID.String()+"&page_size=1", nil)
+		resp, err = app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		body = responses.NotificationsResponse{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, int64(2), body.Total)
+		assert.Equal(t, 2, body.TotalPages)
+		assert.Len(t, body.Data, 1)
+	})
+}
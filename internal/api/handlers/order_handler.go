@@ -1,19 +1,23 @@
 package handlers
 
 import (
-	"fmt"
+	"crypto/subtle"
 	"log"
 	"math"
-	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/google/uuid"
 	"github.com/ybds/internal/api/requests"
 	"github.com/ybds/internal/api/responses"
+	"github.com/ybds/internal/middleware"
+	"github.com/ybds/internal/models/account"
 	"github.com/ybds/internal/models/order"
 	"github.com/ybds/internal/services"
+	"github.com/ybds/pkg/upload"
 	"gorm.io/gorm"
 )
 
@@ -23,12 +27,37 @@ type OrderHandler struct {
 }
 
 // NewOrderHandler creates a new instance of OrderHandler
-func NewOrderHandler(db *gorm.DB, productService *services.ProductService, userService *services.UserService, notificationService *services.NotificationService) *OrderHandler {
+func NewOrderHandler(db *gorm.DB, productService *services.ProductService, userService *services.UserService, notificationService *services.NotificationService, webhookService *services.WebhookService, uploadService *upload.Service, currencyService *services.CurrencyService, settings services.OrderSettings) *OrderHandler {
+	orderService := services.NewOrderService(db, productService, userService, notificationService)
+	orderService.WebhookService = webhookService
+	orderService.UploadService = uploadService
+	orderService.CurrencyService = currencyService
+	orderService.Settings = settings.WithDefaults()
+
 	return &OrderHandler{
-		orderService: services.NewOrderService(db, productService, userService, notificationService),
+		orderService: orderService,
+	}
+}
+
+// discountReasonRules returns the configured rules for discount reasons
+func (h *OrderHandler) discountReasonRules() requests.DiscountReasonRules {
+	return requests.DiscountReasonRules{
+		Required:  h.orderService.Settings.DiscountReasonRequired,
+		MaxLength: h.orderService.Settings.DiscountReasonMaxLength,
 	}
 }
 
+// formatAmounts fills the currency and the formatted amounts of an order response using the
+// configured currency and locale
+func (h *OrderHandler) formatAmounts(detail *responses.OrderDetail) {
+	detail.FormatAmounts(h.orderService.Settings.Currency, h.orderService.Settings.CurrencyLocale)
+}
+
+// hasPermission reports whether the authenticated user or API key has the permission
+func hasPermission(c *fiber.Ctx, permission account.Permission) bool {
+	return middleware.HasPermission(c, permission)
+}
+
 // RegisterRoutes registers all routes related to orders
 func (h *OrderHandler) RegisterRoutes(router fiber.Router, authMiddleware fiber.Handler) {
 	orders := router.Group("/orders")
@@ -37,13 +66,31 @@ func (h *OrderHandler) RegisterRoutes(router fiber.Router, authMiddleware fiber.
 	// Order routes - accessible by admin or agent
 	orders.Post("/", h.CreateOrder)
 	orders.Get("/", h.GetOrders)
+	orders.Post("/bulk-assign", h.BulkAssignOrders)
+	orders.Post("/bulk-status", h.BulkUpdateOrderStatus)
+	orders.Post("/bulk-status-by-filter", h.BulkUpdateOrderStatusByFilter)
+	orders.Post("/preview-discount", h.PreviewDiscount)
+	orders.Post("/ghn-webhook/test", h.TestGHNWebhook) // Admin only
+	orders.Get("/queue", h.GetOrderQueue)
+	orders.Get("/availability/:inventory_id", h.GetInventoryAvailability)
 	orders.Get("/:id", h.GetOrderByID)
+	orders.Get("/:id/trail", h.GetOrderTrail)
+	orders.Get("/:id/history", h.GetOrderStatusHistory)
+	orders.Get("/:id/label", h.GetShippingLabel)
 	orders.Get("/tracking/:number", h.GetOrderByTrackingNumber)
+	orders.Get("/number/:number", h.GetOrderByOrderNumber)
 	orders.Get("/phone/:phone", h.GetOrdersByPhoneNumber)
 	orders.Put("/:id/details", h.UpdateOrderDetails)
+	orders.Post("/:id/reprice", h.RepriceOrder)
+	orders.Post("/:id/promo", h.ApplyPromoCode)
 	orders.Put("/:id/shipment", h.UpdateShipment)
+	orders.Post("/:id/shipments", h.CreateShipment)
+	orders.Put("/:id/shipments/:shipment_id", h.UpdateShipment)
 	orders.Put("/:id/status", h.UpdateOrderStatus)
-	orders.Delete("/:id", h.DeleteOrder)
+	orders.Post("/:id/returns", h.ReturnOrderItems)
+	orders.Post("/:id/delivery-proof", h.RecordDeliveryProof)
+	orders.Get("/:id/delivery-proof", h.GetDeliveryProof)
+	orders.Delete("/:id", middleware.RequirePermission(account.PermissionOrderDelete), h.DeleteOrder)
 	orders.Get("/:id/debug", h.DebugOrder) // Debug endpoint
 
 	// Order item routes - accessible by admin or agent
@@ -51,19 +98,52 @@ func (h *OrderHandler) RegisterRoutes(router fiber.Router, authMiddleware fiber.
 	orders.Put("/items/:id", h.UpdateOrderItem)
 	orders.Delete("/items/:id", h.DeleteOrderItem)
 
+	// Shipment routes - accessible by admin or agent
+	shipments := router.Group("/shipments")
+	shipments.Use(authMiddleware)
+	shipments.Get("/", h.GetShipments)
+
+	// Inventory variant routes - accessible by admin or agent
+	inventories := router.Group("/inventories")
+	inventories.Use(authMiddleware)
+	inventories.Get("/:id/orders", h.GetOrdersByInventory)
+	inventories.Post("/:id/hold", h.HoldInventory)
+	inventories.Post("/holds/:id/release", h.ReleaseInventoryHold)
+
+	// Customer order routes - accessible by admin or agent; the other customer routes are
+	// registered by CustomerHandler
+	router.Get("/customers/:id/orders", authMiddleware, h.GetCustomerOrders)
+
 	// Admin-only routes can be added here if needed
 	// If we need to separate admin-only routes, we can modify this method to accept an adminRouter parameter
 }
 
+// RegisterPublicRoutes registers the unauthenticated, rate-limited order routes for customers
+func (h *OrderHandler) RegisterPublicRoutes(router fiber.Router) {
+	router.Get("/track", limiter.New(limiter.Config{
+		Max:        10,
+		Expiration: time.Minute,
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(responses.ErrorResponse{
+				Success: false,
+				Message: "Too many requests",
+				Error:   "Rate limit exceeded, please try again later",
+			})
+		},
+	}), h.TrackOrder)
+}
+
 // CreateOrder godoc
 // @Summary Create a new order
-// @Description Create a new order with items and optional shipment information. Only customer_name and items are required, all other fields are optional. Customer phone number must be a valid Vietnamese number.
+// @Description Create a new order with items and optional shipment information. Only customer_name and items are required, all other fields are optional. Customer phone number must be a valid Vietnamese number and is stored in E.164 format (e.g. +84912345678). A discount may not exceed the order total; a discount_percent is converted to an amount of the current total. A discount_reason is required with a discount when configured and may not exceed the configured length. Discounts above the configured approval threshold can only be given by admins.
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param order body requests.CreateOrderRequest true "Order details"
 // @Success 201 {object} responses.OrderResponse
 // @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
 // @Failure 500 {object} responses.ErrorResponse
 // @Router /api/orders [post]
 // @Security ApiKeyAuth
@@ -96,6 +176,9 @@ func (h *OrderHandler) CreateOrder(c *fiber.Ctx) error {
 		if errorMessage == "invalid Vietnamese phone number format" {
 			errorMessage = "Phone number must be a valid Vietnamese mobile or landline number (e.g., 0912345678, 0281234567)"
 		}
+		if errorMessage == "invalid email format" {
+			errorMessage = "Customer email must be a valid email address (e.g., john@example.com)"
+		}
 
 		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
 			Success: false,
@@ -104,6 +187,15 @@ func (h *OrderHandler) CreateOrder(c *fiber.Ctx) error {
 		})
 	}
 
+	// Validate discount reason
+	if err := req.ValidateDiscountReason(h.discountReasonRules()); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+	}
+
 	// Set default values for optional fields
 	paymentMethod := order.PaymentMethod("cash")
 	if req.PaymentMethod != "" {
@@ -124,6 +216,7 @@ func (h *OrderHandler) CreateOrder(c *fiber.Ctx) error {
 		paymentMethod,
 		items,
 		req.DiscountAmount,
+		req.DiscountPercent,
 		req.DiscountReason,
 		&userID, // CreatedBy (staff member)
 		req.ShippingAddress,
@@ -135,10 +228,11 @@ func (h *OrderHandler) CreateOrder(c *fiber.Ctx) error {
 		req.CustomerEmail,
 		req.CustomerPhone,
 		req.Notes,
+		hasPermission(c, account.PermissionOrderUpdate),
 	)
 
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
 			Success: false,
 			Message: "Failed to create order",
 			Error:   err.Error(),
@@ -147,7 +241,10 @@ func (h *OrderHandler) CreateOrder(c *fiber.Ctx) error {
 
 	// Create shipment if tracking number or carrier is provided
 	if req.ShipmentTrackingNumber != "" || req.ShipmentCarrier != "" {
-		err = h.orderService.UpdateShipment(result.OrderID, req.ShipmentTrackingNumber, req.ShipmentCarrier)
+		err = h.orderService.UpdateShipment(result.OrderID, services.ShipmentDetails{
+			TrackingNumber: req.ShipmentTrackingNumber,
+			Carrier:        req.ShipmentCarrier,
+		})
 		if err != nil {
 			// Log error but continue, as the order was created successfully
 			log.Printf("Failed to create shipment for order %s: %v", result.OrderID, err)
@@ -178,25 +275,29 @@ func (h *OrderHandler) CreateOrder(c *fiber.Ctx) error {
 	responseItems := make([]responses.OrderItemResponse, len(createdOrder.Items))
 	for i, item := range createdOrder.Items {
 		responseItems[i] = responses.OrderItemResponse{
-			ID:          item.ID,
-			OrderID:     item.OrderID,
-			InventoryID: item.InventoryID,
-			Quantity:    item.Quantity,
-			Price:       item.PriceAtOrder,
-			Subtotal:    item.PriceAtOrder * float64(item.Quantity),
-			CreatedAt:   item.CreatedAt,
-			UpdatedAt:   item.UpdatedAt,
+			ID:               item.ID,
+			OrderID:          item.OrderID,
+			InventoryID:      item.InventoryID,
+			Quantity:         item.Quantity,
+			ReturnedQuantity: item.ReturnedQuantity,
+			Price:            item.PriceAtOrder,
+			Currency:         item.Currency,
+			OriginalPrice:    item.OriginalPrice,
+			OriginalCurrency: item.OriginalCurrency,
+			Subtotal:         item.PriceAtOrder * float64(item.Quantity),
+			CreatedAt:        item.CreatedAt,
+			UpdatedAt:        item.UpdatedAt,
 		}
 
 		// Get inventory details if available
-		inventory, err := h.orderService.ProductService.GetInventoryByID(item.InventoryID)
+		inventory, err := h.orderService.ProductService.GetInventoryIncludingDeleted(item.InventoryID)
 		if err == nil && inventory != nil {
 			// Add inventory details
 			responseItems[i].Size = inventory.Size
 			responseItems[i].Color = inventory.Color
 
 			// Get product details if available
-			product, err := h.orderService.ProductService.GetProductByID(inventory.ProductID)
+			product, err := h.orderService.ProductService.GetProductIncludingDeleted(inventory.ProductID)
 			if err == nil && product != nil {
 				responseItems[i].ProductID = product.ID
 				responseItems[i].ProductName = product.Name
@@ -205,53 +306,51 @@ func (h *OrderHandler) CreateOrder(c *fiber.Ctx) error {
 				price, err := h.orderService.ProductService.GetCurrentPrice(product.ID)
 				if err == nil && price != nil {
 					responseItems[i].PriceID = price.ID
-					responseItems[i].Currency = price.Currency
+					if responseItems[i].Currency == "" {
+						responseItems[i].Currency = price.Currency
+					}
 				}
 			}
 		}
 	}
 
-	// Create shipment response if available
-	var shipmentResponse *responses.ShipmentResponse
-	if createdOrder.Shipment != nil {
-		shipmentResponse = &responses.ShipmentResponse{
-			ID:             createdOrder.Shipment.ID,
-			OrderID:        createdOrder.Shipment.OrderID,
-			TrackingNumber: createdOrder.Shipment.TrackingNumber,
-			Carrier:        createdOrder.Shipment.Carrier,
-			CreatedAt:      createdOrder.Shipment.CreatedAt,
-			UpdatedAt:      createdOrder.Shipment.UpdatedAt,
-		}
-	}
+	detail := responses.OrderDetail{
+		ID:               createdOrder.ID,
+		OrderNumber:      createdOrder.OrderNumber,
+		CustomerName:     createdOrder.CustomerName,
+		CustomerEmail:    createdOrder.CustomerEmail,
+		CustomerPhone:    createdOrder.CustomerPhone,
+		ShippingAddress:  createdOrder.ShippingAddress,
+		ShippingWard:     createdOrder.ShippingWard,
+		ShippingDistrict: createdOrder.ShippingDistrict,
+		ShippingCity:     createdOrder.ShippingCity,
+		ShippingCountry:  createdOrder.ShippingCountry,
+		PaymentMethod:    string(createdOrder.PaymentMethod),
+		Currency:         createdOrder.Currency,
+		Status:           string(createdOrder.OrderStatus),
+		Notes:            createdOrder.Notes,
+		Total:            createdOrder.TotalAmount,
+		DiscountAmount:   createdOrder.DiscountAmount,
+		DiscountReason:   createdOrder.DiscountReason,
+		DiscountPercent:  createdOrder.DiscountPercent,
+		PromoCode:        createdOrder.PromoCode,
+		FinalTotal:       createdOrder.FinalTotalAmount,
+		CreatedBy:        *createdOrder.CreatedBy,
+		CreatedByName:    creatorName,
+		Items:            responseItems,
+		CustomerID:       createdOrder.CustomerID,
+		DeliveryProof:    responses.ConvertToDeliveryProofResponse(*createdOrder),
+		CreatedAt:        createdOrder.CreatedAt,
+		UpdatedAt:        createdOrder.UpdatedAt,
+	}
+	detail.SetShipments(createdOrder.Shipments)
+	h.formatAmounts(&detail)
 
 	// Return response with complete order information
 	return c.Status(fiber.StatusCreated).JSON(responses.OrderResponse{
 		Success: true,
 		Message: "Order created successfully",
-		Data: responses.OrderDetail{
-			ID:               createdOrder.ID,
-			CustomerName:     createdOrder.CustomerName,
-			CustomerEmail:    createdOrder.CustomerEmail,
-			CustomerPhone:    createdOrder.CustomerPhone,
-			ShippingAddress:  createdOrder.ShippingAddress,
-			ShippingWard:     createdOrder.ShippingWard,
-			ShippingDistrict: createdOrder.ShippingDistrict,
-			ShippingCity:     createdOrder.ShippingCity,
-			ShippingCountry:  createdOrder.ShippingCountry,
-			PaymentMethod:    string(createdOrder.PaymentMethod),
-			Status:           string(createdOrder.OrderStatus),
-			Notes:            createdOrder.Notes,
-			Total:            createdOrder.TotalAmount,
-			DiscountAmount:   createdOrder.DiscountAmount,
-			DiscountReason:   createdOrder.DiscountReason,
-			FinalTotal:       createdOrder.FinalTotalAmount,
-			CreatedBy:        *createdOrder.CreatedBy,
-			CreatedByName:    creatorName,
-			Items:            responseItems,
-			Shipment:         shipmentResponse,
-			CreatedAt:        createdOrder.CreatedAt,
-			UpdatedAt:        createdOrder.UpdatedAt,
-		},
+		Data:    detail,
 	})
 }
 
@@ -263,17 +362,22 @@ func (h *OrderHandler) CreateOrder(c *fiber.Ctx) error {
 // @Produce json
 // @Param page query int false "Page number"
 // @Param page_size query int false "Page size"
-// @Param status query string false "Filter by status"
+// @Param status query string false "Filter by status; a comma-separated list matches any of the statuses"
 // @Param created_by query string false "Filter by creator ID"
+// @Param customer_id query string false "Filter by customer ID"
 // @Param from_date query string false "Filter by start date (YYYY-MM-DD)"
 // @Param to_date query string false "Filter by end date (YYYY-MM-DD)"
 // @Param phone_number query string false "Filter by customer phone number"
-// @Param search query string false "Search term"
+// @Param carrier query string false "Filter by shipment carrier (e.g. GHN)"
+// @Param order_number query string false "Filter by order number (case-insensitive)"
+// @Param search query string false "Search by order number, customer name or phone number"
 // @Success 200 {object} responses.OrdersResponse
 // @Failure 500 {object} responses.ErrorResponse
 // @Router /api/orders [get]
 // @Security ApiKeyAuth
 func (h *OrderHandler) GetOrders(c *fiber.Ctx) error {
+	orderService := h.orderService.WithContext(c.UserContext())
+
 	// Parse pagination parameters
 	page, err := strconv.Atoi(c.Query("page", "1"))
 	if err != nil || page < 1 {
@@ -288,9 +392,19 @@ func (h *OrderHandler) GetOrders(c *fiber.Ctx) error {
 	// Initialize filters
 	filters := make(map[string]interface{})
 
-	// Apply status filter if provided
+	// Apply status filter if provided; a comma-separated list matches any of the statuses
 	if status := c.Query("status"); status != "" {
-		filters["order_status"] = status
+		var statuses []string
+		for _, value := range strings.Split(status, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				statuses = append(statuses, value)
+			}
+		}
+		if len(statuses) == 1 {
+			filters["order_status"] = statuses[0]
+		} else if len(statuses) > 1 {
+			filters["order_status"] = statuses
+		}
 	}
 
 	// Apply creator ID filter if provided
@@ -301,6 +415,13 @@ func (h *OrderHandler) GetOrders(c *fiber.Ctx) error {
 		}
 	}
 
+	// Apply customer ID filter if provided
+	if customerID := c.Query("customer_id"); customerID != "" {
+		if id, err := uuid.Parse(customerID); err == nil {
+			filters["customer_id"] = id
+		}
+	}
+
 	// Apply from_date filter if provided
 	if fromDate := c.Query("from_date"); fromDate != "" {
 		// Parse date in format YYYY-MM-DD
@@ -328,289 +449,221 @@ func (h *OrderHandler) GetOrders(c *fiber.Ctx) error {
 		filters["phone_number"] = phoneNumber
 	}
 
-	// Get orders with filters
-	orders, total, err := h.orderService.GetAllOrders(page, pageSize, filters)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+	// Apply shipment carrier filter if provided
+	if carrier := c.Query("carrier"); carrier != "" {
+		filters["carrier"] = carrier
+	}
+
+	// Apply order number filter if provided
+	if orderNumber := strings.TrimSpace(c.Query("order_number")); orderNumber != "" {
+		filters["order_number"] = strings.ToUpper(orderNumber)
+	}
+
+	// Apply free-text search if provided
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		filters["search"] = search
+	}
+
+	return h.respondWithOrders(c, orderService, page, pageSize, filters)
+}
+
+// GetOrderQueue godoc
+// @Summary Get the order queue snapshot
+// @Description Get the orders to work on at shift start in one call, grouped into buckets with their count and the oldest orders of each: new (pending orders created in the last 24 hours), awaiting_action (older pending orders and packed orders waiting for the carrier) and flagged (orders without a tracking number past the shipment SLA, or being returned). Agents only see the orders created by or assigned to them; admins see all orders.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param limit query int false "Number of orders listed per bucket (default 5, max 50)"
+// @Success 200 {object} responses.OrderQueueResponse
+// @Failure 401 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/orders/queue [get]
+// @Security ApiKeyAuth
+func (h *OrderHandler) GetOrderQueue(c *fiber.Ctx) error {
+	orderService := h.orderService.WithContext(c.UserContext())
+
+	// Get user ID from context (set by auth middleware)
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Failed to get orders",
-			Error:   err.Error(),
+			Message: "Unauthorized",
+			Error:   "Invalid user ID",
 		})
 	}
 
-	// Format response data
-	var orderList []responses.OrderDetail
+	if _, ok := c.Locals("roles").([]string); !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Unauthorized",
+			Error:   "Invalid user roles",
+		})
+	}
 
-	// Create user info cache to avoid repeated database queries
-	userCache := make(map[uuid.UUID]string)
+	// Admins see the whole queue, agents only their own orders
+	canViewAll := hasPermission(c, account.PermissionOrderViewAll)
 
-	for _, o := range orders {
-		// Get creator name if available
-		var creatorName string
-		if o.CreatedBy != nil {
-			// Check if we already have this user in cache
-			if name, found := userCache[*o.CreatedBy]; found {
-				creatorName = name
-			} else {
-				// If not in cache, fetch from database
-				user, err := h.orderService.UserService.GetUserByID(*o.CreatedBy)
-				if err == nil {
-					creatorName = user.Username
-					// Add to cache
-					userCache[*o.CreatedBy] = creatorName
-				}
-			}
-		}
+	var agentID *uuid.UUID
+	if !canViewAll {
+		agentID = &userID
+	}
 
-		// Create basic order detail
-		orderDetail := responses.OrderDetail{
-			ID:               o.ID,
-			CustomerName:     o.CustomerName,
-			CustomerEmail:    o.CustomerEmail,
-			CustomerPhone:    o.CustomerPhone,
-			ShippingAddress:  o.ShippingAddress,
-			ShippingWard:     o.ShippingWard,
-			ShippingDistrict: o.ShippingDistrict,
-			ShippingCity:     o.ShippingCity,
-			ShippingCountry:  o.ShippingCountry,
-			PaymentMethod:    string(o.PaymentMethod),
-			Status:           string(o.OrderStatus),
-			Notes:            o.Notes,
-			Total:            o.TotalAmount,
-			DiscountAmount:   o.DiscountAmount,
-			DiscountReason:   o.DiscountReason,
-			FinalTotal:       o.FinalTotalAmount,
-			CreatedAt:        o.CreatedAt,
-			UpdatedAt:        o.UpdatedAt,
-		}
-
-		// Set created by if available
-		if o.CreatedBy != nil {
-			orderDetail.CreatedBy = *o.CreatedBy
-			orderDetail.CreatedByName = creatorName
-		}
-
-		// Add shipment info if available
-		if o.Shipment != nil {
-			orderDetail.Shipment = &responses.ShipmentResponse{
-				ID:             o.Shipment.ID,
-				OrderID:        o.Shipment.OrderID,
-				TrackingNumber: o.Shipment.TrackingNumber,
-				Carrier:        o.Shipment.Carrier,
-				CreatedAt:      o.Shipment.CreatedAt,
-				UpdatedAt:      o.Shipment.UpdatedAt,
-			}
-		}
+	limit, err := strconv.Atoi(c.Query("limit", "0"))
+	if err != nil {
+		limit = 0
+	}
 
-		// Add items if available
-		items := make([]responses.OrderItemResponse, len(o.Items))
-		for i, item := range o.Items {
-			// Create basic item
-			items[i] = responses.OrderItemResponse{
-				ID:          item.ID,
-				OrderID:     item.OrderID,
-				InventoryID: item.InventoryID,
-				Quantity:    item.Quantity,
-				Price:       item.PriceAtOrder,
-				Subtotal:    item.PriceAtOrder * float64(item.Quantity),
-				CreatedAt:   item.CreatedAt,
-				UpdatedAt:   item.UpdatedAt,
-			}
+	queue, err := orderService.GetOrderQueue(agentID, limit)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to get order queue",
+			Error:   err.Error(),
+		})
+	}
 
-			// Get inventory details if needed
-			inventory, err := h.orderService.ProductService.GetInventoryByID(item.InventoryID)
-			if err == nil && inventory != nil {
-				// Add inventory details
-				items[i].Size = inventory.Size
-				items[i].Color = inventory.Color
-
-				// Get product details if available
-				product, err := h.orderService.ProductService.GetProductByID(inventory.ProductID)
-				if err == nil && product != nil {
-					items[i].ProductID = product.ID
-					items[i].ProductName = product.Name
-
-					// Get price details if available
-					price, err := h.orderService.ProductService.GetCurrentPrice(product.ID)
-					if err == nil && price != nil {
-						items[i].PriceID = price.ID
-						items[i].Currency = price.Currency
-					}
-				}
-			}
+	bucket := func(b services.OrderQueueBucket) responses.OrderQueueBucketResponse {
+		orders := make([]responses.OrderQueueItemResponse, len(b.Orders))
+		for i, o := range b.Orders {
+			orders[i] = responses.ConvertToOrderQueueItemResponse(o, queue.GeneratedAt)
 		}
-
-		orderDetail.Items = items
-		orderList = append(orderList, orderDetail)
+		return responses.OrderQueueBucketResponse{Count: b.Count, Orders: orders}
 	}
 
-	// Return response
-	return c.Status(fiber.StatusOK).JSON(responses.OrdersResponse{
-		Success:    true,
-		Message:    "Orders retrieved successfully",
-		Data:       orderList,
-		Total:      total,
-		Page:       page,
-		PageSize:   pageSize,
-		TotalPages: int64(math.Ceil(float64(total) / float64(pageSize))),
+	return c.Status(fiber.StatusOK).JSON(responses.OrderQueueResponse{
+		Success: true,
+		Message: "Order queue retrieved successfully",
+		Data: responses.OrderQueueData{
+			GeneratedAt:    queue.GeneratedAt,
+			New:            bucket(queue.New),
+			AwaitingAction: bucket(queue.AwaitingAction),
+			Flagged:        bucket(queue.Flagged),
+		},
 	})
 }
 
-// GetOrderByID godoc
-// @Summary Get an order by ID
-// @Description Get a specific order with all its items and details
+// GetOrdersByInventory godoc
+// @Summary Get orders containing an inventory variant
+// @Description Get a paginated list of the orders that have at least one item of the given inventory variant
 // @Tags orders
 // @Accept json
 // @Produce json
-// @Param id path string true "Order ID"
-// @Success 200 {object} responses.OrderDetailResponse
+// @Param id path string true "Inventory ID"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} responses.OrdersResponse
 // @Failure 400 {object} responses.ErrorResponse
 // @Failure 404 {object} responses.ErrorResponse
 // @Failure 500 {object} responses.ErrorResponse
-// @Router /api/orders/{id} [get]
+// @Router /api/inventories/{id}/orders [get]
 // @Security ApiKeyAuth
-func (h *OrderHandler) GetOrderByID(c *fiber.Ctx) error {
-	// Parse order ID
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+func (h *OrderHandler) GetOrdersByInventory(c *fiber.Ctx) error {
+	orderService := h.orderService.WithContext(c.UserContext())
+
+	// Parse inventory ID
+	inventoryID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Invalid order ID format",
+			Message: "Invalid inventory ID",
 			Error:   err.Error(),
 		})
 	}
 
-	// Get order
-	o, err := h.orderService.GetOrderByID(id)
-	if err != nil {
+	// Check that the inventory exists
+	if _, err := orderService.ProductService.GetInventoryByID(inventoryID); err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Order not found",
+			Message: "Inventory not found",
 			Error:   err.Error(),
 		})
 	}
 
-	// Convert items to response format
-	items := make([]responses.OrderItemResponse, len(o.Items))
-	for i, item := range o.Items {
-		items[i] = responses.OrderItemResponse{
-			ID:          item.ID,
-			OrderID:     item.OrderID,
-			InventoryID: item.InventoryID,
-			Quantity:    item.Quantity,
-			Price:       item.PriceAtOrder,
-			Subtotal:    item.PriceAtOrder * float64(item.Quantity),
-			CreatedAt:   item.CreatedAt,
-			UpdatedAt:   item.UpdatedAt,
-			// Other fields would need to be fetched from related services
-			ProductID:    uuid.Nil, // Will be set below if product is found
-			ProductName:  "",       // Will be set below if product is found
-			ProductImage: "",       // Will be set below if product is found
-			Size:         "",       // Will be set below if inventory is found
-			Color:        "",       // Will be set below if inventory is found
-			PriceID:      uuid.Nil, // Will be set below if price is found
-			Currency:     "",       // Will be set below if price is found
-			Notes:        "",       // Not in the model, would need to add
-		}
+	// Parse pagination parameters
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
 
-		// Get inventory details if available
-		inventory, err := h.orderService.ProductService.GetInventoryByID(item.InventoryID)
-		if err == nil && inventory != nil {
-			// Add inventory details
-			items[i].Size = inventory.Size
-			items[i].Color = inventory.Color
+	pageSize, err := strconv.Atoi(c.Query("page_size", "10"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10
+	}
 
-			// Get product details if available
-			product, err := h.orderService.ProductService.GetProductByID(inventory.ProductID)
-			if err == nil && product != nil {
-				items[i].ProductID = product.ID
-				items[i].ProductName = product.Name
-				items[i].ProductImage = h.orderService.ProductService.GetPrimaryImageURL(product.ID)
+	return h.respondWithOrders(c, orderService, page, pageSize, map[string]interface{}{"inventory_id": inventoryID})
+}
 
-				// Get price details if available
-				price, err := h.orderService.ProductService.GetCurrentPrice(product.ID)
-				if err == nil && price != nil {
-					items[i].PriceID = price.ID
-					items[i].Currency = price.Currency
-				}
-			}
-		}
+// GetCustomerOrders godoc
+// @Summary Get the orders of a customer
+// @Description Get a paginated list of the orders linked to a customer profile
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param id path string true "Customer ID"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} responses.OrdersResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/customers/{id}/orders [get]
+// @Security ApiKeyAuth
+func (h *OrderHandler) GetCustomerOrders(c *fiber.Ctx) error {
+	orderService := h.orderService.WithContext(c.UserContext())
+
+	// Parse customer ID
+	customerID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid customer ID format",
+			Error:   err.Error(),
+		})
 	}
 
-	// Get creator information if available
-	var creatorName string
-	if o.CreatedBy != nil {
-		// Get user information
-		user, err := h.orderService.UserService.GetUserByID(*o.CreatedBy)
-		if err == nil {
-			creatorName = user.Username
-		}
+	// Check that the customer exists
+	if _, err := orderService.GetCustomerByID(customerID); err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to get customer",
+			Error:   err.Error(),
+		})
 	}
 
-	// Create shipment response if available
-	var shipmentResponse *responses.ShipmentResponse
-	if o.Shipment != nil {
-		shipmentResponse = &responses.ShipmentResponse{
-			ID:             o.Shipment.ID,
-			OrderID:        o.Shipment.OrderID,
-			TrackingNumber: o.Shipment.TrackingNumber,
-			Carrier:        o.Shipment.Carrier,
-			CreatedAt:      o.Shipment.CreatedAt,
-			UpdatedAt:      o.Shipment.UpdatedAt,
-		}
+	// Parse pagination parameters
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
 	}
 
-	// Return response
-	return c.Status(fiber.StatusOK).JSON(responses.OrderDetailResponse{
-		Success: true,
-		Message: "Order retrieved successfully",
-		Data: responses.OrderDetail{
-			ID:               o.ID,
-			CustomerName:     o.CustomerName,
-			CustomerEmail:    o.CustomerEmail,
-			CustomerPhone:    o.CustomerPhone,
-			ShippingAddress:  o.ShippingAddress,
-			ShippingWard:     o.ShippingWard,
-			ShippingDistrict: o.ShippingDistrict,
-			ShippingCity:     o.ShippingCity,
-			ShippingCountry:  o.ShippingCountry,
-			PaymentMethod:    string(o.PaymentMethod),
-			Status:           string(o.OrderStatus),
-			Notes:            o.Notes,
-			Total:            o.TotalAmount,
-			DiscountAmount:   o.DiscountAmount,
-			DiscountReason:   o.DiscountReason,
-			FinalTotal:       o.FinalTotalAmount,
-			CreatedBy:        *o.CreatedBy,
-			CreatedByName:    creatorName,
-			Items:            items,
-			Shipment:         shipmentResponse,
-			CreatedAt:        o.CreatedAt,
-			UpdatedAt:        o.UpdatedAt,
-		},
-	})
+	pageSize, err := strconv.Atoi(c.Query("page_size", "10"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10
+	}
+
+	return h.respondWithOrders(c, orderService, page, pageSize, map[string]interface{}{"customer_id": customerID})
 }
 
-// UpdateOrderStatus godoc
-// @Summary Update an order's status
-// @Description Update the status of an order. Admins can change to any status. Agents can only change orders with status 'pending_confirmation', 'confirmed', or 'shipment_requested'.
+// HoldInventory godoc
+// @Summary Hold stock of an inventory
+// @Description Hold stock of an inventory variant for a limited time without creating an order, e.g. for a quotation. The hold reduces the available-to-promise quantity, not the raw quantity, and expires automatically.
 // @Tags orders
 // @Accept json
 // @Produce json
-// @Param id path string true "Order ID"
-// @Param status body requests.UpdateOrderStatusRequest true "Order status"
-// @Success 200 {object} responses.OrderResponse
+// @Param id path string true "Inventory ID"
+// @Param request body requests.HoldInventoryRequest true "Hold details"
+// @Success 201 {object} responses.InventoryHoldResponse
 // @Failure 400 {object} responses.ErrorResponse
-// @Failure 401 {object} responses.ErrorResponse
-// @Failure 403 {object} responses.ErrorResponse
 // @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
 // @Failure 500 {object} responses.ErrorResponse
-// @Router /api/orders/{id}/status [put]
+// @Router /api/inventories/{id}/hold [post]
 // @Security ApiKeyAuth
-func (h *OrderHandler) UpdateOrderStatus(c *fiber.Ctx) error {
-	// Get user roles from context (set by auth middleware)
-	_, ok := c.Locals("userID").(uuid.UUID)
+func (h *OrderHandler) HoldInventory(c *fiber.Ctx) error {
+	orderService := h.orderService.WithContext(c.UserContext())
+
+	// Get user ID from context (set by auth middleware)
+	userID, ok := c.Locals("userID").(uuid.UUID)
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
 			Success: false,
@@ -619,211 +672,486 @@ func (h *OrderHandler) UpdateOrderStatus(c *fiber.Ctx) error {
 		})
 	}
 
-	userRoles, ok := c.Locals("roles").([]string)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
+	// Parse inventory ID
+	inventoryID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Unauthorized",
-			Error:   "Invalid user roles",
+			Message: "Invalid inventory ID",
+			Error:   err.Error(),
 		})
 	}
 
-	// Parse order ID
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
-	if err != nil {
+	// Parse request
+	var req requests.HoldInventoryRequest
+	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Invalid order ID format",
+			Message: "Invalid request",
 			Error:   err.Error(),
 		})
 	}
 
-	// Get current order to check status
-	currentOrder, err := h.orderService.GetOrderByID(id)
-	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(responses.ErrorResponse{
+	// Validate request
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Order not found",
+			Message: "Validation failed",
 			Error:   err.Error(),
 		})
 	}
 
-	// Check if user is an admin
-	isAdmin := false
-	for _, role := range userRoles {
-		if role == "admin" {
-			isAdmin = true
-			break
-		}
+	hold, err := orderService.HoldInventory(inventoryID, req.Quantity, time.Duration(req.DurationMinutes)*time.Minute,
+		req.CustomerID, req.Notes, &userID)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to hold inventory",
+			Error:   err.Error(),
+		})
 	}
 
-	// For non-admin users, check if the order is in an allowed status for agents
-	if !isAdmin {
-		currentStatus := string(currentOrder.OrderStatus)
-		// Check if current status is one of the allowed statuses for agents
-		allowedStatuses := []string{"pending_confirmation", "confirmed", "shipment_requested"}
-		isAllowed := false
-		for _, status := range allowedStatuses {
-			if currentStatus == status {
-				isAllowed = true
-				break
-			}
-		}
+	return c.Status(fiber.StatusCreated).JSON(responses.InventoryHoldResponse{
+		Success: true,
+		Message: "Inventory held successfully",
+		Data:    responses.ConvertToInventoryHoldDetail(*hold),
+	})
+}
 
-		if !isAllowed {
-			return c.Status(fiber.StatusForbidden).JSON(responses.ErrorResponse{
-				Success: false,
-				Message: "Permission denied",
-				Error:   "Agents can only update orders with status: pending_confirmation, confirmed, or shipment_requested",
-			})
-		}
-	}
+// ReleaseInventoryHold godoc
+// @Summary Release an inventory hold
+// @Description Release an active inventory hold before it expires so its stock can be promised again
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Inventory hold ID"
+// @Success 200 {object} responses.InventoryHoldResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/inventories/holds/{id}/release [post]
+// @Security ApiKeyAuth
+func (h *OrderHandler) ReleaseInventoryHold(c *fiber.Ctx) error {
+	orderService := h.orderService.WithContext(c.UserContext())
 
-	// Parse request
-	var req requests.UpdateOrderStatusRequest
-	if err := c.BodyParser(&req); err != nil {
+	// Parse hold ID
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Invalid request",
+			Message: "Invalid inventory hold ID",
 			Error:   err.Error(),
 		})
 	}
 
-	// Validate request
-	if err := req.Validate(); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+	hold, err := orderService.ReleaseInventoryHold(id)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Validation failed",
+			Message: "Failed to release inventory hold",
 			Error:   err.Error(),
 		})
 	}
 
-	// Update order status
-	_, err = h.orderService.UpdateOrderStatus(id, order.OrderStatus(req.Status))
+	return c.Status(fiber.StatusOK).JSON(responses.InventoryHoldResponse{
+		Success: true,
+		Message: "Inventory hold released successfully",
+		Data:    responses.ConvertToInventoryHoldDetail(*hold),
+	})
+}
+
+// buildOrderListDetail converts an order with its batch-loaded details to the detail returned by
+// the order lists
+func (h *OrderHandler) buildOrderListDetail(d services.OrderWithDetails) responses.OrderDetail {
+	o := d.Order
+	detail := responses.OrderDetail{
+		ID:               o.ID,
+		OrderNumber:      o.OrderNumber,
+		CustomerName:     o.CustomerName,
+		CustomerEmail:    o.CustomerEmail,
+		CustomerPhone:    o.CustomerPhone,
+		ShippingAddress:  o.ShippingAddress,
+		ShippingWard:     o.ShippingWard,
+		ShippingDistrict: o.ShippingDistrict,
+		ShippingCity:     o.ShippingCity,
+		ShippingCountry:  o.ShippingCountry,
+		PaymentMethod:    string(o.PaymentMethod),
+		Currency:         o.Currency,
+		Status:           string(o.OrderStatus),
+		Notes:            o.Notes,
+		Total:            o.TotalAmount,
+		DiscountAmount:   o.DiscountAmount,
+		DiscountReason:   o.DiscountReason,
+		DiscountPercent:  o.DiscountPercent,
+		PromoCode:        o.PromoCode,
+		FinalTotal:       o.FinalTotalAmount,
+		CustomerID:       o.CustomerID,
+		DeliveryProof:    responses.ConvertToDeliveryProofResponse(o),
+		CreatedAt:        o.CreatedAt,
+		UpdatedAt:        o.UpdatedAt,
+	}
+
+	// Set created by if available
+	if o.CreatedBy != nil {
+		detail.CreatedBy = *o.CreatedBy
+		detail.CreatedByName = d.CreatorName
+	}
+
+	// Add shipments if available
+	detail.SetShipments(o.Shipments)
+
+	items := make([]responses.OrderItemResponse, len(d.Items))
+	for i, itemDetails := range d.Items {
+		item := itemDetails.Item
+		items[i] = responses.OrderItemResponse{
+			ID:               item.ID,
+			OrderID:          item.OrderID,
+			InventoryID:      item.InventoryID,
+			Quantity:         item.Quantity,
+			ReturnedQuantity: item.ReturnedQuantity,
+			Price:            item.PriceAtOrder,
+			Currency:         item.Currency,
+			OriginalPrice:    item.OriginalPrice,
+			OriginalCurrency: item.OriginalCurrency,
+			Subtotal:         item.PriceAtOrder * float64(item.Quantity),
+			CreatedAt:        item.CreatedAt,
+			UpdatedAt:        item.UpdatedAt,
+		}
+
+		if inventory := itemDetails.Inventory; inventory != nil {
+			items[i].Size = inventory.Size
+			items[i].Color = inventory.Color
+		}
+		if product := itemDetails.Product; product != nil {
+			items[i].ProductID = product.ID
+			items[i].ProductName = product.Name
+		}
+		if price := itemDetails.Price; price != nil {
+			items[i].PriceID = price.ID
+			if items[i].Currency == "" {
+				items[i].Currency = price.Currency
+			}
+		}
+	}
+
+	detail.Items = items
+	h.formatAmounts(&detail)
+	return detail
+}
+
+// respondWithOrders fetches a page of the orders matching the filters and writes them as an orders response
+func (h *OrderHandler) respondWithOrders(c *fiber.Ctx, orderService *services.OrderService, page, pageSize int, filters map[string]interface{}) error {
+	// Get orders with the details of their items loaded in batches
+	orders, total, err := orderService.GetOrdersWithDetails(page, pageSize, filters)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Failed to update order status",
+			Message: "Failed to get orders",
 			Error:   err.Error(),
 		})
 	}
 
-	// Get the updated order to return complete information
-	updatedOrder, err := h.orderService.GetOrderByID(id)
+	// Format response data
+	var orderList []responses.OrderDetail
+	for _, o := range orders {
+		orderList = append(orderList, h.buildOrderListDetail(o))
+	}
+
+	// Return response
+	return c.Status(fiber.StatusOK).JSON(responses.OrdersResponse{
+		Success:    true,
+		Message:    "Orders retrieved successfully",
+		Data:       orderList,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int64(math.Ceil(float64(total) / float64(pageSize))),
+	})
+}
+
+// GetShipments godoc
+// @Summary Get all shipments
+// @Description Get a shipment-centric list of shipments with the summary of their orders, with pagination and filtering. The date range applies to the shipment creation date.
+// @Tags shipments
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param carrier query string false "Filter by carrier (e.g. GHN)"
+// @Param has_tracking query bool false "Filter by whether a tracking number is set"
+// @Param status query string false "Filter by order status"
+// @Param from_date query string false "Filter by start date (YYYY-MM-DD)"
+// @Param to_date query string false "Filter by end date (YYYY-MM-DD)"
+// @Success 200 {object} responses.ShipmentsResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/shipments [get]
+// @Security ApiKeyAuth
+func (h *OrderHandler) GetShipments(c *fiber.Ctx) error {
+	orderService := h.orderService.WithContext(c.UserContext())
+
+	// Parse pagination parameters
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(c.Query("page_size", "10"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10
+	}
+
+	filters := make(map[string]interface{})
+
+	if carrier := c.Query("carrier"); carrier != "" {
+		filters["carrier"] = carrier
+	}
+
+	if hasTracking := c.Query("has_tracking"); hasTracking != "" {
+		value, err := strconv.ParseBool(hasTracking)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+				Success: false,
+				Message: "Invalid has_tracking filter",
+				Error:   "has_tracking must be true or false",
+			})
+		}
+		filters["has_tracking"] = value
+	}
+
+	if status := c.Query("status"); status != "" {
+		filters["order_status"] = status
+	}
+
+	// Apply from_date filter if provided, from the beginning of the day
+	if fromDate := c.Query("from_date"); fromDate != "" {
+		date, err := time.Parse("2006-01-02", fromDate)
+		if err == nil {
+			filters["from_date"] = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+		}
+	}
+
+	// Apply to_date filter if provided, until the end of the day
+	if toDate := c.Query("to_date"); toDate != "" {
+		date, err := time.Parse("2006-01-02", toDate)
+		if err == nil {
+			filters["to_date"] = time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 999999999, date.Location())
+		}
+	}
+
+	rows, total, err := orderService.GetShipments(page, pageSize, filters)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Failed to retrieve updated order",
+			Message: "Failed to get shipments",
 			Error:   err.Error(),
 		})
 	}
 
-	// Get creator information if available
-	var creatorName string
-	if updatedOrder.CreatedBy != nil {
-		// Get user information
-		user, err := h.orderService.UserService.GetUserByID(*updatedOrder.CreatedBy)
-		if err == nil {
-			creatorName = user.Username
+	shipments := make([]responses.ShipmentListItem, len(rows))
+	for i, row := range rows {
+		shipments[i] = responses.ShipmentListItem{
+			ID:             row.ID,
+			OrderID:        row.OrderID,
+			OrderNumber:    row.OrderNumber,
+			OrderStatus:    string(row.OrderStatus),
+			CustomerName:   row.CustomerName,
+			CustomerPhone:  row.CustomerPhone,
+			TrackingNumber: row.TrackingNumber,
+			Carrier:        row.Carrier,
+			ShippingCost:   row.ShippingCost,
+			CODAmount:      row.CODAmount,
+			CreatedAt:      row.CreatedAt,
+			UpdatedAt:      row.UpdatedAt,
 		}
 	}
 
+	return c.Status(fiber.StatusOK).JSON(responses.ShipmentsResponse{
+		Success:    true,
+		Message:    "Shipments retrieved successfully",
+		Data:       shipments,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int64(math.Ceil(float64(total) / float64(pageSize))),
+	})
+}
+
+// GetOrderByID godoc
+// @Summary Get an order by ID
+// @Description Get a specific order with all its items and details
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} responses.OrderDetailResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/orders/{id} [get]
+// @Security ApiKeyAuth
+func (h *OrderHandler) GetOrderByID(c *fiber.Ctx) error {
+	orderService := h.orderService.WithContext(c.UserContext())
+
+	// Parse order ID
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid order ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	// Get order
+	o, err := orderService.GetOrderByID(id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Order not found",
+			Error:   err.Error(),
+		})
+	}
+
 	// Convert items to response format
-	items := make([]responses.OrderItemResponse, len(updatedOrder.Items))
-	for i, item := range updatedOrder.Items {
+	items := make([]responses.OrderItemResponse, len(o.Items))
+	for i, item := range o.Items {
 		items[i] = responses.OrderItemResponse{
-			ID:          item.ID,
-			OrderID:     item.OrderID,
-			InventoryID: item.InventoryID,
-			Quantity:    item.Quantity,
-			Price:       item.PriceAtOrder,
-			Subtotal:    item.PriceAtOrder * float64(item.Quantity),
-			CreatedAt:   item.CreatedAt,
-			UpdatedAt:   item.UpdatedAt,
+			ID:               item.ID,
+			OrderID:          item.OrderID,
+			InventoryID:      item.InventoryID,
+			Quantity:         item.Quantity,
+			ReturnedQuantity: item.ReturnedQuantity,
+			Price:            item.PriceAtOrder,
+			Currency:         item.Currency,
+			OriginalPrice:    item.OriginalPrice,
+			OriginalCurrency: item.OriginalCurrency,
+			Subtotal:         item.PriceAtOrder * float64(item.Quantity),
+			CreatedAt:        item.CreatedAt,
+			UpdatedAt:        item.UpdatedAt,
+			// Other fields would need to be fetched from related services
+			ProductID:    uuid.Nil, // Will be set below if product is found
+			ProductName:  "",       // Will be set below if product is found
+			ProductImage: "",       // Will be set below if product is found
+			Size:         "",       // Will be set below if inventory is found
+			Color:        "",       // Will be set below if inventory is found
+			PriceID:      uuid.Nil, // Will be set below if price is found
+			Notes:        "",       // Not in the model, would need to add
 		}
 
 		// Get inventory details if available
-		inventory, err := h.orderService.ProductService.GetInventoryByID(item.InventoryID)
+		inventory, err := orderService.ProductService.GetInventoryIncludingDeleted(item.InventoryID)
 		if err == nil && inventory != nil {
 			// Add inventory details
 			items[i].Size = inventory.Size
 			items[i].Color = inventory.Color
 
 			// Get product details if available
-			product, err := h.orderService.ProductService.GetProductByID(inventory.ProductID)
+			product, err := orderService.ProductService.GetProductIncludingDeleted(inventory.ProductID)
 			if err == nil && product != nil {
 				items[i].ProductID = product.ID
 				items[i].ProductName = product.Name
+				items[i].ProductImage = product.PrimaryImageURL()
 
 				// Get price details if available
-				price, err := h.orderService.ProductService.GetCurrentPrice(product.ID)
+				price, err := orderService.ProductService.GetCurrentPrice(product.ID)
 				if err == nil && price != nil {
 					items[i].PriceID = price.ID
-					items[i].Currency = price.Currency
+					if items[i].Currency == "" {
+						items[i].Currency = price.Currency
+					}
 				}
 			}
 		}
 	}
 
-	// Create shipment response if available
-	var shipmentResponse *responses.ShipmentResponse
-	if updatedOrder.Shipment != nil {
-		shipmentResponse = &responses.ShipmentResponse{
-			ID:             updatedOrder.Shipment.ID,
-			OrderID:        updatedOrder.Shipment.OrderID,
-			TrackingNumber: updatedOrder.Shipment.TrackingNumber,
-			Carrier:        updatedOrder.Shipment.Carrier,
-			CreatedAt:      updatedOrder.Shipment.CreatedAt,
-			UpdatedAt:      updatedOrder.Shipment.UpdatedAt,
+	// Get creator information if available
+	var creatorName string
+	if o.CreatedBy != nil {
+		// Get user information
+		user, err := orderService.UserService.GetUserByID(*o.CreatedBy)
+		if err == nil {
+			creatorName = user.Username
 		}
 	}
 
-	// Return response with complete order information
-	return c.Status(fiber.StatusOK).JSON(responses.OrderResponse{
+	detail := responses.OrderDetail{
+		ID:               o.ID,
+		OrderNumber:      o.OrderNumber,
+		CustomerName:     o.CustomerName,
+		CustomerEmail:    o.CustomerEmail,
+		CustomerPhone:    o.CustomerPhone,
+		ShippingAddress:  o.ShippingAddress,
+		ShippingWard:     o.ShippingWard,
+		ShippingDistrict: o.ShippingDistrict,
+		ShippingCity:     o.ShippingCity,
+		ShippingCountry:  o.ShippingCountry,
+		PaymentMethod:    string(o.PaymentMethod),
+		Currency:         o.Currency,
+		Status:           string(o.OrderStatus),
+		Notes:            o.Notes,
+		Total:            o.TotalAmount,
+		DiscountAmount:   o.DiscountAmount,
+		DiscountReason:   o.DiscountReason,
+		DiscountPercent:  o.DiscountPercent,
+		PromoCode:        o.PromoCode,
+		FinalTotal:       o.FinalTotalAmount,
+		CreatedBy:        *o.CreatedBy,
+		CreatedByName:    creatorName,
+		Items:            items,
+		CustomerID:       o.CustomerID,
+		DeliveryProof:    responses.ConvertToDeliveryProofResponse(*o),
+		CreatedAt:        o.CreatedAt,
+		UpdatedAt:        o.UpdatedAt,
+	}
+	detail.SetShipments(o.Shipments)
+	h.formatAmounts(&detail)
+
+	// Return response
+	return c.Status(fiber.StatusOK).JSON(responses.OrderDetailResponse{
 		Success: true,
-		Message: "Order status updated successfully",
-		Data: responses.OrderDetail{
-			ID:               updatedOrder.ID,
-			CustomerName:     updatedOrder.CustomerName,
-			CustomerEmail:    updatedOrder.CustomerEmail,
-			CustomerPhone:    updatedOrder.CustomerPhone,
-			ShippingAddress:  updatedOrder.ShippingAddress,
-			ShippingWard:     updatedOrder.ShippingWard,
-			ShippingDistrict: updatedOrder.ShippingDistrict,
-			ShippingCity:     updatedOrder.ShippingCity,
-			ShippingCountry:  updatedOrder.ShippingCountry,
-			PaymentMethod:    string(updatedOrder.PaymentMethod),
-			Status:           string(updatedOrder.OrderStatus),
-			Notes:            updatedOrder.Notes,
-			Total:            updatedOrder.TotalAmount,
-			DiscountAmount:   updatedOrder.DiscountAmount,
-			DiscountReason:   updatedOrder.DiscountReason,
-			FinalTotal:       updatedOrder.FinalTotalAmount,
-			CreatedBy:        *updatedOrder.CreatedBy,
-			CreatedByName:    creatorName,
-			Items:            items,
-			Shipment:         shipmentResponse,
-			CreatedAt:        updatedOrder.CreatedAt,
-			UpdatedAt:        updatedOrder.UpdatedAt,
-		},
+		Message: "Order retrieved successfully",
+		Data:    detail,
 	})
 }
 
-// DeleteOrder godoc
-// @Summary Delete an order
-// @Description Delete an order and all its items
+// UpdateOrderStatus godoc
+// @Summary Update an order's status
+// @Description Update the status of an order; the change is recorded in the status history of the order together with the user and the optional note. Admins can change to any status. Agents can only change orders with status 'pending_confirmation', 'confirmed', or 'shipment_requested', and only to one of the configured agent target statuses (by default 'packed' or 'canceled').
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param id path string true "Order ID"
-// @Success 200 {object} responses.SuccessResponse
+// @Param status body requests.UpdateOrderStatusRequest true "Order status"
+// @Success 200 {object} responses.OrderResponse
 // @Failure 400 {object} responses.ErrorResponse
+// @Failure 401 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
 // @Failure 404 {object} responses.ErrorResponse
 // @Failure 500 {object} responses.ErrorResponse
-// @Router /api/orders/{id} [delete]
+// @Router /api/orders/{id}/status [put]
 // @Security ApiKeyAuth
-func (h *OrderHandler) DeleteOrder(c *fiber.Ctx) error {
+func (h *OrderHandler) UpdateOrderStatus(c *fiber.Ctx) error {
+	// Get user from context (set by auth middleware)
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Unauthorized",
+			Error:   "Invalid user ID",
+		})
+	}
+
+	if _, ok := c.Locals("roles").([]string); !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Unauthorized",
+			Error:   "Invalid user roles",
+		})
+	}
+
 	// Parse order ID
 	idStr := c.Params("id")
 	id, err := uuid.Parse(idStr)
@@ -835,33 +1163,253 @@ func (h *OrderHandler) DeleteOrder(c *fiber.Ctx) error {
 		})
 	}
 
-	// Delete order
-	result, err := h.orderService.DeleteOrder(id)
+	// Get current order to check status
+	currentOrder, err := h.orderService.GetOrderByID(id)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+		return c.Status(fiber.StatusNotFound).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Failed to delete order",
+			Message: "Order not found",
 			Error:   err.Error(),
 		})
 	}
 
-	// Return response
-	return c.Status(fiber.StatusOK).JSON(responses.SuccessResponse{
-		Success: true,
-		Message: result.Message,
-	})
-}
+	// Check if the user may change the status of orders in any status
+	canUpdateAnyStatus := hasPermission(c, account.PermissionOrderUpdateStatus)
 
-// AddOrderItem godoc
-// @Summary Add an item to an order
-// @Description Add a new item to an existing order
-// @Tags orders
-// @Accept json
+	// Otherwise, check if the order is in an allowed status for agents
+	if !canUpdateAnyStatus {
+		currentStatus := string(currentOrder.OrderStatus)
+		// Check if current status is one of the allowed statuses for agents
+		allowedStatuses := []string{"pending_confirmation", "confirmed", "shipment_requested"}
+		isAllowed := false
+		for _, status := range allowedStatuses {
+			if currentStatus == status {
+				isAllowed = true
+				break
+			}
+		}
+
+		if !isAllowed {
+			return c.Status(fiber.StatusForbidden).JSON(responses.ErrorResponse{
+				Success: false,
+				Message: "Permission denied",
+				Error:   "Agents can only update orders with status: pending_confirmation, confirmed, or shipment_requested",
+			})
+		}
+	}
+
+	// Parse request
+	var req requests.UpdateOrderStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+	}
+
+	// Otherwise, check that the target status is one agents may set
+	targetStatus := order.OrderStatus(req.Status)
+	if !canUpdateAnyStatus && !h.orderService.Settings.AgentCanSetStatus(targetStatus) {
+		allowedTargets := make([]string, len(h.orderService.Settings.AgentTargetStatuses))
+		for i, status := range h.orderService.Settings.AgentTargetStatuses {
+			allowedTargets[i] = string(status)
+		}
+		return c.Status(fiber.StatusForbidden).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Permission denied",
+			Error:   "Agents can only change orders to status: " + strings.Join(allowedTargets, ", "),
+		})
+	}
+
+	// Update order status
+	_, err = h.orderService.ChangeOrderStatus(id, targetStatus, services.StatusChange{ChangedBy: &userID, Note: req.Note})
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to update order status",
+			Error:   err.Error(),
+		})
+	}
+
+	// Get the updated order to return complete information
+	updatedOrder, err := h.orderService.GetOrderByID(id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve updated order",
+			Error:   err.Error(),
+		})
+	}
+
+	// Get creator information if available
+	var creatorName string
+	if updatedOrder.CreatedBy != nil {
+		// Get user information
+		user, err := h.orderService.UserService.GetUserByID(*updatedOrder.CreatedBy)
+		if err == nil {
+			creatorName = user.Username
+		}
+	}
+
+	// Convert items to response format
+	items := make([]responses.OrderItemResponse, len(updatedOrder.Items))
+	for i, item := range updatedOrder.Items {
+		items[i] = responses.OrderItemResponse{
+			ID:               item.ID,
+			OrderID:          item.OrderID,
+			InventoryID:      item.InventoryID,
+			Quantity:         item.Quantity,
+			ReturnedQuantity: item.ReturnedQuantity,
+			Price:            item.PriceAtOrder,
+			Currency:         item.Currency,
+			OriginalPrice:    item.OriginalPrice,
+			OriginalCurrency: item.OriginalCurrency,
+			Subtotal:         item.PriceAtOrder * float64(item.Quantity),
+			CreatedAt:        item.CreatedAt,
+			UpdatedAt:        item.UpdatedAt,
+		}
+
+		// Get inventory details if available
+		inventory, err := h.orderService.ProductService.GetInventoryIncludingDeleted(item.InventoryID)
+		if err == nil && inventory != nil {
+			// Add inventory details
+			items[i].Size = inventory.Size
+			items[i].Color = inventory.Color
+
+			// Get product details if available
+			product, err := h.orderService.ProductService.GetProductIncludingDeleted(inventory.ProductID)
+			if err == nil && product != nil {
+				items[i].ProductID = product.ID
+				items[i].ProductName = product.Name
+
+				// Get price details if available
+				price, err := h.orderService.ProductService.GetCurrentPrice(product.ID)
+				if err == nil && price != nil {
+					items[i].PriceID = price.ID
+					if items[i].Currency == "" {
+						items[i].Currency = price.Currency
+					}
+				}
+			}
+		}
+	}
+
+	detail := responses.OrderDetail{
+		ID:               updatedOrder.ID,
+		OrderNumber:      updatedOrder.OrderNumber,
+		CustomerName:     updatedOrder.CustomerName,
+		CustomerEmail:    updatedOrder.CustomerEmail,
+		CustomerPhone:    updatedOrder.CustomerPhone,
+		ShippingAddress:  updatedOrder.ShippingAddress,
+		ShippingWard:     updatedOrder.ShippingWard,
+		ShippingDistrict: updatedOrder.ShippingDistrict,
+		ShippingCity:     updatedOrder.ShippingCity,
+		ShippingCountry:  updatedOrder.ShippingCountry,
+		PaymentMethod:    string(updatedOrder.PaymentMethod),
+		Currency:         updatedOrder.Currency,
+		Status:           string(updatedOrder.OrderStatus),
+		Notes:            updatedOrder.Notes,
+		Total:            updatedOrder.TotalAmount,
+		DiscountAmount:   updatedOrder.DiscountAmount,
+		DiscountReason:   updatedOrder.DiscountReason,
+		DiscountPercent:  updatedOrder.DiscountPercent,
+		PromoCode:        updatedOrder.PromoCode,
+		FinalTotal:       updatedOrder.FinalTotalAmount,
+		CreatedBy:        *updatedOrder.CreatedBy,
+		CreatedByName:    creatorName,
+		Items:            items,
+		CustomerID:       updatedOrder.CustomerID,
+		DeliveryProof:    responses.ConvertToDeliveryProofResponse(*updatedOrder),
+		CreatedAt:        updatedOrder.CreatedAt,
+		UpdatedAt:        updatedOrder.UpdatedAt,
+	}
+	detail.SetShipments(updatedOrder.Shipments)
+	h.formatAmounts(&detail)
+
+	// Return response with complete order information
+	return c.Status(fiber.StatusOK).JSON(responses.OrderResponse{
+		Success: true,
+		Message: "Order status updated successfully",
+		Data:    detail,
+	})
+}
+
+// DeleteOrder godoc
+// @Summary Cancel or delete an order
+// @Description Cancel an order (set its status to canceled, release its inventory and keep the record) or purge it, soft-deleting it with all its items and its shipment. Without a mode, shipment_requested and canceled orders are purged and all other orders are canceled.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param mode query string false "Delete mode: cancel or purge"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 401 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/orders/{id} [delete]
+// @Security ApiKeyAuth
+func (h *OrderHandler) DeleteOrder(c *fiber.Ctx) error {
+	// Get user ID from context (set by auth middleware)
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Unauthorized",
+			Error:   "Invalid user ID",
+		})
+	}
+
+	// Parse order ID
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid order ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	// Cancel or delete order
+	mode := services.OrderDeleteMode(c.Query("mode"))
+	result, err := h.orderService.RemoveOrder(id, mode, services.StatusChange{ChangedBy: &userID})
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to delete order",
+			Error:   err.Error(),
+		})
+	}
+
+	// Return response
+	return c.Status(fiber.StatusOK).JSON(responses.SuccessResponse{
+		Success: true,
+		Message: result.Message,
+	})
+}
+
+// AddOrderItem godoc
+// @Summary Add an item to an order
+// @Description Add a new item to an existing order
+// @Tags orders
+// @Accept json
 // @Produce json
 // @Param id path string true "Order ID"
 // @Param item body requests.AddOrderItemRequest true "Order item details"
 // @Success 201 {object} responses.OrderItemDetailResponse
 // @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
 // @Failure 404 {object} responses.ErrorResponse
 // @Failure 500 {object} responses.ErrorResponse
 // @Router /api/orders/{id}/items [post]
@@ -898,9 +1446,9 @@ func (h *OrderHandler) AddOrderItem(c *fiber.Ctx) error {
 	}
 
 	// Add order item
-	err = h.orderService.AddOrderItem(orderID, req.InventoryID, req.Quantity)
+	err = h.orderService.AddOrderItem(orderID, req.InventoryID, req.Quantity, hasPermission(c, account.PermissionOrderUpdate))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
 			Success: false,
 			Message: "Failed to add order item",
 			Error:   err.Error(),
@@ -937,7 +1485,7 @@ func (h *OrderHandler) AddOrderItem(c *fiber.Ctx) error {
 	}
 
 	// Get inventory details
-	inventory, err := h.orderService.ProductService.GetInventoryByID(newItem.InventoryID)
+	inventory, err := h.orderService.ProductService.GetInventoryIncludingDeleted(newItem.InventoryID)
 	if err != nil {
 		// Log the error but continue
 		// We can still return the order item without the product details
@@ -945,19 +1493,23 @@ func (h *OrderHandler) AddOrderItem(c *fiber.Ctx) error {
 
 	// Create response with actual data
 	response := responses.OrderItemResponse{
-		ID:          newItem.ID,
-		OrderID:     newItem.OrderID,
-		InventoryID: newItem.InventoryID,
-		Quantity:    newItem.Quantity,
-		Price:       newItem.PriceAtOrder,
-		Subtotal:    newItem.PriceAtOrder * float64(newItem.Quantity),
-		CreatedAt:   newItem.CreatedAt,
-		UpdatedAt:   newItem.UpdatedAt,
+		ID:               newItem.ID,
+		OrderID:          newItem.OrderID,
+		InventoryID:      newItem.InventoryID,
+		Quantity:         newItem.Quantity,
+		ReturnedQuantity: newItem.ReturnedQuantity,
+		Price:            newItem.PriceAtOrder,
+		Currency:         newItem.Currency,
+		OriginalPrice:    newItem.OriginalPrice,
+		OriginalCurrency: newItem.OriginalCurrency,
+		Subtotal:         newItem.PriceAtOrder * float64(newItem.Quantity),
+		CreatedAt:        newItem.CreatedAt,
+		UpdatedAt:        newItem.UpdatedAt,
 	}
 
 	// Add product details if available
 	if inventory != nil {
-		product, err := h.orderService.ProductService.GetProductByID(inventory.ProductID)
+		product, err := h.orderService.ProductService.GetProductIncludingDeleted(inventory.ProductID)
 		if err == nil && product != nil {
 			response.ProductID = product.ID
 			response.ProductName = product.Name
@@ -966,7 +1518,9 @@ func (h *OrderHandler) AddOrderItem(c *fiber.Ctx) error {
 			price, err := h.orderService.ProductService.GetCurrentPrice(product.ID)
 			if err == nil && price != nil {
 				response.PriceID = price.ID
-				response.Currency = price.Currency
+				if response.Currency == "" {
+					response.Currency = price.Currency
+				}
 			}
 		}
 
@@ -974,6 +1528,7 @@ func (h *OrderHandler) AddOrderItem(c *fiber.Ctx) error {
 		response.Size = inventory.Size
 		response.Color = inventory.Color
 	}
+	response.FormatAmounts(h.orderService.Settings.Currency, h.orderService.Settings.CurrencyLocale)
 
 	// Return response
 	return c.Status(fiber.StatusCreated).JSON(responses.OrderItemDetailResponse{
@@ -1010,8 +1565,7 @@ func (h *OrderHandler) UpdateOrderItem(c *fiber.Ctx) error {
 		})
 	}
 
-	userRoles, ok := c.Locals("roles").([]string)
-	if !ok {
+	if _, ok := c.Locals("roles").([]string); !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
 			Success: false,
 			Message: "Unauthorized",
@@ -1050,17 +1604,11 @@ func (h *OrderHandler) UpdateOrderItem(c *fiber.Ctx) error {
 		})
 	}
 
-	// Check if user is an admin
-	isAdmin := false
-	for _, role := range userRoles {
-		if role == "admin" {
-			isAdmin = true
-			break
-		}
-	}
+	// Check if the user may edit orders in any status
+	canUpdateAny := hasPermission(c, account.PermissionOrderUpdate)
 
-	// For non-admin users, check if the order is in an allowed status for agents
-	if !isAdmin {
+	// Otherwise, check if the order is in an allowed status for agents
+	if !canUpdateAny {
 		currentStatus := string(order.OrderStatus)
 		// Check if current status is one of the allowed statuses for agents
 		allowedStatuses := []string{"pending_confirmation", "confirmed", "shipment_requested"}
@@ -1101,9 +1649,9 @@ func (h *OrderHandler) UpdateOrderItem(c *fiber.Ctx) error {
 	}
 
 	// Update order item
-	err = h.orderService.UpdateOrderItem(id, req.Quantity)
+	err = h.orderService.UpdateOrderItem(id, req.Quantity, canUpdateAny)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
 			Success: false,
 			Message: "Failed to update order item",
 			Error:   err.Error(),
@@ -1121,7 +1669,7 @@ func (h *OrderHandler) UpdateOrderItem(c *fiber.Ctx) error {
 	}
 
 	// Get inventory details if needed
-	inventory, err := h.orderService.ProductService.GetInventoryByID(updatedItem.InventoryID)
+	inventory, err := h.orderService.ProductService.GetInventoryIncludingDeleted(updatedItem.InventoryID)
 	if err != nil {
 		// Log the error but continue
 		// We can still return the order item without the product details
@@ -1129,19 +1677,23 @@ func (h *OrderHandler) UpdateOrderItem(c *fiber.Ctx) error {
 
 	// Create response with actual data
 	response := responses.OrderItemResponse{
-		ID:          updatedItem.ID,
-		OrderID:     updatedItem.OrderID,
-		InventoryID: updatedItem.InventoryID,
-		Quantity:    updatedItem.Quantity,
-		Price:       updatedItem.PriceAtOrder,
-		Subtotal:    updatedItem.PriceAtOrder * float64(updatedItem.Quantity),
-		CreatedAt:   updatedItem.CreatedAt,
-		UpdatedAt:   updatedItem.UpdatedAt,
+		ID:               updatedItem.ID,
+		OrderID:          updatedItem.OrderID,
+		InventoryID:      updatedItem.InventoryID,
+		Quantity:         updatedItem.Quantity,
+		ReturnedQuantity: updatedItem.ReturnedQuantity,
+		Price:            updatedItem.PriceAtOrder,
+		Currency:         updatedItem.Currency,
+		OriginalPrice:    updatedItem.OriginalPrice,
+		OriginalCurrency: updatedItem.OriginalCurrency,
+		Subtotal:         updatedItem.PriceAtOrder * float64(updatedItem.Quantity),
+		CreatedAt:        updatedItem.CreatedAt,
+		UpdatedAt:        updatedItem.UpdatedAt,
 	}
 
 	// Add product details if available
 	if inventory != nil {
-		product, err := h.orderService.ProductService.GetProductByID(inventory.ProductID)
+		product, err := h.orderService.ProductService.GetProductIncludingDeleted(inventory.ProductID)
 		if err == nil && product != nil {
 			response.ProductID = product.ID
 			response.ProductName = product.Name
@@ -1150,7 +1702,9 @@ func (h *OrderHandler) UpdateOrderItem(c *fiber.Ctx) error {
 			price, err := h.orderService.ProductService.GetCurrentPrice(product.ID)
 			if err == nil && price != nil {
 				response.PriceID = price.ID
-				response.Currency = price.Currency
+				if response.Currency == "" {
+					response.Currency = price.Currency
+				}
 			}
 		}
 
@@ -1158,6 +1712,7 @@ func (h *OrderHandler) UpdateOrderItem(c *fiber.Ctx) error {
 		response.Size = inventory.Size
 		response.Color = inventory.Color
 	}
+	response.FormatAmounts(h.orderService.Settings.Currency, h.orderService.Settings.CurrencyLocale)
 
 	// Return response
 	return c.Status(fiber.StatusOK).JSON(responses.OrderItemDetailResponse{
@@ -1169,13 +1724,14 @@ func (h *OrderHandler) UpdateOrderItem(c *fiber.Ctx) error {
 
 // DeleteOrderItem godoc
 // @Summary Delete an order item
-// @Description Delete an existing order item
+// @Description Delete an existing order item. The last item of an order cannot be deleted; cancel the order instead.
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param id path string true "Order Item ID"
 // @Success 200 {object} responses.SuccessResponse
 // @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
 // @Failure 404 {object} responses.ErrorResponse
 // @Failure 500 {object} responses.ErrorResponse
 // @Router /api/orders/items/{id} [delete]
@@ -1193,9 +1749,9 @@ func (h *OrderHandler) DeleteOrderItem(c *fiber.Ctx) error {
 	}
 
 	// Delete order item
-	err = h.orderService.DeleteOrderItem(id)
+	err = h.orderService.DeleteOrderItem(id, hasPermission(c, account.PermissionOrderUpdate))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
 			Success: false,
 			Message: "Failed to delete order item",
 			Error:   err.Error(),
@@ -1211,7 +1767,7 @@ func (h *OrderHandler) DeleteOrderItem(c *fiber.Ctx) error {
 
 // UpdateOrderDetails godoc
 // @Summary Update order details
-// @Description Update the details of an order including payment details, shipping address, and customer information. Customer phone number must be a valid Vietnamese number. Admins can update any order. Agents can only update orders with status 'pending_confirmation', 'confirmed', or 'shipment_requested'.
+// @Description Update the details of an order including payment details, shipping address, and customer information. Customer phone number must be a valid Vietnamese number and is stored in E.164 format (e.g. +84912345678). Fields left out keep their current value; the discount is only changed when discount_amount or discount_percent is sent. A discount may not exceed the order total; a discount_percent is converted to an amount of the current total. A discount_reason is required with a discount when configured and may not exceed the configured length. Admins can update any order. Agents can only update orders with status 'pending_confirmation', 'confirmed', or 'shipment_requested'. Orders handed to the carrier (picked up or with a tracking number) cannot be edited unless admins are allowed to override the lock.
 // @Tags orders
 // @Accept json
 // @Produce json
@@ -1236,8 +1792,7 @@ func (h *OrderHandler) UpdateOrderDetails(c *fiber.Ctx) error {
 		})
 	}
 
-	userRoles, ok := c.Locals("roles").([]string)
-	if !ok {
+	if _, ok := c.Locals("roles").([]string); !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
 			Success: false,
 			Message: "Unauthorized",
@@ -1266,17 +1821,11 @@ func (h *OrderHandler) UpdateOrderDetails(c *fiber.Ctx) error {
 		})
 	}
 
-	// Check if user is an admin
-	isAdmin := false
-	for _, role := range userRoles {
-		if role == "admin" {
-			isAdmin = true
-			break
-		}
-	}
+	// Check if the user may edit orders in any status
+	canUpdateAny := hasPermission(c, account.PermissionOrderUpdate)
 
-	// For non-admin users, check if the order is in an allowed status for agents
-	if !isAdmin {
+	// Otherwise, check if the order is in an allowed status for agents
+	if !canUpdateAny {
 		currentStatus := string(currentOrder.OrderStatus)
 		// Check if current status is one of the allowed statuses for agents
 		allowedStatuses := []string{"pending_confirmation", "confirmed", "shipment_requested"}
@@ -1315,6 +1864,9 @@ func (h *OrderHandler) UpdateOrderDetails(c *fiber.Ctx) error {
 		if errorMessage == "invalid Vietnamese phone number format" {
 			errorMessage = "Phone number must be a valid Vietnamese mobile or landline number (e.g., 0912345678, 0281234567)"
 		}
+		if errorMessage == "invalid email format" {
+			errorMessage = "Customer email must be a valid email address (e.g., john@example.com)"
+		}
 
 		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
 			Success: false,
@@ -1323,6 +1875,15 @@ func (h *OrderHandler) UpdateOrderDetails(c *fiber.Ctx) error {
 		})
 	}
 
+	// Validate discount reason
+	if err := req.ValidateDiscountReason(h.discountReasonRules()); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+	}
+
 	// Convert payment method
 	var paymentMethod order.PaymentMethod
 	if req.PaymentMethod != "" {
@@ -1335,6 +1896,7 @@ func (h *OrderHandler) UpdateOrderDetails(c *fiber.Ctx) error {
 		req.Notes,
 		paymentMethod,
 		req.DiscountAmount,
+		req.DiscountPercent,
 		req.DiscountReason,
 		req.ShippingAddress,
 		req.ShippingWard,
@@ -1344,10 +1906,11 @@ func (h *OrderHandler) UpdateOrderDetails(c *fiber.Ctx) error {
 		req.CustomerName,
 		req.CustomerEmail,
 		req.CustomerPhone,
+		canUpdateAny,
 	)
 
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
 			Success: false,
 			Message: "Failed to update order details",
 			Error:   err.Error(),
@@ -1364,133 +1927,28 @@ func (h *OrderHandler) UpdateOrderDetails(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get creator information if available
-	var creatorName string
-	if updatedOrder.CreatedBy != nil {
-		// Get user information
-		user, err := h.orderService.UserService.GetUserByID(*updatedOrder.CreatedBy)
-		if err == nil {
-			creatorName = user.Username
-		}
-	}
-
-	// Convert items to response format
-	items := make([]responses.OrderItemResponse, len(updatedOrder.Items))
-	for i, item := range updatedOrder.Items {
-		items[i] = responses.OrderItemResponse{
-			ID:          item.ID,
-			OrderID:     item.OrderID,
-			InventoryID: item.InventoryID,
-			Quantity:    item.Quantity,
-			Price:       item.PriceAtOrder,
-			Subtotal:    item.PriceAtOrder * float64(item.Quantity),
-			CreatedAt:   item.CreatedAt,
-			UpdatedAt:   item.UpdatedAt,
-		}
-
-		// Get inventory details if available
-		inventory, err := h.orderService.ProductService.GetInventoryByID(item.InventoryID)
-		if err == nil && inventory != nil {
-			// Add inventory details
-			items[i].Size = inventory.Size
-			items[i].Color = inventory.Color
-
-			// Get product details if available
-			product, err := h.orderService.ProductService.GetProductByID(inventory.ProductID)
-			if err == nil && product != nil {
-				items[i].ProductID = product.ID
-				items[i].ProductName = product.Name
-
-				// Get price details if available
-				price, err := h.orderService.ProductService.GetCurrentPrice(product.ID)
-				if err == nil && price != nil {
-					items[i].PriceID = price.ID
-					items[i].Currency = price.Currency
-				}
-			}
-		}
-	}
-
-	// Create shipment response if available
-	var shipmentResponse *responses.ShipmentResponse
-	if updatedOrder.Shipment != nil {
-		shipmentResponse = &responses.ShipmentResponse{
-			ID:             updatedOrder.Shipment.ID,
-			OrderID:        updatedOrder.Shipment.OrderID,
-			TrackingNumber: updatedOrder.Shipment.TrackingNumber,
-			Carrier:        updatedOrder.Shipment.Carrier,
-			CreatedAt:      updatedOrder.Shipment.CreatedAt,
-			UpdatedAt:      updatedOrder.Shipment.UpdatedAt,
-		}
-	}
-
 	// Return response with complete order information
 	return c.Status(fiber.StatusOK).JSON(responses.OrderResponse{
 		Success: true,
 		Message: "Order details updated successfully",
-		Data: responses.OrderDetail{
-			ID:               updatedOrder.ID,
-			CustomerName:     updatedOrder.CustomerName,
-			CustomerEmail:    updatedOrder.CustomerEmail,
-			CustomerPhone:    updatedOrder.CustomerPhone,
-			ShippingAddress:  updatedOrder.ShippingAddress,
-			ShippingWard:     updatedOrder.ShippingWard,
-			ShippingDistrict: updatedOrder.ShippingDistrict,
-			ShippingCity:     updatedOrder.ShippingCity,
-			ShippingCountry:  updatedOrder.ShippingCountry,
-			PaymentMethod:    string(updatedOrder.PaymentMethod),
-			Status:           string(updatedOrder.OrderStatus),
-			Notes:            updatedOrder.Notes,
-			Total:            updatedOrder.TotalAmount,
-			DiscountAmount:   updatedOrder.DiscountAmount,
-			DiscountReason:   updatedOrder.DiscountReason,
-			FinalTotal:       updatedOrder.FinalTotalAmount,
-			CreatedBy:        *updatedOrder.CreatedBy,
-			CreatedByName:    creatorName,
-			Items:            items,
-			Shipment:         shipmentResponse,
-			CreatedAt:        updatedOrder.CreatedAt,
-			UpdatedAt:        updatedOrder.UpdatedAt,
-		},
+		Data:    h.buildOrderDetail(updatedOrder),
 	})
 }
 
-// UpdateShipment godoc
-// @Summary Update shipment details
-// @Description Update the shipment details of an order. Admins can update any order's shipment. Agents can only update shipments for orders with status 'pending_confirmation', 'confirmed', or 'shipment_requested'.
+// RepriceOrder godoc
+// @Summary Reprice an order to current prices
+// @Description Update the price of every item of a shipment_requested order to the current product price and recompute the order totals
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param id path string true "Order ID"
-// @Param shipment body requests.UpdateShipmentRequest true "Shipment details"
 // @Success 200 {object} responses.OrderResponse
 // @Failure 400 {object} responses.ErrorResponse
-// @Failure 401 {object} responses.ErrorResponse
-// @Failure 403 {object} responses.ErrorResponse
 // @Failure 404 {object} responses.ErrorResponse
 // @Failure 500 {object} responses.ErrorResponse
-// @Router /api/orders/{id}/shipment [put]
+// @Router /api/orders/{id}/reprice [post]
 // @Security ApiKeyAuth
-func (h *OrderHandler) UpdateShipment(c *fiber.Ctx) error {
-	// Get user roles from context (set by auth middleware)
-	_, ok := c.Locals("userID").(uuid.UUID)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
-			Success: false,
-			Message: "Unauthorized",
-			Error:   "Invalid user ID",
-		})
-	}
-
-	userRoles, ok := c.Locals("roles").([]string)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
-			Success: false,
-			Message: "Unauthorized",
-			Error:   "Invalid user roles",
-		})
-	}
-
+func (h *OrderHandler) RepriceOrder(c *fiber.Ctx) error {
 	// Parse order ID
 	idStr := c.Params("id")
 	id, err := uuid.Parse(idStr)
@@ -1502,49 +1960,59 @@ func (h *OrderHandler) UpdateShipment(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get current order to check status
-	currentOrder, err := h.orderService.GetOrderByID(id)
-	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(responses.ErrorResponse{
+	// Reprice the order
+	if _, err := h.orderService.RepriceOrder(id); err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Order not found",
+			Message: "Failed to reprice order",
 			Error:   err.Error(),
 		})
 	}
 
-	// Check if user is an admin
-	isAdmin := false
-	for _, role := range userRoles {
-		if role == "admin" {
-			isAdmin = true
-			break
-		}
+	// Get the updated order to return complete information
+	updatedOrder, err := h.orderService.GetOrderByID(id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve updated order",
+			Error:   err.Error(),
+		})
 	}
 
-	// For non-admin users, check if the order is in an allowed status for agents
-	if !isAdmin {
-		currentStatus := string(currentOrder.OrderStatus)
-		// Check if current status is one of the allowed statuses for agents
-		allowedStatuses := []string{"pending_confirmation", "confirmed", "shipment_requested"}
-		isAllowed := false
-		for _, status := range allowedStatuses {
-			if currentStatus == status {
-				isAllowed = true
-				break
-			}
-		}
+	return c.Status(fiber.StatusOK).JSON(responses.OrderResponse{
+		Success: true,
+		Message: "Order repriced successfully",
+		Data:    h.buildOrderDetail(updatedOrder),
+	})
+}
 
-		if !isAllowed {
-			return c.Status(fiber.StatusForbidden).JSON(responses.ErrorResponse{
-				Success: false,
-				Message: "Permission denied",
-				Error:   "Agents can only update shipments for orders with status: pending_confirmation, confirmed, or shipment_requested",
-			})
-		}
+// ReturnOrderItems godoc
+// @Summary Return some units of an order's items
+// @Description Return some units of the items of a delivered or return_processing order to inventory. An item can be returned until all its units have been returned; returned units are not released again when the order is marked as returned.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param request body requests.ReturnOrderItemsRequest true "Returned items"
+// @Success 200 {object} responses.OrderResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/orders/{id}/returns [post]
+// @Security ApiKeyAuth
+func (h *OrderHandler) ReturnOrderItems(c *fiber.Ctx) error {
+	// Parse order ID
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid order ID format",
+			Error:   err.Error(),
+		})
 	}
 
-	// Parse request
-	var req requests.UpdateShipmentRequest
+	var req requests.ReturnOrderItemsRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
 			Success: false,
@@ -1553,7 +2021,6 @@ func (h *OrderHandler) UpdateShipment(c *fiber.Ctx) error {
 		})
 	}
 
-	// Validate request
 	if err := req.Validate(); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
 			Success: false,
@@ -1562,17 +2029,24 @@ func (h *OrderHandler) UpdateShipment(c *fiber.Ctx) error {
 		})
 	}
 
-	// Update shipment details
-	err = h.orderService.UpdateShipment(id, req.TrackingNumber, req.Carrier)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+	returns := make([]services.ItemReturn, len(req.Items))
+	for i, item := range req.Items {
+		returns[i] = services.ItemReturn{
+			OrderItemID: item.OrderItemID,
+			Quantity:    item.Quantity,
+		}
+	}
+
+	// Return the items
+	if _, err := h.orderService.ReturnOrderItems(id, returns); err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Failed to update shipment details",
+			Message: "Failed to return order items",
 			Error:   err.Error(),
 		})
 	}
 
-	// Get updated order to return in response
+	// Get the updated order to return complete information
 	updatedOrder, err := h.orderService.GetOrderByID(id)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
@@ -1582,99 +2056,29 @@ func (h *OrderHandler) UpdateShipment(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get creator information if available
-	var creatorName string
-	if updatedOrder.CreatedBy != nil {
-		// Get user information
-		user, err := h.orderService.UserService.GetUserByID(*updatedOrder.CreatedBy)
-		if err == nil {
-			creatorName = user.Username
-		}
-	}
-
-	// Convert items to response format
-	items := make([]responses.OrderItemResponse, len(updatedOrder.Items))
-	for i, item := range updatedOrder.Items {
-		items[i] = responses.OrderItemResponse{
-			ID:          item.ID,
-			OrderID:     item.OrderID,
-			InventoryID: item.InventoryID,
-			Quantity:    item.Quantity,
-			Price:       item.PriceAtOrder,
-			Subtotal:    item.PriceAtOrder * float64(item.Quantity),
-			CreatedAt:   item.CreatedAt,
-			UpdatedAt:   item.UpdatedAt,
-		}
-
-		// Get inventory details if available
-		inventory, err := h.orderService.ProductService.GetInventoryByID(item.InventoryID)
-		if err == nil && inventory != nil {
-			// Add inventory details
-			items[i].Size = inventory.Size
-			items[i].Color = inventory.Color
-
-			// Get product details if available
-			product, err := h.orderService.ProductService.GetProductByID(inventory.ProductID)
-			if err == nil && product != nil {
-				items[i].ProductID = product.ID
-				items[i].ProductName = product.Name
-
-				// Get price details if available
-				price, err := h.orderService.ProductService.GetCurrentPrice(product.ID)
-				if err == nil && price != nil {
-					items[i].PriceID = price.ID
-					items[i].Currency = price.Currency
-				}
-			}
-		}
-	}
-
-	// Create shipment response if available
-	var shipmentResponse *responses.ShipmentResponse
-	if updatedOrder.Shipment != nil {
-		shipmentResponse = &responses.ShipmentResponse{
-			ID:             updatedOrder.Shipment.ID,
-			OrderID:        updatedOrder.Shipment.OrderID,
-			TrackingNumber: updatedOrder.Shipment.TrackingNumber,
-			Carrier:        updatedOrder.Shipment.Carrier,
-			CreatedAt:      updatedOrder.Shipment.CreatedAt,
-			UpdatedAt:      updatedOrder.Shipment.UpdatedAt,
-		}
-	}
-
-	// Return response with complete order information
 	return c.Status(fiber.StatusOK).JSON(responses.OrderResponse{
 		Success: true,
-		Message: "Shipment details updated successfully",
-		Data: responses.OrderDetail{
-			ID:               updatedOrder.ID,
-			CustomerName:     updatedOrder.CustomerName,
-			CustomerEmail:    updatedOrder.CustomerEmail,
-			CustomerPhone:    updatedOrder.CustomerPhone,
-			ShippingAddress:  updatedOrder.ShippingAddress,
-			ShippingWard:     updatedOrder.ShippingWard,
-			ShippingDistrict: updatedOrder.ShippingDistrict,
-			ShippingCity:     updatedOrder.ShippingCity,
-			ShippingCountry:  updatedOrder.ShippingCountry,
-			PaymentMethod:    string(updatedOrder.PaymentMethod),
-			Status:           string(updatedOrder.OrderStatus),
-			Notes:            updatedOrder.Notes,
-			Total:            updatedOrder.TotalAmount,
-			DiscountAmount:   updatedOrder.DiscountAmount,
-			DiscountReason:   updatedOrder.DiscountReason,
-			FinalTotal:       updatedOrder.FinalTotalAmount,
-			CreatedBy:        *updatedOrder.CreatedBy,
-			CreatedByName:    creatorName,
-			Items:            items,
-			Shipment:         shipmentResponse,
-			CreatedAt:        updatedOrder.CreatedAt,
-			UpdatedAt:        updatedOrder.UpdatedAt,
-		},
+		Message: "Order items returned successfully",
+		Data:    h.buildOrderDetail(updatedOrder),
 	})
 }
 
-// DebugOrder is a debug endpoint to check if an order exists
-func (h *OrderHandler) DebugOrder(c *fiber.Ctx) error {
+// ApplyPromoCode godoc
+// @Summary Apply a promo code to an order
+// @Description Apply a promo code to a shipment_requested order, replacing its discount. The code must be active, below its usage limit and the order total must reach its minimum order amount. An order can only use one promo code.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param request body requests.ApplyPromoCodeRequest true "Promo code"
+// @Success 200 {object} responses.OrderResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/orders/{id}/promo [post]
+// @Security ApiKeyAuth
+func (h *OrderHandler) ApplyPromoCode(c *fiber.Ctx) error {
 	// Parse order ID
 	idStr := c.Params("id")
 	id, err := uuid.Parse(idStr)
@@ -1686,63 +2090,123 @@ func (h *OrderHandler) DebugOrder(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get order
-	o, err := h.orderService.GetOrderByID(id)
+	var req requests.ApplyPromoCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	// Apply the promo code
+	if _, err := h.orderService.ApplyPromoCode(id, req.Code); err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to apply promo code",
+			Error:   err.Error(),
+		})
+	}
+
+	// Get the updated order to return complete information
+	updatedOrder, err := h.orderService.GetOrderByID(id)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(responses.ErrorResponse{
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Order not found",
+			Message: "Failed to retrieve updated order",
 			Error:   err.Error(),
 		})
 	}
 
-	// Return response
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"success": true,
-		"message": "Order found",
-		"data": fiber.Map{
-			"id":           o.ID,
-			"created_by":   o.CreatedBy,
-			"order_status": o.OrderStatus,
-			"created_at":   o.CreatedAt,
-		},
+	return c.Status(fiber.StatusOK).JSON(responses.OrderResponse{
+		Success: true,
+		Message: "Promo code applied successfully",
+		Data:    h.buildOrderDetail(updatedOrder),
 	})
 }
 
-// GetOrderByTrackingNumber godoc
-// @Summary Get order by tracking number
-// @Description Get a specific order by its shipment tracking number
+// PreviewDiscount godoc
+// @Summary Preview an order discount
+// @Description Price the given items at their current prices and apply an order-level discount without creating an order. The discount is split across the items in proportion to their totals and may not exceed the order total.
 // @Tags orders
 // @Accept json
 // @Produce json
-// @Param number path string true "Tracking Number"
-// @Success 200 {object} responses.OrderDetailResponse
+// @Param request body requests.PreviewDiscountRequest true "Cart items and discount"
+// @Success 200 {object} responses.DiscountPreviewResponse
 // @Failure 400 {object} responses.ErrorResponse
 // @Failure 404 {object} responses.ErrorResponse
 // @Failure 500 {object} responses.ErrorResponse
-// @Router /api/orders/tracking/{number} [get]
+// @Router /api/orders/preview-discount [post]
 // @Security ApiKeyAuth
-func (h *OrderHandler) GetOrderByTrackingNumber(c *fiber.Ctx) error {
-	// Get tracking number from params
-	trackingNumber := c.Params("number")
-	if trackingNumber == "" {
+func (h *OrderHandler) PreviewDiscount(c *fiber.Ctx) error {
+	var req requests.PreviewDiscountRequest
+	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Tracking number is required",
-			Error:   "Missing tracking number",
+			Message: "Invalid request",
+			Error:   err.Error(),
 		})
 	}
 
-	// Get order by tracking number
-	o, err := h.orderService.GetOrderByTrackingNumber(trackingNumber)
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+	}
+
+	items := make([]services.OrderItemInfo, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = services.OrderItemInfo{
+			InventoryID: item.InventoryID,
+			Quantity:    item.Quantity,
+		}
+	}
+
+	pricing, err := h.orderService.PreviewOrderDiscount(items, req.DiscountAmount, req.DiscountPercent, req.DiscountReason)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(responses.ErrorResponse{
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Order not found",
+			Message: "Failed to preview discount",
 			Error:   err.Error(),
 		})
 	}
 
+	previewItems := make([]responses.DiscountPreviewItem, len(pricing.Lines))
+	for i, line := range pricing.Lines {
+		previewItems[i] = responses.DiscountPreviewItem{
+			InventoryID:   line.InventoryID.String(),
+			ProductID:     line.ProductID.String(),
+			ProductName:   line.ProductName,
+			ProductSKU:    line.SKU,
+			Quantity:      line.Quantity,
+			UnitPrice:     line.UnitPrice,
+			LineTotal:     line.LineTotal,
+			DiscountShare: line.DiscountShare,
+			FinalTotal:    line.FinalTotal,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.DiscountPreviewResponse{
+		Success: true,
+		Message: "Discount preview calculated successfully",
+		Data: responses.DiscountPreview{
+			Items:            previewItems,
+			Subtotal:         pricing.Subtotal,
+			DiscountAmount:   pricing.DiscountAmount,
+			DiscountReason:   pricing.DiscountReason,
+			DiscountPercent:  pricing.DiscountPercent,
+			TaxAmount:        pricing.TaxAmount,
+			FinalTotal:       pricing.FinalTotal,
+			RequiresApproval: pricing.RequiresApproval,
+		},
+	})
+}
+
+// buildOrderDetail converts an order with its items and shipment into the detailed order response,
+// resolving the creator name and the product details of each item
+func (h *OrderHandler) buildOrderDetail(o *order.Order) responses.OrderDetail {
 	// Get creator information if available
 	var creatorName string
 	if o.CreatedBy != nil {
@@ -1757,25 +2221,29 @@ func (h *OrderHandler) GetOrderByTrackingNumber(c *fiber.Ctx) error {
 	items := make([]responses.OrderItemResponse, len(o.Items))
 	for i, item := range o.Items {
 		items[i] = responses.OrderItemResponse{
-			ID:          item.ID,
-			OrderID:     item.OrderID,
-			InventoryID: item.InventoryID,
-			Quantity:    item.Quantity,
-			Price:       item.PriceAtOrder,
-			Subtotal:    item.PriceAtOrder * float64(item.Quantity),
-			CreatedAt:   item.CreatedAt,
-			UpdatedAt:   item.UpdatedAt,
+			ID:               item.ID,
+			OrderID:          item.OrderID,
+			InventoryID:      item.InventoryID,
+			Quantity:         item.Quantity,
+			ReturnedQuantity: item.ReturnedQuantity,
+			Price:            item.PriceAtOrder,
+			Currency:         item.Currency,
+			OriginalPrice:    item.OriginalPrice,
+			OriginalCurrency: item.OriginalCurrency,
+			Subtotal:         item.PriceAtOrder * float64(item.Quantity),
+			CreatedAt:        item.CreatedAt,
+			UpdatedAt:        item.UpdatedAt,
 		}
 
 		// Get inventory details if available
-		inventory, err := h.orderService.ProductService.GetInventoryByID(item.InventoryID)
+		inventory, err := h.orderService.ProductService.GetInventoryIncludingDeleted(item.InventoryID)
 		if err == nil && inventory != nil {
 			// Add inventory details
 			items[i].Size = inventory.Size
 			items[i].Color = inventory.Color
 
 			// Get product details if available
-			product, err := h.orderService.ProductService.GetProductByID(inventory.ProductID)
+			product, err := h.orderService.ProductService.GetProductIncludingDeleted(inventory.ProductID)
 			if err == nil && product != nil {
 				items[i].ProductID = product.ID
 				items[i].ProductName = product.Name
@@ -1784,277 +2252,1500 @@ func (h *OrderHandler) GetOrderByTrackingNumber(c *fiber.Ctx) error {
 				price, err := h.orderService.ProductService.GetCurrentPrice(product.ID)
 				if err == nil && price != nil {
 					items[i].PriceID = price.ID
-					items[i].Currency = price.Currency
+					if items[i].Currency == "" {
+						items[i].Currency = price.Currency
+					}
 				}
 			}
 		}
 	}
 
-	// Create shipment response if available
-	var shipmentResponse *responses.ShipmentResponse
-	if o.Shipment != nil {
-		shipmentResponse = &responses.ShipmentResponse{
-			ID:             o.Shipment.ID,
-			OrderID:        o.Shipment.OrderID,
-			TrackingNumber: o.Shipment.TrackingNumber,
-			Carrier:        o.Shipment.Carrier,
-			CreatedAt:      o.Shipment.CreatedAt,
-			UpdatedAt:      o.Shipment.UpdatedAt,
-		}
+	detail := responses.OrderDetail{
+		ID:               o.ID,
+		OrderNumber:      o.OrderNumber,
+		CustomerName:     o.CustomerName,
+		CustomerEmail:    o.CustomerEmail,
+		CustomerPhone:    o.CustomerPhone,
+		ShippingAddress:  o.ShippingAddress,
+		ShippingWard:     o.ShippingWard,
+		ShippingDistrict: o.ShippingDistrict,
+		ShippingCity:     o.ShippingCity,
+		ShippingCountry:  o.ShippingCountry,
+		PaymentMethod:    string(o.PaymentMethod),
+		Currency:         o.Currency,
+		Status:           string(o.OrderStatus),
+		Notes:            o.Notes,
+		Total:            o.TotalAmount,
+		DiscountAmount:   o.DiscountAmount,
+		DiscountReason:   o.DiscountReason,
+		DiscountPercent:  o.DiscountPercent,
+		PromoCode:        o.PromoCode,
+		FinalTotal:       o.FinalTotalAmount,
+		CreatedByName:    creatorName,
+		Items:            items,
+		CustomerID:       o.CustomerID,
+		DeliveryProof:    responses.ConvertToDeliveryProofResponse(*o),
+		CreatedAt:        o.CreatedAt,
+		UpdatedAt:        o.UpdatedAt,
+	}
+	if o.CreatedBy != nil {
+		detail.CreatedBy = *o.CreatedBy
 	}
+	detail.SetShipments(o.Shipments)
+	h.formatAmounts(&detail)
 
-	// Return response with order details
-	return c.Status(fiber.StatusOK).JSON(responses.OrderDetailResponse{
-		Success: true,
-		Message: "Order found",
-		Data: responses.OrderDetail{
-			ID:               o.ID,
-			CustomerName:     o.CustomerName,
-			CustomerEmail:    o.CustomerEmail,
-			CustomerPhone:    o.CustomerPhone,
-			ShippingAddress:  o.ShippingAddress,
-			ShippingWard:     o.ShippingWard,
-			ShippingDistrict: o.ShippingDistrict,
-			ShippingCity:     o.ShippingCity,
-			ShippingCountry:  o.ShippingCountry,
-			PaymentMethod:    string(o.PaymentMethod),
-			Status:           string(o.OrderStatus),
-			Notes:            o.Notes,
-			Total:            o.TotalAmount,
-			DiscountAmount:   o.DiscountAmount,
-			DiscountReason:   o.DiscountReason,
-			FinalTotal:       o.FinalTotalAmount,
-			CreatedBy:        *o.CreatedBy,
-			CreatedByName:    creatorName,
-			Items:            items,
-			Shipment:         shipmentResponse,
-			CreatedAt:        o.CreatedAt,
-			UpdatedAt:        o.UpdatedAt,
-		},
-	})
+	return detail
 }
 
-// GetOrdersByPhoneNumber godoc
-// @Summary Get orders by phone number
-// @Description Get a list of all orders with a specific customer phone number
+// CreateShipment godoc
+// @Summary Add a shipment to an order
+// @Description Split an order into several shipments, e.g. when its items ship from different warehouses. The new shipment carries the given order items, which must not be assigned to another shipment. Without item IDs it carries every unassigned item. Shipments can only be added while the order is 'shipment_requested' or 'packed'.
 // @Tags orders
 // @Accept json
 // @Produce json
-// @Param phone path string true "Customer phone number"
-// @Param page query int false "Page number"
-// @Param page_size query int false "Page size"
-// @Param status query string false "Filter by status"
-// @Param from_date query string false "Filter by start date (YYYY-MM-DD)"
-// @Param to_date query string false "Filter by end date (YYYY-MM-DD)"
-// @Success 200 {object} responses.OrdersResponse
+// @Param id path string true "Order ID"
+// @Param shipment body requests.CreateShipmentRequest true "Shipment and its items"
+// @Success 201 {object} responses.OrderResponse
 // @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
 // @Failure 500 {object} responses.ErrorResponse
-// @Router /api/orders/phone/{phone} [get]
+// @Router /api/orders/{id}/shipments [post]
 // @Security ApiKeyAuth
-func (h *OrderHandler) GetOrdersByPhoneNumber(c *fiber.Ctx) error {
-	// Get phone number from path parameter
-	phoneNumber := c.Params("phone")
-	if phoneNumber == "" {
+func (h *OrderHandler) CreateShipment(c *fiber.Ctx) error {
+	// Parse order ID
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Phone number is required",
-			Error:   "Missing phone number",
+			Message: "Invalid order ID format",
+			Error:   err.Error(),
 		})
 	}
 
-	// Parse pagination parameters
-	page, err := strconv.Atoi(c.Query("page", "1"))
-	if err != nil || page < 1 {
-		page = 1
+	// Parse request
+	var req requests.CreateShipmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
 	}
 
-	pageSize, err := strconv.Atoi(c.Query("page_size", "10"))
-	if err != nil || pageSize < 1 {
-		pageSize = 10
+	// Create the shipment
+	if _, err := h.orderService.CreateShipment(id, req.TrackingNumber, req.Carrier, req.ItemIDs); err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to create shipment",
+			Error:   err.Error(),
+		})
 	}
 
-	// Initialize additional filters
-	additionalFilters := make(map[string]interface{})
-
-	// Apply status filter if provided
-	if status := c.Query("status"); status != "" {
-		additionalFilters["order_status"] = status
+	// Get the updated order to return all of its shipments
+	updatedOrder, err := h.orderService.GetOrderByID(id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve updated order",
+			Error:   err.Error(),
+		})
 	}
 
-	// Apply from_date filter if provided
-	if fromDate := c.Query("from_date"); fromDate != "" {
-		// Parse date in format YYYY-MM-DD
-		date, err := time.Parse("2006-01-02", fromDate)
-		if err == nil {
-			// Set time to beginning of day
-			date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-			additionalFilters["from_date"] = date
-		}
+	return c.Status(fiber.StatusCreated).JSON(responses.OrderResponse{
+		Success: true,
+		Message: "Shipment created successfully",
+		Data:    h.buildOrderDetail(updatedOrder),
+	})
+}
+
+// UpdateShipment godoc
+// @Summary Update shipment details
+// @Description Update the shipment details of an order, including package weight (grams), dimensions (cm), shipping cost and COD amount. Without a shipment ID the primary shipment of the order is updated. Shipments can only be changed while the order is 'shipment_requested' or 'packed'. Admins can update any order's shipment. Agents can only update shipments for orders with status 'pending_confirmation', 'confirmed', or 'shipment_requested'.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param shipment_id path string false "Shipment ID"
+// @Param shipment body requests.UpdateShipmentRequest true "Shipment details"
+// @Success 200 {object} responses.OrderResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 401 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/orders/{id}/shipment [put]
+// @Router /api/orders/{id}/shipments/{shipment_id} [put]
+// @Security ApiKeyAuth
+func (h *OrderHandler) UpdateShipment(c *fiber.Ctx) error {
+	// Get user roles from context (set by auth middleware)
+	_, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Unauthorized",
+			Error:   "Invalid user ID",
+		})
 	}
 
-	// Apply to_date filter if provided
-	if toDate := c.Query("to_date"); toDate != "" {
-		// Parse date in format YYYY-MM-DD
-		date, err := time.Parse("2006-01-02", toDate)
-		if err == nil {
-			// Set time to end of day
-			date = time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 999999999, date.Location())
-			additionalFilters["to_date"] = date
-		}
+	if _, ok := c.Locals("roles").([]string); !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Unauthorized",
+			Error:   "Invalid user roles",
+		})
 	}
 
-	// Get orders by phone number using dedicated method
-	orders, total, err := h.orderService.GetOrdersByPhoneNumber(phoneNumber, page, pageSize, additionalFilters)
+	// Parse order ID
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Failed to get orders",
+			Message: "Invalid order ID format",
 			Error:   err.Error(),
 		})
 	}
 
-	// Format response data
-	var orderList []responses.OrderDetail
+	// Parse shipment ID; the primary shipment is updated when it is omitted
+	var shipmentID uuid.UUID
+	if shipmentIDStr := c.Params("shipment_id"); shipmentIDStr != "" {
+		shipmentID, err = uuid.Parse(shipmentIDStr)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+				Success: false,
+				Message: "Invalid shipment ID format",
+				Error:   err.Error(),
+			})
+		}
+	}
+
+	// Get current order to check status
+	currentOrder, err := h.orderService.GetOrderByID(id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Order not found",
+			Error:   err.Error(),
+		})
+	}
 
-	// Create user info cache to avoid repeated database queries
-	userCache := make(map[uuid.UUID]string)
+	// Check if the user may edit orders in any status
+	canUpdateAny := hasPermission(c, account.PermissionOrderUpdate)
 
-	for _, o := range orders {
-		// Get creator name if available
-		var creatorName string
-		if o.CreatedBy != nil {
-			// Check if we already have this user in cache
-			if name, found := userCache[*o.CreatedBy]; found {
-				creatorName = name
-			} else {
-				// If not in cache, fetch from database
-				user, err := h.orderService.UserService.GetUserByID(*o.CreatedBy)
-				if err == nil {
-					creatorName = user.Username
-					// Add to cache
-					userCache[*o.CreatedBy] = creatorName
-				}
+	// Otherwise, check if the order is in an allowed status for agents
+	if !canUpdateAny {
+		currentStatus := string(currentOrder.OrderStatus)
+		// Check if current status is one of the allowed statuses for agents
+		allowedStatuses := []string{"pending_confirmation", "confirmed", "shipment_requested"}
+		isAllowed := false
+		for _, status := range allowedStatuses {
+			if currentStatus == status {
+				isAllowed = true
+				break
 			}
 		}
 
-		// Create basic order detail
-		orderDetail := responses.OrderDetail{
-			ID:               o.ID,
-			CustomerName:     o.CustomerName,
-			CustomerEmail:    o.CustomerEmail,
-			CustomerPhone:    o.CustomerPhone,
-			ShippingAddress:  o.ShippingAddress,
-			ShippingWard:     o.ShippingWard,
-			ShippingDistrict: o.ShippingDistrict,
-			ShippingCity:     o.ShippingCity,
-			ShippingCountry:  o.ShippingCountry,
-			PaymentMethod:    string(o.PaymentMethod),
-			Status:           string(o.OrderStatus),
-			Notes:            o.Notes,
-			Total:            o.TotalAmount,
-			DiscountAmount:   o.DiscountAmount,
-			DiscountReason:   o.DiscountReason,
-			FinalTotal:       o.FinalTotalAmount,
-			CreatedAt:        o.CreatedAt,
-			UpdatedAt:        o.UpdatedAt,
-		}
-
-		// Set created by if available
-		if o.CreatedBy != nil {
-			orderDetail.CreatedBy = *o.CreatedBy
-			orderDetail.CreatedByName = creatorName
-		}
-
-		// Add shipment info if available
-		if o.Shipment != nil {
-			orderDetail.Shipment = &responses.ShipmentResponse{
-				ID:             o.Shipment.ID,
-				OrderID:        o.Shipment.OrderID,
-				TrackingNumber: o.Shipment.TrackingNumber,
-				Carrier:        o.Shipment.Carrier,
-				CreatedAt:      o.Shipment.CreatedAt,
-				UpdatedAt:      o.Shipment.UpdatedAt,
-			}
+		if !isAllowed {
+			return c.Status(fiber.StatusForbidden).JSON(responses.ErrorResponse{
+				Success: false,
+				Message: "Permission denied",
+				Error:   "Agents can only update shipments for orders with status: pending_confirmation, confirmed, or shipment_requested",
+			})
 		}
+	}
 
-		// Add items if available
-		items := make([]responses.OrderItemResponse, len(o.Items))
-		for i, item := range o.Items {
-			// Create basic item
-			items[i] = responses.OrderItemResponse{
-				ID:          item.ID,
-				OrderID:     item.OrderID,
-				InventoryID: item.InventoryID,
-				Quantity:    item.Quantity,
-				Price:       item.PriceAtOrder,
-				Subtotal:    item.PriceAtOrder * float64(item.Quantity),
-				CreatedAt:   item.CreatedAt,
-				UpdatedAt:   item.UpdatedAt,
-			}
+	// Parse request
+	var req requests.UpdateShipmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+	}
+
+	// Update shipment details
+	err = h.orderService.UpdateShipment(id, services.ShipmentDetails{
+		ShipmentID:     shipmentID,
+		TrackingNumber: req.TrackingNumber,
+		Carrier:        req.Carrier,
+		Weight:         req.Weight,
+		Length:         req.Length,
+		Width:          req.Width,
+		Height:         req.Height,
+		ShippingCost:   req.ShippingCost,
+		CODAmount:      req.CODAmount,
+	})
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to update shipment details",
+			Error:   err.Error(),
+		})
+	}
+
+	// Get updated order to return in response
+	updatedOrder, err := h.orderService.GetOrderByID(id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve updated order",
+			Error:   err.Error(),
+		})
+	}
+
+	// Get creator information if available
+	var creatorName string
+	if updatedOrder.CreatedBy != nil {
+		// Get user information
+		user, err := h.orderService.UserService.GetUserByID(*updatedOrder.CreatedBy)
+		if err == nil {
+			creatorName = user.Username
+		}
+	}
+
+	// Convert items to response format
+	items := make([]responses.OrderItemResponse, len(updatedOrder.Items))
+	for i, item := range updatedOrder.Items {
+		items[i] = responses.OrderItemResponse{
+			ID:               item.ID,
+			OrderID:          item.OrderID,
+			InventoryID:      item.InventoryID,
+			Quantity:         item.Quantity,
+			ReturnedQuantity: item.ReturnedQuantity,
+			Price:            item.PriceAtOrder,
+			Currency:         item.Currency,
+			OriginalPrice:    item.OriginalPrice,
+			OriginalCurrency: item.OriginalCurrency,
+			Subtotal:         item.PriceAtOrder * float64(item.Quantity),
+			CreatedAt:        item.CreatedAt,
+			UpdatedAt:        item.UpdatedAt,
+		}
+
+		// Get inventory details if available
+		inventory, err := h.orderService.ProductService.GetInventoryIncludingDeleted(item.InventoryID)
+		if err == nil && inventory != nil {
+			// Add inventory details
+			items[i].Size = inventory.Size
+			items[i].Color = inventory.Color
+
+			// Get product details if available
+			product, err := h.orderService.ProductService.GetProductIncludingDeleted(inventory.ProductID)
+			if err == nil && product != nil {
+				items[i].ProductID = product.ID
+				items[i].ProductName = product.Name
 
-			// Get inventory details if needed
-			inventory, err := h.orderService.ProductService.GetInventoryByID(item.InventoryID)
-			if err == nil && inventory != nil {
-				// Add inventory details
-				items[i].Size = inventory.Size
-				items[i].Color = inventory.Color
-
-				// Get product details if available
-				product, err := h.orderService.ProductService.GetProductByID(inventory.ProductID)
-				if err == nil && product != nil {
-					items[i].ProductID = product.ID
-					items[i].ProductName = product.Name
-
-					// Get price details if available
-					price, err := h.orderService.ProductService.GetCurrentPrice(product.ID)
-					if err == nil && price != nil {
-						items[i].PriceID = price.ID
+				// Get price details if available
+				price, err := h.orderService.ProductService.GetCurrentPrice(product.ID)
+				if err == nil && price != nil {
+					items[i].PriceID = price.ID
+					if items[i].Currency == "" {
 						items[i].Currency = price.Currency
 					}
 				}
 			}
 		}
+	}
+
+	detail := responses.OrderDetail{
+		ID:               updatedOrder.ID,
+		OrderNumber:      updatedOrder.OrderNumber,
+		CustomerName:     updatedOrder.CustomerName,
+		CustomerEmail:    updatedOrder.CustomerEmail,
+		CustomerPhone:    updatedOrder.CustomerPhone,
+		ShippingAddress:  updatedOrder.ShippingAddress,
+		ShippingWard:     updatedOrder.ShippingWard,
+		ShippingDistrict: updatedOrder.ShippingDistrict,
+		ShippingCity:     updatedOrder.ShippingCity,
+		ShippingCountry:  updatedOrder.ShippingCountry,
+		PaymentMethod:    string(updatedOrder.PaymentMethod),
+		Currency:         updatedOrder.Currency,
+		Status:           string(updatedOrder.OrderStatus),
+		Notes:            updatedOrder.Notes,
+		Total:            updatedOrder.TotalAmount,
+		DiscountAmount:   updatedOrder.DiscountAmount,
+		DiscountReason:   updatedOrder.DiscountReason,
+		DiscountPercent:  updatedOrder.DiscountPercent,
+		PromoCode:        updatedOrder.PromoCode,
+		FinalTotal:       updatedOrder.FinalTotalAmount,
+		CreatedBy:        *updatedOrder.CreatedBy,
+		CreatedByName:    creatorName,
+		Items:            items,
+		CustomerID:       updatedOrder.CustomerID,
+		DeliveryProof:    responses.ConvertToDeliveryProofResponse(*updatedOrder),
+		CreatedAt:        updatedOrder.CreatedAt,
+		UpdatedAt:        updatedOrder.UpdatedAt,
+	}
+	detail.SetShipments(updatedOrder.Shipments)
+	h.formatAmounts(&detail)
+
+	// Return response with complete order information
+	return c.Status(fiber.StatusOK).JSON(responses.OrderResponse{
+		Success: true,
+		Message: "Shipment details updated successfully",
+		Data:    detail,
+	})
+}
+
+// DebugOrder is a debug endpoint to check if an order exists
+func (h *OrderHandler) DebugOrder(c *fiber.Ctx) error {
+	// Parse order ID
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid order ID format",
+			Error:   err.Error(),
+		})
+	}
 
-		orderDetail.Items = items
-		orderList = append(orderList, orderDetail)
+	// Get order
+	o, err := h.orderService.GetOrderByID(id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Order not found",
+			Error:   err.Error(),
+		})
 	}
 
 	// Return response
-	return c.Status(fiber.StatusOK).JSON(responses.OrdersResponse{
-		Success:    true,
-		Message:    "Orders retrieved successfully",
-		Data:       orderList,
-		Total:      total,
-		Page:       page,
-		PageSize:   pageSize,
-		TotalPages: int64(math.Ceil(float64(total) / float64(pageSize))),
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Order found",
+		"data": fiber.Map{
+			"id":           o.ID,
+			"created_by":   o.CreatedBy,
+			"order_status": o.OrderStatus,
+			"created_at":   o.CreatedAt,
+		},
 	})
 }
 
-// ORDER STATUS WEBHOOK FOR GHN NOT USE YET
-func (h *OrderHandler) HandleGHNOrderStatusWebhook(c *fiber.Ctx) error {
-	// 1. Optional: validate GHN token via header or query
-	ghnToken := c.Get("X-GHN-Token")
-	if ghnToken != os.Getenv("GHN_WEBHOOK_SECRET") {
-		return c.SendStatus(fiber.StatusUnauthorized)
+// GetOrderTrail godoc
+// @Summary Get the activity trail of an order
+// @Description Get everything that happened to an order in chronological order: creation, status changes, notes, shipment updates and emitted notifications
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} responses.OrderTrailResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/orders/{id}/trail [get]
+// @Security ApiKeyAuth
+func (h *OrderHandler) GetOrderTrail(c *fiber.Ctx) error {
+	orderService := h.orderService.WithContext(c.UserContext())
+
+	// Parse order ID
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid order ID format",
+			Error:   err.Error(),
+		})
 	}
 
-	// 2. Parse JSON body
-	var payload GHNWebhookPayload
-	if err := c.BodyParser(&payload); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "Invalid payload",
-			"error":   err.Error(),
+	// Get trail
+	trail, err := orderService.GetOrderTrail(id)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve order trail",
+			Error:   err.Error(),
 		})
 	}
 
-	// 3. Log & process
-	fmt.Printf("GHN webhook: %+v\n", payload)
+	// Convert to response format
+	entries := make([]responses.OrderTrailEntryResponse, len(trail))
+	for i, entry := range trail {
+		entries[i] = responses.OrderTrailEntryResponse{
+			Timestamp: entry.Timestamp,
+			Source:    entry.Source,
+			Event:     entry.Event,
+			Message:   entry.Message,
+			Metadata:  entry.Metadata,
+		}
+	}
 
-	// TODO: update order by payload.OrderCode, set status = payload.Status
+	return c.Status(fiber.StatusOK).JSON(responses.OrderTrailResponse{
+		Success: true,
+		Message: "Order trail retrieved successfully",
+		Data:    entries,
+	})
+}
 
-	return c.SendStatus(fiber.StatusOK)
+// GetOrderStatusHistory godoc
+// @Summary Get the status history of an order
+// @Description Get the status changes of an order, oldest first, with who made each change and its note. Changes made by the system, e.g. from GHN webhooks, have no changed_by.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} responses.OrderStatusHistoryResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/orders/{id}/history [get]
+// @Security ApiKeyAuth
+func (h *OrderHandler) GetOrderStatusHistory(c *fiber.Ctx) error {
+	orderService := h.orderService.WithContext(c.UserContext())
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid order ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	history, err := orderService.GetOrderStatusHistory(id)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve order status history",
+			Error:   err.Error(),
+		})
+	}
+
+	data := make([]responses.OrderStatusChangeResponse, len(history))
+	for i, change := range history {
+		data[i] = responses.ConvertToOrderStatusChangeResponse(change)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.OrderStatusHistoryResponse{
+		Success: true,
+		Message: "Order status history retrieved successfully",
+		Data:    data,
+	})
+}
+
+// GetShippingLabel godoc
+// @Summary Get the shipping label data of an order
+// @Description Assemble the data needed to create a GHN shipment or print its label: the configured sender, the recipient from the order, the actual and chargeable weight, the COD amount and a summary of the contents. Fails when the sender or the recipient address is incomplete.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} responses.ShippingLabelResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/orders/{id}/label [get]
+// @Security ApiKeyAuth
+func (h *OrderHandler) GetShippingLabel(c *fiber.Ctx) error {
+	orderService := h.orderService.WithContext(c.UserContext())
+
+	// Parse order ID
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid order ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	label, err := orderService.GetShippingLabel(id)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to build shipping label",
+			Error:   err.Error(),
+		})
+	}
+
+	items := make([]responses.ShippingLabelItemResponse, len(label.Items))
+	for i, item := range label.Items {
+		items[i] = responses.ShippingLabelItemResponse{
+			InventoryID: item.InventoryID,
+			SKU:         item.SKU,
+			Name:        item.Name,
+			Size:        item.Size,
+			Color:       item.Color,
+			Quantity:    item.Quantity,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.ShippingLabelResponse{
+		Success: true,
+		Message: "Shipping label retrieved successfully",
+		Data: responses.ShippingLabelData{
+			OrderID:          label.OrderID,
+			OrderNumber:      label.OrderNumber,
+			Sender:           convertToShippingPartyResponse(label.Sender),
+			Recipient:        convertToShippingPartyResponse(label.Recipient),
+			Carrier:          label.Carrier,
+			TrackingNumber:   label.TrackingNumber,
+			Weight:           label.Weight,
+			ChargeableWeight: label.ChargeableWeight,
+			Length:           label.Length,
+			Width:            label.Width,
+			Height:           label.Height,
+			CODAmount:        label.CODAmount,
+			Items:            items,
+			TotalQuantity:    label.TotalQuantity,
+			ContentsSummary:  label.ContentsSummary,
+			Notes:            label.Notes,
+		},
+	})
+}
+
+// convertToShippingPartyResponse converts a services.ShippingParty to a ShippingPartyResponse
+func convertToShippingPartyResponse(party services.ShippingParty) responses.ShippingPartyResponse {
+	return responses.ShippingPartyResponse{
+		Name:     party.Name,
+		Phone:    party.Phone,
+		Address:  party.Address,
+		Ward:     party.Ward,
+		District: party.District,
+		City:     party.City,
+		Country:  party.Country,
+	}
+}
+
+// RecordDeliveryProof godoc
+// @Summary Record the delivery proof of an order
+// @Description Attach the signature or photo captured on delivery, the recipient name and the delivery time to an order and mark it as delivered. Orders that are already delivered only get their proof replaced. Agents can only mark orders as delivered when delivered is one of the configured agent target statuses.
+// @Tags orders
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param file formData file true "Signature or photo (supported formats: JPG, PNG, GIF)"
+// @Param recipient_name formData string true "Name of the person who received the order"
+// @Param delivered_at formData string false "Delivery time in RFC3339 format (default: now)"
+// @Success 200 {object} responses.DeliveryProofDetailResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 401 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/orders/{id}/delivery-proof [post]
+// @Security ApiKeyAuth
+func (h *OrderHandler) RecordDeliveryProof(c *fiber.Ctx) error {
+	// Get user ID from context (set by auth middleware)
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Unauthorized",
+			Error:   "Invalid user ID",
+		})
+	}
+
+	if _, ok := c.Locals("roles").([]string); !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Unauthorized",
+			Error:   "Invalid user roles",
+		})
+	}
+
+	// Parse order ID
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid order ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	// Get the file from the request
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to get file from request",
+			Error:   err.Error(),
+		})
+	}
+
+	// Parse delivered_at parameter
+	var deliveredAt time.Time
+	if value := c.FormValue("delivered_at"); value != "" {
+		deliveredAt, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+				Success: false,
+				Message: "Invalid delivered_at format",
+				Error:   "delivered_at must be in RFC3339 format",
+			})
+		}
+	}
+
+	// Check if the user may change the status of orders in any status
+	canUpdateAnyStatus := hasPermission(c, account.PermissionOrderUpdateStatus)
+
+	// Otherwise, check that agents may mark orders as delivered
+	if !canUpdateAnyStatus {
+		currentOrder, err := h.orderService.GetOrderByID(id)
+		if err != nil {
+			return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+				Success: false,
+				Message: "Failed to record delivery proof",
+				Error:   err.Error(),
+			})
+		}
+
+		if currentOrder.OrderStatus != order.OrderDelivered && !h.orderService.Settings.AgentCanSetStatus(order.OrderDelivered) {
+			return c.Status(fiber.StatusForbidden).JSON(responses.ErrorResponse{
+				Success: false,
+				Message: "Permission denied",
+				Error:   "Agents cannot mark orders as delivered",
+			})
+		}
+	}
+
+	o, err := h.orderService.RecordDeliveryProof(id, file, c.FormValue("recipient_name"), deliveredAt, userID)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to record delivery proof",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.DeliveryProofDetailResponse{
+		Success: true,
+		Message: "Delivery proof recorded successfully",
+		Data:    *responses.ConvertToDeliveryProofResponse(*o),
+	})
+}
+
+// GetDeliveryProof godoc
+// @Summary Get the delivery proof of an order
+// @Description Get the signature or photo, recipient name and delivery time recorded when the order was delivered
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} responses.DeliveryProofDetailResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/orders/{id}/delivery-proof [get]
+// @Security ApiKeyAuth
+func (h *OrderHandler) GetDeliveryProof(c *fiber.Ctx) error {
+	orderService := h.orderService.WithContext(c.UserContext())
+
+	// Parse order ID
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid order ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	o, err := orderService.GetOrderByID(id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Order not found",
+			Error:   err.Error(),
+		})
+	}
+
+	proof := responses.ConvertToDeliveryProofResponse(*o)
+	if proof == nil {
+		return c.Status(fiber.StatusNotFound).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Delivery proof not found",
+			Error:   "No delivery proof has been recorded for this order",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.DeliveryProofDetailResponse{
+		Success: true,
+		Message: "Delivery proof retrieved successfully",
+		Data:    *proof,
+	})
+}
+
+// GetInventoryAvailability godoc
+// @Summary Get the available-to-promise quantity of an inventory
+// @Description Get the raw quantity of an inventory, the quantity committed to open orders whose stock has not been reserved yet, the quantity held by active inventory holds, and the quantity still available to promise to new orders
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param inventory_id path string true "Inventory ID"
+// @Success 200 {object} responses.InventoryAvailabilityResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/orders/availability/{inventory_id} [get]
+// @Security ApiKeyAuth
+func (h *OrderHandler) GetInventoryAvailability(c *fiber.Ctx) error {
+	orderService := h.orderService.WithContext(c.UserContext())
+
+	// Parse inventory ID
+	inventoryID, err := uuid.Parse(c.Params("inventory_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid inventory ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	availability, err := orderService.GetInventoryAvailability(inventoryID)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve inventory availability",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.InventoryAvailabilityResponse{
+		Success: true,
+		Message: "Inventory availability retrieved successfully",
+		Data: responses.InventoryAvailabilityDetail{
+			InventoryID:        availability.InventoryID.String(),
+			ProductID:          availability.ProductID.String(),
+			Quantity:           availability.Quantity,
+			Committed:          availability.Committed,
+			Held:               availability.Held,
+			AvailableToPromise: availability.AvailableToPromise,
+		},
+	})
+}
+
+// GetOrderByTrackingNumber godoc
+// @Summary Get order by tracking number
+// @Description Get a specific order by its shipment tracking number
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param number path string true "Tracking Number"
+// @Success 200 {object} responses.OrderDetailResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/orders/tracking/{number} [get]
+// @Security ApiKeyAuth
+func (h *OrderHandler) GetOrderByTrackingNumber(c *fiber.Ctx) error {
+	orderService := h.orderService.WithContext(c.UserContext())
+
+	// Get tracking number from params
+	trackingNumber := c.Params("number")
+	if trackingNumber == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Tracking number is required",
+			Error:   "Missing tracking number",
+		})
+	}
+
+	// Get order by tracking number
+	o, err := orderService.GetOrderByTrackingNumber(trackingNumber)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Order not found",
+			Error:   err.Error(),
+		})
+	}
+
+	return h.respondWithOrderDetail(c, orderService, o)
+}
+
+// GetOrderByOrderNumber godoc
+// @Summary Get order by order number
+// @Description Get a specific order by its human-readable order number (e.g. YB-20240115-000123); the lookup is case-insensitive
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param number path string true "Order Number"
+// @Success 200 {object} responses.OrderDetailResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/orders/number/{number} [get]
+// @Security ApiKeyAuth
+func (h *OrderHandler) GetOrderByOrderNumber(c *fiber.Ctx) error {
+	orderService := h.orderService.WithContext(c.UserContext())
+
+	o, err := orderService.GetOrderByOrderNumber(c.Params("number"))
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Order not found",
+			Error:   err.Error(),
+		})
+	}
+
+	return h.respondWithOrderDetail(c, orderService, o)
+}
+
+// respondWithOrderDetail responds with the details of a single order, including its items and creator
+func (h *OrderHandler) respondWithOrderDetail(c *fiber.Ctx, orderService *services.OrderService, o *order.Order) error {
+	// Get creator information if available
+	var creatorName string
+	if o.CreatedBy != nil {
+		// Get user information
+		user, err := orderService.UserService.GetUserByID(*o.CreatedBy)
+		if err == nil {
+			creatorName = user.Username
+		}
+	}
+
+	// Convert items to response format
+	items := make([]responses.OrderItemResponse, len(o.Items))
+	for i, item := range o.Items {
+		items[i] = responses.OrderItemResponse{
+			ID:               item.ID,
+			OrderID:          item.OrderID,
+			InventoryID:      item.InventoryID,
+			Quantity:         item.Quantity,
+			ReturnedQuantity: item.ReturnedQuantity,
+			Price:            item.PriceAtOrder,
+			Currency:         item.Currency,
+			OriginalPrice:    item.OriginalPrice,
+			OriginalCurrency: item.OriginalCurrency,
+			Subtotal:         item.PriceAtOrder * float64(item.Quantity),
+			CreatedAt:        item.CreatedAt,
+			UpdatedAt:        item.UpdatedAt,
+		}
+
+		// Get inventory details if available
+		inventory, err := orderService.ProductService.GetInventoryIncludingDeleted(item.InventoryID)
+		if err == nil && inventory != nil {
+			// Add inventory details
+			items[i].Size = inventory.Size
+			items[i].Color = inventory.Color
+
+			// Get product details if available
+			product, err := orderService.ProductService.GetProductIncludingDeleted(inventory.ProductID)
+			if err == nil && product != nil {
+				items[i].ProductID = product.ID
+				items[i].ProductName = product.Name
+
+				// Get price details if available
+				price, err := orderService.ProductService.GetCurrentPrice(product.ID)
+				if err == nil && price != nil {
+					items[i].PriceID = price.ID
+					if items[i].Currency == "" {
+						items[i].Currency = price.Currency
+					}
+				}
+			}
+		}
+	}
+
+	detail := responses.OrderDetail{
+		ID:               o.ID,
+		OrderNumber:      o.OrderNumber,
+		CustomerName:     o.CustomerName,
+		CustomerEmail:    o.CustomerEmail,
+		CustomerPhone:    o.CustomerPhone,
+		ShippingAddress:  o.ShippingAddress,
+		ShippingWard:     o.ShippingWard,
+		ShippingDistrict: o.ShippingDistrict,
+		ShippingCity:     o.ShippingCity,
+		ShippingCountry:  o.ShippingCountry,
+		PaymentMethod:    string(o.PaymentMethod),
+		Currency:         o.Currency,
+		Status:           string(o.OrderStatus),
+		Notes:            o.Notes,
+		Total:            o.TotalAmount,
+		DiscountAmount:   o.DiscountAmount,
+		DiscountReason:   o.DiscountReason,
+		DiscountPercent:  o.DiscountPercent,
+		PromoCode:        o.PromoCode,
+		FinalTotal:       o.FinalTotalAmount,
+		CreatedBy:        *o.CreatedBy,
+		CreatedByName:    creatorName,
+		Items:            items,
+		CustomerID:       o.CustomerID,
+		DeliveryProof:    responses.ConvertToDeliveryProofResponse(*o),
+		CreatedAt:        o.CreatedAt,
+		UpdatedAt:        o.UpdatedAt,
+	}
+	detail.SetShipments(o.Shipments)
+	h.formatAmounts(&detail)
+
+	// Return response with order details
+	return c.Status(fiber.StatusOK).JSON(responses.OrderDetailResponse{
+		Success: true,
+		Message: "Order found",
+		Data:    detail,
+	})
+}
+
+// GetOrdersByPhoneNumber godoc
+// @Summary Get orders by phone number
+// @Description Get a list of all orders with a specific customer phone number
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param phone path string true "Customer phone number"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param status query string false "Filter by status"
+// @Param from_date query string false "Filter by start date (YYYY-MM-DD)"
+// @Param to_date query string false "Filter by end date (YYYY-MM-DD)"
+// @Success 200 {object} responses.OrdersResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/orders/phone/{phone} [get]
+// @Security ApiKeyAuth
+func (h *OrderHandler) GetOrdersByPhoneNumber(c *fiber.Ctx) error {
+	orderService := h.orderService.WithContext(c.UserContext())
+
+	// Get phone number from path parameter
+	phoneNumber := c.Params("phone")
+	if phoneNumber == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Phone number is required",
+			Error:   "Missing phone number",
+		})
+	}
+
+	// Parse pagination parameters
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(c.Query("page_size", "10"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10
+	}
+
+	// Initialize additional filters
+	additionalFilters := make(map[string]interface{})
+
+	// Apply status filter if provided
+	if status := c.Query("status"); status != "" {
+		additionalFilters["order_status"] = status
+	}
+
+	// Apply from_date filter if provided
+	if fromDate := c.Query("from_date"); fromDate != "" {
+		// Parse date in format YYYY-MM-DD
+		date, err := time.Parse("2006-01-02", fromDate)
+		if err == nil {
+			// Set time to beginning of day
+			date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+			additionalFilters["from_date"] = date
+		}
+	}
+
+	// Apply to_date filter if provided
+	if toDate := c.Query("to_date"); toDate != "" {
+		// Parse date in format YYYY-MM-DD
+		date, err := time.Parse("2006-01-02", toDate)
+		if err == nil {
+			// Set time to end of day
+			date = time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 999999999, date.Location())
+			additionalFilters["to_date"] = date
+		}
+	}
+
+	// Get orders by phone number with the details of their items loaded in batches
+	orders, total, err := orderService.GetOrdersByPhoneNumberWithDetails(phoneNumber, page, pageSize, additionalFilters)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to get orders",
+			Error:   err.Error(),
+		})
+	}
+
+	// Format response data
+	var orderList []responses.OrderDetail
+	for _, o := range orders {
+		orderList = append(orderList, h.buildOrderListDetail(o))
+	}
+
+	// Return response
+	return c.Status(fiber.StatusOK).JSON(responses.OrdersResponse{
+		Success:    true,
+		Message:    "Orders retrieved successfully",
+		Data:       orderList,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int64(math.Ceil(float64(total) / float64(pageSize))),
+	})
+}
+
+// TrackOrder godoc
+// @Summary Track an order
+// @Description Public endpoint for customers to track their order by order number and the phone number used for the order. Only the status, shipment tracking information and a basic timeline are returned. Rate-limited per client.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param number query string true "Order number"
+// @Param phone query string true "Customer phone number"
+// @Success 200 {object} responses.OrderTrackingResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 429 {object} responses.ErrorResponse
+// @Router /api/track [get]
+func (h *OrderHandler) TrackOrder(c *fiber.Ctx) error {
+	number := c.Query("number")
+	phone := c.Query("phone")
+	if number == "" || phone == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   "order number and phone are required",
+		})
+	}
+
+	o, err := h.orderService.TrackOrder(number, phone)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Order not found",
+			Error:   "No order matches the given order number and phone",
+		})
+	}
+
+	detail := responses.OrderTrackingDetail{
+		OrderNumber: o.OrderNumber,
+		Status:      string(o.OrderStatus),
+		Timeline: []responses.TrackingEventResponse{
+			{Status: string(order.OrderShipmentRequested), Time: o.CreatedAt},
+		},
+	}
+	if o.OrderStatus != order.OrderShipmentRequested {
+		detail.Timeline = append(detail.Timeline, responses.TrackingEventResponse{
+			Status: string(o.OrderStatus),
+			Time:   o.UpdatedAt,
+		})
+	}
+	if shipment := o.PrimaryShipment(); shipment != nil {
+		detail.TrackingNumber = shipment.TrackingNumber
+		detail.Carrier = shipment.Carrier
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.OrderTrackingResponse{
+		Success: true,
+		Message: "Order retrieved successfully",
+		Data:    detail,
+	})
+}
+
+// BulkAssignOrders godoc
+// @Summary Bulk-assign orders to an agent
+// @Description Assign several pending orders to an active agent at once (admin only). Each order is processed independently and the result of every order is returned.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param assignment body requests.BulkAssignOrdersRequest true "Order IDs and target agent"
+// @Success 200 {object} responses.BulkAssignOrdersResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 401 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/orders/bulk-assign [post]
+// @Security ApiKeyAuth
+func (h *OrderHandler) BulkAssignOrders(c *fiber.Ctx) error {
+	// Get user ID from context (set by auth middleware)
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Unauthorized",
+			Error:   "Invalid user ID",
+		})
+	}
+
+	if _, ok := c.Locals("roles").([]string); !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Unauthorized",
+			Error:   "Invalid user roles",
+		})
+	}
+
+	// Only users allowed to assign orders can distribute them among agents
+	canAssign := hasPermission(c, account.PermissionOrderAssign)
+
+	if !canAssign {
+		return c.Status(fiber.StatusForbidden).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Permission denied",
+			Error:   "Only admins can assign orders",
+		})
+	}
+
+	// Parse request
+	var req requests.BulkAssignOrdersRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+	}
+
+	// Assign orders
+	result, err := h.orderService.BulkAssignOrders(req.OrderIDs, req.AgentID, userID)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: result.Message,
+			Error:   result.Error,
+		})
+	}
+
+	// Convert per-order results
+	results := make([]responses.OrderAssignmentResponse, len(result.Results))
+	for i, r := range result.Results {
+		results[i] = responses.OrderAssignmentResponse{
+			OrderID: r.OrderID,
+			Success: r.Success,
+			Error:   r.Error,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.BulkAssignOrdersResponse{
+		Success: result.Success,
+		Message: result.Message,
+		Data: responses.BulkAssignOrdersData{
+			AgentID:  result.AgentID,
+			Assigned: result.Assigned,
+			Failed:   result.Failed,
+			Results:  results,
+		},
+	})
+}
+
+// BulkUpdateOrderStatus godoc
+// @Summary Bulk-update the status of orders
+// @Description Move the given orders to the target status. Each order goes through the same transition and role checks as a single status update, including its inventory changes, and is recorded in its status history. The result of every order is returned with the failure reason (not_found, invalid_transition, permission_denied or failed) so partial failures are visible.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param request body requests.BulkStatusRequest true "Order IDs and target status"
+// @Success 200 {object} responses.BulkStatusResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 401 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/orders/bulk-status [post]
+// @Security ApiKeyAuth
+func (h *OrderHandler) BulkUpdateOrderStatus(c *fiber.Ctx) error {
+	// Get user ID from context (set by auth middleware)
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Unauthorized",
+			Error:   "Invalid user ID",
+		})
+	}
+
+	if _, ok := c.Locals("roles").([]string); !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Unauthorized",
+			Error:   "Invalid user roles",
+		})
+	}
+
+	// Check if the user may change the status of orders in any status
+	canUpdateAnyStatus := hasPermission(c, account.PermissionOrderUpdateStatus)
+
+	// Parse request
+	var req requests.BulkStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+	}
+
+	// Update the orders
+	change := services.StatusChange{ChangedBy: &userID, Note: req.Note}
+	result, err := h.orderService.BulkUpdateOrderStatus(req.OrderIDs, order.OrderStatus(req.Status), !canUpdateAnyStatus, change)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: result.Message,
+			Error:   result.Error,
+		})
+	}
+
+	// Convert per-order results
+	results := make([]responses.OrderStatusUpdateResponse, len(result.Results))
+	for i, r := range result.Results {
+		results[i] = responses.OrderStatusUpdateResponse{
+			OrderID:        r.OrderID,
+			PreviousStatus: string(r.PreviousStatus),
+			Success:        r.Success,
+			Error:          r.Error,
+			Reason:         r.Reason,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.BulkStatusResponse{
+		Success: result.Success,
+		Message: result.Message,
+		Data: responses.BulkStatusData{
+			TargetStatus: string(result.TargetStatus),
+			Updated:      result.Updated,
+			Failed:       result.Failed,
+			Results:      results,
+		},
+	})
+}
+
+// BulkUpdateOrderStatusByFilter godoc
+// @Summary Bulk-update the status of orders matching a filter
+// @Description Move every order matching the status and creation date filter to the target status, oldest first. Each order goes through the same transition and role checks as a single status update and the result of every order is returned. Filters matching more orders than the configured limit are rejected.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param request body requests.BulkStatusByFilterRequest true "Order filter and target status"
+// @Success 200 {object} responses.BulkStatusByFilterResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 401 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/orders/bulk-status-by-filter [post]
+// @Security ApiKeyAuth
+func (h *OrderHandler) BulkUpdateOrderStatusByFilter(c *fiber.Ctx) error {
+	// Get user ID from context (set by auth middleware)
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Unauthorized",
+			Error:   "Invalid user ID",
+		})
+	}
+
+	if _, ok := c.Locals("roles").([]string); !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Unauthorized",
+			Error:   "Invalid user roles",
+		})
+	}
+
+	// Check if the user may change the status of orders in any status
+	canUpdateAnyStatus := hasPermission(c, account.PermissionOrderUpdateStatus)
+
+	// Parse request
+	var req requests.BulkStatusByFilterRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+	}
+
+	filter := services.OrderStatusFilter{
+		Status:   order.OrderStatus(req.Status),
+		FromDate: req.FromDate,
+		ToDate:   req.ToDate,
+	}
+
+	// Update the matching orders
+	change := services.StatusChange{ChangedBy: &userID}
+	result, err := h.orderService.BulkUpdateOrderStatusByFilter(filter, order.OrderStatus(req.TargetStatus), !canUpdateAnyStatus, change)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: result.Message,
+			Error:   result.Error,
+		})
+	}
+
+	// Convert per-order results
+	results := make([]responses.OrderStatusUpdateResponse, len(result.Results))
+	for i, r := range result.Results {
+		results[i] = responses.OrderStatusUpdateResponse{
+			OrderID:        r.OrderID,
+			PreviousStatus: string(r.PreviousStatus),
+			Success:        r.Success,
+			Error:          r.Error,
+			Reason:         r.Reason,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.BulkStatusByFilterResponse{
+		Success: result.Success,
+		Message: result.Message,
+		Data: responses.BulkStatusByFilterData{
+			TargetStatus: string(result.TargetStatus),
+			Matched:      result.Matched,
+			Updated:      result.Updated,
+			Failed:       result.Failed,
+			Results:      results,
+		},
+	})
+}
+
+// HandleGHNOrderStatusWebhook godoc
+// @Summary Receive a GHN order status webhook
+// @Description Apply a shipment status reported by GHN to the order shipped with the GHN order code, falling back to the client order code as order number. The request must carry the configured webhook secret in the X-GHN-Token header. Statuses that do not change the order, such as unknown GHN statuses or transitions the order flow does not allow, are logged and acknowledged with 200 so GHN does not retry them; applied changes notify the order creator like any other status update.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param X-GHN-Token header string true "GHN webhook secret"
+// @Param payload body GHNWebhookPayload true "GHN webhook payload"
+// @Success 200
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 401
+// @Failure 500
+// @Router /webhook/ghn/order_status [post]
+func (h *OrderHandler) HandleGHNOrderStatusWebhook(c *fiber.Ctx) error {
+	// Reject every request while no secret is configured
+	secret := h.orderService.Settings.GHNWebhookSecret
+	if secret == "" || subtle.ConstantTimeCompare([]byte(c.Get("X-GHN-Token")), []byte(secret)) != 1 {
+		return c.SendStatus(fiber.StatusUnauthorized)
+	}
+
+	var payload GHNWebhookPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid payload",
+			Error:   err.Error(),
+		})
+	}
+
+	log.Printf("GHN webhook for order code %s: status %s", payload.OrderCode, payload.Status)
+
+	update, err := h.orderService.WithContext(c.UserContext()).
+		ApplyGHNStatus(payload.OrderCode, payload.ClientOrderCode, payload.Status, false)
+	if err != nil {
+		log.Printf("GHN webhook for order code %s not applied: %v", payload.OrderCode, err)
+		// Unknown orders and invalid payloads are acknowledged so GHN does not retry them
+		if ErrorStatus(err) == fiber.StatusInternalServerError {
+			return c.SendStatus(fiber.StatusInternalServerError)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	}
+	if !update.Applied {
+		log.Printf("GHN webhook for order %s ignored: %s", update.OrderID, update.Reason)
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// TestGHNWebhook godoc
+// @Summary Replay a GHN webhook payload
+// @Description Run a GHN order status webhook payload through the same status mapping and order update as the real webhook. With dry_run=true the update is only evaluated and reported, without changing the order. Admin only.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param dry_run query bool false "Report what would change without persisting"
+// @Param payload body GHNWebhookPayload true "GHN webhook payload"
+// @Success 200 {object} responses.GHNWebhookTestResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/orders/ghn-webhook/test [post]
+// @Security ApiKeyAuth
+func (h *OrderHandler) TestGHNWebhook(c *fiber.Ctx) error {
+	if !hasPermission(c, account.PermissionOrderReplayWebhook) {
+		return c.Status(fiber.StatusForbidden).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Forbidden",
+			Error:   "Only admins can replay GHN webhooks",
+		})
+	}
+
+	var payload GHNWebhookPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid payload",
+			Error:   err.Error(),
+		})
+	}
+
+	dryRun := c.QueryBool("dry_run", false)
+	update, err := h.orderService.WithContext(c.UserContext()).
+		ApplyGHNStatus(payload.OrderCode, payload.ClientOrderCode, payload.Status, dryRun)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to process GHN webhook payload",
+			Error:   err.Error(),
+		})
+	}
+
+	message := "GHN webhook payload processed"
+	if dryRun {
+		message = "GHN webhook payload evaluated without changes"
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.GHNWebhookTestResponse{
+		Success: true,
+		Message: message,
+		Data: responses.GHNStatusUpdateDetail{
+			OrderID:        update.OrderID,
+			OrderNumber:    update.OrderNumber,
+			TrackingNumber: update.TrackingNumber,
+			GHNStatus:      update.GHNStatus,
+			CurrentStatus:  string(update.CurrentStatus),
+			TargetStatus:   string(update.TargetStatus),
+			Changes:        update.Changes,
+			Unmapped:       update.Unmapped,
+			Applied:        update.Applied,
+			DryRun:         update.DryRun,
+			Reason:         update.Reason,
+		},
+	})
 }
 
 type GHNWebhookPayload struct {
@@ -13,7 +13,9 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/ybds/internal/api/handlers"
 	"github.com/ybds/internal/models/order"
+	"github.com/ybds/internal/services"
 	"github.com/ybds/internal/testutil"
 )
 
@@ -556,3 +558,357 @@ func TestOrderHandler(t *testing.T) {
 		assert.Equal(t, "Status is required", response["message"])
 	})
 }
+
+// TestTrackOrderPublicRoute tests validation and rate limiting of the public tracking endpoint
+func TestTrackOrderPublicRoute(t *testing.T) {
+	app := fiber.New()
+	orderHandler := handlers.NewOrderHandler(nil, nil, nil, nil, nil, nil, nil, services.DefaultOrderSettings())
+	orderHandler.RegisterPublicRoutes(app.Group("/api"))
+
+	// Missing phone is rejected before any lookup
+	for i := 0; i < 10; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/track?number=YB-20240115-000001", nil)
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+
+	// The next request from the same client exceeds the limit
+	req := httptest.NewRequest(http.MethodGet, "/api/track?number=YB-20240115-000001", nil)
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+}
+
+// TestUpdateShipmentMissingShipment tests that updating a shipment that does not exist yields 404
+func TestUpdateShipmentMissingShipment(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	o := &order.Order{
+		PaymentMethod:    order.PaymentCash,
+		TotalAmount:      100,
+		FinalTotalAmount: 100,
+		OrderStatus:      order.OrderShipmentRequested,
+		CustomerName:     "John Doe",
+	}
+	assert.NoError(t, db.Create(o).Error)
+
+	app := fiber.New()
+	orderHandler := handlers.NewOrderHandler(db, nil, nil, nil, nil, nil, nil, services.DefaultOrderSettings())
+	orderHandler.RegisterRoutes(app.Group("/api"), func(c *fiber.Ctx) error {
+		c.Locals("userID", uuid.New())
+		c.Locals("roles", []string{"admin"})
+		return c.Next()
+	})
+
+	body, _ := json.Marshal(map[string]interface{}{"tracking_number": "GHN123"})
+	req := httptest.NewRequest(http.MethodPut, "/api/orders/"+o.ID.String()+"/shipment", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+// TestGHNWebhookDryRun tests that a replayed GHN payload in dry-run mode reports the transition without changing the order
+func TestGHNWebhookDryRun(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	o := &order.Order{
+		PaymentMethod:    order.PaymentCash,
+		TotalAmount:      100,
+		FinalTotalAmount: 100,
+		OrderStatus:      order.OrderPacked,
+		CustomerName:     "John Doe",
+	}
+	assert.NoError(t, db.Create(o).Error)
+	assert.NoError(t, db.Create(&order.Shipment{OrderID: o.ID, Carrier: "GHN", TrackingNumber: "GHN123"}).Error)
+
+	roles := []string{"admin"}
+	app := fiber.New()
+	orderHandler := handlers.NewOrderHandler(db, nil, nil, nil, nil, nil, nil, services.DefaultOrderSettings())
+	orderHandler.RegisterRoutes(app.Group("/api"), func(c *fiber.Ctx) error {
+		c.Locals("userID", uuid.New())
+		c.Locals("roles", roles)
+		return c.Next()
+	})
+
+	replay := func(status string) (int, map[string]interface{}) {
+		body, _ := json.Marshal(map[string]interface{}{"OrderCode": "GHN123", "Status": status})
+		req := httptest.NewRequest(http.MethodPost, "/api/orders/ghn-webhook/test?dry_run=true", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+
+		var response map[string]interface{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		return resp.StatusCode, response
+	}
+
+	status, response := replay("delivering")
+	assert.Equal(t, http.StatusOK, status)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, o.ID.String(), data["order_id"])
+	assert.Equal(t, string(order.OrderPacked), data["current_status"])
+	assert.Equal(t, string(order.OrderDelivering), data["target_status"])
+	assert.Equal(t, true, data["changes"])
+	assert.Equal(t, false, data["applied"])
+	assert.Equal(t, true, data["dry_run"])
+
+	var reloaded order.Order
+	assert.NoError(t, db.First(&reloaded, "id = ?", o.ID).Error)
+	assert.Equal(t, order.OrderPacked, reloaded.OrderStatus)
+
+	// A transition the order flow does not allow is reported with a reason
+	_, response = replay("returned")
+	data = response["data"].(map[string]interface{})
+	assert.Equal(t, false, data["changes"])
+	assert.NotEmpty(t, data["reason"])
+
+	// Only admins may replay payloads
+	roles = []string{"agent"}
+	status, _ = replay("delivering")
+	assert.Equal(t, http.StatusForbidden, status)
+}
+
+// TestUpdateOrderStatusAgentTargetStatuses tests that agents can only move orders to the configured target statuses
+func TestUpdateOrderStatusAgentTargetStatuses(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	app := fiber.New()
+	orderHandler := handlers.NewOrderHandler(db, nil, nil, nil, nil, nil, nil, services.DefaultOrderSettings())
+	orderHandler.RegisterRoutes(app.Group("/api"), func(c *fiber.Ctx) error {
+		c.Locals("userID", uuid.New())
+		c.Locals("roles", []string{"agent"})
+		return c.Next()
+	})
+
+	updateStatus := func(o *order.Order, status order.OrderStatus) int {
+		body, _ := json.Marshal(map[string]interface{}{"status": status})
+		req := httptest.NewRequest(http.MethodPut, "/api/orders/"+o.ID.String()+"/status", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	newOrder := func() *order.Order {
+		o := &order.Order{
+			PaymentMethod:    order.PaymentCash,
+			TotalAmount:      100,
+			FinalTotalAmount: 100,
+			OrderStatus:      order.OrderShipmentRequested,
+			CustomerName:     "John Doe",
+		}
+		assert.NoError(t, db.Create(o).Error)
+		return o
+	}
+
+	t.Run("AllowedTarget", func(t *testing.T) {
+		o := newOrder()
+		assert.Equal(t, http.StatusOK, updateStatus(o, order.OrderPacked))
+
+		var stored order.Order
+		assert.NoError(t, db.First(&stored, "id = ?", o.ID).Error)
+		assert.Equal(t, order.OrderPacked, stored.OrderStatus)
+	})
+
+	t.Run("DisallowedTarget", func(t *testing.T) {
+		o := newOrder()
+		assert.Equal(t, http.StatusForbidden, updateStatus(o, order.OrderDelivering))
+
+		var stored order.Order
+		assert.NoError(t, db.First(&stored, "id = ?", o.ID).Error)
+		assert.Equal(t, order.OrderShipmentRequested, stored.OrderStatus)
+	})
+}
+
+// TestGetOrderQueue tests that the order queue groups the seeded orders into buckets scoped to the agent
+func TestGetOrderQueue(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	agentID := uuid.New()
+	otherAgentID := uuid.New()
+	now := time.Now()
+
+	seed := func(status order.OrderStatus, createdBy uuid.UUID, age time.Duration, trackingNumber string) *order.Order {
+		o := &order.Order{
+			PaymentMethod:    order.PaymentCash,
+			TotalAmount:      100,
+			FinalTotalAmount: 100,
+			OrderStatus:      status,
+			CustomerName:     "John Doe",
+		}
+		o.CreatedBy = &createdBy
+		o.CreatedAt = now.Add(-age)
+		assert.NoError(t, db.Create(o).Error)
+		assert.NoError(t, db.Create(&order.Shipment{OrderID: o.ID, TrackingNumber: trackingNumber}).Error)
+		return o
+	}
+
+	newOrder := seed(order.OrderShipmentRequested, agentID, time.Hour, "")
+	stalePending := seed(order.OrderShipmentRequested, agentID, 48*time.Hour, "")
+	packed := seed(order.OrderPacked, agentID, 2*time.Hour, "GHN123")
+	returning := seed(order.OrderReturnProcessing, agentID, 72*time.Hour, "GHN456")
+	seed(order.OrderDelivered, agentID, 72*time.Hour, "GHN789")
+	seed(order.OrderShipmentRequested, otherAgentID, time.Hour, "")
+	seed(order.OrderReturnProcessing, otherAgentID, 72*time.Hour, "GHN999")
+
+	roles := []string{"agent"}
+	app := fiber.New()
+	orderHandler := handlers.NewOrderHandler(db, nil, nil, nil, nil, nil, nil, services.DefaultOrderSettings())
+	orderHandler.RegisterRoutes(app.Group("/api"), func(c *fiber.Ctx) error {
+		c.Locals("userID", agentID)
+		c.Locals("roles", roles)
+		return c.Next()
+	})
+
+	getQueue := func() map[string]interface{} {
+		req := httptest.NewRequest(http.MethodGet, "/api/orders/queue", nil)
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response map[string]interface{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		return response["data"].(map[string]interface{})
+	}
+
+	bucketIDs := func(data map[string]interface{}, name string) (float64, []string) {
+		bucket := data[name].(map[string]interface{})
+		var ids []string
+		for _, o := range bucket["orders"].([]interface{}) {
+			ids = append(ids, o.(map[string]interface{})["order_id"].(string))
+		}
+		return bucket["count"].(float64), ids
+	}
+
+	// Agents only see their own orders; buckets list the oldest orders first
+	data := getQueue()
+	count, ids := bucketIDs(data, "new")
+	assert.Equal(t, float64(1), count)
+	assert.Equal(t, []string{newOrder.ID.String()}, ids)
+
+	count, ids = bucketIDs(data, "awaiting_action")
+	assert.Equal(t, float64(2), count)
+	assert.Equal(t, []string{stalePending.ID.String(), packed.ID.String()}, ids)
+
+	count, ids = bucketIDs(data, "flagged")
+	assert.Equal(t, float64(2), count)
+	assert.Equal(t, []string{returning.ID.String(), stalePending.ID.String()}, ids)
+
+	// Admins see the orders of every agent
+	roles = []string{"admin"}
+	data = getQueue()
+	count, _ = bucketIDs(data, "new")
+	assert.Equal(t, float64(2), count)
+	count, _ = bucketIDs(data, "flagged")
+	assert.Equal(t, float64(3), count)
+}
+
+// TestGHNOrderStatusWebhook tests that GHN webhooks carrying the secret move the order and unknown statuses are ignored
+func TestGHNOrderStatusWebhook(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	o := &order.Order{
+		PaymentMethod:    order.PaymentCash,
+		TotalAmount:      100,
+		FinalTotalAmount: 100,
+		OrderStatus:      order.OrderPacked,
+		CustomerName:     "John Doe",
+	}
+	assert.NoError(t, db.Create(o).Error)
+	assert.NoError(t, db.Create(&order.Shipment{OrderID: o.ID, Carrier: "GHN", TrackingNumber: "GHN123"}).Error)
+
+	settings := services.DefaultOrderSettings()
+	settings.GHNWebhookSecret = "ghn-secret"
+	app := fiber.New()
+	orderHandler := handlers.NewOrderHandler(db, nil, nil, nil, nil, nil, nil, settings)
+	app.Post("/webhook/ghn/order_status", orderHandler.HandleGHNOrderStatusWebhook)
+
+	send := func(token, status string) int {
+		body, _ := json.Marshal(map[string]interface{}{"OrderCode": "GHN123", "Status": status})
+		req := httptest.NewRequest(http.MethodPost, "/webhook/ghn/order_status", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("X-GHN-Token", token)
+		}
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	status := func() order.OrderStatus {
+		var reloaded order.Order
+		assert.NoError(t, db.First(&reloaded, "id = ?", o.ID).Error)
+		return reloaded.OrderStatus
+	}
+
+	// Requests without the secret are rejected
+	assert.Equal(t, http.StatusUnauthorized, send("", "picked"))
+	assert.Equal(t, http.StatusUnauthorized, send("wrong", "picked"))
+	assert.Equal(t, order.OrderPacked, status())
+
+	// Unknown GHN statuses are acknowledged without changing the order
+	assert.Equal(t, http.StatusOK, send("ghn-secret", "something_new"))
+	assert.Equal(t, order.OrderPacked, status())
+
+	assert.Equal(t, http.StatusOK, send("ghn-secret", "picked"))
+	assert.Equal(t, order.OrderPicked, status())
+}
+
+// TestGetOrderByOrderNumber tests that orders can be looked up and filtered by their order number
+func TestGetOrderByOrderNumber(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	createdBy := uuid.New()
+	seed := func(orderNumber, customerName string) *order.Order {
+		o := &order.Order{
+			OrderNumber:      orderNumber,
+			PaymentMethod:    order.PaymentCash,
+			TotalAmount:      100,
+			FinalTotalAmount: 100,
+			OrderStatus:      order.OrderShipmentRequested,
+			CustomerName:     customerName,
+		}
+		o.CreatedBy = &createdBy
+		assert.NoError(t, db.Create(o).Error)
+		return o
+	}
+	first := seed("YB-20240115-000123", "John Doe")
+	seed("YB-20240115-000124", "Jane Roe")
+
+	app := fiber.New()
+	orderHandler := handlers.NewOrderHandler(db, nil, nil, nil, nil, nil, nil, services.DefaultOrderSettings())
+	orderHandler.RegisterRoutes(app.Group("/api"), orderMockJWTMiddleware)
+
+	get := func(url string) (int, map[string]interface{}) {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, url, nil))
+		assert.NoError(t, err)
+		var response map[string]interface{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		return resp.StatusCode, response
+	}
+
+	// The lookup is case-insensitive
+	status, response := get("/api/orders/number/yb-20240115-000123")
+	assert.Equal(t, http.StatusOK, status)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, first.ID.String(), data["id"])
+	assert.Equal(t, "YB-20240115-000123", data["order_number"])
+
+	status, _ = get("/api/orders/number/YB-20240115-999999")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	// Listing filters by exact order number and searches partial order numbers
+	status, response = get("/api/orders?order_number=yb-20240115-000124")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, float64(1), response["total"])
+
+	status, response = get("/api/orders?search=000123")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, float64(1), response["total"])
+}
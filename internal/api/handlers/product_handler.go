@@ -12,6 +12,9 @@ import (
 	"github.com/google/uuid"
 	"github.com/ybds/internal/api/requests"
 	"github.com/ybds/internal/api/responses"
+	"github.com/ybds/internal/middleware"
+	"github.com/ybds/internal/models/account"
+	"github.com/ybds/internal/models/product"
 	"github.com/ybds/internal/services"
 	"github.com/ybds/pkg/upload"
 	"gorm.io/gorm"
@@ -44,10 +47,23 @@ type ProductHandler struct {
 }
 
 // NewProductHandler creates a new instance of ProductHandler
-func NewProductHandler(db *gorm.DB, notificationService *services.NotificationService, uploadService *upload.Service) *ProductHandler {
+func NewProductHandler(db *gorm.DB, notificationService *services.NotificationService, uploadService *upload.Service, priceSelection product.PriceSelection) *ProductHandler {
+	productService := services.NewProductService(db, notificationService, uploadService)
+	if priceSelection.IsValid() {
+		productService.PriceSelection = priceSelection
+	}
+
 	return &ProductHandler{
-		productService: services.NewProductService(db, notificationService, uploadService),
+		productService: productService,
+	}
+}
+
+// currentUserID returns the ID of the authenticated user, or nil if the request carries none
+func currentUserID(c *fiber.Ctx) *uuid.UUID {
+	if userID, ok := c.Locals("userID").(uuid.UUID); ok {
+		return &userID
 	}
+	return nil
 }
 
 // RegisterRoutes registers all routes related to products
@@ -58,22 +74,40 @@ func (h *ProductHandler) RegisterRoutes(router fiber.Router, authMiddleware fibe
 	// Product routes
 	products.Post("/", h.CreateProduct)
 	products.Get("/", h.GetProducts)
+	products.Get("/featured", h.GetFeaturedProducts)
+	products.Put("/featured/reorder", h.ReorderFeaturedProducts)
 	products.Get("/:id", h.GetProductByID)
 	products.Put("/:id", h.UpdateProduct)
-	products.Delete("/:id", h.DeleteProduct)
+	products.Delete("/:id", middleware.RequirePermission(account.PermissionProductDelete), h.DeleteProduct)
+	products.Post("/:id/restore", middleware.RequirePermission(account.PermissionProductRestore), h.RestoreProduct) // Admin only
+	products.Put("/:id/featured", h.SetProductFeatured)
 
 	// Inventory routes
 	products.Post("/:id/inventories", h.CreateInventory)
 	products.Post("/:id/inventories/batch", h.CreateInventory)
+	products.Post("/inventories/transfer", h.TransferInventory)
 	products.Put("/inventories/:id", h.UpdateInventory)
 	products.Delete("/inventories/:id", h.DeleteInventory)
+	products.Get("/inventories/:id/restocks", h.GetInventoryRestocks)
+	products.Get("/inventories/:id/movements", h.GetInventoryMovements)
+	products.Post("/:id/evaluate-stock", h.EvaluateStock)
+
+	// Variant option routes
+	products.Get("/:id/variant-options", h.GetVariantOptions)
+	products.Post("/:id/variant-options", h.CreateVariantOption)
+	products.Delete("/:id/variant-options/:optionId", h.DeleteVariantOption)
 
 	// Price routes
 	products.Post("/:id/prices", h.CreatePrice)
 	products.Put("/prices/:id", h.UpdatePrice)
 	products.Delete("/prices/:id", h.DeletePrice)
+	products.Get("/prices/:id/tiers", h.GetPriceTiers)
+	products.Post("/prices/:id/tiers", h.CreatePriceTier)
+	products.Put("/prices/tiers/:id", h.UpdatePriceTier)
+	products.Delete("/prices/tiers/:id", h.DeletePriceTier)
 
 	// Image routes
+	products.Post("/images/bulk", h.BulkUploadProductImages)
 	products.Get("/:id/images", h.GetProductImages)
 	products.Post("/:id/images", h.UploadProductImage)
 	products.Post("/:id/images/multiple", h.UploadMultipleProductImages)
@@ -95,7 +129,7 @@ func (h *ProductHandler) RegisterRoutes(router fiber.Router, authMiddleware fibe
 // @Param inventories formData string false "JSON array of inventory objects [{\"size\":\"M\",\"color\":\"Red\",\"quantity\":10,\"location\":\"Warehouse A\"}]"
 // @Param prices formData string false "JSON array of price objects [{\"price\":99.99,\"currency\":\"USD\",\"endDate\":\"2023-12-31T23:59:59Z\"}]"
 // @Param images formData file false "Product images (can upload multiple, first image will be set as primary)"
-// @Success 201 {object} responses.ProductDetailResponse "Returns the created product with all related data"
+// @Success 201 {object} responses.SingleProductResponse "Returns the created product with all related data"
 // @Failure 400 {object} responses.ErrorResponse "Invalid request data"
 // @Failure 500 {object} responses.ErrorResponse "Server error"
 // @Router /api/products [post]
@@ -152,6 +186,8 @@ func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 				inv.Color,
 				inv.Quantity,
 				inv.Location,
+				inv.LowStockThreshold,
+				currentUserID(c),
 			)
 
 			if err != nil {
@@ -224,10 +260,10 @@ func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 	}
 
 	// Return response
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"success": true,
-		"message": "Product created successfully",
-		"data":    product,
+	return c.Status(fiber.StatusCreated).JSON(responses.SingleProductResponse{
+		Success: true,
+		Message: "Product created successfully",
+		Data:    responses.ConvertToProductDetailResponse(*product),
 	})
 }
 
@@ -239,13 +275,22 @@ func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 // @Produce json
 // @Param page query int false "Page number"
 // @Param page_size query int false "Page size"
-// @Param search query string false "Search term"
+// @Param search query string false "Full-text search over name, SKU, category and description, also matching partial SKUs; results are ranked by relevance unless sort is given"
 // @Param category query string false "Filter by category"
+// @Param sort query string false "Sort order: popular (best sellers first)"
+// @Param missing query string false "Only products that cannot be ordered: price (no current price) or inventory (no stock)"
+// @Param from_date query string false "Only products created on or after this date (YYYY-MM-DD)"
+// @Param to_date query string false "Only products created on or before this date (YYYY-MM-DD)"
+// @Param include_deleted query bool false "Also list deleted products, which carry a deleted_at date (admin only)"
 // @Success 200 {object} responses.ProductsResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
 // @Failure 500 {object} responses.ErrorResponse
 // @Router /api/products [get]
 // @Security ApiKeyAuth
 func (h *ProductHandler) GetProducts(c *fiber.Ctx) error {
+	productService := h.productService.WithContext(c.UserContext())
+
 	// Parse pagination parameters
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	pageSize, _ := strconv.Atoi(c.Query("page_size", "10"))
@@ -269,10 +314,62 @@ func (h *ProductHandler) GetProducts(c *fiber.Ctx) error {
 		filters["category"] = category
 	}
 
+	switch sort := c.Query("sort"); sort {
+	case "":
+	case "popular":
+		filters["sort"] = sort
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid sort option",
+			Error:   "sort must be one of: popular",
+		})
+	}
+
+	switch missing := c.Query("missing"); missing {
+	case "":
+	case "price", "inventory":
+		filters["missing"] = missing
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid missing filter",
+			Error:   "missing must be one of: price, inventory",
+		})
+	}
+
+	// Apply from_date filter if provided, from the beginning of the day
+	if fromDate := c.Query("from_date"); fromDate != "" {
+		date, err := time.Parse("2006-01-02", fromDate)
+		if err == nil {
+			filters["from_date"] = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+		}
+	}
+
+	// Apply to_date filter if provided, until the end of the day
+	if toDate := c.Query("to_date"); toDate != "" {
+		date, err := time.Parse("2006-01-02", toDate)
+		if err == nil {
+			filters["to_date"] = time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 999999999, date.Location())
+		}
+	}
+
+	// Deleted products are hidden unless an admin asks for them
+	if includeDeleted, _ := strconv.ParseBool(c.Query("include_deleted")); includeDeleted {
+		if !middleware.HasPermission(c, account.PermissionProductRestore) {
+			return c.Status(fiber.StatusForbidden).JSON(responses.ErrorResponse{
+				Success: false,
+				Message: "Forbidden",
+				Error:   "Only admins can list deleted products",
+			})
+		}
+		filters["include_deleted"] = true
+	}
+
 	// First, get the total count to calculate total pages
-	_, total, err := h.productService.GetAllProducts(1, 1, filters)
+	_, total, err := productService.GetAllProducts(1, 1, filters)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
 			Success: false,
 			Message: "Failed to retrieve products count",
 			Error:   err.Error(),
@@ -288,29 +385,171 @@ func (h *ProductHandler) GetProducts(c *fiber.Ctx) error {
 	}
 
 	// Get products
-	products, _, err := h.productService.GetAllProducts(page, pageSize, filters)
+	products, _, err := productService.GetAllProducts(page, pageSize, filters)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
 			Success: false,
 			Message: "Failed to retrieve products",
 			Error:   err.Error(),
 		})
 	}
 
-	// Convert products to response objects
-	productResponses := responses.ConvertToProductDetailResponses(products)
-
 	// Return response
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"success": true,
-		"message": "Products retrieved successfully",
-		"data": fiber.Map{
-			"products":    productResponses,
-			"total":       total,
-			"page":        page,
-			"page_size":   pageSize,
-			"total_pages": totalPages,
-		},
+	return c.Status(fiber.StatusOK).JSON(responses.ProductsResponse{
+		Success:    true,
+		Message:    "Products retrieved successfully",
+		Data:       responses.ConvertToProductDetailResponses(products),
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	})
+}
+
+// GetFeaturedProducts godoc
+// @Summary Get featured products
+// @Description Get the products curated for the storefront homepage in their featured order
+// @Tags products
+// @Accept json
+// @Produce json
+// @Success 200 {object} responses.FeaturedProductsResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/products/featured [get]
+// @Security ApiKeyAuth
+func (h *ProductHandler) GetFeaturedProducts(c *fiber.Ctx) error {
+	productService := h.productService.WithContext(c.UserContext())
+
+	products, err := productService.GetFeaturedProducts()
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve featured products",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.FeaturedProductsResponse{
+		Success: true,
+		Message: "Featured products retrieved successfully",
+		Data:    responses.ConvertToProductDetailResponses(products),
+	})
+}
+
+// SetProductFeatured godoc
+// @Summary Feature or unfeature a product
+// @Description Add a product to the end of the featured list or remove it from the list. Featuring an already featured product keeps its position.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param request body requests.SetFeaturedRequest true "Featured flag"
+// @Success 200 {object} responses.SingleProductResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/products/{id}/featured [put]
+// @Security ApiKeyAuth
+func (h *ProductHandler) SetProductFeatured(c *fiber.Ctx) error {
+	// Parse product ID
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid product ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	var req requests.SetFeaturedRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+	}
+
+	product, err := h.productService.SetProductFeatured(id, *req.Featured)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to update featured product",
+			Error:   err.Error(),
+		})
+	}
+
+	message := "Product removed from featured products"
+	if product.IsFeatured {
+		message = "Product added to featured products"
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.SingleProductResponse{
+		Success: true,
+		Message: message,
+		Data:    responses.ConvertToProductDetailResponse(*product),
+	})
+}
+
+// ReorderFeaturedProducts godoc
+// @Summary Reorder featured products
+// @Description Set the display order of featured products. Featured products left out of the list keep their relative order after the listed ones.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param request body requests.ReorderFeaturedRequest true "Featured product IDs in the desired display order"
+// @Success 200 {object} responses.FeaturedProductsResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/products/featured/reorder [put]
+// @Security ApiKeyAuth
+func (h *ProductHandler) ReorderFeaturedProducts(c *fiber.Ctx) error {
+	var req requests.ReorderFeaturedRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := h.productService.ReorderFeaturedProducts(req.ProductIDs); err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to reorder featured products",
+			Error:   err.Error(),
+		})
+	}
+
+	products, err := h.productService.GetFeaturedProducts()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve featured products",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.FeaturedProductsResponse{
+		Success: true,
+		Message: "Featured products reordered successfully",
+		Data:    responses.ConvertToProductDetailResponses(products),
 	})
 }
 
@@ -321,13 +560,15 @@ func (h *ProductHandler) GetProducts(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path string true "Product ID"
-// @Success 200 {object} responses.ProductDetailResponse
+// @Success 200 {object} responses.SingleProductResponse
 // @Failure 400 {object} responses.ErrorResponse
 // @Failure 404 {object} responses.ErrorResponse
 // @Failure 500 {object} responses.ErrorResponse
 // @Router /api/products/{id} [get]
 // @Security ApiKeyAuth
 func (h *ProductHandler) GetProductByID(c *fiber.Ctx) error {
+	productService := h.productService.WithContext(c.UserContext())
+
 	// Parse product ID
 	idStr := c.Params("id")
 	id, err := uuid.Parse(idStr)
@@ -340,7 +581,7 @@ func (h *ProductHandler) GetProductByID(c *fiber.Ctx) error {
 	}
 
 	// Get product
-	product, err := h.productService.GetProductByID(id)
+	product, err := productService.GetProductByID(id)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(responses.ErrorResponse{
 			Success: false,
@@ -349,14 +590,11 @@ func (h *ProductHandler) GetProductByID(c *fiber.Ctx) error {
 		})
 	}
 
-	// Convert product to response object
-	productResponse := responses.ConvertToProductDetailResponse(*product)
-
 	// Return response
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"success": true,
-		"message": "Product retrieved successfully",
-		"data":    productResponse,
+	return c.Status(fiber.StatusOK).JSON(responses.SingleProductResponse{
+		Success: true,
+		Message: "Product retrieved successfully",
+		Data:    responses.ConvertToProductDetailResponse(*product),
 	})
 }
 
@@ -372,7 +610,7 @@ func (h *ProductHandler) GetProductByID(c *fiber.Ctx) error {
 // @Param sku formData string false "Product SKU (unique identifier)"
 // @Param category formData string false "Product category"
 // @Param images formData file false "Product images to add (can upload multiple, first image will be set as primary if no existing images)"
-// @Success 200 {object} responses.ProductDetailResponse "Returns the updated product with all related data"
+// @Success 200 {object} responses.SingleProductResponse "Returns the updated product with all related data"
 // @Failure 400 {object} responses.ErrorResponse "Invalid request data"
 // @Failure 404 {object} responses.ErrorResponse "Product not found"
 // @Failure 500 {object} responses.ErrorResponse "Server error"
@@ -455,16 +693,16 @@ func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
 	}
 
 	// Return response
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"success": true,
-		"message": "Product updated successfully",
-		"data":    product,
+	return c.Status(fiber.StatusOK).JSON(responses.SingleProductResponse{
+		Success: true,
+		Message: "Product updated successfully",
+		Data:    responses.ConvertToProductDetailResponse(*product),
 	})
 }
 
 // DeleteProduct godoc
 // @Summary Delete a product
-// @Description Delete a product and all associated inventories and prices
+// @Description Soft-delete a product. It is hidden from product listings and can no longer be ordered, while past orders and reports keep showing it. Admins can restore it.
 // @Tags products
 // @Accept json
 // @Produce json
@@ -488,7 +726,7 @@ func (h *ProductHandler) DeleteProduct(c *fiber.Ctx) error {
 	}
 
 	// Delete product
-	result, err := h.productService.DeleteProduct(id)
+	_, err = h.productService.DeleteProduct(id)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
 			Success: false,
@@ -498,10 +736,62 @@ func (h *ProductHandler) DeleteProduct(c *fiber.Ctx) error {
 	}
 
 	// Return response
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"success": true,
-		"message": "Product deleted successfully",
-		"data":    result,
+	return c.Status(fiber.StatusOK).JSON(responses.SuccessResponse{
+		Success: true,
+		Message: "Product deleted successfully",
+	})
+}
+
+// RestoreProduct godoc
+// @Summary Restore a deleted product
+// @Description Restore a deleted product, making it visible in product listings and orderable again
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} responses.SingleProductResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/products/{id}/restore [post]
+// @Security ApiKeyAuth
+func (h *ProductHandler) RestoreProduct(c *fiber.Ctx) error {
+	// Parse product ID
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid product ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	// Restore product
+	if _, err := h.productService.RestoreProduct(id); err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to restore product",
+			Error:   err.Error(),
+		})
+	}
+
+	// Get the restored product with all relations
+	product, err := h.productService.GetProductByID(id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Product restored but failed to retrieve details",
+			Error:   err.Error(),
+		})
+	}
+
+	// Return response
+	return c.Status(fiber.StatusOK).JSON(responses.SingleProductResponse{
+		Success: true,
+		Message: "Product restored successfully",
+		Data:    responses.ConvertToProductDetailResponse(*product),
 	})
 }
 
@@ -513,7 +803,7 @@ func (h *ProductHandler) DeleteProduct(c *fiber.Ctx) error {
 // @Produce json
 // @Param id path string true "Product ID"
 // @Param inventory body requests.CreateInventoryRequest true "Single inventory information"
-// @Success 201 {object} responses.InventoryResponse
+// @Success 201 {object} responses.SingleInventoryResponse
 // @Failure 400 {object} responses.ErrorResponse
 // @Failure 404 {object} responses.ErrorResponse
 // @Failure 500 {object} responses.ErrorResponse
@@ -567,21 +857,33 @@ func (h *ProductHandler) CreateInventory(c *fiber.Ctx) error {
 		req.Color,
 		req.Quantity,
 		req.Location,
+		req.LowStockThreshold,
+		currentUserID(c),
 	)
 
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
 			Success: false,
 			Message: "Failed to create inventory",
 			Error:   err.Error(),
 		})
 	}
 
+	// Get the created inventory
+	inventory, err := h.productService.GetInventoryByID(result.InventoryID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Inventory created but failed to retrieve details",
+			Error:   err.Error(),
+		})
+	}
+
 	// Return response
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"success": true,
-		"message": "Inventory created successfully",
-		"data":    result,
+	return c.Status(fiber.StatusCreated).JSON(responses.SingleInventoryResponse{
+		Success: true,
+		Message: "Inventory created successfully",
+		Data:    responses.ConvertToInventoryResponse(*inventory),
 	})
 }
 
@@ -593,7 +895,7 @@ func (h *ProductHandler) CreateInventory(c *fiber.Ctx) error {
 // @Produce json
 // @Param id path string true "Product ID"
 // @Param inventories body requests.CreateMultipleInventoriesRequest true "Multiple inventory information"
-// @Success 201 {object} responses.SuccessResponse{data=[]responses.InventoryResponse}
+// @Success 201 {object} responses.InventoriesResponse
 // @Failure 400 {object} responses.ErrorResponse
 // @Failure 404 {object} responses.ErrorResponse
 // @Failure 500 {object} responses.ErrorResponse
@@ -610,7 +912,7 @@ func (h *ProductHandler) createMultipleInventories(c *fiber.Ctx, productID uuid.
 	}
 
 	// Create inventories
-	var results []interface{}
+	results := make([]responses.InventoryResponse, 0, len(req.Inventories))
 	var failedCount int
 
 	for _, inv := range req.Inventories {
@@ -620,15 +922,24 @@ func (h *ProductHandler) createMultipleInventories(c *fiber.Ctx, productID uuid.
 			inv.Color,
 			inv.Quantity,
 			inv.Location,
+			inv.LowStockThreshold,
+			currentUserID(c),
 		)
 
 		if err != nil {
 			failedCount++
 			// Log error but continue with other inventories
 			log.Printf("Failed to create inventory: %v", err)
-		} else {
-			results = append(results, result)
+			continue
+		}
+
+		inventory, err := h.productService.GetInventoryByID(result.InventoryID)
+		if err != nil {
+			failedCount++
+			log.Printf("Failed to retrieve created inventory: %v", err)
+			continue
 		}
+		results = append(results, responses.ConvertToInventoryResponse(*inventory))
 	}
 
 	// Return response
@@ -637,22 +948,22 @@ func (h *ProductHandler) createMultipleInventories(c *fiber.Ctx, productID uuid.
 		message += fmt.Sprintf(", %d failed", failedCount)
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"success": true,
-		"message": message,
-		"data":    results,
+	return c.Status(fiber.StatusCreated).JSON(responses.InventoriesResponse{
+		Success: true,
+		Message: message,
+		Data:    results,
 	})
 }
 
 // UpdateInventory godoc
 // @Summary Update an inventory
-// @Description Update inventory information
+// @Description Update inventory information. The quantity is left unchanged when omitted; setting it to 0 marks the variant out of stock.
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path string true "Inventory ID"
 // @Param inventory body requests.UpdateInventoryRequest true "Updated inventory information"
-// @Success 200 {object} responses.InventoryResponse
+// @Success 200 {object} responses.SingleInventoryResponse
 // @Failure 400 {object} responses.ErrorResponse
 // @Failure 404 {object} responses.ErrorResponse
 // @Failure 500 {object} responses.ErrorResponse
@@ -690,11 +1001,20 @@ func (h *ProductHandler) UpdateInventory(c *fiber.Ctx) error {
 		})
 	}
 
-	// Create a pointer to the quantity value
-	var quantityPtr *int
-	if req.Quantity != 0 {
-		quantity := req.Quantity
-		quantityPtr = &quantity
+	if req.Quantity != nil && *req.Quantity < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   "Quantity cannot be negative",
+		})
+	}
+
+	if req.LowStockThreshold != nil && *req.LowStockThreshold < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   "Low stock threshold cannot be negative",
+		})
 	}
 
 	// Update inventory
@@ -702,23 +1022,35 @@ func (h *ProductHandler) UpdateInventory(c *fiber.Ctx) error {
 		id,
 		req.Size,
 		req.Color,
-		quantityPtr,
+		req.Quantity,
 		req.Location,
+		req.LowStockThreshold,
+		currentUserID(c),
 	)
 
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
 			Success: false,
 			Message: "Failed to update inventory",
 			Error:   err.Error(),
 		})
 	}
 
+	// Get the updated inventory
+	inventory, err := h.productService.GetInventoryByID(result.InventoryID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Inventory updated but failed to retrieve details",
+			Error:   err.Error(),
+		})
+	}
+
 	// Return response
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"success": true,
-		"message": "Inventory updated successfully",
-		"data":    result,
+	return c.Status(fiber.StatusOK).JSON(responses.SingleInventoryResponse{
+		Success: true,
+		Message: "Inventory updated successfully",
+		Data:    responses.ConvertToInventoryResponse(*inventory),
 	})
 }
 
@@ -748,7 +1080,7 @@ func (h *ProductHandler) DeleteInventory(c *fiber.Ctx) error {
 	}
 
 	// Delete inventory
-	result, err := h.productService.DeleteInventory(id)
+	_, err = h.productService.DeleteInventory(id)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
 			Success: false,
@@ -758,60 +1090,431 @@ func (h *ProductHandler) DeleteInventory(c *fiber.Ctx) error {
 	}
 
 	// Return response
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"success": true,
-		"message": "Inventory deleted successfully",
-		"data":    result,
+	return c.Status(fiber.StatusOK).JSON(responses.SuccessResponse{
+		Success: true,
+		Message: "Inventory deleted successfully",
 	})
 }
 
-// CreatePrice godoc
-// @Summary Create a new price for a product
-// @Description Add price information for a specific product
+// GetInventoryRestocks godoc
+// @Summary Get the restock history of an inventory
+// @Description Get the stock increases of an inventory that were not caused by orders (inbound stock and positive adjustments), newest first
 // @Tags products
 // @Accept json
 // @Produce json
-// @Param id path string true "Product ID"
-// @Param price body requests.CreatePriceRequest true "Price information"
-// @Success 200 {object} responses.PriceResponse
+// @Param id path string true "Inventory ID"
+// @Success 200 {object} responses.InventoryRestocksResponse
 // @Failure 400 {object} responses.ErrorResponse
 // @Failure 404 {object} responses.ErrorResponse
 // @Failure 500 {object} responses.ErrorResponse
-// @Router /api/products/{id}/prices [post]
+// @Router /api/products/inventories/{id}/restocks [get]
 // @Security ApiKeyAuth
-func (h *ProductHandler) CreatePrice(c *fiber.Ctx) error {
-	// Parse product ID
+func (h *ProductHandler) GetInventoryRestocks(c *fiber.Ctx) error {
+	productService := h.productService.WithContext(c.UserContext())
+
+	// Parse inventory ID
 	idStr := c.Params("id")
-	productID, err := uuid.Parse(idStr)
+	id, err := uuid.Parse(idStr)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Invalid product ID format",
+			Message: "Invalid inventory ID format",
 			Error:   err.Error(),
 		})
 	}
 
-	// Parse request
-	var req requests.CreatePriceRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+	// Get restocks
+	transactions, err := productService.GetRestocks(id)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Invalid request",
+			Message: "Failed to retrieve inventory restocks",
 			Error:   err.Error(),
 		})
 	}
 
-	// Validate request
-	if err := req.Validate(); err != nil {
+	// Convert to response format
+	data := make([]responses.InventoryTransactionResponse, len(transactions))
+	for i, transaction := range transactions {
+		data[i] = responses.ConvertToInventoryTransactionResponse(transaction)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.InventoryRestocksResponse{
+		Success: true,
+		Message: "Inventory restocks retrieved successfully",
+		Data:    data,
+	})
+}
+
+// GetInventoryMovements godoc
+// @Summary Get the stock change history of an inventory
+// @Description Get every stock change of an inventory, newest first: order reservations and releases, manual adjustments, transfers and restocks. Each movement carries its reason, the acting user if known, and the resulting balance; movements recorded before balances were tracked have none.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Inventory ID"
+// @Success 200 {object} responses.InventoryMovementsResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/products/inventories/{id}/movements [get]
+// @Security ApiKeyAuth
+func (h *ProductHandler) GetInventoryMovements(c *fiber.Ctx) error {
+	productService := h.productService.WithContext(c.UserContext())
+
+	// Parse inventory ID
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
 			Success: false,
-			Message: "Validation failed",
+			Message: "Invalid inventory ID format",
 			Error:   err.Error(),
 		})
 	}
 
-	// Create price
-	startDate := time.Now()
+	// Get movements
+	transactions, err := productService.GetMovements(id)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve inventory movements",
+			Error:   err.Error(),
+		})
+	}
+
+	// Convert to response format
+	data := make([]responses.InventoryTransactionResponse, len(transactions))
+	for i, transaction := range transactions {
+		data[i] = responses.ConvertToInventoryTransactionResponse(transaction)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.InventoryMovementsResponse{
+		Success: true,
+		Message: "Inventory movements retrieved successfully",
+		Data:    data,
+	})
+}
+
+// TransferInventory godoc
+// @Summary Transfer stock between inventories
+// @Description Move stock from one inventory to another of the same product, size and color, e.g. between warehouse locations. Both stock changes are applied atomically and recorded as a transfer; transfers exceeding the stock of the source are rejected with 409 and change nothing.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param transfer body requests.TransferInventoryRequest true "Transfer information"
+// @Success 201 {object} responses.SingleInventoryTransferResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/products/inventories/transfer [post]
+// @Security ApiKeyAuth
+func (h *ProductHandler) TransferInventory(c *fiber.Ctx) error {
+	productService := h.productService.WithContext(c.UserContext())
+
+	// Parse request
+	var req requests.TransferInventoryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+	}
+
+	// Transfer the stock
+	transfer, err := productService.TransferInventory(req.FromInventoryID, req.ToInventoryID, req.Quantity, req.Notes, currentUserID(c))
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to transfer inventory",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(responses.SingleInventoryTransferResponse{
+		Success: true,
+		Message: "Inventory transferred successfully",
+		Data:    responses.ConvertToInventoryTransferResponse(*transfer),
+	})
+}
+
+// GetVariantOptions godoc
+// @Summary Get the variant options of a product
+// @Description Get the sizes and colors a product allows for its inventories. Attributes without options accept any value.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} responses.VariantOptionsResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/products/{id}/variant-options [get]
+// @Security ApiKeyAuth
+func (h *ProductHandler) GetVariantOptions(c *fiber.Ctx) error {
+	productService := h.productService.WithContext(c.UserContext())
+
+	// Parse product ID
+	productID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid product ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	options, err := productService.GetVariantOptions(productID)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve variant options",
+			Error:   err.Error(),
+		})
+	}
+
+	// Convert to response format
+	data := make([]responses.VariantOptionResponse, len(options))
+	for i, option := range options {
+		data[i] = responses.ConvertToVariantOptionResponse(option)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.VariantOptionsResponse{
+		Success: true,
+		Message: "Variant options retrieved successfully",
+		Data:    data,
+	})
+}
+
+// CreateVariantOption godoc
+// @Summary Define a variant option of a product
+// @Description Add a size or color the product allows. Once a product defines options for an attribute, inventories with a value that is not one of them are rejected; values are matched case-insensitively and stored with the spelling of the option.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param option body requests.CreateVariantOptionRequest true "Variant option information"
+// @Success 201 {object} responses.SingleVariantOptionResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/products/{id}/variant-options [post]
+// @Security ApiKeyAuth
+func (h *ProductHandler) CreateVariantOption(c *fiber.Ctx) error {
+	productService := h.productService.WithContext(c.UserContext())
+
+	// Parse product ID
+	productID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid product ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	// Parse request
+	var req requests.CreateVariantOptionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+	}
+
+	option, err := productService.CreateVariantOption(productID, product.VariantAttribute(req.Attribute), req.Value)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to create variant option",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(responses.SingleVariantOptionResponse{
+		Success: true,
+		Message: "Variant option created successfully",
+		Data:    responses.ConvertToVariantOptionResponse(*option),
+	})
+}
+
+// DeleteVariantOption godoc
+// @Summary Delete a variant option of a product
+// @Description Remove a size or color from the options of a product. Options still used by an inventory are rejected with 409.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param optionId path string true "Variant option ID"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/products/{id}/variant-options/{optionId} [delete]
+// @Security ApiKeyAuth
+func (h *ProductHandler) DeleteVariantOption(c *fiber.Ctx) error {
+	productService := h.productService.WithContext(c.UserContext())
+
+	// Parse product and option IDs
+	productID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid product ID format",
+			Error:   err.Error(),
+		})
+	}
+	optionID, err := uuid.Parse(c.Params("optionId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid variant option ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := productService.DeleteVariantOption(productID, optionID); err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to delete variant option",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.SuccessResponse{
+		Success: true,
+		Message: "Variant option deleted successfully",
+	})
+}
+
+// EvaluateStock godoc
+// @Summary Re-evaluate stock levels of a product
+// @Description Check each inventory of a product against its low stock threshold and send low/out-of-stock alerts for inventories that are currently low. Inventories with an unread alert for the same event are not alerted again.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} responses.StockEvaluationResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Router /api/products/{id}/evaluate-stock [post]
+// @Security ApiKeyAuth
+func (h *ProductHandler) EvaluateStock(c *fiber.Ctx) error {
+	// Parse product ID
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid product ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	// Evaluate stock
+	result, err := h.productService.EvaluateStock(id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: result.Message,
+			Error:   result.Error,
+		})
+	}
+
+	// Convert to response format
+	data := make([]responses.StockEvaluationItemResponse, len(result.Evaluations))
+	for i, evaluation := range result.Evaluations {
+		data[i] = responses.StockEvaluationItemResponse{
+			InventoryID:       evaluation.InventoryID,
+			Size:              evaluation.Size,
+			Color:             evaluation.Color,
+			Quantity:          evaluation.Quantity,
+			LowStockThreshold: evaluation.LowStockThreshold,
+			Event:             evaluation.Event,
+			Notified:          evaluation.Notified,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.StockEvaluationResponse{
+		Success: true,
+		Message: result.Message,
+		Data:    data,
+	})
+}
+
+// CreatePrice godoc
+// @Summary Create a new price for a product
+// @Description Add price information for a specific product. A future start_date schedules the price to become current automatically.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param price body requests.CreatePriceRequest true "Price information"
+// @Success 201 {object} responses.SinglePriceResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/products/{id}/prices [post]
+// @Security ApiKeyAuth
+func (h *ProductHandler) CreatePrice(c *fiber.Ctx) error {
+	// Parse product ID
+	idStr := c.Params("id")
+	productID, err := uuid.Parse(idStr)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid product ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	// Parse request
+	var req requests.CreatePriceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+	}
+
+	// Create price, starting now unless it is scheduled for later
+	startDate := time.Now()
+	if req.StartDate != nil {
+		startDate = *req.StartDate
+	}
 	var endDate *time.Time
 
 	if req.EndDate != nil {
@@ -835,11 +1538,21 @@ func (h *ProductHandler) CreatePrice(c *fiber.Ctx) error {
 		})
 	}
 
+	// Get the created price
+	price, err := h.productService.GetPriceByID(result.PriceID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Price created but failed to retrieve details",
+			Error:   err.Error(),
+		})
+	}
+
 	// Return response
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"success": true,
-		"message": "Price created successfully",
-		"data":    result,
+	return c.Status(fiber.StatusCreated).JSON(responses.SinglePriceResponse{
+		Success: true,
+		Message: "Price created successfully",
+		Data:    responses.ConvertToPriceResponse(*price),
 	})
 }
 
@@ -851,7 +1564,7 @@ func (h *ProductHandler) CreatePrice(c *fiber.Ctx) error {
 // @Produce json
 // @Param id path string true "Price ID"
 // @Param price body requests.UpdatePriceRequest true "Updated price information"
-// @Success 200 {object} responses.PriceResponse
+// @Success 200 {object} responses.SinglePriceResponse
 // @Failure 400 {object} responses.ErrorResponse
 // @Failure 404 {object} responses.ErrorResponse
 // @Failure 500 {object} responses.ErrorResponse
@@ -919,11 +1632,21 @@ func (h *ProductHandler) UpdatePrice(c *fiber.Ctx) error {
 		})
 	}
 
+	// Get the updated price
+	price, err := h.productService.GetPriceByID(result.PriceID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Price updated but failed to retrieve details",
+			Error:   err.Error(),
+		})
+	}
+
 	// Return response
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"success": true,
-		"message": "Price updated successfully",
-		"data":    result,
+	return c.Status(fiber.StatusOK).JSON(responses.SinglePriceResponse{
+		Success: true,
+		Message: "Price updated successfully",
+		Data:    responses.ConvertToPriceResponse(*price),
 	})
 }
 
@@ -953,7 +1676,7 @@ func (h *ProductHandler) DeletePrice(c *fiber.Ctx) error {
 	}
 
 	// Delete price
-	result, err := h.productService.DeletePrice(id)
+	_, err = h.productService.DeletePrice(id)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
 			Success: false,
@@ -963,10 +1686,223 @@ func (h *ProductHandler) DeletePrice(c *fiber.Ctx) error {
 	}
 
 	// Return response
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"success": true,
-		"message": "Price deleted successfully",
-		"data":    result,
+	return c.Status(fiber.StatusOK).JSON(responses.SuccessResponse{
+		Success: true,
+		Message: "Price deleted successfully",
+	})
+}
+
+// GetPriceTiers godoc
+// @Summary Get the quantity breaks of a price
+// @Description Get the tiers of a price, ordered by minimum quantity. Order lines reaching a tier's minimum quantity are charged its unit price.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Price ID"
+// @Success 200 {object} responses.PriceTiersResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/products/prices/{id}/tiers [get]
+// @Security ApiKeyAuth
+func (h *ProductHandler) GetPriceTiers(c *fiber.Ctx) error {
+	productService := h.productService.WithContext(c.UserContext())
+
+	// Parse price ID
+	priceID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid price ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	tiers, err := productService.GetPriceTiers(priceID)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve price tiers",
+			Error:   err.Error(),
+		})
+	}
+
+	// Convert to response format
+	data := make([]responses.PriceTierResponse, len(tiers))
+	for i, tier := range tiers {
+		data[i] = responses.ConvertToPriceTierResponse(tier)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.PriceTiersResponse{
+		Success: true,
+		Message: "Price tiers retrieved successfully",
+		Data:    data,
+	})
+}
+
+// CreatePriceTier godoc
+// @Summary Add a quantity break to a price
+// @Description Charge a lower unit price for order lines of at least the minimum quantity, e.g. for wholesale buyers. The unit price must be below the base price and each minimum quantity may only be used once per price.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Price ID"
+// @Param tier body requests.CreatePriceTierRequest true "Price tier information"
+// @Success 201 {object} responses.SinglePriceTierResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/products/prices/{id}/tiers [post]
+// @Security ApiKeyAuth
+func (h *ProductHandler) CreatePriceTier(c *fiber.Ctx) error {
+	productService := h.productService.WithContext(c.UserContext())
+
+	// Parse price ID
+	priceID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid price ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	// Parse request
+	var req requests.CreatePriceTierRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+	}
+
+	tier, err := productService.CreatePriceTier(priceID, req.MinQuantity, req.UnitPrice)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to create price tier",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(responses.SinglePriceTierResponse{
+		Success: true,
+		Message: "Price tier created successfully",
+		Data:    responses.ConvertToPriceTierResponse(*tier),
+	})
+}
+
+// UpdatePriceTier godoc
+// @Summary Update a quantity break of a price
+// @Description Change the minimum quantity or unit price of a price tier; omitted fields are left unchanged
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Price tier ID"
+// @Param tier body requests.UpdatePriceTierRequest true "Updated price tier information"
+// @Success 200 {object} responses.SinglePriceTierResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/products/prices/tiers/{id} [put]
+// @Security ApiKeyAuth
+func (h *ProductHandler) UpdatePriceTier(c *fiber.Ctx) error {
+	productService := h.productService.WithContext(c.UserContext())
+
+	// Parse price tier ID
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid price tier ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	// Parse request
+	var req requests.UpdatePriceTierRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+	}
+
+	tier, err := productService.UpdatePriceTier(id, req.MinQuantity, req.UnitPrice)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to update price tier",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.SinglePriceTierResponse{
+		Success: true,
+		Message: "Price tier updated successfully",
+		Data:    responses.ConvertToPriceTierResponse(*tier),
+	})
+}
+
+// DeletePriceTier godoc
+// @Summary Delete a quantity break of a price
+// @Description Remove a price tier; order lines reaching its minimum quantity fall back to the next lower tier or the base price
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Price tier ID"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/products/prices/tiers/{id} [delete]
+// @Security ApiKeyAuth
+func (h *ProductHandler) DeletePriceTier(c *fiber.Ctx) error {
+	productService := h.productService.WithContext(c.UserContext())
+
+	// Parse price tier ID
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid price tier ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := productService.DeletePriceTier(id); err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to delete price tier",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.SuccessResponse{
+		Success: true,
+		Message: "Price tier deleted successfully",
 	})
 }
 
@@ -977,13 +1913,15 @@ func (h *ProductHandler) DeletePrice(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path string true "Product ID"
-// @Success 200 {object} responses.SuccessResponse{data=[]ProductImage} "Returns an array of product images with URLs, filenames, and metadata"
+// @Success 200 {object} responses.ImagesResponse "Returns an array of product images with URLs, filenames, and metadata"
 // @Failure 400 {object} responses.ErrorResponse "Invalid product ID format"
 // @Failure 404 {object} responses.ErrorResponse "Product not found"
 // @Failure 500 {object} responses.ErrorResponse "Server error"
 // @Router /api/products/{id}/images [get]
 // @Security ApiKeyAuth
 func (h *ProductHandler) GetProductImages(c *fiber.Ctx) error {
+	productService := h.productService.WithContext(c.UserContext())
+
 	// Parse product ID
 	idStr := c.Params("id")
 	id, err := uuid.Parse(idStr)
@@ -996,7 +1934,7 @@ func (h *ProductHandler) GetProductImages(c *fiber.Ctx) error {
 	}
 
 	// Get product images
-	images, err := h.productService.GetProductImages(id)
+	images, err := productService.GetProductImages(id)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
 			Success: false,
@@ -1006,10 +1944,15 @@ func (h *ProductHandler) GetProductImages(c *fiber.Ctx) error {
 	}
 
 	// Return response
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"success": true,
-		"message": "Product images retrieved successfully",
-		"data":    images,
+	data := make([]responses.ImageResponse, len(images))
+	for i, image := range images {
+		data[i] = responses.ConvertToImageResponse(image)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.ImagesResponse{
+		Success: true,
+		Message: "Product images retrieved successfully",
+		Data:    data,
 	})
 }
 
@@ -1020,10 +1963,10 @@ func (h *ProductHandler) GetProductImages(c *fiber.Ctx) error {
 // @Accept multipart/form-data
 // @Produce json
 // @Param id path string true "Product ID"
-// @Param file formData file true "Image file (supported formats: JPG, PNG, GIF)"
+// @Param file formData file true "Image file (JPEG, PNG, GIF or WebP, detected from its content; at most 10MB by default)"
 // @Param is_primary formData boolean false "Set as primary image (default: false)"
-// @Success 200 {object} responses.SuccessResponse{data=ProductImage} "Returns the uploaded image details including URL and metadata"
-// @Failure 400 {object} responses.ErrorResponse "Invalid request or file format"
+// @Success 201 {object} responses.SingleImageResponse "Returns the uploaded image details including URL and metadata"
+// @Failure 400 {object} responses.ErrorResponse "Invalid request, file too large or file type not allowed"
 // @Failure 404 {object} responses.ErrorResponse "Product not found"
 // @Failure 500 {object} responses.ErrorResponse "Server error"
 // @Router /api/products/{id}/images [post]
@@ -1056,7 +1999,7 @@ func (h *ProductHandler) UploadProductImage(c *fiber.Ctx) error {
 	// Upload the image
 	result, err := h.productService.UploadProductImage(id, file, isPrimary)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
 			Success: false,
 			Message: "Failed to upload product image",
 			Error:   err.Error(),
@@ -1064,10 +2007,19 @@ func (h *ProductHandler) UploadProductImage(c *fiber.Ctx) error {
 	}
 
 	// Return response
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"success": true,
-		"message": "Product image uploaded successfully",
-		"data":    result,
+	image, err := h.productService.GetProductImageByID(result.ImageID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Image uploaded but failed to retrieve details",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(responses.SingleImageResponse{
+		Success: true,
+		Message: "Product image uploaded successfully",
+		Data:    responses.ConvertToImageResponse(*image),
 	})
 }
 
@@ -1079,7 +2031,7 @@ func (h *ProductHandler) UploadProductImage(c *fiber.Ctx) error {
 // @Produce json
 // @Param id path string true "Product ID"
 // @Param imageId path string true "Image ID"
-// @Success 200 {object} responses.SuccessResponse{data=ProductImage} "Returns the updated image details"
+// @Success 200 {object} responses.SingleImageResponse "Returns the updated image details"
 // @Failure 400 {object} responses.ErrorResponse "Invalid ID format"
 // @Failure 404 {object} responses.ErrorResponse "Product or image not found"
 // @Failure 500 {object} responses.ErrorResponse "Server error"
@@ -1119,10 +2071,19 @@ func (h *ProductHandler) SetPrimaryProductImage(c *fiber.Ctx) error {
 	}
 
 	// Return response
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"success": true,
-		"message": "Primary image set successfully",
-		"data":    result,
+	image, err := h.productService.GetProductImageByID(result.ImageID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Primary image set but failed to retrieve details",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.SingleImageResponse{
+		Success: true,
+		Message: "Primary image set successfully",
+		Data:    responses.ConvertToImageResponse(*image),
 	})
 }
 
@@ -1200,7 +2161,7 @@ func (h *ProductHandler) ReorderProductImages(c *fiber.Ctx) error {
 // @Produce json
 // @Param id path string true "Product ID"
 // @Param imageId path string true "Image ID"
-// @Success 200 {object} responses.SuccessResponse{data=services.ProductImageResult} "Image deleted successfully"
+// @Success 200 {object} responses.SuccessResponse "Image deleted successfully"
 // @Failure 400 {object} responses.ErrorResponse "Invalid ID format"
 // @Failure 404 {object} responses.ErrorResponse "Product or image not found"
 // @Failure 500 {object} responses.ErrorResponse "Server error"
@@ -1230,7 +2191,7 @@ func (h *ProductHandler) DeleteProductImage(c *fiber.Ctx) error {
 	}
 
 	// Delete the image
-	result, err := h.productService.DeleteProductImage(imageID, productID)
+	_, err = h.productService.DeleteProductImage(imageID, productID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
 			Success: false,
@@ -1240,23 +2201,22 @@ func (h *ProductHandler) DeleteProductImage(c *fiber.Ctx) error {
 	}
 
 	// Return response
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"success": true,
-		"message": "Image deleted successfully",
-		"data":    result,
+	return c.Status(fiber.StatusOK).JSON(responses.SuccessResponse{
+		Success: true,
+		Message: "Image deleted successfully",
 	})
 }
 
 // UploadMultipleProductImages godoc
 // @Summary Upload multiple images for a product
-// @Description Upload multiple images for a product at once. Images are stored at /uploads/products/ path.
+// @Description Upload multiple images for a product at once. Images are stored at /uploads/products/ path. Each file is processed independently; the files field reports the outcome of every file so the client can tell which ones were rejected.
 // @Tags product-images
 // @Accept multipart/form-data
 // @Produce json
 // @Param id path string true "Product ID"
 // @Param files formData file true "Image files (supported formats: JPG, PNG, GIF) - can upload multiple files"
 // @Param primary_index formData integer false "Index of the image to set as primary (0-based, default: -1 which means don't set any as primary)"
-// @Success 200 {object} responses.SuccessResponse{data=services.MultipleProductImageResult} "Returns details of all uploaded images"
+// @Success 201 {object} responses.ImageUploadResponse "Returns details of all uploaded images and the outcome of each file"
 // @Failure 400 {object} responses.ErrorResponse "Invalid request or file format"
 // @Failure 404 {object} responses.ErrorResponse "Product not found"
 // @Failure 500 {object} responses.ErrorResponse "Server error"
@@ -1312,7 +2272,16 @@ func (h *ProductHandler) UploadMultipleProductImages(c *fiber.Ctx) error {
 	// Upload the images
 	result, err := h.productService.UploadMultipleProductImages(id, files, primaryIndex)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+		// Report the outcome of each file when every file was rejected
+		if result != nil && len(result.Files) > 0 {
+			return c.Status(ErrorStatus(err)).JSON(responses.ImageUploadResponse{
+				Success: false,
+				Message: "Failed to upload product images",
+				Error:   err.Error(),
+				Data:    convertMultipleImageUploadResult(result),
+			})
+		}
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
 			Success: false,
 			Message: "Failed to upload product images",
 			Error:   err.Error(),
@@ -1320,9 +2289,125 @@ func (h *ProductHandler) UploadMultipleProductImages(c *fiber.Ctx) error {
 	}
 
 	// Return response
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"success": true,
-		"message": "Product images uploaded successfully",
-		"data":    result,
+	return c.Status(fiber.StatusCreated).JSON(responses.ImageUploadResponse{
+		Success: true,
+		Message: result.Message,
+		Data:    convertMultipleImageUploadResult(result),
+	})
+}
+
+// BulkUploadProductImages godoc
+// @Summary Upload images for multiple products by SKU
+// @Description Upload images for several products at once, e.g. when migrating a folder of images named by SKU. Each file is attached to the product whose SKU it is mapped to; files missing from the mapping are matched by their name without extension. A product without images gets its first uploaded file as primary image. Each file is processed independently; the files field reports the outcome of every file.
+// @Tags product-images
+// @Accept multipart/form-data
+// @Produce json
+// @Param files formData file true "Image files (supported formats: JPG, PNG, GIF) - can upload multiple files"
+// @Param mapping formData string false "JSON object mapping file names to product SKUs, e.g. {\"front.jpg\":\"SHIRT-001\"}"
+// @Success 201 {object} responses.ImageUploadResponse "Returns the outcome of each file"
+// @Failure 400 {object} responses.ErrorResponse "Invalid request or mapping"
+// @Failure 500 {object} responses.ErrorResponse "Server error"
+// @Router /api/products/images/bulk [post]
+// @Security ApiKeyAuth
+func (h *ProductHandler) BulkUploadProductImages(c *fiber.Ctx) error {
+	// Get the files from the request
+	form, err := c.MultipartForm()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to parse multipart form",
+			Error:   err.Error(),
+		})
+	}
+
+	files := form.File["files"]
+	if len(files) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "No files provided",
+			Error:   "No files were uploaded",
+		})
+	}
+
+	// Parse the optional filename to SKU mapping
+	mapping := make(map[string]string)
+	if mappingStr := c.FormValue("mapping"); mappingStr != "" {
+		if err := json.Unmarshal([]byte(mappingStr), &mapping); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+				Success: false,
+				Message: "Invalid mapping",
+				Error:   "mapping must be a JSON object of file names to SKUs",
+			})
+		}
+	}
+
+	result, err := h.productService.BulkUploadProductImages(files, mapping)
+	if err != nil {
+		// Report the outcome of each file when every file was rejected
+		if result != nil && len(result.Files) > 0 {
+			return c.Status(fiber.StatusInternalServerError).JSON(responses.ImageUploadResponse{
+				Success: false,
+				Message: "Failed to upload product images",
+				Error:   err.Error(),
+				Data:    convertBulkImageUploadResult(result),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to upload product images",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(responses.ImageUploadResponse{
+		Success: true,
+		Message: result.Message,
+		Data:    convertBulkImageUploadResult(result),
 	})
 }
+
+// convertMultipleImageUploadResult converts the outcome of a multiple image upload to its response format
+func convertMultipleImageUploadResult(result *services.MultipleProductImageResult) responses.ImageUploadData {
+	data := responses.ImageUploadData{
+		Files:    make([]responses.ImageUploadFileResponse, len(result.Files)),
+		Uploaded: len(result.Images),
+		Failed:   result.Failed,
+	}
+	for i, file := range result.Files {
+		data.Files[i] = responses.ImageUploadFileResponse{
+			Index:     file.Index,
+			Filename:  file.Filename,
+			ProductID: &result.ProductID,
+			Success:   file.Success,
+			Error:     file.Error,
+			ImageID:   file.ImageID,
+			URL:       file.URL,
+			IsPrimary: file.IsPrimary,
+		}
+	}
+	return data
+}
+
+// convertBulkImageUploadResult converts the outcome of a bulk image upload to its response format
+func convertBulkImageUploadResult(result *services.BulkProductImageResult) responses.ImageUploadData {
+	data := responses.ImageUploadData{
+		Files:    make([]responses.ImageUploadFileResponse, len(result.Files)),
+		Products: result.Products,
+		Uploaded: result.Uploaded,
+		Failed:   result.Failed,
+	}
+	for i, file := range result.Files {
+		data.Files[i] = responses.ImageUploadFileResponse{
+			Index:     file.Index,
+			Filename:  file.Filename,
+			SKU:       file.SKU,
+			ProductID: file.ProductID,
+			Success:   file.Success,
+			Error:     file.Error,
+			ImageID:   file.ImageID,
+			URL:       file.URL,
+			IsPrimary: file.IsPrimary,
+		}
+	}
+	return data
+}
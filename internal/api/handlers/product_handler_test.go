@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -12,8 +13,11 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/ybds/internal/api/handlers"
+	"github.com/ybds/internal/api/responses"
 	"github.com/ybds/internal/models/product"
 	"github.com/ybds/internal/testutil"
+	"github.com/ybds/pkg/upload"
 )
 
 // mockJWTMiddleware creates a simple JWT middleware for testing
@@ -53,16 +57,14 @@ func TestProductHandler(t *testing.T) {
 		}
 
 		// Return response
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"success": true,
-			"message": "Products retrieved successfully",
-			"data": fiber.Map{
-				"products":    products,
-				"total":       total,
-				"page":        page,
-				"page_size":   pageSize,
-				"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
-			},
+		return c.Status(fiber.StatusOK).JSON(responses.ProductsResponse{
+			Success:    true,
+			Message:    "Products retrieved successfully",
+			Data:       responses.ConvertToProductDetailResponses(products),
+			Total:      total,
+			Page:       page,
+			PageSize:   pageSize,
+			TotalPages: (total + int64(pageSize) - 1) / int64(pageSize),
 		})
 	})
 
@@ -189,11 +191,10 @@ func TestProductHandler(t *testing.T) {
 		assert.Equal(t, true, response["success"])
 		assert.Equal(t, "Products retrieved successfully", response["message"])
 
-		data := response["data"].(map[string]interface{})
-		assert.NotNil(t, data["products"])
-		assert.Equal(t, float64(2), data["total"])
-		assert.Equal(t, float64(1), data["page"])
-		assert.Equal(t, float64(10), data["page_size"])
+		assert.Len(t, response["data"], 2)
+		assert.Equal(t, float64(2), response["total"])
+		assert.Equal(t, float64(1), response["page"])
+		assert.Equal(t, float64(10), response["page_size"])
 
 		// Verify mock expectations
 		mockProductService.AssertExpectations(t)
@@ -418,3 +419,257 @@ func TestProductHandler(t *testing.T) {
 		mockProductService.AssertExpectations(t)
 	})
 }
+
+// TestProductResponseShapes tests that product endpoints return the typed response envelopes
+func TestProductResponseShapes(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	p := &product.Product{Name: "Shape Shirt", SKU: "SHAPE-001", Category: "Shapes"}
+	assert.NoError(t, db.Create(p).Error)
+
+	app := fiber.New()
+	productHandler := handlers.NewProductHandler(db, nil, nil, product.PriceSelectionLatestStart)
+	productHandler.RegisterRoutes(app.Group("/api"), func(c *fiber.Ctx) error {
+		c.Locals("userID", uuid.New())
+		c.Locals("roles", []string{"admin"})
+		return c.Next()
+	})
+
+	send := func(method, path string, body interface{}) (int, map[string]interface{}) {
+		t.Helper()
+
+		var reader *bytes.Reader
+		if body != nil {
+			payload, _ := json.Marshal(body)
+			reader = bytes.NewReader(payload)
+		} else {
+			reader = bytes.NewReader(nil)
+		}
+		req := httptest.NewRequest(method, path, reader)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+
+		var response map[string]interface{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		assert.Equal(t, true, response["success"])
+		return resp.StatusCode, response
+	}
+
+	t.Run("List", func(t *testing.T) {
+		status, response := send(http.MethodGet, "/api/products?category=Shapes", nil)
+		assert.Equal(t, http.StatusOK, status)
+		if items, ok := response["data"].([]interface{}); assert.True(t, ok) && assert.Len(t, items, 1) {
+			assert.Equal(t, p.ID.String(), items[0].(map[string]interface{})["id"])
+		}
+		assert.Equal(t, float64(1), response["total"])
+		assert.Equal(t, float64(1), response["page"])
+		assert.Equal(t, float64(10), response["page_size"])
+		assert.Equal(t, float64(1), response["total_pages"])
+	})
+
+	t.Run("Detail", func(t *testing.T) {
+		status, response := send(http.MethodGet, "/api/products/"+p.ID.String(), nil)
+		assert.Equal(t, http.StatusOK, status)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, p.ID.String(), data["id"])
+		assert.Equal(t, "SHAPE-001", data["sku"])
+	})
+
+	t.Run("Inventory", func(t *testing.T) {
+		status, response := send(http.MethodPost, "/api/products/"+p.ID.String()+"/inventories",
+			map[string]interface{}{"size": "M", "color": "Blue", "quantity": 20, "location": "A1"})
+		assert.Equal(t, http.StatusCreated, status)
+		data := response["data"].(map[string]interface{})
+		assert.NotEmpty(t, data["id"])
+		assert.Equal(t, p.ID.String(), data["product_id"])
+		assert.Equal(t, float64(20), data["quantity"])
+
+		status, response = send(http.MethodDelete, "/api/products/inventories/"+data["id"].(string), nil)
+		assert.Equal(t, http.StatusOK, status)
+		assert.NotContains(t, response, "data")
+	})
+
+	t.Run("Price", func(t *testing.T) {
+		status, response := send(http.MethodPost, "/api/products/"+p.ID.String()+"/prices",
+			map[string]interface{}{"price": 150000, "currency": "VND"})
+		assert.Equal(t, http.StatusCreated, status)
+		data := response["data"].(map[string]interface{})
+		assert.NotEmpty(t, data["id"])
+		assert.Equal(t, p.ID.String(), data["product_id"])
+		assert.Equal(t, float64(150000), data["price"])
+		assert.Equal(t, "VND", data["currency"])
+	})
+
+	t.Run("Images", func(t *testing.T) {
+		// An image uploaded before variants were generated falls back to the original
+		legacy := &product.ProductImage{ProductID: p.ID, URL: "/uploads/products/legacy.jpg", Filename: "legacy.jpg"}
+		assert.NoError(t, db.Create(legacy).Error)
+
+		status, response := send(http.MethodGet, "/api/products/"+p.ID.String()+"/images", nil)
+		assert.Equal(t, http.StatusOK, status)
+		if items, ok := response["data"].([]interface{}); assert.True(t, ok) && assert.Len(t, items, 1) {
+			image := items[0].(map[string]interface{})
+			assert.Equal(t, legacy.URL, image["thumbnail_url"])
+			assert.Equal(t, legacy.URL, image["medium_url"])
+		}
+	})
+}
+
+// TestUpdateInventoryQuantity tests that an omitted quantity is left unchanged while an explicit zero empties the stock
+func TestUpdateInventoryQuantity(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	p := &product.Product{Name: "Stock Shirt", SKU: "STOCK-001", Category: "Stock"}
+	assert.NoError(t, db.Create(p).Error)
+	inv := &product.Inventory{ProductID: p.ID, Size: "M", Color: "Blue", Quantity: 10}
+	assert.NoError(t, db.Create(inv).Error)
+
+	app := fiber.New()
+	productHandler := handlers.NewProductHandler(db, nil, nil, product.PriceSelectionLatestStart)
+	productHandler.RegisterRoutes(app.Group("/api"), productMockJWTMiddleware)
+
+	update := func(body map[string]interface{}) (int, map[string]interface{}) {
+		t.Helper()
+
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPut, "/api/products/inventories/"+inv.ID.String(), bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+
+		var response map[string]interface{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		return resp.StatusCode, response
+	}
+
+	t.Run("Omitted", func(t *testing.T) {
+		status, response := update(map[string]interface{}{"location": "B2"})
+		assert.Equal(t, http.StatusOK, status)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, float64(10), data["quantity"])
+		assert.Equal(t, "B2", data["location"])
+	})
+
+	t.Run("Zero", func(t *testing.T) {
+		status, response := update(map[string]interface{}{"quantity": 0})
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, float64(0), response["data"].(map[string]interface{})["quantity"])
+	})
+
+	t.Run("Positive", func(t *testing.T) {
+		status, response := update(map[string]interface{}{"quantity": 7})
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, float64(7), response["data"].(map[string]interface{})["quantity"])
+	})
+
+	t.Run("Negative", func(t *testing.T) {
+		status, _ := update(map[string]interface{}{"quantity": -1})
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+}
+
+// TestRestoreProductAdminOnly tests that only admins list deleted products and restore them
+func TestRestoreProductAdminOnly(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	p := &product.Product{Name: "Restore Shirt", SKU: "RESTORE-001", Category: "Restores"}
+	assert.NoError(t, db.Create(p).Error)
+	assert.NoError(t, db.Delete(p).Error)
+
+	app := fiber.New()
+	productHandler := handlers.NewProductHandler(db, nil, nil, product.PriceSelectionLatestStart)
+	productHandler.RegisterRoutes(app.Group("/api"), func(c *fiber.Ctx) error {
+		c.Locals("userID", uuid.New())
+		c.Locals("roles", []string{c.Get("X-Test-Role")})
+		return c.Next()
+	})
+
+	send := func(method, path, role string) (int, map[string]interface{}) {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-Test-Role", role)
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+
+		var response map[string]interface{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		return resp.StatusCode, response
+	}
+
+	status, response := send(http.MethodGet, "/api/products?category=Restores", "admin")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, float64(0), response["total"])
+
+	status, _ = send(http.MethodGet, "/api/products?category=Restores&include_deleted=true", "agent")
+	assert.Equal(t, http.StatusForbidden, status)
+
+	status, response = send(http.MethodGet, "/api/products?category=Restores&include_deleted=true", "admin")
+	assert.Equal(t, http.StatusOK, status)
+	if items, ok := response["data"].([]interface{}); assert.True(t, ok) && assert.Len(t, items, 1) {
+		assert.NotEmpty(t, items[0].(map[string]interface{})["deleted_at"])
+	}
+
+	status, _ = send(http.MethodPost, "/api/products/"+p.ID.String()+"/restore", "agent")
+	assert.Equal(t, http.StatusForbidden, status)
+
+	status, response = send(http.MethodPost, "/api/products/"+p.ID.String()+"/restore", "admin")
+	assert.Equal(t, http.StatusOK, status)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, p.ID.String(), data["id"])
+	assert.NotContains(t, data, "deleted_at")
+
+	status, _ = send(http.MethodPost, "/api/products/"+p.ID.String()+"/restore", "admin")
+	assert.Equal(t, http.StatusConflict, status)
+}
+
+// TestUploadMultipleProductImagesRejected tests that rejected uploads are reported as client errors
+func TestUploadMultipleProductImagesRejected(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	uploadService, err := upload.NewService(upload.NewConfig(t.TempDir()))
+	assert.NoError(t, err)
+
+	p := &product.Product{Name: "Upload Shirt", SKU: "UPLOAD-001", Category: "Shirts"}
+	assert.NoError(t, db.Create(p).Error)
+
+	app := fiber.New()
+	productHandler := handlers.NewProductHandler(db, nil, uploadService, product.PriceSelectionLatestStart)
+	productHandler.RegisterRoutes(app.Group("/api"), productMockJWTMiddleware)
+
+	send := func(productID uuid.UUID) (int, map[string]interface{}) {
+		t.Helper()
+
+		var form bytes.Buffer
+		writer := multipart.NewWriter(&form)
+		for _, name := range []string{"notes.txt", "readme.txt"} {
+			part, err := writer.CreateFormFile("files", name)
+			assert.NoError(t, err)
+			part.Write([]byte("this is not an image"))
+		}
+		assert.NoError(t, writer.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/api/products/"+productID.String()+"/images/multiple", &form)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+
+		var response map[string]interface{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		return resp.StatusCode, response
+	}
+
+	// Every file is rejected
+	status, response := send(p.ID)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, false, response["success"])
+	if data, ok := response["data"].(map[string]interface{}); assert.True(t, ok) {
+		assert.Len(t, data["files"], 2)
+	}
+
+	// Unknown product
+	status, _ = send(uuid.New())
+	assert.Equal(t, http.StatusNotFound, status)
+}
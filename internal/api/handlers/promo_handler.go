@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ybds/internal/api/requests"
+	"github.com/ybds/internal/api/responses"
+	"github.com/ybds/internal/models/order"
+	"github.com/ybds/internal/services"
+	"gorm.io/gorm"
+)
+
+// PromoHandler handles HTTP requests related to promo codes
+type PromoHandler struct {
+	promoService *services.PromoService
+}
+
+// NewPromoHandler creates a new instance of PromoHandler
+func NewPromoHandler(db *gorm.DB) *PromoHandler {
+	return &PromoHandler{
+		promoService: services.NewPromoService(db),
+	}
+}
+
+// RegisterRoutes registers all routes related to promo codes
+func (h *PromoHandler) RegisterRoutes(router fiber.Router, authMiddleware fiber.Handler) {
+	promoCodes := router.Group("/promo-codes")
+	promoCodes.Use(authMiddleware)
+
+	promoCodes.Post("/", h.CreatePromoCode)
+	promoCodes.Get("/", h.GetPromoCodes)
+	promoCodes.Get("/:id", h.GetPromoCodeByID)
+	promoCodes.Put("/:id", h.UpdatePromoCode)
+	promoCodes.Delete("/:id", h.DeletePromoCode)
+}
+
+// promoCodeDetails converts a promo code request to the details used by the promo service
+func promoCodeDetails(req requests.PromoCodeRequest) services.PromoCodeDetails {
+	var startDate time.Time
+	if req.StartDate != nil {
+		startDate = *req.StartDate
+	}
+
+	return services.PromoCodeDetails{
+		Code:           req.Code,
+		Type:           order.PromoType(req.Type),
+		Value:          req.Value,
+		StartDate:      startDate,
+		EndDate:        req.EndDate,
+		MaxUses:        req.MaxUses,
+		MinOrderAmount: req.MinOrderAmount,
+		Description:    req.Description,
+	}
+}
+
+// CreatePromoCode godoc
+// @Summary Create a promo code
+// @Description Create a promo code that takes a percent or a fixed amount off an order. Codes are matched case-insensitively and may only contain letters, digits, dashes and underscores. The start date defaults to now; without an end date the code never expires and a max_uses of 0 allows unlimited uses.
+// @Tags promo-codes
+// @Accept json
+// @Produce json
+// @Param promo_code body requests.PromoCodeRequest true "Promo code"
+// @Success 201 {object} responses.SinglePromoCodeResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/admin/promo-codes [post]
+// @Security ApiKeyAuth
+func (h *PromoHandler) CreatePromoCode(c *fiber.Ctx) error {
+	var req requests.PromoCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	var createdBy *uuid.UUID
+	if userID, ok := c.Locals("userID").(uuid.UUID); ok {
+		createdBy = &userID
+	}
+
+	promo, err := h.promoService.CreatePromoCode(promoCodeDetails(req), createdBy)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to create promo code",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(responses.SinglePromoCodeResponse{
+		Success: true,
+		Message: "Promo code created successfully",
+		Data:    responses.ConvertToPromoCodeResponse(*promo),
+	})
+}
+
+// GetPromoCodes godoc
+// @Summary List promo codes
+// @Description List the promo codes, newest first, with how often they have been used
+// @Tags promo-codes
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} responses.PromoCodesResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/admin/promo-codes [get]
+// @Security ApiKeyAuth
+func (h *PromoHandler) GetPromoCodes(c *fiber.Ctx) error {
+	// Parse pagination parameters
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(c.Query("page_size", "10"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10
+	}
+
+	promoCodes, total, err := h.promoService.GetPromoCodes(page, pageSize)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to get promo codes",
+			Error:   err.Error(),
+		})
+	}
+
+	data := make([]responses.PromoCodeResponse, len(promoCodes))
+	for i, promo := range promoCodes {
+		data[i] = responses.ConvertToPromoCodeResponse(promo)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.PromoCodesResponse{
+		Success:    true,
+		Message:    "Promo codes retrieved successfully",
+		Data:       data,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int64(math.Ceil(float64(total) / float64(pageSize))),
+	})
+}
+
+// GetPromoCodeByID godoc
+// @Summary Get a promo code
+// @Description Get a promo code by ID
+// @Tags promo-codes
+// @Accept json
+// @Produce json
+// @Param id path string true "Promo code ID"
+// @Success 200 {object} responses.SinglePromoCodeResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/admin/promo-codes/{id} [get]
+// @Security ApiKeyAuth
+func (h *PromoHandler) GetPromoCodeByID(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid promo code ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	promo, err := h.promoService.GetPromoCodeByID(id)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to get promo code",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.SinglePromoCodeResponse{
+		Success: true,
+		Message: "Promo code retrieved successfully",
+		Data:    responses.ConvertToPromoCodeResponse(*promo),
+	})
+}
+
+// UpdatePromoCode godoc
+// @Summary Update a promo code
+// @Description Replace the details of a promo code. Its used count is kept; lowering max_uses to it or below stops further use. Orders the code was already applied to keep their discount.
+// @Tags promo-codes
+// @Accept json
+// @Produce json
+// @Param id path string true "Promo code ID"
+// @Param promo_code body requests.PromoCodeRequest true "Promo code"
+// @Success 200 {object} responses.SinglePromoCodeResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/admin/promo-codes/{id} [put]
+// @Security ApiKeyAuth
+func (h *PromoHandler) UpdatePromoCode(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid promo code ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	var req requests.PromoCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	var updatedBy *uuid.UUID
+	if userID, ok := c.Locals("userID").(uuid.UUID); ok {
+		updatedBy = &userID
+	}
+
+	promo, err := h.promoService.UpdatePromoCode(id, promoCodeDetails(req), updatedBy)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to update promo code",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.SinglePromoCodeResponse{
+		Success: true,
+		Message: "Promo code updated successfully",
+		Data:    responses.ConvertToPromoCodeResponse(*promo),
+	})
+}
+
+// DeletePromoCode godoc
+// @Summary Delete a promo code
+// @Description Delete a promo code so it can no longer be applied. Orders it was applied to keep their discount.
+// @Tags promo-codes
+// @Accept json
+// @Produce json
+// @Param id path string true "Promo code ID"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/admin/promo-codes/{id} [delete]
+// @Security ApiKeyAuth
+func (h *PromoHandler) DeletePromoCode(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid promo code ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := h.promoService.DeletePromoCode(id); err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to delete promo code",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.SuccessResponse{
+		Success: true,
+		Message: "Promo code deleted successfully",
+	})
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ybds/internal/api/responses"
+	"github.com/ybds/internal/services"
+	"gorm.io/gorm"
+)
+
+// RecommendationHandler handles HTTP requests related to product recommendations
+type RecommendationHandler struct {
+	recommendationService *services.RecommendationService
+}
+
+// NewRecommendationHandler creates a new instance of RecommendationHandler
+func NewRecommendationHandler(orderDB, productDB *gorm.DB) *RecommendationHandler {
+	return &RecommendationHandler{
+		recommendationService: services.NewRecommendationService(orderDB, productDB),
+	}
+}
+
+// RegisterRoutes registers all routes related to product recommendations
+func (h *RecommendationHandler) RegisterRoutes(router fiber.Router, authMiddleware fiber.Handler) {
+	router.Get("/products/:id/related", authMiddleware, h.GetRelatedProducts)
+}
+
+// GetRelatedProducts godoc
+// @Summary Get products frequently bought together
+// @Description Get the products bought in the same orders as the given product, ranked by the number of orders they share with it. Canceled orders and the product itself are excluded.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param limit query int false "Maximum number of products (default 10, max 50)"
+// @Success 200 {object} responses.RelatedProductsResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/products/{id}/related [get]
+// @Security ApiKeyAuth
+func (h *RecommendationHandler) GetRelatedProducts(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid product ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	related, err := h.recommendationService.GetRelatedProducts(id, c.QueryInt("limit", services.DefaultRelatedProductsLimit))
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to get related products",
+			Error:   err.Error(),
+		})
+	}
+
+	data := make([]responses.RelatedProductResponse, len(related))
+	for i, r := range related {
+		data[i] = responses.RelatedProductResponse{
+			ID:         r.Product.ID,
+			Name:       r.Product.Name,
+			SKU:        r.Product.SKU,
+			Category:   r.Product.Category,
+			ImageURL:   r.Product.PrimaryImageURL(),
+			OrderCount: r.OrderCount,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.RelatedProductsResponse{
+		Success: true,
+		Message: "Related products retrieved successfully",
+		Data:    data,
+	})
+}
@@ -0,0 +1,464 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ybds/internal/api/responses"
+	"github.com/ybds/internal/services"
+	"gorm.io/gorm"
+)
+
+// ReportHandler handles HTTP requests related to reports
+type ReportHandler struct {
+	reportService *services.ReportService
+}
+
+// NewReportHandler creates a new instance of ReportHandler
+func NewReportHandler(orderDB, productDB *gorm.DB, reorderMultiplier int) *ReportHandler {
+	return &ReportHandler{
+		reportService: services.NewReportService(orderDB, productDB, reorderMultiplier),
+	}
+}
+
+// RegisterRoutes registers all routes related to reports
+func (h *ReportHandler) RegisterRoutes(router fiber.Router, authMiddleware fiber.Handler) {
+	reports := router.Group("/reports")
+	reports.Use(authMiddleware)
+
+	reports.Get("/low-stock", h.GetLowStockReport)
+	reports.Get("/low-stock-trend", h.GetLowStockTrend)
+	reports.Get("/revenue-by-payment", h.GetRevenueByPayment)
+	reports.Get("/shipment-sla", h.GetShipmentSLAReport)
+	reports.Get("/inventory-value", h.GetInventoryValueReport)
+	reports.Get("/sales-summary", h.GetSalesSummary)
+	reports.Get("/top-products", h.GetTopProducts)
+}
+
+// GetLowStockReport godoc
+// @Summary Get low stock report
+// @Description List all inventories at or below their low stock threshold, joined with product information, with a suggested reorder quantity
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Success 200 {object} responses.LowStockReportResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/reports/low-stock [get]
+// @Security ApiKeyAuth
+func (h *ReportHandler) GetLowStockReport(c *fiber.Ctx) error {
+	items, err := h.reportService.GetLowStockReport()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve low stock report",
+			Error:   err.Error(),
+		})
+	}
+
+	// Convert to response format
+	data := make([]responses.LowStockItemResponse, len(items))
+	for i, item := range items {
+		data[i] = responses.LowStockItemResponse{
+			InventoryID:       item.InventoryID,
+			ProductID:         item.ProductID,
+			ProductName:       item.ProductName,
+			SKU:               item.SKU,
+			Category:          item.Category,
+			Size:              item.Size,
+			Color:             item.Color,
+			Location:          item.Location,
+			Quantity:          item.Quantity,
+			LowStockThreshold: item.LowStockThreshold,
+			SuggestedQuantity: item.SuggestedQuantity,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.LowStockReportResponse{
+		Success: true,
+		Message: "Low stock report retrieved successfully",
+		Data:    data,
+		Total:   len(data),
+	})
+}
+
+// GetLowStockTrend godoc
+// @Summary Get daily low stock trend
+// @Description Get the number of inventories at or below their low stock threshold at the end of each day, derived from the inventory transaction ledger. Defaults to the last 30 days; the range may cover at most 366 days.
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param from_date query string false "Start date (YYYY-MM-DD)"
+// @Param to_date query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} responses.LowStockTrendResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/reports/low-stock-trend [get]
+// @Security ApiKeyAuth
+func (h *ReportHandler) GetLowStockTrend(c *fiber.Ctx) error {
+	fromDate, toDate, err := parseReportDateRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid date range",
+			Error:   err.Error(),
+		})
+	}
+
+	// Default to the last 30 days
+	if toDate == nil {
+		now := time.Now()
+		toDate = &now
+	}
+	if fromDate == nil {
+		from := toDate.AddDate(0, 0, -29)
+		fromDate = &from
+	}
+
+	trend, err := h.reportService.GetLowStockTrend(*fromDate, *toDate)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve low stock trend",
+			Error:   err.Error(),
+		})
+	}
+
+	// Convert to response format
+	data := make([]responses.LowStockTrendDayResponse, len(trend))
+	for i, day := range trend {
+		data[i] = responses.LowStockTrendDayResponse{
+			Date:          day.Date.Format("2006-01-02"),
+			LowStockCount: day.LowStockCount,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.LowStockTrendResponse{
+		Success:  true,
+		Message:  "Low stock trend retrieved successfully",
+		Data:     data,
+		FromDate: fromDate.Format("2006-01-02"),
+		ToDate:   toDate.Format("2006-01-02"),
+	})
+}
+
+// GetRevenueByPayment godoc
+// @Summary Get revenue by payment method
+// @Description Get the total revenue and order count of non-canceled orders grouped by payment method
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param from_date query string false "Start date (YYYY-MM-DD)"
+// @Param to_date query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} responses.RevenueByPaymentResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/reports/revenue-by-payment [get]
+// @Security ApiKeyAuth
+func (h *ReportHandler) GetRevenueByPayment(c *fiber.Ctx) error {
+	fromDate, toDate, err := parseReportDateRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid date range",
+			Error:   err.Error(),
+		})
+	}
+
+	revenues, err := h.reportService.GetRevenueByPaymentMethod(fromDate, toDate)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve revenue by payment method",
+			Error:   err.Error(),
+		})
+	}
+
+	// Convert to response format and compute the overall totals
+	data := responses.RevenueByPaymentData{
+		Methods: make([]responses.PaymentMethodRevenueResponse, len(revenues)),
+	}
+	for i, revenue := range revenues {
+		data.Methods[i] = responses.PaymentMethodRevenueResponse{
+			PaymentMethod: string(revenue.PaymentMethod),
+			OrderCount:    revenue.OrderCount,
+			TotalRevenue:  revenue.TotalRevenue,
+		}
+		data.OrderCount += revenue.OrderCount
+		data.TotalRevenue += revenue.TotalRevenue
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.RevenueByPaymentResponse{
+		Success: true,
+		Message: "Revenue by payment method retrieved successfully",
+		Data:    data,
+	})
+}
+
+// GetShipmentSLAReport godoc
+// @Summary Get shipment SLA report
+// @Description List orders awaiting shipment that have had no tracking number for more than the given number of hours, oldest first
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param hours query int false "SLA in hours (default 24)"
+// @Success 200 {object} responses.ShipmentSLAReportResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/reports/shipment-sla [get]
+// @Security ApiKeyAuth
+func (h *ReportHandler) GetShipmentSLAReport(c *fiber.Ctx) error {
+	hours := services.DefaultShipmentSLAHours
+	if value := c.Query("hours"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+				Success: false,
+				Message: "Invalid hours",
+				Error:   "hours must be a positive integer",
+			})
+		}
+		hours = parsed
+	}
+
+	breaches, err := h.reportService.GetShipmentSLABreaches(hours)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve shipment SLA report",
+			Error:   err.Error(),
+		})
+	}
+
+	// Convert to response format
+	data := make([]responses.ShipmentSLABreachResponse, len(breaches))
+	for i, breach := range breaches {
+		data[i] = responses.ShipmentSLABreachResponse{
+			OrderID:       breach.OrderID,
+			OrderNumber:   breach.OrderNumber,
+			CustomerName:  breach.CustomerName,
+			CustomerPhone: breach.CustomerPhone,
+			Status:        string(breach.Status),
+			CreatedBy:     breach.CreatedBy,
+			WaitingSince:  breach.WaitingSince,
+			HoursWaiting:  breach.HoursWaiting,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.ShipmentSLAReportResponse{
+		Success:  true,
+		Message:  "Shipment SLA report retrieved successfully",
+		Data:     data,
+		Total:    len(data),
+		SLAHours: hours,
+	})
+}
+
+// GetInventoryValueReport godoc
+// @Summary Get inventory value report
+// @Description Get the total value of the on-hand stock (quantity * current price) with a per-category breakdown. Inventories of products without a current price are excluded and counted.
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Success 200 {object} responses.InventoryValueResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/reports/inventory-value [get]
+// @Security ApiKeyAuth
+func (h *ReportHandler) GetInventoryValueReport(c *fiber.Ctx) error {
+	report, err := h.reportService.GetInventoryValue()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve inventory value report",
+			Error:   err.Error(),
+		})
+	}
+
+	// Convert to response format
+	data := responses.InventoryValueData{
+		TotalValue:    report.TotalValue,
+		TotalQuantity: report.TotalQuantity,
+		ExcludedCount: report.ExcludedCount,
+		Categories:    make([]responses.CategoryInventoryValueResponse, len(report.Categories)),
+	}
+	for i, category := range report.Categories {
+		data.Categories[i] = responses.CategoryInventoryValueResponse{
+			Category:      category.Category,
+			Quantity:      category.Quantity,
+			TotalValue:    category.TotalValue,
+			ExcludedCount: category.ExcludedCount,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.InventoryValueResponse{
+		Success: true,
+		Message: "Inventory value report retrieved successfully",
+		Data:    data,
+	})
+}
+
+// GetSalesSummary godoc
+// @Summary Get sales summary
+// @Description Get the order count, gross total, total discount and net total of the orders created in the date range, grouped by day, week or month in chronological order. Canceled orders are excluded from the revenue and counted separately; periods without orders are left out.
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD)"
+// @Param group_by query string false "Period to group by: day (default), week or month"
+// @Success 200 {object} responses.SalesSummaryResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/reports/sales-summary [get]
+// @Security ApiKeyAuth
+func (h *ReportHandler) GetSalesSummary(c *fiber.Ctx) error {
+	fromDate, toDate, err := parseDateRange(c, "from", "to")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid date range",
+			Error:   err.Error(),
+		})
+	}
+
+	summary, err := h.reportService.GetSalesSummary(fromDate, toDate, services.SalesGroupBy(c.Query("group_by")))
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve sales summary",
+			Error:   err.Error(),
+		})
+	}
+
+	// Convert to response format
+	data := responses.SalesSummaryData{
+		GroupBy:       string(summary.GroupBy),
+		Buckets:       make([]responses.SalesBucketResponse, len(summary.Buckets)),
+		OrderCount:    summary.OrderCount,
+		GrossTotal:    summary.GrossTotal,
+		TotalDiscount: summary.TotalDiscount,
+		NetTotal:      summary.NetTotal,
+		CanceledCount: summary.CanceledCount,
+	}
+	for i, bucket := range summary.Buckets {
+		data.Buckets[i] = responses.SalesBucketResponse{
+			PeriodStart:   bucket.PeriodStart.Format("2006-01-02"),
+			OrderCount:    bucket.OrderCount,
+			GrossTotal:    bucket.GrossTotal,
+			TotalDiscount: bucket.TotalDiscount,
+			NetTotal:      bucket.NetTotal,
+			CanceledCount: bucket.CanceledCount,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.SalesSummaryResponse{
+		Success: true,
+		Message: "Sales summary retrieved successfully",
+		Data:    data,
+	})
+}
+
+// GetTopProducts godoc
+// @Summary Get top selling products
+// @Description Get the best selling products of the orders created in the date range with their units sold and revenue at the order prices. Returned units and canceled or returned orders are not counted.
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD)"
+// @Param sort query string false "Rank by quantity (default) or revenue"
+// @Param limit query int false "Maximum number of products (default 10, max 100)"
+// @Success 200 {object} responses.TopProductsResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/reports/top-products [get]
+// @Security ApiKeyAuth
+func (h *ReportHandler) GetTopProducts(c *fiber.Ctx) error {
+	fromDate, toDate, err := parseDateRange(c, "from", "to")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid date range",
+			Error:   err.Error(),
+		})
+	}
+
+	limit := services.DefaultTopProductsLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+				Success: false,
+				Message: "Invalid limit",
+				Error:   "limit must be a positive integer",
+			})
+		}
+		limit = parsed
+	}
+
+	sortBy := services.TopProductsSort(c.Query("sort", string(services.TopProductsByQuantity)))
+	products, err := h.reportService.GetTopProducts(fromDate, toDate, sortBy, limit)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve top products",
+			Error:   err.Error(),
+		})
+	}
+
+	// Convert to response format
+	data := make([]responses.TopProductResponse, len(products))
+	for i, p := range products {
+		data[i] = responses.TopProductResponse{
+			ProductID: p.ProductID,
+			Name:      p.Name,
+			SKU:       p.SKU,
+			UnitsSold: p.UnitsSold,
+			Revenue:   p.Revenue,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.TopProductsResponse{
+		Success: true,
+		Message: "Top products retrieved successfully",
+		Data:    data,
+		Sort:    string(sortBy),
+		Total:   len(data),
+	})
+}
+
+// parseReportDateRange parses the optional from_date and to_date query parameters (YYYY-MM-DD).
+// from_date starts at the beginning of the day and to_date ends at the end of the day.
+func parseReportDateRange(c *fiber.Ctx) (*time.Time, *time.Time, error) {
+	return parseDateRange(c, "from_date", "to_date")
+}
+
+// parseDateRange parses the optional date range in the given query parameters (YYYY-MM-DD).
+// The start date begins at the beginning of the day and the end date ends at the end of the day.
+func parseDateRange(c *fiber.Ctx, fromParam, toParam string) (*time.Time, *time.Time, error) {
+	var fromDate, toDate *time.Time
+
+	if value := c.Query(fromParam); value != "" {
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s must be in YYYY-MM-DD format", fromParam)
+		}
+		fromDate = &date
+	}
+
+	if value := c.Query(toParam); value != "" {
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s must be in YYYY-MM-DD format", toParam)
+		}
+		date = time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 999999999, date.Location())
+		toDate = &date
+	}
+
+	if fromDate != nil && toDate != nil && fromDate.After(*toDate) {
+		return nil, nil, fmt.Errorf("%s must not be after %s", fromParam, toParam)
+	}
+
+	return fromDate, toDate, nil
+}
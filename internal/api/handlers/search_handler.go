@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/ybds/internal/api/responses"
+	"github.com/ybds/internal/services"
+	"gorm.io/gorm"
+)
+
+// SearchHandler handles HTTP requests related to searching across resources
+type SearchHandler struct {
+	searchService *services.SearchService
+}
+
+// NewSearchHandler creates a new instance of SearchHandler
+func NewSearchHandler(orderDB, productDB *gorm.DB) *SearchHandler {
+	return &SearchHandler{
+		searchService: services.NewSearchService(orderDB, productDB),
+	}
+}
+
+// RegisterRoutes registers all routes related to search
+func (h *SearchHandler) RegisterRoutes(router fiber.Router, authMiddleware fiber.Handler) {
+	search := router.Group("/search")
+	search.Use(authMiddleware)
+
+	search.Get("/", h.Search)
+}
+
+// Search godoc
+// @Summary Search orders and products
+// @Description Search orders by order number, customer phone or customer name and products by name or SKU in one call
+// @Tags search
+// @Accept json
+// @Produce json
+// @Param q query string true "Search query"
+// @Param limit query int false "Maximum results per section (default 5, max 20)"
+// @Success 200 {object} responses.SearchResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/search [get]
+// @Security ApiKeyAuth
+func (h *SearchHandler) Search(c *fiber.Ctx) error {
+	result, err := h.searchService.Search(c.Query("q"), c.QueryInt("limit", services.DefaultSearchLimit))
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to search",
+			Error:   err.Error(),
+		})
+	}
+
+	// Convert to response format
+	data := responses.SearchData{
+		Query:    result.Query,
+		Orders:   make([]responses.OrderSearchResult, len(result.Orders)),
+		Products: make([]responses.ProductSearchResult, len(result.Products)),
+	}
+	for i, o := range result.Orders {
+		data.Orders[i] = responses.OrderSearchResult{
+			ID:            o.ID,
+			OrderNumber:   o.OrderNumber,
+			CustomerName:  o.CustomerName,
+			CustomerPhone: o.CustomerPhone,
+			Status:        string(o.OrderStatus),
+			FinalTotal:    o.FinalTotalAmount,
+			CreatedAt:     o.CreatedAt,
+		}
+	}
+	for i, p := range result.Products {
+		data.Products[i] = responses.ProductSearchResult{
+			ID:       p.ID,
+			Name:     p.Name,
+			SKU:      p.SKU,
+			Category: p.Category,
+			ImageURL: p.PrimaryImageURL(),
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.SearchResponse{
+		Success: true,
+		Message: "Search completed successfully",
+		Data:    data,
+	})
+}
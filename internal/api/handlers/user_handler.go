@@ -14,13 +14,16 @@ import (
 
 // UserHandler handles HTTP requests related to users
 type UserHandler struct {
-	userService *services.UserService
+	userService  *services.UserService
+	orderService *services.OrderService
 }
 
 // NewUserHandler creates a new instance of UserHandler
-func NewUserHandler(db *gorm.DB, notificationService *services.NotificationService) *UserHandler {
+func NewUserHandler(db *gorm.DB, orderDB *gorm.DB, notificationService *services.NotificationService) *UserHandler {
+	userService := services.NewUserService(db, notificationService)
 	return &UserHandler{
-		userService: services.NewUserService(db, notificationService),
+		userService:  userService,
+		orderService: services.NewOrderService(orderDB, nil, userService, nil),
 	}
 }
 
@@ -30,7 +33,9 @@ func (h *UserHandler) RegisterRoutes(router fiber.Router, authMiddleware fiber.H
 	users.Use(authMiddleware)
 
 	users.Get("/", h.GetUsers)
+	users.Get("/telegram/chats", h.GetTelegramChats)
 	users.Get("/:id", h.GetUserByID)
+	users.Get("/:id/workload", h.GetUserWorkload)
 	users.Patch("/:id/telegram", h.UpdateTelegramID)
 }
 
@@ -176,6 +181,112 @@ func (h *UserHandler) GetUserByID(c *fiber.Ctx) error {
 	})
 }
 
+// GetUserWorkload godoc
+// @Summary Get a user's order workload
+// @Description Get the number of orders created by or assigned to a user grouped by status, with open and pending totals
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} responses.UserWorkloadResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/admin/users/{id}/workload [get]
+// @Security ApiKeyAuth
+func (h *UserHandler) GetUserWorkload(c *fiber.Ctx) error {
+	// Parse user ID from path
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid user ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	// Get workload from service
+	workload, err := h.orderService.GetAgentWorkload(id)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve user workload",
+			Error:   err.Error(),
+		})
+	}
+
+	// Convert to response format
+	statusCounts := make(map[string]int64, len(workload.StatusCounts))
+	for status, count := range workload.StatusCounts {
+		statusCounts[string(status)] = count
+	}
+
+	// Return response
+	return c.Status(fiber.StatusOK).JSON(responses.UserWorkloadResponse{
+		Success: true,
+		Message: "User workload retrieved successfully",
+		Data: responses.UserWorkloadData{
+			UserID:       workload.UserID,
+			Username:     workload.Username,
+			StatusCounts: statusCounts,
+			PendingCount: workload.PendingCount,
+			OpenCount:    workload.OpenCount,
+			TotalCount:   workload.TotalCount,
+		},
+	})
+}
+
+// GetTelegramChats godoc
+// @Summary Discover Telegram chat IDs
+// @Description List the chats that messaged the Telegram bot in the last 24 hours, most recent first, with the name of the sender. Ask the user to send any message to the bot, then link the chat ID with PATCH /api/admin/users/{id}/telegram. Fails while a webhook is set for the bot.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Success 200 {object} responses.TelegramChatsResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/admin/users/telegram/chats [get]
+// @Security ApiKeyAuth
+func (h *UserHandler) GetTelegramChats(c *fiber.Ctx) error {
+	if h.userService.NotificationService == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to discover Telegram chats",
+			Error:   "Telegram is not configured",
+		})
+	}
+
+	chats, err := h.userService.NotificationService.DiscoverTelegramChats()
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to discover Telegram chats",
+			Error:   err.Error(),
+		})
+	}
+
+	data := make([]responses.TelegramChatResponse, len(chats))
+	for i, chat := range chats {
+		data[i] = responses.TelegramChatResponse{
+			ChatID:         chat.ChatID,
+			Type:           chat.Type,
+			Title:          chat.Title,
+			SenderName:     chat.SenderName,
+			SenderUsername: chat.SenderUsername,
+			LastMessage:    chat.LastMessage,
+			LastSeenAt:     chat.LastSeenAt,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.TelegramChatsResponse{
+		Success: true,
+		Message: "Telegram chats retrieved successfully",
+		Data:    data,
+	})
+}
+
 // UpdateTelegramID godoc
 // @Summary Update a user's Telegram ID
 // @Description Update the Telegram ID for a specific user
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ybds/internal/api/requests"
+	"github.com/ybds/internal/api/responses"
+	"github.com/ybds/internal/models/order"
+	"github.com/ybds/internal/services"
+)
+
+// WebhookHandler handles HTTP requests related to outbound webhooks
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+// NewWebhookHandler creates a new instance of WebhookHandler. It shares the webhook service
+// of the order service so deliveries in flight are tracked in one place.
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// RegisterRoutes registers all routes related to outbound webhooks
+func (h *WebhookHandler) RegisterRoutes(router fiber.Router, authMiddleware fiber.Handler) {
+	webhooks := router.Group("/webhooks")
+	webhooks.Use(authMiddleware)
+
+	webhooks.Post("/", h.CreateEndpoint)
+	webhooks.Get("/", h.GetEndpoints)
+	webhooks.Delete("/:id", h.DeleteEndpoint)
+	webhooks.Get("/:id/deliveries", h.GetDeliveries)
+}
+
+// CreateEndpoint godoc
+// @Summary Register a webhook endpoint
+// @Description Register an external URL that receives the subscribed order events (order.created, order.status_changed). Requests are signed with HMAC-SHA256 of "<timestamp>.<body>" in the X-Webhook-Signature header; the secret is generated when omitted and only returned in this response.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param endpoint body requests.CreateWebhookEndpointRequest true "Webhook endpoint"
+// @Success 201 {object} responses.SingleWebhookEndpointResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/admin/webhooks [post]
+// @Security ApiKeyAuth
+func (h *WebhookHandler) CreateEndpoint(c *fiber.Ctx) error {
+	var req requests.CreateWebhookEndpointRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid request",
+			Error:   err.Error(),
+		})
+	}
+
+	events := make([]order.WebhookEvent, len(req.Events))
+	for i, event := range req.Events {
+		events[i] = order.WebhookEvent(event)
+	}
+
+	endpoint, err := h.webhookService.CreateEndpoint(req.URL, req.Secret, events, req.Description)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to create webhook endpoint",
+			Error:   err.Error(),
+		})
+	}
+
+	data := responses.ConvertToWebhookEndpointResponse(*endpoint)
+	data.Secret = endpoint.Secret
+
+	return c.Status(fiber.StatusCreated).JSON(responses.SingleWebhookEndpointResponse{
+		Success: true,
+		Message: "Webhook endpoint created successfully",
+		Data:    data,
+	})
+}
+
+// GetEndpoints godoc
+// @Summary List webhook endpoints
+// @Description List the registered webhook endpoints and the events they subscribe to
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Success 200 {object} responses.WebhookEndpointsResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/admin/webhooks [get]
+// @Security ApiKeyAuth
+func (h *WebhookHandler) GetEndpoints(c *fiber.Ctx) error {
+	endpoints, err := h.webhookService.GetEndpoints()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to get webhook endpoints",
+			Error:   err.Error(),
+		})
+	}
+
+	data := make([]responses.WebhookEndpointResponse, len(endpoints))
+	for i, endpoint := range endpoints {
+		data[i] = responses.ConvertToWebhookEndpointResponse(endpoint)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.WebhookEndpointsResponse{
+		Success: true,
+		Message: "Webhook endpoints retrieved successfully",
+		Data:    data,
+	})
+}
+
+// DeleteEndpoint godoc
+// @Summary Delete a webhook endpoint
+// @Description Delete a webhook endpoint; its pending deliveries are no longer retried
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook endpoint ID"
+// @Success 200 {object} responses.SuccessResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/admin/webhooks/{id} [delete]
+// @Security ApiKeyAuth
+func (h *WebhookHandler) DeleteEndpoint(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid webhook endpoint ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := h.webhookService.DeleteEndpoint(id); err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to delete webhook endpoint",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.SuccessResponse{
+		Success: true,
+		Message: "Webhook endpoint deleted successfully",
+	})
+}
+
+// GetDeliveries godoc
+// @Summary Get the delivery log of a webhook endpoint
+// @Description Get the deliveries of a webhook endpoint, newest first, with their status, attempts and last response
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook endpoint ID"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} responses.WebhookDeliveriesResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /api/admin/webhooks/{id}/deliveries [get]
+// @Security ApiKeyAuth
+func (h *WebhookHandler) GetDeliveries(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Invalid webhook endpoint ID format",
+			Error:   err.Error(),
+		})
+	}
+
+	// Parse pagination parameters
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(c.Query("page_size", "10"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10
+	}
+
+	deliveries, total, err := h.webhookService.GetDeliveries(id, page, pageSize)
+	if err != nil {
+		return c.Status(ErrorStatus(err)).JSON(responses.ErrorResponse{
+			Success: false,
+			Message: "Failed to get webhook deliveries",
+			Error:   err.Error(),
+		})
+	}
+
+	data := make([]responses.WebhookDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		data[i] = responses.ConvertToWebhookDeliveryResponse(delivery)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(responses.WebhookDeliveriesResponse{
+		Success:    true,
+		Message:    "Webhook deliveries retrieved successfully",
+		Data:       data,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int64(math.Ceil(float64(total) / float64(pageSize))),
+	})
+}
@@ -60,3 +60,70 @@ func (r *RegisterRequest) Validate() error {
 
 	return nil
 }
+
+// RefreshTokenRequest defines the request model for refreshing an access token or logging out
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Validate validates the refresh token request
+func (r *RefreshTokenRequest) Validate() error {
+	r.RefreshToken = strings.TrimSpace(r.RefreshToken)
+
+	if r.RefreshToken == "" {
+		return fmt.Errorf("refresh_token is required")
+	}
+
+	return nil
+}
+
+// PasswordResetRequest defines the request model for requesting a password reset
+type PasswordResetRequest struct {
+	Email string `json:"email"`
+}
+
+// Validate validates the password reset request
+func (r *PasswordResetRequest) Validate() error {
+	r.Email = strings.TrimSpace(r.Email)
+
+	if r.Email == "" {
+		return fmt.Errorf("email is required")
+	}
+
+	return nil
+}
+
+// PasswordResetConfirmRequest defines the request model for choosing a new password with a reset token
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// Validate validates the password reset confirmation request. The password policy is enforced by the service.
+func (r *PasswordResetConfirmRequest) Validate() error {
+	r.Token = strings.TrimSpace(r.Token)
+
+	if r.Token == "" {
+		return fmt.Errorf("token is required")
+	}
+
+	if r.NewPassword == "" {
+		return fmt.Errorf("new_password is required")
+	}
+
+	return nil
+}
+
+// CreateAPIKeyRequest defines the request model for creating an API key. Scopes are the
+// permissions granted to the key, e.g. backoffice:access and order:update_status.
+type CreateAPIKeyRequest struct {
+	Label  string   `json:"label" example:"POS terminal"`
+	Scopes []string `json:"scopes" example:"backoffice:access,order:update_status"`
+}
+
+// UpdateAPIKeyRequest defines the request model for updating an API key. Fields left out keep
+// their current value.
+type UpdateAPIKeyRequest struct {
+	Label  *string  `json:"label,omitempty" example:"POS terminal"`
+	Scopes []string `json:"scopes,omitempty" example:"backoffice:access"`
+}
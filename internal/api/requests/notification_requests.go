@@ -2,8 +2,10 @@ package requests
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/ybds/internal/models/notification"
 )
 
 // MarkNotificationAsReadRequest represents a request to mark a notification as read
@@ -19,6 +21,26 @@ func (r *MarkNotificationAsReadRequest) Validate() error {
 	return nil
 }
 
+// MarkReadByEventRequest represents a request to mark the notifications of an event as read
+type MarkReadByEventRequest struct {
+	Event    string `json:"event"`
+	Resource string `json:"resource,omitempty"`
+}
+
+// Validate validates the mark read by event request
+func (r *MarkReadByEventRequest) Validate() error {
+	r.Event = strings.TrimSpace(r.Event)
+	if r.Event == "" {
+		return errors.New("event is required")
+	}
+	switch r.Resource {
+	case "", "order", "product":
+		return nil
+	default:
+		return errors.New("resource must be one of: order, product")
+	}
+}
+
 // CreateNotificationRequest represents a request to create a notification
 type CreateNotificationRequest struct {
 	RecipientID   *uuid.UUID             `json:"recipient_id"`
@@ -60,3 +82,30 @@ func (r *GetNotificationsRequest) Validate() error {
 	}
 	return nil
 }
+
+// ListNotificationsRequest represents an admin request to list notifications of all recipients
+type ListNotificationsRequest struct {
+	Page          int        `json:"page" query:"page"`
+	PageSize      int        `json:"page_size" query:"page_size"`
+	RecipientType string     `json:"recipient_type" query:"recipient_type"`
+	RecipientID   *uuid.UUID `json:"recipient_id" query:"recipient_id"`
+}
+
+// Validate validates the list notifications request
+func (r *ListNotificationsRequest) Validate() error {
+	if r.Page < 1 {
+		return errors.New("page must be greater than 0")
+	}
+	if r.PageSize < 1 || r.PageSize > 100 {
+		return errors.New("page size must be between 1 and 100")
+	}
+	if r.RecipientType != "" {
+		switch notification.RecipientType(r.RecipientType) {
+		case notification.RecipientUser, notification.RecipientGuest,
+			notification.RecipientPotentialCustomer, notification.RecipientPartner:
+		default:
+			return errors.New("invalid recipient type")
+		}
+	}
+	return nil
+}
@@ -178,3 +178,43 @@ func TestGetNotificationsRequest_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestListNotificationsRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request ListNotificationsRequest
+		wantErr bool
+	}{
+		{
+			name:    "Valid request - no filters",
+			request: ListNotificationsRequest{Page: 1, PageSize: 10},
+			wantErr: false,
+		},
+		{
+			name:    "Valid request - recipient type",
+			request: ListNotificationsRequest{Page: 1, PageSize: 10, RecipientType: "partner"},
+			wantErr: false,
+		},
+		{
+			name:    "Invalid request - unknown recipient type",
+			request: ListNotificationsRequest{Page: 1, PageSize: 10, RecipientType: "robot"},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid request - page size too large",
+			request: ListNotificationsRequest{Page: 1, PageSize: 1000},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
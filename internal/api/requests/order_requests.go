@@ -3,9 +3,13 @@ package requests
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/ybds/internal/utils"
+	"github.com/ybds/pkg/phone"
 )
 
 // OrderItemInfo represents an item to be added to an order
@@ -27,12 +31,13 @@ func (i *OrderItemInfo) Validate() error {
 
 // CreateOrderRequest represents a request to create a new order
 type CreateOrderRequest struct {
-	PaymentMethod  string          `json:"payment_method" example:"cash"`
-	Status         string          `json:"status" example:"pending_confirmation"`
-	Notes          string          `json:"notes" example:"Please deliver in the morning"`
-	DiscountAmount float64         `json:"discount_amount" example:"10.50"`
-	DiscountReason string          `json:"discount_reason" example:"Loyalty discount"`
-	Items          []OrderItemInfo `json:"items" required:"true"`
+	PaymentMethod   string          `json:"payment_method" example:"cash"`
+	Status          string          `json:"status" example:"pending_confirmation"`
+	Notes           string          `json:"notes" example:"Please deliver in the morning"`
+	DiscountAmount  float64         `json:"discount_amount" example:"10.50"`
+	DiscountPercent *float64        `json:"discount_percent,omitempty" example:"10"`
+	DiscountReason  string          `json:"discount_reason" example:"Loyalty discount"`
+	Items           []OrderItemInfo `json:"items" required:"true"`
 	// Shipping address information
 	ShippingAddress  string `json:"shipping_address" example:"123 Main St"`
 	ShippingWard     string `json:"shipping_ward" example:"Ward 1"`
@@ -55,8 +60,13 @@ func (r *CreateOrderRequest) Validate() error {
 	}
 
 	// Validate Vietnamese phone number if provided
-	if r.CustomerPhone != "" && !utils.IsValidVietnamesePhone(r.CustomerPhone) {
-		return errors.New("invalid Vietnamese phone number format")
+	if r.CustomerPhone != "" && !phone.IsValid(r.CustomerPhone) {
+		return phone.ErrInvalid
+	}
+
+	// Validate email format if provided
+	if r.CustomerEmail != "" && !utils.IsValidEmail(r.CustomerEmail) {
+		return errors.New("invalid email format")
 	}
 
 	if len(r.Items) == 0 {
@@ -70,12 +80,41 @@ func (r *CreateOrderRequest) Validate() error {
 		}
 	}
 
-	return nil
+	return validateDiscount(r.DiscountAmount, r.DiscountPercent)
+}
+
+// PreviewDiscountRequest represents a request to preview an order discount across a cart
+type PreviewDiscountRequest struct {
+	Items           []OrderItemInfo `json:"items" required:"true"`
+	DiscountAmount  float64         `json:"discount_amount" example:"10.50"`
+	DiscountPercent *float64        `json:"discount_percent,omitempty" example:"10"`
+	DiscountReason  string          `json:"discount_reason" example:"Loyalty discount"`
 }
 
-// UpdateOrderStatusRequest represents a request to update an order's status
+// Validate validates the preview discount request
+func (r *PreviewDiscountRequest) Validate() error {
+	if len(r.Items) == 0 {
+		return errors.New("at least one item is required")
+	}
+
+	for i, item := range r.Items {
+		if err := item.Validate(); err != nil {
+			return fmt.Errorf("item %d: %s", i, err.Error())
+		}
+	}
+
+	if r.DiscountAmount < 0 {
+		return errors.New("discount amount cannot be negative")
+	}
+
+	return validateDiscount(r.DiscountAmount, r.DiscountPercent)
+}
+
+// UpdateOrderStatusRequest represents a request to update an order's status. The optional note
+// is recorded in the status history of the order.
 type UpdateOrderStatusRequest struct {
 	Status string `json:"status"`
+	Note   string `json:"note,omitempty" example:"Customer asked to ship today"`
 }
 
 // Validate validates the update order status request
@@ -86,6 +125,35 @@ func (r *UpdateOrderStatusRequest) Validate() error {
 	return nil
 }
 
+// ItemReturnInfo represents a number of units of an order item being returned
+type ItemReturnInfo struct {
+	OrderItemID uuid.UUID `json:"order_item_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Quantity    int       `json:"quantity" example:"2"`
+}
+
+// ReturnOrderItemsRequest represents a request to return some units of an order's items to inventory
+type ReturnOrderItemsRequest struct {
+	Items []ItemReturnInfo `json:"items" required:"true"`
+}
+
+// Validate validates the return order items request
+func (r *ReturnOrderItemsRequest) Validate() error {
+	if len(r.Items) == 0 {
+		return errors.New("at least one item is required")
+	}
+
+	for i, item := range r.Items {
+		if item.OrderItemID == uuid.Nil {
+			return fmt.Errorf("item %d: order item ID is required", i)
+		}
+		if item.Quantity <= 0 {
+			return fmt.Errorf("item %d: quantity must be greater than 0", i)
+		}
+	}
+
+	return nil
+}
+
 // AddOrderItemRequest represents a request to add an item to an order
 type AddOrderItemRequest struct {
 	InventoryID uuid.UUID `json:"inventory_id" example:"550e8400-e29b-41d4-a716-446655440000"`
@@ -103,6 +171,25 @@ func (r *AddOrderItemRequest) Validate() error {
 	return nil
 }
 
+// HoldInventoryRequest represents a request to hold stock of an inventory without creating an order
+type HoldInventoryRequest struct {
+	Quantity        int        `json:"quantity" example:"2"`
+	DurationMinutes int        `json:"duration_minutes" example:"1440"`
+	CustomerID      *uuid.UUID `json:"customer_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Notes           string     `json:"notes" example:"Quotation Q-1024"`
+}
+
+// Validate validates the hold inventory request
+func (r *HoldInventoryRequest) Validate() error {
+	if r.Quantity <= 0 {
+		return errors.New("quantity must be greater than 0")
+	}
+	if r.DurationMinutes <= 0 {
+		return errors.New("duration_minutes must be greater than 0")
+	}
+	return nil
+}
+
 // UpdateOrderItemRequest represents a request to update an order item
 type UpdateOrderItemRequest struct {
 	Quantity int `json:"quantity" example:"3"`
@@ -116,13 +203,16 @@ func (r *UpdateOrderItemRequest) Validate() error {
 	return nil
 }
 
-// UpdateOrderDetailsRequest represents a request to update order details
+// UpdateOrderDetailsRequest represents a request to update order details. Fields left empty keep
+// their current value; the discount is only changed when discount_amount or discount_percent is
+// sent, and its reason only when discount_reason is sent.
 type UpdateOrderDetailsRequest struct {
 	// Order information
-	PaymentMethod  string  `json:"payment_method" example:"cash"`
-	Notes          string  `json:"notes" example:"Please deliver in the morning"`
-	DiscountAmount float64 `json:"discount_amount" example:"10.50"`
-	DiscountReason string  `json:"discount_reason" example:"Free delivery"`
+	PaymentMethod   string   `json:"payment_method" example:"cash"`
+	Notes           string   `json:"notes" example:"Please deliver in the morning"`
+	DiscountAmount  *float64 `json:"discount_amount,omitempty" example:"10.50"`
+	DiscountPercent *float64 `json:"discount_percent,omitempty" example:"10"`
+	DiscountReason  *string  `json:"discount_reason,omitempty" example:"Free delivery"`
 	// Shipping address information
 	ShippingAddress  string `json:"shipping_address" example:"123 Main St"`
 	ShippingWard     string `json:"shipping_ward" example:"Ward 1"`
@@ -138,23 +228,228 @@ type UpdateOrderDetailsRequest struct {
 // Validate validates the update order details request
 func (r *UpdateOrderDetailsRequest) Validate() error {
 	// Validate Vietnamese phone number if provided
-	if r.CustomerPhone != "" && !utils.IsValidVietnamesePhone(r.CustomerPhone) {
-		return errors.New("invalid Vietnamese phone number format")
+	if r.CustomerPhone != "" && !phone.IsValid(r.CustomerPhone) {
+		return phone.ErrInvalid
+	}
+
+	// Validate email format if provided
+	if r.CustomerEmail != "" && !utils.IsValidEmail(r.CustomerEmail) {
+		return errors.New("invalid email format")
+	}
+
+	return validateDiscount(r.discountAmount(), r.DiscountPercent)
+}
+
+// discountAmount returns the requested fixed discount, or 0 when none is sent
+func (r *UpdateOrderDetailsRequest) discountAmount() float64 {
+	if r.DiscountAmount == nil {
+		return 0
 	}
+	return *r.DiscountAmount
+}
+
+// DiscountReasonRules configures how the reason of an order discount is validated
+type DiscountReasonRules struct {
+	// Required makes a reason mandatory whenever a discount is applied
+	Required bool
+	// MaxLength is the largest number of characters a reason may have; 0 disables the limit
+	MaxLength int
+}
 
+// ValidateDiscountReason validates the discount reason against the given rules
+func (r *CreateOrderRequest) ValidateDiscountReason(rules DiscountReasonRules) error {
+	return validateDiscountReason(r.DiscountReason, hasDiscount(r.DiscountAmount, r.DiscountPercent), rules)
+}
+
+// ValidateDiscountReason validates the discount reason against the given rules. Nothing is
+// validated when neither the discount nor its reason is changed.
+func (r *UpdateOrderDetailsRequest) ValidateDiscountReason(rules DiscountReasonRules) error {
+	if r.DiscountAmount == nil && r.DiscountPercent == nil && r.DiscountReason == nil {
+		return nil
+	}
+
+	var reason string
+	if r.DiscountReason != nil {
+		reason = *r.DiscountReason
+	}
+	return validateDiscountReason(reason, hasDiscount(r.discountAmount(), r.DiscountPercent), rules)
+}
+
+// hasDiscount reports whether a fixed or a percentage discount is set
+func hasDiscount(discountAmount float64, discountPercent *float64) bool {
+	return discountAmount > 0 || (discountPercent != nil && *discountPercent > 0)
+}
+
+// validateDiscountReason checks that a reason is given for a discount when the rules require
+// one and that it is not longer than the maximum length
+func validateDiscountReason(reason string, discounted bool, rules DiscountReasonRules) error {
+	if rules.Required && discounted && strings.TrimSpace(reason) == "" {
+		return errors.New("discount_reason is required when a discount is applied")
+	}
+	if rules.MaxLength > 0 && utf8.RuneCountInString(reason) > rules.MaxLength {
+		return fmt.Errorf("discount_reason must be at most %d characters", rules.MaxLength)
+	}
+	return nil
+}
+
+// validateDiscount checks that at most one of a fixed and a percentage discount is set
+// and that a percentage discount is within (0, 100]
+func validateDiscount(discountAmount float64, discountPercent *float64) error {
+	if discountPercent == nil {
+		return nil
+	}
+	if discountAmount != 0 {
+		return errors.New("only one of discount_amount and discount_percent can be set")
+	}
+	if *discountPercent <= 0 || *discountPercent > 100 {
+		return errors.New("discount_percent must be greater than 0 and at most 100")
+	}
 	return nil
 }
 
-// UpdateShipmentRequest represents a request to update shipment details
+// CreateShipmentRequest represents a request to add a shipment to an order carrying some of its items.
+// Without item IDs the shipment carries every item that is not assigned to another shipment.
+type CreateShipmentRequest struct {
+	TrackingNumber string      `json:"tracking_number" example:"GHN123456"`
+	Carrier        string      `json:"carrier" example:"GHN"`
+	ItemIDs        []uuid.UUID `json:"item_ids"`
+}
+
+// UpdateShipmentRequest represents a request to update shipment details.
+// Weight is in grams and dimensions are in centimeters; omitted fields are left unchanged.
 type UpdateShipmentRequest struct {
-	TrackingNumber string `json:"tracking_number"`
-	Carrier        string `json:"carrier"`
+	TrackingNumber string   `json:"tracking_number"`
+	Carrier        string   `json:"carrier"`
+	Weight         *float64 `json:"weight,omitempty" example:"500"`
+	Length         *float64 `json:"length,omitempty" example:"20"`
+	Width          *float64 `json:"width,omitempty" example:"15"`
+	Height         *float64 `json:"height,omitempty" example:"10"`
+	ShippingCost   *float64 `json:"shipping_cost,omitempty" example:"30000"`
+	CODAmount      *float64 `json:"cod_amount,omitempty" example:"250000"`
 }
 
 // Validate validates the UpdateShipmentRequest
 func (r *UpdateShipmentRequest) Validate() error {
-	if r.TrackingNumber == "" && r.Carrier == "" {
-		return errors.New("at least one of tracking number or carrier is required")
+	numericFields := []struct {
+		name  string
+		value *float64
+	}{
+		{"weight", r.Weight},
+		{"length", r.Length},
+		{"width", r.Width},
+		{"height", r.Height},
+		{"shipping cost", r.ShippingCost},
+		{"COD amount", r.CODAmount},
+	}
+
+	hasNumericField := false
+	for _, field := range numericFields {
+		if field.value == nil {
+			continue
+		}
+		hasNumericField = true
+		if *field.value < 0 {
+			return fmt.Errorf("%s cannot be negative", field.name)
+		}
+	}
+
+	if r.TrackingNumber == "" && r.Carrier == "" && !hasNumericField {
+		return errors.New("at least one shipment field is required")
+	}
+	return nil
+}
+
+// BulkAssignOrdersRequest represents a request to assign several orders to an agent
+type BulkAssignOrdersRequest struct {
+	OrderIDs []uuid.UUID `json:"order_ids"`
+	AgentID  uuid.UUID   `json:"agent_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+}
+
+// Validate validates the bulk assign orders request
+func (r *BulkAssignOrdersRequest) Validate() error {
+	if r.AgentID == uuid.Nil {
+		return errors.New("agent ID is required")
+	}
+	if len(r.OrderIDs) == 0 {
+		return errors.New("at least one order ID is required")
+	}
+	for i, id := range r.OrderIDs {
+		if id == uuid.Nil {
+			return fmt.Errorf("order %d: order ID is required", i)
+		}
+	}
+	return nil
+}
+
+// BulkStatusRequest represents a request to change the status of several orders at once
+type BulkStatusRequest struct {
+	OrderIDs []uuid.UUID `json:"order_ids"`
+	Status   string      `json:"status" example:"packed"`
+	Note     string      `json:"note,omitempty" example:"Packed in morning batch"`
+}
+
+// Validate validates the bulk status request
+func (r *BulkStatusRequest) Validate() error {
+	if r.Status == "" {
+		return errors.New("status is required")
+	}
+	if len(r.OrderIDs) == 0 {
+		return errors.New("at least one order ID is required")
+	}
+	for i, id := range r.OrderIDs {
+		if id == uuid.Nil {
+			return fmt.Errorf("order %d: order ID is required", i)
+		}
 	}
 	return nil
 }
+
+// BulkStatusByFilterRequest represents a request to change the status of all orders matching a filter
+type BulkStatusByFilterRequest struct {
+	Status       string     `json:"status" example:"shipment_requested"`
+	FromDate     *time.Time `json:"from_date,omitempty"`
+	ToDate       *time.Time `json:"to_date,omitempty"`
+	TargetStatus string     `json:"target_status" example:"packed"`
+}
+
+// Validate validates the bulk status by filter request
+func (r *BulkStatusByFilterRequest) Validate() error {
+	if r.TargetStatus == "" {
+		return errors.New("target status is required")
+	}
+	if r.Status == "" && r.FromDate == nil && r.ToDate == nil {
+		return errors.New("at least one filter (status, from_date or to_date) is required")
+	}
+	if r.FromDate != nil && r.ToDate != nil && r.FromDate.After(*r.ToDate) {
+		return errors.New("from_date must not be after to_date")
+	}
+	return nil
+}
+
+// CreateWebhookEndpointRequest represents a request to register an external URL for order events.
+// When no secret is given one is generated and returned in the response.
+type CreateWebhookEndpointRequest struct {
+	URL         string   `json:"url" example:"https://erp.example.com/hooks/orders"`
+	Secret      string   `json:"secret"`
+	Events      []string `json:"events" example:"order.created,order.status_changed"`
+	Description string   `json:"description" example:"ERP order sync"`
+}
+
+// ApplyPromoCodeRequest represents a request to apply a promo code to an order
+type ApplyPromoCodeRequest struct {
+	Code string `json:"code" example:"SUMMER10"`
+}
+
+// PromoCodeRequest represents a request to create or update a promo code. The start date
+// defaults to now; without an end date the code never expires and a max_uses of 0 allows
+// unlimited uses.
+type PromoCodeRequest struct {
+	Code           string     `json:"code" example:"SUMMER10"`
+	Type           string     `json:"type" example:"percent"`
+	Value          float64    `json:"value" example:"10"`
+	StartDate      *time.Time `json:"start_date,omitempty" example:"2025-06-01T00:00:00Z"`
+	EndDate        *time.Time `json:"end_date,omitempty" example:"2025-08-31T23:59:59Z"`
+	MaxUses        int        `json:"max_uses" example:"100"`
+	MinOrderAmount float64    `json:"min_order_amount" example:"200000"`
+	Description    string     `json:"description" example:"Summer sale"`
+}
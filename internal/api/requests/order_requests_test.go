@@ -0,0 +1,273 @@
+package requests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateShipmentRequest_Validate(t *testing.T) {
+	weight := 500.0
+	negative := -1.0
+
+	tests := []struct {
+		name    string
+		request UpdateShipmentRequest
+		wantErr bool
+	}{
+		{
+			name:    "Valid request - tracking number only",
+			request: UpdateShipmentRequest{TrackingNumber: "GHN123"},
+			wantErr: false,
+		},
+		{
+			name:    "Valid request - package details only",
+			request: UpdateShipmentRequest{Weight: &weight},
+			wantErr: false,
+		},
+		{
+			name:    "Invalid request - empty",
+			request: UpdateShipmentRequest{},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid request - negative weight",
+			request: UpdateShipmentRequest{Carrier: "GHN", Weight: &negative},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid request - negative COD amount",
+			request: UpdateShipmentRequest{CODAmount: &negative},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCreateOrderRequest_ValidateQuantity(t *testing.T) {
+	newRequest := func(quantity int) CreateOrderRequest {
+		return CreateOrderRequest{
+			CustomerName: "John Doe",
+			Items:        []OrderItemInfo{{InventoryID: uuid.New(), Quantity: quantity}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		quantity int
+		wantErr  bool
+	}{
+		{"Valid quantity", 1, false},
+		{"Zero quantity", 0, true},
+		{"Negative quantity", -2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := newRequest(tt.quantity)
+			err := request.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAddOrderItemRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request AddOrderItemRequest
+		wantErr bool
+	}{
+		{"Valid quantity", AddOrderItemRequest{InventoryID: uuid.New(), Quantity: 2}, false},
+		{"Zero quantity", AddOrderItemRequest{InventoryID: uuid.New(), Quantity: 0}, true},
+		{"Negative quantity", AddOrderItemRequest{InventoryID: uuid.New(), Quantity: -1}, true},
+		{"Missing inventory", AddOrderItemRequest{Quantity: 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestOrderRequests_ValidateCustomerEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		email   string
+		wantErr bool
+	}{
+		{name: "Valid email", email: "john@example.com", wantErr: false},
+		{name: "Valid email - subdomain and plus tag", email: "john.doe+shop@mail.example.vn", wantErr: false},
+		{name: "Empty email", email: "", wantErr: false},
+		{name: "Invalid email - missing at sign", email: "john.example.com", wantErr: true},
+		{name: "Invalid email - missing domain", email: "john@", wantErr: true},
+		{name: "Invalid email - domain without dot", email: "john@example", wantErr: true},
+		{name: "Invalid email - display name", email: "John <john@example.com>", wantErr: true},
+		{name: "Invalid email - whitespace", email: "john doe@example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			create := CreateOrderRequest{
+				CustomerName:  "John Doe",
+				CustomerEmail: tt.email,
+				Items:         []OrderItemInfo{{InventoryID: uuid.New(), Quantity: 1}},
+			}
+			update := UpdateOrderDetailsRequest{CustomerEmail: tt.email}
+
+			if tt.wantErr {
+				assert.EqualError(t, create.Validate(), "invalid email format")
+				assert.EqualError(t, update.Validate(), "invalid email format")
+			} else {
+				assert.NoError(t, create.Validate())
+				assert.NoError(t, update.Validate())
+			}
+		})
+	}
+}
+
+func TestBulkStatusByFilterRequest_Validate(t *testing.T) {
+	from := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	tests := []struct {
+		name    string
+		request BulkStatusByFilterRequest
+		wantErr string
+	}{
+		{name: "Valid status filter", request: BulkStatusByFilterRequest{Status: "shipment_requested", TargetStatus: "packed"}},
+		{name: "Valid date filter", request: BulkStatusByFilterRequest{FromDate: &from, ToDate: &to, TargetStatus: "packed"}},
+		{name: "Missing target status", request: BulkStatusByFilterRequest{Status: "shipment_requested"}, wantErr: "target status is required"},
+		{name: "Missing filter", request: BulkStatusByFilterRequest{TargetStatus: "packed"}, wantErr: "at least one filter (status, from_date or to_date) is required"},
+		{name: "Inverted date range", request: BulkStatusByFilterRequest{FromDate: &to, ToDate: &from, TargetStatus: "packed"}, wantErr: "from_date must not be after to_date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestOrderRequests_ValidateDiscountMode(t *testing.T) {
+	percent := func(value float64) *float64 { return &value }
+
+	tests := []struct {
+		name            string
+		discountAmount  float64
+		discountPercent *float64
+		wantErr         string
+	}{
+		{name: "Fixed discount", discountAmount: 10},
+		{name: "Percentage discount", discountPercent: percent(10)},
+		{name: "Full percentage discount", discountPercent: percent(100)},
+		{name: "Both discount modes", discountAmount: 10, discountPercent: percent(10), wantErr: "only one of discount_amount and discount_percent can be set"},
+		{name: "Zero percentage", discountPercent: percent(0), wantErr: "discount_percent must be greater than 0 and at most 100"},
+		{name: "Percentage over 100", discountPercent: percent(101), wantErr: "discount_percent must be greater than 0 and at most 100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			create := CreateOrderRequest{
+				CustomerName:    "John Doe",
+				DiscountAmount:  tt.discountAmount,
+				DiscountPercent: tt.discountPercent,
+				Items:           []OrderItemInfo{{InventoryID: uuid.New(), Quantity: 1}},
+			}
+			update := UpdateOrderDetailsRequest{DiscountAmount: &tt.discountAmount, DiscountPercent: tt.discountPercent}
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, create.Validate(), tt.wantErr)
+				assert.EqualError(t, update.Validate(), tt.wantErr)
+			} else {
+				assert.NoError(t, create.Validate())
+				assert.NoError(t, update.Validate())
+			}
+		})
+	}
+}
+
+func TestOrderRequests_ValidateDiscountReason(t *testing.T) {
+	percent := func(value float64) *float64 { return &value }
+	required := DiscountReasonRules{Required: true, MaxLength: 20}
+
+	tests := []struct {
+		name            string
+		discountAmount  float64
+		discountPercent *float64
+		discountReason  string
+		rules           DiscountReasonRules
+		wantErr         string
+	}{
+		{name: "Discount with reason", discountAmount: 10, discountReason: "Loyalty discount", rules: required},
+		{name: "Percentage discount with reason", discountPercent: percent(10), discountReason: "Loyalty discount", rules: required},
+		{name: "No discount without reason", rules: required},
+		{name: "Discount without reason", discountAmount: 10, rules: required, wantErr: "discount_reason is required when a discount is applied"},
+		{name: "Percentage discount with blank reason", discountPercent: percent(10), discountReason: "  ", rules: required, wantErr: "discount_reason is required when a discount is applied"},
+		{name: "Discount without reason when optional", discountAmount: 10, rules: DiscountReasonRules{MaxLength: 20}},
+		{name: "Over-length reason", discountAmount: 10, discountReason: "Customer complained about late delivery", rules: required, wantErr: "discount_reason must be at most 20 characters"},
+		{name: "Reason at max length in characters", discountAmount: 10, discountReason: "Giảm giá khách quen!", rules: required},
+		{name: "Over-length reason without limit", discountAmount: 10, discountReason: "Customer complained about late delivery", rules: DiscountReasonRules{Required: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			create := CreateOrderRequest{
+				CustomerName:    "John Doe",
+				DiscountAmount:  tt.discountAmount,
+				DiscountPercent: tt.discountPercent,
+				DiscountReason:  tt.discountReason,
+				Items:           []OrderItemInfo{{InventoryID: uuid.New(), Quantity: 1}},
+			}
+			update := UpdateOrderDetailsRequest{DiscountAmount: &tt.discountAmount, DiscountPercent: tt.discountPercent, DiscountReason: &tt.discountReason}
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, create.ValidateDiscountReason(tt.rules), tt.wantErr)
+				assert.EqualError(t, update.ValidateDiscountReason(tt.rules), tt.wantErr)
+			} else {
+				assert.NoError(t, create.ValidateDiscountReason(tt.rules))
+				assert.NoError(t, update.ValidateDiscountReason(tt.rules))
+			}
+		})
+	}
+}
+
+func TestOrderRequests_UpdateDetailsWithoutDiscount(t *testing.T) {
+	required := DiscountReasonRules{Required: true, MaxLength: 20}
+
+	// Details updates that leave the discount alone need no reason
+	update := UpdateOrderDetailsRequest{CustomerPhone: "0912345678"}
+	assert.NoError(t, update.Validate())
+	assert.NoError(t, update.ValidateDiscountReason(required))
+
+	// A reason sent on its own is still limited in length
+	reason := "Customer complained about late delivery"
+	update = UpdateOrderDetailsRequest{DiscountReason: &reason}
+	assert.EqualError(t, update.ValidateDiscountReason(required), "discount_reason must be at most 20 characters")
+}
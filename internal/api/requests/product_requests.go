@@ -4,14 +4,18 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
-// InventoryRequest defines the inventory data in a request
+// InventoryRequest defines the inventory data in a request.
+// LowStockThreshold defaults to 5 when omitted.
 type InventoryRequest struct {
-	Size     string `json:"size"`
-	Color    string `json:"color"`
-	Quantity int    `json:"quantity"`
-	Location string `json:"location"`
+	Size              string `json:"size"`
+	Color             string `json:"color"`
+	Quantity          int    `json:"quantity"`
+	Location          string `json:"location"`
+	LowStockThreshold *int   `json:"low_stock_threshold,omitempty" example:"5"`
 }
 
 // PriceRequest defines the price data in a request
@@ -55,6 +59,9 @@ func (r *CreateProductRequest) Validate() error {
 		if inv.Quantity < 0 {
 			return fmt.Errorf("inventory %d: quantity cannot be negative", i+1)
 		}
+		if inv.LowStockThreshold != nil && *inv.LowStockThreshold < 0 {
+			return fmt.Errorf("inventory %d: low stock threshold cannot be negative", i+1)
+		}
 	}
 
 	// Validate prices if provided
@@ -80,12 +87,14 @@ type UpdateProductRequest struct {
 	ImageURL    string `json:"image_url"`
 }
 
-// CreateInventoryRequest defines the request model for creating an inventory
+// CreateInventoryRequest defines the request model for creating an inventory.
+// LowStockThreshold defaults to 5 when omitted.
 type CreateInventoryRequest struct {
-	Size     string `json:"size"`
-	Color    string `json:"color"`
-	Quantity int    `json:"quantity"`
-	Location string `json:"location"`
+	Size              string `json:"size"`
+	Color             string `json:"color"`
+	Quantity          int    `json:"quantity"`
+	Location          string `json:"location"`
+	LowStockThreshold *int   `json:"low_stock_threshold,omitempty" example:"5"`
 }
 
 // Validate validates the create inventory request
@@ -94,6 +103,10 @@ func (r *CreateInventoryRequest) Validate() error {
 		return fmt.Errorf("quantity cannot be negative")
 	}
 
+	if r.LowStockThreshold != nil && *r.LowStockThreshold < 0 {
+		return fmt.Errorf("low stock threshold cannot be negative")
+	}
+
 	return nil
 }
 
@@ -117,19 +130,68 @@ func (r *CreateMultipleInventoriesRequest) Validate() error {
 	return nil
 }
 
-// UpdateInventoryRequest defines the request model for updating an inventory
+// UpdateInventoryRequest defines the request model for updating an inventory.
+// Quantity and LowStockThreshold are left unchanged when omitted; an explicit 0 quantity empties the stock.
 type UpdateInventoryRequest struct {
-	Size     string `json:"size"`
-	Color    string `json:"color"`
-	Quantity int    `json:"quantity"`
-	Location string `json:"location"`
+	Size              string `json:"size"`
+	Color             string `json:"color"`
+	Quantity          *int   `json:"quantity,omitempty"`
+	Location          string `json:"location"`
+	LowStockThreshold *int   `json:"low_stock_threshold,omitempty" example:"5"`
+}
+
+// TransferInventoryRequest defines the request model for moving stock between two inventories
+type TransferInventoryRequest struct {
+	FromInventoryID uuid.UUID `json:"from_inventory_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ToInventoryID   uuid.UUID `json:"to_inventory_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Quantity        int       `json:"quantity" example:"10"`
+	Notes           string    `json:"notes,omitempty" example:"Rebalance to Warehouse B"`
+}
+
+// Validate validates the transfer inventory request
+func (r *TransferInventoryRequest) Validate() error {
+	if r.FromInventoryID == uuid.Nil {
+		return fmt.Errorf("from inventory ID is required")
+	}
+	if r.ToInventoryID == uuid.Nil {
+		return fmt.Errorf("to inventory ID is required")
+	}
+	if r.FromInventoryID == r.ToInventoryID {
+		return fmt.Errorf("from and to inventories must differ")
+	}
+	if r.Quantity <= 0 {
+		return fmt.Errorf("quantity must be greater than zero")
+	}
+	return nil
 }
 
-// CreatePriceRequest defines the request model for creating a price
+// CreateVariantOptionRequest defines the request model for defining a size or color a product allows
+type CreateVariantOptionRequest struct {
+	Attribute string `json:"attribute" example:"color"`
+	Value     string `json:"value" example:"Red"`
+}
+
+// Validate validates the create variant option request
+func (r *CreateVariantOptionRequest) Validate() error {
+	r.Attribute = strings.ToLower(strings.TrimSpace(r.Attribute))
+	r.Value = strings.TrimSpace(r.Value)
+
+	if r.Attribute != "size" && r.Attribute != "color" {
+		return fmt.Errorf("attribute must be size or color")
+	}
+	if r.Value == "" {
+		return fmt.Errorf("value is required")
+	}
+	return nil
+}
+
+// CreatePriceRequest defines the request model for creating a price.
+// StartDate defaults to now; a future start date schedules the price to become current automatically.
 type CreatePriceRequest struct {
-	Price    float64    `json:"price"`
-	Currency string     `json:"currency"`
-	EndDate  *time.Time `json:"end_date,omitempty"`
+	Price     float64    `json:"price"`
+	Currency  string     `json:"currency"`
+	StartDate *time.Time `json:"start_date,omitempty"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
 }
 
 // Validate validates the create price request
@@ -143,6 +205,10 @@ func (r *CreatePriceRequest) Validate() error {
 		return fmt.Errorf("currency is required")
 	}
 
+	if r.StartDate != nil && r.EndDate != nil && !r.EndDate.After(*r.StartDate) {
+		return fmt.Errorf("end date must be after start date")
+	}
+
 	return nil
 }
 
@@ -152,3 +218,67 @@ type UpdatePriceRequest struct {
 	Currency string     `json:"currency"`
 	EndDate  *time.Time `json:"end_date,omitempty"`
 }
+
+// CreatePriceTierRequest defines the request model for adding a quantity break to a price
+type CreatePriceTierRequest struct {
+	MinQuantity int     `json:"min_quantity" example:"100"`
+	UnitPrice   float64 `json:"unit_price" example:"80000"`
+}
+
+// Validate validates the create price tier request
+func (r *CreatePriceTierRequest) Validate() error {
+	if r.MinQuantity < 2 {
+		return fmt.Errorf("minimum quantity must be at least 2")
+	}
+	if r.UnitPrice <= 0 {
+		return fmt.Errorf("unit price must be greater than zero")
+	}
+	return nil
+}
+
+// UpdatePriceTierRequest defines the request model for updating a quantity break.
+// Omitted fields are left unchanged.
+type UpdatePriceTierRequest struct {
+	MinQuantity *int     `json:"min_quantity,omitempty" example:"100"`
+	UnitPrice   *float64 `json:"unit_price,omitempty" example:"80000"`
+}
+
+// Validate validates the update price tier request
+func (r *UpdatePriceTierRequest) Validate() error {
+	if r.MinQuantity == nil && r.UnitPrice == nil {
+		return fmt.Errorf("min_quantity or unit_price is required")
+	}
+	if r.MinQuantity != nil && *r.MinQuantity < 2 {
+		return fmt.Errorf("minimum quantity must be at least 2")
+	}
+	if r.UnitPrice != nil && *r.UnitPrice <= 0 {
+		return fmt.Errorf("unit price must be greater than zero")
+	}
+	return nil
+}
+
+// SetFeaturedRequest represents a request to add a product to or remove it from the featured list
+type SetFeaturedRequest struct {
+	Featured *bool `json:"featured" example:"true"`
+}
+
+// Validate validates the set featured request
+func (r *SetFeaturedRequest) Validate() error {
+	if r.Featured == nil {
+		return fmt.Errorf("featured is required")
+	}
+	return nil
+}
+
+// ReorderFeaturedRequest represents a request to reorder the featured products
+type ReorderFeaturedRequest struct {
+	ProductIDs []uuid.UUID `json:"product_ids" example:"550e8400-e29b-41d4-a716-446655440000"`
+}
+
+// Validate validates the reorder featured request
+func (r *ReorderFeaturedRequest) Validate() error {
+	if len(r.ProductIDs) == 0 {
+		return fmt.Errorf("at least one product ID is required")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package responses
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models/account"
+)
+
+// APIKeyResponse represents an API key in responses. The key itself is only returned when the
+// API key is created; afterwards the key prefix tells keys apart.
+type APIKeyResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Label      string     `json:"label"`
+	Key        string     `json:"key,omitempty"`
+	KeyPrefix  string     `json:"key_prefix"`
+	Scopes     []string   `json:"scopes"`
+	Revoked    bool       `json:"revoked"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// ConvertToAPIKeyResponse converts an account.APIKey to an APIKeyResponse
+func ConvertToAPIKeyResponse(key account.APIKey) APIKeyResponse {
+	scopes := make([]string, 0)
+	for _, scope := range key.ScopeList() {
+		scopes = append(scopes, string(scope))
+	}
+
+	return APIKeyResponse{
+		ID:         key.ID,
+		Label:      key.Label,
+		KeyPrefix:  key.KeyPrefix,
+		Scopes:     scopes,
+		Revoked:    key.Revoked,
+		RevokedAt:  key.RevokedAt,
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+		UpdatedAt:  key.UpdatedAt,
+	}
+}
+
+// SingleAPIKeyResponse represents a single API key response
+type SingleAPIKeyResponse struct {
+	Success bool           `json:"success"`
+	Message string         `json:"message"`
+	Data    APIKeyResponse `json:"data"`
+}
+
+// APIKeysResponse represents a list of API keys in responses
+type APIKeysResponse struct {
+	Success bool             `json:"success"`
+	Message string           `json:"message"`
+	Data    []APIKeyResponse `json:"data"`
+}
@@ -0,0 +1,60 @@
+package responses
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models/account"
+)
+
+// AuditLogResponse represents an audit log entry in responses
+type AuditLogResponse struct {
+	ID         uuid.UUID       `json:"id"`
+	ActorID    *uuid.UUID      `json:"actor_id,omitempty"`
+	ActorRoles string          `json:"actor_roles"`
+	Method     string          `json:"method"`
+	Path       string          `json:"path"`
+	Resource   string          `json:"resource"`
+	TargetID   string          `json:"target_id,omitempty"`
+	StatusCode int             `json:"status_code"`
+	Body       json.RawMessage `json:"body,omitempty" swaggertype:"object"`
+	IP         string          `json:"ip"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// ConvertToAuditLogResponse converts an account.AuditLog to an AuditLogResponse
+func ConvertToAuditLogResponse(entry account.AuditLog) AuditLogResponse {
+	response := AuditLogResponse{
+		ID:         entry.ID,
+		ActorID:    entry.ActorID,
+		ActorRoles: entry.ActorRoles,
+		Method:     entry.Method,
+		Path:       entry.Path,
+		Resource:   entry.Resource,
+		TargetID:   entry.TargetID,
+		StatusCode: entry.StatusCode,
+		IP:         entry.IP,
+		CreatedAt:  entry.CreatedAt,
+	}
+	if entry.Body != "" {
+		if json.Valid([]byte(entry.Body)) {
+			response.Body = json.RawMessage(entry.Body)
+		} else {
+			// Entries recorded before truncated bodies were kept as JSON hold a cut-off body
+			response.Body, _ = json.Marshal(entry.Body)
+		}
+	}
+	return response
+}
+
+// AuditLogsResponse represents a paginated list of audit log entries in responses
+type AuditLogsResponse struct {
+	Success    bool               `json:"success"`
+	Message    string             `json:"message"`
+	Data       []AuditLogResponse `json:"data"`
+	Total      int64              `json:"total"`
+	Page       int                `json:"page"`
+	PageSize   int                `json:"page_size"`
+	TotalPages int64              `json:"total_pages"`
+}
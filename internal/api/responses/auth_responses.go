@@ -1,6 +1,8 @@
 package responses
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
@@ -21,10 +23,21 @@ type UserResponse struct {
 
 // LoginResponse defines the login response model
 type LoginResponse struct {
-	Success bool         `json:"success"`
-	Message string       `json:"message"`
-	Token   string       `json:"token"`
-	User    UserResponse `json:"user"`
+	Success          bool         `json:"success"`
+	Message          string       `json:"message"`
+	Token            string       `json:"token"`
+	RefreshToken     string       `json:"refresh_token"`
+	RefreshExpiresAt time.Time    `json:"refresh_expires_at"`
+	User             UserResponse `json:"user"`
+}
+
+// RefreshTokenResponse defines the response model for exchanging a refresh token
+type RefreshTokenResponse struct {
+	Success          bool      `json:"success"`
+	Message          string    `json:"message"`
+	Token            string    `json:"token"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
 }
 
 // RegisterResponse defines the registration response model
@@ -0,0 +1,43 @@
+package responses
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CustomerResponse represents a customer in responses
+type CustomerResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Phone     string    `json:"phone"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CustomerProfileResponse represents a customer with a summary of their orders
+type CustomerProfileResponse struct {
+	CustomerResponse
+	OrderCount  int64      `json:"order_count"`
+	TotalSpent  float64    `json:"total_spent"`
+	LastOrderAt *time.Time `json:"last_order_at,omitempty"`
+}
+
+// CustomerDetailResponse represents a customer profile response
+type CustomerDetailResponse struct {
+	Success bool                    `json:"success"`
+	Message string                  `json:"message"`
+	Data    CustomerProfileResponse `json:"data"`
+}
+
+// CustomersResponse represents a paginated list of customers in responses
+type CustomersResponse struct {
+	Success    bool               `json:"success"`
+	Message    string             `json:"message"`
+	Data       []CustomerResponse `json:"data"`
+	Total      int64              `json:"total"`
+	Page       int                `json:"page"`
+	PageSize   int                `json:"page_size"`
+	TotalPages int64              `json:"total_pages"`
+}
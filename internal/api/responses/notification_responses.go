@@ -36,3 +36,30 @@ type NotificationReadResponse struct {
 	Message string               `json:"message"`
 	Data    NotificationResponse `json:"data"`
 }
+
+// NotificationEventCountResponse represents the number of unread notifications for a resource and event
+type NotificationEventCountResponse struct {
+	Resource string `json:"resource"`
+	Event    string `json:"event"`
+	Count    int64  `json:"count"`
+}
+
+// NotificationSummaryData represents the unread notification counts of the current user
+type NotificationSummaryData struct {
+	TotalUnread int64                            `json:"total_unread"`
+	Events      []NotificationEventCountResponse `json:"events"`
+}
+
+// NotificationSummaryResponse represents the notification summary in the response
+type NotificationSummaryResponse struct {
+	Success bool                    `json:"success"`
+	Message string                  `json:"message"`
+	Data    NotificationSummaryData `json:"data"`
+}
+
+// NotificationsMarkedReadResponse represents the number of notifications marked as read
+type NotificationsMarkedReadResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Updated int64  `json:"updated"`
+}
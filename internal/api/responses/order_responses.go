@@ -4,26 +4,33 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/ybds/internal/models/order"
+	"github.com/ybds/internal/utils"
 )
 
 // OrderItemResponse represents an order item in responses
 type OrderItemResponse struct {
-	ID           uuid.UUID `json:"id"`
-	OrderID      uuid.UUID `json:"order_id"`
-	ProductID    uuid.UUID `json:"product_id"`
-	ProductName  string    `json:"product_name"`
-	ProductImage string    `json:"product_image"`
-	InventoryID  uuid.UUID `json:"inventory_id"`
-	Size         string    `json:"size"`
-	Color        string    `json:"color"`
-	PriceID      uuid.UUID `json:"price_id"`
-	Price        float64   `json:"price"`
-	Currency     string    `json:"currency"`
-	Quantity     int       `json:"quantity"`
-	Subtotal     float64   `json:"subtotal"`
-	Notes        string    `json:"notes"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID                uuid.UUID `json:"id"`
+	OrderID           uuid.UUID `json:"order_id"`
+	ProductID         uuid.UUID `json:"product_id"`
+	ProductName       string    `json:"product_name"`
+	ProductImage      string    `json:"product_image"`
+	InventoryID       uuid.UUID `json:"inventory_id"`
+	Size              string    `json:"size"`
+	Color             string    `json:"color"`
+	PriceID           uuid.UUID `json:"price_id"`
+	Price             float64   `json:"price"`
+	PriceFormatted    string    `json:"price_formatted"`
+	Currency          string    `json:"currency"`
+	OriginalPrice     float64   `json:"original_price,omitempty"`
+	OriginalCurrency  string    `json:"original_currency,omitempty"`
+	Quantity          int       `json:"quantity"`
+	ReturnedQuantity  int       `json:"returned_quantity"`
+	Subtotal          float64   `json:"subtotal"`
+	SubtotalFormatted string    `json:"subtotal_formatted"`
+	Notes             string    `json:"notes"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
 }
 
 // ShipmentResponse represents a shipment in responses
@@ -32,10 +39,96 @@ type ShipmentResponse struct {
 	OrderID        uuid.UUID `json:"order_id"`
 	TrackingNumber string    `json:"tracking_number"`
 	Carrier        string    `json:"carrier"`
+	Weight         float64   `json:"weight"`
+	Length         float64   `json:"length"`
+	Width          float64   `json:"width"`
+	Height         float64   `json:"height"`
+	ShippingCost   float64   `json:"shipping_cost"`
+	CODAmount      float64   `json:"cod_amount"`
+	// IDs of the order items carried by the shipment; empty when it carries every unassigned item
+	ItemIDs   []uuid.UUID `json:"item_ids"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// ConvertToShipmentResponse converts an order.Shipment to a ShipmentResponse
+func ConvertToShipmentResponse(shipment order.Shipment) ShipmentResponse {
+	itemIDs := make([]uuid.UUID, len(shipment.Items))
+	for i, item := range shipment.Items {
+		itemIDs[i] = item.OrderItemID
+	}
+
+	return ShipmentResponse{
+		ID:             shipment.ID,
+		OrderID:        shipment.OrderID,
+		TrackingNumber: shipment.TrackingNumber,
+		Carrier:        shipment.Carrier,
+		Weight:         shipment.Weight,
+		Length:         shipment.Length,
+		Width:          shipment.Width,
+		Height:         shipment.Height,
+		ShippingCost:   shipment.ShippingCost,
+		CODAmount:      shipment.CODAmount,
+		ItemIDs:        itemIDs,
+		CreatedAt:      shipment.CreatedAt,
+		UpdatedAt:      shipment.UpdatedAt,
+	}
+}
+
+// ShipmentListItem represents a shipment with the summary of its order in a shipment listing
+type ShipmentListItem struct {
+	ID             uuid.UUID `json:"id"`
+	OrderID        uuid.UUID `json:"order_id"`
+	OrderNumber    string    `json:"order_number"`
+	OrderStatus    string    `json:"order_status"`
+	CustomerName   string    `json:"customer_name"`
+	CustomerPhone  string    `json:"customer_phone"`
+	TrackingNumber string    `json:"tracking_number"`
+	Carrier        string    `json:"carrier"`
+	ShippingCost   float64   `json:"shipping_cost"`
+	CODAmount      float64   `json:"cod_amount"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
 
+// ShipmentsResponse represents a paginated list of shipments in responses
+type ShipmentsResponse struct {
+	Success    bool               `json:"success"`
+	Message    string             `json:"message"`
+	Data       []ShipmentListItem `json:"data"`
+	Total      int64              `json:"total"`
+	Page       int                `json:"page"`
+	PageSize   int                `json:"page_size"`
+	TotalPages int64              `json:"total_pages"`
+}
+
+// DeliveryProofResponse represents the proof captured when an order was delivered
+type DeliveryProofResponse struct {
+	ImageURL      string     `json:"image_url"`
+	RecipientName string     `json:"recipient_name"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+// ConvertToDeliveryProofResponse converts the delivery proof of an order to a DeliveryProofResponse,
+// returning nil when no proof has been recorded
+func ConvertToDeliveryProofResponse(o order.Order) *DeliveryProofResponse {
+	if !o.HasDeliveryProof() {
+		return nil
+	}
+	return &DeliveryProofResponse{
+		ImageURL:      o.DeliveryProofURL,
+		RecipientName: o.DeliveryRecipientName,
+		DeliveredAt:   o.DeliveredAt,
+	}
+}
+
+// DeliveryProofDetailResponse represents the response of a delivery proof lookup or capture
+type DeliveryProofDetailResponse struct {
+	Success bool                  `json:"success"`
+	Message string                `json:"message"`
+	Data    DeliveryProofResponse `json:"data"`
+}
+
 // OrderResponse represents an order in responses
 type OrderResponse struct {
 	Success bool        `json:"success"`
@@ -45,28 +138,83 @@ type OrderResponse struct {
 
 // OrderDetail represents the details of an order
 type OrderDetail struct {
-	ID               uuid.UUID           `json:"id"`
-	CustomerName     string              `json:"customer_name"`
-	CustomerEmail    string              `json:"customer_email"`
-	CustomerPhone    string              `json:"customer_phone"`
-	ShippingAddress  string              `json:"shipping_address"`
-	ShippingWard     string              `json:"shipping_ward"`
-	ShippingDistrict string              `json:"shipping_district"`
-	ShippingCity     string              `json:"shipping_city"`
-	ShippingCountry  string              `json:"shipping_country"`
-	PaymentMethod    string              `json:"payment_method"`
-	Status           string              `json:"status"`
-	Notes            string              `json:"notes"`
-	Total            float64             `json:"total"`
-	DiscountAmount   float64             `json:"discount_amount"`
-	DiscountReason   string              `json:"discount_reason"`
-	FinalTotal       float64             `json:"final_total"`
-	CreatedBy        uuid.UUID           `json:"created_by"`
-	CreatedByName    string              `json:"created_by_name"`
-	Items            []OrderItemResponse `json:"items,omitempty"`
-	Shipment         *ShipmentResponse   `json:"shipment,omitempty"`
-	CreatedAt        time.Time           `json:"created_at"`
-	UpdatedAt        time.Time           `json:"updated_at"`
+	ID                      uuid.UUID              `json:"id"`
+	OrderNumber             string                 `json:"order_number"`
+	CustomerID              *uuid.UUID             `json:"customer_id,omitempty"`
+	CustomerName            string                 `json:"customer_name"`
+	CustomerEmail           string                 `json:"customer_email"`
+	CustomerPhone           string                 `json:"customer_phone"`
+	ShippingAddress         string                 `json:"shipping_address"`
+	ShippingWard            string                 `json:"shipping_ward"`
+	ShippingDistrict        string                 `json:"shipping_district"`
+	ShippingCity            string                 `json:"shipping_city"`
+	ShippingCountry         string                 `json:"shipping_country"`
+	PaymentMethod           string                 `json:"payment_method"`
+	Status                  string                 `json:"status"`
+	Notes                   string                 `json:"notes"`
+	Currency                string                 `json:"currency"`
+	Total                   float64                `json:"total"`
+	TotalFormatted          string                 `json:"total_formatted"`
+	DiscountAmount          float64                `json:"discount_amount"`
+	DiscountAmountFormatted string                 `json:"discount_amount_formatted"`
+	DiscountReason          string                 `json:"discount_reason"`
+	DiscountPercent         *float64               `json:"discount_percent,omitempty"`
+	PromoCode               string                 `json:"promo_code,omitempty"`
+	FinalTotal              float64                `json:"final_total"`
+	FinalTotalFormatted     string                 `json:"final_total_formatted"`
+	CreatedBy               uuid.UUID              `json:"created_by"`
+	CreatedByName           string                 `json:"created_by_name"`
+	Items                   []OrderItemResponse    `json:"items,omitempty"`
+	Shipment                *ShipmentResponse      `json:"shipment,omitempty"`
+	Shipments               []ShipmentResponse     `json:"shipments,omitempty"`
+	DeliveryProof           *DeliveryProofResponse `json:"delivery_proof,omitempty"`
+	CreatedAt               time.Time              `json:"created_at"`
+	UpdatedAt               time.Time              `json:"updated_at"`
+}
+
+// SetShipments fills the shipment list of the order detail. Shipment is kept as the primary,
+// i.e. the first, shipment for clients that predate orders split into several shipments.
+func (d *OrderDetail) SetShipments(shipments []order.Shipment) {
+	d.Shipment = nil
+	d.Shipments = make([]ShipmentResponse, len(shipments))
+	for i, shipment := range shipments {
+		d.Shipments[i] = ConvertToShipmentResponse(shipment)
+	}
+	if len(d.Shipments) > 0 {
+		d.Shipment = &d.Shipments[0]
+	}
+}
+
+// FormatAmounts fills the formatted price and subtotal of the item for display. The item is
+// written in its own currency, or in the given currency when its price currency is unknown.
+func (i *OrderItemResponse) FormatAmounts(currency, locale string) {
+	if i.Currency == "" {
+		i.Currency = currency
+	}
+	i.PriceFormatted = utils.FormatMoney(i.Price, i.Currency, locale)
+	i.SubtotalFormatted = utils.FormatMoney(i.Subtotal, i.Currency, locale)
+}
+
+// FormatAmounts fills the currency and the formatted amounts of the order and its items for
+// display. Orders created before orders had a currency are in the price currency of their items,
+// falling back to defaultCurrency.
+func (d *OrderDetail) FormatAmounts(defaultCurrency, locale string) {
+	if d.Currency == "" {
+		d.Currency = defaultCurrency
+		for _, item := range d.Items {
+			if item.Currency != "" {
+				d.Currency = item.Currency
+				break
+			}
+		}
+	}
+
+	d.TotalFormatted = utils.FormatMoney(d.Total, d.Currency, locale)
+	d.DiscountAmountFormatted = utils.FormatMoney(d.DiscountAmount, d.Currency, locale)
+	d.FinalTotalFormatted = utils.FormatMoney(d.FinalTotal, d.Currency, locale)
+	for i := range d.Items {
+		d.Items[i].FormatAmounts(d.Currency, locale)
+	}
 }
 
 // OrdersResponse represents a list of orders in responses
@@ -93,3 +241,346 @@ type OrderItemDetailResponse struct {
 	Message string            `json:"message"`
 	Data    OrderItemResponse `json:"data"`
 }
+
+// OrderAssignmentResponse represents the assignment outcome of a single order
+type OrderAssignmentResponse struct {
+	OrderID uuid.UUID `json:"order_id"`
+	Success bool      `json:"success"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// BulkAssignOrdersData represents the data of a bulk assignment response
+type BulkAssignOrdersData struct {
+	AgentID  uuid.UUID                 `json:"agent_id"`
+	Assigned int                       `json:"assigned"`
+	Failed   int                       `json:"failed"`
+	Results  []OrderAssignmentResponse `json:"results"`
+}
+
+// BulkAssignOrdersResponse represents the response of a bulk order assignment
+type BulkAssignOrdersResponse struct {
+	Success bool                 `json:"success"`
+	Message string               `json:"message"`
+	Data    BulkAssignOrdersData `json:"data"`
+}
+
+// OrderStatusUpdateResponse represents the status update outcome of a single order
+type OrderStatusUpdateResponse struct {
+	OrderID        uuid.UUID `json:"order_id"`
+	PreviousStatus string    `json:"previous_status,omitempty"`
+	Success        bool      `json:"success"`
+	Error          string    `json:"error,omitempty"`
+	Reason         string    `json:"reason,omitempty" example:"invalid_transition"`
+}
+
+// BulkStatusData represents the data of a bulk status update response
+type BulkStatusData struct {
+	TargetStatus string                      `json:"target_status"`
+	Updated      int                         `json:"updated"`
+	Failed       int                         `json:"failed"`
+	Results      []OrderStatusUpdateResponse `json:"results"`
+}
+
+// BulkStatusResponse represents the response of a bulk status update of the given orders
+type BulkStatusResponse struct {
+	Success bool           `json:"success"`
+	Message string         `json:"message"`
+	Data    BulkStatusData `json:"data"`
+}
+
+// BulkStatusByFilterData represents the data of a bulk status update response
+type BulkStatusByFilterData struct {
+	TargetStatus string                      `json:"target_status"`
+	Matched      int                         `json:"matched"`
+	Updated      int                         `json:"updated"`
+	Failed       int                         `json:"failed"`
+	Results      []OrderStatusUpdateResponse `json:"results"`
+}
+
+// BulkStatusByFilterResponse represents the response of a bulk status update by filter
+type BulkStatusByFilterResponse struct {
+	Success bool                   `json:"success"`
+	Message string                 `json:"message"`
+	Data    BulkStatusByFilterData `json:"data"`
+}
+
+// TrackingEventResponse represents a step in the public order timeline
+type TrackingEventResponse struct {
+	Status string    `json:"status"`
+	Time   time.Time `json:"time"`
+}
+
+// OrderTrackingDetail represents the publicly visible details of an order
+type OrderTrackingDetail struct {
+	OrderNumber    string                  `json:"order_number"`
+	Status         string                  `json:"status"`
+	TrackingNumber string                  `json:"tracking_number,omitempty"`
+	Carrier        string                  `json:"carrier,omitempty"`
+	Timeline       []TrackingEventResponse `json:"timeline"`
+}
+
+// OrderTrackingResponse represents the public order tracking response
+type OrderTrackingResponse struct {
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+	Data    OrderTrackingDetail `json:"data"`
+}
+
+// DiscountPreviewItem represents the effect of an order discount on a single cart item
+type DiscountPreviewItem struct {
+	InventoryID   string  `json:"inventory_id"`
+	ProductID     string  `json:"product_id"`
+	ProductName   string  `json:"product_name"`
+	ProductSKU    string  `json:"product_sku"`
+	Quantity      int     `json:"quantity"`
+	UnitPrice     float64 `json:"unit_price"`
+	LineTotal     float64 `json:"line_total"`
+	DiscountShare float64 `json:"discount_share"`
+	FinalTotal    float64 `json:"final_total"`
+}
+
+// DiscountPreview represents the computed amounts of a cart after applying an order discount
+type DiscountPreview struct {
+	Items            []DiscountPreviewItem `json:"items"`
+	Subtotal         float64               `json:"subtotal"`
+	DiscountAmount   float64               `json:"discount_amount"`
+	DiscountPercent  *float64              `json:"discount_percent,omitempty"`
+	DiscountReason   string                `json:"discount_reason,omitempty"`
+	TaxAmount        float64               `json:"tax_amount"`
+	FinalTotal       float64               `json:"final_total"`
+	RequiresApproval bool                  `json:"requires_approval"`
+}
+
+// DiscountPreviewResponse represents the response of a discount preview
+type DiscountPreviewResponse struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Data    DiscountPreview `json:"data"`
+}
+
+// InventoryAvailabilityDetail represents the stock of an inventory that can still be promised to new orders
+type InventoryAvailabilityDetail struct {
+	InventoryID        string `json:"inventory_id"`
+	ProductID          string `json:"product_id"`
+	Quantity           int    `json:"quantity"`
+	Committed          int    `json:"committed"`
+	Held               int    `json:"held"`
+	AvailableToPromise int    `json:"available_to_promise"`
+}
+
+// InventoryAvailabilityResponse represents the response of an inventory availability lookup
+type InventoryAvailabilityResponse struct {
+	Success bool                        `json:"success"`
+	Message string                      `json:"message"`
+	Data    InventoryAvailabilityDetail `json:"data"`
+}
+
+// InventoryHoldDetail represents stock of an inventory held without an order
+type InventoryHoldDetail struct {
+	ID          uuid.UUID  `json:"id"`
+	InventoryID uuid.UUID  `json:"inventory_id"`
+	Quantity    int        `json:"quantity"`
+	Status      string     `json:"status"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	ReleasedAt  *time.Time `json:"released_at,omitempty"`
+	CustomerID  *uuid.UUID `json:"customer_id,omitempty"`
+	Notes       string     `json:"notes"`
+	CreatedBy   *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ConvertToInventoryHoldDetail converts an order.InventoryHold to an InventoryHoldDetail
+func ConvertToInventoryHoldDetail(hold order.InventoryHold) InventoryHoldDetail {
+	return InventoryHoldDetail{
+		ID:          hold.ID,
+		InventoryID: hold.InventoryID,
+		Quantity:    hold.Quantity,
+		Status:      string(hold.Status),
+		ExpiresAt:   hold.ExpiresAt,
+		ReleasedAt:  hold.ReleasedAt,
+		CustomerID:  hold.CustomerID,
+		Notes:       hold.Notes,
+		CreatedBy:   hold.CreatedBy,
+		CreatedAt:   hold.CreatedAt,
+		UpdatedAt:   hold.UpdatedAt,
+	}
+}
+
+// InventoryHoldResponse represents the response of an inventory hold or release
+type InventoryHoldResponse struct {
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+	Data    InventoryHoldDetail `json:"data"`
+}
+
+// GHNStatusUpdateDetail represents the effect of a GHN webhook status update on an order
+type GHNStatusUpdateDetail struct {
+	OrderID        uuid.UUID `json:"order_id"`
+	OrderNumber    string    `json:"order_number"`
+	TrackingNumber string    `json:"tracking_number"`
+	GHNStatus      string    `json:"ghn_status"`
+	CurrentStatus  string    `json:"current_status"`
+	TargetStatus   string    `json:"target_status,omitempty"`
+	Changes        bool      `json:"changes"`
+	Unmapped       bool      `json:"unmapped"`
+	Applied        bool      `json:"applied"`
+	DryRun         bool      `json:"dry_run"`
+	Reason         string    `json:"reason,omitempty"`
+}
+
+// GHNWebhookTestResponse represents the response of a replayed GHN webhook payload
+type GHNWebhookTestResponse struct {
+	Success bool                  `json:"success"`
+	Message string                `json:"message"`
+	Data    GHNStatusUpdateDetail `json:"data"`
+}
+
+// OrderTrailEntryResponse represents a single event in the activity trail of an order
+type OrderTrailEntryResponse struct {
+	Timestamp time.Time              `json:"timestamp"`
+	Source    string                 `json:"source"`
+	Event     string                 `json:"event,omitempty"`
+	Message   string                 `json:"message"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ShippingPartyResponse represents the sender or the recipient on a shipping label
+type ShippingPartyResponse struct {
+	Name     string `json:"name"`
+	Phone    string `json:"phone"`
+	Address  string `json:"address"`
+	Ward     string `json:"ward"`
+	District string `json:"district"`
+	City     string `json:"city"`
+	Country  string `json:"country"`
+}
+
+// ShippingLabelItemResponse represents a line of the contents of a shipping label
+type ShippingLabelItemResponse struct {
+	InventoryID uuid.UUID `json:"inventory_id"`
+	SKU         string    `json:"sku"`
+	Name        string    `json:"name"`
+	Size        string    `json:"size"`
+	Color       string    `json:"color"`
+	Quantity    int       `json:"quantity"`
+}
+
+// ShippingLabelData represents the data printed on the shipping label of an order.
+// Weights are in grams and dimensions in centimeters.
+type ShippingLabelData struct {
+	OrderID          uuid.UUID                   `json:"order_id"`
+	OrderNumber      string                      `json:"order_number"`
+	Sender           ShippingPartyResponse       `json:"sender"`
+	Recipient        ShippingPartyResponse       `json:"recipient"`
+	Carrier          string                      `json:"carrier"`
+	TrackingNumber   string                      `json:"tracking_number"`
+	Weight           float64                     `json:"weight"`
+	ChargeableWeight float64                     `json:"chargeable_weight"`
+	Length           float64                     `json:"length"`
+	Width            float64                     `json:"width"`
+	Height           float64                     `json:"height"`
+	CODAmount        float64                     `json:"cod_amount"`
+	Items            []ShippingLabelItemResponse `json:"items"`
+	TotalQuantity    int                         `json:"total_quantity"`
+	ContentsSummary  string                      `json:"contents_summary"`
+	Notes            string                      `json:"notes"`
+}
+
+// ShippingLabelResponse represents the shipping label of an order
+type ShippingLabelResponse struct {
+	Success bool              `json:"success"`
+	Message string            `json:"message"`
+	Data    ShippingLabelData `json:"data"`
+}
+
+// OrderTrailResponse represents the activity trail of an order in chronological order
+type OrderTrailResponse struct {
+	Success bool                      `json:"success"`
+	Message string                    `json:"message"`
+	Data    []OrderTrailEntryResponse `json:"data"`
+}
+
+// OrderStatusChangeResponse represents a change of the status of an order
+type OrderStatusChangeResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	FromStatus string     `json:"from_status"`
+	ToStatus   string     `json:"to_status"`
+	ChangedBy  *uuid.UUID `json:"changed_by,omitempty"`
+	Note       string     `json:"note,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ConvertToOrderStatusChangeResponse converts an order.OrderStatusHistory to an OrderStatusChangeResponse
+func ConvertToOrderStatusChangeResponse(change order.OrderStatusHistory) OrderStatusChangeResponse {
+	return OrderStatusChangeResponse{
+		ID:         change.ID,
+		FromStatus: string(change.FromStatus),
+		ToStatus:   string(change.ToStatus),
+		ChangedBy:  change.ChangedBy,
+		Note:       change.Note,
+		CreatedAt:  change.CreatedAt,
+	}
+}
+
+// OrderStatusHistoryResponse represents the status history of an order, oldest first
+type OrderStatusHistoryResponse struct {
+	Success bool                        `json:"success"`
+	Message string                      `json:"message"`
+	Data    []OrderStatusChangeResponse `json:"data"`
+}
+
+// OrderQueueItemResponse represents an order listed in the order queue
+type OrderQueueItemResponse struct {
+	OrderID        uuid.UUID  `json:"order_id"`
+	OrderNumber    string     `json:"order_number"`
+	CustomerName   string     `json:"customer_name"`
+	CustomerPhone  string     `json:"customer_phone"`
+	Status         string     `json:"status"`
+	FinalTotal     float64    `json:"final_total"`
+	TrackingNumber string     `json:"tracking_number"`
+	CreatedBy      *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	HoursWaiting   float64    `json:"hours_waiting"`
+}
+
+// ConvertToOrderQueueItemResponse converts an order to its order queue entry; the waiting time is
+// measured from the order creation up to now
+func ConvertToOrderQueueItemResponse(o order.Order, now time.Time) OrderQueueItemResponse {
+	item := OrderQueueItemResponse{
+		OrderID:       o.ID,
+		OrderNumber:   o.OrderNumber,
+		CustomerName:  o.CustomerName,
+		CustomerPhone: o.CustomerPhone,
+		Status:        string(o.OrderStatus),
+		FinalTotal:    o.FinalTotalAmount,
+		CreatedBy:     o.CreatedBy,
+		CreatedAt:     o.CreatedAt,
+		HoursWaiting:  now.Sub(o.CreatedAt).Hours(),
+	}
+	if shipment := o.PrimaryShipment(); shipment != nil {
+		item.TrackingNumber = shipment.TrackingNumber
+	}
+	return item
+}
+
+// OrderQueueBucketResponse represents a group of orders in the order queue
+type OrderQueueBucketResponse struct {
+	Count  int64                    `json:"count"`
+	Orders []OrderQueueItemResponse `json:"orders"`
+}
+
+// OrderQueueData represents a snapshot of the orders an agent has to work on
+type OrderQueueData struct {
+	GeneratedAt    time.Time                `json:"generated_at"`
+	New            OrderQueueBucketResponse `json:"new"`
+	AwaitingAction OrderQueueBucketResponse `json:"awaiting_action"`
+	Flagged        OrderQueueBucketResponse `json:"flagged"`
+}
+
+// OrderQueueResponse represents the order queue snapshot
+type OrderQueueResponse struct {
+	Success bool           `json:"success"`
+	Message string         `json:"message"`
+	Data    OrderQueueData `json:"data"`
+}
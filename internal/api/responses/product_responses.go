@@ -9,14 +9,17 @@ import (
 
 // InventoryResponse defines the inventory data in a response
 type InventoryResponse struct {
-	ID        uuid.UUID `json:"id"`
-	ProductID uuid.UUID `json:"product_id"`
-	Size      string    `json:"size"`
-	Color     string    `json:"color"`
-	Quantity  int       `json:"quantity"`
-	Location  string    `json:"location"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                uuid.UUID  `json:"id"`
+	ProductID         uuid.UUID  `json:"product_id"`
+	Size              string     `json:"size"`
+	Color             string     `json:"color"`
+	Quantity          int        `json:"quantity"`
+	Location          string     `json:"location"`
+	LowStockThreshold int        `json:"low_stock_threshold"`
+	SizeOptionID      *uuid.UUID `json:"size_option_id,omitempty"`
+	ColorOptionID     *uuid.UUID `json:"color_option_id,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
 }
 
 // PriceResponse defines the price data in a response
@@ -45,17 +48,21 @@ type ProductResponse struct {
 
 // ProductDetailResponse defines the detailed product data in a response
 type ProductDetailResponse struct {
-	ID          uuid.UUID           `json:"id"`
-	Name        string              `json:"name"`
-	Description string              `json:"description"`
-	SKU         string              `json:"sku"`
-	Category    string              `json:"category"`
-	ImageURL    string              `json:"image_url"`
-	Inventories []InventoryResponse `json:"inventories,omitempty"`
-	Prices      []PriceResponse     `json:"prices,omitempty"`
-	Images      []ImageResponse     `json:"images,omitempty"`
-	CreatedAt   time.Time           `json:"created_at"`
-	UpdatedAt   time.Time           `json:"updated_at"`
+	ID            uuid.UUID           `json:"id"`
+	Name          string              `json:"name"`
+	Description   string              `json:"description"`
+	SKU           string              `json:"sku"`
+	Category      string              `json:"category"`
+	ImageURL      string              `json:"image_url"`
+	SoldCount     int                 `json:"sold_count"`
+	IsFeatured    bool                `json:"is_featured"`
+	FeaturedOrder int                 `json:"featured_order,omitempty"`
+	Inventories   []InventoryResponse `json:"inventories,omitempty"`
+	Prices        []PriceResponse     `json:"prices,omitempty"`
+	Images        []ImageResponse     `json:"images,omitempty"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+	DeletedAt     *time.Time          `json:"deleted_at,omitempty"`
 }
 
 // ImageResponse defines the image data in a response
@@ -63,22 +70,97 @@ type ImageResponse struct {
 	ID        uuid.UUID `json:"id"`
 	ProductID uuid.UUID `json:"product_id"`
 	URL       string    `json:"url"`
-	Filename  string    `json:"filename"`
-	IsPrimary bool      `json:"is_primary"`
-	SortOrder int       `json:"sort_order"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	// Resized copies of the image for lists and detail pages
+	ThumbnailURL string    `json:"thumbnail_url"`
+	MediumURL    string    `json:"medium_url"`
+	Filename     string    `json:"filename"`
+	IsPrimary    bool      `json:"is_primary"`
+	SortOrder    int       `json:"sort_order"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SingleProductResponse defines the response for a single product
+type SingleProductResponse struct {
+	Success bool                  `json:"success"`
+	Message string                `json:"message"`
+	Data    ProductDetailResponse `json:"data"`
 }
 
-// ProductsResponse defines the response for a list of products
+// ProductsResponse defines the response for a paginated list of products
 type ProductsResponse struct {
 	Success    bool                    `json:"success"`
 	Message    string                  `json:"message"`
-	Products   []ProductDetailResponse `json:"products"`
+	Data       []ProductDetailResponse `json:"data"`
 	Total      int64                   `json:"total"`
 	Page       int                     `json:"page"`
 	PageSize   int                     `json:"page_size"`
-	TotalPages int                     `json:"total_pages"`
+	TotalPages int64                   `json:"total_pages"`
+}
+
+// SingleInventoryResponse defines the response for a single inventory
+type SingleInventoryResponse struct {
+	Success bool              `json:"success"`
+	Message string            `json:"message"`
+	Data    InventoryResponse `json:"data"`
+}
+
+// InventoriesResponse defines the response for a list of inventories
+type InventoriesResponse struct {
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+	Data    []InventoryResponse `json:"data"`
+}
+
+// SinglePriceResponse defines the response for a single price
+type SinglePriceResponse struct {
+	Success bool          `json:"success"`
+	Message string        `json:"message"`
+	Data    PriceResponse `json:"data"`
+}
+
+// SingleImageResponse defines the response for a single product image
+type SingleImageResponse struct {
+	Success bool          `json:"success"`
+	Message string        `json:"message"`
+	Data    ImageResponse `json:"data"`
+}
+
+// ImagesResponse defines the response for a list of product images
+type ImagesResponse struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Data    []ImageResponse `json:"data"`
+}
+
+// ImageUploadFileResponse defines the outcome of a single file of a multiple or bulk image upload
+type ImageUploadFileResponse struct {
+	Index     int        `json:"index"`
+	Filename  string     `json:"filename"`
+	SKU       string     `json:"sku,omitempty"`
+	ProductID *uuid.UUID `json:"product_id,omitempty"`
+	Success   bool       `json:"success"`
+	Error     string     `json:"error,omitempty"`
+	ImageID   *uuid.UUID `json:"image_id,omitempty"`
+	URL       string     `json:"url,omitempty"`
+	IsPrimary bool       `json:"is_primary"`
+}
+
+// ImageUploadData defines the outcome of a multiple or bulk image upload
+type ImageUploadData struct {
+	Files    []ImageUploadFileResponse `json:"files"`
+	Products int                       `json:"products,omitempty"`
+	Uploaded int                       `json:"uploaded"`
+	Failed   int                       `json:"failed"`
+}
+
+// ImageUploadResponse defines the response for a multiple or bulk image upload. Error is set
+// when every file was rejected; Data still reports the outcome of each file.
+type ImageUploadResponse struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Error   string          `json:"error,omitempty"`
+	Data    ImageUploadData `json:"data"`
 }
 
 // SuccessResponse defines a standard success response
@@ -90,14 +172,17 @@ type SuccessResponse struct {
 // ConvertToInventoryResponse converts a product.Inventory to an InventoryResponse
 func ConvertToInventoryResponse(inventory product.Inventory) InventoryResponse {
 	return InventoryResponse{
-		ID:        inventory.ID,
-		ProductID: inventory.ProductID,
-		Size:      inventory.Size,
-		Color:     inventory.Color,
-		Quantity:  inventory.Quantity,
-		Location:  inventory.Location,
-		CreatedAt: inventory.CreatedAt,
-		UpdatedAt: inventory.UpdatedAt,
+		ID:                inventory.ID,
+		ProductID:         inventory.ProductID,
+		Size:              inventory.Size,
+		Color:             inventory.Color,
+		Quantity:          inventory.Quantity,
+		Location:          inventory.Location,
+		LowStockThreshold: inventory.LowStockThreshold,
+		SizeOptionID:      inventory.SizeOptionID,
+		ColorOptionID:     inventory.ColorOptionID,
+		CreatedAt:         inventory.CreatedAt,
+		UpdatedAt:         inventory.UpdatedAt,
 	}
 }
 
@@ -117,29 +202,46 @@ func ConvertToPriceResponse(price product.Price) PriceResponse {
 
 // ConvertToImageResponse converts a product.ProductImage to an ImageResponse
 func ConvertToImageResponse(image product.ProductImage) ImageResponse {
-	return ImageResponse{
-		ID:        image.ID,
-		ProductID: image.ProductID,
-		URL:       image.URL,
-		Filename:  image.Filename,
-		IsPrimary: image.IsPrimary,
-		SortOrder: image.SortOrder,
-		CreatedAt: image.CreatedAt,
-		UpdatedAt: image.UpdatedAt,
+	response := ImageResponse{
+		ID:           image.ID,
+		ProductID:    image.ProductID,
+		URL:          image.URL,
+		ThumbnailURL: image.ThumbnailURL,
+		MediumURL:    image.MediumURL,
+		Filename:     image.Filename,
+		IsPrimary:    image.IsPrimary,
+		SortOrder:    image.SortOrder,
+		CreatedAt:    image.CreatedAt,
+		UpdatedAt:    image.UpdatedAt,
+	}
+
+	// Images uploaded before variants were generated are shown in their original size
+	if response.ThumbnailURL == "" {
+		response.ThumbnailURL = image.URL
+	}
+	if response.MediumURL == "" {
+		response.MediumURL = image.URL
 	}
+	return response
 }
 
 // ConvertToProductDetailResponse converts a product.Product to a ProductDetailResponse
 func ConvertToProductDetailResponse(p product.Product) ProductDetailResponse {
 	response := ProductDetailResponse{
-		ID:          p.ID,
-		Name:        p.Name,
-		Description: p.Description,
-		SKU:         p.SKU,
-		Category:    p.Category,
-		ImageURL:    p.ImageURL,
-		CreatedAt:   p.CreatedAt,
-		UpdatedAt:   p.UpdatedAt,
+		ID:            p.ID,
+		Name:          p.Name,
+		Description:   p.Description,
+		SKU:           p.SKU,
+		Category:      p.Category,
+		ImageURL:      p.PrimaryImageURL(),
+		SoldCount:     p.SoldCount,
+		IsFeatured:    p.IsFeatured,
+		FeaturedOrder: p.FeaturedOrder,
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+	}
+	if p.DeletedAt.Valid {
+		response.DeletedAt = &p.DeletedAt.Time
 	}
 
 	// Convert inventories
@@ -177,3 +279,192 @@ func ConvertToProductDetailResponses(products []product.Product) []ProductDetail
 	}
 	return responses
 }
+
+// StockEvaluationItemResponse defines the stock evaluation of a single inventory in a response
+type StockEvaluationItemResponse struct {
+	InventoryID       uuid.UUID `json:"inventory_id"`
+	Size              string    `json:"size"`
+	Color             string    `json:"color"`
+	Quantity          int       `json:"quantity"`
+	LowStockThreshold int       `json:"low_stock_threshold"`
+	Event             string    `json:"event,omitempty"`
+	Notified          bool      `json:"notified"`
+}
+
+// StockEvaluationResponse defines the response for a product stock evaluation
+type StockEvaluationResponse struct {
+	Success bool                          `json:"success"`
+	Message string                        `json:"message"`
+	Data    []StockEvaluationItemResponse `json:"data"`
+}
+
+// InventoryTransactionResponse defines an inventory stock movement in a response
+type InventoryTransactionResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	InventoryID   uuid.UUID  `json:"inventory_id"`
+	Quantity      int        `json:"quantity"`
+	Type          string     `json:"type"`
+	Reason        string     `json:"reason"`
+	ReferenceID   *uuid.UUID `json:"reference_id,omitempty"`
+	ReferenceType string     `json:"reference_type,omitempty"`
+	Notes         string     `json:"notes,omitempty"`
+	Balance       *int       `json:"balance,omitempty"`
+	CreatedBy     *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ConvertToInventoryTransactionResponse converts a product.InventoryTransaction to an InventoryTransactionResponse
+func ConvertToInventoryTransactionResponse(transaction product.InventoryTransaction) InventoryTransactionResponse {
+	return InventoryTransactionResponse{
+		ID:            transaction.ID,
+		InventoryID:   transaction.InventoryID,
+		Quantity:      transaction.Quantity,
+		Type:          string(transaction.Type),
+		Reason:        string(transaction.Reason),
+		ReferenceID:   transaction.ReferenceID,
+		ReferenceType: transaction.ReferenceType,
+		Notes:         transaction.Notes,
+		Balance:       transaction.Balance,
+		CreatedBy:     transaction.CreatedBy,
+		CreatedAt:     transaction.CreatedAt,
+	}
+}
+
+// InventoryRestocksResponse defines the response for the restock history of an inventory
+type InventoryRestocksResponse struct {
+	Success bool                           `json:"success"`
+	Message string                         `json:"message"`
+	Data    []InventoryTransactionResponse `json:"data"`
+}
+
+// InventoryMovementsResponse defines the response for the stock change history of an inventory
+type InventoryMovementsResponse struct {
+	Success bool                           `json:"success"`
+	Message string                         `json:"message"`
+	Data    []InventoryTransactionResponse `json:"data"`
+}
+
+// InventoryTransferResponse defines a stock transfer between two inventories in a response
+type InventoryTransferResponse struct {
+	ID              uuid.UUID  `json:"id"`
+	FromInventoryID uuid.UUID  `json:"from_inventory_id"`
+	ToInventoryID   uuid.UUID  `json:"to_inventory_id"`
+	Quantity        int        `json:"quantity"`
+	Notes           string     `json:"notes,omitempty"`
+	CreatedBy       *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// ConvertToInventoryTransferResponse converts a product.InventoryTransfer to an InventoryTransferResponse
+func ConvertToInventoryTransferResponse(transfer product.InventoryTransfer) InventoryTransferResponse {
+	return InventoryTransferResponse{
+		ID:              transfer.ID,
+		FromInventoryID: transfer.FromInventoryID,
+		ToInventoryID:   transfer.ToInventoryID,
+		Quantity:        transfer.Quantity,
+		Notes:           transfer.Notes,
+		CreatedBy:       transfer.CreatedBy,
+		CreatedAt:       transfer.CreatedAt,
+	}
+}
+
+// SingleInventoryTransferResponse defines the response for a stock transfer
+type SingleInventoryTransferResponse struct {
+	Success bool                      `json:"success"`
+	Message string                    `json:"message"`
+	Data    InventoryTransferResponse `json:"data"`
+}
+
+// VariantOptionResponse defines a size or color a product allows in a response
+type VariantOptionResponse struct {
+	ID        uuid.UUID `json:"id"`
+	ProductID uuid.UUID `json:"product_id"`
+	Attribute string    `json:"attribute"`
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ConvertToVariantOptionResponse converts a product.VariantOption to a VariantOptionResponse
+func ConvertToVariantOptionResponse(option product.VariantOption) VariantOptionResponse {
+	return VariantOptionResponse{
+		ID:        option.ID,
+		ProductID: option.ProductID,
+		Attribute: string(option.Attribute),
+		Value:     option.Value,
+		CreatedAt: option.CreatedAt,
+	}
+}
+
+// VariantOptionsResponse defines the response for the variant options of a product
+type VariantOptionsResponse struct {
+	Success bool                    `json:"success"`
+	Message string                  `json:"message"`
+	Data    []VariantOptionResponse `json:"data"`
+}
+
+// SingleVariantOptionResponse defines the response for a single variant option
+type SingleVariantOptionResponse struct {
+	Success bool                  `json:"success"`
+	Message string                `json:"message"`
+	Data    VariantOptionResponse `json:"data"`
+}
+
+// PriceTierResponse defines a quantity break of a price in a response
+type PriceTierResponse struct {
+	ID          uuid.UUID `json:"id"`
+	PriceID     uuid.UUID `json:"price_id"`
+	MinQuantity int       `json:"min_quantity"`
+	UnitPrice   float64   `json:"unit_price"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ConvertToPriceTierResponse converts a product.PriceTier to a PriceTierResponse
+func ConvertToPriceTierResponse(tier product.PriceTier) PriceTierResponse {
+	return PriceTierResponse{
+		ID:          tier.ID,
+		PriceID:     tier.PriceID,
+		MinQuantity: tier.MinQuantity,
+		UnitPrice:   tier.UnitPrice,
+		CreatedAt:   tier.CreatedAt,
+		UpdatedAt:   tier.UpdatedAt,
+	}
+}
+
+// PriceTiersResponse defines the response for the quantity breaks of a price
+type PriceTiersResponse struct {
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+	Data    []PriceTierResponse `json:"data"`
+}
+
+// SinglePriceTierResponse defines the response for a single quantity break
+type SinglePriceTierResponse struct {
+	Success bool              `json:"success"`
+	Message string            `json:"message"`
+	Data    PriceTierResponse `json:"data"`
+}
+
+// FeaturedProductsResponse defines the response for the featured product list
+type FeaturedProductsResponse struct {
+	Success bool                    `json:"success"`
+	Message string                  `json:"message"`
+	Data    []ProductDetailResponse `json:"data"`
+}
+
+// RelatedProductResponse represents a product frequently bought together with another product
+type RelatedProductResponse struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	SKU        string    `json:"sku"`
+	Category   string    `json:"category"`
+	ImageURL   string    `json:"image_url"`
+	OrderCount int       `json:"order_count"`
+}
+
+// RelatedProductsResponse defines the response for the products related to a product
+type RelatedProductsResponse struct {
+	Success bool                     `json:"success"`
+	Message string                   `json:"message"`
+	Data    []RelatedProductResponse `json:"data"`
+}
@@ -0,0 +1,60 @@
+package responses
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models/order"
+)
+
+// PromoCodeResponse represents a promo code in responses
+type PromoCodeResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	Code           string     `json:"code"`
+	Type           string     `json:"type"`
+	Value          float64    `json:"value"`
+	StartDate      time.Time  `json:"start_date"`
+	EndDate        *time.Time `json:"end_date,omitempty"`
+	MaxUses        int        `json:"max_uses"`
+	UsedCount      int        `json:"used_count"`
+	MinOrderAmount float64    `json:"min_order_amount"`
+	Description    string     `json:"description"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// ConvertToPromoCodeResponse converts an order.PromoCode to a PromoCodeResponse
+func ConvertToPromoCodeResponse(promo order.PromoCode) PromoCodeResponse {
+	return PromoCodeResponse{
+		ID:             promo.ID,
+		Code:           promo.Code,
+		Type:           string(promo.Type),
+		Value:          promo.Value,
+		StartDate:      promo.StartDate,
+		EndDate:        promo.EndDate,
+		MaxUses:        promo.MaxUses,
+		UsedCount:      promo.UsedCount,
+		MinOrderAmount: promo.MinOrderAmount,
+		Description:    promo.Description,
+		CreatedAt:      promo.CreatedAt,
+		UpdatedAt:      promo.UpdatedAt,
+	}
+}
+
+// SinglePromoCodeResponse represents a single promo code response
+type SinglePromoCodeResponse struct {
+	Success bool              `json:"success"`
+	Message string            `json:"message"`
+	Data    PromoCodeResponse `json:"data"`
+}
+
+// PromoCodesResponse represents a paginated list of promo codes in responses
+type PromoCodesResponse struct {
+	Success    bool                `json:"success"`
+	Message    string              `json:"message"`
+	Data       []PromoCodeResponse `json:"data"`
+	Total      int64               `json:"total"`
+	Page       int                 `json:"page"`
+	PageSize   int                 `json:"page_size"`
+	TotalPages int64               `json:"total_pages"`
+}
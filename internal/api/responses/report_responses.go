@@ -0,0 +1,156 @@
+package responses
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LowStockItemResponse represents an inventory that is low on stock with a reorder suggestion
+type LowStockItemResponse struct {
+	InventoryID       uuid.UUID `json:"inventory_id"`
+	ProductID         uuid.UUID `json:"product_id"`
+	ProductName       string    `json:"product_name"`
+	SKU               string    `json:"sku"`
+	Category          string    `json:"category"`
+	Size              string    `json:"size"`
+	Color             string    `json:"color"`
+	Location          string    `json:"location"`
+	Quantity          int       `json:"quantity"`
+	LowStockThreshold int       `json:"low_stock_threshold"`
+	SuggestedQuantity int       `json:"suggested_quantity"`
+}
+
+// LowStockReportResponse represents the low stock report
+type LowStockReportResponse struct {
+	Success bool                   `json:"success"`
+	Message string                 `json:"message"`
+	Data    []LowStockItemResponse `json:"data"`
+	Total   int                    `json:"total"`
+}
+
+// PaymentMethodRevenueResponse represents the revenue collected through a payment method
+type PaymentMethodRevenueResponse struct {
+	PaymentMethod string  `json:"payment_method"`
+	OrderCount    int64   `json:"order_count"`
+	TotalRevenue  float64 `json:"total_revenue"`
+}
+
+// RevenueByPaymentData represents the revenue breakdown by payment method
+type RevenueByPaymentData struct {
+	Methods      []PaymentMethodRevenueResponse `json:"methods"`
+	OrderCount   int64                          `json:"order_count"`
+	TotalRevenue float64                        `json:"total_revenue"`
+}
+
+// RevenueByPaymentResponse represents the revenue by payment method report
+type RevenueByPaymentResponse struct {
+	Success bool                 `json:"success"`
+	Message string               `json:"message"`
+	Data    RevenueByPaymentData `json:"data"`
+}
+
+// ShipmentSLABreachResponse represents an order that has waited too long for a shipment
+type ShipmentSLABreachResponse struct {
+	OrderID       uuid.UUID  `json:"order_id"`
+	OrderNumber   string     `json:"order_number"`
+	CustomerName  string     `json:"customer_name"`
+	CustomerPhone string     `json:"customer_phone"`
+	Status        string     `json:"status"`
+	CreatedBy     *uuid.UUID `json:"created_by,omitempty"`
+	WaitingSince  time.Time  `json:"waiting_since"`
+	HoursWaiting  float64    `json:"hours_waiting"`
+}
+
+// ShipmentSLAReportResponse represents the shipment SLA report
+type ShipmentSLAReportResponse struct {
+	Success  bool                        `json:"success"`
+	Message  string                      `json:"message"`
+	Data     []ShipmentSLABreachResponse `json:"data"`
+	Total    int                         `json:"total"`
+	SLAHours int                         `json:"sla_hours"`
+}
+
+// LowStockTrendDayResponse represents the number of inventories low on stock at the end of a day
+type LowStockTrendDayResponse struct {
+	Date          string `json:"date"`
+	LowStockCount int64  `json:"low_stock_count"`
+}
+
+// LowStockTrendResponse represents the daily low stock trend report
+type LowStockTrendResponse struct {
+	Success  bool                       `json:"success"`
+	Message  string                     `json:"message"`
+	Data     []LowStockTrendDayResponse `json:"data"`
+	FromDate string                     `json:"from_date"`
+	ToDate   string                     `json:"to_date"`
+}
+
+// CategoryInventoryValueResponse represents the value of the on-hand stock of a product category
+type CategoryInventoryValueResponse struct {
+	Category      string  `json:"category"`
+	Quantity      int64   `json:"quantity"`
+	TotalValue    float64 `json:"total_value"`
+	ExcludedCount int64   `json:"excluded_count"`
+}
+
+// InventoryValueData represents the value of the on-hand stock at current prices
+type InventoryValueData struct {
+	TotalValue    float64                          `json:"total_value"`
+	TotalQuantity int64                            `json:"total_quantity"`
+	ExcludedCount int64                            `json:"excluded_count"`
+	Categories    []CategoryInventoryValueResponse `json:"categories"`
+}
+
+// InventoryValueResponse represents the inventory value report
+type InventoryValueResponse struct {
+	Success bool               `json:"success"`
+	Message string             `json:"message"`
+	Data    InventoryValueData `json:"data"`
+}
+
+// SalesBucketResponse represents the sales of the orders created in one period
+type SalesBucketResponse struct {
+	PeriodStart   string  `json:"period_start" example:"2024-01-15"`
+	OrderCount    int64   `json:"order_count"`
+	GrossTotal    float64 `json:"gross_total"`
+	TotalDiscount float64 `json:"total_discount"`
+	NetTotal      float64 `json:"net_total"`
+	CanceledCount int64   `json:"canceled_count"`
+}
+
+// SalesSummaryData represents the sales grouped by period with the overall totals
+type SalesSummaryData struct {
+	GroupBy       string                `json:"group_by"`
+	Buckets       []SalesBucketResponse `json:"buckets"`
+	OrderCount    int64                 `json:"order_count"`
+	GrossTotal    float64               `json:"gross_total"`
+	TotalDiscount float64               `json:"total_discount"`
+	NetTotal      float64               `json:"net_total"`
+	CanceledCount int64                 `json:"canceled_count"`
+}
+
+// SalesSummaryResponse represents the sales summary report
+type SalesSummaryResponse struct {
+	Success bool             `json:"success"`
+	Message string           `json:"message"`
+	Data    SalesSummaryData `json:"data"`
+}
+
+// TopProductResponse represents the sales of a product in the top products report
+type TopProductResponse struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Name      string    `json:"name"`
+	SKU       string    `json:"sku"`
+	UnitsSold int64     `json:"units_sold"`
+	Revenue   float64   `json:"revenue"`
+}
+
+// TopProductsResponse represents the top products report
+type TopProductsResponse struct {
+	Success bool                 `json:"success"`
+	Message string               `json:"message"`
+	Data    []TopProductResponse `json:"data"`
+	Sort    string               `json:"sort"`
+	Total   int                  `json:"total"`
+}
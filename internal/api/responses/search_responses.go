@@ -0,0 +1,41 @@
+package responses
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderSearchResult represents an order matching a search query
+type OrderSearchResult struct {
+	ID            uuid.UUID `json:"id"`
+	OrderNumber   string    `json:"order_number"`
+	CustomerName  string    `json:"customer_name"`
+	CustomerPhone string    `json:"customer_phone"`
+	Status        string    `json:"status"`
+	FinalTotal    float64   `json:"final_total"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ProductSearchResult represents a product matching a search query
+type ProductSearchResult struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	SKU      string    `json:"sku"`
+	Category string    `json:"category"`
+	ImageURL string    `json:"image_url"`
+}
+
+// SearchData represents the matches of a search grouped by resource type
+type SearchData struct {
+	Query    string                `json:"query"`
+	Orders   []OrderSearchResult   `json:"orders"`
+	Products []ProductSearchResult `json:"products"`
+}
+
+// SearchResponse represents the response of a global search
+type SearchResponse struct {
+	Success bool       `json:"success"`
+	Message string     `json:"message"`
+	Data    SearchData `json:"data"`
+}
@@ -36,3 +36,38 @@ type UsersResponse struct {
 	PageSize   int                  `json:"page_size"`
 	TotalPages int                  `json:"total_pages"`
 }
+
+// UserWorkloadData represents the order counts of a user grouped by status
+type UserWorkloadData struct {
+	UserID       uuid.UUID        `json:"user_id"`
+	Username     string           `json:"username"`
+	StatusCounts map[string]int64 `json:"status_counts"`
+	PendingCount int64            `json:"pending_count"`
+	OpenCount    int64            `json:"open_count"`
+	TotalCount   int64            `json:"total_count"`
+}
+
+// UserWorkloadResponse defines the response for a user's workload
+type UserWorkloadResponse struct {
+	Success bool             `json:"success"`
+	Message string           `json:"message"`
+	Data    UserWorkloadData `json:"data"`
+}
+
+// TelegramChatResponse represents a chat that recently messaged the Telegram bot
+type TelegramChatResponse struct {
+	ChatID         int64     `json:"chat_id"`
+	Type           string    `json:"type"`
+	Title          string    `json:"title"`
+	SenderName     string    `json:"sender_name"`
+	SenderUsername string    `json:"sender_username"`
+	LastMessage    string    `json:"last_message"`
+	LastSeenAt     time.Time `json:"last_seen_at"`
+}
+
+// TelegramChatsResponse defines the response for the list of discovered Telegram chats
+type TelegramChatsResponse struct {
+	Success bool                   `json:"success"`
+	Message string                 `json:"message"`
+	Data    []TelegramChatResponse `json:"data"`
+}
@@ -0,0 +1,96 @@
+package responses
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models/order"
+)
+
+// WebhookEndpointResponse represents a webhook endpoint in responses. The secret is only
+// returned when the endpoint is created.
+type WebhookEndpointResponse struct {
+	ID          uuid.UUID `json:"id"`
+	URL         string    `json:"url"`
+	Secret      string    `json:"secret,omitempty"`
+	Events      []string  `json:"events"`
+	Description string    `json:"description"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ConvertToWebhookEndpointResponse converts an order.WebhookEndpoint to a WebhookEndpointResponse
+func ConvertToWebhookEndpointResponse(endpoint order.WebhookEndpoint) WebhookEndpointResponse {
+	events := make([]string, 0)
+	for _, event := range endpoint.EventList() {
+		events = append(events, string(event))
+	}
+
+	return WebhookEndpointResponse{
+		ID:          endpoint.ID,
+		URL:         endpoint.URL,
+		Events:      events,
+		Description: endpoint.Description,
+		Active:      endpoint.Active,
+		CreatedAt:   endpoint.CreatedAt,
+		UpdatedAt:   endpoint.UpdatedAt,
+	}
+}
+
+// SingleWebhookEndpointResponse represents a single webhook endpoint response
+type SingleWebhookEndpointResponse struct {
+	Success bool                    `json:"success"`
+	Message string                  `json:"message"`
+	Data    WebhookEndpointResponse `json:"data"`
+}
+
+// WebhookEndpointsResponse represents a list of webhook endpoints in responses
+type WebhookEndpointsResponse struct {
+	Success bool                      `json:"success"`
+	Message string                    `json:"message"`
+	Data    []WebhookEndpointResponse `json:"data"`
+}
+
+// WebhookDeliveryResponse represents a webhook delivery in responses
+type WebhookDeliveryResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	EndpointID     uuid.UUID  `json:"endpoint_id"`
+	Event          string     `json:"event"`
+	OrderID        *uuid.UUID `json:"order_id,omitempty"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	ResponseStatus int        `json:"response_status"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// ConvertToWebhookDeliveryResponse converts an order.WebhookDelivery to a WebhookDeliveryResponse
+func ConvertToWebhookDeliveryResponse(delivery order.WebhookDelivery) WebhookDeliveryResponse {
+	return WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		EndpointID:     delivery.EndpointID,
+		Event:          string(delivery.Event),
+		OrderID:        delivery.OrderID,
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastAttemptAt:  delivery.LastAttemptAt,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
+
+// WebhookDeliveriesResponse represents a paginated list of webhook deliveries in responses
+type WebhookDeliveriesResponse struct {
+	Success    bool                      `json:"success"`
+	Message    string                    `json:"message"`
+	Data       []WebhookDeliveryResponse `json:"data"`
+	Total      int64                     `json:"total"`
+	Page       int                       `json:"page"`
+	PageSize   int                       `json:"page_size"`
+	TotalPages int64                     `json:"total_pages"`
+}
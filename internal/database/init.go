@@ -7,7 +7,9 @@ import (
 	"github.com/ybds/internal/models/notification"
 	"github.com/ybds/internal/models/order"
 	"github.com/ybds/internal/models/product"
+	"github.com/ybds/internal/repositories"
 	"github.com/ybds/pkg/database"
+	"github.com/ybds/pkg/phone"
 	"gorm.io/gorm"
 )
 
@@ -74,6 +76,10 @@ func migrateAccountModels(db *gorm.DB) error {
 		&account.User{},
 		&account.Role{},
 		&account.UserRole{},
+		&account.AuditLog{},
+		&account.RefreshToken{},
+		&account.PasswordResetToken{},
+		&account.APIKey{},
 	)
 }
 
@@ -89,21 +95,193 @@ func migrateNotificationModels(db *gorm.DB) error {
 // migrateOrderModels auto-migrates order-related models
 func migrateOrderModels(db *gorm.DB) error {
 	log.Println("Migrating order models...")
-	return db.AutoMigrate(
+
+	// Orders created before reservations were tracked already hold stock once they are packed
+	backfillReservations := db.Migrator().HasTable(&order.Order{}) &&
+		!db.Migrator().HasColumn(&order.Order{}, "InventoryReserved")
+
+	// Shipments used to be unique per order; orders may now be split into several shipments
+	if db.Migrator().HasIndex(&order.Shipment{}, "idx_shipments_order_id") {
+		if err := db.Migrator().DropIndex(&order.Shipment{}, "idx_shipments_order_id"); err != nil {
+			return err
+		}
+	}
+
+	if err := db.AutoMigrate(
+		&order.Customer{},
 		&order.Order{},
 		&order.OrderItem{},
 		&order.Shipment{},
-	)
+		&order.ShipmentItem{},
+		&order.OrderNumberSequence{},
+		&order.InventoryHold{},
+		&order.PromoCode{},
+		&order.WebhookEndpoint{},
+		&order.WebhookDelivery{},
+		&order.OrderStatusHistory{},
+	); err != nil {
+		return err
+	}
+
+	if backfillReservations {
+		log.Println("Backfilling inventory reservations of existing orders...")
+		if err := db.Model(&order.Order{}).
+			Where("order_status IN ?", []order.OrderStatus{
+				order.OrderPacked,
+				order.OrderPicked,
+				order.OrderDelivering,
+				order.OrderDelivered,
+				order.OrderReturnProcessing,
+			}).
+			UpdateColumn("inventory_reserved", true).Error; err != nil {
+			return err
+		}
+	}
+
+	// Orders placed before customers existed, or imported without one, are linked by phone number
+	return db.Transaction(backfillOrderCustomers)
+}
+
+// unlinkedOrderPhones selects the orders not linked to a customer with their phone number normalized
+// like the phone of customers, e.g. 0912-345-678 becomes +84912345678. Numbers that are not valid
+// Vietnamese numbers are only stripped of their formatting.
+const unlinkedOrderPhones = `
+	SELECT id, customer_name, customer_email, created_at,
+		CASE
+			WHEN national ~ '` + phone.NationalPattern + `' THEN '+84' || SUBSTRING(national FROM 2)
+			ELSE national
+		END AS phone
+	FROM (
+		SELECT id, customer_name, customer_email, created_at,
+			CASE
+				WHEN digits LIKE '+84%' THEN '0' || SUBSTRING(digits FROM 4)
+				WHEN digits LIKE '84%' AND LENGTH(digits) IN (11, 12) THEN '0' || SUBSTRING(digits FROM 3)
+				ELSE digits
+			END AS national
+		FROM (
+			SELECT id, customer_name, customer_email, created_at,
+				REGEXP_REPLACE(COALESCE(customer_phone, ''), '[^0-9+]', '', 'g') AS digits
+			FROM orders
+			WHERE customer_id IS NULL AND deleted_at IS NULL
+		) AS stripped
+	) AS nationalized`
+
+// backfillOrderCustomers converts the phone numbers of customers created in national format to
+// E.164, creates a customer for every phone number of the orders not linked to one, named after its
+// latest order, then links the orders to the customer of their phone number. Orders without a phone
+// number stay unlinked, so running it again only picks up new unlinked orders.
+func backfillOrderCustomers(tx *gorm.DB) error {
+	if err := tx.Exec(`
+		UPDATE customers
+		SET phone = '+84' || SUBSTRING(phone FROM 2)
+		WHERE phone ~ '` + phone.NationalPattern + `'
+			AND NOT EXISTS (
+				SELECT 1 FROM customers AS normalized
+				WHERE normalized.phone = '+84' || SUBSTRING(customers.phone FROM 2))`).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Exec(`
+		INSERT INTO customers (id, phone, name, email, created_at, updated_at)
+		SELECT gen_random_uuid(), phone, customer_name, customer_email, first_order_at, NOW()
+		FROM (
+			SELECT DISTINCT ON (phone) phone, customer_name, customer_email,
+				MIN(created_at) OVER (PARTITION BY phone) AS first_order_at
+			FROM (` + unlinkedOrderPhones + `) AS unlinked
+			WHERE phone <> ''
+			ORDER BY phone, created_at DESC
+		) AS latest
+		ON CONFLICT (phone) DO NOTHING`).Error; err != nil {
+		return err
+	}
+
+	return tx.Exec(`
+		UPDATE orders
+		SET customer_id = customers.id
+		FROM (` + unlinkedOrderPhones + `) AS unlinked, customers
+		WHERE unlinked.id = orders.id
+			AND customers.phone = unlinked.phone
+			AND customers.deleted_at IS NULL`).Error
 }
 
 // migrateProductModels auto-migrates product-related models
 func migrateProductModels(db *gorm.DB) error {
 	log.Println("Migrating product models...")
-	return db.AutoMigrate(
+
+	// Inventories created before variant options existed carry free-text sizes and colors
+	backfillVariantOptions := db.Migrator().HasTable(&product.Inventory{}) &&
+		!db.Migrator().HasColumn(&product.Inventory{}, "SizeOptionID")
+
+	if err := db.AutoMigrate(
 		&product.Product{},
 		&product.Inventory{},
 		&product.Price{},
 		&product.InventoryTransaction{},
+		&product.InventoryTransfer{},
 		&product.ProductImage{},
-	)
+		&product.VariantOption{},
+		&product.PriceTier{},
+	); err != nil {
+		return err
+	}
+
+	if err := createProductSearchIndexes(db); err != nil {
+		return err
+	}
+
+	if backfillVariantOptions {
+		log.Println("Backfilling variant options of existing inventories...")
+		return db.Transaction(func(tx *gorm.DB) error {
+			for _, attribute := range []product.VariantAttribute{product.AttributeSize, product.AttributeColor} {
+				if err := backfillVariantOption(tx, attribute); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	return nil
+}
+
+// createProductSearchIndexes creates the GIN indexes used by product search: one on the full-text
+// document of products and a trigram index on SKUs for partial SKU matches
+func createProductSearchIndexes(db *gorm.DB) error {
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		return err
+	}
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_products_search ON products USING GIN ((" +
+		repositories.ProductSearchVector + "))").Error; err != nil {
+		return err
+	}
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_products_sku_trgm ON products USING GIN (sku gin_trgm_ops)").Error
+}
+
+// backfillVariantOption defines the distinct values of an attribute used by the inventories of each
+// product as its options, then links the inventories to them. Values differing only in case or
+// surrounding spaces become one option, and the inventories take over its spelling.
+func backfillVariantOption(tx *gorm.DB, attribute product.VariantAttribute) error {
+	column := string(attribute)
+	optionColumn := column + "_option_id"
+
+	if err := tx.Exec(`
+		INSERT INTO product_variant_options (id, product_id, attribute, value, created_at, updated_at)
+		SELECT gen_random_uuid(), product_id, ?, value, NOW(), NOW()
+		FROM (
+			SELECT DISTINCT ON (product_id, LOWER(TRIM(`+column+`))) product_id, TRIM(`+column+`) AS value
+			FROM inventory
+			WHERE deleted_at IS NULL AND TRIM(`+column+`) <> ''
+			ORDER BY product_id, LOWER(TRIM(`+column+`)), TRIM(`+column+`)
+		) AS used`, attribute).Error; err != nil {
+		return err
+	}
+
+	return tx.Exec(`
+		UPDATE inventory
+		SET `+optionColumn+` = options.id, `+column+` = options.value
+		FROM product_variant_options AS options
+		WHERE options.product_id = inventory.product_id
+			AND options.attribute = ?
+			AND options.deleted_at IS NULL
+			AND LOWER(options.value) = LOWER(TRIM(inventory.`+column+`))`, attribute).Error
 }
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/ybds/internal/models/account"
+	"github.com/ybds/pkg/jwt"
+)
+
+// HeaderAPIKey is the request header carrying an API key
+const HeaderAPIKey = "X-API-Key"
+
+// APIKeyValidator looks up the API key a request is authenticated with
+type APIKeyValidator interface {
+	// ValidateAPIKey returns the API key, or an error if it is unknown or revoked
+	ValidateAPIKey(key string) (*account.APIKey, error)
+}
+
+// APIKeyAuth creates a middleware that authenticates requests with the API key in the X-API-Key
+// header and sets the apiKeyID and the scopes of the key in context. API keys have no user, so
+// neither userID nor any roles are set; the scopes are checked by RequirePermission.
+func APIKeyAuth(validator APIKeyValidator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(HeaderAPIKey)
+		if key == "" {
+			return fiber.NewError(fiber.StatusUnauthorized, "X-API-Key header is required")
+		}
+
+		apiKey, err := validator.ValidateAPIKey(key)
+		if err != nil {
+			return fiber.NewError(fiber.StatusUnauthorized, "Invalid or revoked API key")
+		}
+
+		c.Locals("apiKeyID", apiKey.ID)
+		c.Locals("roles", []string{})
+		c.Locals("scopes", apiKey.ScopeList())
+
+		return c.Next()
+	}
+}
+
+// JWTOrAPIKeyAuth creates a middleware that authenticates requests with an API key when the
+// X-API-Key header is present and with a JWT token otherwise
+func JWTOrAPIKeyAuth(jwtService *jwt.JWTService, validator APIKeyValidator) fiber.Handler {
+	jwtAuth := JWTAuth(jwtService)
+	apiKeyAuth := APIKeyAuth(validator)
+
+	return func(c *fiber.Ctx) error {
+		if c.Get(HeaderAPIKey) != "" {
+			return apiKeyAuth(c)
+		}
+		return jwtAuth(c)
+	}
+}
@@ -0,0 +1,83 @@
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/middleware"
+	"github.com/ybds/internal/models/account"
+	"github.com/ybds/pkg/config"
+	"github.com/ybds/pkg/jwt"
+)
+
+// fakeAPIKeyValidator validates the API keys it holds; revoked keys are rejected
+type fakeAPIKeyValidator map[string]*account.APIKey
+
+// ValidateAPIKey returns the API key, or an error if it is unknown or revoked
+func (v fakeAPIKeyValidator) ValidateAPIKey(key string) (*account.APIKey, error) {
+	apiKey, ok := v[key]
+	if !ok {
+		return nil, errors.New("invalid API key")
+	}
+	if apiKey.Revoked {
+		return nil, errors.New("API key has been revoked")
+	}
+	return apiKey, nil
+}
+
+// TestAPIKeyAuth tests authenticating requests with API keys next to JWT tokens, and that the
+// scopes of a key restrict the routes it may call
+func TestAPIKeyAuth(t *testing.T) {
+	jwtService, err := jwt.NewJWTService(&config.JWTConfig{Secret: "secret", Expiry: "15m", RefreshExpiry: "720h"})
+	assert.NoError(t, err)
+
+	validator := fakeAPIKeyValidator{
+		"ybds_pos":     {Label: "POS", Scopes: "backoffice:access,order:update_status"},
+		"ybds_revoked": {Label: "Old POS", Scopes: "backoffice:access,order:update_status", Revoked: true},
+	}
+
+	app := fiber.New()
+	app.Use(middleware.JWTOrAPIKeyAuth(jwtService, validator))
+	app.Use(middleware.AdminOrAgentGuard())
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Put("/orders/:id/status", middleware.RequirePermission(account.PermissionOrderUpdateStatus), ok)
+	app.Delete("/orders/:id", middleware.RequirePermission(account.PermissionOrderDelete), ok)
+
+	send := func(method, path string, header, value string) int {
+		t.Helper()
+		req := httptest.NewRequest(method, path, nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	t.Run("Valid key", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, send(http.MethodPut, "/orders/1/status", middleware.HeaderAPIKey, "ybds_pos"))
+	})
+
+	t.Run("Unknown key", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, send(http.MethodPut, "/orders/1/status", middleware.HeaderAPIKey, "ybds_unknown"))
+	})
+
+	t.Run("Revoked key", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, send(http.MethodPut, "/orders/1/status", middleware.HeaderAPIKey, "ybds_revoked"))
+	})
+
+	t.Run("Scope-restricted key", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, send(http.MethodDelete, "/orders/1", middleware.HeaderAPIKey, "ybds_pos"))
+	})
+
+	t.Run("JWT token", func(t *testing.T) {
+		token, err := jwtService.GenerateToken("8b7f1c1e-4b8e-4d2a-9d6b-0c3f5a1e2b7d", []string{string(account.RoleAgent)})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, send(http.MethodDelete, "/orders/1", "Authorization", "Bearer "+token))
+		assert.Equal(t, http.StatusUnauthorized, send(http.MethodDelete, "/orders/1", "", ""))
+	})
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ybds/internal/services"
+)
+
+// Audit creates a middleware that records the mutating requests of authenticated users in the
+// audit log once they are handled. Read requests are not recorded, and a failure to record a
+// request is logged without affecting the response.
+func Audit(auditService *services.AuditService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return c.Next()
+		}
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			if fiberErr, ok := err.(*fiber.Error); ok {
+				status = fiberErr.Code
+			}
+		}
+
+		req := services.AuditRequest{
+			Method:      c.Method(),
+			Path:        c.Path(),
+			TargetID:    c.Params("id"),
+			StatusCode:  status,
+			ContentType: c.Get(fiber.HeaderContentType),
+			IP:          c.IP(),
+		}
+		if userID, ok := c.Locals("userID").(uuid.UUID); ok {
+			req.ActorID = &userID
+		}
+		req.ActorRoles, _ = c.Locals("roles").([]string)
+
+		// Multipart requests are recorded by their form values, without the uploaded files
+		if strings.HasPrefix(req.ContentType, fiber.MIMEMultipartForm) {
+			if form, formErr := c.MultipartForm(); formErr == nil {
+				req.Form = form.Value
+			}
+		} else {
+			req.Body = c.Body()
+		}
+
+		if recordErr := auditService.RecordRequest(req); recordErr != nil {
+			log.Printf("Error recording audit log for %s %s: %v", req.Method, req.Path, recordErr)
+		}
+
+		return err
+	}
+}
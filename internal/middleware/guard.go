@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/ybds/internal/models/account"
 	"github.com/ybds/internal/utils"
 )
 
@@ -38,9 +39,43 @@ func RoleGuard(requiredRoles ...string) fiber.Handler {
 	}
 }
 
-// AdminGuard creates a middleware that checks if the user is an admin
+// RequirePermission creates a middleware that checks if any of the user's roles, or the scopes of
+// the API key the request is authenticated with, grants the permission
+func RequirePermission(permission account.Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		_, hasRoles := c.Locals("roles").([]string)
+		_, hasScopes := c.Locals("scopes").([]account.Permission)
+		if !hasRoles && !hasScopes {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		if !HasPermission(c, permission) {
+			return utils.ForbiddenResponse(c)
+		}
+
+		return c.Next()
+	}
+}
+
+// HasPermission reports whether the roles of the authenticated user, or the scopes of the API key
+// the request is authenticated with, grant the permission
+func HasPermission(c *fiber.Ctx, permission account.Permission) bool {
+	if userRoles, ok := c.Locals("roles").([]string); ok && account.HasPermission(userRoles, permission) {
+		return true
+	}
+
+	scopes, _ := c.Locals("scopes").([]account.Permission)
+	for _, scope := range scopes {
+		if scope == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminGuard creates a middleware that checks if the user may access the admin-only routes
 func AdminGuard() fiber.Handler {
-	return RoleGuard("admin")
+	return RequirePermission(account.PermissionAdminAccess)
 }
 
 // AgentGuard creates a middleware that checks if the user is an AI agent
@@ -48,7 +83,8 @@ func AgentGuard() fiber.Handler {
 	return RoleGuard("agent")
 }
 
-// AdminOrAgentGuard creates a middleware that checks if the user is an admin or an AI agent
+// AdminOrAgentGuard creates a middleware that checks if the user may access the routes shared
+// by admins and AI agents
 func AdminOrAgentGuard() fiber.Handler {
-	return RoleGuard("admin", "agent")
+	return RequirePermission(account.PermissionBackofficeAccess)
 }
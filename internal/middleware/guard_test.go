@@ -0,0 +1,75 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/middleware"
+	"github.com/ybds/internal/models/account"
+)
+
+// newGuardedApp returns an app whose routes are protected by the guard and that authenticates
+// requests with the roles in the X-Roles header
+func newGuardedApp(guard fiber.Handler) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if roles := c.Get("X-Roles"); roles != "" {
+			c.Locals("roles", []string{roles})
+		}
+		return c.Next()
+	})
+	app.Get("/", guard, func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+// requestStatus sends a request with the given role to the app and returns the response status
+func requestStatus(t *testing.T, app *fiber.App, role string) int {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if role != "" {
+		req.Header.Set("X-Roles", role)
+	}
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	return resp.StatusCode
+}
+
+// TestRequirePermission tests that a role is only let through for the permissions it grants
+func TestRequirePermission(t *testing.T) {
+	const dispatcher account.RoleType = "dispatcher"
+	account.RolePermissions[dispatcher] = []account.Permission{account.PermissionOrderUpdateStatus}
+	t.Cleanup(func() { delete(account.RolePermissions, dispatcher) })
+
+	assert.True(t, account.HasPermission([]string{string(dispatcher)}, account.PermissionOrderUpdateStatus))
+	assert.False(t, account.HasPermission([]string{string(dispatcher)}, account.PermissionOrderDelete))
+
+	updateStatus := newGuardedApp(middleware.RequirePermission(account.PermissionOrderUpdateStatus))
+	assert.Equal(t, http.StatusOK, requestStatus(t, updateStatus, string(dispatcher)))
+	assert.Equal(t, http.StatusOK, requestStatus(t, updateStatus, string(account.RoleAdmin)))
+	assert.Equal(t, http.StatusForbidden, requestStatus(t, updateStatus, string(account.RoleStaff)))
+	assert.Equal(t, http.StatusUnauthorized, requestStatus(t, updateStatus, ""))
+
+	deleteOrder := newGuardedApp(middleware.RequirePermission(account.PermissionOrderDelete))
+	assert.Equal(t, http.StatusForbidden, requestStatus(t, deleteOrder, string(dispatcher)))
+	assert.Equal(t, http.StatusOK, requestStatus(t, deleteOrder, string(account.RoleAgent)))
+}
+
+// TestRoleGuards tests that the admin and admin-or-agent guards admit the same roles as before
+// they were backed by permissions
+func TestRoleGuards(t *testing.T) {
+	admin := newGuardedApp(middleware.AdminGuard())
+	assert.Equal(t, http.StatusOK, requestStatus(t, admin, string(account.RoleAdmin)))
+	assert.Equal(t, http.StatusForbidden, requestStatus(t, admin, string(account.RoleAgent)))
+	assert.Equal(t, http.StatusForbidden, requestStatus(t, admin, string(account.RoleStaff)))
+
+	adminOrAgent := newGuardedApp(middleware.AdminOrAgentGuard())
+	assert.Equal(t, http.StatusOK, requestStatus(t, adminOrAgent, string(account.RoleAdmin)))
+	assert.Equal(t, http.StatusOK, requestStatus(t, adminOrAgent, string(account.RoleAgent)))
+	assert.Equal(t, http.StatusForbidden, requestStatus(t, adminOrAgent, string(account.RoleStaff)))
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestTimeout creates a middleware that bounds the user context of each request with
+// the given timeout. Database queries run with c.UserContext() are aborted once the
+// deadline passes. A timeout of zero or less disables the deadline.
+func RequestTimeout(timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if timeout <= 0 {
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
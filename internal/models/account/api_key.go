@@ -0,0 +1,38 @@
+package account
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ybds/internal/models"
+)
+
+// APIKey authenticates an external system, e.g. a POS, calling the API without a user. The key is
+// only shown when it is created; afterwards only its SHA-256 hash and its first characters, to
+// tell keys apart, are stored. Scopes holds the comma-separated permissions granted to the key.
+type APIKey struct {
+	models.Base
+	Label      string     `gorm:"column:label;type:varchar(100);not null" json:"label"`
+	KeyHash    string     `gorm:"column:key_hash;type:varchar(64);not null;uniqueIndex" json:"-"`
+	KeyPrefix  string     `gorm:"column:key_prefix;type:varchar(16);not null" json:"key_prefix"`
+	Scopes     string     `gorm:"column:scopes;type:text;not null" json:"scopes"`
+	Revoked    bool       `gorm:"column:revoked;not null;default:false" json:"revoked"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"last_used_at,omitempty"`
+}
+
+// TableName specifies the table name for APIKey
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// ScopeList returns the permissions granted to the key
+func (k *APIKey) ScopeList() []Permission {
+	var scopes []Permission
+	for _, scope := range strings.Split(k.Scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, Permission(scope))
+		}
+	}
+	return scopes
+}
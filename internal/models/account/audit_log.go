@@ -0,0 +1,26 @@
+package account
+
+import (
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models"
+)
+
+// AuditLog records a mutating request made by an authenticated user, e.g. a product update.
+// Body holds the request body with secrets redacted; file uploads are not recorded.
+type AuditLog struct {
+	models.Base
+	ActorID    *uuid.UUID `gorm:"column:actor_id;type:uuid;index" json:"actor_id,omitempty"`
+	ActorRoles string     `gorm:"column:actor_roles;type:varchar(255)" json:"actor_roles"`
+	Method     string     `gorm:"column:method;type:varchar(10);not null" json:"method"`
+	Path       string     `gorm:"column:path;type:varchar(255);not null" json:"path"`
+	Resource   string     `gorm:"column:resource;type:varchar(50);not null;index" json:"resource"`
+	TargetID   string     `gorm:"column:target_id;type:varchar(64);index" json:"target_id"`
+	StatusCode int        `gorm:"column:status_code;not null" json:"status_code"`
+	Body       string     `gorm:"column:body;type:text" json:"body"`
+	IP         string     `gorm:"column:ip;type:varchar(64)" json:"ip"`
+}
+
+// TableName specifies the table name for AuditLog
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
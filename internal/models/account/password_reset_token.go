@@ -0,0 +1,33 @@
+package account
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models"
+)
+
+// PasswordResetToken is a single-use token that lets a user choose a new password. Only the
+// SHA-256 hash of the token is stored; UsedAt is set once the token has reset a password.
+type PasswordResetToken struct {
+	models.Base
+	UserID    uuid.UUID  `gorm:"column:user_id;type:uuid;not null;index" json:"user_id"`
+	TokenHash string     `gorm:"column:token_hash;type:varchar(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null" json:"expires_at"`
+	UsedAt    *time.Time `gorm:"column:used_at" json:"used_at,omitempty"`
+}
+
+// TableName specifies the table name for PasswordResetToken
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
+
+// IsExpired reports whether the token has expired at the given time
+func (t *PasswordResetToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// IsUsed reports whether the token has already been used to reset a password
+func (t *PasswordResetToken) IsUsed() bool {
+	return t.UsedAt != nil
+}
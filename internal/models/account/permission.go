@@ -0,0 +1,82 @@
+package account
+
+// Permission names an action a role may perform, in the form "<resource>:<action>"
+type Permission string
+
+const (
+	// PermissionAdminAccess grants access to the admin-only routes
+	PermissionAdminAccess Permission = "admin:access"
+	// PermissionBackofficeAccess grants access to the routes shared by admins and agents
+	PermissionBackofficeAccess Permission = "backoffice:access"
+
+	// PermissionOrderViewAll allows seeing the orders of all agents, e.g. in the order queue
+	PermissionOrderViewAll Permission = "order:view_all"
+	// PermissionOrderUpdate allows editing the items, details and shipments of orders in any status.
+	// Without it, only orders in the statuses open to agents can be edited.
+	PermissionOrderUpdate Permission = "order:update"
+	// PermissionOrderUpdateStatus allows changing the status of orders in any status to any status.
+	// Without it, only the transitions open to agents are allowed.
+	PermissionOrderUpdateStatus Permission = "order:update_status"
+	// PermissionOrderDelete allows canceling and deleting orders
+	PermissionOrderDelete Permission = "order:delete"
+	// PermissionOrderAssign allows distributing orders among agents
+	PermissionOrderAssign Permission = "order:assign"
+	// PermissionOrderReplayWebhook allows replaying shipping carrier webhooks against orders
+	PermissionOrderReplayWebhook Permission = "order:replay_webhook"
+
+	// PermissionProductDelete allows deleting products
+	PermissionProductDelete Permission = "product:delete"
+	// PermissionProductRestore allows listing deleted products and restoring them
+	PermissionProductRestore Permission = "product:restore"
+
+	// PermissionNotificationViewAll allows seeing the notifications of all recipients
+	PermissionNotificationViewAll Permission = "notification:view_all"
+)
+
+// AllPermissions lists every permission
+var AllPermissions = []Permission{
+	PermissionAdminAccess,
+	PermissionBackofficeAccess,
+	PermissionOrderViewAll,
+	PermissionOrderUpdate,
+	PermissionOrderUpdateStatus,
+	PermissionOrderDelete,
+	PermissionOrderAssign,
+	PermissionOrderReplayWebhook,
+	PermissionProductDelete,
+	PermissionProductRestore,
+	PermissionNotificationViewAll,
+}
+
+// IsValid checks if the permission is one of the defined permissions
+func (p Permission) IsValid() bool {
+	for _, permission := range AllPermissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RolePermissions maps each role to the permissions it grants. A user has the union of the
+// permissions of their roles; roles that are not listed grant no permissions.
+var RolePermissions = map[RoleType][]Permission{
+	RoleAdmin: AllPermissions,
+	RoleAgent: {
+		PermissionBackofficeAccess,
+		PermissionOrderDelete,
+		PermissionProductDelete,
+	},
+}
+
+// HasPermission reports whether any of the given roles grants the permission
+func HasPermission(roles []string, permission Permission) bool {
+	for _, role := range roles {
+		for _, granted := range RolePermissions[RoleType(role)] {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
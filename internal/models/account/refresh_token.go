@@ -0,0 +1,33 @@
+package account
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models"
+)
+
+// RefreshToken is a long-lived token a user exchanges for new access tokens. Only the SHA-256
+// hash of the token is stored; a revoked or expired token can no longer be used.
+type RefreshToken struct {
+	models.Base
+	UserID    uuid.UUID  `gorm:"column:user_id;type:uuid;not null;index" json:"user_id"`
+	TokenHash string     `gorm:"column:token_hash;type:varchar(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null" json:"expires_at"`
+	RevokedAt *time.Time `gorm:"column:revoked_at" json:"revoked_at,omitempty"`
+}
+
+// TableName specifies the table name for RefreshToken
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// IsExpired reports whether the token has expired at the given time
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// IsRevoked reports whether the token has been revoked
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
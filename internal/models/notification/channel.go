@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/ybds/internal/models"
@@ -68,8 +69,10 @@ type Channel struct {
 	Channel        ChannelType   `gorm:"column:channel;type:varchar(50);not null" json:"channel"`
 	Status         ChannelStatus `gorm:"column:status;type:varchar(50);not null;default:'pending'" json:"status"`
 	Attempts       int           `gorm:"column:attempts;not null;default:0" json:"attempts"`
-	Response       Response      `gorm:"column:response;type:jsonb" json:"response,omitempty"`
-	Notification   Notification  `gorm:"foreignKey:NotificationID" json:"notification,omitempty"`
+	// NextAttemptAt is when a failed channel is retried; nil when no retry is scheduled
+	NextAttemptAt *time.Time   `gorm:"column:next_attempt_at;index" json:"next_attempt_at,omitempty"`
+	Response      Response     `gorm:"column:response;type:jsonb" json:"response,omitempty"`
+	Notification  Notification `gorm:"foreignKey:NotificationID" json:"notification,omitempty"`
 }
 
 // TableName specifies the table name for Channel
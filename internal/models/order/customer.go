@@ -0,0 +1,19 @@
+package order
+
+import (
+	"github.com/ybds/internal/models"
+)
+
+// Customer represents a customer profile shared by all orders placed with the same phone number.
+// Phone holds the normalized number, e.g. +84 912 345 678 is stored as 0912345678.
+type Customer struct {
+	models.Base
+	Phone string `gorm:"column:phone;type:varchar(20);not null;uniqueIndex" json:"phone"`
+	Name  string `gorm:"column:name;type:varchar(255)" json:"name"`
+	Email string `gorm:"column:email;type:varchar(255)" json:"email"`
+}
+
+// TableName specifies the table name for Customer
+func (Customer) TableName() string {
+	return "customers"
+}
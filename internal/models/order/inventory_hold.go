@@ -0,0 +1,45 @@
+package order
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models"
+)
+
+// HoldStatus represents the state of an inventory hold
+type HoldStatus string
+
+const (
+	// HoldActive holds stock until it expires or is released
+	HoldActive HoldStatus = "active"
+	// HoldReleased was released before it expired
+	HoldReleased HoldStatus = "released"
+	// HoldExpired was released by the expiry sweeper
+	HoldExpired HoldStatus = "expired"
+)
+
+// InventoryHold represents stock of an inventory held for a customer, e.g. for a quotation,
+// without creating an order. An active hold reduces the available-to-promise quantity of the
+// inventory until it expires; the raw inventory quantity is never changed.
+type InventoryHold struct {
+	models.Base
+	InventoryID uuid.UUID  `gorm:"column:inventory_id;type:uuid;not null;index" json:"inventory_id"`
+	Quantity    int        `gorm:"column:quantity;not null" json:"quantity"`
+	Status      HoldStatus `gorm:"column:status;type:varchar(20);not null;default:'active';index" json:"status"`
+	ExpiresAt   time.Time  `gorm:"column:expires_at;not null;index" json:"expires_at"`
+	ReleasedAt  *time.Time `gorm:"column:released_at" json:"released_at,omitempty"`
+	CustomerID  *uuid.UUID `gorm:"column:customer_id;type:uuid;index" json:"customer_id,omitempty"`
+	Notes       string     `gorm:"column:notes;type:text" json:"notes"`
+	CreatedBy   *uuid.UUID `gorm:"column:created_by;type:uuid" json:"created_by,omitempty"`
+}
+
+// TableName specifies the table name for InventoryHold
+func (InventoryHold) TableName() string {
+	return "inventory_holds"
+}
+
+// IsActiveAt reports whether the hold still holds stock at the given time
+func (h InventoryHold) IsActiveAt(t time.Time) bool {
+	return h.Status == HoldActive && t.Before(h.ExpiresAt)
+}
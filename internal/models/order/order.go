@@ -1,6 +1,11 @@
 package order
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/ybds/internal/models"
 )
 
@@ -48,32 +53,112 @@ const (
 	OrderCanceled OrderStatus = "canceled"
 )
 
+// IsValid reports whether the order status is known
+func (s OrderStatus) IsValid() bool {
+	switch s {
+	case OrderShipmentRequested, OrderPacked, OrderPicked, OrderDelivering,
+		OrderDelivered, OrderReturnProcessing, OrderReturned, OrderCanceled:
+		return true
+	default:
+		return false
+	}
+}
+
+// AllowsShipmentChanges reports whether the shipment of an order in this status may be created
+// or updated. Once the carrier has picked the order up, its shipment is managed by the carrier.
+func (s OrderStatus) AllowsShipmentChanges() bool {
+	return s == OrderShipmentRequested || s == OrderPacked
+}
+
+// ParseOrderStatuses parses a comma-separated list of order statuses, ignoring blank entries
+func ParseOrderStatuses(value string) ([]OrderStatus, error) {
+	var statuses []OrderStatus
+	for _, part := range strings.Split(value, ",") {
+		status := OrderStatus(strings.ToLower(strings.TrimSpace(part)))
+		if status == "" {
+			continue
+		}
+		if !status.IsValid() {
+			return nil, fmt.Errorf("invalid order status %q", status)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
 // Order represents an order in the system
 type Order struct {
 	models.Base
-	PaymentMethod    PaymentMethod `gorm:"column:payment_method;type:varchar(50);not null;index" json:"payment_method"`
-	TotalAmount      float64       `gorm:"column:total_amount;type:decimal(10,2);not null" json:"total_amount"`
-	DiscountAmount   float64       `gorm:"column:discount_amount;type:decimal(10,2);not null;default:0" json:"discount_amount"`
-	DiscountReason   string        `gorm:"column:discount_reason;type:varchar(255)" json:"discount_reason"`
-	FinalTotalAmount float64       `gorm:"column:final_total_amount;type:decimal(10,2);not null" json:"final_total_amount"`
-	OrderStatus      OrderStatus   `gorm:"column:order_status;type:varchar(50);not null;default:'shipment_requested';index" json:"order_status"`
-	Notes            string        `gorm:"column:notes;type:text" json:"notes"`
+	OrderNumber     string        `gorm:"column:order_number;type:varchar(32);not null;default:'';uniqueIndex:idx_orders_order_number,where:order_number <> ''" json:"order_number"`
+	PaymentMethod   PaymentMethod `gorm:"column:payment_method;type:varchar(50);not null;index" json:"payment_method"`
+	TotalAmount     float64       `gorm:"column:total_amount;type:decimal(10,2);not null" json:"total_amount"`
+	DiscountAmount  float64       `gorm:"column:discount_amount;type:decimal(10,2);not null;default:0" json:"discount_amount"`
+	DiscountReason  string        `gorm:"column:discount_reason;type:varchar(255)" json:"discount_reason"`
+	DiscountPercent *float64      `gorm:"column:discount_percent;type:decimal(5,2)" json:"discount_percent,omitempty"`
+	// The promo code the discount was taken from, if any
+	PromoCodeID      *uuid.UUID  `gorm:"column:promo_code_id;type:uuid;index" json:"promo_code_id,omitempty"`
+	PromoCode        string      `gorm:"column:promo_code;type:varchar(50)" json:"promo_code,omitempty"`
+	FinalTotalAmount float64     `gorm:"column:final_total_amount;type:decimal(10,2);not null" json:"final_total_amount"`
+	OrderStatus      OrderStatus `gorm:"column:order_status;type:varchar(50);not null;default:'shipment_requested';index" json:"order_status"`
+	Notes            string      `gorm:"column:notes;type:text" json:"notes"`
+	// The ISO 4217 currency the amounts of the order and its items are in; empty for orders
+	// created before orders had a currency
+	Currency string `gorm:"column:currency;type:varchar(10)" json:"currency"`
+	// Whether the stock of the order items is currently reserved
+	InventoryReserved bool `gorm:"column:inventory_reserved;not null;default:false" json:"inventory_reserved"`
+	// Whether the item quantities are currently counted in the sold count of their products
+	SalesCounted bool `gorm:"column:sales_counted;not null;default:false" json:"sales_counted"`
 	// Shipping address fields
 	ShippingAddress  string `gorm:"column:shipping_address;type:text" json:"shipping_address"`
 	ShippingWard     string `gorm:"column:shipping_ward;type:varchar(100)" json:"shipping_ward"`
 	ShippingDistrict string `gorm:"column:shipping_district;type:varchar(100)" json:"shipping_district"`
 	ShippingCity     string `gorm:"column:shipping_city;type:varchar(100)" json:"shipping_city"`
 	ShippingCountry  string `gorm:"column:shipping_country;type:varchar(100);default:'Vietnam'" json:"shipping_country"`
-	// Customer contact information
-	CustomerName  string `gorm:"column:customer_name;type:varchar(255)" json:"customer_name"`
-	CustomerEmail string `gorm:"column:customer_email;type:varchar(255)" json:"customer_email"`
-	CustomerPhone string `gorm:"column:customer_phone;type:varchar(20)" json:"customer_phone"`
+	// Customer contact information, kept on the order alongside the linked customer profile
+	CustomerID    *uuid.UUID `gorm:"column:customer_id;type:uuid;index" json:"customer_id,omitempty"`
+	CustomerName  string     `gorm:"column:customer_name;type:varchar(255)" json:"customer_name"`
+	CustomerEmail string     `gorm:"column:customer_email;type:varchar(255)" json:"customer_email"`
+	CustomerPhone string     `gorm:"column:customer_phone;type:varchar(20)" json:"customer_phone"`
+	// Delivery proof captured by the driver or agent when the order was handed over
+	DeliveryProofURL      string     `gorm:"column:delivery_proof_url;type:text" json:"delivery_proof_url"`
+	DeliveryProofFilename string     `gorm:"column:delivery_proof_filename;type:varchar(255)" json:"-"`
+	DeliveryRecipientName string     `gorm:"column:delivery_recipient_name;type:varchar(255)" json:"delivery_recipient_name"`
+	DeliveredAt           *time.Time `gorm:"column:delivered_at" json:"delivered_at,omitempty"`
 	// Relationships
-	Items    []OrderItem `gorm:"foreignKey:OrderID" json:"items,omitempty"`
-	Shipment *Shipment   `gorm:"foreignKey:OrderID" json:"shipment,omitempty"`
+	Items     []OrderItem `gorm:"foreignKey:OrderID" json:"items,omitempty"`
+	Shipments []Shipment  `gorm:"foreignKey:OrderID" json:"shipments,omitempty"`
 }
 
 // TableName specifies the table name for Order
 func (Order) TableName() string {
 	return "orders"
 }
+
+// IsHandedToCarrier reports whether the order has been picked up by the carrier or has a carrier
+// shipment with a tracking number. From then on its items and address must match what the carrier holds.
+func (o Order) IsHandedToCarrier() bool {
+	switch o.OrderStatus {
+	case OrderPicked, OrderDelivering, OrderDelivered, OrderReturnProcessing, OrderReturned:
+		return true
+	}
+	for _, shipment := range o.Shipments {
+		if shipment.TrackingNumber != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// PrimaryShipment returns the first shipment of the order, or nil when it has none. Shipments are
+// loaded oldest first, so this is the shipment created together with the order.
+func (o Order) PrimaryShipment() *Shipment {
+	if len(o.Shipments) == 0 {
+		return nil
+	}
+	return &o.Shipments[0]
+}
+
+// HasDeliveryProof reports whether a delivery proof has been recorded for the order
+func (o Order) HasDeliveryProof() bool {
+	return o.DeliveryProofURL != ""
+}
@@ -8,14 +8,26 @@ import (
 // OrderItem represents an item in an order
 type OrderItem struct {
 	models.Base
-	OrderID      uuid.UUID `gorm:"column:order_id;type:uuid;not null" json:"order_id"`
-	InventoryID  uuid.UUID `gorm:"column:inventory_id;type:uuid;not null" json:"inventory_id"`
-	Quantity     int       `gorm:"column:quantity;not null" json:"quantity"`
-	PriceAtOrder float64   `gorm:"column:price_at_order;type:decimal(10,2);not null" json:"price_at_order"`
-	Order        Order     `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+	OrderID     uuid.UUID `gorm:"column:order_id;type:uuid;not null" json:"order_id"`
+	InventoryID uuid.UUID `gorm:"column:inventory_id;type:uuid;not null" json:"inventory_id"`
+	Quantity    int       `gorm:"column:quantity;not null" json:"quantity"`
+	// ReturnedQuantity is how many of the units have been returned to inventory
+	ReturnedQuantity int     `gorm:"column:returned_quantity;not null;default:0" json:"returned_quantity"`
+	PriceAtOrder     float64 `gorm:"column:price_at_order;type:decimal(10,2);not null" json:"price_at_order"`
+	// Currency is the currency of PriceAtOrder, i.e. the order currency
+	Currency string `gorm:"column:currency;type:varchar(10)" json:"currency"`
+	// The unit price and currency the product was listed at before conversion to the order currency
+	OriginalPrice    float64 `gorm:"column:original_price;type:decimal(10,2)" json:"original_price"`
+	OriginalCurrency string  `gorm:"column:original_currency;type:varchar(10)" json:"original_currency"`
+	Order            Order   `gorm:"foreignKey:OrderID" json:"order,omitempty"`
 }
 
 // TableName specifies the table name for OrderItem
 func (OrderItem) TableName() string {
 	return "order_items"
 }
+
+// ReturnableQuantity returns how many units of the item can still be returned
+func (i OrderItem) ReturnableQuantity() int {
+	return i.Quantity - i.ReturnedQuantity
+}
@@ -0,0 +1,70 @@
+package order
+
+import (
+	"fmt"
+	"time"
+)
+
+// OrderNumberPrefix is the default prefix of human-readable order numbers
+const OrderNumberPrefix = "YB"
+
+// OrderNumberReset defines how often the order number sequence restarts from 1
+type OrderNumberReset string
+
+const (
+	// OrderNumberResetDaily restarts the sequence every day, e.g. YB-20240115-000123
+	OrderNumberResetDaily OrderNumberReset = "daily"
+	// OrderNumberResetMonthly restarts the sequence every month, e.g. YB-202401-000123
+	OrderNumberResetMonthly OrderNumberReset = "monthly"
+	// OrderNumberResetYearly restarts the sequence every year, e.g. YB-2024-000123
+	OrderNumberResetYearly OrderNumberReset = "yearly"
+	// OrderNumberResetNever never restarts the sequence, e.g. YB-000123
+	OrderNumberResetNever OrderNumberReset = "never"
+)
+
+// IsValid reports whether the reset policy is known
+func (r OrderNumberReset) IsValid() bool {
+	switch r {
+	case OrderNumberResetDaily, OrderNumberResetMonthly, OrderNumberResetYearly, OrderNumberResetNever:
+		return true
+	default:
+		return false
+	}
+}
+
+// OrderNumberSequence keeps the last issued order number sequence for a period
+type OrderNumberSequence struct {
+	Period    string    `gorm:"column:period;type:varchar(32);primaryKey" json:"period"`
+	LastValue int64     `gorm:"column:last_value;not null;default:0" json:"last_value"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// TableName specifies the table name for OrderNumberSequence
+func (OrderNumberSequence) TableName() string {
+	return "order_number_sequences"
+}
+
+// OrderNumberPeriod returns the sequence period an order created at the given time belongs to.
+// Each reset policy uses a period of a different length, so their sequences never collide.
+// The period is empty when the sequence never resets.
+func OrderNumberPeriod(t time.Time, reset OrderNumberReset) string {
+	switch reset {
+	case OrderNumberResetMonthly:
+		return t.Format("200601")
+	case OrderNumberResetYearly:
+		return t.Format("2006")
+	case OrderNumberResetNever:
+		return ""
+	default:
+		return t.Format("20060102")
+	}
+}
+
+// FormatOrderNumber formats an order number like YB-20240115-000123, or YB-000123 when
+// the period is empty
+func FormatOrderNumber(prefix, period string, sequence int64) string {
+	if period == "" {
+		return fmt.Sprintf("%s-%06d", prefix, sequence)
+	}
+	return fmt.Sprintf("%s-%s-%06d", prefix, period, sequence)
+}
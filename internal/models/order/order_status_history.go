@@ -0,0 +1,22 @@
+package order
+
+import (
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models"
+)
+
+// OrderStatusHistory records a change of the status of an order and who made it. ChangedBy is
+// empty for changes made by the system, e.g. from a carrier webhook.
+type OrderStatusHistory struct {
+	models.Base
+	OrderID    uuid.UUID   `gorm:"column:order_id;type:uuid;not null;index" json:"order_id"`
+	FromStatus OrderStatus `gorm:"column:from_status;type:varchar(50);not null" json:"from_status"`
+	ToStatus   OrderStatus `gorm:"column:to_status;type:varchar(50);not null" json:"to_status"`
+	ChangedBy  *uuid.UUID  `gorm:"column:changed_by;type:uuid;index" json:"changed_by,omitempty"`
+	Note       string      `gorm:"column:note;type:text" json:"note"`
+}
+
+// TableName specifies the table name for OrderStatusHistory
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
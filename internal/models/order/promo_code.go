@@ -0,0 +1,52 @@
+package order
+
+import (
+	"time"
+
+	"github.com/ybds/internal/models"
+)
+
+// PromoType defines how the value of a promo code is applied to an order
+type PromoType string
+
+const (
+	// PromoPercent takes a percentage of the order total
+	PromoPercent PromoType = "percent"
+	// PromoFixed takes a fixed amount off the order total
+	PromoFixed PromoType = "fixed"
+)
+
+// IsValid reports whether the type is a known promo type
+func (t PromoType) IsValid() bool {
+	return t == PromoPercent || t == PromoFixed
+}
+
+// PromoCode is a reusable discount code that can be applied to orders. Codes are stored upper-case.
+// MaxUses limits how many orders may redeem the code; 0 means unlimited.
+type PromoCode struct {
+	models.Base
+	Code           string     `gorm:"column:code;type:varchar(50);not null;uniqueIndex:idx_promo_codes_code,where:deleted_at IS NULL" json:"code"`
+	Type           PromoType  `gorm:"column:type;type:varchar(20);not null" json:"type"`
+	Value          float64    `gorm:"column:value;type:decimal(10,2);not null" json:"value"`
+	StartDate      time.Time  `gorm:"column:start_date;not null" json:"start_date"`
+	EndDate        *time.Time `gorm:"column:end_date" json:"end_date,omitempty"`
+	MaxUses        int        `gorm:"column:max_uses;not null;default:0" json:"max_uses"`
+	UsedCount      int        `gorm:"column:used_count;not null;default:0" json:"used_count"`
+	MinOrderAmount float64    `gorm:"column:min_order_amount;type:decimal(10,2);not null;default:0" json:"min_order_amount"`
+	Description    string     `gorm:"column:description;type:varchar(255)" json:"description"`
+}
+
+// TableName specifies the table name for PromoCode
+func (PromoCode) TableName() string {
+	return "promo_codes"
+}
+
+// IsActiveAt reports whether the code can be redeemed at the given time
+func (p PromoCode) IsActiveAt(t time.Time) bool {
+	return !t.Before(p.StartDate) && (p.EndDate == nil || t.Before(*p.EndDate))
+}
+
+// IsExhausted reports whether the code has been redeemed as often as it may be
+func (p PromoCode) IsExhausted() bool {
+	return p.MaxUses > 0 && p.UsedCount >= p.MaxUses
+}
@@ -5,16 +5,43 @@ import (
 	"github.com/ybds/internal/models"
 )
 
-// Shipment represents a shipment for an order
+// Shipment represents a shipment for an order. An order may be split into several shipments,
+// each carrying a subset of its items; a shipment without items carries every item of the
+// order that is not assigned to another shipment.
+// Weight is measured in grams and the package dimensions in centimeters.
 type Shipment struct {
 	models.Base
-	OrderID        uuid.UUID `gorm:"column:order_id;type:uuid;not null;uniqueIndex" json:"order_id"`
-	TrackingNumber string    `gorm:"column:tracking_number;type:varchar(100)" json:"tracking_number"`
-	Carrier        string    `gorm:"column:carrier;type:varchar(50)" json:"carrier"`
-	Order          Order     `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+	OrderID        uuid.UUID      `gorm:"column:order_id;type:uuid;not null;index:idx_shipments_order" json:"order_id"`
+	TrackingNumber string         `gorm:"column:tracking_number;type:varchar(100)" json:"tracking_number"`
+	Carrier        string         `gorm:"column:carrier;type:varchar(50)" json:"carrier"`
+	Weight         float64        `gorm:"column:weight;type:decimal(10,2);not null;default:0" json:"weight"`
+	Length         float64        `gorm:"column:length;type:decimal(10,2);not null;default:0" json:"length"`
+	Width          float64        `gorm:"column:width;type:decimal(10,2);not null;default:0" json:"width"`
+	Height         float64        `gorm:"column:height;type:decimal(10,2);not null;default:0" json:"height"`
+	ShippingCost   float64        `gorm:"column:shipping_cost;type:decimal(10,2);not null;default:0" json:"shipping_cost"`
+	CODAmount      float64        `gorm:"column:cod_amount;type:decimal(10,2);not null;default:0" json:"cod_amount"`
+	Order          Order          `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+	Items          []ShipmentItem `gorm:"foreignKey:ShipmentID" json:"items,omitempty"`
 }
 
 // TableName specifies the table name for Shipment
 func (Shipment) TableName() string {
 	return "shipments"
 }
+
+// HasItems reports whether the shipment carries an explicit subset of the order items
+func (s Shipment) HasItems() bool {
+	return len(s.Items) > 0
+}
+
+// ShipmentItem assigns an order item to a shipment of its order
+type ShipmentItem struct {
+	models.Base
+	ShipmentID  uuid.UUID `gorm:"column:shipment_id;type:uuid;not null;index" json:"shipment_id"`
+	OrderItemID uuid.UUID `gorm:"column:order_item_id;type:uuid;not null;index" json:"order_item_id"`
+}
+
+// TableName specifies the table name for ShipmentItem
+func (ShipmentItem) TableName() string {
+	return "shipment_items"
+}
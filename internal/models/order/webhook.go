@@ -0,0 +1,103 @@
+package order
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models"
+)
+
+// WebhookEvent is an order event pushed to external systems
+type WebhookEvent string
+
+const (
+	// WebhookOrderCreated is sent when an order is created
+	WebhookOrderCreated WebhookEvent = "order.created"
+	// WebhookOrderStatusChanged is sent when the status of an order changes
+	WebhookOrderStatusChanged WebhookEvent = "order.status_changed"
+)
+
+// WebhookEvents lists the events an endpoint may subscribe to
+var WebhookEvents = []WebhookEvent{WebhookOrderCreated, WebhookOrderStatusChanged}
+
+// IsValid reports whether the event is a known webhook event
+func (e WebhookEvent) IsValid() bool {
+	for _, event := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEndpoint is an external URL that receives the order events it subscribes to.
+// Events holds the subscribed event names separated by commas. Requests are signed with Secret.
+type WebhookEndpoint struct {
+	models.Base
+	URL         string `gorm:"column:url;type:varchar(500);not null" json:"url"`
+	Secret      string `gorm:"column:secret;type:varchar(255);not null" json:"-"`
+	Events      string `gorm:"column:events;type:varchar(255);not null" json:"events"`
+	Description string `gorm:"column:description;type:varchar(255)" json:"description"`
+	Active      bool   `gorm:"column:active;not null;default:true" json:"active"`
+}
+
+// TableName specifies the table name for WebhookEndpoint
+func (WebhookEndpoint) TableName() string {
+	return "webhook_endpoints"
+}
+
+// EventList returns the events the endpoint subscribes to
+func (e WebhookEndpoint) EventList() []WebhookEvent {
+	var events []WebhookEvent
+	for _, event := range strings.Split(e.Events, ",") {
+		if event = strings.TrimSpace(event); event != "" {
+			events = append(events, WebhookEvent(event))
+		}
+	}
+	return events
+}
+
+// Subscribes reports whether the endpoint receives the event
+func (e WebhookEndpoint) Subscribes(event WebhookEvent) bool {
+	for _, subscribed := range e.EventList() {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus represents the state of a webhook delivery
+type DeliveryStatus string
+
+const (
+	// DeliveryPending is waiting for its first attempt or a retry
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliverySucceeded was acknowledged by the endpoint with a 2xx response
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryFailed gave up after the maximum number of attempts
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// WebhookDelivery records the delivery of an event to an endpoint. Pending deliveries are
+// retried at NextAttemptAt until they succeed or run out of attempts.
+type WebhookDelivery struct {
+	models.Base
+	EndpointID     uuid.UUID      `gorm:"column:endpoint_id;type:uuid;not null;index" json:"endpoint_id"`
+	Event          WebhookEvent   `gorm:"column:event;type:varchar(50);not null" json:"event"`
+	OrderID        *uuid.UUID     `gorm:"column:order_id;type:uuid;index" json:"order_id,omitempty"`
+	Payload        string         `gorm:"column:payload;type:text;not null" json:"payload"`
+	Status         DeliveryStatus `gorm:"column:status;type:varchar(20);not null;default:'pending';index" json:"status"`
+	Attempts       int            `gorm:"column:attempts;not null;default:0" json:"attempts"`
+	NextAttemptAt  *time.Time     `gorm:"column:next_attempt_at;index" json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time     `gorm:"column:last_attempt_at" json:"last_attempt_at,omitempty"`
+	ResponseStatus int            `gorm:"column:response_status" json:"response_status"`
+	ResponseBody   string         `gorm:"column:response_body;type:text" json:"response_body"`
+	LastError      string         `gorm:"column:last_error;type:text" json:"last_error"`
+}
+
+// TableName specifies the table name for WebhookDelivery
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
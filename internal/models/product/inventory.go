@@ -5,15 +5,23 @@ import (
 	"github.com/ybds/internal/models"
 )
 
+// DefaultLowStockThreshold is the quantity at or below which an inventory is considered low on stock
+const DefaultLowStockThreshold = 5
+
 // Inventory represents a product inventory entry
 type Inventory struct {
 	models.Base
-	ProductID uuid.UUID `gorm:"column:product_id;type:uuid;not null;index" json:"product_id"`
-	Size      string    `gorm:"column:size;type:varchar(10);index" json:"size"`
-	Color     string    `gorm:"column:color;type:varchar(50);index" json:"color"`
-	Quantity  int       `gorm:"column:quantity;not null;default:0;index" json:"quantity"`
-	Location  string    `gorm:"column:location;type:varchar(255);index" json:"location"`
-	Product   Product   `gorm:"foreignKey:ProductID" json:"-"`
+	ProductID         uuid.UUID `gorm:"column:product_id;type:uuid;not null;index" json:"product_id"`
+	Size              string    `gorm:"column:size;type:varchar(10);index" json:"size"`
+	Color             string    `gorm:"column:color;type:varchar(50);index" json:"color"`
+	Quantity          int       `gorm:"column:quantity;not null;default:0;index" json:"quantity"`
+	Location          string    `gorm:"column:location;type:varchar(255);index" json:"location"`
+	LowStockThreshold int       `gorm:"column:low_stock_threshold;not null;default:5" json:"low_stock_threshold"`
+	// SizeOptionID and ColorOptionID reference the variant options of the product the size and color
+	// were validated against; they are nil while the product defines no options for the attribute
+	SizeOptionID  *uuid.UUID `gorm:"column:size_option_id;type:uuid;index" json:"size_option_id,omitempty"`
+	ColorOptionID *uuid.UUID `gorm:"column:color_option_id;type:uuid;index" json:"color_option_id,omitempty"`
+	Product       Product    `gorm:"foreignKey:ProductID" json:"-"`
 }
 
 // TableName specifies the table name for Inventory
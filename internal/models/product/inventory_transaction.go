@@ -19,6 +19,8 @@ const (
 	TransactionRelease TransactionType = "release"
 	// TransactionAdjustment represents an inventory adjustment
 	TransactionAdjustment TransactionType = "adjustment"
+	// TransactionTransfer represents stock moved to or from another inventory
+	TransactionTransfer TransactionType = "transfer"
 )
 
 // TransactionReason defines the reason for an inventory transaction
@@ -41,9 +43,12 @@ const (
 	ReasonReservation TransactionReason = "reservation"
 	// ReasonOrderCancellation represents a cancellation of an order
 	ReasonOrderCancellation TransactionReason = "order_cancellation"
+	// ReasonRelocation represents stock moved between locations
+	ReasonRelocation TransactionReason = "relocation"
 )
 
-// InventoryTransaction represents a transaction affecting inventory
+// InventoryTransaction represents a transaction affecting inventory. Balance is the quantity of the
+// inventory after the transaction; it is nil for transactions recorded before balances were tracked.
 type InventoryTransaction struct {
 	models.Base
 	InventoryID   uuid.UUID         `gorm:"column:inventory_id;type:uuid;not null;index" json:"inventory_id"`
@@ -53,6 +58,7 @@ type InventoryTransaction struct {
 	ReferenceID   *uuid.UUID        `gorm:"column:reference_id;type:uuid;null;index" json:"reference_id,omitempty"`
 	ReferenceType string            `gorm:"column:reference_type;type:varchar(50);null;index" json:"reference_type,omitempty"`
 	Notes         string            `gorm:"column:notes;type:text" json:"notes,omitempty"`
+	Balance       *int              `gorm:"column:balance" json:"balance,omitempty"`
 	Inventory     Inventory         `gorm:"foreignKey:InventoryID" json:"inventory,omitempty"`
 }
 
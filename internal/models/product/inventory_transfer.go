@@ -0,0 +1,20 @@
+package product
+
+import (
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models"
+)
+
+// InventoryTransfer records stock moved from one inventory to another, e.g. between warehouses
+type InventoryTransfer struct {
+	models.Base
+	FromInventoryID uuid.UUID `gorm:"column:from_inventory_id;type:uuid;not null;index" json:"from_inventory_id"`
+	ToInventoryID   uuid.UUID `gorm:"column:to_inventory_id;type:uuid;not null;index" json:"to_inventory_id"`
+	Quantity        int       `gorm:"column:quantity;not null" json:"quantity"`
+	Notes           string    `gorm:"column:notes;type:text" json:"notes,omitempty"`
+}
+
+// TableName specifies the table name for InventoryTransfer
+func (InventoryTransfer) TableName() string {
+	return "inventory_transfers"
+}
@@ -42,3 +42,55 @@ func (p *Price) BeforeUpdate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+// PriceSelection defines which price is used when several prices of a product are valid at the same time
+type PriceSelection string
+
+const (
+	// PriceSelectionLatestStart uses the price with the latest start date. This is the default.
+	PriceSelectionLatestStart PriceSelection = "latest_start"
+	// PriceSelectionLowest uses the lowest of the valid prices
+	PriceSelectionLowest PriceSelection = "lowest"
+	// PriceSelectionError refuses to pick a price until the overlap is resolved
+	PriceSelectionError PriceSelection = "error"
+)
+
+// ErrMultiplePrices is returned by SelectPrice when several prices are valid and the selection is PriceSelectionError
+var ErrMultiplePrices = errors.New("multiple prices are valid at the same time")
+
+// IsValid reports whether the price selection is known
+func (s PriceSelection) IsValid() bool {
+	switch s {
+	case PriceSelectionLatestStart, PriceSelectionLowest, PriceSelectionError:
+		return true
+	default:
+		return false
+	}
+}
+
+// SelectPrice picks one of the prices valid at the same time according to the selection.
+// The prices must be ordered by start date, creation time and ID, newest first, which also
+// breaks ties between equal prices. An unknown selection falls back to PriceSelectionLatestStart.
+func SelectPrice(prices []Price, selection PriceSelection) (*Price, error) {
+	if len(prices) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if len(prices) == 1 {
+		return &prices[0], nil
+	}
+
+	switch selection {
+	case PriceSelectionLowest:
+		lowest := &prices[0]
+		for i := range prices[1:] {
+			if prices[i+1].Price < lowest.Price {
+				lowest = &prices[i+1]
+			}
+		}
+		return lowest, nil
+	case PriceSelectionError:
+		return nil, ErrMultiplePrices
+	default:
+		return &prices[0], nil
+	}
+}
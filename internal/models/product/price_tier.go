@@ -0,0 +1,34 @@
+package product
+
+import (
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models"
+)
+
+// PriceTier is a quantity break of a price: order lines of at least MinQuantity units are charged
+// UnitPrice per unit instead of the base price, e.g. for wholesale buyers
+type PriceTier struct {
+	models.Base
+	PriceID     uuid.UUID `gorm:"column:price_id;type:uuid;not null;uniqueIndex:idx_price_tiers_min_quantity,where:deleted_at IS NULL" json:"price_id"`
+	MinQuantity int       `gorm:"column:min_quantity;not null;uniqueIndex:idx_price_tiers_min_quantity,where:deleted_at IS NULL" json:"min_quantity"`
+	UnitPrice   float64   `gorm:"column:unit_price;type:decimal(10,2);not null" json:"unit_price"`
+}
+
+// TableName specifies the table name for PriceTier
+func (PriceTier) TableName() string {
+	return "price_tiers"
+}
+
+// UnitPriceForQuantity returns the unit price charged for quantity units: the price of the tier with
+// the highest minimum quantity the quantity reaches, or the base price when it reaches none
+func UnitPriceForQuantity(price Price, tiers []PriceTier, quantity int) float64 {
+	unitPrice := price.Price
+	best := 0
+	for _, tier := range tiers {
+		if tier.PriceID == price.ID && quantity >= tier.MinQuantity && tier.MinQuantity > best {
+			best = tier.MinQuantity
+			unitPrice = tier.UnitPrice
+		}
+	}
+	return unitPrice
+}
@@ -7,17 +7,45 @@ import (
 // Product represents a product in the system
 type Product struct {
 	models.Base
-	Name        string         `gorm:"column:name;type:varchar(255);not null;index" json:"name"`
-	Description string         `gorm:"column:description;type:text" json:"description"`
-	SKU         string         `gorm:"column:sku;type:varchar(50);not null;uniqueIndex" json:"sku"`
-	Category    string         `gorm:"column:category;type:varchar(100);not null;index" json:"category"`
-	ImageURL    string         `gorm:"column:image_url;type:text" json:"image_url"`
-	Inventory   []Inventory    `gorm:"foreignKey:ProductID" json:"inventory,omitempty"`
-	Prices      []Price        `gorm:"foreignKey:ProductID" json:"prices,omitempty"`
-	Images      []ProductImage `gorm:"foreignKey:ProductID" json:"images,omitempty"`
+	Name          string         `gorm:"column:name;type:varchar(255);not null;index" json:"name"`
+	Description   string         `gorm:"column:description;type:text" json:"description"`
+	SKU           string         `gorm:"column:sku;type:varchar(50);not null;uniqueIndex" json:"sku"`
+	Category      string         `gorm:"column:category;type:varchar(100);not null;index" json:"category"`
+	ImageURL      string         `gorm:"column:image_url;type:text" json:"image_url"`
+	SoldCount     int            `gorm:"column:sold_count;not null;default:0;index" json:"sold_count"`
+	IsFeatured    bool           `gorm:"column:is_featured;not null;default:false;index" json:"is_featured"`
+	FeaturedOrder int            `gorm:"column:featured_order;not null;default:0" json:"featured_order"`
+	Inventory     []Inventory    `gorm:"foreignKey:ProductID" json:"inventory,omitempty"`
+	Prices        []Price        `gorm:"foreignKey:ProductID" json:"prices,omitempty"`
+	Images        []ProductImage `gorm:"foreignKey:ProductID" json:"images,omitempty"`
 }
 
 // TableName specifies the table name for Product
 func (Product) TableName() string {
 	return "products"
 }
+
+// PrimaryImageURL returns the URL shown for the product: its primary image, the first image by
+// sort order when none is primary, or ImageURL when the product has no image rows loaded
+func (p Product) PrimaryImageURL() string {
+	return PrimaryImageURL(p.Images, p.ImageURL)
+}
+
+// PrimaryImageURL returns the URL of the primary image, the URL of the first image by sort order
+// when none is primary, or the fallback URL when there are no images
+func PrimaryImageURL(images []ProductImage, fallback string) string {
+	var first *ProductImage
+	for i := range images {
+		if images[i].IsPrimary {
+			return images[i].URL
+		}
+		if first == nil || images[i].SortOrder < first.SortOrder {
+			first = &images[i]
+		}
+	}
+
+	if first != nil {
+		return first.URL
+	}
+	return fallback
+}
@@ -10,10 +10,13 @@ type ProductImage struct {
 	models.Base
 	ProductID uuid.UUID `gorm:"column:product_id;type:uuid;not null;index" json:"product_id"`
 	URL       string    `gorm:"column:url;type:text;not null" json:"url"`
-	Filename  string    `gorm:"column:filename;type:varchar(255);not null" json:"filename"`
-	IsPrimary bool      `gorm:"column:is_primary;type:boolean;default:false" json:"is_primary"`
-	SortOrder int       `gorm:"column:sort_order;type:int;default:0" json:"sort_order"`
-	Product   *Product  `gorm:"foreignKey:ProductID" json:"-"`
+	// Resized copies of the image; they are the original URL when the image could not be resized
+	ThumbnailURL string   `gorm:"column:thumbnail_url;type:text" json:"thumbnail_url"`
+	MediumURL    string   `gorm:"column:medium_url;type:text" json:"medium_url"`
+	Filename     string   `gorm:"column:filename;type:varchar(255);not null" json:"filename"`
+	IsPrimary    bool     `gorm:"column:is_primary;type:boolean;default:false" json:"is_primary"`
+	SortOrder    int      `gorm:"column:sort_order;type:int;default:0" json:"sort_order"`
+	Product      *Product `gorm:"foreignKey:ProductID" json:"-"`
 }
 
 // TableName specifies the table name for ProductImage
@@ -0,0 +1,35 @@
+package product
+
+import (
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models"
+)
+
+// VariantAttribute is a product attribute that tells the inventories of a product apart
+type VariantAttribute string
+
+const (
+	// AttributeSize is the size of a product variant
+	AttributeSize VariantAttribute = "size"
+	// AttributeColor is the color of a product variant
+	AttributeColor VariantAttribute = "color"
+)
+
+// IsValid checks if the attribute is one of the supported variant attributes
+func (a VariantAttribute) IsValid() bool {
+	return a == AttributeSize || a == AttributeColor
+}
+
+// VariantOption is a value a product allows for a variant attribute, e.g. size "M" or color "Red".
+// Once a product defines options for an attribute, its inventories must use one of them.
+type VariantOption struct {
+	models.Base
+	ProductID uuid.UUID        `gorm:"column:product_id;type:uuid;not null;uniqueIndex:idx_variant_options_value,where:deleted_at IS NULL" json:"product_id"`
+	Attribute VariantAttribute `gorm:"column:attribute;type:varchar(20);not null;uniqueIndex:idx_variant_options_value,where:deleted_at IS NULL" json:"attribute"`
+	Value     string           `gorm:"column:value;type:varchar(50);not null;uniqueIndex:idx_variant_options_value,where:deleted_at IS NULL" json:"value"`
+}
+
+// TableName specifies the table name for VariantOption
+func (VariantOption) TableName() string {
+	return "product_variant_options"
+}
@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models/account"
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository handles database operations for API keys
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository creates a new instance of APIKeyRepository
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{
+		db: db,
+	}
+}
+
+// CreateAPIKey creates a new API key
+func (r *APIKeyRepository) CreateAPIKey(key *account.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+// GetAPIKeyByID retrieves an API key by ID
+func (r *APIKeyRepository) GetAPIKeyByID(id uuid.UUID) (*account.APIKey, error) {
+	var key account.APIKey
+	err := r.db.Where("id = ?", id).First(&key).Error
+	return &key, err
+}
+
+// GetAPIKeyByHash retrieves an API key by the hash of the key
+func (r *APIKeyRepository) GetAPIKeyByHash(hash string) (*account.APIKey, error) {
+	var key account.APIKey
+	err := r.db.Where("key_hash = ?", hash).First(&key).Error
+	return &key, err
+}
+
+// GetAPIKeys retrieves all API keys, newest first
+func (r *APIKeyRepository) GetAPIKeys() ([]account.APIKey, error) {
+	var keys []account.APIKey
+	err := r.db.Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// UpdateAPIKey updates the label, scopes and revocation of an API key
+func (r *APIKeyRepository) UpdateAPIKey(key *account.APIKey) error {
+	return r.db.Model(key).
+		Select("label", "scopes", "revoked", "revoked_at", "updated_at", "updated_by").
+		Updates(key).Error
+}
+
+// TouchAPIKey records when an API key was last used
+func (r *APIKeyRepository) TouchAPIKey(id uuid.UUID, usedAt time.Time) error {
+	return r.db.Model(&account.APIKey{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", usedAt).Error
+}
+
+// DeleteAPIKey deletes an API key
+func (r *APIKeyRepository) DeleteAPIKey(id uuid.UUID) error {
+	return r.db.Delete(&account.APIKey{}, id).Error
+}
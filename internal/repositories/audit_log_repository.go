@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/ybds/internal/models/account"
+	"gorm.io/gorm"
+)
+
+// AuditLogRepository handles database operations for audit logs
+type AuditLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditLogRepository creates a new instance of AuditLogRepository
+func NewAuditLogRepository(db *gorm.DB) *AuditLogRepository {
+	return &AuditLogRepository{
+		db: db,
+	}
+}
+
+// WithContext returns a copy of the repository whose queries run with the given context,
+// so they are aborted when the context is canceled or its deadline passes
+func (r *AuditLogRepository) WithContext(ctx context.Context) *AuditLogRepository {
+	return &AuditLogRepository{
+		db: r.db.WithContext(ctx),
+	}
+}
+
+// CreateAuditLog creates a new audit log entry
+func (r *AuditLogRepository) CreateAuditLog(entry *account.AuditLog) error {
+	return r.db.Create(entry).Error
+}
+
+// GetAuditLogs retrieves audit log entries, newest first, with pagination and filtering
+func (r *AuditLogRepository) GetAuditLogs(page, pageSize int, filters map[string]interface{}) ([]account.AuditLog, int64, error) {
+	var entries []account.AuditLog
+	var total int64
+
+	query := r.db.Model(&account.AuditLog{})
+	for key, value := range filters {
+		switch key {
+		case "actor_id":
+			query = query.Where("actor_id = ?", value)
+		case "resource":
+			query = query.Where("resource = ?", value)
+		case "target_id":
+			query = query.Where("target_id = ?", value)
+		case "method":
+			query = query.Where("method = ?", value)
+		case "from_date":
+			query = query.Where("created_at >= ?", value)
+		case "to_date":
+			query = query.Where("created_at <= ?", value)
+		}
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("created_at DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&entries).Error
+
+	return entries, total, err
+}
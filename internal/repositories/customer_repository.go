@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models/order"
+	"github.com/ybds/pkg/phone"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CustomerRepository handles database operations for customers
+type CustomerRepository struct {
+	db *gorm.DB
+}
+
+// NewCustomerRepository creates a new instance of CustomerRepository
+func NewCustomerRepository(db *gorm.DB) *CustomerRepository {
+	return &CustomerRepository{
+		db: db,
+	}
+}
+
+// WithContext returns a copy of the repository whose queries run with the given context,
+// so they are aborted when the context is canceled or its deadline passes
+func (r *CustomerRepository) WithContext(ctx context.Context) *CustomerRepository {
+	return &CustomerRepository{
+		db: r.db.WithContext(ctx),
+	}
+}
+
+// GetCustomerByID retrieves a customer by ID
+func (r *CustomerRepository) GetCustomerByID(id uuid.UUID) (*order.Customer, error) {
+	var customer order.Customer
+	err := r.db.Where("id = ?", id).First(&customer).Error
+	return &customer, err
+}
+
+// GetCustomerByPhone retrieves a customer by phone number in E.164 format
+func (r *CustomerRepository) GetCustomerByPhone(phone string) (*order.Customer, error) {
+	var customer order.Customer
+	err := r.db.Where("phone = ?", phone).First(&customer).Error
+	return &customer, err
+}
+
+// GetCustomers retrieves customers with pagination, newest first. A non-empty search term
+// matches the name, email or phone.
+func (r *CustomerRepository) GetCustomers(page, pageSize int, search string) ([]order.Customer, int64, error) {
+	var customers []order.Customer
+	var total int64
+
+	query := r.db.Model(&order.Customer{})
+	if search != "" {
+		// Phone numbers are stored in E.164 format and matched whatever form they are searched in
+		pattern := containsPattern(search)
+		query = query.Where("name ILIKE ? OR email ILIKE ? OR phone LIKE ?", pattern, pattern, containsPattern(phone.SearchTerm(search)))
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("created_at DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&customers).Error
+
+	return customers, total, err
+}
+
+// UpsertCustomer returns the customer with the given phone in E.164 format, creating it when it does not
+// exist yet. The name and email of an existing customer are replaced by the given ones when they are
+// not empty, so the profile follows the latest order.
+func (r *CustomerRepository) UpsertCustomer(phone, name, email string) (*order.Customer, error) {
+	customer := order.Customer{Phone: phone, Name: name, Email: email}
+	if err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "phone"}},
+		DoNothing: true,
+	}).Create(&customer).Error; err != nil {
+		return nil, err
+	}
+
+	existing, err := r.GetCustomerByPhone(phone)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+	if name != "" && name != existing.Name {
+		updates["name"] = name
+	}
+	if email != "" && email != existing.Email {
+		updates["email"] = email
+	}
+	if len(updates) > 0 {
+		if err := r.db.Model(existing).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return existing, nil
+}
+
+// CustomerOrderStats summarizes the orders of a customer
+type CustomerOrderStats struct {
+	OrderCount  int64
+	TotalSpent  float64
+	LastOrderAt *time.Time
+}
+
+// GetCustomerOrderStats counts the orders of a customer and sums the final totals of those
+// that were not canceled
+func (r *CustomerRepository) GetCustomerOrderStats(customerID uuid.UUID) (*CustomerOrderStats, error) {
+	var stats CustomerOrderStats
+	err := r.db.Model(&order.Order{}).
+		Select("COUNT(*) AS order_count, "+
+			"COALESCE(SUM(CASE WHEN order_status <> ? THEN final_total_amount ELSE 0 END), 0) AS total_spent, "+
+			"MAX(created_at) AS last_order_at", order.OrderCanceled).
+		Where("customer_id = ?", customerID).
+		Scan(&stats).Error
+	return &stats, err
+}
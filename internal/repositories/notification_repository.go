@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/ybds/internal/models/notification"
 	"gorm.io/gorm"
@@ -45,19 +47,34 @@ func (r *NotificationRepository) GetUnreadNotificationsByRecipient(recipientID u
 	return notifications, err
 }
 
-// GetAllNotifications retrieves all notifications with pagination
-func (r *NotificationRepository) GetAllNotifications(page, pageSize int) ([]notification.Notification, int64, error) {
+// GetAllNotifications retrieves all notifications with pagination and filtering, newest first
+func (r *NotificationRepository) GetAllNotifications(page, pageSize int, filters map[string]interface{}) ([]notification.Notification, int64, error) {
 	var notifications []notification.Notification
 	var total int64
 
+	query := r.db.Model(&notification.Notification{})
+
+	// Apply filters
+	for key, value := range filters {
+		switch key {
+		case "recipient_type":
+			query = query.Where("recipient_type = ?", value)
+		case "recipient_id":
+			query = query.Where("recipient_id = ?", value)
+		case "is_read":
+			query = query.Where("is_read = ?", value)
+		}
+	}
+
 	// Count total records
-	if err := r.db.Model(&notification.Notification{}).Count(&total).Error; err != nil {
+	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get paginated records
 	offset := (page - 1) * pageSize
-	err := r.db.Offset(offset).Limit(pageSize).
+	err := query.Order("created_at DESC").
+		Offset(offset).Limit(pageSize).
 		Preload("Channels").
 		Find(&notifications).Error
 
@@ -79,6 +96,83 @@ func (r *NotificationRepository) DeleteNotification(id uuid.UUID) error {
 	return r.db.Delete(&notification.Notification{}, id).Error
 }
 
+// DeleteReadNotificationsBefore permanently deletes read notifications created before the cutoff
+// together with their delivery channels. Unread notifications are always kept.
+// It returns the number of deleted notifications.
+func (r *NotificationRepository) DeleteReadNotificationsBefore(cutoff time.Time) (int64, error) {
+	var deleted int64
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		expired := tx.Unscoped().Model(&notification.Notification{}).
+			Select("id").
+			Where("is_read = ? AND created_at < ?", true, cutoff)
+
+		if err := tx.Unscoped().
+			Where("notification_id IN (?)", expired).
+			Delete(&notification.Channel{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().
+			Where("is_read = ? AND created_at < ?", true, cutoff).
+			Delete(&notification.Notification{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		deleted = result.RowsAffected
+		return nil
+	})
+
+	return deleted, err
+}
+
+// HasUnreadInventoryNotification reports whether an unread notification for the given
+// inventory and event already exists, so repeated stock alerts can be suppressed
+func (r *NotificationRepository) HasUnreadInventoryNotification(inventoryID uuid.UUID, event string) (bool, error) {
+	var count int64
+	err := r.db.Model(&notification.Notification{}).
+		Where("is_read = ?", false).
+		Where("metadata->>'inventory_id' = ? AND metadata->>'event' = ?", inventoryID.String(), event).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetNotificationsByOrderID retrieves all notifications whose metadata references the order, oldest first
+func (r *NotificationRepository) GetNotificationsByOrderID(orderID uuid.UUID) ([]notification.Notification, error) {
+	var notifications []notification.Notification
+	err := r.db.Where("metadata->>'order_id' = ?", orderID.String()).
+		Order("created_at ASC").
+		Find(&notifications).Error
+	return notifications, err
+}
+
+// NotificationEventCount is the number of notifications about a kind of resource for an event
+type NotificationEventCount struct {
+	Resource string
+	Event    string
+	Count    int64
+}
+
+// CountUnreadByEvent counts the unread notifications of a recipient grouped by the resource they
+// are about (order, product or other) and the event stored in their metadata, largest count first
+func (r *NotificationRepository) CountUnreadByEvent(recipientID uuid.UUID, recipientType notification.RecipientType) ([]NotificationEventCount, error) {
+	var counts []NotificationEventCount
+	err := r.db.Model(&notification.Notification{}).
+		Select(`CASE
+				WHEN metadata->>'order_id' IS NOT NULL THEN 'order'
+				WHEN metadata->>'product_id' IS NOT NULL THEN 'product'
+				ELSE 'other'
+			END AS resource,
+			COALESCE(metadata->>'event', '') AS event,
+			COUNT(*) AS count`).
+		Where("recipient_id = ? AND recipient_type = ? AND is_read = ?", recipientID, recipientType, false).
+		Group("resource, event").
+		Order("count DESC, resource, event").
+		Scan(&counts).Error
+	return counts, err
+}
+
 // MarkNotificationAsRead marks a notification as read
 func (r *NotificationRepository) MarkNotificationAsRead(id uuid.UUID) error {
 	return r.db.Model(&notification.Notification{}).Where("id = ?", id).Update("is_read", true).Error
@@ -91,6 +185,25 @@ func (r *NotificationRepository) MarkAllNotificationsAsRead(recipientID uuid.UUI
 		Update("is_read", true).Error
 }
 
+// MarkUnreadAsReadByEvent marks the unread notifications of a recipient with the given metadata event
+// as read and returns how many were updated. A non-empty resource ("order" or "product") further
+// limits the update to notifications of that resource.
+func (r *NotificationRepository) MarkUnreadAsReadByEvent(recipientID uuid.UUID, recipientType notification.RecipientType, event, resource string) (int64, error) {
+	query := r.db.Model(&notification.Notification{}).
+		Where("recipient_id = ? AND recipient_type = ? AND is_read = ?", recipientID, recipientType, false).
+		Where("metadata->>'event' = ?", event)
+
+	switch resource {
+	case "order":
+		query = query.Where("metadata->>'order_id' IS NOT NULL")
+	case "product":
+		query = query.Where("metadata->>'order_id' IS NULL AND metadata->>'product_id' IS NOT NULL")
+	}
+
+	result := query.Update("is_read", true)
+	return result.RowsAffected, result.Error
+}
+
 // GetChannelByID retrieves a channel by ID
 func (r *NotificationRepository) GetChannelByID(id uuid.UUID) (*notification.Channel, error) {
 	var channel notification.Channel
@@ -116,6 +229,25 @@ func (r *NotificationRepository) GetChannelsByNotificationID(notificationID uuid
 	return channels, err
 }
 
+// GetDueChannels retrieves up to limit failed channels of existing notifications whose retry is
+// due at the given time and that have been attempted fewer than maxAttempts times, oldest due first
+func (r *NotificationRepository) GetDueChannels(now time.Time, maxAttempts, limit int) ([]notification.Channel, error) {
+	var channels []notification.Channel
+	err := r.db.Joins("JOIN notifications ON notifications.id = notification_channels.notification_id AND notifications.deleted_at IS NULL").
+		Preload("Notification").
+		Where("notification_channels.status = ? AND notification_channels.attempts < ? AND notification_channels.next_attempt_at <= ?",
+			notification.ChannelFailed, maxAttempts, now).
+		Order("notification_channels.next_attempt_at").
+		Limit(limit).
+		Find(&channels).Error
+	return channels, err
+}
+
+// ClearChannelNextAttempt unschedules the retry of a channel
+func (r *NotificationRepository) ClearChannelNextAttempt(id uuid.UUID) error {
+	return r.db.Model(&notification.Channel{}).Where("id = ?", id).Update("next_attempt_at", nil).Error
+}
+
 // CreateChannel creates a new channel
 func (r *NotificationRepository) CreateChannel(channel *notification.Channel) error {
 	return r.db.Create(channel).Error
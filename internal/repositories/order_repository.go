@@ -1,10 +1,13 @@
 package repositories
 
 import (
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/ybds/internal/models/order"
+	"github.com/ybds/pkg/phone"
 	"gorm.io/gorm"
 )
 
@@ -20,12 +23,27 @@ func NewOrderRepository(db *gorm.DB) *OrderRepository {
 	}
 }
 
+// WithContext returns a copy of the repository whose queries run with the given context,
+// so they are aborted when the context is canceled or its deadline passes
+func (r *OrderRepository) WithContext(ctx context.Context) *OrderRepository {
+	return &OrderRepository{
+		db: r.db.WithContext(ctx),
+	}
+}
+
+// preloadShipments loads the shipments of orders oldest first, together with their assigned items
+func preloadShipments(db *gorm.DB) *gorm.DB {
+	return db.Preload("Shipments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("shipments.created_at ASC")
+	}).Preload("Shipments.Items")
+}
+
 // GetOrderByID retrieves an order by ID with all relations
 func (r *OrderRepository) GetOrderByID(id uuid.UUID) (*order.Order, error) {
 	var o order.Order
 	err := r.db.Where("id = ?", id).
 		Preload("Items").
-		Preload("Shipment").
+		Scopes(preloadShipments).
 		First(&o).Error
 	return &o, err
 }
@@ -36,51 +54,158 @@ func (r *OrderRepository) GetOrderByTrackingNumber(trackingNumber string) (*orde
 	err := r.db.Joins("JOIN shipments ON orders.id = shipments.order_id").
 		Where("shipments.tracking_number = ? AND shipments.deleted_at IS NULL", trackingNumber).
 		Preload("Items").
-		Preload("Shipment").
+		Scopes(preloadShipments).
+		First(&o).Error
+	return &o, err
+}
+
+// GetOrderByOrderNumber retrieves an order by its human-readable order number
+func (r *OrderRepository) GetOrderByOrderNumber(orderNumber string) (*order.Order, error) {
+	var o order.Order
+	err := r.db.Where("order_number = ?", orderNumber).
+		Preload("Items").
+		Scopes(preloadShipments).
 		First(&o).Error
 	return &o, err
 }
 
+// NextOrderNumberSequence atomically increments and returns the order number sequence of a period.
+// When called inside a transaction the sequence row stays locked until commit, so numbers are gapless.
+func (r *OrderRepository) NextOrderNumberSequence(period string) (int64, error) {
+	var sequence int64
+	err := r.db.Raw(`INSERT INTO order_number_sequences (period, last_value, updated_at)
+		VALUES (?, 1, NOW())
+		ON CONFLICT (period) DO UPDATE
+		SET last_value = order_number_sequences.last_value + 1, updated_at = NOW()
+		RETURNING last_value`, period).
+		Scan(&sequence).Error
+	return sequence, err
+}
+
 // GetAllOrders retrieves all orders with pagination and filtering
 func (r *OrderRepository) GetAllOrders(page, pageSize int, filters map[string]interface{}) ([]order.Order, int64, error) {
 	var orders []order.Order
 	var total int64
 
-	query := r.db.Model(&order.Order{})
+	query := applyOrderFilters(r.db.Model(&order.Order{}), filters)
+
+	// Count total records
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Get paginated records
+	offset := (page - 1) * pageSize
+	err := query.Offset(offset).Limit(pageSize).
+		Preload("Items").
+		Scopes(preloadShipments).
+		Find(&orders).Error
+
+	return orders, total, err
+}
+
+// GetOrderIDsByFilter retrieves the IDs of at most limit orders matching the filters, oldest first,
+// together with the total number of matching orders
+func (r *OrderRepository) GetOrderIDsByFilter(filters map[string]interface{}, limit int) ([]uuid.UUID, int64, error) {
+	var ids []uuid.UUID
+	var total int64
+
+	query := applyOrderFilters(r.db.Model(&order.Order{}), filters)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("orders.created_at ASC").
+		Limit(limit).
+		Pluck("orders.id", &ids).Error
+
+	return ids, total, err
+}
+
+// GetOrderQueue retrieves at most limit orders matching the filters, oldest first, together with
+// the total number of matching orders
+func (r *OrderRepository) GetOrderQueue(filters map[string]interface{}, limit int) ([]order.Order, int64, error) {
+	var orders []order.Order
+	var total int64
+
+	query := applyOrderFilters(r.db.Model(&order.Order{}), filters)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("orders.created_at ASC").
+		Limit(limit).
+		Scopes(preloadShipments).
+		Find(&orders).Error
+
+	return orders, total, err
+}
 
-	// Apply filters
+// applyOrderFilters adds the conditions of the order list filters to the query
+func applyOrderFilters(query *gorm.DB, filters map[string]interface{}) *gorm.DB {
 	for key, value := range filters {
 		switch key {
 		case "payment_method":
-			query = query.Where("payment_method = ?", value)
+			query = query.Where("orders.payment_method = ?", value)
 		case "payment_status":
-			query = query.Where("payment_status = ?", value)
+			query = query.Where("orders.payment_status = ?", value)
 		case "order_status":
-			query = query.Where("order_status = ?", value)
+			// A list of statuses matches orders in any of them
+			if statuses, ok := value.([]string); ok {
+				query = query.Where("orders.order_status IN ?", statuses)
+			} else {
+				query = query.Where("orders.order_status = ?", value)
+			}
 		case "created_by":
-			query = query.Where("created_by = ?", value)
+			query = query.Where("orders.created_by = ?", value)
+		case "customer_id":
+			query = query.Where("orders.customer_id = ?", value)
 		case "from_date":
 			query = query.Where("orders.created_at >= ?", value)
 		case "to_date":
 			query = query.Where("orders.created_at <= ?", value)
 		case "phone_number":
-			query = query.Where("customer_phone LIKE ?", "%"+value.(string)+"%")
+			// Numbers are matched whether they were stored in national or E.164 format
+			query = query.Where("orders.customer_phone LIKE ?", containsPattern(phone.SearchTerm(value.(string))))
+		case "order_number":
+			query = query.Where("orders.order_number = ?", value)
+		case "search":
+			// Partial, case-insensitive match on the order number, customer name or phone; phone
+			// numbers are matched whether they were stored in national or E.164 format
+			term := containsPattern(value.(string))
+			query = query.Where("(orders.order_number ILIKE ? OR orders.customer_name ILIKE ? OR orders.customer_phone LIKE ?)",
+				term, term, containsPattern(phone.SearchTerm(value.(string))))
+		case "carrier":
+			// An order split into several shipments matches when any of them uses the carrier
+			query = query.Where(`EXISTS (
+				SELECT 1 FROM shipments
+				WHERE shipments.order_id = orders.id AND shipments.deleted_at IS NULL
+				AND LOWER(shipments.carrier) = LOWER(?))`, value)
+		case "awaiting_action_before":
+			// Orders waiting for an agent: pending since before the cutoff, or packed
+			query = query.Where("((orders.order_status = ? AND orders.created_at < ?) OR orders.order_status = ?)",
+				order.OrderShipmentRequested, value, order.OrderPacked)
+		case "flagged_before":
+			// Orders needing attention: waiting for a shipment tracking number since before the
+			// cutoff, or being returned by the carrier
+			query = query.Where(`((orders.order_status IN ? AND orders.created_at <= ? AND NOT EXISTS (
+				SELECT 1 FROM shipments
+				WHERE shipments.order_id = orders.id AND shipments.deleted_at IS NULL
+				AND COALESCE(shipments.tracking_number, '') <> ''))
+				OR orders.order_status = ?)`,
+				[]order.OrderStatus{order.OrderShipmentRequested, order.OrderPacked}, value, order.OrderReturnProcessing)
+		case "inventory_id":
+			// Orders with at least one item of the inventory variant; the item order IDs are
+			// deduplicated so an order holding the variant in several items is listed once
+			query = query.Joins(`JOIN (
+				SELECT DISTINCT order_items.order_id FROM order_items
+				WHERE order_items.inventory_id = ? AND order_items.deleted_at IS NULL
+			) AS variant_items ON variant_items.order_id = orders.id`, value)
 		}
 	}
-
-	// Count total records
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// Get paginated records
-	offset := (page - 1) * pageSize
-	err := query.Offset(offset).Limit(pageSize).
-		Preload("Items").
-		Preload("Shipment").
-		Find(&orders).Error
-
-	return orders, total, err
+	return query
 }
 
 // CreateOrder creates a new order
@@ -103,6 +228,20 @@ func (r *OrderRepository) UpdateOrderStatus(id uuid.UUID, status order.OrderStat
 	return r.db.Model(&order.Order{}).Where("id = ?", id).Update("order_status", status).Error
 }
 
+// CreateStatusHistory records a change of the status of an order
+func (r *OrderRepository) CreateStatusHistory(history *order.OrderStatusHistory) error {
+	return r.db.Create(history).Error
+}
+
+// GetStatusHistory retrieves the status changes of an order, oldest first
+func (r *OrderRepository) GetStatusHistory(orderID uuid.UUID) ([]order.OrderStatusHistory, error) {
+	var history []order.OrderStatusHistory
+	err := r.db.Where("order_id = ?", orderID).
+		Order("created_at ASC").
+		Find(&history).Error
+	return history, err
+}
+
 // GetOrderItemByID retrieves an order item by ID
 func (r *OrderRepository) GetOrderItemByID(id uuid.UUID) (*order.OrderItem, error) {
 	var item order.OrderItem
@@ -153,7 +292,7 @@ func (r *OrderRepository) GetShipmentByID(id uuid.UUID) (*order.Shipment, error)
 	return &shipment, err
 }
 
-// GetShipmentByOrderID retrieves a shipment by order ID
+// GetShipmentByOrderID retrieves the primary shipment of an order, i.e. its oldest shipment
 func (r *OrderRepository) GetShipmentByOrderID(orderID uuid.UUID) (*order.Shipment, error) {
 	// Check if order exists and is not deleted
 	var count int64
@@ -165,11 +304,21 @@ func (r *OrderRepository) GetShipmentByOrderID(orderID uuid.UUID) (*order.Shipme
 	}
 
 	var shipment order.Shipment
-	err := r.db.Where("order_id = ?", orderID).First(&shipment).Error
+	err := r.db.Where("order_id = ?", orderID).Order("created_at ASC").Preload("Items").First(&shipment).Error
 	return &shipment, err
 }
 
-// CreateShipment creates a new shipment
+// GetShipmentsByOrderID retrieves all shipments of an order oldest first, together with their assigned items
+func (r *OrderRepository) GetShipmentsByOrderID(orderID uuid.UUID) ([]order.Shipment, error) {
+	var shipments []order.Shipment
+	err := r.db.Where("order_id = ?", orderID).
+		Order("created_at ASC").
+		Preload("Items").
+		Find(&shipments).Error
+	return shipments, err
+}
+
+// CreateShipment creates a new shipment together with its assigned items
 func (r *OrderRepository) CreateShipment(shipment *order.Shipment) error {
 	return r.db.Create(shipment).Error
 }
@@ -179,9 +328,74 @@ func (r *OrderRepository) UpdateShipment(shipment *order.Shipment) error {
 	return r.db.Save(shipment).Error
 }
 
-// DeleteShipment deletes a shipment by ID
+// DeleteShipment deletes a shipment by ID together with its item assignments
 func (r *OrderRepository) DeleteShipment(id uuid.UUID) error {
-	return r.db.Delete(&order.Shipment{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("shipment_id = ?", id).Delete(&order.ShipmentItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&order.Shipment{}, id).Error
+	})
+}
+
+// ShipmentListItem represents a shipment together with the minimal information of its order
+type ShipmentListItem struct {
+	ID             uuid.UUID
+	OrderID        uuid.UUID
+	OrderNumber    string
+	OrderStatus    order.OrderStatus
+	CustomerName   string
+	CustomerPhone  string
+	TrackingNumber string
+	Carrier        string
+	ShippingCost   float64
+	CODAmount      float64
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// GetShipments retrieves shipments of non-deleted orders with pagination and filtering, newest first.
+// Supported filters are carrier, has_tracking, order_status, from_date and to_date,
+// where the date range applies to the shipment creation time.
+func (r *OrderRepository) GetShipments(page, pageSize int, filters map[string]interface{}) ([]ShipmentListItem, int64, error) {
+	var rows []ShipmentListItem
+	var total int64
+
+	query := r.db.Model(&order.Shipment{}).
+		Joins("JOIN orders ON orders.id = shipments.order_id AND orders.deleted_at IS NULL")
+
+	for key, value := range filters {
+		switch key {
+		case "carrier":
+			query = query.Where("LOWER(shipments.carrier) = LOWER(?)", value)
+		case "has_tracking":
+			if value.(bool) {
+				query = query.Where("COALESCE(shipments.tracking_number, '') <> ''")
+			} else {
+				query = query.Where("COALESCE(shipments.tracking_number, '') = ''")
+			}
+		case "order_status":
+			query = query.Where("orders.order_status = ?", value)
+		case "from_date":
+			query = query.Where("shipments.created_at >= ?", value)
+		case "to_date":
+			query = query.Where("shipments.created_at <= ?", value)
+		}
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Select("shipments.id, shipments.order_id, orders.order_number, orders.order_status, " +
+		"orders.customer_name, orders.customer_phone, shipments.tracking_number, shipments.carrier, " +
+		"shipments.shipping_cost, shipments.cod_amount, shipments.created_at, shipments.updated_at").
+		Order("shipments.created_at DESC").
+		Offset(offset).Limit(pageSize).
+		Scan(&rows).Error
+
+	return rows, total, err
 }
 
 // GetOrdersByPhoneNumber retrieves orders with a specific phone number with pagination
@@ -190,7 +404,7 @@ func (r *OrderRepository) GetOrdersByPhoneNumber(phoneNumber string, page, pageS
 	var total int64
 
 	// Start building the query
-	query := r.db.Model(&order.Order{}).Where("customer_phone LIKE ?", "%"+phoneNumber+"%")
+	query := r.db.Model(&order.Order{}).Where("customer_phone LIKE ?", containsPattern(phone.SearchTerm(phoneNumber)))
 
 	// Apply additional filters if provided
 	if additionalFilters != nil {
@@ -224,10 +438,266 @@ func (r *OrderRepository) GetOrdersByPhoneNumber(phoneNumber string, page, pageS
 	// Execute the query
 	if err := query.
 		Preload("Items").
-		Preload("Shipment").
+		Scopes(preloadShipments).
 		Find(&orders).Error; err != nil {
 		return nil, 0, err
 	}
 
 	return orders, total, nil
 }
+
+// PaymentMethodRevenue represents the aggregated revenue of orders paid with a payment method
+type PaymentMethodRevenue struct {
+	PaymentMethod order.PaymentMethod
+	OrderCount    int64
+	TotalRevenue  float64
+}
+
+// GetRevenueByPaymentMethod aggregates the final totals of non-canceled orders grouped by payment method.
+// A nil bound leaves that side of the date range open.
+func (r *OrderRepository) GetRevenueByPaymentMethod(fromDate, toDate *time.Time) ([]PaymentMethodRevenue, error) {
+	var rows []PaymentMethodRevenue
+
+	query := r.db.Model(&order.Order{}).
+		Select("payment_method, COUNT(*) AS order_count, COALESCE(SUM(final_total_amount), 0) AS total_revenue").
+		Where("order_status <> ?", order.OrderCanceled)
+
+	if fromDate != nil {
+		query = query.Where("orders.created_at >= ?", *fromDate)
+	}
+	if toDate != nil {
+		query = query.Where("orders.created_at <= ?", *toDate)
+	}
+
+	err := query.Group("payment_method").
+		Order("total_revenue DESC").
+		Scan(&rows).Error
+
+	return rows, err
+}
+
+// SalesPeriodSummary represents the aggregated sales of the orders created in one period
+type SalesPeriodSummary struct {
+	Period        time.Time
+	OrderCount    int64
+	GrossTotal    float64
+	TotalDiscount float64
+	NetTotal      float64
+	CanceledCount int64
+}
+
+// GetSalesSummary aggregates the orders created in the date range per period, oldest period first.
+// The unit is a PostgreSQL date_trunc field (day, week or month) and must be validated by the caller.
+// Canceled orders are left out of the count and totals and counted separately. A nil bound leaves
+// that side of the date range open.
+func (r *OrderRepository) GetSalesSummary(unit string, fromDate, toDate *time.Time) ([]SalesPeriodSummary, error) {
+	var rows []SalesPeriodSummary
+
+	query := r.db.Model(&order.Order{}).
+		Select(fmt.Sprintf("date_trunc('%s', orders.created_at) AS period", unit)+
+			", COUNT(*) FILTER (WHERE order_status <> ?) AS order_count"+
+			", COALESCE(SUM(total_amount) FILTER (WHERE order_status <> ?), 0) AS gross_total"+
+			", COALESCE(SUM(discount_amount) FILTER (WHERE order_status <> ?), 0) AS total_discount"+
+			", COALESCE(SUM(final_total_amount) FILTER (WHERE order_status <> ?), 0) AS net_total"+
+			", COUNT(*) FILTER (WHERE order_status = ?) AS canceled_count",
+			order.OrderCanceled, order.OrderCanceled, order.OrderCanceled, order.OrderCanceled, order.OrderCanceled)
+
+	if fromDate != nil {
+		query = query.Where("orders.created_at >= ?", *fromDate)
+	}
+	if toDate != nil {
+		query = query.Where("orders.created_at <= ?", *toDate)
+	}
+
+	err := query.Group("period").
+		Order("period ASC").
+		Scan(&rows).Error
+
+	return rows, err
+}
+
+// UnshippedOrder represents an order that is still waiting for a shipment tracking number
+type UnshippedOrder struct {
+	OrderID       uuid.UUID
+	OrderNumber   string
+	CustomerName  string
+	CustomerPhone string
+	OrderStatus   order.OrderStatus
+	CreatedBy     *uuid.UUID
+	WaitingSince  time.Time
+}
+
+// GetOrdersAwaitingShipment retrieves orders in one of the given statuses that have no shipment
+// with a tracking number and have been waiting since before the cutoff, oldest first.
+// The waiting time is measured from the order creation.
+func (r *OrderRepository) GetOrdersAwaitingShipment(statuses []order.OrderStatus, cutoff time.Time) ([]UnshippedOrder, error) {
+	var rows []UnshippedOrder
+
+	err := r.db.Model(&order.Order{}).
+		Select("orders.id AS order_id, orders.order_number, orders.customer_name, orders.customer_phone, "+
+			"orders.order_status, orders.created_by, orders.created_at AS waiting_since").
+		Where("orders.order_status IN ?", statuses).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM shipments
+			WHERE shipments.order_id = orders.id AND shipments.deleted_at IS NULL
+			AND COALESCE(shipments.tracking_number, '') <> '')`).
+		Where("orders.created_at <= ?", cutoff).
+		Order("orders.created_at ASC").
+		Scan(&rows).Error
+
+	return rows, err
+}
+
+// InventorySales represents the units sold and the revenue of an inventory
+type InventorySales struct {
+	InventoryID uuid.UUID
+	UnitsSold   int64
+	Revenue     float64
+}
+
+// GetInventorySales sums the kept units (quantity minus returned quantity) and their revenue at the
+// order price per inventory over the orders created in the date range that are not in one of the
+// excluded statuses. A nil bound leaves that side of the date range open.
+func (r *OrderRepository) GetInventorySales(fromDate, toDate *time.Time, excluded []order.OrderStatus) ([]InventorySales, error) {
+	var rows []InventorySales
+
+	query := r.db.Model(&order.OrderItem{}).
+		Select("order_items.inventory_id, " +
+			"COALESCE(SUM(order_items.quantity - order_items.returned_quantity), 0) AS units_sold, " +
+			"COALESCE(SUM((order_items.quantity - order_items.returned_quantity) * order_items.price_at_order), 0) AS revenue").
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL")
+
+	if len(excluded) > 0 {
+		query = query.Where("orders.order_status NOT IN ?", excluded)
+	}
+	if fromDate != nil {
+		query = query.Where("orders.created_at >= ?", *fromDate)
+	}
+	if toDate != nil {
+		query = query.Where("orders.created_at <= ?", *toDate)
+	}
+
+	err := query.Group("order_items.inventory_id").
+		Scan(&rows).Error
+
+	return rows, err
+}
+
+// CoPurchasedItem is an inventory bought in the same order as another inventory
+type CoPurchasedItem struct {
+	OrderID     uuid.UUID
+	InventoryID uuid.UUID
+}
+
+// GetCoPurchasedItems finds the other inventories bought in non-canceled orders that contain any of
+// the given inventories, by joining order_items with itself through orders. Each order and inventory
+// pair is returned once; inventories in the given list are excluded.
+func (r *OrderRepository) GetCoPurchasedItems(inventoryIDs []uuid.UUID) ([]CoPurchasedItem, error) {
+	var rows []CoPurchasedItem
+	if len(inventoryIDs) == 0 {
+		return rows, nil
+	}
+
+	err := r.db.Table("order_items AS target").
+		Select("DISTINCT other.order_id, other.inventory_id").
+		Joins("JOIN orders ON orders.id = target.order_id AND orders.deleted_at IS NULL").
+		Joins("JOIN order_items AS other ON other.order_id = target.order_id AND other.deleted_at IS NULL").
+		Where("target.inventory_id IN ? AND target.deleted_at IS NULL", inventoryIDs).
+		Where("orders.order_status <> ?", order.OrderCanceled).
+		Where("other.inventory_id NOT IN ?", inventoryIDs).
+		Scan(&rows).Error
+
+	return rows, err
+}
+
+// GetCommittedInventoryQuantity sums the item quantities of an inventory in orders that are in one
+// of the given statuses and whose stock has not been reserved yet
+func (r *OrderRepository) GetCommittedInventoryQuantity(inventoryID uuid.UUID, statuses []order.OrderStatus) (int, error) {
+	var committed int
+
+	err := r.db.Model(&order.OrderItem{}).
+		Select("COALESCE(SUM(order_items.quantity), 0)").
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Where("order_items.inventory_id = ?", inventoryID).
+		Where("orders.order_status IN ?", statuses).
+		Where("orders.inventory_reserved = ?", false).
+		Scan(&committed).Error
+
+	return committed, err
+}
+
+// GetHeldInventoryQuantity sums the quantities of the holds of an inventory that are active at the given time
+func (r *OrderRepository) GetHeldInventoryQuantity(inventoryID uuid.UUID, at time.Time) (int, error) {
+	var held int
+
+	err := r.db.Model(&order.InventoryHold{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("inventory_id = ? AND status = ? AND expires_at > ?", inventoryID, order.HoldActive, at).
+		Scan(&held).Error
+
+	return held, err
+}
+
+// CreateInventoryHold creates a new inventory hold
+func (r *OrderRepository) CreateInventoryHold(hold *order.InventoryHold) error {
+	return r.db.Create(hold).Error
+}
+
+// GetInventoryHoldByID retrieves an inventory hold by ID
+func (r *OrderRepository) GetInventoryHoldByID(id uuid.UUID) (*order.InventoryHold, error) {
+	var hold order.InventoryHold
+	err := r.db.Where("id = ?", id).First(&hold).Error
+	return &hold, err
+}
+
+// ReleaseInventoryHold moves an active hold to the given status at the given time and reports
+// whether it was still active. A hold is released at most once.
+func (r *OrderRepository) ReleaseInventoryHold(id uuid.UUID, status order.HoldStatus, at time.Time) (bool, error) {
+	result := r.db.Model(&order.InventoryHold{}).
+		Where("id = ? AND status = ?", id, order.HoldActive).
+		Updates(map[string]interface{}{"status": status, "released_at": at})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ExpireInventoryHolds marks the active holds that expired at or before the given time as expired
+// and returns how many were expired
+func (r *OrderRepository) ExpireInventoryHolds(at time.Time) (int64, error) {
+	result := r.db.Model(&order.InventoryHold{}).
+		Where("status = ? AND expires_at <= ?", order.HoldActive, at).
+		Updates(map[string]interface{}{"status": order.HoldExpired, "released_at": at})
+	return result.RowsAffected, result.Error
+}
+
+// OrderStatusCount represents the number of orders in a status
+type OrderStatusCount struct {
+	OrderStatus order.OrderStatus
+	OrderCount  int64
+}
+
+// CountOrdersByStatusForCreator counts the orders created by or assigned to a user grouped by status
+func (r *OrderRepository) CountOrdersByStatusForCreator(userID uuid.UUID) ([]OrderStatusCount, error) {
+	var rows []OrderStatusCount
+
+	err := r.db.Model(&order.Order{}).
+		Select("order_status, COUNT(*) AS order_count").
+		Where("created_by = ?", userID).
+		Group("order_status").
+		Order("order_status").
+		Scan(&rows).Error
+
+	return rows, err
+}
+
+// SearchOrders finds orders whose order number, customer phone or customer name contains the term,
+// newest first
+func (r *OrderRepository) SearchOrders(term string, limit int) ([]order.Order, error) {
+	var orders []order.Order
+	pattern := containsPattern(term)
+
+	err := r.db.Where("order_number ILIKE ? OR customer_phone LIKE ? OR customer_name ILIKE ?", pattern, pattern, pattern).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&orders).Error
+
+	return orders, err
+}
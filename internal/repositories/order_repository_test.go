@@ -0,0 +1,282 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/models/order"
+	"github.com/ybds/internal/repositories"
+	"github.com/ybds/internal/testutil"
+	"gorm.io/gorm"
+)
+
+// seedOrder creates an order with the given payment method, status and final total
+func seedOrder(t *testing.T, db *gorm.DB, method order.PaymentMethod, status order.OrderStatus, finalTotal float64) *order.Order {
+	t.Helper()
+
+	o := &order.Order{
+		PaymentMethod:    method,
+		TotalAmount:      finalTotal,
+		FinalTotalAmount: finalTotal,
+		OrderStatus:      status,
+		CustomerName:     "John Doe",
+	}
+	if err := db.Create(o).Error; err != nil {
+		t.Fatalf("failed to seed order: %v", err)
+	}
+	return o
+}
+
+// TestGetRevenueByPaymentMethod tests the revenue breakdown grouped by payment method
+func TestGetRevenueByPaymentMethod(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := repositories.NewOrderRepository(db)
+
+	seedOrder(t, db, order.PaymentCash, order.OrderDelivered, 100)
+	seedOrder(t, db, order.PaymentCash, order.OrderShipmentRequested, 50)
+	seedOrder(t, db, order.PaymentBankTransfer, order.OrderDelivered, 300)
+	seedOrder(t, db, order.PaymentBankTransfer, order.OrderCanceled, 1000)
+
+	rows, err := repo.GetRevenueByPaymentMethod(nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+
+	// Highest revenue first, canceled orders excluded
+	assert.Equal(t, order.PaymentBankTransfer, rows[0].PaymentMethod)
+	assert.Equal(t, int64(1), rows[0].OrderCount)
+	assert.Equal(t, 300.0, rows[0].TotalRevenue)
+	assert.Equal(t, order.PaymentCash, rows[1].PaymentMethod)
+	assert.Equal(t, int64(2), rows[1].OrderCount)
+	assert.Equal(t, 150.0, rows[1].TotalRevenue)
+
+	// A range entirely in the future matches nothing
+	from := time.Now().Add(24 * time.Hour)
+	rows, err = repo.GetRevenueByPaymentMethod(&from, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, rows)
+}
+
+// TestGetAllOrdersByCarrier tests filtering the order listing by shipment carrier
+func TestGetAllOrdersByCarrier(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := repositories.NewOrderRepository(db)
+
+	ghnOrder := seedOrder(t, db, order.PaymentCash, order.OrderDelivering, 100)
+	ghtkOrder := seedOrder(t, db, order.PaymentCash, order.OrderDelivering, 200)
+	seedOrder(t, db, order.PaymentCash, order.OrderShipmentRequested, 300)
+
+	for _, s := range []*order.Shipment{
+		{OrderID: ghnOrder.ID, Carrier: "GHN", TrackingNumber: "GHN001"},
+		{OrderID: ghtkOrder.ID, Carrier: "GHTK", TrackingNumber: "GHTK001"},
+	} {
+		if err := db.Create(s).Error; err != nil {
+			t.Fatalf("failed to seed shipment: %v", err)
+		}
+	}
+
+	orders, total, err := repo.GetAllOrders(1, 10, map[string]interface{}{"carrier": "ghn"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, orders, 1) {
+		assert.Equal(t, ghnOrder.ID, orders[0].ID)
+		assert.Len(t, orders[0].Shipments, 1)
+	}
+
+	// The carrier filter combines with the other filters
+	orders, total, err = repo.GetAllOrders(1, 10, map[string]interface{}{
+		"carrier":      "GHTK",
+		"order_status": order.OrderDelivering,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, orders, 1) {
+		assert.Equal(t, ghtkOrder.ID, orders[0].ID)
+	}
+
+	orders, total, err = repo.GetAllOrders(1, 10, map[string]interface{}{"carrier": "VNPost"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.Empty(t, orders)
+}
+
+// TestGetAllOrdersByStatuses tests filtering the order listing by several statuses at once
+func TestGetAllOrdersByStatuses(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := repositories.NewOrderRepository(db)
+
+	requested := seedOrder(t, db, order.PaymentCash, order.OrderShipmentRequested, 100)
+	packed := seedOrder(t, db, order.PaymentCash, order.OrderPacked, 200)
+	seedOrder(t, db, order.PaymentCash, order.OrderDelivered, 300)
+	seedOrder(t, db, order.PaymentCash, order.OrderCanceled, 400)
+
+	orders, total, err := repo.GetAllOrders(1, 10, map[string]interface{}{
+		"order_status": []string{string(order.OrderShipmentRequested), string(order.OrderPacked)},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	ids := make([]interface{}, len(orders))
+	for i, o := range orders {
+		ids[i] = o.ID
+	}
+	assert.ElementsMatch(t, []interface{}{requested.ID, packed.ID}, ids)
+
+	// A single status keeps matching by equality
+	orders, total, err = repo.GetAllOrders(1, 10, map[string]interface{}{"order_status": string(order.OrderPacked)})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, orders, 1) {
+		assert.Equal(t, packed.ID, orders[0].ID)
+	}
+}
+
+// TestGetShipments tests the shipment listing filtered by carrier and shipment date
+func TestGetShipments(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := repositories.NewOrderRepository(db)
+
+	ghnOrder := seedOrder(t, db, order.PaymentCash, order.OrderDelivering, 100)
+	ghtkOrder := seedOrder(t, db, order.PaymentCash, order.OrderDelivering, 200)
+	oldOrder := seedOrder(t, db, order.PaymentCash, order.OrderDelivered, 300)
+	seedOrder(t, db, order.PaymentCash, order.OrderShipmentRequested, 400)
+
+	ghn := &order.Shipment{OrderID: ghnOrder.ID, Carrier: "GHN", TrackingNumber: "GHN001"}
+	ghtk := &order.Shipment{OrderID: ghtkOrder.ID, Carrier: "GHTK"}
+	old := &order.Shipment{OrderID: oldOrder.ID, Carrier: "GHN", TrackingNumber: "GHN000"}
+	for _, s := range []*order.Shipment{ghn, ghtk, old} {
+		if err := db.Create(s).Error; err != nil {
+			t.Fatalf("failed to seed shipment: %v", err)
+		}
+	}
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour)
+	if err := db.Model(old).Update("created_at", lastWeek).Error; err != nil {
+		t.Fatalf("failed to backdate shipment: %v", err)
+	}
+
+	// Orders without a shipment are not listed
+	rows, total, err := repo.GetShipments(1, 10, map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Len(t, rows, 3)
+
+	rows, total, err = repo.GetShipments(1, 10, map[string]interface{}{"carrier": "ghn"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	if assert.Len(t, rows, 2) {
+		// Newest shipment first, with the order summary joined in
+		assert.Equal(t, ghn.ID, rows[0].ID)
+		assert.Equal(t, ghnOrder.ID, rows[0].OrderID)
+		assert.Equal(t, order.OrderDelivering, rows[0].OrderStatus)
+		assert.Equal(t, "John Doe", rows[0].CustomerName)
+		assert.Equal(t, old.ID, rows[1].ID)
+	}
+
+	// The date range applies to the shipment creation time
+	from := time.Now().Add(-24 * time.Hour)
+	rows, total, err = repo.GetShipments(1, 10, map[string]interface{}{"carrier": "GHN", "from_date": from})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, rows, 1) {
+		assert.Equal(t, ghn.ID, rows[0].ID)
+	}
+
+	rows, total, err = repo.GetShipments(1, 10, map[string]interface{}{"to_date": from})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, rows, 1) {
+		assert.Equal(t, old.ID, rows[0].ID)
+	}
+
+	rows, total, err = repo.GetShipments(1, 10, map[string]interface{}{"has_tracking": false})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, rows, 1) {
+		assert.Equal(t, ghtk.ID, rows[0].ID)
+	}
+}
+
+// TestOrderRepositoryWithCanceledContext tests that queries run with a canceled context are aborted
+func TestOrderRepositoryWithCanceledContext(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := repositories.NewOrderRepository(db)
+
+	o := seedOrder(t, db, order.PaymentCash, order.OrderShipmentRequested, 100)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.WithContext(ctx).GetOrderByID(o.ID)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, _, err = repo.WithContext(ctx).GetAllOrders(1, 10, map[string]interface{}{})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// The original repository is not bound to the canceled context
+	found, err := repo.GetOrderByID(o.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, o.ID, found.ID)
+}
+
+// TestGetAllOrdersByInventory tests that only orders holding the inventory variant are listed, once each
+func TestGetAllOrdersByInventory(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := repositories.NewOrderRepository(db)
+
+	inventoryID := uuid.New()
+	otherInventoryID := uuid.New()
+
+	single := seedOrder(t, db, order.PaymentCash, order.OrderShipmentRequested, 100)
+	twice := seedOrder(t, db, order.PaymentCash, order.OrderPacked, 200)
+	other := seedOrder(t, db, order.PaymentCash, order.OrderPacked, 300)
+	removed := seedOrder(t, db, order.PaymentCash, order.OrderPacked, 400)
+
+	items := []*order.OrderItem{
+		{OrderID: single.ID, InventoryID: inventoryID, Quantity: 1, PriceAtOrder: 100},
+		{OrderID: twice.ID, InventoryID: inventoryID, Quantity: 1, PriceAtOrder: 100},
+		{OrderID: twice.ID, InventoryID: inventoryID, Quantity: 1, PriceAtOrder: 100},
+		{OrderID: other.ID, InventoryID: otherInventoryID, Quantity: 3, PriceAtOrder: 100},
+		{OrderID: removed.ID, InventoryID: inventoryID, Quantity: 4, PriceAtOrder: 100},
+	}
+	for _, item := range items {
+		if err := db.Create(item).Error; err != nil {
+			t.Fatalf("failed to seed order item: %v", err)
+		}
+	}
+	// A deleted item no longer ties its order to the variant
+	if err := db.Delete(items[4]).Error; err != nil {
+		t.Fatalf("failed to delete order item: %v", err)
+	}
+
+	orders, total, err := repo.GetAllOrders(1, 10, map[string]interface{}{"inventory_id": inventoryID})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	ids := make([]interface{}, len(orders))
+	for i, o := range orders {
+		ids[i] = o.ID
+	}
+	assert.ElementsMatch(t, []interface{}{single.ID, twice.ID}, ids)
+}
+
+// TestGetAllOrdersSearch tests that wildcard characters in the search term are matched literally
+func TestGetAllOrdersSearch(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := repositories.NewOrderRepository(db)
+
+	discounted := seedOrder(t, db, order.PaymentCash, order.OrderPacked, 100)
+	other := seedOrder(t, db, order.PaymentCash, order.OrderPacked, 200)
+	assert.NoError(t, db.Model(discounted).Update("customer_name", "Shop 50% Off").Error)
+	assert.NoError(t, db.Model(other).Update("customer_name", "Shop 50 Off").Error)
+
+	orders, total, err := repo.GetAllOrders(1, 10, map[string]interface{}{"search": "50%"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, orders, 1) {
+		assert.Equal(t, discounted.ID, orders[0].ID)
+	}
+
+	_, total, err = repo.GetAllOrders(1, 10, map[string]interface{}{"search": "shop_50"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+}
@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models/account"
+	"gorm.io/gorm"
+)
+
+// PasswordResetRepository handles database operations for password reset tokens
+type PasswordResetRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordResetRepository creates a new instance of PasswordResetRepository
+func NewPasswordResetRepository(db *gorm.DB) *PasswordResetRepository {
+	return &PasswordResetRepository{
+		db: db,
+	}
+}
+
+// CreatePasswordResetToken creates a new password reset token
+func (r *PasswordResetRepository) CreatePasswordResetToken(token *account.PasswordResetToken) error {
+	return r.db.Create(token).Error
+}
+
+// GetPasswordResetTokenByHash retrieves a password reset token by the hash of the token
+func (r *PasswordResetRepository) GetPasswordResetTokenByHash(hash string) (*account.PasswordResetToken, error) {
+	var token account.PasswordResetToken
+	err := r.db.Where("token_hash = ?", hash).First(&token).Error
+	return &token, err
+}
+
+// ConsumePasswordResetToken marks a password reset token as used unless it already is. The check
+// and the update are a single conditional update, so a token can only be consumed once even by
+// concurrent requests; consumed is false when the token had already been used.
+func (r *PasswordResetRepository) ConsumePasswordResetToken(id uuid.UUID, usedAt time.Time) (consumed bool, err error) {
+	result := r.db.Model(&account.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", usedAt)
+	return result.RowsAffected == 1, result.Error
+}
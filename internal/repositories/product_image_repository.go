@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"context"
+
 	"github.com/google/uuid"
 	"github.com/ybds/internal/models/product"
 	"gorm.io/gorm"
@@ -18,6 +20,14 @@ func NewProductImageRepository(db *gorm.DB) *ProductImageRepository {
 	}
 }
 
+// WithContext returns a copy of the repository whose queries run with the given context,
+// so they are aborted when the context is canceled or its deadline passes
+func (r *ProductImageRepository) WithContext(ctx context.Context) *ProductImageRepository {
+	return &ProductImageRepository{
+		db: r.db.WithContext(ctx),
+	}
+}
+
 // GetImagesByProductID retrieves all images for a product
 func (r *ProductImageRepository) GetImagesByProductID(productID uuid.UUID) ([]product.ProductImage, error) {
 	// Check if product exists and is not deleted
@@ -1,11 +1,13 @@
 package repositories
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/ybds/internal/models/product"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ProductRepository handles database operations for products
@@ -20,6 +22,14 @@ func NewProductRepository(db *gorm.DB) *ProductRepository {
 	}
 }
 
+// WithContext returns a copy of the repository whose queries run with the given context,
+// so they are aborted when the context is canceled or its deadline passes
+func (r *ProductRepository) WithContext(ctx context.Context) *ProductRepository {
+	return &ProductRepository{
+		db: r.db.WithContext(ctx),
+	}
+}
+
 // GetProductByID retrieves a product by ID with all relations
 func (r *ProductRepository) GetProductByID(id uuid.UUID) (*product.Product, error) {
 	var p product.Product
@@ -47,17 +57,47 @@ func (r *ProductRepository) GetAllProducts(page, pageSize int, filters map[strin
 	var products []product.Product
 	var total int64
 
-	query := r.db.Model(&product.Product{})
+	// Deleted products are only listed when asked for
+	db := r.db
+	if filters["include_deleted"] == true {
+		db = db.Unscoped()
+	}
+	query := db.Model(&product.Product{})
 
 	// Apply filters
 	for key, value := range filters {
 		switch key {
+		case "search":
+			// Full-text match on name, SKU, category and description, or a partial SKU match
+			term := value.(string)
+			query = query.Where("(("+ProductSearchVector+") @@ plainto_tsquery('simple', ?) OR sku % ? OR sku ILIKE ?)",
+				term, term, containsPattern(term))
 		case "name":
 			query = query.Where("name LIKE ?", "%"+value.(string)+"%")
 		case "category":
 			query = query.Where("category = ?", value)
 		case "sku":
 			query = query.Where("sku LIKE ?", "%"+value.(string)+"%")
+		case "from_date":
+			query = query.Where("products.created_at >= ?", value)
+		case "to_date":
+			query = query.Where("products.created_at <= ?", value)
+		case "missing":
+			// Products that cannot be ordered because they lack a current price or any stock
+			switch value {
+			case "price":
+				now := time.Now()
+				query = query.Where(`NOT EXISTS (
+					SELECT 1 FROM prices
+					WHERE prices.product_id = products.id AND prices.deleted_at IS NULL
+					AND prices.start_date <= ? AND (prices.end_date IS NULL OR prices.end_date > ?))`,
+					now, now)
+			case "inventory":
+				query = query.Where(`NOT EXISTS (
+					SELECT 1 FROM inventory
+					WHERE inventory.product_id = products.id AND inventory.deleted_at IS NULL
+					AND inventory.quantity > 0)`)
+			}
 		}
 	}
 
@@ -66,6 +106,17 @@ func (r *ProductRepository) GetAllProducts(page, pageSize int, filters map[strin
 		return nil, 0, err
 	}
 
+	// Apply sorting; best sellers first when sorting by popularity, otherwise search results by
+	// relevance with SKU similarity ranking partial SKU matches
+	if filters["sort"] == "popular" {
+		query = query.Order("sold_count DESC, name ASC")
+	} else if term, ok := filters["search"].(string); ok {
+		query = query.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "ts_rank(" + ProductSearchVector + ", plainto_tsquery('simple', ?)) DESC, similarity(sku, ?) DESC, name ASC",
+			Vars: []interface{}{term, term},
+		}})
+	}
+
 	// Get paginated records
 	offset := (page - 1) * pageSize
 	err := query.Offset(offset).Limit(pageSize).
@@ -87,11 +138,92 @@ func (r *ProductRepository) UpdateProduct(p *product.Product) error {
 	return r.db.Save(p).Error
 }
 
-// DeleteProduct deletes a product by ID
+// DeleteProduct soft-deletes a product by ID, so that past orders and reports can still refer to it
 func (r *ProductRepository) DeleteProduct(id uuid.UUID) error {
 	return r.db.Delete(&product.Product{}, id).Error
 }
 
+// RestoreProduct restores a soft-deleted product. gorm.ErrRecordNotFound is returned when there is
+// no deleted product with the ID.
+func (r *ProductRepository) RestoreProduct(id uuid.UUID) error {
+	result := r.db.Unscoped().Model(&product.Product{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// AdjustSoldCount adds delta to the sold count of a product. The count never drops below zero.
+func (r *ProductRepository) AdjustSoldCount(productID uuid.UUID, delta int) error {
+	return r.db.Model(&product.Product{}).
+		Where("id = ?", productID).
+		UpdateColumn("sold_count", gorm.Expr("GREATEST(sold_count + ?, 0)", delta)).Error
+}
+
+// GetFeaturedProducts retrieves all featured products in their featured order
+func (r *ProductRepository) GetFeaturedProducts() ([]product.Product, error) {
+	var products []product.Product
+	err := r.db.Where("is_featured = ?", true).
+		Order("featured_order ASC, name ASC").
+		Preload("Inventory").
+		Preload("Prices").
+		Preload("Images").
+		Find(&products).Error
+	return products, err
+}
+
+// SetFeatured adds a product to the end of the featured list or removes it from the list.
+// Featuring an already featured product keeps its position.
+func (r *ProductRepository) SetFeatured(productID uuid.UUID, featured bool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var p product.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "is_featured").
+			First(&p, "id = ?", productID).Error; err != nil {
+			return err
+		}
+
+		if !featured {
+			return tx.Model(&product.Product{}).Where("id = ?", productID).
+				Updates(map[string]interface{}{"is_featured": false, "featured_order": 0}).Error
+		}
+		if p.IsFeatured {
+			return nil
+		}
+
+		var lastOrder int
+		if err := tx.Model(&product.Product{}).
+			Where("is_featured = ?", true).
+			Select("COALESCE(MAX(featured_order), 0)").
+			Scan(&lastOrder).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&product.Product{}).Where("id = ?", productID).
+			Updates(map[string]interface{}{"is_featured": true, "featured_order": lastOrder + 1}).Error
+	})
+}
+
+// ReorderFeaturedProducts sets the featured order of the given featured products to their
+// position in productIDs
+func (r *ProductRepository) ReorderFeaturedProducts(productIDs []uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i, productID := range productIDs {
+			if err := tx.Model(&product.Product{}).
+				Where("id = ? AND is_featured = ?", productID, true).
+				Update("featured_order", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // GetInventoryByID retrieves an inventory by ID
 func (r *ProductRepository) GetInventoryByID(id uuid.UUID) (*product.Inventory, error) {
 	var inventory product.Inventory
@@ -134,6 +266,140 @@ func (r *ProductRepository) DeleteInventory(id uuid.UUID) error {
 	return r.db.Delete(&product.Inventory{}, id).Error
 }
 
+// GetVariantOptions retrieves the variant options of a product, ordered by attribute and value
+func (r *ProductRepository) GetVariantOptions(productID uuid.UUID) ([]product.VariantOption, error) {
+	var options []product.VariantOption
+	err := r.db.Where("product_id = ?", productID).
+		Order("attribute ASC, value ASC").
+		Find(&options).Error
+	return options, err
+}
+
+// GetVariantOptionByID retrieves a variant option by its ID
+func (r *ProductRepository) GetVariantOptionByID(id uuid.UUID) (*product.VariantOption, error) {
+	var option product.VariantOption
+	if err := r.db.First(&option, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &option, nil
+}
+
+// CreateVariantOption creates a new variant option
+func (r *ProductRepository) CreateVariantOption(option *product.VariantOption) error {
+	return r.db.Create(option).Error
+}
+
+// DeleteVariantOption deletes a variant option by ID
+func (r *ProductRepository) DeleteVariantOption(id uuid.UUID) error {
+	return r.db.Delete(&product.VariantOption{}, id).Error
+}
+
+// CountInventoriesWithOption counts the non-deleted inventories using a variant option
+func (r *ProductRepository) CountInventoriesWithOption(optionID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&product.Inventory{}).
+		Where("size_option_id = ? OR color_option_id = ?", optionID, optionID).
+		Count(&count).Error
+	return count, err
+}
+
+// LowStockInventory represents an inventory at or below its low stock threshold joined with its product
+type LowStockInventory struct {
+	InventoryID       uuid.UUID
+	ProductID         uuid.UUID
+	ProductName       string
+	SKU               string
+	Category          string
+	Size              string
+	Color             string
+	Location          string
+	Quantity          int
+	LowStockThreshold int
+}
+
+// GetLowStockInventories retrieves all inventories whose quantity is at or below their low stock threshold
+func (r *ProductRepository) GetLowStockInventories() ([]LowStockInventory, error) {
+	var items []LowStockInventory
+	err := r.db.Table("inventory").
+		Select(`inventory.id AS inventory_id, inventory.product_id, products.name AS product_name,
+			products.sku, products.category, inventory.size, inventory.color, inventory.location,
+			inventory.quantity, inventory.low_stock_threshold`).
+		Joins("JOIN products ON products.id = inventory.product_id").
+		Where("inventory.quantity <= inventory.low_stock_threshold").
+		Where("inventory.deleted_at IS NULL AND products.deleted_at IS NULL").
+		Order("inventory.quantity ASC, products.name ASC").
+		Scan(&items).Error
+	return items, err
+}
+
+// LowStockDay represents the number of inventories that were low on stock at the end of a day
+type LowStockDay struct {
+	Day           time.Time
+	LowStockCount int64
+}
+
+// GetLowStockCountsByDay counts, for every day from fromDay to toDay inclusive, the inventories whose
+// quantity at the end of that day was at or below their low stock threshold. The quantity at the end
+// of a day is rebuilt from the current quantity by undoing the inventory transactions recorded after it.
+// Both bounds must be the start of a day; days without low stock are returned with a zero count.
+func (r *ProductRepository) GetLowStockCountsByDay(fromDay, toDay time.Time) ([]LowStockDay, error) {
+	var rows []LowStockDay
+	err := r.db.Raw(`
+		SELECT days.day AS day, COUNT(inventory.id) AS low_stock_count
+		FROM generate_series(CAST(? AS timestamptz), CAST(? AS timestamptz), interval '1 day') AS days(day)
+		LEFT JOIN inventory ON inventory.deleted_at IS NULL
+			AND inventory.created_at < days.day + interval '1 day'
+			AND inventory.product_id IN (SELECT id FROM products WHERE deleted_at IS NULL)
+			AND inventory.quantity - COALESCE((
+				SELECT SUM(inventory_transactions.quantity)
+				FROM inventory_transactions
+				WHERE inventory_transactions.inventory_id = inventory.id
+					AND inventory_transactions.deleted_at IS NULL
+					AND inventory_transactions.created_at >= days.day + interval '1 day'
+			), 0) <= inventory.low_stock_threshold
+		GROUP BY days.day
+		ORDER BY days.day`, fromDay, toDay).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// CategoryInventoryValue represents the value of the on-hand stock of a product category
+type CategoryInventoryValue struct {
+	Category      string
+	Quantity      int64
+	TotalValue    float64
+	ExcludedCount int64
+}
+
+// GetInventoryValueByCategory sums quantity * price of every inventory, grouped by product category,
+// using the price valid at the given time (latest start date first, as in GetPriceAt). Inventories
+// whose product has no valid price are left out of the value and counted in ExcludedCount.
+func (r *ProductRepository) GetInventoryValueByCategory(at time.Time) ([]CategoryInventoryValue, error) {
+	var rows []CategoryInventoryValue
+	err := r.db.Raw(`
+		SELECT products.category AS category,
+			COALESCE(SUM(inventory.quantity) FILTER (WHERE current_price.price IS NOT NULL), 0) AS quantity,
+			COALESCE(SUM(inventory.quantity * current_price.price), 0) AS total_value,
+			COUNT(*) FILTER (WHERE current_price.price IS NULL) AS excluded_count
+		FROM inventory
+		JOIN products ON products.id = inventory.product_id
+		LEFT JOIN LATERAL (
+			SELECT prices.price
+			FROM prices
+			WHERE prices.product_id = products.id
+				AND prices.deleted_at IS NULL
+				AND prices.start_date <= ?
+				AND (prices.end_date IS NULL OR prices.end_date > ?)
+			ORDER BY prices.start_date DESC, prices.created_at DESC, prices.id DESC
+			LIMIT 1
+		) AS current_price ON true
+		WHERE inventory.deleted_at IS NULL AND products.deleted_at IS NULL
+		GROUP BY products.category
+		ORDER BY total_value DESC, products.category`, at, at).
+		Scan(&rows).Error
+	return rows, err
+}
+
 // GetPriceByID retrieves a price by ID
 func (r *ProductRepository) GetPriceByID(id uuid.UUID) (*product.Price, error) {
 	var price product.Price
@@ -143,6 +409,28 @@ func (r *ProductRepository) GetPriceByID(id uuid.UUID) (*product.Price, error) {
 	return &price, err
 }
 
+// GetPricesAt retrieves every price whose validity window [start_date, end_date) contains the given time,
+// ordered by start date, creation time and ID, newest first
+func (r *ProductRepository) GetPricesAt(productID uuid.UUID, at time.Time) ([]product.Price, error) {
+	var prices []product.Price
+
+	// Check if product exists and is not deleted
+	var count int64
+	if err := r.db.Model(&product.Product{}).Where("id = ? AND deleted_at IS NULL", productID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	err := r.db.Where("product_id = ? AND start_date <= ? AND (end_date IS NULL OR end_date > ?)",
+		productID, at, at).
+		Order("start_date DESC, created_at DESC, id DESC").
+		Find(&prices).Error
+
+	return prices, err
+}
+
 // GetPricesByProductID retrieves all prices for a product
 func (r *ProductRepository) GetPricesByProductID(productID uuid.UUID) ([]product.Price, error) {
 	var prices []product.Price
@@ -162,8 +450,14 @@ func (r *ProductRepository) GetPricesByProductID(productID uuid.UUID) ([]product
 
 // GetCurrentPrice retrieves the current valid price for an inventory
 func (r *ProductRepository) GetCurrentPrice(productID uuid.UUID) (*product.Price, error) {
+	return r.GetPriceAt(productID, time.Now())
+}
+
+// GetPriceAt retrieves the price whose validity window [start_date, end_date) contains the given time.
+// When several windows overlap, the price with the latest start date wins; creation time and ID
+// break any remaining ties so the result is deterministic.
+func (r *ProductRepository) GetPriceAt(productID uuid.UUID, at time.Time) (*product.Price, error) {
 	var price product.Price
-	now := time.Now()
 
 	// Check if product exists and is not deleted
 	var count int64
@@ -175,8 +469,8 @@ func (r *ProductRepository) GetCurrentPrice(productID uuid.UUID) (*product.Price
 	}
 
 	err := r.db.Where("product_id = ? AND start_date <= ? AND (end_date IS NULL OR end_date > ?)",
-		productID, now, now).
-		Order("start_date DESC, created_at DESC").
+		productID, at, at).
+		Order("start_date DESC, created_at DESC, id DESC").
 		First(&price).Error
 
 	return &price, err
@@ -197,69 +491,325 @@ func (r *ProductRepository) DeletePrice(id uuid.UUID) error {
 	return r.db.Delete(&product.Price{}, id).Error
 }
 
+// GetPriceTiers retrieves the quantity breaks of a price, ordered by minimum quantity
+func (r *ProductRepository) GetPriceTiers(priceID uuid.UUID) ([]product.PriceTier, error) {
+	var tiers []product.PriceTier
+	err := r.db.Where("price_id = ?", priceID).
+		Order("min_quantity ASC").
+		Find(&tiers).Error
+	return tiers, err
+}
+
+// GetPriceTierByID retrieves a price tier by its ID
+func (r *ProductRepository) GetPriceTierByID(id uuid.UUID) (*product.PriceTier, error) {
+	var tier product.PriceTier
+	if err := r.db.First(&tier, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &tier, nil
+}
+
+// CreatePriceTier creates a new price tier
+func (r *ProductRepository) CreatePriceTier(tier *product.PriceTier) error {
+	return r.db.Create(tier).Error
+}
+
+// UpdatePriceTier updates an existing price tier
+func (r *ProductRepository) UpdatePriceTier(tier *product.PriceTier) error {
+	return r.db.Save(tier).Error
+}
+
+// DeletePriceTier deletes a price tier by ID
+func (r *ProductRepository) DeletePriceTier(id uuid.UUID) error {
+	return r.db.Delete(&product.PriceTier{}, id).Error
+}
+
 // CreateInventoryTransaction creates a new inventory transaction
 func (r *ProductRepository) CreateInventoryTransaction(transaction *product.InventoryTransaction) error {
 	return r.db.Create(transaction).Error
 }
 
-// GetInventoryTransactionsByInventoryID retrieves all transactions for an inventory
-func (r *ProductRepository) GetInventoryTransactionsByInventoryID(inventoryID uuid.UUID) ([]product.InventoryTransaction, error) {
-	// Check if inventory exists and is not deleted
+// inventoryExists reports whether an inventory exists and belongs to a product that is not deleted
+func inventoryExists(db *gorm.DB, inventoryID uuid.UUID) error {
 	var count int64
-	if err := r.db.Model(&product.Inventory{}).
-		Joins("JOIN products ON inventories.product_id = products.id").
-		Where("inventories.id = ? AND products.deleted_at IS NULL", inventoryID).
+	if err := db.Model(&product.Inventory{}).
+		Joins("JOIN products ON inventory.product_id = products.id").
+		Where("inventory.id = ? AND products.deleted_at IS NULL", inventoryID).
 		Count(&count).Error; err != nil {
-		return nil, err
+		return err
 	}
 	if count == 0 {
-		return nil, gorm.ErrRecordNotFound
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetInventoryTransactionsByInventoryID retrieves all transactions for an inventory, newest first
+func (r *ProductRepository) GetInventoryTransactionsByInventoryID(inventoryID uuid.UUID) ([]product.InventoryTransaction, error) {
+	// Check if inventory exists and is not deleted
+	if err := inventoryExists(r.db, inventoryID); err != nil {
+		return nil, err
 	}
 
 	var transactions []product.InventoryTransaction
-	err := r.db.Where("inventory_id = ?", inventoryID).Find(&transactions).Error
+	err := r.db.Where("inventory_id = ?", inventoryID).
+		Order("created_at DESC").
+		Find(&transactions).Error
 	return transactions, err
 }
 
-// UpdateInventoryQuantity updates the quantity of an inventory and creates a transaction
+// GetRestockTransactions retrieves the stock increases of an inventory that were not caused by
+// orders, i.e. inbound stock and positive adjustments, newest first
+func (r *ProductRepository) GetRestockTransactions(inventoryID uuid.UUID) ([]product.InventoryTransaction, error) {
+	// Check if inventory exists and is not deleted
+	if err := inventoryExists(r.db, inventoryID); err != nil {
+		return nil, err
+	}
+
+	var transactions []product.InventoryTransaction
+	err := r.db.Where("inventory_id = ? AND quantity > 0", inventoryID).
+		Where("type IN ?", []product.TransactionType{product.TransactionInbound, product.TransactionAdjustment}).
+		Order("created_at DESC").
+		Find(&transactions).Error
+	return transactions, err
+}
+
+// UpdateInventoryQuantity adds quantity (which may be negative) to the stock of an inventory and
+// records the change as an inventory transaction. The inventory row is locked until the change
+// is committed, so concurrent updates are applied one after another.
 func (r *ProductRepository) UpdateInventoryQuantity(inventoryID uuid.UUID, quantity int, txType product.TransactionType, reason product.TransactionReason, referenceID *uuid.UUID, referenceType string, notes string) error {
-	// Start a transaction
-	tx := r.db.Begin()
-	if tx.Error != nil {
-		return tx.Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Check if inventory exists and is not deleted
+		if err := inventoryExists(tx, inventoryID); err != nil {
+			return err
+		}
+
+		// Lock the inventory and update its quantity
+		var inventory product.Inventory
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&inventory, "id = ?", inventoryID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&inventory).UpdateColumn("quantity", gorm.Expr("quantity + ?", quantity)).Error; err != nil {
+			return err
+		}
+
+		// Create a transaction record
+		balance := inventory.Quantity + quantity
+		transaction := product.InventoryTransaction{
+			InventoryID:   inventoryID,
+			Quantity:      quantity,
+			Type:          txType,
+			Reason:        reason,
+			ReferenceID:   referenceID,
+			ReferenceType: referenceType,
+			Notes:         notes,
+			Balance:       &balance,
+		}
+		return tx.Create(&transaction).Error
+	})
+}
+
+// ReserveInventoryQuantity takes quantity units from the stock of an inventory for the referenced
+// order and records the reservation as an inventory transaction. The stock is checked and reduced
+// under a row lock, so concurrent reservations can never take more than the inventory holds;
+// reserved is false and nothing changes when less than quantity units are left. The returned
+// inventory holds the quantity before the reservation.
+func (r *ProductRepository) ReserveInventoryQuantity(inventoryID uuid.UUID, quantity int, referenceID *uuid.UUID, referenceType string) (inventory *product.Inventory, reserved bool, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		// Check if inventory exists and is not deleted
+		if err := inventoryExists(tx, inventoryID); err != nil {
+			return err
+		}
+
+		// Lock the inventory before checking its quantity
+		var locked product.Inventory
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&locked, "id = ?", inventoryID).Error; err != nil {
+			return err
+		}
+		inventory = &locked
+		if locked.Quantity < quantity {
+			return nil
+		}
+
+		if err := tx.Model(&locked).UpdateColumn("quantity", gorm.Expr("quantity - ?", quantity)).Error; err != nil {
+			return err
+		}
+		reserved = true
+
+		balance := locked.Quantity - quantity
+		transaction := product.InventoryTransaction{
+			InventoryID:   inventoryID,
+			Quantity:      -quantity,
+			Type:          product.TransactionReservation,
+			Reason:        product.ReasonReservation,
+			ReferenceID:   referenceID,
+			ReferenceType: referenceType,
+			Balance:       &balance,
+		}
+		return tx.Create(&transaction).Error
+	})
+	if err != nil {
+		return nil, false, err
 	}
+	return inventory, reserved, nil
+}
 
-	// Get the inventory and check if associated product is not deleted
-	var inventory product.Inventory
-	if err := tx.Joins("JOIN products ON inventories.product_id = products.id").
-		Where("inventories.id = ? AND products.deleted_at IS NULL", inventoryID).
-		First(&inventory).Error; err != nil {
-		tx.Rollback()
-		return err
+// TransferInventoryQuantity moves the quantity of the transfer from its source to its destination
+// inventory, records the transfer and an inventory transaction on both sides. Both inventories are
+// locked, in a fixed order so concurrent transfers cannot deadlock; transferred is false and nothing
+// changes when the source holds less than the quantity. The returned inventories hold the quantities
+// before the transfer.
+func (r *ProductRepository) TransferInventoryQuantity(transfer *product.InventoryTransfer) (from, to *product.Inventory, transferred bool, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		// Check if both inventories exist and are not deleted
+		for _, id := range []uuid.UUID{transfer.FromInventoryID, transfer.ToInventoryID} {
+			if err := inventoryExists(tx, id); err != nil {
+				return err
+			}
+		}
+
+		var locked []product.Inventory
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", []uuid.UUID{transfer.FromInventoryID, transfer.ToInventoryID}).
+			Order("id").
+			Find(&locked).Error; err != nil {
+			return err
+		}
+		for i := range locked {
+			switch locked[i].ID {
+			case transfer.FromInventoryID:
+				from = &locked[i]
+			case transfer.ToInventoryID:
+				to = &locked[i]
+			}
+		}
+		if from == nil || to == nil {
+			return gorm.ErrRecordNotFound
+		}
+		if from.Quantity < transfer.Quantity {
+			return nil
+		}
+
+		if err := tx.Model(from).UpdateColumn("quantity", gorm.Expr("quantity - ?", transfer.Quantity)).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(to).UpdateColumn("quantity", gorm.Expr("quantity + ?", transfer.Quantity)).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(transfer).Error; err != nil {
+			return err
+		}
+
+		fromBalance, toBalance := from.Quantity-transfer.Quantity, to.Quantity+transfer.Quantity
+		transactions := []product.InventoryTransaction{
+			{InventoryID: from.ID, Quantity: -transfer.Quantity, Balance: &fromBalance},
+			{InventoryID: to.ID, Quantity: transfer.Quantity, Balance: &toBalance},
+		}
+		for i := range transactions {
+			transactions[i].Type = product.TransactionTransfer
+			transactions[i].Reason = product.ReasonRelocation
+			transactions[i].ReferenceID = &transfer.ID
+			transactions[i].ReferenceType = "transfer"
+			transactions[i].Notes = transfer.Notes
+			transactions[i].CreatedBy = transfer.CreatedBy
+		}
+		if err := tx.Create(&transactions).Error; err != nil {
+			return err
+		}
+
+		transferred = true
+		return nil
+	})
+	if err != nil {
+		return nil, nil, false, err
 	}
+	return from, to, transferred, nil
+}
 
-	// Update the inventory quantity
-	inventory.Quantity += quantity
-	if err := tx.Save(&inventory).Error; err != nil {
-		tx.Rollback()
-		return err
+// SearchProducts finds products whose name or SKU contains the term, ordered by name
+func (r *ProductRepository) SearchProducts(term string, limit int) ([]product.Product, error) {
+	var products []product.Product
+	pattern := containsPattern(term)
+
+	err := r.db.Where("name ILIKE ? OR sku ILIKE ?", pattern, pattern).
+		Order("name ASC").
+		Limit(limit).
+		Find(&products).Error
+
+	return products, err
+}
+
+// GetInventoriesByIDs retrieves the inventories with the given IDs, including deleted ones, so that
+// variants that have since been removed can still be traced back to their product
+func (r *ProductRepository) GetInventoriesByIDs(ids []uuid.UUID) ([]product.Inventory, error) {
+	var inventories []product.Inventory
+	if len(ids) == 0 {
+		return inventories, nil
+	}
+
+	err := r.db.Unscoped().Where("id IN ?", ids).Find(&inventories).Error
+	return inventories, err
+}
+
+// GetProductIncludingDeleted retrieves a product by ID even when it has been deleted, so that
+// past orders can still show what was ordered
+func (r *ProductRepository) GetProductIncludingDeleted(id uuid.UUID) (*product.Product, error) {
+	var p product.Product
+	err := r.db.Unscoped().Where("id = ?", id).
+		Preload("Images").
+		First(&p).Error
+	return &p, err
+}
+
+// GetInventoryIncludingDeleted retrieves an inventory by ID even when it or its product has been
+// deleted, so that past orders can still show what was ordered
+func (r *ProductRepository) GetInventoryIncludingDeleted(id uuid.UUID) (*product.Inventory, error) {
+	var inventory product.Inventory
+	err := r.db.Unscoped().Where("id = ?", id).First(&inventory).Error
+	return &inventory, err
+}
+
+// GetProductsByIDsIncludingDeleted retrieves the products with the given IDs together with their
+// images, including deleted ones, so that past orders and reports can still name them
+func (r *ProductRepository) GetProductsByIDsIncludingDeleted(ids []uuid.UUID) ([]product.Product, error) {
+	var products []product.Product
+	if len(ids) == 0 {
+		return products, nil
 	}
 
-	// Create a transaction record
-	transaction := product.InventoryTransaction{
-		InventoryID:   inventoryID,
-		Quantity:      quantity,
-		Type:          txType,
-		Reason:        reason,
-		ReferenceID:   referenceID,
-		ReferenceType: referenceType,
-		Notes:         notes,
+	err := r.db.Unscoped().Where("id IN ?", ids).
+		Preload("Images").
+		Find(&products).Error
+	return products, err
+}
+
+// GetProductsByIDs retrieves the non-deleted products with the given IDs together with their images
+func (r *ProductRepository) GetProductsByIDs(ids []uuid.UUID) ([]product.Product, error) {
+	var products []product.Product
+	if len(ids) == 0 {
+		return products, nil
 	}
-	if err := tx.Create(&transaction).Error; err != nil {
-		tx.Rollback()
-		return err
+
+	err := r.db.Where("id IN ?", ids).
+		Preload("Images").
+		Find(&products).Error
+	return products, err
+}
+
+// GetPricesAtByProductIDs retrieves the prices of the given products whose validity window contains
+// the given time, ordered per product like GetPricesAt
+func (r *ProductRepository) GetPricesAtByProductIDs(productIDs []uuid.UUID, at time.Time) ([]product.Price, error) {
+	var prices []product.Price
+	if len(productIDs) == 0 {
+		return prices, nil
 	}
 
-	// Commit the transaction
-	return tx.Commit().Error
+	err := r.db.Where("product_id IN ? AND start_date <= ? AND (end_date IS NULL OR end_date > ?)",
+		productIDs, at, at).
+		Order("product_id, start_date DESC, created_at DESC, id DESC").
+		Find(&prices).Error
+
+	return prices, err
 }
@@ -0,0 +1,277 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/models/product"
+	"github.com/ybds/internal/repositories"
+	"github.com/ybds/internal/testutil"
+	"gorm.io/gorm"
+)
+
+// seedProduct creates a product with the given SKU
+func seedProduct(t *testing.T, db *gorm.DB, name, sku string) *product.Product {
+	t.Helper()
+
+	p := &product.Product{
+		Name:     name,
+		SKU:      sku,
+		Category: "Shirts",
+	}
+	if err := db.Create(p).Error; err != nil {
+		t.Fatalf("failed to seed product: %v", err)
+	}
+	return p
+}
+
+// seedInventory creates an inventory for a product with the given quantity and threshold
+func seedInventory(t *testing.T, db *gorm.DB, p *product.Product, size string, quantity, threshold int) *product.Inventory {
+	t.Helper()
+
+	inv := &product.Inventory{
+		ProductID:         p.ID,
+		Size:              size,
+		Color:             "Blue",
+		Quantity:          quantity,
+		LowStockThreshold: threshold,
+	}
+	if err := db.Create(inv).Error; err != nil {
+		t.Fatalf("failed to seed inventory: %v", err)
+	}
+	return inv
+}
+
+// TestGetLowStockInventories tests that only inventories at or below their threshold are returned
+func TestGetLowStockInventories(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := repositories.NewProductRepository(db)
+
+	p := seedProduct(t, db, "T-Shirt", "TS-001")
+	below := seedInventory(t, db, p, "S", 2, 5)
+	atThreshold := seedInventory(t, db, p, "M", 10, 10)
+	seedInventory(t, db, p, "L", 20, 5)
+
+	items, err := repo.GetLowStockInventories()
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+
+	// Lowest quantity first
+	assert.Equal(t, below.ID, items[0].InventoryID)
+	assert.Equal(t, "T-Shirt", items[0].ProductName)
+	assert.Equal(t, "TS-001", items[0].SKU)
+	assert.Equal(t, 2, items[0].Quantity)
+	assert.Equal(t, 5, items[0].LowStockThreshold)
+	assert.Equal(t, atThreshold.ID, items[1].InventoryID)
+}
+
+// seedPrice creates a price for a product valid in the given window
+func seedPrice(t *testing.T, db *gorm.DB, p *product.Product, amount float64, start time.Time, end *time.Time) *product.Price {
+	t.Helper()
+
+	price := &product.Price{
+		ProductID: p.ID,
+		Price:     amount,
+		Currency:  "VND",
+		StartDate: start,
+		EndDate:   end,
+	}
+	if err := db.Create(price).Error; err != nil {
+		t.Fatalf("failed to seed price: %v", err)
+	}
+	return price
+}
+
+// TestGetPriceAt tests that a scheduled price supersedes the current one once its start date passes
+func TestGetPriceAt(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := repositories.NewProductRepository(db)
+
+	p := seedProduct(t, db, "Jacket", "JK-001")
+	now := time.Now()
+	switchover := now.Add(24 * time.Hour)
+
+	current := seedPrice(t, db, p, 100000, now.Add(-24*time.Hour), &switchover)
+	scheduled := seedPrice(t, db, p, 120000, switchover, nil)
+
+	t.Run("CurrentPriceBeforeSwitchover", func(t *testing.T) {
+		price, err := repo.GetCurrentPrice(p.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, current.ID, price.ID)
+	})
+
+	t.Run("PriorEndDateIsExclusive", func(t *testing.T) {
+		price, err := repo.GetPriceAt(p.ID, switchover.Add(-time.Second))
+		assert.NoError(t, err)
+		assert.Equal(t, current.ID, price.ID)
+
+		price, err = repo.GetPriceAt(p.ID, switchover)
+		assert.NoError(t, err)
+		assert.Equal(t, scheduled.ID, price.ID)
+	})
+
+	t.Run("ScheduledPriceAfterSwitchover", func(t *testing.T) {
+		price, err := repo.GetPriceAt(p.ID, switchover.Add(time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, scheduled.ID, price.ID)
+		assert.Equal(t, 120000.0, price.Price)
+	})
+
+	t.Run("LatestStartDateWinsOnOverlap", func(t *testing.T) {
+		other := seedProduct(t, db, "Coat", "CT-001")
+		seedPrice(t, db, other, 50000, now.Add(-48*time.Hour), nil)
+		newer := seedPrice(t, db, other, 55000, now.Add(-time.Hour), nil)
+
+		price, err := repo.GetCurrentPrice(other.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, newer.ID, price.ID)
+	})
+
+	t.Run("NoPriceBeforeFirstStartDate", func(t *testing.T) {
+		_, err := repo.GetPriceAt(p.ID, now.Add(-48*time.Hour))
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+// TestProductRepositoryWithCanceledContext tests that queries run with a canceled context are aborted
+func TestProductRepositoryWithCanceledContext(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := repositories.NewProductRepository(db)
+
+	p := seedProduct(t, db, "Basic Tee", "TEE-001")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.WithContext(ctx).GetProductByID(p.ID)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, _, err = repo.WithContext(ctx).GetAllProducts(1, 10, map[string]interface{}{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// TestGetAllProductsMissing tests filtering products that lack a current price or any stock
+func TestGetAllProductsMissing(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := repositories.NewProductRepository(db)
+
+	now := time.Now()
+	complete := seedProduct(t, db, "Complete", "MIS-001")
+	seedInventory(t, db, complete, "M", 5, 1)
+
+	noPrice := seedProduct(t, db, "No Price", "MIS-002")
+	seedInventory(t, db, noPrice, "M", 5, 1)
+
+	expired := seedProduct(t, db, "Expired Price", "MIS-003")
+	seedInventory(t, db, expired, "M", 5, 1)
+
+	noInventory := seedProduct(t, db, "No Inventory", "MIS-004")
+	soldOut := seedProduct(t, db, "Sold Out", "MIS-005")
+	seedInventory(t, db, soldOut, "M", 0, 1)
+
+	expiredEnd := now.Add(-time.Hour)
+	seedPrice(t, db, complete, 100, now.Add(-24*time.Hour), nil)
+	seedPrice(t, db, expired, 100, now.Add(-24*time.Hour), &expiredEnd)
+	seedPrice(t, db, noInventory, 100, now.Add(-24*time.Hour), nil)
+	seedPrice(t, db, soldOut, 100, now.Add(-24*time.Hour), nil)
+
+	productIDs := func(products []product.Product) []uuid.UUID {
+		ids := make([]uuid.UUID, len(products))
+		for i, p := range products {
+			ids[i] = p.ID
+		}
+		return ids
+	}
+
+	products, total, err := repo.GetAllProducts(1, 10, map[string]interface{}{"missing": "price"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.ElementsMatch(t, []uuid.UUID{noPrice.ID, expired.ID}, productIDs(products))
+
+	products, total, err = repo.GetAllProducts(1, 10, map[string]interface{}{"missing": "inventory"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.ElementsMatch(t, []uuid.UUID{noInventory.ID, soldOut.ID}, productIDs(products))
+}
+
+// TestGetAllProductsCreatedBetween tests that the date range filter only returns products created in the window
+func TestGetAllProductsCreatedBetween(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := repositories.NewProductRepository(db)
+
+	now := time.Now()
+	before := seedProduct(t, db, "Before", "NEW-001")
+	inside := seedProduct(t, db, "Inside", "NEW-002")
+	insideOtherCategory := seedProduct(t, db, "Inside Other Category", "NEW-003")
+	after := seedProduct(t, db, "After", "NEW-004")
+	for p, createdAt := range map[*product.Product]time.Time{
+		before:              now.Add(-10 * 24 * time.Hour),
+		inside:              now.Add(-3 * 24 * time.Hour),
+		insideOtherCategory: now.Add(-2 * 24 * time.Hour),
+		after:               now.Add(24 * time.Hour),
+	} {
+		assert.NoError(t, db.Model(p).Update("created_at", createdAt).Error)
+	}
+	assert.NoError(t, db.Model(insideOtherCategory).Update("category", "Pants").Error)
+
+	filters := map[string]interface{}{
+		"from_date": now.Add(-7 * 24 * time.Hour),
+		"to_date":   now,
+	}
+	products, total, err := repo.GetAllProducts(1, 10, filters)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	ids := make([]uuid.UUID, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	assert.ElementsMatch(t, []uuid.UUID{inside.ID, insideOtherCategory.ID}, ids)
+
+	// The range composes with the other filters
+	filters["category"] = "Shirts"
+	products, total, err = repo.GetAllProducts(1, 10, filters)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, products, 1) {
+		assert.Equal(t, inside.ID, products[0].ID)
+	}
+}
+
+// TestGetAllProductsSearch tests that search results are ranked by relevance, match partial SKUs and
+// compose with the category filter
+func TestGetAllProductsSearch(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := repositories.NewProductRepository(db)
+
+	describedOnly := seedProduct(t, db, "Basic Tee", "SRCH-001")
+	assert.NoError(t, db.Model(describedOnly).Update("description", "Pairs well with a linen jacket").Error)
+	named := seedProduct(t, db, "Linen Jacket", "SRCH-002")
+	pants := seedProduct(t, db, "Linen Pants", "SRCH-003")
+	assert.NoError(t, db.Model(pants).Update("category", "Pants").Error)
+	seedProduct(t, db, "Denim Shorts", "OTHER-001")
+
+	products, total, err := repo.GetAllProducts(1, 10, map[string]interface{}{"search": "linen jacket"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	if assert.Len(t, products, 2) {
+		assert.Equal(t, named.ID, products[0].ID)
+		assert.Equal(t, describedOnly.ID, products[1].ID)
+	}
+
+	// A partial SKU matches without a full word
+	products, total, err = repo.GetAllProducts(1, 10, map[string]interface{}{"search": "SRCH-00"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Len(t, products, 3)
+
+	// Search composes with the category filter
+	products, total, err = repo.GetAllProducts(1, 10, map[string]interface{}{"search": "linen", "category": "Pants"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, products, 1) {
+		assert.Equal(t, pants.ID, products[0].ID)
+	}
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models/order"
+	"gorm.io/gorm"
+)
+
+// PromoRepository handles database operations for promo codes
+type PromoRepository struct {
+	db *gorm.DB
+}
+
+// NewPromoRepository creates a new instance of PromoRepository
+func NewPromoRepository(db *gorm.DB) *PromoRepository {
+	return &PromoRepository{
+		db: db,
+	}
+}
+
+// CreatePromoCode creates a new promo code
+func (r *PromoRepository) CreatePromoCode(promo *order.PromoCode) error {
+	return r.db.Create(promo).Error
+}
+
+// GetPromoCodeByID retrieves a promo code by ID
+func (r *PromoRepository) GetPromoCodeByID(id uuid.UUID) (*order.PromoCode, error) {
+	var promo order.PromoCode
+	err := r.db.Where("id = ?", id).First(&promo).Error
+	return &promo, err
+}
+
+// GetPromoCodeByCode retrieves a promo code by its code
+func (r *PromoRepository) GetPromoCodeByCode(code string) (*order.PromoCode, error) {
+	var promo order.PromoCode
+	err := r.db.Where("code = ?", code).First(&promo).Error
+	return &promo, err
+}
+
+// GetPromoCodes retrieves promo codes with pagination, newest first
+func (r *PromoRepository) GetPromoCodes(page, pageSize int) ([]order.PromoCode, int64, error) {
+	var promos []order.PromoCode
+	var total int64
+
+	query := r.db.Model(&order.PromoCode{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&promos).Error
+	return promos, total, err
+}
+
+// UpdatePromoCode updates an existing promo code. The used count is left alone so that
+// redemptions made concurrently with the update are not lost.
+func (r *PromoRepository) UpdatePromoCode(promo *order.PromoCode) error {
+	return r.db.Model(promo).Select("code", "type", "value", "start_date", "end_date", "max_uses",
+		"min_order_amount", "description", "updated_by").Updates(promo).Error
+}
+
+// DeletePromoCode deletes a promo code by ID
+func (r *PromoRepository) DeletePromoCode(id uuid.UUID) error {
+	return r.db.Delete(&order.PromoCode{}, id).Error
+}
+
+// RedeemPromoCode counts one use of a promo code unless it has reached its maximum number of
+// uses. The check and the increment are a single conditional update, so concurrent redemptions
+// can never exceed the limit; redeemed is false when the code is exhausted.
+func (r *PromoRepository) RedeemPromoCode(id uuid.UUID) (redeemed bool, err error) {
+	result := r.db.Model(&order.PromoCode{}).
+		Where("id = ? AND (max_uses = 0 OR used_count < max_uses)", id).
+		UpdateColumn("used_count", gorm.Expr("used_count + 1"))
+	return result.RowsAffected == 1, result.Error
+}
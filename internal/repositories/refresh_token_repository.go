@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models/account"
+	"gorm.io/gorm"
+)
+
+// RefreshTokenRepository handles database operations for refresh tokens
+type RefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository creates a new instance of RefreshTokenRepository
+func NewRefreshTokenRepository(db *gorm.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{
+		db: db,
+	}
+}
+
+// CreateRefreshToken creates a new refresh token
+func (r *RefreshTokenRepository) CreateRefreshToken(token *account.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+// GetRefreshTokenByHash retrieves a refresh token by the hash of the token
+func (r *RefreshTokenRepository) GetRefreshTokenByHash(hash string) (*account.RefreshToken, error) {
+	var token account.RefreshToken
+	err := r.db.Where("token_hash = ?", hash).First(&token).Error
+	return &token, err
+}
+
+// RevokeRefreshToken marks a refresh token as revoked. Tokens that are already revoked keep
+// their original revocation time.
+func (r *RefreshTokenRepository) RevokeRefreshToken(token *account.RefreshToken, revokedAt time.Time) error {
+	return r.db.Model(token).
+		Where("revoked_at IS NULL").
+		Update("revoked_at", revokedAt).Error
+}
+
+// RevokeUserRefreshTokens revokes all refresh tokens of a user that are not revoked yet
+func (r *RefreshTokenRepository) RevokeUserRefreshTokens(userID uuid.UUID, revokedAt time.Time) error {
+	return r.db.Model(&account.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", revokedAt).Error
+}
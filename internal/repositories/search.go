@@ -0,0 +1,20 @@
+package repositories
+
+import "strings"
+
+// ProductSearchVector is the weighted full-text document of a product: name and SKU rank above the
+// category, which ranks above the description. The 'simple' configuration neither stems nor drops
+// stop words, so Vietnamese names are matched as written. Indexes on the document must use this
+// exact expression for Postgres to use them.
+const ProductSearchVector = `setweight(to_tsvector('simple', coalesce(name, '')), 'A') || ` +
+	`setweight(to_tsvector('simple', coalesce(sku, '')), 'A') || ` +
+	`setweight(to_tsvector('simple', coalesce(category, '')), 'B') || ` +
+	`setweight(to_tsvector('simple', coalesce(description, '')), 'C')`
+
+// likeEscaper escapes the LIKE wildcard characters of user input
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsPattern returns a LIKE pattern matching values that contain the term literally
+func containsPattern(term string) string {
+	return "%" + likeEscaper.Replace(term) + "%"
+}
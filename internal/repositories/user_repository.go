@@ -27,6 +27,17 @@ func (r *UserRepository) GetUserByID(id uuid.UUID) (*account.User, error) {
 	return &user, err
 }
 
+// GetUsersByIDs retrieves the users with the given IDs
+func (r *UserRepository) GetUsersByIDs(ids []uuid.UUID) ([]account.User, error) {
+	var users []account.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+
+	err := r.db.Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
 // GetUserByUsername retrieves a user by username
 func (r *UserRepository) GetUserByUsername(username string) (*account.User, error) {
 	var user account.User
@@ -97,6 +108,13 @@ func (r *UserRepository) UpdateUser(user *account.User) error {
 	return r.db.Save(user).Error
 }
 
+// UpdatePassword replaces the password hash and salt of a user
+func (r *UserRepository) UpdatePassword(id uuid.UUID, hash, salt string) error {
+	return r.db.Model(&account.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"password_hash": hash, "salt": salt}).Error
+}
+
 // DeleteUser deletes a user by ID
 func (r *UserRepository) DeleteUser(id uuid.UUID) error {
 	return r.db.Delete(&account.User{}, id).Error
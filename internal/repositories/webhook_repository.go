@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models/order"
+	"gorm.io/gorm"
+)
+
+// WebhookRepository handles database operations for webhook endpoints and their deliveries
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository creates a new instance of WebhookRepository
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{
+		db: db,
+	}
+}
+
+// CreateEndpoint creates a new webhook endpoint
+func (r *WebhookRepository) CreateEndpoint(endpoint *order.WebhookEndpoint) error {
+	return r.db.Create(endpoint).Error
+}
+
+// GetEndpointByID retrieves a webhook endpoint by ID
+func (r *WebhookRepository) GetEndpointByID(id uuid.UUID) (*order.WebhookEndpoint, error) {
+	var endpoint order.WebhookEndpoint
+	err := r.db.Where("id = ?", id).First(&endpoint).Error
+	return &endpoint, err
+}
+
+// GetEndpoints retrieves all webhook endpoints, oldest first
+func (r *WebhookRepository) GetEndpoints() ([]order.WebhookEndpoint, error) {
+	var endpoints []order.WebhookEndpoint
+	err := r.db.Order("created_at ASC").Find(&endpoints).Error
+	return endpoints, err
+}
+
+// GetActiveEndpoints retrieves the active webhook endpoints
+func (r *WebhookRepository) GetActiveEndpoints() ([]order.WebhookEndpoint, error) {
+	var endpoints []order.WebhookEndpoint
+	err := r.db.Where("active = ?", true).Order("created_at ASC").Find(&endpoints).Error
+	return endpoints, err
+}
+
+// DeleteEndpoint deletes a webhook endpoint by ID
+func (r *WebhookRepository) DeleteEndpoint(id uuid.UUID) error {
+	return r.db.Delete(&order.WebhookEndpoint{}, id).Error
+}
+
+// CreateDelivery creates a new webhook delivery
+func (r *WebhookRepository) CreateDelivery(delivery *order.WebhookDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+// GetDeliveryByID retrieves a webhook delivery by ID
+func (r *WebhookRepository) GetDeliveryByID(id uuid.UUID) (*order.WebhookDelivery, error) {
+	var delivery order.WebhookDelivery
+	err := r.db.Where("id = ?", id).First(&delivery).Error
+	return &delivery, err
+}
+
+// UpdateDelivery updates an existing webhook delivery
+func (r *WebhookRepository) UpdateDelivery(delivery *order.WebhookDelivery) error {
+	return r.db.Save(delivery).Error
+}
+
+// GetDueDeliveries retrieves at most limit pending deliveries whose next attempt is due at the given time, oldest first
+func (r *WebhookRepository) GetDueDeliveries(at time.Time, limit int) ([]order.WebhookDelivery, error) {
+	var deliveries []order.WebhookDelivery
+	err := r.db.Where("status = ? AND next_attempt_at <= ?", order.DeliveryPending, at).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// GetDeliveriesByEndpointID retrieves the deliveries of an endpoint, newest first, with pagination
+func (r *WebhookRepository) GetDeliveriesByEndpointID(endpointID uuid.UUID, page, pageSize int) ([]order.WebhookDelivery, int64, error) {
+	var deliveries []order.WebhookDelivery
+	var total int64
+
+	query := r.db.Model(&order.WebhookDelivery{}).Where("endpoint_id = ?", endpointID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("created_at DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&deliveries).Error
+
+	return deliveries, total, err
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models/account"
+	"github.com/ybds/internal/repositories"
+	"gorm.io/gorm"
+)
+
+const (
+	// apiKeyPrefix starts every API key so that leaked keys are easy to recognize
+	apiKeyPrefix = "ybds_"
+	// apiKeyDisplayLength is how many leading characters of a key are stored to tell keys apart
+	apiKeyDisplayLength = 12
+)
+
+// APIKeyService handles the API keys external systems authenticate with
+type APIKeyService struct {
+	APIKeyRepo *repositories.APIKeyRepository
+}
+
+// NewAPIKeyService creates a new instance of APIKeyService
+func NewAPIKeyService(db *gorm.DB) *APIKeyService {
+	return &APIKeyService{
+		APIKeyRepo: repositories.NewAPIKeyRepository(db),
+	}
+}
+
+// CreateAPIKey creates an API key granting the given scopes and returns it together with the key
+// itself. The key is not stored and cannot be retrieved again.
+func (s *APIKeyService) CreateAPIKey(label string, scopes []account.Permission, createdBy *uuid.UUID) (*account.APIKey, string, error) {
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return nil, "", validationError("label is required")
+	}
+	scopeList, err := joinAPIKeyScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	apiKey := &account.APIKey{
+		Label:     label,
+		KeyHash:   hashAPIKey(key),
+		KeyPrefix: key[:apiKeyDisplayLength],
+		Scopes:    scopeList,
+	}
+	apiKey.CreatedBy = createdBy
+	if err := s.APIKeyRepo.CreateAPIKey(apiKey); err != nil {
+		return nil, "", err
+	}
+
+	return apiKey, key, nil
+}
+
+// joinAPIKeyScopes validates the scopes of an API key and joins them for storage. API keys cannot
+// be granted access to the admin routes.
+func joinAPIKeyScopes(scopes []account.Permission) (string, error) {
+	if len(scopes) == 0 {
+		return "", validationError("at least one scope is required")
+	}
+
+	names := make([]string, 0, len(scopes))
+	seen := make(map[account.Permission]bool, len(scopes))
+	for _, scope := range scopes {
+		if !scope.IsValid() {
+			return "", validationError(fmt.Sprintf("unknown scope %s", scope))
+		}
+		if scope == account.PermissionAdminAccess {
+			return "", validationError(fmt.Sprintf("scope %s cannot be granted to API keys", scope))
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			names = append(names, string(scope))
+		}
+	}
+
+	return strings.Join(names, ","), nil
+}
+
+// hashAPIKey returns the hex encoded SHA-256 hash under which an API key is stored
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidateAPIKey looks up an API key and checks that it has not been revoked. The time the key
+// was last used is recorded.
+func (s *APIKeyService) ValidateAPIKey(key string) (*account.APIKey, error) {
+	apiKey, err := s.APIKeyRepo.GetAPIKeyByHash(hashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, unauthorizedError("invalid API key")
+		}
+		return nil, err
+	}
+	if apiKey.Revoked {
+		return nil, unauthorizedError("API key has been revoked")
+	}
+
+	if err := s.APIKeyRepo.TouchAPIKey(apiKey.ID, time.Now()); err != nil {
+		log.Printf("Error recording use of API key %s: %v", apiKey.ID, err)
+	}
+
+	return apiKey, nil
+}
+
+// GetAPIKeys lists all API keys, newest first
+func (s *APIKeyService) GetAPIKeys() ([]account.APIKey, error) {
+	return s.APIKeyRepo.GetAPIKeys()
+}
+
+// GetAPIKeyByID retrieves an API key by ID
+func (s *APIKeyService) GetAPIKeyByID(id uuid.UUID) (*account.APIKey, error) {
+	apiKey, err := s.APIKeyRepo.GetAPIKeyByID(id)
+	if err != nil {
+		return nil, notFoundError("API key", err)
+	}
+	return apiKey, nil
+}
+
+// UpdateAPIKey changes the label and the scopes of an API key. Fields left nil keep their current value.
+func (s *APIKeyService) UpdateAPIKey(id uuid.UUID, label *string, scopes []account.Permission, updatedBy *uuid.UUID) (*account.APIKey, error) {
+	apiKey, err := s.GetAPIKeyByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if label != nil {
+		if apiKey.Label = strings.TrimSpace(*label); apiKey.Label == "" {
+			return nil, validationError("label cannot be empty")
+		}
+	}
+	if scopes != nil {
+		if apiKey.Scopes, err = joinAPIKeyScopes(scopes); err != nil {
+			return nil, err
+		}
+	}
+
+	apiKey.UpdatedBy = updatedBy
+	if err := s.APIKeyRepo.UpdateAPIKey(apiKey); err != nil {
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+// RevokeAPIKey revokes an API key so it can no longer authenticate requests. Revoking a key that
+// is already revoked keeps its original revocation time.
+func (s *APIKeyService) RevokeAPIKey(id uuid.UUID, revokedBy *uuid.UUID) (*account.APIKey, error) {
+	apiKey, err := s.GetAPIKeyByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey.Revoked {
+		return apiKey, nil
+	}
+
+	now := time.Now()
+	apiKey.Revoked = true
+	apiKey.RevokedAt = &now
+	apiKey.UpdatedBy = revokedBy
+	if err := s.APIKeyRepo.UpdateAPIKey(apiKey); err != nil {
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+// DeleteAPIKey deletes an API key
+func (s *APIKeyService) DeleteAPIKey(id uuid.UUID) error {
+	if _, err := s.GetAPIKeyByID(id); err != nil {
+		return err
+	}
+	return s.APIKeyRepo.DeleteAPIKey(id)
+}
@@ -0,0 +1,48 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/models/account"
+	"github.com/ybds/internal/services"
+	"github.com/ybds/internal/testutil"
+)
+
+// TestAPIKeyLifecycle tests that API keys are stored hashed, validate until they are revoked and
+// cannot be granted admin access
+func TestAPIKeyLifecycle(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	apiKeyService := services.NewAPIKeyService(db)
+
+	_, _, err := apiKeyService.CreateAPIKey("Admin", []account.Permission{account.PermissionAdminAccess}, nil)
+	assert.ErrorIs(t, err, services.ErrValidation)
+	_, _, err = apiKeyService.CreateAPIKey("Unknown", []account.Permission{"order:everything"}, nil)
+	assert.ErrorIs(t, err, services.ErrValidation)
+
+	apiKey, key, err := apiKeyService.CreateAPIKey("POS", []account.Permission{account.PermissionBackofficeAccess, account.PermissionOrderUpdateStatus}, nil)
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, apiKey.KeyHash)
+	assert.Contains(t, key, apiKey.KeyPrefix)
+
+	// The key is not stored in full
+	var stored account.APIKey
+	assert.NoError(t, db.First(&stored, "id = ?", apiKey.ID).Error)
+	assert.NotContains(t, stored.KeyHash, key)
+	assert.NotEqual(t, key, stored.KeyPrefix)
+
+	validated, err := apiKeyService.ValidateAPIKey(key)
+	assert.NoError(t, err)
+	assert.Equal(t, apiKey.ID, validated.ID)
+	assert.Equal(t, []account.Permission{account.PermissionBackofficeAccess, account.PermissionOrderUpdateStatus}, validated.ScopeList())
+
+	_, err = apiKeyService.ValidateAPIKey(key + "x")
+	assert.ErrorIs(t, err, services.ErrUnauthorized)
+
+	revoked, err := apiKeyService.RevokeAPIKey(apiKey.ID, nil)
+	assert.NoError(t, err)
+	assert.True(t, revoked.Revoked)
+
+	_, err = apiKeyService.ValidateAPIKey(key)
+	assert.ErrorIs(t, err, services.ErrUnauthorized)
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models/account"
+	"github.com/ybds/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// MaxAuditBodyLength is the longest request body, in bytes, kept in an audit log entry
+const MaxAuditBodyLength = 8192
+
+// jsonContentType is the content type of JSON request bodies
+const jsonContentType = "application/json"
+
+// redactedValue replaces the value of secret fields in audit log bodies
+const redactedValue = "[REDACTED]"
+
+// secretFieldMarkers are the parts of field names whose values are never written to the audit log
+var secretFieldMarkers = []string{"password", "token", "secret", "api_key", "apikey", "authorization"}
+
+// auditResources maps path segments to the audited resource they change. Nested resources are
+// listed first, so /products/prices/:id is recorded as a price change.
+var auditResources = []struct {
+	Segment  string
+	Resource string
+}{
+	{"prices", "price"},
+	{"inventories", "inventory"},
+	{"products", "product"},
+	{"orders", "order"},
+	{"users", "user"},
+}
+
+// AuditService handles the audit trail of changes made by users
+type AuditService struct {
+	DB           *gorm.DB
+	AuditLogRepo *repositories.AuditLogRepository
+}
+
+// NewAuditService creates a new instance of AuditService
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{
+		DB:           db,
+		AuditLogRepo: repositories.NewAuditLogRepository(db),
+	}
+}
+
+// WithContext returns a copy of the service whose queries run with the given context
+func (s *AuditService) WithContext(ctx context.Context) *AuditService {
+	clone := *s
+	clone.DB = s.DB.WithContext(ctx)
+	clone.AuditLogRepo = s.AuditLogRepo.WithContext(ctx)
+	return &clone
+}
+
+// AuditRequest describes a mutating request to record in the audit log
+type AuditRequest struct {
+	ActorID     *uuid.UUID
+	ActorRoles  []string
+	Method      string
+	Path        string
+	TargetID    string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	Form        map[string][]string
+	IP          string
+}
+
+// RecordRequest stores a mutating request in the audit log. Requests that do not change an audited
+// resource (products, prices, inventories, orders and users) are ignored.
+func (s *AuditService) RecordRequest(req AuditRequest) error {
+	resource := AuditResource(req.Path)
+	if resource == "" {
+		return nil
+	}
+
+	return s.AuditLogRepo.CreateAuditLog(&account.AuditLog{
+		ActorID:    req.ActorID,
+		ActorRoles: strings.Join(req.ActorRoles, ","),
+		Method:     req.Method,
+		Path:       req.Path,
+		Resource:   resource,
+		TargetID:   req.TargetID,
+		StatusCode: req.StatusCode,
+		Body:       RedactAuditBody(req.ContentType, req.Body, req.Form),
+		IP:         req.IP,
+	})
+}
+
+// GetAuditLogs retrieves audit log entries, newest first, with pagination and filtering
+func (s *AuditService) GetAuditLogs(page, pageSize int, filters map[string]interface{}) ([]account.AuditLog, int64, error) {
+	return s.AuditLogRepo.GetAuditLogs(page, pageSize, filters)
+}
+
+// AuditResource returns the audited resource changed by a request to the given path, or an empty
+// string when the path does not belong to an audited resource
+func AuditResource(path string) string {
+	segments := strings.Split(strings.ToLower(path), "/")
+	for _, candidate := range auditResources {
+		for _, segment := range segments {
+			if segment == candidate.Segment {
+				return candidate.Resource
+			}
+		}
+	}
+	return ""
+}
+
+// RedactAuditBody returns the request body to keep in the audit log with the values of secret
+// fields replaced. JSON bodies are kept as JSON and form bodies as a JSON object of their values;
+// uploaded files and bodies of other types are not recorded. Bodies longer than MaxAuditBodyLength
+// are replaced by a {"truncated":true,"preview":"..."} object, so the stored body is always JSON.
+func RedactAuditBody(contentType string, body []byte, form map[string][]string) string {
+	var value interface{}
+	switch {
+	case len(form) > 0:
+		fields := make(map[string]interface{}, len(form))
+		for key, values := range form {
+			if len(values) == 1 {
+				fields[key] = values[0]
+			} else {
+				fields[key] = values
+			}
+		}
+		value = fields
+	case strings.HasPrefix(strings.ToLower(contentType), jsonContentType) && len(body) > 0:
+		if err := json.Unmarshal(body, &value); err != nil {
+			return ""
+		}
+	default:
+		return ""
+	}
+
+	redacted, err := json.Marshal(redactSecrets(value))
+	if err != nil {
+		return ""
+	}
+	if len(redacted) > MaxAuditBodyLength {
+		return truncatedAuditBody(string(redacted))
+	}
+	return string(redacted)
+}
+
+// truncatedAuditBody returns a JSON object holding the start of a body too long for the audit log.
+// The preview is cut at a rune boundary and shortened until the escaped object fits in
+// MaxAuditBodyLength bytes.
+func truncatedAuditBody(body string) string {
+	preview := body
+	for {
+		truncated, err := json.Marshal(map[string]interface{}{
+			"truncated": true,
+			"preview":   preview,
+		})
+		if err != nil {
+			return ""
+		}
+		if len(truncated) <= MaxAuditBodyLength {
+			return string(truncated)
+		}
+
+		cut := len(preview) - (len(truncated) - MaxAuditBodyLength)
+		if cut < 0 {
+			cut = 0
+		}
+		for cut > 0 && !utf8.RuneStart(preview[cut]) {
+			cut--
+		}
+		preview = preview[:cut]
+	}
+}
+
+// redactSecrets replaces the values of secret fields in a decoded JSON value, at any depth
+func redactSecrets(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if isSecretField(key) {
+				v[key] = redactedValue
+			} else {
+				v[key] = redactSecrets(field)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactSecrets(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// isSecretField reports whether the value of a field must not be written to the audit log
+func isSecretField(name string) bool {
+	name = strings.ToLower(name)
+	for _, marker := range secretFieldMarkers {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
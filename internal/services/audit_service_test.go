@@ -0,0 +1,65 @@
+package services_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/services"
+)
+
+// TestAuditResource tests that request paths are mapped to the audited resource they change
+func TestAuditResource(t *testing.T) {
+	assert.Equal(t, "product", services.AuditResource("/api/products/123"))
+	assert.Equal(t, "price", services.AuditResource("/api/products/prices/123"))
+	assert.Equal(t, "inventory", services.AuditResource("/api/products/inventories/123"))
+	assert.Equal(t, "inventory", services.AuditResource("/api/inventories/123/hold"))
+	assert.Equal(t, "order", services.AuditResource("/api/orders/123/status"))
+	assert.Equal(t, "user", services.AuditResource("/api/admin/users/123"))
+	assert.Equal(t, "", services.AuditResource("/api/admin/notifications/read-all"))
+}
+
+// TestRedactAuditBody tests that secrets are redacted from JSON and form bodies
+func TestRedactAuditBody(t *testing.T) {
+	body := services.RedactAuditBody("application/json; charset=utf-8",
+		[]byte(`{"username":"jane","password":"s3cret","profile":{"api_key":"k"},"items":[{"refresh_token":"t","quantity":2}]}`), nil)
+
+	var decoded map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(body), &decoded))
+	assert.Equal(t, "jane", decoded["username"])
+	assert.Equal(t, "[REDACTED]", decoded["password"])
+	assert.Equal(t, "[REDACTED]", decoded["profile"].(map[string]interface{})["api_key"])
+	item := decoded["items"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "[REDACTED]", item["refresh_token"])
+	assert.Equal(t, float64(2), item["quantity"])
+
+	body = services.RedactAuditBody("multipart/form-data; boundary=x", nil,
+		map[string][]string{"name": {"Shirt"}, "new_password": {"s3cret"}})
+	assert.JSONEq(t, `{"name":"Shirt","new_password":"[REDACTED]"}`, body)
+
+	// Bodies that cannot be redacted are not recorded
+	assert.Equal(t, "", services.RedactAuditBody("application/json", []byte(`{"password":`), nil))
+	assert.Equal(t, "", services.RedactAuditBody("text/plain", []byte("password=s3cret"), nil))
+}
+
+// TestRedactAuditBodyTruncation tests that bodies longer than the limit are kept as valid JSON
+func TestRedactAuditBodyTruncation(t *testing.T) {
+	long, err := json.Marshal(map[string]string{"description": strings.Repeat(`Áo "sơ mi" `, 2000)})
+	assert.NoError(t, err)
+	assert.Greater(t, len(long), services.MaxAuditBodyLength)
+
+	body := services.RedactAuditBody("application/json", long, nil)
+	assert.LessOrEqual(t, len(body), services.MaxAuditBodyLength)
+	assert.True(t, utf8.ValidString(body))
+
+	var decoded map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(body), &decoded))
+	assert.Equal(t, true, decoded["truncated"])
+	preview, ok := decoded["preview"].(string)
+	if assert.True(t, ok) {
+		assert.True(t, utf8.ValidString(preview))
+		assert.True(t, strings.HasPrefix(string(long), preview))
+	}
+}
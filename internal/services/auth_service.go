@@ -1,40 +1,82 @@
 package services
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/google/uuid"
+	"github.com/ybds/internal/models/account"
+	"github.com/ybds/internal/models/notification"
+	"github.com/ybds/internal/repositories"
 	"github.com/ybds/pkg/jwt"
 	passwordpkg "github.com/ybds/pkg/password"
 	"gorm.io/gorm"
 )
 
+const (
+	// DefaultPasswordResetExpiry is how long a password reset token stays valid
+	DefaultPasswordResetExpiry = time.Hour
+	// MinPasswordLength is the minimum length of a password chosen through a password reset
+	MinPasswordLength = 8
+)
+
+// AuthSettings holds the configurable behavior of the AuthService
+type AuthSettings struct {
+	// PasswordResetExpiry is how long a password reset token stays valid
+	PasswordResetExpiry time.Duration
+}
+
+// WithDefaults returns a copy of the settings with unset values replaced by their defaults
+func (s AuthSettings) WithDefaults() AuthSettings {
+	if s.PasswordResetExpiry <= 0 {
+		s.PasswordResetExpiry = DefaultPasswordResetExpiry
+	}
+	return s
+}
+
 // AuthService handles authentication-related business logic
 type AuthService struct {
-	db          *gorm.DB
-	jwtService  *jwt.JWTService
-	userService *UserService
+	db                *gorm.DB
+	jwtService        *jwt.JWTService
+	userService       *UserService
+	RefreshTokenRepo  *repositories.RefreshTokenRepository
+	PasswordResetRepo *repositories.PasswordResetRepository
+	Settings          AuthSettings
 }
 
 // NewAuthService creates a new instance of AuthService
 func NewAuthService(db *gorm.DB, jwtService *jwt.JWTService, userService *UserService) *AuthService {
 	return &AuthService{
-		db:          db,
-		jwtService:  jwtService,
-		userService: userService,
+		db:                db,
+		jwtService:        jwtService,
+		userService:       userService,
+		RefreshTokenRepo:  repositories.NewRefreshTokenRepository(db),
+		PasswordResetRepo: repositories.NewPasswordResetRepository(db),
+		Settings:          AuthSettings{}.WithDefaults(),
 	}
 }
 
 // LoginResult represents the result of a login attempt
 type LoginResult struct {
-	Success  bool
-	Message  string
-	Error    string
-	Token    string
-	UserID   uuid.UUID
-	Username string
-	Email    string
-	Roles    []string
+	Success bool
+	Message string
+	Error   string
+	Token   string
+	// RefreshToken is exchanged for new access tokens until RefreshExpiresAt or until it is revoked
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+	UserID           uuid.UUID
+	Username         string
+	Email            string
+	Roles            []string
 }
 
 // Login authenticates a user and returns a JWT token if successful
@@ -83,18 +125,124 @@ func (s *AuthService) Login(username, plainPassword string) (*LoginResult, error
 		}, err
 	}
 
+	// Issue a refresh token for obtaining new access tokens
+	refreshToken, err := s.issueRefreshToken(user.ID)
+	if err != nil {
+		return &LoginResult{
+			Success: false,
+			Message: "Authentication failed",
+			Error:   "Failed to generate token",
+		}, err
+	}
+
 	// Return successful result
 	return &LoginResult{
-		Success:  true,
-		Message:  "Authentication successful",
-		Token:    token,
-		UserID:   user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-		Roles:    roles,
+		Success:          true,
+		Message:          "Authentication successful",
+		Token:            token,
+		RefreshToken:     refreshToken.Token,
+		RefreshExpiresAt: refreshToken.ExpiresAt,
+		UserID:           user.ID,
+		Username:         user.Username,
+		Email:            user.Email,
+		Roles:            roles,
+	}, nil
+}
+
+// issueRefreshToken generates a refresh token for the user and stores its hash
+func (s *AuthService) issueRefreshToken(userID uuid.UUID) (*jwt.RefreshToken, error) {
+	refreshToken, err := s.jwtService.GenerateRefreshToken(userID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.RefreshTokenRepo.CreateRefreshToken(&account.RefreshToken{
+		UserID:    userID,
+		TokenHash: jwt.HashRefreshToken(refreshToken.Token),
+		ExpiresAt: refreshToken.ExpiresAt,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return refreshToken, nil
+}
+
+// getRefreshToken looks up a refresh token and checks that it can still be used
+func (s *AuthService) getRefreshToken(token string) (*account.RefreshToken, error) {
+	stored, err := s.RefreshTokenRepo.GetRefreshTokenByHash(jwt.HashRefreshToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, unauthorizedError("invalid refresh token")
+		}
+		return nil, err
+	}
+
+	if stored.IsRevoked() {
+		return nil, unauthorizedError("refresh token has been revoked")
+	}
+	if stored.IsExpired(time.Now()) {
+		return nil, unauthorizedError("refresh token has expired")
+	}
+
+	return stored, nil
+}
+
+// Refresh exchanges a valid refresh token for a new access token carrying the user's current
+// roles. The refresh token itself stays valid until it expires or is revoked.
+func (s *AuthService) Refresh(token string) (*LoginResult, error) {
+	stored, err := s.getRefreshToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.GetUserByID(stored.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, unauthorizedError("user no longer exists")
+		}
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, unauthorizedError("account is inactive")
+	}
+
+	var roles []string
+	for _, role := range user.Roles {
+		roles = append(roles, string(role.Name))
+	}
+
+	accessToken, err := s.jwtService.GenerateToken(user.ID.String(), roles)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{
+		Success:          true,
+		Message:          "Token refreshed successfully",
+		Token:            accessToken,
+		RefreshToken:     token,
+		RefreshExpiresAt: stored.ExpiresAt,
+		UserID:           user.ID,
+		Username:         user.Username,
+		Email:            user.Email,
+		Roles:            roles,
 	}, nil
 }
 
+// Logout revokes a refresh token so it can no longer be exchanged for access tokens. Revoking
+// a token that is already revoked succeeds.
+func (s *AuthService) Logout(token string) error {
+	stored, err := s.RefreshTokenRepo.GetRefreshTokenByHash(jwt.HashRefreshToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return unauthorizedError("invalid refresh token")
+		}
+		return err
+	}
+
+	return s.RefreshTokenRepo.RevokeRefreshToken(stored, time.Now())
+}
+
 // RegistrationResult represents the result of a registration attempt
 type RegistrationResult struct {
 	Success  bool
@@ -126,3 +274,146 @@ func (s *AuthService) Register(email, phone, password string) (*RegistrationResu
 		Email:    userResult.Email,
 	}, nil
 }
+
+// RequestPasswordReset generates a password reset token for the active user with the given email
+// address and sends it to them by email. To not reveal which addresses are registered, an unknown
+// or inactive address succeeds without sending anything.
+func (s *AuthService) RequestPasswordReset(email string) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return validationError("email is required")
+	}
+
+	user, err := s.userService.UserRepo.GetUserByEmail(email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !user.IsActive {
+		return nil
+	}
+
+	if s.userService.NotificationService == nil {
+		return errors.New("notification service is not configured")
+	}
+
+	token, err := generatePasswordResetToken()
+	if err != nil {
+		return err
+	}
+	if err := s.PasswordResetRepo.CreatePasswordResetToken(&account.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashPasswordResetToken(token),
+		ExpiresAt: time.Now().Add(s.Settings.PasswordResetExpiry),
+	}); err != nil {
+		return fmt.Errorf("failed to store password reset token: %w", err)
+	}
+
+	// The code is emailed directly, so it is never stored in a notification that admins, the user's
+	// notification list or the retry worker could read; a delivery failure is not reported so that
+	// registered addresses are not revealed
+	if err := s.userService.NotificationService.SendEmail(user.Email, "Reset your password",
+		fmt.Sprintf("Use this code to choose a new password: %s\n\nThe code expires in %s and can only be used once. "+
+			"If you did not request a password reset, you can ignore this message.", token, s.Settings.PasswordResetExpiry),
+	); err != nil {
+		log.Printf("Failed to send password reset email to user %s: %v", user.ID, err)
+	}
+
+	// Record that a reset was requested, without the code
+	_, err = s.userService.NotificationService.CreateNotification(
+		&user.ID,
+		notification.RecipientUser,
+		"Password reset requested",
+		"A password reset code was sent to your email address. If you did not request it, you can ignore it.",
+		notification.Metadata{
+			"user_id": user.ID.String(),
+			"event":   "password_reset_requested",
+		},
+		[]notification.ChannelType{notification.ChannelWebsocket},
+	)
+	return err
+}
+
+// ResetPassword sets a new password for the user a password reset token was issued to. The token
+// is consumed, so it cannot be used again, and the user's refresh tokens are revoked.
+func (s *AuthService) ResetPassword(token, newPassword string) error {
+	if err := validatePasswordPolicy(newPassword); err != nil {
+		return err
+	}
+
+	stored, err := s.PasswordResetRepo.GetPasswordResetTokenByHash(hashPasswordResetToken(strings.TrimSpace(token)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return validationError("invalid password reset token")
+		}
+		return err
+	}
+	if stored.IsUsed() {
+		return validationError("password reset token has already been used")
+	}
+	now := time.Now()
+	if stored.IsExpired(now) {
+		return validationError("password reset token has expired")
+	}
+
+	hash, salt, err := passwordpkg.GenerateHashAndSalt(newPassword)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		consumed, err := repositories.NewPasswordResetRepository(tx).ConsumePasswordResetToken(stored.ID, now)
+		if err != nil {
+			return err
+		}
+		if !consumed {
+			return validationError("password reset token has already been used")
+		}
+
+		if err := repositories.NewUserRepository(tx).UpdatePassword(stored.UserID, hash, salt); err != nil {
+			return err
+		}
+
+		return repositories.NewRefreshTokenRepository(tx).RevokeUserRefreshTokens(stored.UserID, now)
+	})
+}
+
+// validatePasswordPolicy checks that a password is at least MinPasswordLength characters long
+// and contains both a letter and a digit
+func validatePasswordPolicy(password string) error {
+	if len([]rune(password)) < MinPasswordLength {
+		return validationError(fmt.Sprintf("password must be at least %d characters long", MinPasswordLength))
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return validationError("password must contain at least one letter and one digit")
+	}
+
+	return nil
+}
+
+// generatePasswordResetToken returns a random 32-byte token, URL-safe base64 encoded
+func generatePasswordResetToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate password reset token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// hashPasswordResetToken returns the hex encoded SHA-256 hash under which a password reset token is stored
+func hashPasswordResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
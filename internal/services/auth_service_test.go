@@ -2,11 +2,19 @@ package services_test
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/models/account"
+	"github.com/ybds/internal/models/notification"
+	"github.com/ybds/internal/services"
 	"github.com/ybds/internal/testutil"
+	"github.com/ybds/pkg/config"
+	"github.com/ybds/pkg/jwt"
+	passwordpkg "github.com/ybds/pkg/password"
 )
 
 func TestAuthService(t *testing.T) {
@@ -174,3 +182,133 @@ func TestRegister(t *testing.T) {
 		mockAuthService.AssertExpectations(t)
 	})
 }
+
+// TestRefreshToken tests exchanging the refresh token issued at login for new access tokens
+func TestRefreshToken(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	jwtService, err := jwt.NewJWTService(&config.JWTConfig{Secret: "secret", Expiry: "15m", RefreshExpiry: "720h"})
+	assert.NoError(t, err)
+	authService := services.NewAuthService(db, jwtService, services.NewUserService(db, nil))
+
+	user := seedUser(t, db, "refresher", account.RoleAdmin, true)
+	hash, salt, err := passwordpkg.GenerateHashAndSalt("password123")
+	assert.NoError(t, err)
+	assert.NoError(t, db.Model(user).Updates(map[string]interface{}{"password_hash": hash, "salt": salt}).Error)
+
+	login, err := authService.Login("refresher", "password123")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, login.RefreshToken)
+	assert.True(t, login.RefreshExpiresAt.After(time.Now().Add(719*time.Hour)))
+
+	t.Run("Successful refresh", func(t *testing.T) {
+		result, err := authService.Refresh(login.RefreshToken)
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, result.UserID)
+		assert.Equal(t, login.RefreshToken, result.RefreshToken)
+
+		claims, err := jwtService.ValidateToken(result.Token)
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID.String(), claims.UserID)
+		assert.Equal(t, []string{string(account.RoleAdmin)}, claims.Roles)
+	})
+
+	t.Run("Unknown refresh token", func(t *testing.T) {
+		_, err := authService.Refresh("not-a-refresh-token")
+		assert.ErrorIs(t, err, services.ErrUnauthorized)
+	})
+
+	t.Run("Expired refresh token", func(t *testing.T) {
+		expired, err := authService.Login("refresher", "password123")
+		assert.NoError(t, err)
+		assert.NoError(t, db.Model(&account.RefreshToken{}).
+			Where("token_hash = ?", jwt.HashRefreshToken(expired.RefreshToken)).
+			Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+		_, err = authService.Refresh(expired.RefreshToken)
+		assert.ErrorIs(t, err, services.ErrUnauthorized)
+	})
+
+	t.Run("Revoked refresh token", func(t *testing.T) {
+		assert.NoError(t, authService.Logout(login.RefreshToken))
+
+		_, err := authService.Refresh(login.RefreshToken)
+		assert.ErrorIs(t, err, services.ErrUnauthorized)
+
+		// Logging out again is a no-op
+		assert.NoError(t, authService.Logout(login.RefreshToken))
+	})
+}
+
+// TestPasswordReset tests resetting a password with an emailed token, and that expired and
+// consumed tokens are rejected
+func TestPasswordReset(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	jwtService, err := jwt.NewJWTService(&config.JWTConfig{Secret: "secret", Expiry: "15m", RefreshExpiry: "720h"})
+	assert.NoError(t, err)
+	notificationService := services.NewNotificationService(db, db, nil, nil)
+	emailClient := &mockEmailClient{sent: make(map[string][2]string)}
+	notificationService.EmailClient = emailClient
+	authService := services.NewAuthService(db, jwtService, services.NewUserService(db, notificationService))
+
+	user := seedUser(t, db, "forgetful", account.RoleStaff, true)
+	tokenPattern := regexp.MustCompile(`new password: ([A-Za-z0-9_-]+)`)
+	requestToken := func() string {
+		t.Helper()
+		assert.NoError(t, authService.RequestPasswordReset(user.Email))
+		notificationService.Wait()
+
+		emailClient.mu.Lock()
+		defer emailClient.mu.Unlock()
+		match := tokenPattern.FindStringSubmatch(emailClient.sent[user.Email][1])
+		if !assert.Len(t, match, 2) {
+			t.FailNow()
+		}
+		return match[1]
+	}
+
+	// Unknown addresses are not revealed
+	assert.NoError(t, authService.RequestPasswordReset("nobody@example.com"))
+
+	t.Run("Successful reset", func(t *testing.T) {
+		token := requestToken()
+
+		assert.ErrorIs(t, authService.ResetPassword(token, "short1"), services.ErrValidation)
+		assert.ErrorIs(t, authService.ResetPassword(token, "lettersonly"), services.ErrValidation)
+		assert.NoError(t, authService.ResetPassword(token, "newpassword1"))
+
+		var updated account.User
+		assert.NoError(t, db.First(&updated, "id = ?", user.ID).Error)
+		assert.True(t, passwordpkg.Verify("newpassword1", updated.PasswordHash, updated.Salt))
+
+		// The token is single-use
+		assert.ErrorIs(t, authService.ResetPassword(token, "otherpassword2"), services.ErrValidation)
+	})
+
+	t.Run("Code is not stored", func(t *testing.T) {
+		token := requestToken()
+
+		var notifications []notification.Notification
+		assert.NoError(t, db.Where("recipient_id = ?", user.ID).Find(&notifications).Error)
+		assert.NotEmpty(t, notifications)
+		for _, notif := range notifications {
+			assert.NotContains(t, notif.Title, token)
+			assert.NotContains(t, notif.Message, token)
+		}
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		token := requestToken()
+		assert.NoError(t, db.Model(&account.PasswordResetToken{}).Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+		err := authService.ResetPassword(token, "newpassword3")
+		assert.ErrorIs(t, err, services.ErrValidation)
+		assert.Contains(t, err.Error(), "expired")
+	})
+
+	t.Run("Unknown token", func(t *testing.T) {
+		assert.ErrorIs(t, authService.ResetPassword("not-a-reset-token", "newpassword4"), services.ErrValidation)
+	})
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ybds/internal/utils"
+	"github.com/ybds/pkg/currency"
+)
+
+// CurrencyService converts amounts between currencies
+type CurrencyService struct {
+	Provider currency.ExchangeRateProvider
+}
+
+// NewCurrencyService creates a new instance of CurrencyService
+func NewCurrencyService(provider currency.ExchangeRateProvider) *CurrencyService {
+	return &CurrencyService{Provider: provider}
+}
+
+// ConvertAmount converts an amount from one currency to another, rounded to whole cents.
+// Amounts already in the target currency are returned unchanged without looking up a rate.
+func (s *CurrencyService) ConvertAmount(amount float64, from, to string) (float64, error) {
+	from, to = currency.Normalize(from), currency.Normalize(to)
+	if from == to {
+		return amount, nil
+	}
+	if s == nil || s.Provider == nil {
+		return 0, validationError(fmt.Sprintf("no exchange rates are configured to convert %s to %s", from, to))
+	}
+
+	rate, err := s.Provider.Rate(from, to)
+	if err != nil {
+		if errors.Is(err, currency.ErrUnknownCurrency) {
+			return 0, validationError(fmt.Sprintf("cannot convert %s to %s: %v", from, to, err))
+		}
+		return 0, fmt.Errorf("error getting exchange rate from %s to %s: %w", from, to, err)
+	}
+
+	return utils.RoundMoney(amount * rate), nil
+}
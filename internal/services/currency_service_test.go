@@ -0,0 +1,52 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/services"
+	"github.com/ybds/pkg/currency"
+)
+
+// stubRateProvider returns fixed exchange rates and counts the lookups
+type stubRateProvider struct {
+	rates map[[2]string]float64
+	calls int
+}
+
+func (p *stubRateProvider) Rate(from, to string) (float64, error) {
+	p.calls++
+	rate, ok := p.rates[[2]string{from, to}]
+	if !ok {
+		return 0, currency.ErrUnknownCurrency
+	}
+	return rate, nil
+}
+
+// TestConvertAmount tests currency conversion and the same-currency no-op
+func TestConvertAmount(t *testing.T) {
+	provider := &stubRateProvider{rates: map[[2]string]float64{{"USD", "VND"}: 25000, {"VND", "USD"}: 0.00004}}
+	currencyService := services.NewCurrencyService(provider)
+
+	converted, err := currencyService.ConvertAmount(12.5, "usd", "VND")
+	assert.NoError(t, err)
+	assert.Equal(t, 312500.0, converted)
+
+	converted, err = currencyService.ConvertAmount(100000, "VND", "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, 4.0, converted)
+	assert.Equal(t, 2, provider.calls)
+
+	// Same-currency amounts are returned as they are without a lookup
+	converted, err = currencyService.ConvertAmount(199.999, "VND", " vnd ")
+	assert.NoError(t, err)
+	assert.Equal(t, 199.999, converted)
+	assert.Equal(t, 2, provider.calls)
+
+	_, err = currencyService.ConvertAmount(10, "JPY", "VND")
+	assert.True(t, errors.Is(err, services.ErrValidation))
+
+	_, err = services.NewCurrencyService(nil).ConvertAmount(10, "USD", "VND")
+	assert.True(t, errors.Is(err, services.ErrValidation))
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models/order"
+	"github.com/ybds/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// CustomerService handles customer profile business logic
+type CustomerService struct {
+	DB           *gorm.DB
+	CustomerRepo *repositories.CustomerRepository
+}
+
+// NewCustomerService creates a new instance of CustomerService
+func NewCustomerService(db *gorm.DB) *CustomerService {
+	return &CustomerService{
+		DB:           db,
+		CustomerRepo: repositories.NewCustomerRepository(db),
+	}
+}
+
+// CustomerProfile represents a customer together with a summary of their orders
+type CustomerProfile struct {
+	Customer    order.Customer
+	OrderCount  int64
+	TotalSpent  float64
+	LastOrderAt *time.Time
+}
+
+// GetCustomerProfile retrieves a customer and summarizes their orders
+func (s *CustomerService) GetCustomerProfile(id uuid.UUID) (*CustomerProfile, error) {
+	customer, err := s.CustomerRepo.GetCustomerByID(id)
+	if err != nil {
+		return nil, notFoundError("customer", err)
+	}
+
+	stats, err := s.CustomerRepo.GetCustomerOrderStats(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CustomerProfile{
+		Customer:    *customer,
+		OrderCount:  stats.OrderCount,
+		TotalSpent:  stats.TotalSpent,
+		LastOrderAt: stats.LastOrderAt,
+	}, nil
+}
+
+// GetCustomers retrieves customers with pagination, optionally matching a search term
+func (s *CustomerService) GetCustomers(page, pageSize int, search string) ([]order.Customer, int64, error) {
+	return s.CustomerRepo.GetCustomers(page, pageSize, search)
+}
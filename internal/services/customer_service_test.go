@@ -0,0 +1,160 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/database"
+	"github.com/ybds/internal/models/order"
+	"github.com/ybds/internal/models/product"
+	"github.com/ybds/internal/services"
+	"github.com/ybds/internal/testutil"
+)
+
+// TestCreateOrderUpsertsCustomer tests that orders placed with the same phone number share one customer profile
+func TestCreateOrderUpsertsCustomer(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+	customerService := services.NewCustomerService(db)
+
+	p, inv := seedProductWithInventory(t, db, "CUS-001", 10, 2)
+	assert.NoError(t, db.Create(&product.Price{ProductID: p.ID, Price: 100, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+	items := []services.OrderItemInfo{{InventoryID: inv.ID, Quantity: 1}}
+	createdBy := uuid.New()
+
+	first, err := orderService.CreateOrder(order.PaymentCash, items, 0, nil, "",
+		&createdBy, "", "", "", "", "", "John Doe", "", "+84 912 345 678", "", true)
+	assert.NoError(t, err)
+	second, err := orderService.CreateOrder(order.PaymentCash, items, 0, nil, "",
+		&createdBy, "", "", "", "", "", "John D.", "john@example.com", "0912-345-678", "", true)
+	assert.NoError(t, err)
+
+	firstOrder, err := orderService.GetOrderByID(first.OrderID)
+	assert.NoError(t, err)
+	secondOrder, err := orderService.GetOrderByID(second.OrderID)
+	assert.NoError(t, err)
+	if !assert.NotNil(t, firstOrder.CustomerID) || !assert.NotNil(t, secondOrder.CustomerID) {
+		return
+	}
+	assert.Equal(t, *firstOrder.CustomerID, *secondOrder.CustomerID)
+
+	// The phone numbers of orders are stored in E.164 format, the other contact fields as entered
+	assert.Equal(t, "+84912345678", firstOrder.CustomerPhone)
+	assert.Equal(t, "+84912345678", secondOrder.CustomerPhone)
+	assert.Equal(t, "John Doe", firstOrder.CustomerName)
+
+	var count int64
+	assert.NoError(t, db.Model(&order.Customer{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	// The profile follows the latest order
+	profile, err := customerService.GetCustomerProfile(*firstOrder.CustomerID)
+	assert.NoError(t, err)
+	assert.Equal(t, "+84912345678", profile.Customer.Phone)
+	assert.Equal(t, "John D.", profile.Customer.Name)
+	assert.Equal(t, "john@example.com", profile.Customer.Email)
+
+	// Orders without a phone number are not linked to a customer
+	anonymous, err := orderService.CreateOrder(order.PaymentCash, items, 0, nil, "",
+		&createdBy, "", "", "", "", "", "Walk-in", "", "", "", true)
+	assert.NoError(t, err)
+	anonymousOrder, err := orderService.GetOrderByID(anonymous.OrderID)
+	assert.NoError(t, err)
+	assert.Nil(t, anonymousOrder.CustomerID)
+}
+
+// TestGetCustomerProfile tests that a customer profile summarizes the customer's orders
+func TestGetCustomerProfile(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	customerService := services.NewCustomerService(db)
+
+	customer, err := customerService.CustomerRepo.UpsertCustomer("+84987654321", "Tran Thi Lan", "lan@example.com")
+	assert.NoError(t, err)
+
+	delivered := seedOrder(t, db, order.OrderDelivered, nil)
+	canceled := seedOrder(t, db, order.OrderCanceled, nil)
+	seedOrder(t, db, order.OrderDelivered, nil)
+	for _, o := range []*order.Order{delivered, canceled} {
+		assert.NoError(t, db.Model(o).Updates(map[string]interface{}{
+			"customer_id":        customer.ID,
+			"final_total_amount": 250,
+		}).Error)
+	}
+
+	profile, err := customerService.GetCustomerProfile(customer.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Tran Thi Lan", profile.Customer.Name)
+	assert.Equal(t, int64(2), profile.OrderCount)
+	assert.Equal(t, 250.0, profile.TotalSpent)
+	assert.NotNil(t, profile.LastOrderAt)
+
+	customers, total, err := customerService.GetCustomers(1, 10, "lan")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, customers, 1)
+
+	// Phone searches in national format match the stored E.164 number
+	_, total, err = customerService.GetCustomers(1, 10, "0987 654")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	_, err = customerService.GetCustomerProfile(uuid.New())
+	assert.ErrorIs(t, err, services.ErrNotFound)
+}
+
+// TestMigrationBackfillsOrderCustomers tests that migrating links existing orders to one customer per phone number
+func TestMigrationBackfillsOrderCustomers(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	orderService := services.NewOrderService(db, nil, nil, nil)
+
+	first := seedOrder(t, db, order.OrderDelivered, nil)
+	second := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	anonymous := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	assert.NoError(t, db.Model(first).Update("customer_phone", "+84 912 345 678").Error)
+	assert.NoError(t, db.Model(second).Updates(map[string]interface{}{
+		"customer_phone": "0912-345-678",
+		"customer_name":  "John D.",
+		"created_at":     time.Now().Add(time.Hour),
+	}).Error)
+
+	// A customer created while customer phones were stored in national format
+	legacy := &order.Customer{Phone: "0987654321", Name: "Tran Thi Lan"}
+	assert.NoError(t, db.Create(legacy).Error)
+
+	assert.NoError(t, database.InitDatabase(db))
+
+	var stored order.Customer
+	assert.NoError(t, db.First(&stored, "id = ?", legacy.ID).Error)
+	assert.Equal(t, "+84987654321", stored.Phone)
+
+	var customers []order.Customer
+	assert.NoError(t, db.Where("id <> ?", legacy.ID).Find(&customers).Error)
+	if !assert.Len(t, customers, 1) {
+		return
+	}
+	assert.Equal(t, "+84912345678", customers[0].Phone)
+	assert.Equal(t, "John D.", customers[0].Name)
+
+	for _, o := range []*order.Order{first, second} {
+		linked, err := orderService.GetOrderByID(o.ID)
+		assert.NoError(t, err)
+		if assert.NotNil(t, linked.CustomerID) {
+			assert.Equal(t, customers[0].ID, *linked.CustomerID)
+		}
+	}
+	unlinked, err := orderService.GetOrderByID(anonymous.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, unlinked.CustomerID)
+
+	// The orders are listed for their customer
+	orders, total, err := orderService.GetOrdersWithDetails(1, 10, map[string]interface{}{"customer_id": customers[0].ID})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, orders, 2)
+
+	_, err = orderService.GetCustomerByID(uuid.New())
+	assert.ErrorIs(t, err, services.ErrNotFound)
+}
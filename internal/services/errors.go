@@ -0,0 +1,52 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Service errors classify failures so that handlers can map them to HTTP status codes.
+// Use errors.Is to check for them; the original cause stays in the error chain.
+var (
+	// ErrNotFound indicates that the requested resource does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrConflict indicates that the operation conflicts with the current state of a resource
+	ErrConflict = errors.New("conflict")
+	// ErrValidation indicates that the input or the resource state does not allow the operation
+	ErrValidation = errors.New("validation failed")
+	// ErrForbidden indicates that the caller is not allowed to perform the operation
+	ErrForbidden = errors.New("forbidden")
+	// ErrUnauthorized indicates that the caller's credentials are missing, invalid or expired
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// notFoundError classifies a missing record error as ErrNotFound for the named resource.
+// Other errors are returned unchanged.
+func notFoundError(resource string, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%s %w: %w", resource, ErrNotFound, err)
+	}
+	return err
+}
+
+// conflictError returns an ErrConflict with the given message
+func conflictError(message string) error {
+	return fmt.Errorf("%w: %s", ErrConflict, message)
+}
+
+// validationError returns an ErrValidation with the given message
+func validationError(message string) error {
+	return fmt.Errorf("%w: %s", ErrValidation, message)
+}
+
+// forbiddenError returns an ErrForbidden with the given message
+func forbiddenError(message string) error {
+	return fmt.Errorf("%w: %s", ErrForbidden, message)
+}
+
+// unauthorizedError returns an ErrUnauthorized with the given message
+func unauthorizedError(message string) error {
+	return fmt.Errorf("%w: %s", ErrUnauthorized, message)
+}
@@ -1,26 +1,81 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/ybds/internal/models/notification"
 	"github.com/ybds/internal/repositories"
+	"github.com/ybds/pkg/email"
+	"github.com/ybds/pkg/sms"
 	"github.com/ybds/pkg/telegram"
 	"github.com/ybds/pkg/websocket"
 	"gorm.io/gorm"
 )
 
+const (
+	// DefaultNotificationMaxAttempts is how many times a channel is attempted before it stays failed
+	DefaultNotificationMaxAttempts = 5
+	// DefaultNotificationRetryBackoff is the delay before the first retry; it doubles with every failed attempt
+	DefaultNotificationRetryBackoff = 30 * time.Second
+	// MaxNotificationRetryDelay is the longest delay between two attempts of a channel
+	MaxNotificationRetryDelay = time.Hour
+	// notificationRetryBatchSize is the largest number of channels retried in one run of the retry worker
+	notificationRetryBatchSize = 100
+)
+
+// Websocket topics that clients subscribe to for notifications not addressed to a user
+const (
+	// TopicOrders receives the notifications about orders
+	TopicOrders = "orders"
+	// TopicProducts receives the notifications about products and their stock
+	TopicProducts = "products"
+)
+
+// NotificationRetrySettings holds the configurable retry behavior of failed notification channels
+type NotificationRetrySettings struct {
+	// MaxAttempts is how many times a channel is attempted, including the first attempt
+	MaxAttempts int
+	// Backoff is the delay before the first retry; it doubles with every failed attempt
+	Backoff time.Duration
+	// Interval is how often the retry worker looks for due retries; non-positive disables the worker
+	Interval time.Duration
+}
+
+// WithDefaults returns a copy of the settings with unset attempts and backoff replaced by their defaults
+func (s NotificationRetrySettings) WithDefaults() NotificationRetrySettings {
+	if s.MaxAttempts <= 0 {
+		s.MaxAttempts = DefaultNotificationMaxAttempts
+	}
+	if s.Backoff <= 0 {
+		s.Backoff = DefaultNotificationRetryBackoff
+	}
+	return s
+}
+
 // NotificationService handles notification-related business logic
 type NotificationService struct {
 	DB               *gorm.DB
 	NotificationRepo *repositories.NotificationRepository
 	WebsocketHub     *websocket.Hub
 	TelegramClient   *telegram.TelegramClient
+	SMSClient        sms.SMSClient
+	EmailClient      email.EmailClient
 	UserRepo         *repositories.UserRepository
+	// TelegramChatDiscovery allows admins to list the chats that recently messaged the bot
+	TelegramChatDiscovery bool
+	// Retry controls how failed channels are retried
+	Retry NotificationRetrySettings
+
+	// inFlight tracks the channel status updates running in the background
+	inFlight sync.WaitGroup
 }
 
 // NewNotificationService creates a new instance of NotificationService
@@ -113,16 +168,7 @@ func (s *NotificationService) CreateNotification(
 
 	// Send notifications through the appropriate channels
 	for _, channelType := range channels {
-		switch channelType {
-		case notification.ChannelWebsocket:
-			if recipientID != nil {
-				s.sendWebsocketNotification(notif)
-			}
-		case notification.ChannelTelegram:
-			s.sendTelegramNotification(notif)
-		case notification.ChannelEmail:
-			s.sendEmailNotification(notif)
-		}
+		s.sendThroughChannel(notif, channelType)
 	}
 
 	return &NotificationResult{
@@ -132,6 +178,22 @@ func (s *NotificationService) CreateNotification(
 	}, nil
 }
 
+// sendThroughChannel sends a notification through one of its channels
+func (s *NotificationService) sendThroughChannel(notif notification.Notification, channelType notification.ChannelType) {
+	switch channelType {
+	case notification.ChannelWebsocket:
+		if notif.RecipientID != nil {
+			s.sendWebsocketNotification(notif)
+		}
+	case notification.ChannelTelegram:
+		s.sendTelegramNotification(notif)
+	case notification.ChannelSMS:
+		s.sendSMSNotification(notif)
+	case notification.ChannelEmail:
+		s.sendEmailNotification(notif)
+	}
+}
+
 // sendWebsocketNotification sends a notification through websocket
 func (s *NotificationService) sendWebsocketNotification(notif notification.Notification) {
 	// Skip if websocketHub is nil
@@ -163,19 +225,29 @@ func (s *NotificationService) sendWebsocketNotification(notif notification.Notif
 		return
 	}
 
-	// Broadcast to the user if it's a user notification
+	// Broadcast to the user if it's a user notification, otherwise to the subscribers of its topic
 	if notif.RecipientType == notification.RecipientUser && notif.RecipientID != nil {
 		s.WebsocketHub.BroadcastToUser(notif.RecipientID.String(), jsonMessage)
 	} else if notif.RecipientID != nil {
-		// Use BroadcastToAll for now as a workaround
-		// TODO: Implement proper topic-based broadcasting
-		s.WebsocketHub.BroadcastToAll(jsonMessage)
+		s.WebsocketHub.BroadcastToTopic(NotificationTopic(notif), jsonMessage)
 	}
 
 	// Update the channel status
 	s.updateChannelStatus(notif.ID, notification.ChannelWebsocket, notification.ChannelSent, "Websocket message sent")
 }
 
+// NotificationTopic returns the websocket topic a notification that is not addressed to a user
+// is broadcast to: orders or products for notifications about them, otherwise the recipient type
+func NotificationTopic(notif notification.Notification) string {
+	if _, ok := notif.Metadata["order_id"]; ok {
+		return TopicOrders
+	}
+	if _, ok := notif.Metadata["product_id"]; ok {
+		return TopicProducts
+	}
+	return string(notif.RecipientType)
+}
+
 // sendTelegramNotification sends a notification through Telegram
 func (s *NotificationService) sendTelegramNotification(notif notification.Notification) {
 	// Skip if TelegramClient is nil
@@ -218,15 +290,134 @@ func (s *NotificationService) sendTelegramNotification(notif notification.Notifi
 	}
 }
 
-// sendEmailNotification sends notification through email
+// sendSMSNotification sends a notification as a text message to the recipient's phone number
+func (s *NotificationService) sendSMSNotification(notif notification.Notification) {
+	// Skip if SMSClient is nil
+	if s.SMSClient == nil {
+		return
+	}
+
+	// Only proceed if this is a user notification with a recipient ID
+	if notif.RecipientType == notification.RecipientUser && notif.RecipientID != nil {
+		// Get the user by ID using the repository
+		user, err := s.UserRepo.GetUserByID(*notif.RecipientID)
+		if err != nil {
+			fmt.Printf("Error finding user for SMS notification: %v\n", err)
+			s.updateChannelStatus(notif.ID, notification.ChannelSMS, notification.ChannelFailed, "User not found")
+			return
+		}
+
+		// Check if user has a phone number
+		if strings.TrimSpace(user.Phone) == "" {
+			fmt.Printf("User %s does not have a phone number\n", user.Username)
+			s.updateChannelStatus(notif.ID, notification.ChannelSMS, notification.ChannelFailed, "User has no phone number")
+			return
+		}
+
+		// Format the message
+		message := fmt.Sprintf("%s: %s", notif.Title, notif.Message)
+
+		// Send the message
+		if err := s.SMSClient.SendSMS(user.Phone, message); err != nil {
+			fmt.Printf("Error sending SMS notification: %v\n", err)
+			s.updateChannelStatus(notif.ID, notification.ChannelSMS, notification.ChannelFailed, err.Error())
+			return
+		}
+
+		// Update channel status
+		s.updateChannelStatus(notif.ID, notification.ChannelSMS, notification.ChannelSent, "Message sent successfully")
+	} else {
+		// Update channel status for non-user notifications
+		s.updateChannelStatus(notif.ID, notification.ChannelSMS, notification.ChannelFailed, "Unsupported recipient type")
+	}
+}
+
+// DiscoverTelegramChats lists the chats that recently messaged the bot, so that an admin
+// can pick the chat ID to link to a user
+func (s *NotificationService) DiscoverTelegramChats() ([]telegram.RecentChat, error) {
+	if !s.TelegramChatDiscovery {
+		return nil, forbiddenError("Telegram chat discovery is disabled")
+	}
+	if s.TelegramClient == nil {
+		return nil, validationError("Telegram bot token is not configured")
+	}
+
+	return s.TelegramClient.GetRecentChats()
+}
+
+// SendEmail renders a message like an email notification and sends it to the address right away,
+// without storing it as a notification. It is meant for messages carrying secrets, such as
+// password reset codes, which must not be readable from the notification list or resent by
+// the retry worker.
+func (s *NotificationService) SendEmail(to, title, message string) error {
+	if s.EmailClient == nil {
+		return errors.New("email service is not configured")
+	}
+	if strings.TrimSpace(to) == "" {
+		return errors.New("email address is required")
+	}
+
+	subject, body, err := email.RenderNotification(title, message)
+	if err != nil {
+		return err
+	}
+	return s.EmailClient.Send(to, subject, body)
+}
+
+// sendEmailNotification sends a notification as an HTML email to the recipient's email address
 func (s *NotificationService) sendEmailNotification(notif notification.Notification) {
-	// Email service is not implemented
-	s.updateChannelStatus(notif.ID, notification.ChannelEmail, notification.ChannelFailed, "Email service not implemented")
+	// Without an email client, e.g. when email is disabled, the channel cannot be delivered
+	if s.EmailClient == nil {
+		s.updateChannelStatus(notif.ID, notification.ChannelEmail, notification.ChannelFailed, "Email service not configured")
+		return
+	}
+
+	// Only proceed if this is a user notification with a recipient ID
+	if notif.RecipientType == notification.RecipientUser && notif.RecipientID != nil {
+		// Get the user by ID using the repository
+		user, err := s.UserRepo.GetUserByID(*notif.RecipientID)
+		if err != nil {
+			fmt.Printf("Error finding user for email notification: %v\n", err)
+			s.updateChannelStatus(notif.ID, notification.ChannelEmail, notification.ChannelFailed, "User not found")
+			return
+		}
+
+		// Check if user has an email address
+		if strings.TrimSpace(user.Email) == "" {
+			fmt.Printf("User %s does not have an email address\n", user.Username)
+			s.updateChannelStatus(notif.ID, notification.ChannelEmail, notification.ChannelFailed, "User has no email address")
+			return
+		}
+
+		// Render the message
+		subject, body, err := email.RenderNotification(notif.Title, notif.Message)
+		if err != nil {
+			fmt.Printf("Error rendering email notification: %v\n", err)
+			s.updateChannelStatus(notif.ID, notification.ChannelEmail, notification.ChannelFailed, err.Error())
+			return
+		}
+
+		// Send the message
+		if err := s.EmailClient.Send(user.Email, subject, body); err != nil {
+			fmt.Printf("Error sending email notification: %v\n", err)
+			s.updateChannelStatus(notif.ID, notification.ChannelEmail, notification.ChannelFailed, err.Error())
+			return
+		}
+
+		// Update channel status
+		s.updateChannelStatus(notif.ID, notification.ChannelEmail, notification.ChannelSent, "Email sent successfully")
+	} else {
+		// Update channel status for non-user notifications
+		s.updateChannelStatus(notif.ID, notification.ChannelEmail, notification.ChannelFailed, "Unsupported recipient type")
+	}
 }
 
-// updateChannelStatus updates the status of a notification channel
+// updateChannelStatus records an attempt of a notification channel and its outcome. A failed
+// channel is scheduled for a retry until the maximum number of attempts is reached.
 func (s *NotificationService) updateChannelStatus(notificationID uuid.UUID, channelType notification.ChannelType, status notification.ChannelStatus, message string) {
+	s.inFlight.Add(1)
 	go func() {
+		defer s.inFlight.Done()
 		var channel notification.Channel
 		// Get the channel using the repository instead of direct DB access
 		channels, err := s.NotificationRepo.GetChannelsByNotificationID(notificationID)
@@ -251,12 +442,21 @@ func (s *NotificationService) updateChannelStatus(notificationID uuid.UUID, chan
 		}
 
 		// Update the channel status and response
+		now := time.Now()
 		channel.Status = status
+		channel.Attempts++
 		channel.Response = notification.Response{
-			"updated_at": time.Now(),
+			"updated_at": now,
 			"message":    message,
 		}
 
+		retry := s.Retry.WithDefaults()
+		channel.NextAttemptAt = nil
+		if status == notification.ChannelFailed && channel.Attempts < retry.MaxAttempts {
+			nextAttemptAt := now.Add(retryDelay(retry.Backoff, channel.Attempts, MaxNotificationRetryDelay))
+			channel.NextAttemptAt = &nextAttemptAt
+		}
+
 		// Save the channel using the repository
 		if err := s.NotificationRepo.UpdateChannel(&channel); err != nil {
 			log.Printf("Error updating channel status: %v", err)
@@ -264,16 +464,107 @@ func (s *NotificationService) updateChannelStatus(notificationID uuid.UUID, chan
 	}()
 }
 
+// Wait blocks until the channel status updates running in the background have completed
+func (s *NotificationService) Wait() {
+	s.inFlight.Wait()
+}
+
+// RetryFailedChannels attempts the failed channels whose retry is due again and returns how many
+// were attempted. Each attempt is counted on the channel; once the maximum number of attempts is
+// reached, a channel that keeps failing is no longer retried.
+func (s *NotificationService) RetryFailedChannels() (int, error) {
+	retry := s.Retry.WithDefaults()
+	channels, err := s.NotificationRepo.GetDueChannels(time.Now(), retry.MaxAttempts, notificationRetryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, channel := range channels {
+		// Unschedule the retry first; a failed attempt schedules the next one. A channel whose
+		// client is no longer configured is thereby not picked up over and over.
+		if err := s.NotificationRepo.ClearChannelNextAttempt(channel.ID); err != nil {
+			return i, err
+		}
+		s.sendThroughChannel(channel.Notification, channel.Channel)
+	}
+
+	return len(channels), nil
+}
+
+// StartRetryWorker periodically retries the failed channels whose retry is due until the context
+// is canceled. The worker is disabled when the retry interval is not positive.
+func (s *NotificationService) StartRetryWorker(ctx context.Context) {
+	interval := s.Retry.Interval
+	if interval <= 0 {
+		log.Println("Notification retry worker is disabled")
+		return
+	}
+
+	log.Printf("Notification retry worker started (interval: %s)", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Notification retry worker stopped")
+			return
+		case <-ticker.C:
+			retried, err := s.RetryFailedChannels()
+			if err != nil {
+				log.Printf("Error retrying notification channels: %v", err)
+				continue
+			}
+			// Wait for the outcomes so the next run does not pick the same channels up again
+			s.Wait()
+			if retried > 0 {
+				log.Printf("Retried %d notification channels", retried)
+			}
+		}
+	}
+}
+
 // GetNotificationsByRecipient retrieves all notifications for a recipient
 func (s *NotificationService) GetNotificationsByRecipient(recipientID uuid.UUID, recipientType notification.RecipientType) ([]notification.Notification, error) {
 	return s.NotificationRepo.GetNotificationsByRecipient(recipientID, recipientType)
 }
 
+// GetAllNotifications retrieves notifications of all recipients with pagination and filtering
+func (s *NotificationService) GetAllNotifications(page, pageSize int, filters map[string]interface{}) ([]notification.Notification, int64, error) {
+	return s.NotificationRepo.GetAllNotifications(page, pageSize, filters)
+}
+
+// GetNotificationsByOrderID retrieves all notifications emitted for an order, oldest first
+func (s *NotificationService) GetNotificationsByOrderID(orderID uuid.UUID) ([]notification.Notification, error) {
+	return s.NotificationRepo.GetNotificationsByOrderID(orderID)
+}
+
 // GetUnreadNotificationsByRecipient retrieves all unread notifications for a recipient
 func (s *NotificationService) GetUnreadNotificationsByRecipient(recipientID uuid.UUID, recipientType notification.RecipientType) ([]notification.Notification, error) {
 	return s.NotificationRepo.GetUnreadNotificationsByRecipient(recipientID, recipientType)
 }
 
+// NotificationSummary holds the unread notification counts of a recipient
+type NotificationSummary struct {
+	TotalUnread int64
+	Events      []repositories.NotificationEventCount
+}
+
+// GetUnreadSummary counts the unread notifications of a recipient, grouped by resource and event
+func (s *NotificationService) GetUnreadSummary(recipientID uuid.UUID, recipientType notification.RecipientType) (*NotificationSummary, error) {
+	counts, err := s.NotificationRepo.CountUnreadByEvent(recipientID, recipientType)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &NotificationSummary{Events: counts}
+	for _, count := range counts {
+		summary.TotalUnread += count.Count
+	}
+	return summary, nil
+}
+
 // MarkNotificationAsRead marks a notification as read
 func (s *NotificationService) MarkNotificationAsRead(id uuid.UUID) error {
 	return s.NotificationRepo.MarkNotificationAsRead(id)
@@ -284,6 +575,55 @@ func (s *NotificationService) MarkAllNotificationsAsRead(recipientID uuid.UUID,
 	return s.NotificationRepo.MarkAllNotificationsAsRead(recipientID, recipientType)
 }
 
+// MarkNotificationsAsReadByEvent marks the unread notifications of a recipient with the given event
+// as read, optionally limited to one resource, and returns how many were marked
+func (s *NotificationService) MarkNotificationsAsReadByEvent(recipientID uuid.UUID, recipientType notification.RecipientType, event, resource string) (int64, error) {
+	return s.NotificationRepo.MarkUnreadAsReadByEvent(recipientID, recipientType, event, resource)
+}
+
+// PurgeReadNotifications permanently deletes read notifications older than the retention window
+// along with their channels. Unread notifications are kept regardless of age.
+func (s *NotificationService) PurgeReadNotifications(retention time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-retention)
+	return s.NotificationRepo.DeleteReadNotificationsBefore(cutoff)
+}
+
+// RunRetentionCleanup periodically purges read notifications older than the retention window
+// until the context is canceled. A non-positive interval or retention disables the cleanup.
+func (s *NotificationService) RunRetentionCleanup(ctx context.Context, interval, retention time.Duration) {
+	if interval <= 0 || retention <= 0 {
+		log.Println("Notification retention cleanup is disabled")
+		return
+	}
+
+	log.Printf("Notification retention cleanup started (interval: %s, retention: %s)", interval, retention)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Notification retention cleanup stopped")
+			return
+		case <-ticker.C:
+			deleted, err := s.PurgeReadNotifications(retention)
+			if err != nil {
+				log.Printf("Error purging read notifications: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("Purged %d read notifications older than %s", deleted, retention)
+			}
+		}
+	}
+}
+
+// HasUnreadStockAlert reports whether an unread stock alert for the inventory and event is still pending
+func (s *NotificationService) HasUnreadStockAlert(inventoryID uuid.UUID, event string) (bool, error) {
+	return s.NotificationRepo.HasUnreadInventoryNotification(inventoryID, event)
+}
+
 // CreateProductNotification creates a notification for a product event
 func (s *NotificationService) CreateProductNotification(productID uuid.UUID, productName string, event string, metadata map[string]interface{}) (*NotificationResult, error) {
 	// Create metadata
@@ -311,6 +651,9 @@ func (s *NotificationService) CreateProductNotification(productID uuid.UUID, pro
 	case "deleted":
 		title = "Product Removed"
 		message = fmt.Sprintf("The product '%s' has been removed from the catalog.", productName)
+	case "restored":
+		title = "Product Restored"
+		message = fmt.Sprintf("The product '%s' has been restored to the catalog.", productName)
 	case "low_stock":
 		title = "Low Stock Alert"
 		message = fmt.Sprintf("The product '%s' is running low on stock.", productName)
@@ -381,6 +724,15 @@ func (s *NotificationService) CreateProductNotification(productID uuid.UUID, pro
 	return lastResult, lastError
 }
 
+// orderReference returns the order number given in the notification metadata, falling back to the
+// start of the order ID for orders created before order numbers were introduced
+func orderReference(orderID uuid.UUID, metadata map[string]interface{}) string {
+	if orderNumber, ok := metadata["order_number"].(string); ok && orderNumber != "" {
+		return orderNumber
+	}
+	return orderID.String()[:8]
+}
+
 // CreateOrderNotification creates a notification for an order event
 func (s *NotificationService) CreateOrderNotification(orderID uuid.UUID, customerID uuid.UUID, event string, metadata map[string]interface{}) (*NotificationResult, error) {
 	// Create metadata
@@ -396,27 +748,34 @@ func (s *NotificationService) CreateOrderNotification(orderID uuid.UUID, custome
 	}
 
 	// Create title and message based on event
+	reference := orderReference(orderID, metadata)
 	title := ""
 	message := ""
 	switch event {
 	case "created":
 		title = "New Order Received"
-		message = fmt.Sprintf("A new order (#%s) has been received.", orderID.String()[:8])
+		message = fmt.Sprintf("A new order (#%s) has been received.", reference)
 	case "confirmed":
 		title = "Order Confirmed"
-		message = fmt.Sprintf("Order (#%s) has been confirmed.", orderID.String()[:8])
+		message = fmt.Sprintf("Order (#%s) has been confirmed.", reference)
 	case "shipped":
 		title = "Order Shipped"
-		message = fmt.Sprintf("Order (#%s) has been shipped.", orderID.String()[:8])
+		message = fmt.Sprintf("Order (#%s) has been shipped.", reference)
 	case "delivered":
 		title = "Order Delivered"
-		message = fmt.Sprintf("Order (#%s) has been delivered.", orderID.String()[:8])
+		message = fmt.Sprintf("Order (#%s) has been delivered.", reference)
 	case "canceled":
 		title = "Order Canceled"
-		message = fmt.Sprintf("Order (#%s) has been canceled.", orderID.String()[:8])
+		message = fmt.Sprintf("Order (#%s) has been canceled.", reference)
+	case "repriced":
+		title = "Order Repriced"
+		message = fmt.Sprintf("Order (#%s) has been repriced to current prices.", reference)
+	case "assigned":
+		title = "Order Assigned"
+		message = fmt.Sprintf("Order (#%s) has been assigned to an agent.", reference)
 	default:
 		title = "Order Update"
-		message = fmt.Sprintf("Update for order (#%s).", orderID.String()[:8])
+		message = fmt.Sprintf("Update for order (#%s).", reference)
 	}
 
 	// Find all admin users using the repository
@@ -1,12 +1,18 @@
 package services_test
 
 import (
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/models/account"
 	"github.com/ybds/internal/models/notification"
 	"github.com/ybds/internal/services"
+	"github.com/ybds/internal/testutil"
+	"gorm.io/gorm"
 )
 
 // TestNotificationService tests the NotificationService functionality
@@ -53,3 +59,349 @@ func TestMetadata(t *testing.T) {
 	assert.Equal(t, 123, metadata["key2"])
 	assert.Equal(t, true, metadata["key3"])
 }
+
+// seedNotification creates a notification with a websocket channel at the given age
+func seedNotification(t *testing.T, db *gorm.DB, title string, isRead bool, age time.Duration) *notification.Notification {
+	t.Helper()
+
+	n := &notification.Notification{
+		RecipientType: notification.RecipientUser,
+		Title:         title,
+		Message:       title,
+		Status:        notification.NotificationSent,
+		Channels: []notification.Channel{
+			{Channel: notification.ChannelWebsocket, Status: notification.ChannelSent},
+		},
+	}
+	n.CreatedAt = time.Now().Add(-age)
+	if err := db.Create(n).Error; err != nil {
+		t.Fatalf("failed to seed notification: %v", err)
+	}
+
+	// is_read defaults to false, so marking as read needs an explicit update
+	if isRead {
+		if err := db.Model(n).UpdateColumn("is_read", true).Error; err != nil {
+			t.Fatalf("failed to mark notification as read: %v", err)
+		}
+	}
+
+	return n
+}
+
+// TestPurgeReadNotifications tests that only old read notifications are purged
+func TestPurgeReadNotifications(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	service := services.NewNotificationService(db, db, nil, nil)
+
+	retention := 30 * 24 * time.Hour
+	oldRead := seedNotification(t, db, "Old read", true, 60*24*time.Hour)
+	oldUnread := seedNotification(t, db, "Old unread", false, 60*24*time.Hour)
+	recentRead := seedNotification(t, db, "Recent read", true, 24*time.Hour)
+
+	deleted, err := service.PurgeReadNotifications(retention)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	var remaining []notification.Notification
+	assert.NoError(t, db.Unscoped().Order("created_at").Find(&remaining).Error)
+	assert.Len(t, remaining, 2)
+	assert.Equal(t, oldUnread.ID, remaining[0].ID)
+	assert.Equal(t, recentRead.ID, remaining[1].ID)
+
+	var channelCount int64
+	assert.NoError(t, db.Unscoped().Model(&notification.Channel{}).
+		Where("notification_id = ?", oldRead.ID).Count(&channelCount).Error)
+	assert.Zero(t, channelCount)
+}
+
+// TestGetUnreadSummary tests that the unread notifications of a user are counted per resource and event
+func TestGetUnreadSummary(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	service := services.NewNotificationService(db, db, nil, nil)
+
+	userID := uuid.New()
+	otherUserID := uuid.New()
+	seed := func(recipientID uuid.UUID, metadata notification.Metadata, isRead bool) {
+		n := seedNotification(t, db, "Notification", isRead, time.Minute)
+		assert.NoError(t, db.Model(n).Updates(map[string]interface{}{
+			"recipient_id": recipientID,
+			"metadata":     metadata,
+		}).Error)
+	}
+
+	orderID, productID := uuid.New().String(), uuid.New().String()
+	seed(userID, notification.Metadata{"order_id": orderID, "event": "created"}, false)
+	seed(userID, notification.Metadata{"order_id": orderID, "event": "created"}, false)
+	seed(userID, notification.Metadata{"order_id": orderID, "event": "status_changed"}, false)
+	seed(userID, notification.Metadata{"product_id": productID, "event": "created"}, false)
+	seed(userID, notification.Metadata{"product_id": productID, "inventory_id": uuid.New().String(), "event": "low_stock"}, false)
+	seed(userID, notification.Metadata{"product_id": productID, "event": "low_stock"}, false)
+	// Read notifications and those of other users are not counted
+	seed(userID, notification.Metadata{"order_id": orderID, "event": "created"}, true)
+	seed(otherUserID, notification.Metadata{"order_id": orderID, "event": "created"}, false)
+
+	summary, err := service.GetUnreadSummary(userID, notification.RecipientUser)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), summary.TotalUnread)
+
+	counts := make(map[string]int64)
+	for _, count := range summary.Events {
+		counts[count.Resource+"/"+count.Event] = count.Count
+	}
+	assert.Equal(t, map[string]int64{
+		"order/created":        2,
+		"order/status_changed": 1,
+		"product/created":      1,
+		"product/low_stock":    2,
+	}, counts)
+
+	summary, err = service.GetUnreadSummary(uuid.New(), notification.RecipientUser)
+	assert.NoError(t, err)
+	assert.Zero(t, summary.TotalUnread)
+	assert.Empty(t, summary.Events)
+}
+
+// TestMarkNotificationsAsReadByEvent tests that only the unread notifications of the given event are marked as read
+func TestMarkNotificationsAsReadByEvent(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	service := services.NewNotificationService(db, db, nil, nil)
+
+	userID := uuid.New()
+	otherUserID := uuid.New()
+	seed := func(recipientID uuid.UUID, metadata notification.Metadata) *notification.Notification {
+		n := seedNotification(t, db, "Notification", false, time.Minute)
+		assert.NoError(t, db.Model(n).Updates(map[string]interface{}{
+			"recipient_id": recipientID,
+			"metadata":     metadata,
+		}).Error)
+		return n
+	}
+	isRead := func(n *notification.Notification) bool {
+		var stored notification.Notification
+		assert.NoError(t, db.First(&stored, "id = ?", n.ID).Error)
+		return stored.IsRead
+	}
+
+	orderID, productID := uuid.New().String(), uuid.New().String()
+	orderCreated := seed(userID, notification.Metadata{"order_id": orderID, "event": "created"})
+	orderCanceled := seed(userID, notification.Metadata{"order_id": orderID, "event": "canceled"})
+	productCreated := seed(userID, notification.Metadata{"product_id": productID, "event": "created"})
+	lowStock := seed(userID, notification.Metadata{"product_id": productID, "event": "low_stock"})
+	otherUser := seed(otherUserID, notification.Metadata{"order_id": orderID, "event": "created"})
+
+	// Limited to order notifications
+	updated, err := service.MarkNotificationsAsReadByEvent(userID, notification.RecipientUser, "created", "order")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+	assert.True(t, isRead(orderCreated))
+	assert.False(t, isRead(productCreated))
+
+	// Every resource
+	updated, err = service.MarkNotificationsAsReadByEvent(userID, notification.RecipientUser, "created", "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+	assert.True(t, isRead(productCreated))
+
+	// Other events and other recipients are untouched
+	assert.False(t, isRead(orderCanceled))
+	assert.False(t, isRead(lowStock))
+	assert.False(t, isRead(otherUser))
+}
+
+// mockSMSClient records the text messages sent through it and fails for the configured numbers
+type mockSMSClient struct {
+	mu      sync.Mutex
+	sent    map[string]string
+	failFor map[string]bool
+}
+
+// SendSMS records the message unless sending to the phone number is set to fail
+func (c *mockSMSClient) SendSMS(phone, message string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failFor[phone] {
+		return errors.New("provider rejected the number")
+	}
+	c.sent[phone] = message
+	return nil
+}
+
+// TestSMSNotification tests that SMS notifications are sent to the recipient's phone number and
+// that the channel is marked as failed when the user has no phone number or sending fails
+func TestSMSNotification(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	client := &mockSMSClient{sent: make(map[string]string), failFor: map[string]bool{"0987654321": true}}
+	service := services.NewNotificationService(db, db, nil, nil)
+	service.SMSClient = client
+
+	withPhone := seedUser(t, db, "sms_with_phone", account.RoleAgent, true)
+	assert.NoError(t, db.Model(withPhone).Update("phone", "0912345678").Error)
+	withoutPhone := seedUser(t, db, "sms_without_phone", account.RoleAgent, true)
+	rejected := seedUser(t, db, "sms_rejected", account.RoleAgent, true)
+	assert.NoError(t, db.Model(rejected).Update("phone", "0987654321").Error)
+
+	send := func(user *account.User) uuid.UUID {
+		t.Helper()
+		result, err := service.CreateNotification(&user.ID, notification.RecipientUser, "Order shipped",
+			"Your order YB-001 is on its way", notification.Metadata{}, []notification.ChannelType{notification.ChannelSMS})
+		assert.NoError(t, err)
+		return result.NotificationID
+	}
+	channelStatus := func(notificationID uuid.UUID) (notification.ChannelStatus, string) {
+		var channel notification.Channel
+		assert.NoError(t, db.First(&channel, "notification_id = ? AND channel = ?", notificationID, notification.ChannelSMS).Error)
+		message, _ := channel.Response["message"].(string)
+		return channel.Status, message
+	}
+	assertChannel := func(notificationID uuid.UUID, status notification.ChannelStatus, message string) {
+		t.Helper()
+		assert.Eventually(t, func() bool {
+			got, gotMessage := channelStatus(notificationID)
+			return got == status && gotMessage == message
+		}, 2*time.Second, 20*time.Millisecond)
+	}
+
+	assertChannel(send(withPhone), notification.ChannelSent, "Message sent successfully")
+	client.mu.Lock()
+	assert.Equal(t, "Order shipped: Your order YB-001 is on its way", client.sent["0912345678"])
+	client.mu.Unlock()
+
+	assertChannel(send(withoutPhone), notification.ChannelFailed, "User has no phone number")
+	assertChannel(send(rejected), notification.ChannelFailed, "provider rejected the number")
+}
+
+// mockEmailClient records the emails sent through it
+type mockEmailClient struct {
+	mu   sync.Mutex
+	sent map[string][2]string
+}
+
+// Send records the subject and body sent to the address
+func (c *mockEmailClient) Send(to, subject, htmlBody string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent[to] = [2]string{subject, htmlBody}
+	return nil
+}
+
+// TestEmailNotification tests that email notifications are rendered and sent to the recipient's
+// email address, and marked as failed while email is disabled
+func TestEmailNotification(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	service := services.NewNotificationService(db, db, nil, nil)
+	user := seedUser(t, db, "email_user", account.RoleAgent, true)
+
+	send := func() uuid.UUID {
+		t.Helper()
+		result, err := service.CreateNotification(&user.ID, notification.RecipientUser, "Order shipped",
+			"Your order YB-001 is on its way", notification.Metadata{}, []notification.ChannelType{notification.ChannelEmail})
+		assert.NoError(t, err)
+		return result.NotificationID
+	}
+	assertChannel := func(notificationID uuid.UUID, status notification.ChannelStatus, message string) {
+		t.Helper()
+		assert.Eventually(t, func() bool {
+			var channel notification.Channel
+			if err := db.First(&channel, "notification_id = ? AND channel = ?", notificationID, notification.ChannelEmail).Error; err != nil {
+				return false
+			}
+			return channel.Status == status && channel.Response["message"] == message
+		}, 2*time.Second, 20*time.Millisecond)
+	}
+
+	// Without an email client the channel is marked as failed
+	assertChannel(send(), notification.ChannelFailed, "Email service not configured")
+
+	client := &mockEmailClient{sent: make(map[string][2]string)}
+	service.EmailClient = client
+	assertChannel(send(), notification.ChannelSent, "Email sent successfully")
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	sent, ok := client.sent[user.Email]
+	if assert.True(t, ok) {
+		assert.Equal(t, "Order shipped", sent[0])
+		assert.Contains(t, sent[1], "<h2>Order shipped</h2>")
+		assert.Contains(t, sent[1], "<p>Your order YB-001 is on its way</p>")
+	}
+}
+
+// TestRetryFailedChannels tests that a transient failure is retried until the channel is sent
+// and that a channel that keeps failing stops being retried after the maximum number of attempts
+func TestRetryFailedChannels(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	client := &mockSMSClient{sent: make(map[string]string), failFor: map[string]bool{"0912345678": true, "0987654321": true}}
+	service := services.NewNotificationService(db, db, nil, nil)
+	service.SMSClient = client
+	service.Retry = services.NotificationRetrySettings{MaxAttempts: 3, Backoff: time.Millisecond}
+
+	transient := seedUser(t, db, "retry_transient", account.RoleAgent, true)
+	assert.NoError(t, db.Model(transient).Update("phone", "0912345678").Error)
+	broken := seedUser(t, db, "retry_broken", account.RoleAgent, true)
+	assert.NoError(t, db.Model(broken).Update("phone", "0987654321").Error)
+
+	send := func(user *account.User) uuid.UUID {
+		t.Helper()
+		result, err := service.CreateNotification(&user.ID, notification.RecipientUser, "Order shipped",
+			"Your order YB-001 is on its way", notification.Metadata{}, []notification.ChannelType{notification.ChannelSMS})
+		assert.NoError(t, err)
+		return result.NotificationID
+	}
+	channel := func(notificationID uuid.UUID) notification.Channel {
+		t.Helper()
+		var stored notification.Channel
+		assert.NoError(t, db.First(&stored, "notification_id = ?", notificationID).Error)
+		return stored
+	}
+	retry := func() int {
+		t.Helper()
+		time.Sleep(10 * time.Millisecond) // Let the backoff pass
+		retried, err := service.RetryFailedChannels()
+		assert.NoError(t, err)
+		service.Wait()
+		return retried
+	}
+
+	transientID := send(transient)
+	brokenID := send(broken)
+	service.Wait()
+
+	failed := channel(transientID)
+	assert.Equal(t, notification.ChannelFailed, failed.Status)
+	assert.Equal(t, 1, failed.Attempts)
+	assert.NotNil(t, failed.NextAttemptAt)
+
+	// The provider recovers for the first number; the retry succeeds
+	client.mu.Lock()
+	delete(client.failFor, "0912345678")
+	client.mu.Unlock()
+	assert.Equal(t, 2, retry())
+
+	sent := channel(transientID)
+	assert.Equal(t, notification.ChannelSent, sent.Status)
+	assert.Equal(t, 2, sent.Attempts)
+	assert.Nil(t, sent.NextAttemptAt)
+
+	// The other number keeps failing until the maximum number of attempts is reached
+	assert.Equal(t, 1, retry())
+	exhausted := channel(brokenID)
+	assert.Equal(t, notification.ChannelFailed, exhausted.Status)
+	assert.Equal(t, 3, exhausted.Attempts)
+	assert.Nil(t, exhausted.NextAttemptAt)
+	assert.Equal(t, 0, retry())
+}
+
+// TestNotificationTopic tests that notifications not addressed to a user are routed to the topic of their resource
+func TestNotificationTopic(t *testing.T) {
+	assert.Equal(t, services.TopicOrders, services.NotificationTopic(notification.Notification{
+		RecipientType: notification.RecipientPartner,
+		Metadata:      notification.Metadata{"order_id": uuid.New().String()},
+	}))
+	assert.Equal(t, services.TopicProducts, services.NotificationTopic(notification.Notification{
+		RecipientType: notification.RecipientPartner,
+		Metadata:      notification.Metadata{"product_id": uuid.New().String(), "inventory_id": uuid.New().String()},
+	}))
+	assert.Equal(t, "partner", services.NotificationTopic(notification.Notification{
+		RecipientType: notification.RecipientPartner,
+	}))
+}
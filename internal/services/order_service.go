@@ -1,15 +1,161 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"mime/multipart"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/ybds/internal/models/account"
+	"github.com/ybds/internal/models/notification"
 	"github.com/ybds/internal/models/order"
+	"github.com/ybds/internal/models/product"
 	"github.com/ybds/internal/repositories"
+	"github.com/ybds/internal/utils"
+	"github.com/ybds/pkg/currency"
+	"github.com/ybds/pkg/phone"
+	"github.com/ybds/pkg/shipping/ghn"
+	"github.com/ybds/pkg/upload"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// DefaultVolumetricDivisor is the package volume in cubic centimeters that counts as one kilogram
+const DefaultVolumetricDivisor = 5000
+
+// DefaultDiscountReasonMaxLength is the longest discount reason that fits the discount_reason column
+const DefaultDiscountReasonMaxLength = 255
+
+// DefaultBulkStatusMaxOrders is the largest number of orders a single bulk status update may change
+const DefaultBulkStatusMaxOrders = 200
+
+// DefaultCurrency is the currency orders are priced in when none is configured
+const DefaultCurrency = "VND"
+
+// DefaultCurrencyLocale is the locale used to format amounts in responses
+const DefaultCurrencyLocale = "en-US"
+
+// OrderSettings holds the configurable behavior of the OrderService
+type OrderSettings struct {
+	// VolumetricDivisor is the package volume in cubic centimeters that counts as one kilogram
+	VolumetricDivisor float64
+	// DiscountApprovalAmount is the largest discount a non-admin may apply; 0 disables the limit
+	DiscountApprovalAmount float64
+	// DiscountApprovalPercent is the largest discount a non-admin may apply as a percentage
+	// of the order total; 0 disables the limit
+	DiscountApprovalPercent float64
+	// OrderNumberPrefix is the prefix of human-readable order numbers
+	OrderNumberPrefix string
+	// OrderNumberReset is how often the order number sequence restarts from 1
+	OrderNumberReset order.OrderNumberReset
+	// AgentTargetStatuses are the statuses an agent may move an order to
+	AgentTargetStatuses []order.OrderStatus
+	// CheckAvailableToPromise makes stock checks subtract the quantities committed to open orders
+	// instead of comparing against the raw inventory quantity
+	CheckAvailableToPromise bool
+	// ReserveOnCreate takes the stock of new orders off the inventory when they are created, so
+	// concurrent orders cannot oversell it; otherwise the stock is only taken when the order is packed
+	ReserveOnCreate bool
+	// BulkStatusMaxOrders is the largest number of orders a bulk status update may change
+	BulkStatusMaxOrders int
+	// RequireDeliveryProof rejects moving an order to delivered until a delivery proof is recorded
+	RequireDeliveryProof bool
+	// ShippingSender is the sender printed on shipping labels
+	ShippingSender ShippingParty
+	// DiscountReasonRequired rejects orders with a discount but no discount reason
+	DiscountReasonRequired bool
+	// DiscountReasonMaxLength is the largest number of characters a discount reason may have
+	DiscountReasonMaxLength int
+	// AdminEditHandedOver lets admins edit the items and details of orders already
+	// handed to the carrier
+	AdminEditHandedOver bool
+	// Currency is the ISO 4217 currency new orders are priced in; item prices listed in another
+	// currency are converted to it
+	Currency string
+	// CurrencyLocale is the locale (e.g. en-US or vi-VN) used to format amounts in responses
+	CurrencyLocale string
+	// GHNWebhookSecret is the token GHN webhooks must carry in the X-GHN-Token header;
+	// GHN webhooks are rejected while it is empty
+	GHNWebhookSecret string
+	// GHNStatusMapper maps the statuses reported by GHN webhooks to order statuses
+	GHNStatusMapper *ghn.StatusMapper
+}
+
+// DefaultOrderSettings returns the settings used when none are configured
+func DefaultOrderSettings() OrderSettings {
+	return OrderSettings{
+		VolumetricDivisor:       DefaultVolumetricDivisor,
+		OrderNumberPrefix:       order.OrderNumberPrefix,
+		OrderNumberReset:        order.OrderNumberResetDaily,
+		AgentTargetStatuses:     []order.OrderStatus{order.OrderPacked, order.OrderCanceled},
+		ReserveOnCreate:         true,
+		BulkStatusMaxOrders:     DefaultBulkStatusMaxOrders,
+		DiscountReasonMaxLength: DefaultDiscountReasonMaxLength,
+		Currency:                DefaultCurrency,
+		CurrencyLocale:          DefaultCurrencyLocale,
+		GHNStatusMapper:         ghn.NewStatusMapper(),
+	}
+}
+
+// WithDefaults returns a copy of the settings with unset values replaced by their defaults
+func (s OrderSettings) WithDefaults() OrderSettings {
+	defaults := DefaultOrderSettings()
+	if s.VolumetricDivisor <= 0 {
+		s.VolumetricDivisor = defaults.VolumetricDivisor
+	}
+	// Order numbers are looked up case-insensitively, so the prefix is stored in upper case
+	s.OrderNumberPrefix = strings.ToUpper(strings.TrimSpace(s.OrderNumberPrefix))
+	if s.OrderNumberPrefix == "" {
+		s.OrderNumberPrefix = defaults.OrderNumberPrefix
+	}
+	if !s.OrderNumberReset.IsValid() {
+		s.OrderNumberReset = defaults.OrderNumberReset
+	}
+	if len(s.AgentTargetStatuses) == 0 {
+		s.AgentTargetStatuses = defaults.AgentTargetStatuses
+	}
+	if s.BulkStatusMaxOrders <= 0 {
+		s.BulkStatusMaxOrders = defaults.BulkStatusMaxOrders
+	}
+	if s.DiscountReasonMaxLength <= 0 || s.DiscountReasonMaxLength > DefaultDiscountReasonMaxLength {
+		s.DiscountReasonMaxLength = defaults.DiscountReasonMaxLength
+	}
+	s.Currency = strings.ToUpper(strings.TrimSpace(s.Currency))
+	if s.Currency == "" {
+		s.Currency = defaults.Currency
+	}
+	if s.CurrencyLocale == "" {
+		s.CurrencyLocale = defaults.CurrencyLocale
+	}
+	if s.GHNStatusMapper == nil {
+		s.GHNStatusMapper = defaults.GHNStatusMapper
+	}
+	return s
+}
+
+// AgentCanSetStatus reports whether an agent may move an order to the given status
+func (s OrderSettings) AgentCanSetStatus(status order.OrderStatus) bool {
+	return containsOrderStatus(s.AgentTargetStatuses, status)
+}
+
+// DiscountRequiresApproval reports whether a discount on an order with the given total
+// exceeds the absolute or percentage approval threshold
+func (s OrderSettings) DiscountRequiresApproval(totalAmount, discountAmount float64) bool {
+	if s.DiscountApprovalAmount > 0 && discountAmount > s.DiscountApprovalAmount {
+		return true
+	}
+	if s.DiscountApprovalPercent > 0 && discountAmount > totalAmount*s.DiscountApprovalPercent/100 {
+		return true
+	}
+	return false
+}
+
 // OrderService handles order-related business logic
 type OrderService struct {
 	DB                  *gorm.DB
@@ -17,6 +163,10 @@ type OrderService struct {
 	ProductService      *ProductService
 	UserService         *UserService
 	NotificationService *NotificationService
+	WebhookService      *WebhookService
+	UploadService       *upload.Service
+	CurrencyService     *CurrencyService
+	Settings            OrderSettings
 }
 
 // NewOrderService creates a new instance of OrderService
@@ -27,6 +177,73 @@ func NewOrderService(db *gorm.DB, productService *ProductService, userService *U
 		ProductService:      productService,
 		UserService:         userService,
 		NotificationService: notificationService,
+		Settings:            DefaultOrderSettings(),
+	}
+}
+
+// WithContext returns a copy of the service whose order and product queries run with the
+// given context, so they are aborted when the context is canceled or its deadline passes
+func (s *OrderService) WithContext(ctx context.Context) *OrderService {
+	clone := *s
+	clone.DB = s.DB.WithContext(ctx)
+	clone.OrderRepo = s.OrderRepo.WithContext(ctx)
+	if s.ProductService != nil {
+		clone.ProductService = s.ProductService.WithContext(ctx)
+	}
+	return &clone
+}
+
+// notifyOrder sends an order notification without affecting the operation that triggered it.
+// Notifications are stored in their own database, so an error or even a panic while sending
+// one is only logged and never fails or rolls back the order change. The order number, when the
+// order has one, is added to the metadata so the notification message can refer to it.
+func (s *OrderService) notifyOrder(orderID uuid.UUID, orderNumber string, recipientID uuid.UUID, event string, metadata map[string]interface{}) {
+	if s.NotificationService == nil {
+		return
+	}
+	if orderNumber != "" {
+		if metadata == nil {
+			metadata = make(map[string]interface{})
+		}
+		metadata["order_number"] = orderNumber
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered from panic while sending %s notification for order %s: %v", event, orderID, r)
+		}
+	}()
+
+	if _, err := s.NotificationService.CreateOrderNotification(orderID, recipientID, event, metadata); err != nil {
+		log.Printf("Failed to send %s notification for order %s: %v", event, orderID, err)
+	}
+}
+
+// dispatchWebhook pushes an order event to the subscribed webhook endpoints without affecting the
+// operation that triggered it. The payload holds a summary of the order and the given extra fields.
+func (s *OrderService) dispatchWebhook(event order.WebhookEvent, o *order.Order, extra map[string]interface{}) {
+	if s.WebhookService == nil {
+		return
+	}
+
+	data := map[string]interface{}{
+		"order_id":       o.ID.String(),
+		"order_number":   o.OrderNumber,
+		"status":         string(o.OrderStatus),
+		"payment_method": string(o.PaymentMethod),
+		"total_amount":   o.TotalAmount,
+		"final_amount":   o.FinalTotalAmount,
+		"customer_name":  o.CustomerName,
+		"customer_phone": o.CustomerPhone,
+		"created_at":     o.CreatedAt,
+		"updated_at":     o.UpdatedAt,
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+
+	if err := s.WebhookService.Dispatch(event, &o.ID, data); err != nil {
+		log.Printf("Failed to dispatch %s webhook for order %s: %v", event, o.ID, err)
 	}
 }
 
@@ -36,6 +253,7 @@ type OrderResult struct {
 	Message        string
 	Error          string
 	OrderID        uuid.UUID
+	OrderNumber    string
 	Status         order.OrderStatus
 	Total          float64
 	DiscountAmount float64
@@ -50,9 +268,21 @@ type OrderItemInfo struct {
 	Quantity    int
 }
 
+// validateItemQuantity ensures an order item has at least one unit
+func validateItemQuantity(quantity int) error {
+	if quantity < 1 {
+		return validationError("quantity must be at least 1")
+	}
+	return nil
+}
+
 // GetOrderByID retrieves an order by ID
 func (s *OrderService) GetOrderByID(id uuid.UUID) (*order.Order, error) {
-	return s.OrderRepo.GetOrderByID(id)
+	o, err := s.OrderRepo.GetOrderByID(id)
+	if err != nil {
+		return nil, notFoundError("order", err)
+	}
+	return o, nil
 }
 
 // GetAllOrders retrieves all orders with pagination and filtering
@@ -60,11 +290,18 @@ func (s *OrderService) GetAllOrders(page, pageSize int, filters map[string]inter
 	return s.OrderRepo.GetAllOrders(page, pageSize, filters)
 }
 
-// CreateOrder creates a new order
+// GetShipments retrieves shipments with their order summary, with pagination and filtering
+func (s *OrderService) GetShipments(page, pageSize int, filters map[string]interface{}) ([]repositories.ShipmentListItem, int64, error) {
+	return s.OrderRepo.GetShipments(page, pageSize, filters)
+}
+
+// CreateOrder creates a new order. Discounts above the approval threshold can only be given by
+// admins.
 func (s *OrderService) CreateOrder(
 	paymentMethod order.PaymentMethod,
 	items []OrderItemInfo,
 	discountAmount float64,
+	discountPercent *float64,
 	discountReason string,
 	createdByID *uuid.UUID,
 	shippingAddress string,
@@ -76,6 +313,7 @@ func (s *OrderService) CreateOrder(
 	customerEmail string,
 	customerPhone string,
 	notes string,
+	isAdmin bool,
 ) (*OrderResult, error) {
 	// Validate input
 	if createdByID == nil {
@@ -94,9 +332,41 @@ func (s *OrderService) CreateOrder(
 		}, fmt.Errorf("at least one item is required")
 	}
 
+	if err := validateDiscountMode(discountAmount, discountPercent); err != nil {
+		return &OrderResult{
+			Success: false,
+			Message: "Order creation failed",
+			Error:   err.Error(),
+		}, err
+	}
+
+	// Validate item quantities
+	for i, item := range items {
+		if err := validateItemQuantity(item.Quantity); err != nil {
+			return &OrderResult{
+				Success: false,
+				Message: "Order creation failed",
+				Error:   fmt.Sprintf("Item %d: quantity must be at least 1", i),
+			}, err
+		}
+	}
+
+	// Store the customer phone number in E.164 format, so every order of a number matches it
+	if customerPhone != "" {
+		normalized, err := phone.Normalize(customerPhone)
+		if err != nil {
+			return &OrderResult{
+				Success: false,
+				Message: "Order creation failed",
+				Error:   err.Error(),
+			}, validationError(err.Error())
+		}
+		customerPhone = normalized
+	}
+
 	// Check inventory availability for all items
 	for _, item := range items {
-		available, err := s.ProductService.CheckInventoryAvailability(item.InventoryID, item.Quantity)
+		available, err := s.checkInventoryAvailability(item.InventoryID, item.Quantity)
 		if err != nil {
 			return &OrderResult{
 				Success: false,
@@ -111,7 +381,7 @@ func (s *OrderService) CreateOrder(
 				Success: false,
 				Message: "Order creation failed",
 				Error:   fmt.Sprintf("Not enough inventory for product %s", inventory.ProductID),
-			}, fmt.Errorf("not enough inventory for product %s", inventory.ProductID)
+			}, conflictError(fmt.Sprintf("not enough inventory for product %s", inventory.ProductID))
 		}
 	}
 
@@ -125,13 +395,26 @@ func (s *OrderService) CreateOrder(
 		}, tx.Error
 	}
 
+	// Generate the human-readable order number
+	orderNumber, err := s.nextOrderNumber(tx, time.Now())
+	if err != nil {
+		tx.Rollback()
+		return &OrderResult{
+			Success: false,
+			Message: "Order creation failed",
+			Error:   "Error generating order number",
+		}, err
+	}
+
 	// Create order
 	o := &order.Order{
+		OrderNumber:      orderNumber,
 		PaymentMethod:    paymentMethod,
 		OrderStatus:      order.OrderShipmentRequested,
 		TotalAmount:      0,
 		DiscountAmount:   discountAmount,
 		DiscountReason:   discountReason,
+		DiscountPercent:  discountPercent,
 		FinalTotalAmount: 0, // Will be calculated later
 		Notes:            notes,
 		// Shipping address fields
@@ -151,6 +434,22 @@ func (s *OrderService) CreateOrder(
 		o.CreatedBy = createdByID
 	}
 
+	// Link the order to the customer profile of its phone number
+	if customerPhone != "" {
+		customer, err := repositories.NewCustomerRepository(tx).UpsertCustomer(customerPhone, customerName, customerEmail)
+		if err != nil {
+			tx.Rollback()
+			return &OrderResult{
+				Success: false,
+				Message: "Order creation failed",
+				Error:   "Error saving customer",
+			}, err
+		}
+		o.CustomerID = &customer.ID
+	}
+
+	o.Currency = s.orderCurrency(o)
+
 	if err := tx.Create(o).Error; err != nil {
 		tx.Rollback()
 		return &OrderResult{
@@ -161,7 +460,7 @@ func (s *OrderService) CreateOrder(
 	}
 
 	// Add items to order
-	totalAmount := 0.0
+	lines := make([]OrderLinePricing, 0, len(items))
 	for _, item := range items {
 		// Get inventory for product ID
 		inventory, err := s.ProductService.GetInventoryByID(item.InventoryID)
@@ -174,8 +473,8 @@ func (s *OrderService) CreateOrder(
 			}, err
 		}
 
-		// Get current price
-		price, err := s.ProductService.GetCurrentPrice(inventory.ProductID)
+		// Get the current unit price for the ordered quantity
+		price, listedPrice, err := s.ProductService.GetPriceForQuantity(inventory.ProductID, item.Quantity)
 		if err != nil {
 			tx.Rollback()
 			return &OrderResult{
@@ -185,12 +484,27 @@ func (s *OrderService) CreateOrder(
 			}, fmt.Errorf("no valid price found for product %s", inventory.ProductID)
 		}
 
+		// Charge the item in the order currency
+		itemPrice, err := s.convertItemPrice(price, listedPrice, o.Currency)
+		if err != nil {
+			tx.Rollback()
+			return &OrderResult{
+				Success: false,
+				Message: "Order creation failed",
+				Error:   err.Error(),
+			}, err
+		}
+		unitPrice := itemPrice.UnitPrice
+
 		// Create order item
 		orderItem := &order.OrderItem{
-			OrderID:      o.ID,
-			InventoryID:  item.InventoryID,
-			Quantity:     item.Quantity,
-			PriceAtOrder: price.Price,
+			OrderID:          o.ID,
+			InventoryID:      item.InventoryID,
+			Quantity:         item.Quantity,
+			PriceAtOrder:     unitPrice,
+			Currency:         o.Currency,
+			OriginalPrice:    itemPrice.OriginalPrice,
+			OriginalCurrency: itemPrice.OriginalCurrency,
 		}
 
 		if err := tx.Create(orderItem).Error; err != nil {
@@ -202,21 +516,83 @@ func (s *OrderService) CreateOrder(
 			}, err
 		}
 
-		// Update total amount
-		totalAmount += price.Price * float64(item.Quantity)
+		lines = append(lines, OrderLinePricing{
+			InventoryID: item.InventoryID,
+			ProductID:   inventory.ProductID,
+			Quantity:    item.Quantity,
+			UnitPrice:   unitPrice,
+		})
 	}
 
-	// Update order total
-	o.TotalAmount = totalAmount
+	// A percentage discount is taken of the order total; no discount may exceed the total
+	subtotal := CalculateOrderPricing(lines, 0).Subtotal
+	if discountPercent != nil {
+		discountAmount = percentOf(subtotal, *discountPercent)
+		o.DiscountAmount = discountAmount
+	}
+	if err := validateDiscountAgainstTotal(discountAmount, subtotal); err != nil {
+		tx.Rollback()
+		return &OrderResult{
+			Success: false,
+			Message: "Order creation failed",
+			Error:   err.Error(),
+		}, err
+	}
 
-	// Calculate final total amount (after discount)
-	o.FinalTotalAmount = totalAmount - discountAmount
-	if o.FinalTotalAmount < 0 {
-		o.FinalTotalAmount = 0 // Ensure final amount is not negative
+	// Large discounts require admin approval
+	if !isAdmin && s.Settings.DiscountRequiresApproval(subtotal, discountAmount) {
+		tx.Rollback()
+		return &OrderResult{
+			Success: false,
+			Message: "Order creation failed",
+			Error:   "Discount requires admin approval",
+		}, forbiddenError("discount requires admin approval")
+	}
+
+	// Take the stock off the inventory; the check and the decrement are atomic per inventory, so
+	// of two orders competing for the last units only one gets them
+	if s.Settings.ReserveOnCreate {
+		if err := s.reserveOrderItems(o.ID, items); err != nil {
+			tx.Rollback()
+			message := "Error reserving inventory"
+			if errors.Is(err, ErrConflict) {
+				message = "Not enough inventory"
+			}
+			return &OrderResult{
+				Success: false,
+				Message: "Order creation failed",
+				Error:   message,
+			}, err
+		}
+		o.InventoryReserved = true
+	}
+
+	// Count the sold quantities towards product popularity; the counts are taken back again when
+	// the order is not created
+	var undo productUndo
+	for _, item := range items {
+		if err := s.adjustSoldCount(item.InventoryID, item.Quantity, &undo); err != nil {
+			tx.Rollback()
+			undo.run()
+			s.releaseCreatedOrderItems(o, items)
+			return &OrderResult{
+				Success: false,
+				Message: "Order creation failed",
+				Error:   "Error updating product sold count",
+			}, err
+		}
 	}
+	o.SalesCounted = true
+
+	// Update order totals
+	pricing := CalculateOrderPricing(lines, discountAmount)
+	o.TotalAmount = pricing.Subtotal
+	o.FinalTotalAmount = pricing.FinalTotal
 
 	if err := tx.Save(o).Error; err != nil {
 		tx.Rollback()
+		undo.run()
+		s.releaseCreatedOrderItems(o, items)
 		return &OrderResult{
 			Success: false,
 			Message: "Order creation failed",
@@ -226,6 +602,8 @@ func (s *OrderService) CreateOrder(
 
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
+		undo.run()
+		s.releaseCreatedOrderItems(o, items)
 		return &OrderResult{
 			Success: false,
 			Message: "Order creation failed",
@@ -250,25 +628,26 @@ func (s *OrderService) CreateOrder(
 			"created_by":      createdByID.String(),
 			"payment_method":  string(paymentMethod),
 			"order_status":    string(o.OrderStatus),
-			"total_amount":    totalAmount,
+			"total_amount":    pricing.Subtotal,
 			"discount_amount": discountAmount,
 			"final_amount":    o.FinalTotalAmount,
 			"number_of_items": len(items),
 		}
 
-		notificationResult, err := s.NotificationService.CreateOrderNotification(o.ID, *createdByID, "created", metadata)
-		if err != nil {
-			log.Printf("Failed to create order notification: %v", err)
-		}
-		log.Println("CreateOrderNotification result day ne ma", notificationResult)
+		s.notifyOrder(o.ID, o.OrderNumber, *createdByID, "created", metadata)
 	}
 
+	s.dispatchWebhook(order.WebhookOrderCreated, o, map[string]interface{}{
+		"number_of_items": len(items),
+	})
+
 	return &OrderResult{
 		Success:        true,
 		Message:        "Order created successfully",
 		OrderID:        o.ID,
+		OrderNumber:    o.OrderNumber,
 		Status:         o.OrderStatus,
-		Total:          totalAmount,
+		Total:          pricing.Subtotal,
 		DiscountAmount: discountAmount,
 		DiscountReason: discountReason,
 		FinalTotal:     o.FinalTotalAmount,
@@ -276,8 +655,20 @@ func (s *OrderService) CreateOrder(
 	}, nil
 }
 
-// UpdateOrderStatus updates the status of an order
+// StatusChange describes who changed the status of an order and why. It is recorded in the
+// status history of the order; a nil ChangedBy records a change made by the system.
+type StatusChange struct {
+	ChangedBy *uuid.UUID
+	Note      string
+}
+
+// UpdateOrderStatus updates the status of an order on behalf of the system
 func (s *OrderService) UpdateOrderStatus(id uuid.UUID, status order.OrderStatus) (*OrderResult, error) {
+	return s.ChangeOrderStatus(id, status, StatusChange{})
+}
+
+// ChangeOrderStatus updates the status of an order and records the change in its status history
+func (s *OrderService) ChangeOrderStatus(id uuid.UUID, status order.OrderStatus, change StatusChange) (*OrderResult, error) {
 	// Get the order
 	o, err := s.OrderRepo.GetOrderByID(id)
 	if err != nil {
@@ -285,7 +676,7 @@ func (s *OrderService) UpdateOrderStatus(id uuid.UUID, status order.OrderStatus)
 			Success: false,
 			Message: "Order status update failed",
 			Error:   "Order not found",
-		}, err
+		}, notFoundError("order", err)
 	}
 
 	// NOTE: The order status flow has been updated.
@@ -299,7 +690,16 @@ func (s *OrderService) UpdateOrderStatus(id uuid.UUID, status order.OrderStatus)
 			Success: false,
 			Message: "Order status update failed",
 			Error:   fmt.Sprintf("Invalid status transition from %s to %s", o.OrderStatus, status),
-		}, fmt.Errorf("invalid status transition from %s to %s", o.OrderStatus, status)
+		}, validationError(fmt.Sprintf("invalid status transition from %s to %s", o.OrderStatus, status))
+	}
+
+	// Check that the hand-over was documented before the order counts as delivered
+	if status == order.OrderDelivered && s.Settings.RequireDeliveryProof && !o.HasDeliveryProof() {
+		return &OrderResult{
+			Success: false,
+			Message: "Order status update failed",
+			Error:   "Delivery proof is required before the order can be marked as delivered",
+		}, validationError("delivery proof is required before the order can be marked as delivered")
 	}
 
 	// Start transaction
@@ -314,6 +714,15 @@ func (s *OrderService) UpdateOrderStatus(id uuid.UUID, status order.OrderStatus)
 
 	oldStatus := o.OrderStatus
 
+	// Stock changes in the product database are reverted unless the status change commits
+	var undo productUndo
+	committed := false
+	defer func() {
+		if !committed {
+			undo.run()
+		}
+	}()
+
 	// Update order status
 	if err := tx.Model(o).Update("order_status", status).Error; err != nil {
 		tx.Rollback()
@@ -324,8 +733,26 @@ func (s *OrderService) UpdateOrderStatus(id uuid.UUID, status order.OrderStatus)
 		}, err
 	}
 
+	// Record the change in the status history
+	history := &order.OrderStatusHistory{
+		OrderID:    o.ID,
+		FromStatus: oldStatus,
+		ToStatus:   status,
+		ChangedBy:  change.ChangedBy,
+		Note:       strings.TrimSpace(change.Note),
+	}
+	history.CreatedBy = change.ChangedBy
+	if err := repositories.NewOrderRepository(tx).CreateStatusHistory(history); err != nil {
+		tx.Rollback()
+		return &OrderResult{
+			Success: false,
+			Message: "Order status update failed",
+			Error:   "Error recording status history",
+		}, err
+	}
+
 	// Handle inventory updates based on status change
-	if err := s.handleInventoryForStatusChange(tx, o, oldStatus, status); err != nil {
+	if err := s.handleInventoryForStatusChange(tx, o, oldStatus, status, &undo); err != nil {
 		tx.Rollback()
 		return &OrderResult{
 			Success: false,
@@ -342,9 +769,10 @@ func (s *OrderService) UpdateOrderStatus(id uuid.UUID, status order.OrderStatus)
 			Error:   "Error committing transaction",
 		}, err
 	}
+	committed = true
 
 	// Send notification
-	if s.NotificationService != nil {
+	if s.NotificationService != nil && o.CreatedBy != nil {
 		metadata := map[string]interface{}{
 			"order_id":   o.ID.String(),
 			"created_by": o.CreatedBy.String(),
@@ -366,9 +794,14 @@ func (s *OrderService) UpdateOrderStatus(id uuid.UUID, status order.OrderStatus)
 			event = "updated"
 		}
 
-		s.NotificationService.CreateOrderNotification(o.ID, *o.CreatedBy, event, metadata)
+		s.notifyOrder(o.ID, o.OrderNumber, *o.CreatedBy, event, metadata)
 	}
 
+	s.dispatchWebhook(order.WebhookOrderStatusChanged, o, map[string]interface{}{
+		"old_status": string(oldStatus),
+		"new_status": string(status),
+	})
+
 	return &OrderResult{
 		Success:   true,
 		Message:   "Order status updated successfully",
@@ -379,607 +812,3115 @@ func (s *OrderService) UpdateOrderStatus(id uuid.UUID, status order.OrderStatus)
 	}, nil
 }
 
-// handleInventoryForStatusChange handles inventory changes based on order status changes
-func (s *OrderService) handleInventoryForStatusChange(tx *gorm.DB, o *order.Order, oldStatus, newStatus order.OrderStatus) error {
-	// Get order items
-	items, err := s.OrderRepo.GetOrderItemsByOrderID(o.ID)
+// openOrderStatuses are the statuses of orders whose items are committed to customers
+// but whose stock has not left the shelf yet
+var openOrderStatuses = []order.OrderStatus{order.OrderShipmentRequested}
+
+// InventoryAvailability describes how much of an inventory can still be promised to new orders
+type InventoryAvailability struct {
+	InventoryID        uuid.UUID
+	ProductID          uuid.UUID
+	Quantity           int
+	Committed          int
+	Held               int
+	AvailableToPromise int
+}
+
+// GetInventoryAvailability returns the raw quantity of an inventory, the quantities committed to
+// open orders and held by active inventory holds, and the available-to-promise quantity left after
+// subtracting both
+func (s *OrderService) GetInventoryAvailability(inventoryID uuid.UUID) (*InventoryAvailability, error) {
+	inventory, err := s.ProductService.GetInventoryByID(inventoryID)
 	if err != nil {
-		return err
+		return nil, notFoundError("inventory", err)
 	}
 
-	// Handle inventory changes based on status transition
-	switch {
-	// When transitioning from shipment_requested to packed, picked, delivering, or delivered status, reduce inventory
-	case oldStatus == order.OrderShipmentRequested && (newStatus == order.OrderPacked ||
-		newStatus == order.OrderPicked ||
-		newStatus == order.OrderDelivering ||
-		newStatus == order.OrderDelivered):
-		for _, item := range items {
-			if err := s.ProductService.ReserveInventory(item.InventoryID, item.Quantity); err != nil {
-				return err
-			}
-		}
+	committed, err := s.OrderRepo.GetCommittedInventoryQuantity(inventoryID, openOrderStatuses)
+	if err != nil {
+		return nil, err
+	}
 
-	// When transitioning to returned status, increase inventory
-	case newStatus == order.OrderReturned:
-		for _, item := range items {
-			if err := s.ProductService.ReleaseInventory(item.InventoryID, item.Quantity); err != nil {
-				return err
-			}
-		}
+	held, err := s.OrderRepo.GetHeldInventoryQuantity(inventoryID, time.Now())
+	if err != nil {
+		return nil, err
+	}
 
-	// When canceling an order that was in packed, picked, delivering, or delivered status, increase inventory
-	case newStatus == order.OrderCanceled && (oldStatus == order.OrderPacked ||
-		oldStatus == order.OrderPicked ||
-		oldStatus == order.OrderDelivering ||
-		oldStatus == order.OrderDelivered):
-		for _, item := range items {
-			if err := s.ProductService.ReleaseInventory(item.InventoryID, item.Quantity); err != nil {
-				return err
-			}
-		}
+	availableToPromise := inventory.Quantity - committed - held
+	if availableToPromise < 0 {
+		availableToPromise = 0
 	}
 
-	return nil
+	return &InventoryAvailability{
+		InventoryID:        inventory.ID,
+		ProductID:          inventory.ProductID,
+		Quantity:           inventory.Quantity,
+		Committed:          committed,
+		Held:               held,
+		AvailableToPromise: availableToPromise,
+	}, nil
 }
 
-// isValidStatusTransition checks if a status transition is valid
-func isValidStatusTransition(oldStatus, newStatus order.OrderStatus) bool {
-	// Allow transition to canceled from most statuses except a few
-	if newStatus == order.OrderCanceled {
-		// Cannot cancel if already returned, in return processing, delivered, or delivering
-		return oldStatus != order.OrderReturned &&
-			oldStatus != order.OrderReturnProcessing &&
-			oldStatus != order.OrderDelivered &&
-			oldStatus != order.OrderDelivering
+// MaxInventoryHoldDuration is the longest time stock may be held without an order
+const MaxInventoryHoldDuration = 30 * 24 * time.Hour
+
+// HoldInventory holds quantity units of an inventory for the given duration without creating an
+// order. The hold is taken from the available-to-promise quantity, so it fails when less than
+// quantity units can still be promised; the raw inventory quantity is left unchanged.
+func (s *OrderService) HoldInventory(inventoryID uuid.UUID, quantity int, duration time.Duration, customerID *uuid.UUID, notes string, createdBy *uuid.UUID) (*order.InventoryHold, error) {
+	if quantity < 1 {
+		return nil, validationError("quantity must be at least 1")
+	}
+	if duration <= 0 || duration > MaxInventoryHoldDuration {
+		return nil, validationError(fmt.Sprintf("hold duration must be greater than 0 and at most %s", MaxInventoryHoldDuration))
 	}
 
-	// Define valid transitions for normal flow
-	validTransitions := map[order.OrderStatus][]order.OrderStatus{
-		order.OrderShipmentRequested: {
-			order.OrderPacked,
-			order.OrderPicked,
-			order.OrderDelivering,
-			order.OrderDelivered, // Allow direct transition to delivered
-			order.OrderCanceled,
-		},
-		order.OrderPacked: {
-			order.OrderPicked,
-			order.OrderDelivering,
-			order.OrderDelivered, // Allow direct transition to delivered
-			order.OrderCanceled,
-		},
-		order.OrderPicked: {
-			order.OrderDelivering,
-			order.OrderDelivered, // Allow direct transition to delivered
-			order.OrderCanceled,
-		},
-		order.OrderDelivering: {
-			order.OrderDelivered,
-			// Note: Cannot be canceled once in delivering state
-		},
-		order.OrderDelivered: {
-			order.OrderReturnProcessing,
-		},
-		order.OrderReturnProcessing: {
-			order.OrderReturned,
-		},
-		order.OrderReturned: {
-			// No further transitions allowed
-		},
-		order.OrderCanceled: {
-			// No further transitions allowed
-		},
+	availability, err := s.GetInventoryAvailability(inventoryID)
+	if err != nil {
+		return nil, err
+	}
+	if availability.AvailableToPromise < quantity {
+		return nil, conflictError(fmt.Sprintf("only %d units of the inventory are available to hold", availability.AvailableToPromise))
 	}
 
-	// Check if transition is valid
-	for _, validStatus := range validTransitions[oldStatus] {
-		if validStatus == newStatus {
-			return true
-		}
+	hold := &order.InventoryHold{
+		InventoryID: inventoryID,
+		Quantity:    quantity,
+		Status:      order.HoldActive,
+		ExpiresAt:   time.Now().Add(duration),
+		CustomerID:  customerID,
+		Notes:       notes,
+		CreatedBy:   createdBy,
+	}
+	if err := s.OrderRepo.CreateInventoryHold(hold); err != nil {
+		return nil, err
 	}
 
-	return false
+	return hold, nil
 }
 
-// DeleteOrder deletes an order
-func (s *OrderService) DeleteOrder(id uuid.UUID) (*OrderResult, error) {
-	// Get the order
-	o, err := s.OrderRepo.GetOrderByID(id)
+// ReleaseInventoryHold releases an active inventory hold so its stock can be promised again
+func (s *OrderService) ReleaseInventoryHold(id uuid.UUID) (*order.InventoryHold, error) {
+	hold, err := s.OrderRepo.GetInventoryHoldByID(id)
 	if err != nil {
-		return &OrderResult{
-			Success: false,
-			Message: "Order deletion failed",
-			Error:   "Order not found",
-		}, err
+		return nil, notFoundError("inventory hold", err)
 	}
 
-	// Only allow deletion of shipment_requested or canceled orders
-	if o.OrderStatus != order.OrderShipmentRequested && o.OrderStatus != order.OrderCanceled {
-		return &OrderResult{
-			Success: false,
-			Message: "Order deletion failed",
-			Error:   "Only shipment_requested or canceled orders can be deleted",
-		}, fmt.Errorf("only shipment_requested or canceled orders can be deleted")
+	released, err := s.OrderRepo.ReleaseInventoryHold(id, order.HoldReleased, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !released {
+		return nil, conflictError(fmt.Sprintf("inventory hold is already %s", hold.Status))
 	}
 
-	// Start transaction
-	tx := s.DB.Begin()
-	if tx.Error != nil {
-		return &OrderResult{
-			Success: false,
-			Message: "Order deletion failed",
-			Error:   "Database transaction error",
-		}, tx.Error
+	return s.OrderRepo.GetInventoryHoldByID(id)
+}
+
+// ExpireInventoryHolds marks the active inventory holds past their expiry as expired and returns
+// how many were expired. Expired holds stop counting towards the available-to-promise quantity
+// even before they are swept.
+func (s *OrderService) ExpireInventoryHolds() (int64, error) {
+	return s.OrderRepo.ExpireInventoryHolds(time.Now())
+}
+
+// RunHoldExpirySweeper periodically expires inventory holds past their expiry until the context
+// is canceled. A non-positive interval disables the sweeper.
+func (s *OrderService) RunHoldExpirySweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		log.Println("Inventory hold expiry sweeper is disabled")
+		return
+	}
+
+	log.Printf("Inventory hold expiry sweeper started (interval: %s)", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Inventory hold expiry sweeper stopped")
+			return
+		case <-ticker.C:
+			expired, err := s.ExpireInventoryHolds()
+			if err != nil {
+				log.Printf("Error expiring inventory holds: %v", err)
+				continue
+			}
+			if expired > 0 {
+				log.Printf("Expired %d inventory holds", expired)
+			}
+		}
 	}
+}
 
-	// Delete order items
-	if err := tx.Where("order_id = ?", id).Delete(&order.OrderItem{}).Error; err != nil {
-		tx.Rollback()
-		return &OrderResult{
-			Success: false,
-			Message: "Order deletion failed",
-			Error:   "Error deleting order items",
-		}, err
+// checkInventoryAvailability checks if the quantity can be taken from the inventory, against the
+// available-to-promise quantity when CheckAvailableToPromise is set and the raw quantity otherwise
+func (s *OrderService) checkInventoryAvailability(inventoryID uuid.UUID, quantity int) (bool, error) {
+	if !s.Settings.CheckAvailableToPromise {
+		return s.ProductService.CheckInventoryAvailability(inventoryID, quantity)
 	}
 
-	// Delete shipment if exists
-	if err := tx.Where("order_id = ?", id).Delete(&order.Shipment{}).Error; err != nil {
-		tx.Rollback()
-		return &OrderResult{
-			Success: false,
-			Message: "Order deletion failed",
-			Error:   "Error deleting shipment",
-		}, err
+	if quantity < 1 {
+		return false, validationError("quantity must be at least 1")
 	}
 
-	// Delete order
-	if err := tx.Delete(o).Error; err != nil {
-		tx.Rollback()
-		return &OrderResult{
-			Success: false,
-			Message: "Order deletion failed",
-			Error:   "Error deleting order",
-		}, err
+	availability, err := s.GetInventoryAvailability(inventoryID)
+	if err != nil {
+		return false, err
 	}
+	return availability.AvailableToPromise >= quantity, nil
+}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		return &OrderResult{
-			Success: false,
-			Message: "Order deletion failed",
-			Error:   "Error committing transaction",
-		}, err
+// handleInventoryForStatusChange handles inventory changes based on order status changes.
+// Reservation and release are idempotent per order, so re-applying a status never
+// reduces or restores the stock twice. Canceled and returned orders also stop counting
+// towards the sold count of their products. The stock changes are recorded in undo, to be
+// reverted when the transaction does not commit.
+func (s *OrderService) handleInventoryForStatusChange(tx *gorm.DB, o *order.Order, oldStatus, newStatus order.OrderStatus, undo *productUndo) error {
+	switch {
+	// When the order is packed, picked, delivering, or delivered, reduce inventory
+	case holdsInventory(newStatus):
+		return s.reserveOrderInventory(tx, o, undo)
+
+	// When the order is returned or canceled, increase inventory
+	case newStatus == order.OrderReturned || newStatus == order.OrderCanceled:
+		if err := s.releaseOrderInventory(tx, o, undo); err != nil {
+			return err
+		}
+		return s.uncountOrderSales(tx, o, undo)
 	}
 
-	return &OrderResult{
-		Success:   true,
-		Message:   "Order deleted successfully",
-		OrderID:   id,
-		Status:    o.OrderStatus,
-		Total:     o.TotalAmount,
-		CreatedBy: o.CreatedBy,
-	}, nil
+	return nil
 }
 
-// CreateShipment creates a shipment for an order
-func (s *OrderService) CreateShipment(orderID uuid.UUID, trackingNumber, carrier string) error {
-	// Get the order
-	o, err := s.OrderRepo.GetOrderByID(orderID)
-	if err != nil {
-		return err
+// holdsInventory reports whether the stock of an order in the status has left the warehouse shelf
+func holdsInventory(status order.OrderStatus) bool {
+	switch status {
+	case order.OrderPacked, order.OrderPicked, order.OrderDelivering, order.OrderDelivered:
+		return true
+	default:
+		return false
 	}
+}
 
-	// Check if order status allows shipment
-	if o.OrderStatus != order.OrderShipmentRequested && o.OrderStatus != order.OrderPacked {
-		return fmt.Errorf("order status does not allow shipment creation")
+// lockOrderForInventory reloads the order inside the transaction with a row lock so that
+// concurrent status updates observe consistent reservation and sales flags
+func lockOrderForInventory(tx *gorm.DB, o *order.Order) error {
+	var locked order.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "inventory_reserved", "sales_counted").
+		First(&locked, "id = ?", o.ID).Error; err != nil {
+		return err
 	}
+	o.InventoryReserved = locked.InventoryReserved
+	o.SalesCounted = locked.SalesCounted
+	return nil
+}
 
-	// Check if shipment already exists
-	existingShipment, err := s.OrderRepo.GetShipmentByOrderID(orderID)
-	if err == nil && existingShipment != nil && existingShipment.ID != uuid.Nil {
-		return fmt.Errorf("shipment already exists for this order")
-	}
+// productUndo collects the steps reverting changes made in the product database while an order
+// transaction is open. The product database cannot join the transaction, so the steps are run
+// when the transaction is rolled back or fails to commit.
+type productUndo struct {
+	steps []func()
+}
 
-	// Create shipment
-	shipment := &order.Shipment{
-		OrderID:        orderID,
-		TrackingNumber: trackingNumber,
-		Carrier:        carrier,
-	}
+// add records a step reverting a change that was applied
+func (u *productUndo) add(step func()) {
+	u.steps = append(u.steps, step)
+}
 
-	// Start transaction
-	tx := s.DB.Begin()
-	if tx.Error != nil {
-		return tx.Error
+// run reverts the recorded changes, most recent first
+func (u *productUndo) run() {
+	for i := len(u.steps) - 1; i >= 0; i-- {
+		u.steps[i]()
 	}
+	u.steps = nil
+}
 
-	// Save shipment
-	if err := tx.Create(shipment).Error; err != nil {
-		tx.Rollback()
+// reserveOrderInventory reduces the stock of the order items unless it is already reserved. Each
+// reservation is recorded in undo, so that running it after a failure releases the items
+// reserved before the failing one.
+func (s *OrderService) reserveOrderInventory(tx *gorm.DB, o *order.Order, undo *productUndo) error {
+	if err := lockOrderForInventory(tx, o); err != nil {
 		return err
 	}
+	if o.InventoryReserved {
+		return nil
+	}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
+	items, err := s.OrderRepo.GetOrderItemsByOrderID(o.ID)
+	if err != nil {
 		return err
 	}
-
-	// Send notification
-	if s.NotificationService != nil {
-		metadata := map[string]interface{}{
-			"order_id":        o.ID.String(),
-			"created_by":      o.CreatedBy.String(),
-			"tracking_number": trackingNumber,
-			"carrier":         carrier,
+	for _, item := range items {
+		if err := s.ProductService.ReserveInventory(item.InventoryID, item.Quantity, o.ID); err != nil {
+			return err
 		}
-
-		s.NotificationService.CreateOrderNotification(o.ID, *o.CreatedBy, "shipment_created", metadata)
+		undo.add(func() {
+			s.releaseOrderItems(o.ID, []OrderItemInfo{{InventoryID: item.InventoryID, Quantity: item.Quantity}})
+		})
 	}
 
+	if err := tx.Model(o).Update("inventory_reserved", true).Error; err != nil {
+		return err
+	}
+	o.InventoryReserved = true
 	return nil
 }
 
-// UpdateShipment updates the shipment details for an order
-func (s *OrderService) UpdateShipment(orderID uuid.UUID, trackingNumber, carrier string) error {
-	// Get the shipment
-	shipment, err := s.OrderRepo.GetShipmentByOrderID(orderID)
-	if err != nil {
+// releaseOrderInventory restores the stock of the order items if it is currently reserved.
+// Units that were already returned with ReturnOrderItems are not restored again. Each release is
+// recorded in undo.
+func (s *OrderService) releaseOrderInventory(tx *gorm.DB, o *order.Order, undo *productUndo) error {
+	if err := lockOrderForInventory(tx, o); err != nil {
 		return err
 	}
-
-	// Update fields
-	if trackingNumber != "" {
-		shipment.TrackingNumber = trackingNumber
-	}
-	if carrier != "" {
-		shipment.Carrier = carrier
+	if !o.InventoryReserved {
+		return nil
 	}
 
-	// Save shipment
-	if err := s.OrderRepo.UpdateShipment(shipment); err != nil {
+	items, err := s.OrderRepo.GetOrderItemsByOrderID(o.ID)
+	if err != nil {
 		return err
 	}
-
-	// Send notification
-	if s.NotificationService != nil {
-		// Get the order
-		o, err := s.OrderRepo.GetOrderByID(orderID)
-		if err == nil && o.CreatedBy != nil {
-			metadata := map[string]interface{}{
-				"order_id":        o.ID.String(),
-				"created_by":      o.CreatedBy.String(),
-				"tracking_number": shipment.TrackingNumber,
-				"carrier":         shipment.Carrier,
-			}
-
-			s.NotificationService.CreateOrderNotification(o.ID, *o.CreatedBy, "shipment_updated", metadata)
+	for _, item := range items {
+		// Units returned on their own are already back in stock
+		if item.ReturnableQuantity() == 0 {
+			continue
+		}
+		quantity := item.ReturnableQuantity()
+		if err := s.ProductService.ReleaseInventory(item.InventoryID, quantity, o.ID); err != nil {
+			return err
 		}
+		undo.add(func() {
+			if err := s.ProductService.ReserveInventory(item.InventoryID, quantity, o.ID); err != nil {
+				log.Printf("Failed to reserve %d units of inventory %s again for order %s: %v", quantity, item.InventoryID, o.ID, err)
+			}
+		})
 	}
 
+	if err := tx.Model(o).Update("inventory_reserved", false).Error; err != nil {
+		return err
+	}
+	o.InventoryReserved = false
 	return nil
 }
 
-// DeleteShipment deletes a shipment
-func (s *OrderService) DeleteShipment(orderID uuid.UUID) error {
-	// Get the shipment
-	shipment, err := s.OrderRepo.GetShipmentByOrderID(orderID)
-	if err != nil {
+// uncountOrderSales removes the order item quantities that were not returned yet from the sold
+// count of their products if they are currently counted. Each change is recorded in undo.
+func (s *OrderService) uncountOrderSales(tx *gorm.DB, o *order.Order, undo *productUndo) error {
+	if err := lockOrderForInventory(tx, o); err != nil {
 		return err
 	}
+	if !o.SalesCounted {
+		return nil
+	}
 
-	// Delete shipment
-	return s.OrderRepo.DeleteShipment(shipment.ID)
-}
-
-// AddOrderItem adds an item to an order
-func (s *OrderService) AddOrderItem(orderID uuid.UUID, inventoryID uuid.UUID, quantity int) error {
-	// Get the order
-	o, err := s.OrderRepo.GetOrderByID(orderID)
+	items, err := s.OrderRepo.GetOrderItemsByOrderID(o.ID)
 	if err != nil {
 		return err
 	}
-
-	// Check if order status allows adding items
-	if o.OrderStatus != order.OrderShipmentRequested {
-		return fmt.Errorf("order status does not allow adding items")
+	for _, item := range items {
+		if item.ReturnableQuantity() == 0 {
+			continue
+		}
+		if err := s.adjustSoldCount(item.InventoryID, -item.ReturnableQuantity(), undo); err != nil {
+			return err
+		}
 	}
 
-	// Check inventory availability
-	available, err := s.ProductService.CheckInventoryAvailability(inventoryID, quantity)
-	if err != nil {
+	if err := tx.Model(o).Update("sales_counted", false).Error; err != nil {
 		return err
 	}
+	o.SalesCounted = false
+	return nil
+}
 
-	if !available {
-		return fmt.Errorf("not enough inventory")
+// adjustSoldCount adds delta units to the sold count of the product an inventory belongs to and
+// records the reverse change in undo
+func (s *OrderService) adjustSoldCount(inventoryID uuid.UUID, delta int, undo *productUndo) error {
+	if err := s.ProductService.AdjustSoldCount(inventoryID, delta); err != nil {
+		return err
 	}
+	undo.add(func() {
+		if err := s.ProductService.AdjustSoldCount(inventoryID, -delta); err != nil {
+			log.Printf("Failed to take back a sold count change of %d for inventory %s: %v", delta, inventoryID, err)
+		}
+	})
+	return nil
+}
 
-	// Get inventory for product ID
-	inventory, err := s.ProductService.GetInventoryByID(inventoryID)
+// ReserveOrderInventory reserves the stock of an order's items. Calling it again for an
+// order whose inventory is already reserved has no effect.
+func (s *OrderService) ReserveOrderInventory(orderID uuid.UUID) error {
+	o, err := s.OrderRepo.GetOrderByID(orderID)
 	if err != nil {
-		return err
+		return notFoundError("order", err)
 	}
 
-	// Get current price
-	price, err := s.ProductService.GetCurrentPrice(inventory.ProductID)
+	var undo productUndo
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		return s.reserveOrderInventory(tx, o, &undo)
+	})
 	if err != nil {
-		return err
+		undo.run()
 	}
+	return err
+}
 
-	// Start transaction
-	tx := s.DB.Begin()
-	if tx.Error != nil {
-		return tx.Error
+// ReleaseOrderInventory releases the reserved stock of an order's items. Calling it for an
+// order without reserved inventory has no effect.
+func (s *OrderService) ReleaseOrderInventory(orderID uuid.UUID) error {
+	o, err := s.OrderRepo.GetOrderByID(orderID)
+	if err != nil {
+		return notFoundError("order", err)
 	}
 
-	// Create order item
-	orderItem := &order.OrderItem{
-		OrderID:      orderID,
-		InventoryID:  inventoryID,
-		Quantity:     quantity,
-		PriceAtOrder: price.Price,
+	var undo productUndo
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		return s.releaseOrderInventory(tx, o, &undo)
+	})
+	if err != nil {
+		undo.run()
 	}
+	return err
+}
 
-	if err := tx.Create(orderItem).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
+// ItemReturn is a number of units of an order item returned to the warehouse
+type ItemReturn struct {
+	OrderItemID uuid.UUID
+	Quantity    int
+}
 
-	// Update order total
-	o.TotalAmount += price.Price * float64(quantity)
-	// Recalculate final total amount
-	o.FinalTotalAmount = o.TotalAmount - o.DiscountAmount
-	if o.FinalTotalAmount < 0 {
-		o.FinalTotalAmount = 0 // Ensure final amount is not negative
+// ReturnOrderItems returns some units of the items of a delivered order, or of an order whose
+// return is being processed, to inventory. Each item can be returned until all its units have
+// been returned; the returned units no longer count as sold and are not released again when the
+// order is marked as returned.
+func (s *OrderService) ReturnOrderItems(orderID uuid.UUID, returns []ItemReturn) (*OrderResult, error) {
+	if len(returns) == 0 {
+		return &OrderResult{
+			Success: false,
+			Message: "Order return failed",
+			Error:   "At least one item is required",
+		}, validationError("at least one item is required")
 	}
 
-	if err := tx.Save(o).Error; err != nil {
-		tx.Rollback()
-		return err
+	// Combine the returns of the same item so its returnable quantity is checked once
+	quantities := make(map[uuid.UUID]int, len(returns))
+	for _, r := range returns {
+		if r.Quantity < 1 {
+			return &OrderResult{
+				Success: false,
+				Message: "Order return failed",
+				Error:   "Returned quantity must be at least 1",
+			}, validationError("returned quantity must be at least 1")
+		}
+		quantities[r.OrderItemID] += r.Quantity
 	}
 
-	// Commit transaction
-	return tx.Commit().Error
-}
-
-// UpdateOrderItem updates an order item
-func (s *OrderService) UpdateOrderItem(id uuid.UUID, quantity int) error {
-	// Get the order item
-	item, err := s.OrderRepo.GetOrderItemByID(id)
+	o, err := s.OrderRepo.GetOrderByID(orderID)
 	if err != nil {
-		return err
+		return &OrderResult{
+			Success: false,
+			Message: "Order return failed",
+			Error:   "Order not found",
+		}, notFoundError("order", err)
 	}
 
-	// Get the order
-	o, err := s.OrderRepo.GetOrderByID(item.OrderID)
-	if err != nil {
-		return err
+	if o.OrderStatus != order.OrderDelivered && o.OrderStatus != order.OrderReturnProcessing {
+		err := validationError(fmt.Sprintf("items can only be returned from %s or %s orders, not %s",
+			order.OrderDelivered, order.OrderReturnProcessing, o.OrderStatus))
+		return &OrderResult{
+			Success: false,
+			Message: "Order return failed",
+			Error:   err.Error(),
+		}, err
 	}
 
-	// Check if order status allows updating items
-	if o.OrderStatus != order.OrderShipmentRequested {
-		return fmt.Errorf("order status does not allow updating items")
-	}
+	// Stock and sold count changes in the product database are reverted unless the return commits
+	var undo productUndo
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the order so concurrent returns see each other's returned quantities
+		if err := lockOrderForInventory(tx, o); err != nil {
+			return err
+		}
+		if !o.InventoryReserved {
+			return validationError("the inventory of the order has already been released")
+		}
 
-	// If quantity is increasing, check inventory availability
-	if quantity > item.Quantity {
-		available, err := s.ProductService.CheckInventoryAvailability(item.InventoryID, quantity-item.Quantity)
+		items, err := s.OrderRepo.GetOrderItemsByOrderID(o.ID)
 		if err != nil {
 			return err
 		}
+		itemsByID := make(map[uuid.UUID]order.OrderItem, len(items))
+		for _, item := range items {
+			itemsByID[item.ID] = item
+		}
+		for itemID, quantity := range quantities {
+			item, ok := itemsByID[itemID]
+			if !ok {
+				return notFoundError("order item", gorm.ErrRecordNotFound)
+			}
+			if quantity > item.ReturnableQuantity() {
+				return validationError(fmt.Sprintf("cannot return %d units of item %s; only %d can be returned",
+					quantity, itemID, item.ReturnableQuantity()))
+			}
+		}
 
-		if !available {
-			return fmt.Errorf("not enough inventory")
+		for itemID, quantity := range quantities {
+			item := itemsByID[itemID]
+			if err := tx.Model(&order.OrderItem{}).Where("id = ?", itemID).
+				UpdateColumn("returned_quantity", gorm.Expr("returned_quantity + ?", quantity)).Error; err != nil {
+				return err
+			}
+			if err := s.ProductService.ReturnInventory(item.InventoryID, quantity, o.ID); err != nil {
+				return err
+			}
+			undo.add(func() {
+				if err := s.ProductService.ReserveInventory(item.InventoryID, quantity, o.ID); err != nil {
+					log.Printf("Failed to take back %d returned units of inventory %s for order %s: %v", quantity, item.InventoryID, o.ID, err)
+				}
+			})
+			if o.SalesCounted {
+				if err := s.adjustSoldCount(item.InventoryID, -quantity, &undo); err != nil {
+					return err
+				}
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		undo.run()
+		return &OrderResult{
+			Success: false,
+			Message: "Order return failed",
+			Error:   err.Error(),
+		}, err
 	}
 
-	// Start transaction
-	tx := s.DB.Begin()
-	if tx.Error != nil {
-		return tx.Error
-	}
+	return &OrderResult{
+		Success:     true,
+		Message:     "Order items returned successfully",
+		OrderID:     o.ID,
+		OrderNumber: o.OrderNumber,
+		Status:      o.OrderStatus,
+		Total:       o.TotalAmount,
+		FinalTotal:  o.FinalTotalAmount,
+		CreatedBy:   o.CreatedBy,
+	}, nil
+}
 
-	// Calculate price difference
-	priceDifference := item.PriceAtOrder * float64(quantity-item.Quantity)
+// reserveOrderItems reserves the stock of the items of a new order. The stock lives in the product
+// database, so it cannot join the order transaction: when an item cannot be reserved, the items
+// reserved before it are released again.
+func (s *OrderService) reserveOrderItems(orderID uuid.UUID, items []OrderItemInfo) error {
+	for i, item := range items {
+		if err := s.ProductService.ReserveInventory(item.InventoryID, item.Quantity, orderID); err != nil {
+			s.releaseOrderItems(orderID, items[:i])
+			return err
+		}
+	}
+	return nil
+}
 
-	// Update order item
-	item.Quantity = quantity
-	if err := tx.Save(item).Error; err != nil {
-		tx.Rollback()
-		return err
+// releaseCreatedOrderItems releases the stock reserved for an order whose creation failed
+func (s *OrderService) releaseCreatedOrderItems(o *order.Order, items []OrderItemInfo) {
+	if o.InventoryReserved {
+		s.releaseOrderItems(o.ID, items)
 	}
+}
 
-	// Update order total
-	o.TotalAmount += priceDifference
-	// Recalculate final total amount
-	o.FinalTotalAmount = o.TotalAmount - o.DiscountAmount
-	if o.FinalTotalAmount < 0 {
-		o.FinalTotalAmount = 0 // Ensure final amount is not negative
+// releaseOrderItems releases the stock reserved for the items. Failures are logged so that the
+// operation that failed is reported rather than the release.
+func (s *OrderService) releaseOrderItems(orderID uuid.UUID, items []OrderItemInfo) {
+	for _, item := range items {
+		if err := s.ProductService.ReleaseInventory(item.InventoryID, item.Quantity, orderID); err != nil {
+			log.Printf("Failed to release %d units of inventory %s for order %s: %v", item.Quantity, item.InventoryID, orderID, err)
+		}
 	}
+}
 
-	if err := tx.Save(o).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
+// OrderDeleteMode defines what deleting an order does
+type OrderDeleteMode string
 
-	// Commit transaction
-	return tx.Commit().Error
-}
+const (
+	// OrderDeleteCancel cancels the order, releasing its inventory and keeping the record
+	OrderDeleteCancel OrderDeleteMode = "cancel"
+	// OrderDeletePurge soft-deletes the order with its items and shipment, hiding them from all queries
+	OrderDeletePurge OrderDeleteMode = "purge"
+)
 
-// DeleteOrderItem deletes an order item
-func (s *OrderService) DeleteOrderItem(id uuid.UUID) error {
-	// Get the order item
-	item, err := s.OrderRepo.GetOrderItemByID(id)
-	if err != nil {
-		return err
+// ResolveOrderDeleteMode returns the delete mode to apply to an order in the given status.
+// When no mode is requested, orders that are still pending or already canceled are purged
+// and all other orders are canceled, so that no order in progress is lost by accident.
+func ResolveOrderDeleteMode(status order.OrderStatus, mode OrderDeleteMode) (OrderDeleteMode, error) {
+	switch mode {
+	case OrderDeleteCancel, OrderDeletePurge:
+		return mode, nil
+	case "":
+		if status == order.OrderShipmentRequested || status == order.OrderCanceled {
+			return OrderDeletePurge, nil
+		}
+		return OrderDeleteCancel, nil
+	default:
+		return "", validationError(fmt.Sprintf("invalid delete mode %q, must be cancel or purge", mode))
 	}
+}
 
+// RemoveOrder cancels or purges an order according to the delete mode. A cancellation is recorded
+// in the status history of the order with the given change.
+func (s *OrderService) RemoveOrder(id uuid.UUID, mode OrderDeleteMode, change StatusChange) (*OrderResult, error) {
 	// Get the order
-	o, err := s.OrderRepo.GetOrderByID(item.OrderID)
+	o, err := s.OrderRepo.GetOrderByID(id)
 	if err != nil {
-		return err
+		return &OrderResult{
+			Success: false,
+			Message: "Order deletion failed",
+			Error:   "Order not found",
+		}, notFoundError("order", err)
 	}
 
-	// Check if order status allows deleting items
-	if o.OrderStatus != order.OrderShipmentRequested {
-		return fmt.Errorf("order status does not allow deleting items")
+	mode, err = ResolveOrderDeleteMode(o.OrderStatus, mode)
+	if err != nil {
+		return &OrderResult{
+			Success: false,
+			Message: "Order deletion failed",
+			Error:   err.Error(),
+		}, err
 	}
 
-	// Start transaction
-	tx := s.DB.Begin()
-	if tx.Error != nil {
-		return tx.Error
+	if mode == OrderDeleteCancel {
+		result, err := s.ChangeOrderStatus(id, order.OrderCanceled, change)
+		if err == nil {
+			result.Message = "Order canceled successfully"
+		}
+		return result, err
 	}
+	return s.DeleteOrder(id)
+}
 
-	// Update order total
-	o.TotalAmount -= item.PriceAtOrder * float64(item.Quantity)
-	// Recalculate final total amount
-	o.FinalTotalAmount = o.TotalAmount - o.DiscountAmount
-	if o.FinalTotalAmount < 0 {
-		o.FinalTotalAmount = 0 // Ensure final amount is not negative
+// isValidStatusTransition checks if a status transition is valid
+func isValidStatusTransition(oldStatus, newStatus order.OrderStatus) bool {
+	// Allow transition to canceled from most statuses except a few
+	if newStatus == order.OrderCanceled {
+		// Cannot cancel if already returned, in return processing, delivered, or delivering
+		return oldStatus != order.OrderReturned &&
+			oldStatus != order.OrderReturnProcessing &&
+			oldStatus != order.OrderDelivered &&
+			oldStatus != order.OrderDelivering
 	}
 
-	if err := tx.Save(o).Error; err != nil {
-		tx.Rollback()
-		return err
+	// Define valid transitions for normal flow
+	validTransitions := map[order.OrderStatus][]order.OrderStatus{
+		order.OrderShipmentRequested: {
+			order.OrderPacked,
+			order.OrderPicked,
+			order.OrderDelivering,
+			order.OrderDelivered, // Allow direct transition to delivered
+			order.OrderCanceled,
+		},
+		order.OrderPacked: {
+			order.OrderPicked,
+			order.OrderDelivering,
+			order.OrderDelivered, // Allow direct transition to delivered
+			order.OrderCanceled,
+		},
+		order.OrderPicked: {
+			order.OrderDelivering,
+			order.OrderDelivered, // Allow direct transition to delivered
+			order.OrderCanceled,
+		},
+		order.OrderDelivering: {
+			order.OrderDelivered,
+			// Note: Cannot be canceled once in delivering state
+		},
+		order.OrderDelivered: {
+			order.OrderReturnProcessing,
+		},
+		order.OrderReturnProcessing: {
+			order.OrderReturned,
+		},
+		order.OrderReturned: {
+			// No further transitions allowed
+		},
+		order.OrderCanceled: {
+			// No further transitions allowed
+		},
 	}
 
-	// Delete order item
-	if err := tx.Delete(item).Error; err != nil {
-		tx.Rollback()
-		return err
+	// Check if transition is valid
+	for _, validStatus := range validTransitions[oldStatus] {
+		if validStatus == newStatus {
+			return true
+		}
 	}
 
-	// Commit transaction
-	return tx.Commit().Error
+	return false
 }
 
-// UpdateOrderDetails updates the details of an order
-func (s *OrderService) UpdateOrderDetails(
-	id uuid.UUID,
-	notes string,
-	paymentMethod order.PaymentMethod,
-	discountAmount float64,
-	discountReason string,
-	shippingAddress string,
-	shippingWard string,
-	shippingDistrict string,
-	shippingCity string,
-	shippingCountry string,
-	customerName string,
-	customerEmail string,
-	customerPhone string,
-) (*OrderResult, error) {
+// DeleteOrder soft-deletes an order with its items and shipment
+func (s *OrderService) DeleteOrder(id uuid.UUID) (*OrderResult, error) {
 	// Get the order
 	o, err := s.OrderRepo.GetOrderByID(id)
 	if err != nil {
 		return &OrderResult{
 			Success: false,
-			Message: "Order details update failed",
+			Message: "Order deletion failed",
 			Error:   "Order not found",
-		}, err
+		}, notFoundError("order", err)
 	}
 
-	// Update fields if provided
-	if paymentMethod != "" {
-		o.PaymentMethod = paymentMethod
+	// Only allow deletion of shipment_requested or canceled orders
+	if o.OrderStatus != order.OrderShipmentRequested && o.OrderStatus != order.OrderCanceled {
+		return &OrderResult{
+			Success: false,
+			Message: "Order deletion failed",
+			Error:   "Only shipment_requested or canceled orders can be deleted",
+		}, validationError("only shipment_requested or canceled orders can be deleted")
 	}
 
-	// Update notes if provided
-	if notes != "" {
-		o.Notes = notes
+	// Start transaction
+	tx := s.DB.Begin()
+	if tx.Error != nil {
+		return &OrderResult{
+			Success: false,
+			Message: "Order deletion failed",
+			Error:   "Database transaction error",
+		}, tx.Error
 	}
 
-	// Update discount if provided
-	if discountAmount >= 0 {
-		o.DiscountAmount = discountAmount
-		o.DiscountReason = discountReason
-		// Recalculate final total
-		o.FinalTotalAmount = o.TotalAmount - o.DiscountAmount
-		if o.FinalTotalAmount < 0 {
-			o.FinalTotalAmount = 0 // Ensure final amount is not negative
+	// Stock changes in the product database are reverted unless the deletion commits
+	var undo productUndo
+	committed := false
+	defer func() {
+		if !committed {
+			undo.run()
 		}
-	}
+	}()
 
-	// Update shipping address if provided
-	if shippingAddress != "" {
-		o.ShippingAddress = shippingAddress
-	}
-	if shippingWard != "" {
-		o.ShippingWard = shippingWard
-	}
-	if shippingDistrict != "" {
-		o.ShippingDistrict = shippingDistrict
-	}
-	if shippingCity != "" {
-		o.ShippingCity = shippingCity
+	// Deleted orders give their reserved stock back
+	if err := s.releaseOrderInventory(tx, o, &undo); err != nil {
+		tx.Rollback()
+		return &OrderResult{
+			Success: false,
+			Message: "Order deletion failed",
+			Error:   "Error updating inventory",
+		}, err
 	}
-	if shippingCountry != "" {
-		o.ShippingCountry = shippingCountry
+
+	// Deleted orders no longer count as sales
+	if err := s.uncountOrderSales(tx, o, &undo); err != nil {
+		tx.Rollback()
+		return &OrderResult{
+			Success: false,
+			Message: "Order deletion failed",
+			Error:   "Error updating product sold count",
+		}, err
 	}
 
-	// Update customer information if provided
-	if customerName != "" {
-		o.CustomerName = customerName
+	// Delete order items
+	if err := tx.Where("order_id = ?", id).Delete(&order.OrderItem{}).Error; err != nil {
+		tx.Rollback()
+		return &OrderResult{
+			Success: false,
+			Message: "Order deletion failed",
+			Error:   "Error deleting order items",
+		}, err
 	}
-	if customerEmail != "" {
-		o.CustomerEmail = customerEmail
+
+	// Delete shipments if exist, together with their item assignments
+	if err := tx.Where("shipment_id IN (?)", tx.Model(&order.Shipment{}).Select("id").Where("order_id = ?", id)).
+		Delete(&order.ShipmentItem{}).Error; err != nil {
+		tx.Rollback()
+		return &OrderResult{
+			Success: false,
+			Message: "Order deletion failed",
+			Error:   "Error deleting shipment items",
+		}, err
 	}
-	if customerPhone != "" {
-		o.CustomerPhone = customerPhone
+	if err := tx.Where("order_id = ?", id).Delete(&order.Shipment{}).Error; err != nil {
+		tx.Rollback()
+		return &OrderResult{
+			Success: false,
+			Message: "Order deletion failed",
+			Error:   "Error deleting shipment",
+		}, err
 	}
 
-	// Save the order
-	if err := s.OrderRepo.UpdateOrder(o); err != nil {
+	// Delete order
+	if err := tx.Delete(o).Error; err != nil {
+		tx.Rollback()
 		return &OrderResult{
 			Success: false,
-			Message: "Order details update failed",
-			Error:   "Error updating order details",
+			Message: "Order deletion failed",
+			Error:   "Error deleting order",
 		}, err
 	}
 
-	// Send notification
-	if s.NotificationService != nil {
-		metadata := map[string]interface{}{
-			"order_id":        o.ID.String(),
-			"payment_method":  string(o.PaymentMethod),
-			"discount_amount": o.DiscountAmount,
-			"discount_reason": o.DiscountReason,
-			"final_amount":    o.FinalTotalAmount,
-		}
-
-		if o.CreatedBy != nil {
-			s.NotificationService.CreateOrderNotification(o.ID, *o.CreatedBy, "details_updated", metadata)
-		}
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		return &OrderResult{
+			Success: false,
+			Message: "Order deletion failed",
+			Error:   "Error committing transaction",
+		}, err
 	}
+	committed = true
 
 	return &OrderResult{
-		Success:        true,
-		Message:        "Order details updated successfully",
-		OrderID:        o.ID,
-		Status:         o.OrderStatus,
-		Total:          o.TotalAmount,
-		DiscountAmount: o.DiscountAmount,
-		DiscountReason: o.DiscountReason,
-		FinalTotal:     o.FinalTotalAmount,
-		CreatedBy:      o.CreatedBy,
+		Success:   true,
+		Message:   "Order deleted successfully",
+		OrderID:   id,
+		Status:    o.OrderStatus,
+		Total:     o.TotalAmount,
+		CreatedBy: o.CreatedBy,
 	}, nil
 }
 
-// GetOrderByTrackingNumber retrieves an order by shipment tracking number
-func (s *OrderService) GetOrderByTrackingNumber(trackingNumber string) (*order.Order, error) {
-	if trackingNumber == "" {
-		return nil, fmt.Errorf("tracking number is required")
+// CreateShipment creates a shipment for an order carrying the given order items. Orders may be split
+// into several shipments, but an item can only be in one of them. Without item IDs the shipment
+// carries every item that is not assigned to another shipment, and only one such shipment may exist.
+func (s *OrderService) CreateShipment(orderID uuid.UUID, trackingNumber, carrier string, itemIDs []uuid.UUID) (*order.Shipment, error) {
+	// Get the order
+	o, err := s.OrderRepo.GetOrderByID(orderID)
+	if err != nil {
+		return nil, notFoundError("order", err)
 	}
-	return s.OrderRepo.GetOrderByTrackingNumber(trackingNumber)
-}
 
-// GetOrdersByPhoneNumber retrieves orders by customer phone number with pagination
-func (s *OrderService) GetOrdersByPhoneNumber(phoneNumber string, page, pageSize int, additionalFilters map[string]interface{}) ([]order.Order, int64, error) {
-	if phoneNumber == "" {
-		return nil, 0, fmt.Errorf("phone number is required")
+	// Check if order status allows shipment
+	if err := checkShipmentStatus(o.OrderStatus); err != nil {
+		return nil, err
 	}
 
-	return s.OrderRepo.GetOrdersByPhoneNumber(phoneNumber, page, pageSize, additionalFilters)
+	// Check that the items are not already shipped
+	if err := checkShipmentItems(o, itemIDs); err != nil {
+		return nil, err
+	}
+
+	// Create shipment
+	shipment := &order.Shipment{
+		OrderID:        orderID,
+		TrackingNumber: trackingNumber,
+		Carrier:        carrier,
+	}
+	for _, itemID := range itemIDs {
+		shipment.Items = append(shipment.Items, order.ShipmentItem{OrderItemID: itemID})
+	}
+
+	// Start transaction
+	tx := s.DB.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	// Save shipment and its items
+	if err := tx.Create(shipment).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	// Send notification
+	if s.NotificationService != nil && o.CreatedBy != nil {
+		metadata := map[string]interface{}{
+			"order_id":        o.ID.String(),
+			"shipment_id":     shipment.ID.String(),
+			"created_by":      o.CreatedBy.String(),
+			"tracking_number": trackingNumber,
+			"carrier":         carrier,
+			"item_count":      len(itemIDs),
+		}
+
+		s.notifyOrder(o.ID, o.OrderNumber, *o.CreatedBy, "shipment_created", metadata)
+	}
+
+	return shipment, nil
+}
+
+// checkShipmentItems checks that a new shipment of an order may carry the given items: they must be
+// distinct items of the order that are not assigned to another shipment. An empty list stands for
+// the unassigned items, which is rejected when another shipment already carries them.
+func checkShipmentItems(o *order.Order, itemIDs []uuid.UUID) error {
+	if len(itemIDs) == 0 {
+		for _, shipment := range o.Shipments {
+			if !shipment.HasItems() {
+				return conflictError("shipment already exists for the unassigned items of this order")
+			}
+		}
+		return nil
+	}
+
+	orderItems := make(map[uuid.UUID]bool, len(o.Items))
+	for _, item := range o.Items {
+		orderItems[item.ID] = true
+	}
+	assigned := make(map[uuid.UUID]bool)
+	for _, shipment := range o.Shipments {
+		for _, item := range shipment.Items {
+			assigned[item.OrderItemID] = true
+		}
+	}
+
+	seen := make(map[uuid.UUID]bool, len(itemIDs))
+	for _, itemID := range itemIDs {
+		if !orderItems[itemID] {
+			return validationError(fmt.Sprintf("item %s does not belong to this order", itemID))
+		}
+		if seen[itemID] {
+			return validationError(fmt.Sprintf("item %s is listed more than once", itemID))
+		}
+		if assigned[itemID] {
+			return conflictError(fmt.Sprintf("item %s is already assigned to a shipment", itemID))
+		}
+		seen[itemID] = true
+	}
+	return nil
+}
+
+// ShipmentDetails holds the shipment fields to update. Empty strings and nil
+// numeric fields leave the stored values unchanged. A zero ShipmentID selects
+// the primary shipment of the order.
+type ShipmentDetails struct {
+	ShipmentID     uuid.UUID
+	TrackingNumber string
+	Carrier        string
+	Weight         *float64
+	Length         *float64
+	Width          *float64
+	Height         *float64
+	ShippingCost   *float64
+	CODAmount      *float64
+}
+
+// UpdateShipment updates the details of a shipment of an order
+func (s *OrderService) UpdateShipment(orderID uuid.UUID, details ShipmentDetails) error {
+	// Get the order
+	o, err := s.OrderRepo.GetOrderByID(orderID)
+	if err != nil {
+		return notFoundError("order", err)
+	}
+
+	// Check if order status allows shipment changes
+	if err := checkShipmentStatus(o.OrderStatus); err != nil {
+		return err
+	}
+
+	// Get the shipment
+	var shipment *order.Shipment
+	if details.ShipmentID == uuid.Nil {
+		shipment, err = s.OrderRepo.GetShipmentByOrderID(orderID)
+	} else {
+		shipment, err = s.OrderRepo.GetShipmentByID(details.ShipmentID)
+		if err == nil && shipment.OrderID != orderID {
+			err = gorm.ErrRecordNotFound
+		}
+	}
+	if err != nil {
+		return notFoundError("shipment", err)
+	}
+
+	// Update fields
+	if details.TrackingNumber != "" {
+		shipment.TrackingNumber = details.TrackingNumber
+	}
+	if details.Carrier != "" {
+		shipment.Carrier = details.Carrier
+	}
+	if details.Weight != nil {
+		shipment.Weight = *details.Weight
+	}
+	if details.Length != nil {
+		shipment.Length = *details.Length
+	}
+	if details.Width != nil {
+		shipment.Width = *details.Width
+	}
+	if details.Height != nil {
+		shipment.Height = *details.Height
+	}
+	if details.ShippingCost != nil {
+		shipment.ShippingCost = *details.ShippingCost
+	}
+	if details.CODAmount != nil {
+		shipment.CODAmount = *details.CODAmount
+	}
+
+	// Save shipment
+	if err := s.OrderRepo.UpdateShipment(shipment); err != nil {
+		return err
+	}
+
+	// Send notification
+	if s.NotificationService != nil && o.CreatedBy != nil {
+		metadata := map[string]interface{}{
+			"order_id":          o.ID.String(),
+			"shipment_id":       shipment.ID.String(),
+			"created_by":        o.CreatedBy.String(),
+			"tracking_number":   shipment.TrackingNumber,
+			"carrier":           shipment.Carrier,
+			"chargeable_weight": s.ShipmentChargeableWeight(shipment),
+		}
+
+		s.notifyOrder(o.ID, o.OrderNumber, *o.CreatedBy, "shipment_updated", metadata)
+	}
+
+	return nil
+}
+
+// checkShipmentStatus returns a validation error when the shipment of an order in the given
+// status may not be created or updated
+func checkShipmentStatus(status order.OrderStatus) error {
+	if !status.AllowsShipmentChanges() {
+		return validationError(fmt.Sprintf("shipment cannot be changed for an order with status %s; only %s and %s orders allow it",
+			status, order.OrderShipmentRequested, order.OrderPacked))
+	}
+	return nil
+}
+
+// VolumetricWeight returns the volumetric weight in grams of a package with the given
+// dimensions in centimeters. The divisor is the volume in cubic centimeters that counts
+// as one kilogram; a non-positive divisor yields zero.
+func VolumetricWeight(length, width, height, divisor float64) float64 {
+	if divisor <= 0 || length <= 0 || width <= 0 || height <= 0 {
+		return 0
+	}
+	return length * width * height / divisor * 1000
+}
+
+// ChargeableWeight returns the weight in grams a carrier charges for: the greater of the
+// actual weight and the volumetric weight of the package dimensions.
+func ChargeableWeight(actualWeight, length, width, height, divisor float64) float64 {
+	return math.Max(actualWeight, VolumetricWeight(length, width, height, divisor))
+}
+
+// ShipmentChargeableWeight returns the chargeable weight in grams of a shipment using the
+// configured volumetric divisor. It is the weight to send when quoting or creating a carrier shipment.
+func (s *OrderService) ShipmentChargeableWeight(shipment *order.Shipment) float64 {
+	divisor := s.Settings.WithDefaults().VolumetricDivisor
+	return ChargeableWeight(shipment.Weight, shipment.Length, shipment.Width, shipment.Height, divisor)
+}
+
+// ShippingParty is the sender or the recipient of a shipment
+type ShippingParty struct {
+	Name     string
+	Phone    string
+	Address  string
+	Ward     string
+	District string
+	City     string
+	Country  string
+}
+
+// ValidateShippingAddress checks that a shipping party has the contact and address fields a
+// carrier requires. The error lists every missing field.
+func ValidateShippingAddress(party ShippingParty, role string) error {
+	var missing []string
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"name", party.Name},
+		{"phone", party.Phone},
+		{"address", party.Address},
+		{"ward", party.Ward},
+		{"district", party.District},
+		{"city", party.City},
+	} {
+		if strings.TrimSpace(field.value) == "" {
+			missing = append(missing, field.name)
+		}
+	}
+	if len(missing) > 0 {
+		return validationError(fmt.Sprintf("%s is missing %s", role, strings.Join(missing, ", ")))
+	}
+	return nil
+}
+
+// ShippingLabelItem is a line of the contents of a shipping label
+type ShippingLabelItem struct {
+	InventoryID uuid.UUID
+	SKU         string
+	Name        string
+	Size        string
+	Color       string
+	Quantity    int
+}
+
+// ShippingLabel holds the data needed to create a carrier shipment or print its label.
+// Weights are in grams and dimensions in centimeters.
+type ShippingLabel struct {
+	OrderID          uuid.UUID
+	OrderNumber      string
+	Sender           ShippingParty
+	Recipient        ShippingParty
+	Carrier          string
+	TrackingNumber   string
+	Weight           float64
+	ChargeableWeight float64
+	Length           float64
+	Width            float64
+	Height           float64
+	CODAmount        float64
+	Items            []ShippingLabelItem
+	TotalQuantity    int
+	ContentsSummary  string
+	Notes            string
+}
+
+// GetShippingLabel assembles the shipping label of an order from the configured sender, the
+// order's recipient and items, and its shipment. The COD amount is the one set on the shipment,
+// or the final total of a cash-on-delivery order when none is set.
+func (s *OrderService) GetShippingLabel(orderID uuid.UUID) (*ShippingLabel, error) {
+	o, err := s.OrderRepo.GetOrderByID(orderID)
+	if err != nil {
+		return nil, notFoundError("order", err)
+	}
+	if len(o.Items) == 0 {
+		return nil, validationError("order has no items to ship")
+	}
+
+	sender := s.Settings.ShippingSender
+	if err := ValidateShippingAddress(sender, "shipping sender"); err != nil {
+		return nil, err
+	}
+
+	recipient := ShippingParty{
+		Name:     o.CustomerName,
+		Phone:    phone.National(o.CustomerPhone),
+		Address:  o.ShippingAddress,
+		Ward:     o.ShippingWard,
+		District: o.ShippingDistrict,
+		City:     o.ShippingCity,
+		Country:  o.ShippingCountry,
+	}
+	if err := ValidateShippingAddress(recipient, "recipient"); err != nil {
+		return nil, err
+	}
+
+	label := &ShippingLabel{
+		OrderID:     o.ID,
+		OrderNumber: o.OrderNumber,
+		Sender:      sender,
+		Recipient:   recipient,
+		Notes:       o.Notes,
+	}
+
+	if shipment := o.PrimaryShipment(); shipment != nil {
+		label.Carrier = shipment.Carrier
+		label.TrackingNumber = shipment.TrackingNumber
+		label.Weight = shipment.Weight
+		label.ChargeableWeight = s.ShipmentChargeableWeight(shipment)
+		label.Length = shipment.Length
+		label.Width = shipment.Width
+		label.Height = shipment.Height
+		label.CODAmount = shipment.CODAmount
+	}
+	if label.CODAmount == 0 && o.PaymentMethod == order.PaymentCOD {
+		label.CODAmount = o.FinalTotalAmount
+	}
+
+	contents := make([]string, 0, len(o.Items))
+	for _, item := range o.Items {
+		line := ShippingLabelItem{
+			InventoryID: item.InventoryID,
+			Quantity:    item.Quantity,
+		}
+		if s.ProductService != nil {
+			if inventory, err := s.ProductService.GetInventoryIncludingDeleted(item.InventoryID); err == nil {
+				line.Size = inventory.Size
+				line.Color = inventory.Color
+				if p, err := s.ProductService.GetProductIncludingDeleted(inventory.ProductID); err == nil {
+					line.SKU = p.SKU
+					line.Name = p.Name
+				}
+			}
+		}
+		label.Items = append(label.Items, line)
+		label.TotalQuantity += item.Quantity
+		contents = append(contents, line.summary())
+	}
+	label.ContentsSummary = strings.Join(contents, ", ")
+
+	return label, nil
+}
+
+// summary describes the item as printed in the contents of a shipping label, e.g. "2 x Shirt (M/Red)"
+func (i ShippingLabelItem) summary() string {
+	name := i.Name
+	if name == "" {
+		name = i.SKU
+	}
+	if name == "" {
+		name = "Item"
+	}
+
+	var variants []string
+	for _, variant := range []string{i.Size, i.Color} {
+		if variant != "" {
+			variants = append(variants, variant)
+		}
+	}
+	if len(variants) > 0 {
+		name += " (" + strings.Join(variants, "/") + ")"
+	}
+
+	return fmt.Sprintf("%d x %s", i.Quantity, name)
+}
+
+// DeleteShipment deletes a shipment
+func (s *OrderService) DeleteShipment(orderID uuid.UUID) error {
+	// Get the shipment
+	shipment, err := s.OrderRepo.GetShipmentByOrderID(orderID)
+	if err != nil {
+		return notFoundError("shipment", err)
+	}
+
+	// Delete shipment
+	return s.OrderRepo.DeleteShipment(shipment.ID)
+}
+
+// checkOrderEditable rejects edits of an order that has been handed to the carrier, unless admins
+// are allowed to override the lock
+func (s *OrderService) checkOrderEditable(o *order.Order, isAdmin bool) error {
+	if !o.IsHandedToCarrier() || (isAdmin && s.Settings.AdminEditHandedOver) {
+		return nil
+	}
+	return validationError(fmt.Sprintf("order has been handed to the carrier (status %s) and can no longer be edited", o.OrderStatus))
+}
+
+// AddOrderItem adds an item to an order
+func (s *OrderService) AddOrderItem(orderID uuid.UUID, inventoryID uuid.UUID, quantity int, isAdmin bool) error {
+	if err := validateItemQuantity(quantity); err != nil {
+		return err
+	}
+
+	// Get the order
+	o, err := s.OrderRepo.GetOrderByID(orderID)
+	if err != nil {
+		return notFoundError("order", err)
+	}
+
+	// Check if the order is still editable
+	if err := s.checkOrderEditable(o, isAdmin); err != nil {
+		return err
+	}
+
+	// Check if order status allows adding items
+	if o.OrderStatus != order.OrderShipmentRequested {
+		return validationError("order status does not allow adding items")
+	}
+
+	// Check inventory availability
+	available, err := s.checkInventoryAvailability(inventoryID, quantity)
+	if err != nil {
+		return notFoundError("inventory", err)
+	}
+
+	if !available {
+		return conflictError("not enough inventory")
+	}
+
+	// Get inventory for product ID
+	inventory, err := s.ProductService.GetInventoryByID(inventoryID)
+	if err != nil {
+		return notFoundError("inventory", err)
+	}
+
+	// Get the current unit price for the added quantity in the order currency
+	price, listedPrice, err := s.ProductService.GetPriceForQuantity(inventory.ProductID, quantity)
+	if err != nil {
+		return err
+	}
+	orderCurrency := s.orderCurrency(o)
+	itemPrice, err := s.convertItemPrice(price, listedPrice, orderCurrency)
+	if err != nil {
+		return err
+	}
+	unitPrice := itemPrice.UnitPrice
+
+	// Start transaction
+	tx := s.DB.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	// Create order item
+	orderItem := &order.OrderItem{
+		OrderID:          orderID,
+		InventoryID:      inventoryID,
+		Quantity:         quantity,
+		PriceAtOrder:     unitPrice,
+		Currency:         orderCurrency,
+		OriginalPrice:    itemPrice.OriginalPrice,
+		OriginalCurrency: itemPrice.OriginalCurrency,
+	}
+
+	if err := tx.Create(orderItem).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var undo productUndo
+	if o.SalesCounted {
+		if err := s.adjustSoldCount(inventoryID, quantity, &undo); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	// Update order total
+	o.TotalAmount += unitPrice * float64(quantity)
+	recalculateFinalTotal(o)
+	if err := s.checkDiscountApproval(o, isAdmin); err != nil {
+		tx.Rollback()
+		undo.run()
+		return err
+	}
+
+	if err := tx.Save(o).Error; err != nil {
+		tx.Rollback()
+		undo.run()
+		return err
+	}
+
+	// Orders whose stock is already reserved take the new item off the inventory too
+	added := []OrderItemInfo{{InventoryID: inventoryID, Quantity: quantity}}
+	if o.InventoryReserved {
+		if err := s.reserveOrderItems(o.ID, added); err != nil {
+			tx.Rollback()
+			undo.run()
+			return err
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		undo.run()
+		s.releaseCreatedOrderItems(o, added)
+		return err
+	}
+	return nil
+}
+
+// UpdateOrderItem updates an order item
+func (s *OrderService) UpdateOrderItem(id uuid.UUID, quantity int, isAdmin bool) error {
+	if err := validateItemQuantity(quantity); err != nil {
+		return err
+	}
+
+	// Get the order item
+	item, err := s.OrderRepo.GetOrderItemByID(id)
+	if err != nil {
+		return notFoundError("order item", err)
+	}
+
+	// Get the order
+	o, err := s.OrderRepo.GetOrderByID(item.OrderID)
+	if err != nil {
+		return notFoundError("order", err)
+	}
+
+	// Check if the order is still editable
+	if err := s.checkOrderEditable(o, isAdmin); err != nil {
+		return err
+	}
+
+	// Check if order status allows updating items
+	if o.OrderStatus != order.OrderShipmentRequested {
+		return validationError("order status does not allow updating items")
+	}
+
+	// If quantity is increasing, check inventory availability
+	if quantity > item.Quantity {
+		available, err := s.checkInventoryAvailability(item.InventoryID, quantity-item.Quantity)
+		if err != nil {
+			return err
+		}
+
+		if !available {
+			return conflictError("not enough inventory")
+		}
+	}
+
+	// Start transaction
+	tx := s.DB.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	// Calculate price difference
+	priceDifference := item.PriceAtOrder * float64(quantity-item.Quantity)
+
+	var undo productUndo
+	if o.SalesCounted && quantity != item.Quantity {
+		if err := s.adjustSoldCount(item.InventoryID, quantity-item.Quantity, &undo); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	// Update order item
+	delta := quantity - item.Quantity
+	item.Quantity = quantity
+	if err := tx.Save(item).Error; err != nil {
+		tx.Rollback()
+		undo.run()
+		return err
+	}
+
+	// Update order total
+	o.TotalAmount += priceDifference
+	recalculateFinalTotal(o)
+	if err := s.checkDiscountApproval(o, isAdmin); err != nil {
+		tx.Rollback()
+		undo.run()
+		return err
+	}
+
+	if err := tx.Save(o).Error; err != nil {
+		tx.Rollback()
+		undo.run()
+		return err
+	}
+
+	// Orders whose stock is already reserved take or give back the difference
+	if o.InventoryReserved && delta > 0 {
+		if err := s.reserveOrderItems(o.ID, []OrderItemInfo{{InventoryID: item.InventoryID, Quantity: delta}}); err != nil {
+			tx.Rollback()
+			undo.run()
+			return err
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		undo.run()
+		if o.InventoryReserved && delta > 0 {
+			s.releaseOrderItems(o.ID, []OrderItemInfo{{InventoryID: item.InventoryID, Quantity: delta}})
+		}
+		return err
+	}
+
+	if o.InventoryReserved && delta < 0 {
+		s.releaseOrderItems(o.ID, []OrderItemInfo{{InventoryID: item.InventoryID, Quantity: -delta}})
+	}
+	return nil
+}
+
+// DeleteOrderItem deletes an order item
+func (s *OrderService) DeleteOrderItem(id uuid.UUID, isAdmin bool) error {
+	// Get the order item
+	item, err := s.OrderRepo.GetOrderItemByID(id)
+	if err != nil {
+		return notFoundError("order item", err)
+	}
+
+	// Get the order
+	o, err := s.OrderRepo.GetOrderByID(item.OrderID)
+	if err != nil {
+		return notFoundError("order", err)
+	}
+
+	// Check if the order is still editable
+	if err := s.checkOrderEditable(o, isAdmin); err != nil {
+		return err
+	}
+
+	// Check if order status allows deleting items
+	if o.OrderStatus != order.OrderShipmentRequested {
+		return validationError("order status does not allow deleting items")
+	}
+
+	// An order must keep at least one item; an order that is no longer wanted is canceled instead
+	if len(o.Items) <= 1 {
+		return validationError("cannot delete the last item of an order; cancel the order instead")
+	}
+
+	// Start transaction
+	tx := s.DB.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	// Update order total
+	o.TotalAmount -= item.PriceAtOrder * float64(item.Quantity)
+	recalculateFinalTotal(o)
+	if err := s.checkDiscountApproval(o, isAdmin); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Save(o).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Delete order item
+	if err := tx.Delete(item).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Remove the item from the shipment it is assigned to
+	if err := tx.Where("order_item_id = ?", item.ID).Delete(&order.ShipmentItem{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var undo productUndo
+	if o.SalesCounted {
+		if err := s.adjustSoldCount(item.InventoryID, -item.Quantity, &undo); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		undo.run()
+		return err
+	}
+
+	// Orders whose stock is already reserved give the removed item back
+	if o.InventoryReserved {
+		s.releaseOrderItems(o.ID, []OrderItemInfo{{InventoryID: item.InventoryID, Quantity: item.Quantity}})
+	}
+	return nil
+}
+
+// UpdateOrderDetails updates the details of an order
+func (s *OrderService) UpdateOrderDetails(
+	id uuid.UUID,
+	notes string,
+	paymentMethod order.PaymentMethod,
+	discountAmount *float64,
+	discountPercent *float64,
+	discountReason *string,
+	shippingAddress string,
+	shippingWard string,
+	shippingDistrict string,
+	shippingCity string,
+	shippingCountry string,
+	customerName string,
+	customerEmail string,
+	customerPhone string,
+	isAdmin bool,
+) (*OrderResult, error) {
+	// Get the order
+	o, err := s.OrderRepo.GetOrderByID(id)
+	if err != nil {
+		return &OrderResult{
+			Success: false,
+			Message: "Order details update failed",
+			Error:   "Order not found",
+		}, notFoundError("order", err)
+	}
+
+	// Check if the order is still editable
+	if err := s.checkOrderEditable(o, isAdmin); err != nil {
+		return &OrderResult{
+			Success: false,
+			Message: "Order details update failed",
+			Error:   err.Error(),
+		}, err
+	}
+
+	// The discount is only changed when a fixed or a percentage discount is given; a
+	// percentage discount is taken of the current order total
+	discountChanged := discountAmount != nil || discountPercent != nil
+	var newDiscountAmount float64
+	if discountAmount != nil {
+		newDiscountAmount = *discountAmount
+	}
+	if discountChanged {
+		if newDiscountAmount < 0 {
+			return &OrderResult{
+				Success: false,
+				Message: "Order details update failed",
+				Error:   "Discount amount cannot be negative",
+			}, validationError("discount amount cannot be negative")
+		}
+		if err := validateDiscountMode(newDiscountAmount, discountPercent); err != nil {
+			return &OrderResult{
+				Success: false,
+				Message: "Order details update failed",
+				Error:   err.Error(),
+			}, err
+		}
+		if discountPercent != nil {
+			newDiscountAmount = percentOf(o.TotalAmount, *discountPercent)
+		}
+		if err := validateDiscountAgainstTotal(newDiscountAmount, o.TotalAmount); err != nil {
+			return &OrderResult{
+				Success: false,
+				Message: "Order details update failed",
+				Error:   err.Error(),
+			}, err
+		}
+
+		// Large discounts require admin approval. Resubmitting the current discount is allowed
+		// so that agents can still edit orders an admin has already approved.
+		if !isAdmin && newDiscountAmount != o.DiscountAmount && s.Settings.DiscountRequiresApproval(o.TotalAmount, newDiscountAmount) {
+			return &OrderResult{
+				Success: false,
+				Message: "Order details update failed",
+				Error:   "Discount requires admin approval",
+			}, forbiddenError("discount requires admin approval")
+		}
+	}
+
+	// Update fields if provided
+	if paymentMethod != "" {
+		o.PaymentMethod = paymentMethod
+	}
+
+	// Update notes if provided
+	if notes != "" {
+		o.Notes = notes
+	}
+
+	// Update discount if provided
+	if discountChanged {
+		o.DiscountAmount = newDiscountAmount
+		o.DiscountPercent = discountPercent
+		recalculateFinalTotal(o)
+	}
+	if discountReason != nil {
+		o.DiscountReason = *discountReason
+	}
+
+	// Update shipping address if provided
+	if shippingAddress != "" {
+		o.ShippingAddress = shippingAddress
+	}
+	if shippingWard != "" {
+		o.ShippingWard = shippingWard
+	}
+	if shippingDistrict != "" {
+		o.ShippingDistrict = shippingDistrict
+	}
+	if shippingCity != "" {
+		o.ShippingCity = shippingCity
+	}
+	if shippingCountry != "" {
+		o.ShippingCountry = shippingCountry
+	}
+
+	// Update customer information if provided
+	if customerName != "" {
+		o.CustomerName = customerName
+	}
+	if customerEmail != "" {
+		o.CustomerEmail = customerEmail
+	}
+	if customerPhone != "" {
+		normalized, err := phone.Normalize(customerPhone)
+		if err != nil {
+			return &OrderResult{
+				Success: false,
+				Message: "Order details update failed",
+				Error:   err.Error(),
+			}, validationError(err.Error())
+		}
+		o.CustomerPhone = normalized
+	}
+
+	// Save the order
+	if err := s.OrderRepo.UpdateOrder(o); err != nil {
+		return &OrderResult{
+			Success: false,
+			Message: "Order details update failed",
+			Error:   "Error updating order details",
+		}, err
+	}
+
+	// Send notification
+	if s.NotificationService != nil {
+		metadata := map[string]interface{}{
+			"order_id":        o.ID.String(),
+			"payment_method":  string(o.PaymentMethod),
+			"discount_amount": o.DiscountAmount,
+			"discount_reason": o.DiscountReason,
+			"final_amount":    o.FinalTotalAmount,
+		}
+
+		if o.CreatedBy != nil {
+			s.notifyOrder(o.ID, o.OrderNumber, *o.CreatedBy, "details_updated", metadata)
+		}
+	}
+
+	return &OrderResult{
+		Success:        true,
+		Message:        "Order details updated successfully",
+		OrderID:        o.ID,
+		Status:         o.OrderStatus,
+		Total:          o.TotalAmount,
+		DiscountAmount: o.DiscountAmount,
+		DiscountReason: o.DiscountReason,
+		FinalTotal:     o.FinalTotalAmount,
+		CreatedBy:      o.CreatedBy,
+	}, nil
+}
+
+// ApplyPromoCode applies a promo code to an order awaiting shipment, replacing its discount. The
+// code must be within its active window, below its usage limit and the order total must reach
+// its minimum order amount. A percent code follows the order total when items change. Each order
+// redeems at most one code; its use is counted atomically, so a code cannot be redeemed more
+// often than its maximum number of uses, even concurrently.
+func (s *OrderService) ApplyPromoCode(orderID uuid.UUID, code string) (*OrderResult, error) {
+	code = normalizePromoCode(code)
+	if code == "" {
+		return &OrderResult{
+			Success: false,
+			Message: "Promo code application failed",
+			Error:   "Promo code is required",
+		}, validationError("promo code is required")
+	}
+
+	o, err := s.OrderRepo.GetOrderByID(orderID)
+	if err != nil {
+		return &OrderResult{
+			Success: false,
+			Message: "Promo code application failed",
+			Error:   "Order not found",
+		}, notFoundError("order", err)
+	}
+
+	promo, err := repositories.NewPromoRepository(s.DB).GetPromoCodeByCode(code)
+	if err != nil {
+		return &OrderResult{
+			Success: false,
+			Message: "Promo code application failed",
+			Error:   "Promo code not found",
+		}, notFoundError("promo code", err)
+	}
+
+	var discountAmount float64
+	var discountPercent *float64
+	if promo.Type == order.PromoPercent {
+		percent := promo.Value
+		discountPercent = &percent
+		discountAmount = percentOf(o.TotalAmount, percent)
+	} else {
+		discountAmount = promo.Value
+	}
+
+	if err := validatePromoCode(o, promo, discountAmount, time.Now()); err != nil {
+		return &OrderResult{
+			Success: false,
+			Message: "Promo code application failed",
+			Error:   err.Error(),
+		}, err
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the order so that concurrent requests cannot both redeem a code for it
+		var locked order.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "promo_code_id", "promo_code").
+			First(&locked, "id = ?", o.ID).Error; err != nil {
+			return err
+		}
+		if locked.PromoCodeID != nil {
+			return conflictError(fmt.Sprintf("order already has promo code %s", locked.PromoCode))
+		}
+
+		redeemed, err := repositories.NewPromoRepository(tx).RedeemPromoCode(promo.ID)
+		if err != nil {
+			return err
+		}
+		if !redeemed {
+			return conflictError("promo code has reached its usage limit")
+		}
+
+		o.DiscountAmount = discountAmount
+		o.DiscountPercent = discountPercent
+		o.DiscountReason = fmt.Sprintf("Promo code %s", promo.Code)
+		o.PromoCodeID = &promo.ID
+		o.PromoCode = promo.Code
+		recalculateFinalTotal(o)
+
+		return tx.Model(o).Updates(map[string]interface{}{
+			"discount_amount":    o.DiscountAmount,
+			"discount_percent":   o.DiscountPercent,
+			"discount_reason":    o.DiscountReason,
+			"final_total_amount": o.FinalTotalAmount,
+			"promo_code_id":      o.PromoCodeID,
+			"promo_code":         o.PromoCode,
+		}).Error
+	})
+	if err != nil {
+		return &OrderResult{
+			Success: false,
+			Message: "Promo code application failed",
+			Error:   err.Error(),
+		}, err
+	}
+
+	return &OrderResult{
+		Success:        true,
+		Message:        "Promo code applied successfully",
+		OrderID:        o.ID,
+		OrderNumber:    o.OrderNumber,
+		Status:         o.OrderStatus,
+		Total:          o.TotalAmount,
+		DiscountAmount: o.DiscountAmount,
+		DiscountReason: o.DiscountReason,
+		FinalTotal:     o.FinalTotalAmount,
+		CreatedBy:      o.CreatedBy,
+	}, nil
+}
+
+// validatePromoCode checks that the promo code can be applied to the order at the given time
+func validatePromoCode(o *order.Order, promo *order.PromoCode, discountAmount float64, now time.Time) error {
+	if o.OrderStatus != order.OrderShipmentRequested {
+		return validationError(fmt.Sprintf("promo codes cannot be applied to %s orders", o.OrderStatus))
+	}
+	if o.PromoCodeID != nil {
+		return conflictError(fmt.Sprintf("order already has promo code %s", o.PromoCode))
+	}
+	if !promo.IsActiveAt(now) {
+		return validationError("promo code is not active")
+	}
+	if promo.IsExhausted() {
+		return conflictError("promo code has reached its usage limit")
+	}
+	if o.TotalAmount < promo.MinOrderAmount {
+		return validationError(fmt.Sprintf("order total must be at least %.2f to use this promo code", promo.MinOrderAmount))
+	}
+	return validateDiscountAgainstTotal(discountAmount, o.TotalAmount)
+}
+
+// validateDiscountMode checks that an order uses at most one discount mode and that a
+// percentage discount is within (0, 100]
+func validateDiscountMode(discountAmount float64, discountPercent *float64) error {
+	if discountPercent == nil {
+		return nil
+	}
+	if discountAmount > 0 {
+		return validationError("only one of discount amount and discount percent can be set")
+	}
+	if *discountPercent <= 0 || *discountPercent > 100 {
+		return validationError("discount percent must be greater than 0 and at most 100")
+	}
+	return nil
+}
+
+// validateDiscountAgainstTotal checks that a discount does not exceed the total it is taken of
+func validateDiscountAgainstTotal(discountAmount, totalAmount float64) error {
+	if discountAmount > totalAmount {
+		return validationError("discount exceeds order total")
+	}
+	return nil
+}
+
+// percentOf returns the given percentage of an amount rounded to the currency precision
+func percentOf(amount, percent float64) float64 {
+	return utils.RoundMoney(amount * percent / 100)
+}
+
+// checkDiscountApproval rejects item changes by non-admins that leave the order discount
+// above the approval threshold of the recalculated total
+func (s *OrderService) checkDiscountApproval(o *order.Order, isAdmin bool) error {
+	if !isAdmin && o.DiscountAmount > 0 && s.Settings.DiscountRequiresApproval(o.TotalAmount, o.DiscountAmount) {
+		return forbiddenError("discount requires admin approval")
+	}
+	return nil
+}
+
+// recalculateFinalTotal recomputes the final total of an order after its total changed. A
+// percentage discount follows the new total; the final total is never negative.
+func recalculateFinalTotal(o *order.Order) {
+	if o.DiscountPercent != nil {
+		o.DiscountAmount = percentOf(o.TotalAmount, *o.DiscountPercent)
+	}
+	o.FinalTotalAmount = o.TotalAmount - o.DiscountAmount
+	if o.FinalTotalAmount < 0 {
+		o.FinalTotalAmount = 0 // Ensure final amount is not negative
+	}
+}
+
+// OrderLinePricing represents a priced order line and its share of the order discount
+type OrderLinePricing struct {
+	InventoryID   uuid.UUID
+	ProductID     uuid.UUID
+	ProductName   string
+	SKU           string
+	Quantity      int
+	UnitPrice     float64
+	LineTotal     float64
+	DiscountShare float64
+	FinalTotal    float64
+}
+
+// OrderPricing represents the computed amounts of an order. Orders carry no separate tax,
+// so TaxAmount is always zero.
+type OrderPricing struct {
+	Lines            []OrderLinePricing
+	Subtotal         float64
+	DiscountAmount   float64
+	DiscountPercent  *float64
+	DiscountReason   string
+	TaxAmount        float64
+	FinalTotal       float64
+	RequiresApproval bool
+}
+
+// CalculateOrderPricing computes the line totals, subtotal and final total of an order from
+// the unit price and quantity of its lines. The discount is split across the lines in
+// proportion to their totals, the last line taking the rounding remainder, and never
+// brings the final total below zero.
+func CalculateOrderPricing(lines []OrderLinePricing, discountAmount float64) OrderPricing {
+	pricing := OrderPricing{
+		Lines:          make([]OrderLinePricing, len(lines)),
+		DiscountAmount: utils.RoundMoney(discountAmount),
+	}
+
+	subtotal := 0.0
+	for i, line := range lines {
+		line.LineTotal = utils.RoundMoney(line.UnitPrice * float64(line.Quantity))
+		subtotal += line.LineTotal
+		pricing.Lines[i] = line
+	}
+	pricing.Subtotal = utils.RoundMoney(subtotal)
+
+	applied := math.Max(0, math.Min(pricing.DiscountAmount, pricing.Subtotal))
+	remaining := applied
+	for i := range pricing.Lines {
+		share := remaining
+		if i < len(pricing.Lines)-1 && pricing.Subtotal > 0 {
+			share = utils.RoundMoney(applied * pricing.Lines[i].LineTotal / pricing.Subtotal)
+		}
+		remaining = utils.RoundMoney(remaining - share)
+
+		pricing.Lines[i].DiscountShare = share
+		pricing.Lines[i].FinalTotal = utils.RoundMoney(pricing.Lines[i].LineTotal - share)
+	}
+	pricing.FinalTotal = utils.RoundMoney(pricing.Subtotal - applied)
+
+	return pricing
+}
+
+// orderItemPrice is the unit price of an order item in the order currency together with the
+// listed unit price it was converted from
+type orderItemPrice struct {
+	UnitPrice        float64
+	OriginalPrice    float64
+	OriginalCurrency string
+}
+
+// orderCurrency returns the currency an order is priced in; orders created before orders had a
+// currency, and orders not created yet, are priced in the configured currency
+func (s *OrderService) orderCurrency(o *order.Order) string {
+	if o != nil && o.Currency != "" {
+		return o.Currency
+	}
+	if configured := currency.Normalize(s.Settings.Currency); configured != "" {
+		return configured
+	}
+	return DefaultCurrency
+}
+
+// convertItemPrice converts the unit price listed in a product price to the order currency.
+// Prices without a currency are taken to be in the order currency.
+func (s *OrderService) convertItemPrice(price *product.Price, unitPrice float64, orderCurrency string) (orderItemPrice, error) {
+	originalCurrency := currency.Normalize(price.Currency)
+	if originalCurrency == "" {
+		originalCurrency = orderCurrency
+	}
+
+	converted, err := s.CurrencyService.ConvertAmount(unitPrice, originalCurrency, orderCurrency)
+	if err != nil {
+		return orderItemPrice{}, err
+	}
+	return orderItemPrice{UnitPrice: converted, OriginalPrice: unitPrice, OriginalCurrency: originalCurrency}, nil
+}
+
+// PreviewOrderDiscount prices the given items at their current prices and applies the
+// discount without persisting anything, so staff can review the effect before ordering
+func (s *OrderService) PreviewOrderDiscount(items []OrderItemInfo, discountAmount float64, discountPercent *float64, discountReason string) (*OrderPricing, error) {
+	if len(items) == 0 {
+		return nil, validationError("at least one item is required")
+	}
+	if discountAmount < 0 {
+		return nil, validationError("discount amount cannot be negative")
+	}
+	if err := validateDiscountMode(discountAmount, discountPercent); err != nil {
+		return nil, err
+	}
+
+	lines := make([]OrderLinePricing, 0, len(items))
+	for i, item := range items {
+		if err := validateItemQuantity(item.Quantity); err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+
+		inventory, err := s.ProductService.GetInventoryByID(item.InventoryID)
+		if err != nil {
+			return nil, notFoundError("inventory", err)
+		}
+
+		p, err := s.ProductService.GetProductByID(inventory.ProductID)
+		if err != nil {
+			return nil, notFoundError("product", err)
+		}
+
+		price, listedPrice, err := s.ProductService.GetPriceForQuantity(inventory.ProductID, item.Quantity)
+		if err != nil {
+			return nil, validationError(fmt.Sprintf("no valid price found for product %s", inventory.ProductID))
+		}
+		itemPrice, err := s.convertItemPrice(price, listedPrice, s.orderCurrency(nil))
+		if err != nil {
+			return nil, err
+		}
+		unitPrice := itemPrice.UnitPrice
+
+		lines = append(lines, OrderLinePricing{
+			InventoryID: item.InventoryID,
+			ProductID:   p.ID,
+			ProductName: p.Name,
+			SKU:         p.SKU,
+			Quantity:    item.Quantity,
+			UnitPrice:   unitPrice,
+		})
+	}
+
+	subtotal := CalculateOrderPricing(lines, 0).Subtotal
+	if discountPercent != nil {
+		discountAmount = percentOf(subtotal, *discountPercent)
+	}
+	if err := validateDiscountAgainstTotal(discountAmount, subtotal); err != nil {
+		return nil, err
+	}
+
+	pricing := CalculateOrderPricing(lines, discountAmount)
+	pricing.DiscountPercent = discountPercent
+	pricing.DiscountReason = discountReason
+	pricing.RequiresApproval = s.Settings.DiscountRequiresApproval(pricing.Subtotal, pricing.DiscountAmount)
+
+	return &pricing, nil
+}
+
+// RepriceOrder updates the price of every item of an open order to the current unit price for its quantity
+// and recomputes the order totals. The price changes are recorded in an order notification.
+func (s *OrderService) RepriceOrder(id uuid.UUID) (*OrderResult, error) {
+	// Get the order
+	o, err := s.OrderRepo.GetOrderByID(id)
+	if err != nil {
+		return &OrderResult{
+			Success: false,
+			Message: "Order reprice failed",
+			Error:   "Order not found",
+		}, notFoundError("order", err)
+	}
+
+	// Only orders whose items can still be edited can be repriced
+	if o.OrderStatus != order.OrderShipmentRequested {
+		return &OrderResult{
+			Success: false,
+			Message: "Order reprice failed",
+			Error:   "Only shipment_requested orders can be repriced",
+		}, validationError("only shipment_requested orders can be repriced")
+	}
+
+	// Start transaction
+	tx := s.DB.Begin()
+	if tx.Error != nil {
+		return &OrderResult{
+			Success: false,
+			Message: "Order reprice failed",
+			Error:   "Database transaction error",
+		}, tx.Error
+	}
+
+	oldTotal := o.TotalAmount
+	orderCurrency := s.orderCurrency(o)
+	var totalAmount float64
+	var changes []map[string]interface{}
+	for i := range o.Items {
+		item := &o.Items[i]
+
+		// Get inventory for product ID
+		inventory, err := s.ProductService.GetInventoryByID(item.InventoryID)
+		if err != nil {
+			tx.Rollback()
+			return &OrderResult{
+				Success: false,
+				Message: "Order reprice failed",
+				Error:   "Inventory not found",
+			}, notFoundError("inventory", err)
+		}
+
+		// Get the current unit price for the ordered quantity
+		price, listedPrice, err := s.ProductService.GetPriceForQuantity(inventory.ProductID, item.Quantity)
+		if err != nil {
+			tx.Rollback()
+			return &OrderResult{
+				Success: false,
+				Message: "Order reprice failed",
+				Error:   fmt.Sprintf("No valid price found for product %s", inventory.ProductID),
+			}, validationError(fmt.Sprintf("no valid price found for product %s", inventory.ProductID))
+		}
+
+		// Charge the item in the order currency at the current exchange rate
+		itemPrice, err := s.convertItemPrice(price, listedPrice, orderCurrency)
+		if err != nil {
+			tx.Rollback()
+			return &OrderResult{
+				Success: false,
+				Message: "Order reprice failed",
+				Error:   err.Error(),
+			}, err
+		}
+		unitPrice := itemPrice.UnitPrice
+
+		if unitPrice != item.PriceAtOrder {
+			changes = append(changes, map[string]interface{}{
+				"item_id":   item.ID.String(),
+				"old_price": item.PriceAtOrder,
+				"new_price": unitPrice,
+			})
+
+			if err := tx.Model(item).Updates(map[string]interface{}{
+				"price_at_order":    unitPrice,
+				"currency":          orderCurrency,
+				"original_price":    itemPrice.OriginalPrice,
+				"original_currency": itemPrice.OriginalCurrency,
+			}).Error; err != nil {
+				tx.Rollback()
+				return &OrderResult{
+					Success: false,
+					Message: "Order reprice failed",
+					Error:   "Error updating order item price",
+				}, err
+			}
+			item.PriceAtOrder = unitPrice
+			item.Currency = orderCurrency
+			item.OriginalPrice = itemPrice.OriginalPrice
+			item.OriginalCurrency = itemPrice.OriginalCurrency
+		}
+
+		totalAmount += item.PriceAtOrder * float64(item.Quantity)
+	}
+
+	// Recalculate totals
+	o.TotalAmount = totalAmount
+	recalculateFinalTotal(o)
+
+	if err := tx.Model(o).Updates(map[string]interface{}{
+		"total_amount":       o.TotalAmount,
+		"discount_amount":    o.DiscountAmount,
+		"final_total_amount": o.FinalTotalAmount,
+	}).Error; err != nil {
+		tx.Rollback()
+		return &OrderResult{
+			Success: false,
+			Message: "Order reprice failed",
+			Error:   "Error updating order total",
+		}, err
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		return &OrderResult{
+			Success: false,
+			Message: "Order reprice failed",
+			Error:   "Error committing transaction",
+		}, err
+	}
+
+	// Record the price changes
+	if s.NotificationService != nil && len(changes) > 0 && o.CreatedBy != nil {
+		metadata := map[string]interface{}{
+			"order_id":     o.ID.String(),
+			"old_total":    oldTotal,
+			"new_total":    o.TotalAmount,
+			"final_amount": o.FinalTotalAmount,
+			"changes":      changes,
+		}
+		s.notifyOrder(o.ID, o.OrderNumber, *o.CreatedBy, "repriced", metadata)
+	}
+
+	return &OrderResult{
+		Success:        true,
+		Message:        "Order repriced successfully",
+		OrderID:        o.ID,
+		OrderNumber:    o.OrderNumber,
+		Status:         o.OrderStatus,
+		Total:          o.TotalAmount,
+		DiscountAmount: o.DiscountAmount,
+		DiscountReason: o.DiscountReason,
+		FinalTotal:     o.FinalTotalAmount,
+		CreatedBy:      o.CreatedBy,
+	}, nil
+}
+
+// GetOrderByTrackingNumber retrieves an order by shipment tracking number
+func (s *OrderService) GetOrderByTrackingNumber(trackingNumber string) (*order.Order, error) {
+	if trackingNumber == "" {
+		return nil, validationError("tracking number is required")
+	}
+	o, err := s.OrderRepo.GetOrderByTrackingNumber(trackingNumber)
+	if err != nil {
+		return nil, notFoundError("order", err)
+	}
+	return o, nil
+}
+
+// GetOrdersByPhoneNumber retrieves orders by customer phone number with pagination
+func (s *OrderService) GetOrdersByPhoneNumber(phoneNumber string, page, pageSize int, additionalFilters map[string]interface{}) ([]order.Order, int64, error) {
+	if phoneNumber == "" {
+		return nil, 0, fmt.Errorf("phone number is required")
+	}
+
+	return s.OrderRepo.GetOrdersByPhoneNumber(phoneNumber, page, pageSize, additionalFilters)
+}
+
+// GetCustomerByID retrieves the customer profile orders are linked to
+func (s *OrderService) GetCustomerByID(id uuid.UUID) (*order.Customer, error) {
+	customer, err := repositories.NewCustomerRepository(s.DB).GetCustomerByID(id)
+	if err != nil {
+		return nil, notFoundError("customer", err)
+	}
+	return customer, nil
+}
+
+// OrderItemDetails is an order item together with its inventory, the product of the inventory and
+// the current price of that product. Each of them is nil when it could not be found.
+type OrderItemDetails struct {
+	Item      order.OrderItem
+	Inventory *product.Inventory
+	Product   *product.Product
+	Price     *product.Price
+}
+
+// OrderWithDetails is an order together with the details of its items and the name of its creator
+type OrderWithDetails struct {
+	Order       order.Order
+	CreatorName string
+	Items       []OrderItemDetails
+}
+
+// GetOrdersWithDetails retrieves orders with pagination and filtering like GetAllOrders, together
+// with the inventories, products, current prices and creators they reference
+func (s *OrderService) GetOrdersWithDetails(page, pageSize int, filters map[string]interface{}) ([]OrderWithDetails, int64, error) {
+	orders, total, err := s.OrderRepo.GetAllOrders(page, pageSize, filters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	details, err := s.loadOrderDetails(orders)
+	return details, total, err
+}
+
+// GetOrdersByPhoneNumberWithDetails retrieves the orders of a phone number like GetOrdersByPhoneNumber,
+// together with the inventories, products, current prices and creators they reference
+func (s *OrderService) GetOrdersByPhoneNumberWithDetails(phoneNumber string, page, pageSize int, additionalFilters map[string]interface{}) ([]OrderWithDetails, int64, error) {
+	orders, total, err := s.GetOrdersByPhoneNumber(phoneNumber, page, pageSize, additionalFilters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	details, err := s.loadOrderDetails(orders)
+	return details, total, err
+}
+
+// loadOrderDetails batch-loads everything the orders reference: one query each for the inventories,
+// products, current prices and creators of all orders, instead of one per item
+func (s *OrderService) loadOrderDetails(orders []order.Order) ([]OrderWithDetails, error) {
+	inventories := make(map[uuid.UUID]*product.Inventory)
+	products := make(map[uuid.UUID]*product.Product)
+	prices := make(map[uuid.UUID]*product.Price)
+	creators := make(map[uuid.UUID]string)
+
+	if s.ProductService != nil {
+		var inventoryIDs []uuid.UUID
+		for _, o := range orders {
+			for _, item := range o.Items {
+				if _, ok := inventories[item.InventoryID]; !ok {
+					inventories[item.InventoryID] = nil
+					inventoryIDs = append(inventoryIDs, item.InventoryID)
+				}
+			}
+		}
+
+		// Deleted inventories and products are included, so past orders still show what was ordered
+		loaded, err := s.ProductService.GetInventoriesByIDs(inventoryIDs)
+		if err != nil {
+			return nil, err
+		}
+		var productIDs []uuid.UUID
+		for i := range loaded {
+			inventories[loaded[i].ID] = &loaded[i]
+			if _, ok := products[loaded[i].ProductID]; !ok {
+				products[loaded[i].ProductID] = nil
+				productIDs = append(productIDs, loaded[i].ProductID)
+			}
+		}
+
+		loadedProducts, err := s.ProductService.GetProductsByIDsIncludingDeleted(productIDs)
+		if err != nil {
+			return nil, err
+		}
+		for i := range loadedProducts {
+			products[loadedProducts[i].ID] = &loadedProducts[i]
+		}
+
+		if prices, err = s.ProductService.GetCurrentPrices(productIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.UserService != nil {
+		var creatorIDs []uuid.UUID
+		seen := make(map[uuid.UUID]bool)
+		for _, o := range orders {
+			if o.CreatedBy != nil && !seen[*o.CreatedBy] {
+				seen[*o.CreatedBy] = true
+				creatorIDs = append(creatorIDs, *o.CreatedBy)
+			}
+		}
+
+		users, err := s.UserService.GetUsersByIDs(creatorIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			creators[user.ID] = user.Username
+		}
+	}
+
+	details := make([]OrderWithDetails, len(orders))
+	for i, o := range orders {
+		details[i] = OrderWithDetails{
+			Order: o,
+			Items: make([]OrderItemDetails, len(o.Items)),
+		}
+		if o.CreatedBy != nil {
+			details[i].CreatorName = creators[*o.CreatedBy]
+		}
+
+		for j, item := range o.Items {
+			itemDetails := OrderItemDetails{Item: item, Inventory: inventories[item.InventoryID]}
+			if itemDetails.Inventory != nil {
+				itemDetails.Product = products[itemDetails.Inventory.ProductID]
+			}
+			if itemDetails.Product != nil {
+				itemDetails.Price = prices[itemDetails.Product.ID]
+			}
+			details[i].Items[j] = itemDetails
+		}
+	}
+
+	return details, nil
+}
+
+// OrderAssignmentResult represents the outcome of assigning a single order to an agent
+type OrderAssignmentResult struct {
+	OrderID     uuid.UUID
+	OrderNumber string
+	Success     bool
+	Error       string
+}
+
+// BulkAssignResult represents the result of a bulk order assignment
+type BulkAssignResult struct {
+	Success  bool
+	Message  string
+	Error    string
+	AgentID  uuid.UUID
+	Assigned int
+	Failed   int
+	Results  []OrderAssignmentResult
+}
+
+// BulkAssignOrders assigns the given pending orders to an active agent.
+// Each order is processed independently so one failure does not block the others.
+func (s *OrderService) BulkAssignOrders(orderIDs []uuid.UUID, agentID uuid.UUID, assignedBy uuid.UUID) (*BulkAssignResult, error) {
+	// Validate the target agent
+	agent, err := s.UserService.GetUserByID(agentID)
+	if err != nil {
+		return &BulkAssignResult{
+			Success: false,
+			Message: "Bulk assignment failed",
+			Error:   "Agent not found",
+			AgentID: agentID,
+		}, notFoundError("agent", err)
+	}
+
+	if err := validateAssignee(agent); err != nil {
+		return &BulkAssignResult{
+			Success: false,
+			Message: "Bulk assignment failed",
+			Error:   err.Error(),
+			AgentID: agentID,
+		}, err
+	}
+
+	result := &BulkAssignResult{
+		AgentID: agentID,
+		Results: make([]OrderAssignmentResult, 0, len(orderIDs)),
+	}
+
+	for _, orderID := range orderIDs {
+		itemResult := OrderAssignmentResult{OrderID: orderID}
+
+		o, err := s.OrderRepo.GetOrderByID(orderID)
+		if err != nil {
+			itemResult.Error = "Order not found"
+		} else if o.OrderStatus != order.OrderShipmentRequested {
+			itemResult.Error = fmt.Sprintf("Order with status %s cannot be reassigned", o.OrderStatus)
+		} else if err := s.DB.Model(&order.Order{}).Where("id = ?", orderID).Updates(map[string]interface{}{
+			"created_by": agentID,
+			"updated_by": assignedBy,
+		}).Error; err != nil {
+			itemResult.Error = "Error assigning order"
+		} else {
+			itemResult.OrderNumber = o.OrderNumber
+			itemResult.Success = true
+		}
+
+		if itemResult.Success {
+			result.Assigned++
+		} else {
+			result.Failed++
+		}
+		result.Results = append(result.Results, itemResult)
+	}
+
+	result.Success = result.Failed == 0
+	result.Message = fmt.Sprintf("%d orders assigned successfully", result.Assigned)
+	if result.Failed > 0 {
+		result.Message += fmt.Sprintf(", %d failed", result.Failed)
+	}
+
+	// Let the agent know about the new work
+	if s.NotificationService != nil && result.Assigned > 0 {
+		for _, r := range result.Results {
+			if !r.Success {
+				continue
+			}
+			metadata := map[string]interface{}{
+				"order_id":    r.OrderID.String(),
+				"assigned_to": agentID.String(),
+				"assigned_by": assignedBy.String(),
+			}
+			s.notifyOrder(r.OrderID, r.OrderNumber, agentID, "assigned", metadata)
+		}
+	}
+
+	return result, nil
+}
+
+// deliveryProofSubDir is the upload directory of delivery proof images
+const deliveryProofSubDir = "delivery-proofs"
+
+// RecordDeliveryProof stores the signature or photo captured on delivery together with the recipient
+// name and delivery time, then moves the order to delivered unless it already is. The transition is
+// checked before the image is uploaded, so an order that cannot be delivered keeps no stray file.
+func (s *OrderService) RecordDeliveryProof(orderID uuid.UUID, file *multipart.FileHeader, recipientName string, deliveredAt time.Time, recordedBy uuid.UUID) (*order.Order, error) {
+	recipientName = strings.TrimSpace(recipientName)
+	if file == nil {
+		return nil, validationError("delivery proof image is required")
+	}
+	if recipientName == "" {
+		return nil, validationError("recipient name is required")
+	}
+	if deliveredAt.IsZero() {
+		deliveredAt = time.Now()
+	}
+	if deliveredAt.After(time.Now()) {
+		return nil, validationError("delivered at cannot be in the future")
+	}
+
+	o, err := s.OrderRepo.GetOrderByID(orderID)
+	if err != nil {
+		return nil, notFoundError("order", err)
+	}
+
+	alreadyDelivered := o.OrderStatus == order.OrderDelivered
+	if !alreadyDelivered && !isValidStatusTransition(o.OrderStatus, order.OrderDelivered) {
+		return nil, validationError(fmt.Sprintf("invalid status transition from %s to %s", o.OrderStatus, order.OrderDelivered))
+	}
+
+	uploadResult, err := s.UploadService.Upload(file, deliveryProofSubDir)
+	if err != nil {
+		return nil, validationError(fmt.Sprintf("invalid delivery proof image: %v", err))
+	}
+
+	previousFilename := o.DeliveryProofFilename
+	if err := s.DB.Model(&order.Order{}).Where("id = ?", orderID).Updates(map[string]interface{}{
+		"delivery_proof_url":      uploadResult.URL,
+		"delivery_proof_filename": uploadResult.Filename,
+		"delivery_recipient_name": recipientName,
+		"delivered_at":            deliveredAt,
+		"updated_by":              recordedBy,
+	}).Error; err != nil {
+		_ = s.UploadService.Delete(uploadResult.Filename)
+		return nil, err
+	}
+
+	// A replaced proof is only soft-deleted so the earlier capture stays traceable
+	if previousFilename != "" {
+		_ = s.UploadService.SoftDelete(previousFilename)
+	}
+
+	if !alreadyDelivered {
+		if _, err := s.ChangeOrderStatus(orderID, order.OrderDelivered, StatusChange{ChangedBy: &recordedBy, Note: "Delivery proof recorded"}); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.OrderRepo.GetOrderByID(orderID)
+}
+
+// agentEditableStatuses are the statuses in which an agent may still change the status of an order
+var agentEditableStatuses = []order.OrderStatus{order.OrderShipmentRequested}
+
+// OrderStatusFilter selects the orders changed by a bulk status update. A nil date leaves that
+// side of the creation date range open.
+type OrderStatusFilter struct {
+	Status   order.OrderStatus
+	FromDate *time.Time
+	ToDate   *time.Time
+}
+
+// Reasons a single order of a bulk status update was not changed
+const (
+	StatusUpdateNotFound          = "not_found"
+	StatusUpdateInvalidTransition = "invalid_transition"
+	StatusUpdatePermissionDenied  = "permission_denied"
+	StatusUpdateFailed            = "failed"
+)
+
+// OrderStatusUpdateResult represents the outcome of changing the status of a single order
+type OrderStatusUpdateResult struct {
+	OrderID        uuid.UUID
+	PreviousStatus order.OrderStatus
+	Success        bool
+	Error          string
+	// Reason is one of the StatusUpdate reasons when the order was not changed
+	Reason string
+}
+
+// BulkStatusResult represents the result of a bulk order status update
+type BulkStatusResult struct {
+	Success      bool
+	Message      string
+	Error        string
+	TargetStatus order.OrderStatus
+	Matched      int
+	Updated      int
+	Failed       int
+	Results      []OrderStatusUpdateResult
+}
+
+// BulkUpdateOrderStatusByFilter moves every order matching the filter to the target status, oldest first.
+// Each order goes through the same transition checks as a single status update and is processed
+// independently, so one failure does not block the others. When asAgent is set the agent rules apply:
+// the target must be one of the configured agent target statuses and each order must still be in a
+// status agents may change. Filters matching more orders than BulkStatusMaxOrders are rejected.
+func (s *OrderService) BulkUpdateOrderStatusByFilter(filter OrderStatusFilter, target order.OrderStatus, asAgent bool, change StatusChange) (*BulkStatusResult, error) {
+	result := &BulkStatusResult{TargetStatus: target}
+
+	fail := func(err error) (*BulkStatusResult, error) {
+		result.Message = "Bulk status update failed"
+		result.Error = err.Error()
+		return result, err
+	}
+
+	if !target.IsValid() {
+		return fail(validationError(fmt.Sprintf("invalid target status %s", target)))
+	}
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return fail(validationError(fmt.Sprintf("invalid status filter %s", filter.Status)))
+	}
+	if asAgent && !s.Settings.AgentCanSetStatus(target) {
+		return fail(forbiddenError(fmt.Sprintf("agents cannot change orders to status %s", target)))
+	}
+
+	filters := make(map[string]interface{})
+	if filter.Status != "" {
+		filters["order_status"] = filter.Status
+	}
+	if filter.FromDate != nil {
+		filters["from_date"] = *filter.FromDate
+	}
+	if filter.ToDate != nil {
+		filters["to_date"] = *filter.ToDate
+	}
+
+	maxOrders := s.Settings.WithDefaults().BulkStatusMaxOrders
+	orderIDs, total, err := s.OrderRepo.GetOrderIDsByFilter(filters, maxOrders)
+	if err != nil {
+		return fail(err)
+	}
+	if total > int64(maxOrders) {
+		return fail(validationError(fmt.Sprintf("%d orders match the filter, more than the limit of %d; narrow the filter", total, maxOrders)))
+	}
+
+	result.Matched = len(orderIDs)
+	s.applyBulkStatus(result, orderIDs, asAgent, change)
+
+	return result, nil
+}
+
+// BulkUpdateOrderStatus moves the given orders to the target status in the order given. Each order
+// goes through the same transition checks as a single status update, including its inventory side
+// effects, and is processed independently, so one failure does not block the others. When asAgent
+// is set the agent rules apply as in BulkUpdateOrderStatusByFilter. Requests for more orders than
+// BulkStatusMaxOrders are rejected.
+func (s *OrderService) BulkUpdateOrderStatus(orderIDs []uuid.UUID, target order.OrderStatus, asAgent bool, change StatusChange) (*BulkStatusResult, error) {
+	result := &BulkStatusResult{TargetStatus: target}
+
+	fail := func(err error) (*BulkStatusResult, error) {
+		result.Message = "Bulk status update failed"
+		result.Error = err.Error()
+		return result, err
+	}
+
+	if len(orderIDs) == 0 {
+		return fail(validationError("at least one order ID is required"))
+	}
+	if !target.IsValid() {
+		return fail(validationError(fmt.Sprintf("invalid target status %s", target)))
+	}
+	if asAgent && !s.Settings.AgentCanSetStatus(target) {
+		return fail(forbiddenError(fmt.Sprintf("agents cannot change orders to status %s", target)))
+	}
+	if maxOrders := s.Settings.WithDefaults().BulkStatusMaxOrders; len(orderIDs) > maxOrders {
+		return fail(validationError(fmt.Sprintf("%d orders requested, more than the limit of %d", len(orderIDs), maxOrders)))
+	}
+
+	result.Matched = len(orderIDs)
+	s.applyBulkStatus(result, orderIDs, asAgent, change)
+
+	return result, nil
+}
+
+// applyBulkStatus changes the status of each order to the target status of the result and records
+// the outcome of every order in the result
+func (s *OrderService) applyBulkStatus(result *BulkStatusResult, orderIDs []uuid.UUID, asAgent bool, change StatusChange) {
+	target := result.TargetStatus
+	result.Results = make([]OrderStatusUpdateResult, 0, len(orderIDs))
+
+	for _, orderID := range orderIDs {
+		itemResult := OrderStatusUpdateResult{OrderID: orderID}
+
+		o, err := s.OrderRepo.GetOrderByID(orderID)
+		if err != nil {
+			itemResult.Error = "Order not found"
+			itemResult.Reason = StatusUpdateNotFound
+		} else {
+			itemResult.PreviousStatus = o.OrderStatus
+			if asAgent && !containsOrderStatus(agentEditableStatuses, o.OrderStatus) {
+				itemResult.Error = fmt.Sprintf("Agents cannot change orders with status %s", o.OrderStatus)
+				itemResult.Reason = StatusUpdatePermissionDenied
+			} else if updateResult, err := s.ChangeOrderStatus(orderID, target, change); err != nil {
+				itemResult.Error = updateResult.Error
+				itemResult.Reason = statusUpdateReason(err)
+			} else {
+				itemResult.Success = true
+			}
+		}
+
+		if itemResult.Success {
+			result.Updated++
+		} else {
+			result.Failed++
+		}
+		result.Results = append(result.Results, itemResult)
+	}
+
+	result.Success = result.Failed == 0
+	result.Message = fmt.Sprintf("%d orders updated to %s", result.Updated, target)
+	if result.Failed > 0 {
+		result.Message += fmt.Sprintf(", %d failed", result.Failed)
+	}
+}
+
+// statusUpdateReason classifies the error of a failed status update of a single order
+func statusUpdateReason(err error) string {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return StatusUpdateNotFound
+	case errors.Is(err, ErrForbidden):
+		return StatusUpdatePermissionDenied
+	case errors.Is(err, ErrValidation):
+		return StatusUpdateInvalidTransition
+	default:
+		return StatusUpdateFailed
+	}
+}
+
+// containsOrderStatus reports whether the status is in the list
+func containsOrderStatus(statuses []order.OrderStatus, status order.OrderStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// validateAssignee checks that a user can receive order assignments
+func validateAssignee(user *account.User) error {
+	if !user.IsActive {
+		return validationError("agent account is inactive")
+	}
+
+	for _, role := range user.Roles {
+		if role.Name == account.RoleAgent {
+			return nil
+		}
+	}
+
+	return validationError("user is not an agent")
+}
+
+// nextOrderNumber generates the next order number within the given transaction using the
+// configured prefix and reset period. The sequence is incremented atomically in the
+// database, so concurrent orders never receive the same number.
+func (s *OrderService) nextOrderNumber(tx *gorm.DB, now time.Time) (string, error) {
+	settings := s.Settings.WithDefaults()
+	period := order.OrderNumberPeriod(now, settings.OrderNumberReset)
+	sequence, err := repositories.NewOrderRepository(tx).NextOrderNumberSequence(period)
+	if err != nil {
+		return "", err
+	}
+	return order.FormatOrderNumber(settings.OrderNumberPrefix, period, sequence), nil
+}
+
+// GenerateOrderNumber issues the next order number for an order created at the given time
+func (s *OrderService) GenerateOrderNumber(now time.Time) (string, error) {
+	var orderNumber string
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		orderNumber, err = s.nextOrderNumber(tx, now)
+		return err
+	})
+	return orderNumber, err
+}
+
+// GetOrderByOrderNumber retrieves an order by its human-readable order number
+func (s *OrderService) GetOrderByOrderNumber(orderNumber string) (*order.Order, error) {
+	if orderNumber == "" {
+		return nil, validationError("order number is required")
+	}
+	o, err := s.OrderRepo.GetOrderByOrderNumber(strings.ToUpper(strings.TrimSpace(orderNumber)))
+	if err != nil {
+		return nil, notFoundError("order", err)
+	}
+	return o, nil
+}
+
+// TrackOrder looks up an order for public tracking. The phone number must match the
+// customer phone of the order; a mismatch is reported exactly like a missing order so
+// that order numbers cannot be probed.
+func (s *OrderService) TrackOrder(orderNumber, customerPhone string) (*order.Order, error) {
+	o, err := s.GetOrderByOrderNumber(orderNumber)
+	if err != nil {
+		return nil, notFoundError("order", gorm.ErrRecordNotFound)
+	}
+
+	if o.CustomerPhone == "" || phone.National(o.CustomerPhone) != phone.National(customerPhone) {
+		return nil, notFoundError("order", gorm.ErrRecordNotFound)
+	}
+
+	return o, nil
+}
+
+// AgentWorkload summarizes the orders created by or assigned to an agent
+type AgentWorkload struct {
+	UserID       uuid.UUID
+	Username     string
+	StatusCounts map[order.OrderStatus]int64
+	PendingCount int64
+	OpenCount    int64
+	TotalCount   int64
+}
+
+// IsOpenOrderStatus reports whether an order in the status still needs work,
+// i.e. it has not been delivered, returned or canceled
+func IsOpenOrderStatus(status order.OrderStatus) bool {
+	switch status {
+	case order.OrderDelivered, order.OrderReturned, order.OrderCanceled:
+		return false
+	default:
+		return true
+	}
+}
+
+// GetAgentWorkload counts the orders of a user grouped by status. Pending orders are
+// those still waiting for a shipment; open orders are all orders not yet finished.
+func (s *OrderService) GetAgentWorkload(userID uuid.UUID) (*AgentWorkload, error) {
+	workload := &AgentWorkload{UserID: userID}
+
+	if s.UserService != nil {
+		user, err := s.UserService.GetUserByID(userID)
+		if err != nil {
+			return nil, notFoundError("user", err)
+		}
+		workload.Username = user.Username
+	}
+
+	rows, err := s.OrderRepo.CountOrdersByStatusForCreator(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	workload.StatusCounts = make(map[order.OrderStatus]int64, len(rows))
+	for _, row := range rows {
+		workload.StatusCounts[row.OrderStatus] = row.OrderCount
+		workload.TotalCount += row.OrderCount
+		if IsOpenOrderStatus(row.OrderStatus) {
+			workload.OpenCount += row.OrderCount
+		}
+		if row.OrderStatus == order.OrderShipmentRequested {
+			workload.PendingCount += row.OrderCount
+		}
+	}
+
+	return workload, nil
+}
+
+const (
+	// OrderQueueNewWindow is how recently a pending order must have been created to count as new in the order queue
+	OrderQueueNewWindow = 24 * time.Hour
+	// DefaultOrderQueueListSize is the number of orders listed per order queue bucket when no limit is given
+	DefaultOrderQueueListSize = 5
+	// MaxOrderQueueListSize is the largest number of orders listed per order queue bucket
+	MaxOrderQueueListSize = 50
+)
+
+// OrderQueueBucket is a group of orders in the order queue: how many orders it holds and the oldest of them
+type OrderQueueBucket struct {
+	Count  int64
+	Orders []order.Order
+}
+
+// OrderQueue is a snapshot of the orders an agent has to work on
+type OrderQueue struct {
+	GeneratedAt time.Time
+	// New holds the pending orders created within the last OrderQueueNewWindow
+	New OrderQueueBucket
+	// AwaitingAction holds the older pending orders and the packed orders waiting to be handed to the carrier
+	AwaitingAction OrderQueueBucket
+	// Flagged holds the orders waiting for a shipment for longer than the shipment SLA and the orders
+	// being returned by the carrier; these may also be in one of the other buckets
+	Flagged OrderQueueBucket
+}
+
+// GetOrderQueue returns a snapshot of the new, awaiting action and flagged orders, listing at most
+// limit of the oldest orders per bucket. When agentID is set only the orders created by or assigned
+// to that agent are included.
+func (s *OrderService) GetOrderQueue(agentID *uuid.UUID, limit int) (*OrderQueue, error) {
+	if limit <= 0 {
+		limit = DefaultOrderQueueListSize
+	}
+	if limit > MaxOrderQueueListSize {
+		limit = MaxOrderQueueListSize
+	}
+
+	now := time.Now()
+	newSince := now.Add(-OrderQueueNewWindow)
+	flaggedBefore := now.Add(-time.Duration(DefaultShipmentSLAHours) * time.Hour)
+
+	scoped := func(filters map[string]interface{}) map[string]interface{} {
+		if agentID != nil {
+			filters["created_by"] = *agentID
+		}
+		return filters
+	}
+
+	queue := &OrderQueue{GeneratedAt: now}
+	buckets := []struct {
+		bucket  *OrderQueueBucket
+		filters map[string]interface{}
+	}{
+		{&queue.New, scoped(map[string]interface{}{
+			"order_status": string(order.OrderShipmentRequested),
+			"from_date":    newSince,
+		})},
+		{&queue.AwaitingAction, scoped(map[string]interface{}{
+			"awaiting_action_before": newSince,
+		})},
+		{&queue.Flagged, scoped(map[string]interface{}{
+			"flagged_before": flaggedBefore,
+		})},
+	}
+
+	for _, b := range buckets {
+		orders, total, err := s.OrderRepo.GetOrderQueue(b.filters, limit)
+		if err != nil {
+			return nil, err
+		}
+		b.bucket.Count = total
+		b.bucket.Orders = orders
+	}
+
+	return queue, nil
+}
+
+// Sources of order trail entries
+const (
+	TrailSourceOrder        = "order"
+	TrailSourceStatus       = "status"
+	TrailSourceNote         = "note"
+	TrailSourceShipment     = "shipment"
+	TrailSourceNotification = "notification"
+)
+
+// OrderTrailEntry represents a single event in the activity trail of an order
+type OrderTrailEntry struct {
+	Timestamp time.Time
+	Source    string
+	Event     string
+	Message   string
+	Metadata  map[string]interface{}
+}
+
+// GetOrderStatusHistory returns the status changes of an order, oldest first
+func (s *OrderService) GetOrderStatusHistory(orderID uuid.UUID) ([]order.OrderStatusHistory, error) {
+	if _, err := s.OrderRepo.GetOrderByID(orderID); err != nil {
+		return nil, notFoundError("order", err)
+	}
+	return s.OrderRepo.GetStatusHistory(orderID)
+}
+
+// GetOrderTrail returns everything that happened to an order in chronological order:
+// its creation, status changes, notes, shipment updates and the notifications emitted for it
+func (s *OrderService) GetOrderTrail(orderID uuid.UUID) ([]OrderTrailEntry, error) {
+	o, err := s.OrderRepo.GetOrderByID(orderID)
+	if err != nil {
+		return nil, notFoundError("order", err)
+	}
+
+	// The notifications live in their own database; when it is unavailable the trail is
+	// still built from the order itself
+	var notifications []notification.Notification
+	if s.NotificationService != nil {
+		notifications, err = s.NotificationService.GetNotificationsByOrderID(orderID)
+		if err != nil {
+			log.Printf("Failed to get notifications for order %s trail: %v", orderID, err)
+			notifications = nil
+		}
+	}
+
+	return BuildOrderTrail(o, notifications), nil
+}
+
+// BuildOrderTrail merges the order, its shipment and its notifications into a trail sorted
+// by time. Order notifications are sent to every admin, so copies of the same event are
+// collapsed into a single entry.
+func BuildOrderTrail(o *order.Order, notifications []notification.Notification) []OrderTrailEntry {
+	trail := []OrderTrailEntry{{
+		Timestamp: o.CreatedAt,
+		Source:    TrailSourceOrder,
+		Event:     "created",
+		Message:   "Order created",
+		Metadata: map[string]interface{}{
+			"order_number": o.OrderNumber,
+			"created_by":   o.CreatedBy,
+		},
+	}}
+
+	if o.Notes != "" {
+		trail = append(trail, OrderTrailEntry{
+			Timestamp: o.UpdatedAt,
+			Source:    TrailSourceNote,
+			Event:     "note",
+			Message:   o.Notes,
+		})
+	}
+
+	for _, shipment := range o.Shipments {
+		metadata := map[string]interface{}{
+			"shipment_id":     shipment.ID.String(),
+			"tracking_number": shipment.TrackingNumber,
+			"carrier":         shipment.Carrier,
+		}
+		trail = append(trail, OrderTrailEntry{
+			Timestamp: shipment.CreatedAt,
+			Source:    TrailSourceShipment,
+			Event:     "shipment_created",
+			Message:   "Shipment created",
+			Metadata:  metadata,
+		})
+		if shipment.UpdatedAt.After(shipment.CreatedAt) {
+			trail = append(trail, OrderTrailEntry{
+				Timestamp: shipment.UpdatedAt,
+				Source:    TrailSourceShipment,
+				Event:     "shipment_updated",
+				Message:   "Shipment updated",
+				Metadata:  metadata,
+			})
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, n := range notifications {
+		key := fmt.Sprintf("%s|%s|%d", n.Title, n.Message, n.CreatedAt.Unix())
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		event, _ := n.Metadata["event"].(string)
+		source := TrailSourceNotification
+		if _, ok := n.Metadata["new_status"]; ok {
+			source = TrailSourceStatus
+		}
+
+		trail = append(trail, OrderTrailEntry{
+			Timestamp: n.CreatedAt,
+			Source:    source,
+			Event:     event,
+			Message:   n.Message,
+			Metadata:  n.Metadata,
+		})
+	}
+
+	sort.SliceStable(trail, func(i, j int) bool {
+		return trail[i].Timestamp.Before(trail[j].Timestamp)
+	})
+
+	return trail
+}
+
+// GHNStatusUpdate describes the effect of a GHN status update on an order
+type GHNStatusUpdate struct {
+	OrderID        uuid.UUID
+	OrderNumber    string
+	TrackingNumber string
+	GHNStatus      string
+	CurrentStatus  order.OrderStatus
+	TargetStatus   order.OrderStatus
+	// Changes reports whether the update moves the order to TargetStatus
+	Changes bool
+	// Unmapped reports whether the GHN status is not mapped to an order status; outside dry-run
+	// mode the raw GHN status is then recorded in the order notes
+	Unmapped bool
+	// Applied reports whether the order was updated; it is never set in dry-run mode
+	Applied bool
+	DryRun  bool
+	// Reason explains why the update does not change the order
+	Reason string
+}
+
+// ApplyGHNStatus applies a status reported by a GHN webhook to the order shipped with the given GHN
+// order code, falling back to the client order code as order number. Updates that do not change the
+// order, e.g. an unmapped status or a transition the order flow does not allow, are reported with a
+// reason rather than an error. In dry-run mode the update is only evaluated and nothing is persisted.
+func (s *OrderService) ApplyGHNStatus(orderCode, clientOrderCode, ghnStatus string, dryRun bool) (*GHNStatusUpdate, error) {
+	if strings.TrimSpace(orderCode) == "" && strings.TrimSpace(clientOrderCode) == "" {
+		return nil, validationError("OrderCode or ClientOrderCode is required")
+	}
+	if strings.TrimSpace(ghnStatus) == "" {
+		return nil, validationError("Status is required")
+	}
+
+	var o *order.Order
+	var err error
+	if orderCode != "" {
+		o, err = s.GetOrderByTrackingNumber(orderCode)
+	}
+	if o == nil && clientOrderCode != "" {
+		o, err = s.GetOrderByOrderNumber(clientOrderCode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	update := &GHNStatusUpdate{
+		OrderID:       o.ID,
+		OrderNumber:   o.OrderNumber,
+		GHNStatus:     ghnStatus,
+		CurrentStatus: o.OrderStatus,
+		DryRun:        dryRun,
+	}
+	if shipment := o.PrimaryShipment(); shipment != nil {
+		update.TrackingNumber = shipment.TrackingNumber
+	}
+	for _, shipment := range o.Shipments {
+		if orderCode != "" && shipment.TrackingNumber == orderCode {
+			update.TrackingNumber = orderCode
+		}
+	}
+
+	target, ok := s.Settings.GHNStatusMapper.Map(ghnStatus)
+	switch {
+	case !ok:
+		update.Unmapped = true
+		update.Reason = fmt.Sprintf("GHN status %s is not mapped to an order status", ghnStatus)
+	case target == "":
+		update.Reason = fmt.Sprintf("GHN status %s does not change the order", ghnStatus)
+	case target == o.OrderStatus:
+		update.TargetStatus = target
+		update.Reason = fmt.Sprintf("order is already %s", target)
+	case !isValidStatusTransition(o.OrderStatus, target):
+		update.TargetStatus = target
+		update.Reason = fmt.Sprintf("invalid status transition from %s to %s", o.OrderStatus, target)
+	case target == order.OrderDelivered && s.Settings.RequireDeliveryProof && !o.HasDeliveryProof():
+		update.TargetStatus = target
+		update.Reason = "delivery proof is required before the order can be marked as delivered"
+	default:
+		update.TargetStatus = target
+		update.Changes = true
+	}
+
+	if dryRun {
+		return update, nil
+	}
+	if update.Unmapped {
+		if err := s.recordUnmappedGHNStatus(o, ghnStatus); err != nil {
+			return nil, err
+		}
+		return update, nil
+	}
+	if !update.Changes {
+		return update, nil
+	}
+
+	if _, err := s.ChangeOrderStatus(o.ID, target, StatusChange{Note: fmt.Sprintf("GHN status %s", ghnStatus)}); err != nil {
+		return nil, err
+	}
+	update.Applied = true
+
+	return update, nil
+}
+
+// recordUnmappedGHNStatus appends the raw GHN status to the order notes for auditing, once per
+// GHN status, so statuses GHN introduces or renames can be added to the mapping
+func (s *OrderService) recordUnmappedGHNStatus(o *order.Order, ghnStatus string) error {
+	note := fmt.Sprintf("GHN status %q is not mapped to an order status", strings.TrimSpace(ghnStatus))
+	if strings.Contains(o.Notes, note) {
+		return nil
+	}
+
+	notes := note
+	if o.Notes != "" {
+		notes = o.Notes + "\n" + note
+	}
+	if err := s.DB.Model(&order.Order{}).Where("id = ?", o.ID).Update("notes", notes).Error; err != nil {
+		return err
+	}
+	o.Notes = notes
+	return nil
+}
+
+// UpdateOrderStatusByTrackingNumber applies a GHN shipment status to the order shipped with the
+// tracking number, through the same transition checks and notifications as UpdateOrderStatus.
+// GHN statuses that do not map to an order status leave the order unchanged, are reported with a
+// reason and are recorded in the order notes.
+func (s *OrderService) UpdateOrderStatusByTrackingNumber(trackingNumber string, ghnStatus string) (*GHNStatusUpdate, error) {
+	if strings.TrimSpace(trackingNumber) == "" {
+		return nil, validationError("tracking number is required")
+	}
+	return s.ApplyGHNStatus(trackingNumber, "", ghnStatus, false)
 }
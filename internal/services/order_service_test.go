@@ -1,12 +1,22 @@
 package services_test
 
 import (
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/models/account"
+	"github.com/ybds/internal/models/notification"
 	"github.com/ybds/internal/models/order"
+	"github.com/ybds/internal/models/product"
 	"github.com/ybds/internal/services"
+	"github.com/ybds/internal/testutil"
+	"github.com/ybds/pkg/shipping/ghn"
+	"github.com/ybds/pkg/upload"
+	"gorm.io/gorm"
 )
 
 // TestOrderService tests the OrderService functionality
@@ -95,3 +105,2402 @@ func TestOrderItem(t *testing.T) {
 	assert.Equal(t, 2, item.Quantity)
 	assert.Equal(t, 500.0, item.PriceAtOrder)
 }
+
+// seedUser creates a user with the given role and active state
+func seedUser(t testing.TB, db *gorm.DB, username string, roleName account.RoleType, active bool) *account.User {
+	t.Helper()
+
+	var role account.Role
+	if err := db.Where(account.Role{Name: roleName}).FirstOrCreate(&role).Error; err != nil {
+		t.Fatalf("failed to seed role: %v", err)
+	}
+
+	user := &account.User{
+		Username:     username,
+		Email:        username + "@example.com",
+		PasswordHash: "hash",
+		Salt:         "salt",
+		IsActive:     true,
+		Roles:        []account.Role{role},
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("failed to seed user: %v", err)
+	}
+
+	// is_active defaults to true, so deactivation needs an explicit update
+	if !active {
+		if err := db.Model(user).Update("is_active", false).Error; err != nil {
+			t.Fatalf("failed to deactivate user: %v", err)
+		}
+	}
+
+	return user
+}
+
+// seedOrder creates an order with the given status
+func seedOrder(t testing.TB, db *gorm.DB, status order.OrderStatus, createdBy *uuid.UUID) *order.Order {
+	t.Helper()
+
+	o := &order.Order{
+		PaymentMethod:    order.PaymentCash,
+		TotalAmount:      100,
+		FinalTotalAmount: 100,
+		OrderStatus:      status,
+		CustomerName:     "John Doe",
+	}
+	o.CreatedBy = createdBy
+	if err := db.Create(o).Error; err != nil {
+		t.Fatalf("failed to seed order: %v", err)
+	}
+
+	return o
+}
+
+// TestBulkAssignOrders tests assigning orders to active and inactive agents
+func TestBulkAssignOrders(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	userService := services.NewUserService(db, nil)
+	orderService := services.NewOrderService(db, nil, userService, nil)
+
+	adminID := uuid.New()
+	activeAgent := seedUser(t, db, "active_agent", account.RoleAgent, true)
+	inactiveAgent := seedUser(t, db, "inactive_agent", account.RoleAgent, false)
+
+	pending := seedOrder(t, db, order.OrderShipmentRequested, &adminID)
+	delivered := seedOrder(t, db, order.OrderDelivered, &adminID)
+
+	t.Run("AssignsPendingOrdersToActiveAgent", func(t *testing.T) {
+		result, err := orderService.BulkAssignOrders([]uuid.UUID{pending.ID, delivered.ID}, activeAgent.ID, adminID)
+		assert.NoError(t, err)
+		assert.False(t, result.Success)
+		assert.Equal(t, 1, result.Assigned)
+		assert.Equal(t, 1, result.Failed)
+		assert.True(t, result.Results[0].Success)
+		assert.False(t, result.Results[1].Success)
+
+		assigned, err := orderService.GetOrderByID(pending.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, activeAgent.ID, *assigned.CreatedBy)
+
+		untouched, err := orderService.GetOrderByID(delivered.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, adminID, *untouched.CreatedBy)
+	})
+
+	t.Run("RejectsInactiveAgent", func(t *testing.T) {
+		result, err := orderService.BulkAssignOrders([]uuid.UUID{pending.ID}, inactiveAgent.ID, adminID)
+		assert.ErrorIs(t, err, services.ErrValidation)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "agent account is inactive")
+
+		unchanged, err := orderService.GetOrderByID(pending.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, activeAgent.ID, *unchanged.CreatedBy)
+	})
+
+	t.Run("RejectsUnknownAgent", func(t *testing.T) {
+		_, err := orderService.BulkAssignOrders([]uuid.UUID{pending.ID}, uuid.New(), adminID)
+		assert.ErrorIs(t, err, services.ErrNotFound)
+	})
+}
+
+// TestBulkUpdateOrderStatusByFilter tests moving the orders matching a status and date filter in one action
+func TestBulkUpdateOrderStatusByFilter(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	orderService := services.NewOrderService(db, nil, nil, nil)
+
+	old := time.Now().Add(-48 * time.Hour)
+	cutoff := time.Now().Add(-24 * time.Hour)
+
+	oldPending := []*order.Order{
+		seedOrder(t, db, order.OrderShipmentRequested, nil),
+		seedOrder(t, db, order.OrderShipmentRequested, nil),
+	}
+	oldDelivered := seedOrder(t, db, order.OrderDelivered, nil)
+	recentPending := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	for _, o := range append(oldPending, oldDelivered) {
+		assert.NoError(t, db.Model(o).Update("created_at", old).Error)
+	}
+
+	filter := services.OrderStatusFilter{Status: order.OrderShipmentRequested, ToDate: &cutoff}
+
+	t.Run("AgentTargetNotAllowed", func(t *testing.T) {
+		_, err := orderService.BulkUpdateOrderStatusByFilter(filter, order.OrderDelivered, true, services.StatusChange{})
+		assert.ErrorIs(t, err, services.ErrForbidden)
+	})
+
+	t.Run("TooManyMatches", func(t *testing.T) {
+		orderService.Settings.BulkStatusMaxOrders = 1
+		defer func() { orderService.Settings.BulkStatusMaxOrders = services.DefaultBulkStatusMaxOrders }()
+
+		_, err := orderService.BulkUpdateOrderStatusByFilter(filter, order.OrderPacked, false, services.StatusChange{})
+		assert.ErrorIs(t, err, services.ErrValidation)
+	})
+
+	t.Run("AppliesToMatchingOrders", func(t *testing.T) {
+		actor := uuid.New()
+		result, err := orderService.BulkUpdateOrderStatusByFilter(filter, order.OrderPacked, true, services.StatusChange{ChangedBy: &actor})
+		assert.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Equal(t, 2, result.Matched)
+		assert.Equal(t, 2, result.Updated)
+		assert.Len(t, result.Results, 2)
+
+		status := func(o *order.Order) order.OrderStatus {
+			var stored order.Order
+			assert.NoError(t, db.First(&stored, "id = ?", o.ID).Error)
+			return stored.OrderStatus
+		}
+		for _, o := range oldPending {
+			assert.Equal(t, order.OrderPacked, status(o))
+
+			history, err := orderService.GetOrderStatusHistory(o.ID)
+			assert.NoError(t, err)
+			if assert.Len(t, history, 1) && assert.NotNil(t, history[0].ChangedBy) {
+				assert.Equal(t, actor, *history[0].ChangedBy)
+			}
+		}
+		assert.Equal(t, order.OrderDelivered, status(oldDelivered))
+		assert.Equal(t, order.OrderShipmentRequested, status(recentPending))
+	})
+
+	t.Run("ReportsInvalidTransitions", func(t *testing.T) {
+		result, err := orderService.BulkUpdateOrderStatusByFilter(
+			services.OrderStatusFilter{ToDate: &cutoff}, order.OrderCanceled, false, services.StatusChange{})
+		assert.NoError(t, err)
+		assert.False(t, result.Success)
+		assert.Equal(t, 3, result.Matched)
+		assert.Equal(t, 2, result.Updated)
+		assert.Equal(t, 1, result.Failed)
+	})
+}
+
+// TestBulkUpdateOrderStatus tests that a bulk status update applies each transition with its
+// inventory changes and reports the reason of every order that could not be changed
+func TestBulkUpdateOrderStatus(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+
+	_, inv := seedProductWithInventory(t, db, "BULK-1", 10, 2)
+	seedPending := func() *order.Order {
+		o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+		assert.NoError(t, db.Create(&order.OrderItem{OrderID: o.ID, InventoryID: inv.ID, Quantity: 2, PriceAtOrder: 100}).Error)
+		return o
+	}
+	stock := func() int {
+		current, err := productService.GetInventoryByID(inv.ID)
+		assert.NoError(t, err)
+		return current.Quantity
+	}
+	status := func(o *order.Order) order.OrderStatus {
+		var stored order.Order
+		assert.NoError(t, db.First(&stored, "id = ?", o.ID).Error)
+		return stored.OrderStatus
+	}
+	actor := uuid.New()
+
+	t.Run("AllSucceed", func(t *testing.T) {
+		first, second := seedPending(), seedPending()
+
+		result, err := orderService.BulkUpdateOrderStatus([]uuid.UUID{first.ID, second.ID}, order.OrderPacked, true,
+			services.StatusChange{ChangedBy: &actor})
+		assert.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Equal(t, 2, result.Updated)
+		assert.Equal(t, 0, result.Failed)
+		for _, r := range result.Results {
+			assert.True(t, r.Success)
+			assert.Empty(t, r.Reason)
+		}
+
+		assert.Equal(t, order.OrderPacked, status(first))
+		assert.Equal(t, order.OrderPacked, status(second))
+		// Packing reserves the stock of every order
+		assert.Equal(t, 6, stock())
+
+		history, err := orderService.GetOrderStatusHistory(first.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, history, 1) && assert.NotNil(t, history[0].ChangedBy) {
+			assert.Equal(t, actor, *history[0].ChangedBy)
+		}
+	})
+
+	t.Run("MixedBatch", func(t *testing.T) {
+		pending := seedPending()
+		delivered := seedOrder(t, db, order.OrderDelivered, nil)
+		missing := uuid.New()
+
+		result, err := orderService.BulkUpdateOrderStatus([]uuid.UUID{pending.ID, delivered.ID, missing}, order.OrderPacked, false,
+			services.StatusChange{ChangedBy: &actor})
+		assert.NoError(t, err)
+		assert.False(t, result.Success)
+		assert.Equal(t, 1, result.Updated)
+		assert.Equal(t, 2, result.Failed)
+		if assert.Len(t, result.Results, 3) {
+			assert.True(t, result.Results[0].Success)
+			assert.False(t, result.Results[1].Success)
+			assert.Equal(t, services.StatusUpdateInvalidTransition, result.Results[1].Reason)
+			assert.Equal(t, order.OrderDelivered, result.Results[1].PreviousStatus)
+			assert.Equal(t, services.StatusUpdateNotFound, result.Results[2].Reason)
+		}
+
+		assert.Equal(t, order.OrderPacked, status(pending))
+		assert.Equal(t, order.OrderDelivered, status(delivered))
+		assert.Equal(t, 4, stock())
+	})
+
+	t.Run("AgentRules", func(t *testing.T) {
+		packed := seedOrder(t, db, order.OrderPacked, nil)
+
+		_, err := orderService.BulkUpdateOrderStatus([]uuid.UUID{packed.ID}, order.OrderDelivered, true, services.StatusChange{})
+		assert.ErrorIs(t, err, services.ErrForbidden)
+
+		result, err := orderService.BulkUpdateOrderStatus([]uuid.UUID{packed.ID}, order.OrderCanceled, true, services.StatusChange{})
+		assert.NoError(t, err)
+		if assert.Len(t, result.Results, 1) {
+			assert.Equal(t, services.StatusUpdatePermissionDenied, result.Results[0].Reason)
+		}
+		assert.Equal(t, order.OrderPacked, status(packed))
+	})
+}
+
+// TestFormatOrderNumber tests the human-readable order number format
+func TestFormatOrderNumber(t *testing.T) {
+	at := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	period := order.OrderNumberPeriod(at, order.OrderNumberResetDaily)
+	assert.Equal(t, "20240115", period)
+	assert.Equal(t, "YB-20240115-000123", order.FormatOrderNumber(order.OrderNumberPrefix, period, 123))
+
+	assert.Equal(t, "202401", order.OrderNumberPeriod(at, order.OrderNumberResetMonthly))
+	assert.Equal(t, "2024", order.OrderNumberPeriod(at, order.OrderNumberResetYearly))
+	assert.Equal(t, "", order.OrderNumberPeriod(at, order.OrderNumberResetNever))
+	assert.Equal(t, "SHOP-000007", order.FormatOrderNumber("SHOP", "", 7))
+}
+
+// TestOrderNumberSettings tests the defaults applied to the order number settings
+func TestOrderNumberSettings(t *testing.T) {
+	settings := services.OrderSettings{OrderNumberPrefix: " shop ", OrderNumberReset: "weekly"}.WithDefaults()
+	assert.Equal(t, "SHOP", settings.OrderNumberPrefix)
+	assert.Equal(t, order.OrderNumberResetDaily, settings.OrderNumberReset)
+
+	settings = services.OrderSettings{}.WithDefaults()
+	assert.Equal(t, order.OrderNumberPrefix, settings.OrderNumberPrefix)
+}
+
+// TestGenerateOrderNumberMonthlyReset tests that monthly sequences restart at the start of each month
+func TestGenerateOrderNumberMonthlyReset(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	orderService := services.NewOrderService(db, nil, nil, nil)
+	orderService.Settings.OrderNumberPrefix = "SHOP"
+	orderService.Settings.OrderNumberReset = order.OrderNumberResetMonthly
+
+	generate := func(at time.Time) string {
+		number, err := orderService.GenerateOrderNumber(at)
+		assert.NoError(t, err)
+		return number
+	}
+
+	assert.Equal(t, "SHOP-202401-000001", generate(time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "SHOP-202401-000002", generate(time.Date(2024, 1, 31, 23, 59, 0, 0, time.UTC)))
+	assert.Equal(t, "SHOP-202402-000001", generate(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "SHOP-202402-000002", generate(time.Date(2024, 2, 14, 12, 0, 0, 0, time.UTC)))
+}
+
+// TestGenerateOrderNumberConcurrently tests that concurrent generation never issues duplicates
+func TestGenerateOrderNumberConcurrently(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	orderService := services.NewOrderService(db, nil, nil, nil)
+	orderService.Settings.OrderNumberReset = order.OrderNumberResetMonthly
+
+	const workers = 20
+	at := time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	numbers := make(chan string, workers)
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			number, err := orderService.GenerateOrderNumber(at)
+			if err != nil {
+				errs <- err
+				return
+			}
+			numbers <- number
+		}()
+	}
+	wg.Wait()
+	close(numbers)
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("failed to generate order number: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for number := range numbers {
+		assert.False(t, seen[number], "duplicate order number %s", number)
+		seen[number] = true
+	}
+	assert.Len(t, seen, workers)
+	assert.True(t, seen["YB-202403-000001"])
+	assert.True(t, seen[fmt.Sprintf("YB-202403-%06d", workers)])
+}
+
+// TestTrackOrder tests public order tracking by order number and phone
+func TestTrackOrder(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	orderService := services.NewOrderService(db, nil, nil, nil)
+
+	o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	assert.NoError(t, db.Model(o).Updates(map[string]interface{}{
+		"order_number":   "YB-20240115-000001",
+		"customer_phone": "0912345678",
+	}).Error)
+
+	t.Run("MatchingNumberAndPhone", func(t *testing.T) {
+		tracked, err := orderService.TrackOrder("yb-20240115-000001", "+84 912 345 678")
+		assert.NoError(t, err)
+		assert.Equal(t, o.ID, tracked.ID)
+	})
+
+	t.Run("PhoneMismatch", func(t *testing.T) {
+		_, err := orderService.TrackOrder("YB-20240115-000001", "0987654321")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("UnknownNumber", func(t *testing.T) {
+		_, err := orderService.TrackOrder("YB-20240115-999999", "0912345678")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+// TestGetOrdersByPhoneNumberNormalizes tests that orders are found by phone number whatever form it was stored and searched in
+func TestGetOrdersByPhoneNumberNormalizes(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	orderService := services.NewOrderService(db, nil, nil, nil)
+
+	legacy := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	normalized := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	other := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	assert.NoError(t, db.Model(legacy).Update("customer_phone", "0912345678").Error)
+	assert.NoError(t, db.Model(normalized).Update("customer_phone", "+84912345678").Error)
+	assert.NoError(t, db.Model(other).Update("customer_phone", "+84987654321").Error)
+
+	for _, search := range []string{"0912345678", "+84912345678", "+84 912 345 678", "84912345678"} {
+		orders, total, err := orderService.GetOrdersByPhoneNumber(search, 1, 10, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total, search)
+		assert.Len(t, orders, 2, search)
+
+		_, total, err = orderService.GetAllOrders(1, 10, map[string]interface{}{"phone_number": search})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total, search)
+
+		_, total, err = orderService.GetAllOrders(1, 10, map[string]interface{}{"search": search})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total, search)
+	}
+
+	// Partial numbers with a leading 0 find the orders stored in E.164 too
+	for _, filter := range []string{"phone_number", "search"} {
+		_, total, err := orderService.GetAllOrders(1, 10, map[string]interface{}{filter: "0912 34"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total, filter)
+	}
+
+	// A national-format search finds an order stored in E.164 only
+	orders, total, err := orderService.GetAllOrders(1, 10, map[string]interface{}{"search": "0987654321"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, orders, 1) {
+		assert.Equal(t, other.ID, orders[0].ID)
+	}
+
+	// Invalid numbers are rejected before they are stored
+	_, err = orderService.UpdateOrderDetails(other.ID, "", "", nil, nil, nil,
+		"", "", "", "", "", "", "", "12345", false)
+	assert.ErrorIs(t, err, services.ErrValidation)
+}
+
+// TestUpdateShipmentDetails tests that package and cost fields round-trip through UpdateShipment
+func TestUpdateShipmentDetails(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	orderService := services.NewOrderService(db, nil, nil, nil)
+
+	o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	_, err := orderService.CreateShipment(o.ID, "GHN123", "GHN", nil)
+	assert.NoError(t, err)
+
+	weight, length, width, height := 750.0, 30.0, 20.0, 12.5
+	shippingCost, codAmount := 32000.0, 450000.0
+	err = orderService.UpdateShipment(o.ID, services.ShipmentDetails{
+		Weight:       &weight,
+		Length:       &length,
+		Width:        &width,
+		Height:       &height,
+		ShippingCost: &shippingCost,
+		CODAmount:    &codAmount,
+	})
+	assert.NoError(t, err)
+
+	shipment, err := orderService.OrderRepo.GetShipmentByOrderID(o.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "GHN123", shipment.TrackingNumber)
+	assert.Equal(t, "GHN", shipment.Carrier)
+	assert.Equal(t, weight, shipment.Weight)
+	assert.Equal(t, length, shipment.Length)
+	assert.Equal(t, width, shipment.Width)
+	assert.Equal(t, height, shipment.Height)
+	assert.Equal(t, shippingCost, shipment.ShippingCost)
+	assert.Equal(t, codAmount, shipment.CODAmount)
+
+	// Omitted fields keep their stored values
+	assert.NoError(t, orderService.UpdateShipment(o.ID, services.ShipmentDetails{Carrier: "GHTK"}))
+	shipment, err = orderService.OrderRepo.GetShipmentByOrderID(o.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "GHTK", shipment.Carrier)
+	assert.Equal(t, weight, shipment.Weight)
+	assert.Equal(t, codAmount, shipment.CODAmount)
+}
+
+// TestShipmentStatusGuard tests that shipments can only be created or updated before the carrier picks the order up
+func TestShipmentStatusGuard(t *testing.T) {
+	for _, status := range []order.OrderStatus{order.OrderShipmentRequested, order.OrderPacked} {
+		assert.True(t, status.AllowsShipmentChanges(), status)
+	}
+	for _, status := range []order.OrderStatus{order.OrderPicked, order.OrderDelivering, order.OrderDelivered, order.OrderCanceled} {
+		assert.False(t, status.AllowsShipmentChanges(), status)
+	}
+
+	db := testutil.SetupTestDB(t)
+	orderService := services.NewOrderService(db, nil, nil, nil)
+	weight := 500.0
+
+	t.Run("Allowed", func(t *testing.T) {
+		o := seedOrder(t, db, order.OrderPacked, nil)
+		_, err := orderService.CreateShipment(o.ID, "GHN456", "GHN", nil)
+		assert.NoError(t, err)
+		assert.NoError(t, orderService.UpdateShipment(o.ID, services.ShipmentDetails{Weight: &weight}))
+
+		shipment, err := orderService.OrderRepo.GetShipmentByOrderID(o.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, weight, shipment.Weight)
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		o := seedOrder(t, db, order.OrderPacked, nil)
+		_, err := orderService.CreateShipment(o.ID, "GHN789", "GHN", nil)
+		assert.NoError(t, err)
+		assert.NoError(t, db.Model(o).Update("order_status", order.OrderPicked).Error)
+
+		err = orderService.UpdateShipment(o.ID, services.ShipmentDetails{Weight: &weight})
+		assert.ErrorIs(t, err, services.ErrValidation)
+		assert.ErrorContains(t, err, "status picked")
+
+		shipment, err := orderService.OrderRepo.GetShipmentByOrderID(o.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 0.0, shipment.Weight)
+
+		canceled := seedOrder(t, db, order.OrderCanceled, nil)
+		_, err = orderService.CreateShipment(canceled.ID, "GHN000", "GHN", nil)
+		assert.ErrorIs(t, err, services.ErrValidation)
+	})
+}
+
+// TestSplitShipments tests splitting an order into shipments that carry disjoint subsets of its items
+func TestSplitShipments(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	orderService := services.NewOrderService(db, nil, nil, nil)
+
+	o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	items := make([]order.OrderItem, 3)
+	for i := range items {
+		items[i] = order.OrderItem{OrderID: o.ID, InventoryID: uuid.New(), Quantity: 1, PriceAtOrder: 100}
+		if err := db.Create(&items[i]).Error; err != nil {
+			t.Fatalf("failed to seed order item: %v", err)
+		}
+	}
+
+	first, err := orderService.CreateShipment(o.ID, "GHN001", "GHN", []uuid.UUID{items[0].ID, items[1].ID})
+	assert.NoError(t, err)
+	second, err := orderService.CreateShipment(o.ID, "GHTK001", "GHTK", []uuid.UUID{items[2].ID})
+	assert.NoError(t, err)
+
+	shipments, err := orderService.OrderRepo.GetShipmentsByOrderID(o.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, shipments, 2) {
+		assert.Equal(t, first.ID, shipments[0].ID)
+		var firstItemIDs []uuid.UUID
+		for _, item := range shipments[0].Items {
+			firstItemIDs = append(firstItemIDs, item.OrderItemID)
+		}
+		assert.ElementsMatch(t, []uuid.UUID{items[0].ID, items[1].ID}, firstItemIDs)
+		assert.Equal(t, second.ID, shipments[1].ID)
+		if assert.Len(t, shipments[1].Items, 1) {
+			assert.Equal(t, items[2].ID, shipments[1].Items[0].OrderItemID)
+		}
+	}
+
+	// The order is loaded with both shipments, the first being the primary one
+	stored, err := orderService.GetOrderByID(o.ID)
+	assert.NoError(t, err)
+	assert.Len(t, stored.Shipments, 2)
+	assert.Equal(t, first.ID, stored.PrimaryShipment().ID)
+
+	// Each shipment is updated on its own
+	weight := 1200.0
+	assert.NoError(t, orderService.UpdateShipment(o.ID, services.ShipmentDetails{ShipmentID: second.ID, Weight: &weight}))
+	updated, err := orderService.OrderRepo.GetShipmentByID(second.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, weight, updated.Weight)
+	primary, err := orderService.OrderRepo.GetShipmentByOrderID(o.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, first.ID, primary.ID)
+	assert.Equal(t, 0.0, primary.Weight)
+
+	t.Run("ItemAlreadyShipped", func(t *testing.T) {
+		_, err := orderService.CreateShipment(o.ID, "GHN002", "GHN", []uuid.UUID{items[1].ID})
+		assert.ErrorIs(t, err, services.ErrConflict)
+	})
+
+	t.Run("ItemOfAnotherOrder", func(t *testing.T) {
+		_, err := orderService.CreateShipment(o.ID, "GHN003", "GHN", []uuid.UUID{uuid.New()})
+		assert.ErrorIs(t, err, services.ErrValidation)
+	})
+
+	t.Run("ShipmentOfAnotherOrder", func(t *testing.T) {
+		other := seedOrder(t, db, order.OrderShipmentRequested, nil)
+		err := orderService.UpdateShipment(other.ID, services.ShipmentDetails{ShipmentID: first.ID, Weight: &weight})
+		assert.ErrorIs(t, err, services.ErrNotFound)
+	})
+
+	t.Run("SecondShipmentForUnassignedItems", func(t *testing.T) {
+		other := seedOrder(t, db, order.OrderShipmentRequested, nil)
+		_, err := orderService.CreateShipment(other.ID, "", "", nil)
+		assert.NoError(t, err)
+		_, err = orderService.CreateShipment(other.ID, "", "", nil)
+		assert.ErrorIs(t, err, services.ErrConflict)
+	})
+}
+
+// TestChargeableWeight tests that the greater of actual and volumetric weight is charged
+func TestChargeableWeight(t *testing.T) {
+	t.Run("VolumetricDominates", func(t *testing.T) {
+		// 50x40x30 cm = 60000 cm³ = 12 kg volumetric at 5000 cm³/kg
+		assert.Equal(t, 12000.0, services.VolumetricWeight(50, 40, 30, services.DefaultVolumetricDivisor))
+		assert.Equal(t, 12000.0, services.ChargeableWeight(2000, 50, 40, 30, services.DefaultVolumetricDivisor))
+	})
+
+	t.Run("ActualDominates", func(t *testing.T) {
+		// 10x10x10 cm = 1000 cm³ = 200 g volumetric at 5000 cm³/kg
+		assert.Equal(t, 200.0, services.VolumetricWeight(10, 10, 10, services.DefaultVolumetricDivisor))
+		assert.Equal(t, 1500.0, services.ChargeableWeight(1500, 10, 10, 10, services.DefaultVolumetricDivisor))
+	})
+
+	t.Run("ConfiguredDivisor", func(t *testing.T) {
+		orderService := services.NewOrderService(nil, nil, nil, nil)
+		orderService.Settings.VolumetricDivisor = 6000
+
+		shipment := &order.Shipment{Weight: 1000, Length: 60, Width: 40, Height: 25}
+		assert.Equal(t, 10000.0, orderService.ShipmentChargeableWeight(shipment))
+	})
+
+	t.Run("MissingDimensions", func(t *testing.T) {
+		assert.Equal(t, 0.0, services.VolumetricWeight(0, 40, 30, services.DefaultVolumetricDivisor))
+		assert.Equal(t, 800.0, services.ChargeableWeight(800, 0, 0, 0, services.DefaultVolumetricDivisor))
+	})
+}
+
+// TestGetAgentWorkload tests that an agent's orders are counted by status
+func TestGetAgentWorkload(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	userService := services.NewUserService(db, nil)
+	orderService := services.NewOrderService(db, nil, userService, nil)
+
+	agent := seedUser(t, db, "workload-agent", account.RoleAgent, true)
+	other := seedUser(t, db, "other-agent", account.RoleAgent, true)
+
+	seedOrder(t, db, order.OrderShipmentRequested, &agent.ID)
+	seedOrder(t, db, order.OrderShipmentRequested, &agent.ID)
+	seedOrder(t, db, order.OrderDelivering, &agent.ID)
+	seedOrder(t, db, order.OrderDelivered, &agent.ID)
+	seedOrder(t, db, order.OrderCanceled, &agent.ID)
+	seedOrder(t, db, order.OrderShipmentRequested, &other.ID)
+
+	workload, err := orderService.GetAgentWorkload(agent.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "workload-agent", workload.Username)
+	assert.Equal(t, map[order.OrderStatus]int64{
+		order.OrderShipmentRequested: 2,
+		order.OrderDelivering:        1,
+		order.OrderDelivered:         1,
+		order.OrderCanceled:          1,
+	}, workload.StatusCounts)
+	assert.Equal(t, int64(2), workload.PendingCount)
+	assert.Equal(t, int64(3), workload.OpenCount)
+	assert.Equal(t, int64(5), workload.TotalCount)
+
+	_, err = orderService.GetAgentWorkload(uuid.New())
+	assert.ErrorIs(t, err, services.ErrNotFound)
+}
+
+// TestInventoryReservationIsIdempotent tests that re-applying a reservation reduces stock only once
+func TestInventoryReservationIsIdempotent(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+
+	_, inv := seedProductWithInventory(t, db, "RESERVE-1", 10, 2)
+	o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	assert.NoError(t, db.Create(&order.OrderItem{OrderID: o.ID, InventoryID: inv.ID, Quantity: 3, PriceAtOrder: 100}).Error)
+
+	stock := func() int {
+		t.Helper()
+		current, err := productService.GetInventoryByID(inv.ID)
+		assert.NoError(t, err)
+		return current.Quantity
+	}
+
+	// First packed transition reserves the stock
+	_, err := orderService.UpdateOrderStatus(o.ID, order.OrderPacked)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, stock())
+
+	// Re-applying the packed transition (e.g. a duplicate webhook) is rejected and leaves stock untouched
+	_, err = orderService.UpdateOrderStatus(o.ID, order.OrderPacked)
+	assert.Error(t, err)
+	assert.Equal(t, 7, stock())
+
+	// Retrying the reservation directly is a no-op
+	assert.NoError(t, orderService.ReserveOrderInventory(o.ID))
+	assert.Equal(t, 7, stock())
+
+	// Moving further along the flow does not reserve again
+	_, err = orderService.UpdateOrderStatus(o.ID, order.OrderPicked)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, stock())
+
+	// Canceling releases the stock exactly once
+	_, err = orderService.UpdateOrderStatus(o.ID, order.OrderCanceled)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, stock())
+	assert.NoError(t, orderService.ReleaseOrderInventory(o.ID))
+	assert.Equal(t, 10, stock())
+}
+
+// TestFailedReservationReleasesReservedItems tests that a packed transition failing on one item
+// gives back the stock of the items reserved before it, so a retry takes the stock only once
+func TestFailedReservationReleasesReservedItems(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+
+	_, available := seedProductWithInventory(t, db, "RESERVE-OK", 10, 2)
+	_, short := seedProductWithInventory(t, db, "RESERVE-SHORT", 1, 0)
+	o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	assert.NoError(t, db.Create(&order.OrderItem{OrderID: o.ID, InventoryID: available.ID, Quantity: 3, PriceAtOrder: 100}).Error)
+	assert.NoError(t, db.Create(&order.OrderItem{OrderID: o.ID, InventoryID: short.ID, Quantity: 2, PriceAtOrder: 100}).Error)
+
+	stock := func(inventoryID uuid.UUID) int {
+		t.Helper()
+		current, err := productService.GetInventoryByID(inventoryID)
+		assert.NoError(t, err)
+		return current.Quantity
+	}
+
+	_, err := orderService.UpdateOrderStatus(o.ID, order.OrderPacked)
+	assert.ErrorIs(t, err, services.ErrConflict)
+	assert.Equal(t, 10, stock(available.ID))
+	assert.Equal(t, 1, stock(short.ID))
+
+	stored, err := orderService.GetOrderByID(o.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, order.OrderShipmentRequested, stored.OrderStatus)
+	assert.False(t, stored.InventoryReserved)
+
+	// Once restocked, retrying the transition takes the stock of every item once
+	assert.NoError(t, db.Model(short).Update("quantity", 5).Error)
+	_, err = orderService.UpdateOrderStatus(o.ID, order.OrderPacked)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, stock(available.ID))
+	assert.Equal(t, 3, stock(short.ID))
+}
+
+// TestCreateOrderReservesInventoryConcurrently tests that of two orders racing for the last unit only one is created
+func TestCreateOrderReservesInventoryConcurrently(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+
+	p, inv := seedProductWithInventory(t, db, "LAST-001", 1, 0)
+	assert.NoError(t, db.Create(&product.Price{ProductID: p.ID, Price: 100, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+
+	const workers = 2
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			createdBy := uuid.New()
+			<-start
+			_, err := orderService.CreateOrder(order.PaymentCash, []services.OrderItemInfo{{InventoryID: inv.ID, Quantity: 1}},
+				0, nil, "", &createdBy, "", "", "", "", "", "John Doe", "", "", "", true)
+			errs <- err
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	var succeeded int
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		} else {
+			assert.ErrorIs(t, err, services.ErrConflict)
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+
+	var orders int64
+	assert.NoError(t, db.Model(&order.OrderItem{}).Where("inventory_id = ?", inv.ID).Count(&orders).Error)
+	assert.Equal(t, int64(1), orders)
+
+	current, err := productService.GetInventoryByID(inv.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, current.Quantity)
+}
+
+// TestReserveOnCreate tests when the stock of an order is taken and given back with and without ReserveOnCreate
+func TestReserveOnCreate(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+
+	p, inv := seedProductWithInventory(t, db, "RESERVE-2", 10, 0)
+	assert.NoError(t, db.Create(&product.Price{ProductID: p.ID, Price: 100, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+
+	stock := func() int {
+		t.Helper()
+		current, err := productService.GetInventoryByID(inv.ID)
+		assert.NoError(t, err)
+		return current.Quantity
+	}
+	create := func(quantity int) uuid.UUID {
+		t.Helper()
+		createdBy := uuid.New()
+		result, err := orderService.CreateOrder(order.PaymentCash, []services.OrderItemInfo{{InventoryID: inv.ID, Quantity: quantity}},
+			0, nil, "", &createdBy, "", "", "", "", "", "John Doe", "", "", "", true)
+		assert.NoError(t, err)
+		return result.OrderID
+	}
+
+	t.Run("Enabled", func(t *testing.T) {
+		orderID := create(3)
+		assert.Equal(t, 7, stock())
+
+		// Item edits move the difference; packing takes nothing more
+		created, err := orderService.GetOrderByID(orderID)
+		assert.NoError(t, err)
+		assert.NoError(t, orderService.UpdateOrderItem(created.Items[0].ID, 4, false))
+		assert.Equal(t, 6, stock())
+		assert.NoError(t, orderService.UpdateOrderItem(created.Items[0].ID, 2, false))
+		assert.Equal(t, 8, stock())
+
+		_, err = orderService.UpdateOrderStatus(orderID, order.OrderPacked)
+		assert.NoError(t, err)
+		assert.Equal(t, 8, stock())
+
+		_, err = orderService.UpdateOrderStatus(orderID, order.OrderCanceled)
+		assert.NoError(t, err)
+		assert.Equal(t, 10, stock())
+
+		// Purging a pending order gives its stock back
+		orderID = create(5)
+		assert.Equal(t, 5, stock())
+		_, err = orderService.RemoveOrder(orderID, services.OrderDeletePurge, services.StatusChange{})
+		assert.NoError(t, err)
+		assert.Equal(t, 10, stock())
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		orderService.Settings.ReserveOnCreate = false
+		defer func() { orderService.Settings.ReserveOnCreate = true }()
+
+		orderID := create(3)
+		assert.Equal(t, 10, stock())
+
+		_, err := orderService.UpdateOrderStatus(orderID, order.OrderPacked)
+		assert.NoError(t, err)
+		assert.Equal(t, 7, stock())
+	})
+}
+
+// TestInventoryAvailableToPromise tests that stock committed to open orders is not available to promise
+func TestInventoryAvailableToPromise(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+
+	p, inv := seedProductWithInventory(t, db, "ATP-001", 5, 1)
+	assert.NoError(t, db.Create(&product.Price{ProductID: p.ID, Price: 100, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+	open := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	assert.NoError(t, db.Create(&order.OrderItem{OrderID: open.ID, InventoryID: inv.ID, Quantity: 5, PriceAtOrder: 100}).Error)
+
+	// Canceled orders do not commit any stock
+	canceled := seedOrder(t, db, order.OrderCanceled, nil)
+	assert.NoError(t, db.Create(&order.OrderItem{OrderID: canceled.ID, InventoryID: inv.ID, Quantity: 2, PriceAtOrder: 100}).Error)
+
+	availability, err := orderService.GetInventoryAvailability(inv.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, availability.Quantity)
+	assert.Equal(t, 5, availability.Committed)
+	assert.Equal(t, 0, availability.AvailableToPromise)
+
+	// The raw check still passes while the available-to-promise check does not
+	assert.NoError(t, orderService.AddOrderItem(seedOrder(t, db, order.OrderShipmentRequested, nil).ID, inv.ID, 1, false))
+
+	orderService.Settings.CheckAvailableToPromise = true
+	err = orderService.AddOrderItem(seedOrder(t, db, order.OrderShipmentRequested, nil).ID, inv.ID, 1, false)
+	assert.ErrorIs(t, err, services.ErrConflict)
+
+	_, err = orderService.GetInventoryAvailability(uuid.New())
+	assert.ErrorIs(t, err, services.ErrNotFound)
+}
+
+// TestInventoryHold tests that holds reduce the available-to-promise quantity until they expire or are released
+func TestInventoryHold(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+
+	_, inv := seedProductWithInventory(t, db, "HOLD-001", 10, 1)
+	customerID := uuid.New()
+
+	t.Run("Hold", func(t *testing.T) {
+		hold, err := orderService.HoldInventory(inv.ID, 4, time.Hour, &customerID, "Quotation Q-1", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, order.HoldActive, hold.Status)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), hold.ExpiresAt, time.Minute)
+
+		availability, err := orderService.GetInventoryAvailability(inv.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 10, availability.Quantity)
+		assert.Equal(t, 4, availability.Held)
+		assert.Equal(t, 6, availability.AvailableToPromise)
+
+		// Holds cannot exceed the available-to-promise quantity
+		_, err = orderService.HoldInventory(inv.ID, 7, time.Hour, nil, "", nil)
+		assert.ErrorIs(t, err, services.ErrConflict)
+
+		_, err = orderService.HoldInventory(inv.ID, 0, time.Hour, nil, "", nil)
+		assert.ErrorIs(t, err, services.ErrValidation)
+		_, err = orderService.HoldInventory(inv.ID, 1, services.MaxInventoryHoldDuration+time.Hour, nil, "", nil)
+		assert.ErrorIs(t, err, services.ErrValidation)
+		_, err = orderService.HoldInventory(uuid.New(), 1, time.Hour, nil, "", nil)
+		assert.ErrorIs(t, err, services.ErrNotFound)
+
+		// Releasing restores the available-to-promise quantity
+		released, err := orderService.ReleaseInventoryHold(hold.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, order.HoldReleased, released.Status)
+		assert.NotNil(t, released.ReleasedAt)
+
+		availability, err = orderService.GetInventoryAvailability(inv.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, availability.Held)
+		assert.Equal(t, 10, availability.AvailableToPromise)
+
+		_, err = orderService.ReleaseInventoryHold(hold.ID)
+		assert.ErrorIs(t, err, services.ErrConflict)
+		_, err = orderService.ReleaseInventoryHold(uuid.New())
+		assert.ErrorIs(t, err, services.ErrNotFound)
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		expiring, err := orderService.HoldInventory(inv.ID, 3, time.Hour, nil, "", nil)
+		assert.NoError(t, err)
+		active, err := orderService.HoldInventory(inv.ID, 2, time.Hour, nil, "", nil)
+		assert.NoError(t, err)
+		assert.NoError(t, db.Model(&order.InventoryHold{}).Where("id = ?", expiring.ID).
+			Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+		// An expired hold stops counting before it is swept
+		availability, err := orderService.GetInventoryAvailability(inv.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, availability.Held)
+		assert.Equal(t, 8, availability.AvailableToPromise)
+
+		expired, err := orderService.ExpireInventoryHolds()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), expired)
+
+		var swept order.InventoryHold
+		assert.NoError(t, db.First(&swept, "id = ?", expiring.ID).Error)
+		assert.Equal(t, order.HoldExpired, swept.Status)
+		assert.NotNil(t, swept.ReleasedAt)
+
+		// The active hold is left alone and an expired hold can no longer be released
+		var kept order.InventoryHold
+		assert.NoError(t, db.First(&kept, "id = ?", active.ID).Error)
+		assert.Equal(t, order.HoldActive, kept.Status)
+
+		_, err = orderService.ReleaseInventoryHold(expiring.ID)
+		assert.ErrorIs(t, err, services.ErrConflict)
+	})
+}
+
+// TestOrderItemQuantityValidation tests that the service rejects zero and negative item quantities
+func TestOrderItemQuantityValidation(t *testing.T) {
+	orderService := services.NewOrderService(nil, services.NewProductService(nil, nil, nil), nil, nil)
+	createdBy := uuid.New()
+
+	for _, quantity := range []int{0, -1} {
+		result, err := orderService.CreateOrder(order.PaymentCash,
+			[]services.OrderItemInfo{{InventoryID: uuid.New(), Quantity: quantity}},
+			0, nil, "", &createdBy, "", "", "", "", "", "John Doe", "", "", "", true)
+		assert.ErrorIs(t, err, services.ErrValidation)
+		assert.False(t, result.Success)
+
+		assert.ErrorIs(t, orderService.AddOrderItem(uuid.New(), uuid.New(), quantity, false), services.ErrValidation)
+		assert.ErrorIs(t, orderService.UpdateOrderItem(uuid.New(), quantity, false), services.ErrValidation)
+
+		available, err := orderService.ProductService.CheckInventoryAvailability(uuid.New(), quantity)
+		assert.ErrorIs(t, err, services.ErrValidation)
+		assert.False(t, available)
+	}
+}
+
+// TestAddOrderItemValidQuantity tests that a positive quantity is added and counted in the order total
+func TestAddOrderItemValidQuantity(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+
+	p, inv := seedProductWithInventory(t, db, "QTY-001", 10, 2)
+	assert.NoError(t, db.Create(&product.Price{ProductID: p.ID, Price: 50, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+	o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+
+	assert.NoError(t, orderService.AddOrderItem(o.ID, inv.ID, 2, false))
+
+	updated, err := orderService.GetOrderByID(o.ID)
+	assert.NoError(t, err)
+	assert.Len(t, updated.Items, 1)
+	assert.Equal(t, 2, updated.Items[0].Quantity)
+	assert.Equal(t, 200.0, updated.TotalAmount)
+}
+
+// TestIsHandedToCarrier tests which orders are locked against edits
+func TestIsHandedToCarrier(t *testing.T) {
+	for status, want := range map[order.OrderStatus]bool{
+		order.OrderShipmentRequested: false,
+		order.OrderPacked:            false,
+		order.OrderCanceled:          false,
+		order.OrderPicked:            true,
+		order.OrderDelivering:        true,
+		order.OrderDelivered:         true,
+		order.OrderReturned:          true,
+	} {
+		assert.Equal(t, want, order.Order{OrderStatus: status}.IsHandedToCarrier(), status)
+	}
+
+	withTracking := order.Order{OrderStatus: order.OrderPacked, Shipments: []order.Shipment{{Carrier: "GHN"}, {TrackingNumber: "GHN123"}}}
+	assert.True(t, withTracking.IsHandedToCarrier())
+	withoutTracking := order.Order{OrderStatus: order.OrderPacked, Shipments: []order.Shipment{{Carrier: "GHN"}}}
+	assert.False(t, withoutTracking.IsHandedToCarrier())
+}
+
+// TestOrderEditLock tests that orders handed to the carrier cannot be edited unless admins may override the lock
+func TestOrderEditLock(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+
+	p, inv := seedProductWithInventory(t, db, "LCK-001", 20, 2)
+	assert.NoError(t, db.Create(&product.Price{ProductID: p.ID, Price: 50, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+
+	updateAddress := func(o *order.Order, isAdmin bool) error {
+		_, err := orderService.UpdateOrderDetails(o.ID, "", "", nil, nil, nil,
+			"34 Le Loi", "", "", "", "", "", "", "", isAdmin)
+		return err
+	}
+
+	t.Run("Allowed", func(t *testing.T) {
+		o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+		assert.NoError(t, orderService.AddOrderItem(o.ID, inv.ID, 1, false))
+		assert.NoError(t, updateAddress(o, false))
+
+		stored, err := orderService.GetOrderByID(o.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "34 Le Loi", stored.ShippingAddress)
+		if assert.Len(t, stored.Items, 1) {
+			assert.NoError(t, orderService.UpdateOrderItem(stored.Items[0].ID, 2, false))
+			assert.NoError(t, orderService.AddOrderItem(o.ID, inv.ID, 1, false))
+			assert.NoError(t, orderService.DeleteOrderItem(stored.Items[0].ID, false))
+		}
+	})
+
+	t.Run("Shipped", func(t *testing.T) {
+		o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+		assert.NoError(t, orderService.AddOrderItem(o.ID, inv.ID, 1, false))
+		assert.NoError(t, db.Model(o).Update("order_status", order.OrderDelivering).Error)
+
+		stored, err := orderService.GetOrderByID(o.ID)
+		assert.NoError(t, err)
+		itemID := stored.Items[0].ID
+
+		for _, isAdmin := range []bool{false, true} {
+			err := updateAddress(o, isAdmin)
+			assert.ErrorIs(t, err, services.ErrValidation)
+			assert.ErrorContains(t, err, "handed to the carrier")
+			assert.ErrorIs(t, orderService.AddOrderItem(o.ID, inv.ID, 1, isAdmin), services.ErrValidation)
+			assert.ErrorIs(t, orderService.UpdateOrderItem(itemID, 2, isAdmin), services.ErrValidation)
+			assert.ErrorIs(t, orderService.DeleteOrderItem(itemID, isAdmin), services.ErrValidation)
+		}
+
+		stored, err = orderService.GetOrderByID(o.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, stored.ShippingAddress)
+		assert.Len(t, stored.Items, 1)
+	})
+
+	t.Run("ActiveCarrierShipment", func(t *testing.T) {
+		o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+		_, err := orderService.CreateShipment(o.ID, "GHN123", "GHN", nil)
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, orderService.AddOrderItem(o.ID, inv.ID, 1, false), services.ErrValidation)
+		assert.ErrorIs(t, updateAddress(o, false), services.ErrValidation)
+
+		// Admins may override the lock when configured
+		orderService.Settings.AdminEditHandedOver = true
+		defer func() { orderService.Settings.AdminEditHandedOver = false }()
+		assert.ErrorIs(t, updateAddress(o, false), services.ErrValidation)
+		assert.NoError(t, updateAddress(o, true))
+		assert.NoError(t, orderService.AddOrderItem(o.ID, inv.ID, 1, true))
+	})
+}
+
+// TestDeleteLastOrderItem tests that the last item of an order cannot be deleted
+func TestDeleteLastOrderItem(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+
+	p, inv := seedProductWithInventory(t, db, "LAST-001", 20, 2)
+	assert.NoError(t, db.Create(&product.Price{ProductID: p.ID, Price: 50, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+
+	o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	assert.NoError(t, orderService.AddOrderItem(o.ID, inv.ID, 1, false))
+	assert.NoError(t, orderService.AddOrderItem(o.ID, inv.ID, 2, false))
+
+	stored, err := orderService.GetOrderByID(o.ID)
+	assert.NoError(t, err)
+	if !assert.Len(t, stored.Items, 2) {
+		return
+	}
+
+	// Deleting one of two items is allowed
+	assert.NoError(t, orderService.DeleteOrderItem(stored.Items[0].ID, false))
+
+	// The remaining item cannot be deleted, for admins either
+	for _, isAdmin := range []bool{false, true} {
+		err = orderService.DeleteOrderItem(stored.Items[1].ID, isAdmin)
+		assert.ErrorIs(t, err, services.ErrValidation)
+		assert.ErrorContains(t, err, "last item")
+	}
+
+	stored, err = orderService.GetOrderByID(o.ID)
+	assert.NoError(t, err)
+	assert.Len(t, stored.Items, 1)
+}
+
+// TestDiscountRequiresApproval tests the absolute and percentage discount approval thresholds
+func TestDiscountRequiresApproval(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		assert.False(t, services.DefaultOrderSettings().DiscountRequiresApproval(100, 100))
+	})
+
+	t.Run("Absolute", func(t *testing.T) {
+		settings := services.OrderSettings{DiscountApprovalAmount: 50}
+		assert.False(t, settings.DiscountRequiresApproval(1000, 50))
+		assert.True(t, settings.DiscountRequiresApproval(1000, 50.01))
+	})
+
+	t.Run("Percentage", func(t *testing.T) {
+		settings := services.OrderSettings{DiscountApprovalPercent: 10}
+		assert.False(t, settings.DiscountRequiresApproval(1000, 100))
+		assert.True(t, settings.DiscountRequiresApproval(1000, 101))
+	})
+
+	t.Run("EitherThreshold", func(t *testing.T) {
+		settings := services.OrderSettings{DiscountApprovalAmount: 50, DiscountApprovalPercent: 10}
+		assert.True(t, settings.DiscountRequiresApproval(200, 30))
+		assert.True(t, settings.DiscountRequiresApproval(10000, 60))
+		assert.False(t, settings.DiscountRequiresApproval(1000, 40))
+	})
+}
+
+// TestAgentTargetStatuses tests the configurable statuses agents may move orders to
+func TestAgentTargetStatuses(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		settings := services.OrderSettings{}.WithDefaults()
+		assert.True(t, settings.AgentCanSetStatus(order.OrderPacked))
+		assert.True(t, settings.AgentCanSetStatus(order.OrderCanceled))
+		assert.False(t, settings.AgentCanSetStatus(order.OrderDelivered))
+	})
+
+	t.Run("Configured", func(t *testing.T) {
+		statuses, err := order.ParseOrderStatuses(" Packed, picked ,")
+		assert.NoError(t, err)
+		assert.Equal(t, []order.OrderStatus{order.OrderPacked, order.OrderPicked}, statuses)
+
+		settings := services.OrderSettings{AgentTargetStatuses: statuses}.WithDefaults()
+		assert.True(t, settings.AgentCanSetStatus(order.OrderPicked))
+		assert.False(t, settings.AgentCanSetStatus(order.OrderCanceled))
+	})
+
+	t.Run("UnknownStatus", func(t *testing.T) {
+		_, err := order.ParseOrderStatuses("packed,confirmed")
+		assert.Error(t, err)
+	})
+}
+
+// TestUpdateOrderDetailsDiscountApproval tests that agents need admin approval for large discounts
+func TestUpdateOrderDetailsDiscountApproval(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	orderService := services.NewOrderService(db, nil, nil, nil)
+	orderService.Settings.DiscountApprovalPercent = 10
+
+	o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	reason := "loyal customer"
+	updateDiscount := func(discount float64, isAdmin bool) (*services.OrderResult, error) {
+		return orderService.UpdateOrderDetails(o.ID, "", "", &discount, nil, &reason,
+			"", "", "", "", "", "", "", "", isAdmin)
+	}
+
+	t.Run("AgentUnderThreshold", func(t *testing.T) {
+		result, err := updateDiscount(10, false)
+		assert.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Equal(t, 90.0, result.FinalTotal)
+	})
+
+	t.Run("AgentOverThreshold", func(t *testing.T) {
+		result, err := updateDiscount(30, false)
+		assert.ErrorIs(t, err, services.ErrForbidden)
+		assert.False(t, result.Success)
+		assert.Equal(t, "Discount requires admin approval", result.Error)
+
+		stored, err := orderService.GetOrderByID(o.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 10.0, stored.DiscountAmount)
+	})
+
+	t.Run("AdminBypassesThreshold", func(t *testing.T) {
+		result, err := updateDiscount(30, true)
+		assert.NoError(t, err)
+		assert.Equal(t, 70.0, result.FinalTotal)
+	})
+
+	t.Run("AgentKeepsApprovedDiscount", func(t *testing.T) {
+		result, err := updateDiscount(30, false)
+		assert.NoError(t, err)
+		assert.True(t, result.Success)
+	})
+}
+
+// TestCreateOrderDiscountApproval tests that agents need admin approval for large discounts on new orders
+func TestCreateOrderDiscountApproval(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+	orderService.Settings.DiscountApprovalPercent = 10
+
+	p, inv := seedProductWithInventory(t, db, "APR-001", 10, 2)
+	assert.NoError(t, db.Create(&product.Price{ProductID: p.ID, Price: 100, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+
+	createdBy := uuid.New()
+	createOrder := func(discountPercent float64, isAdmin bool) (*services.OrderResult, error) {
+		return orderService.CreateOrder(order.PaymentCash, []services.OrderItemInfo{{InventoryID: inv.ID, Quantity: 1}},
+			0, &discountPercent, "Promotion", &createdBy, "", "", "", "", "", "John Doe", "", "", "", isAdmin)
+	}
+
+	t.Run("AgentUnderThreshold", func(t *testing.T) {
+		result, err := createOrder(5, false)
+		assert.NoError(t, err)
+		assert.True(t, result.Success)
+	})
+
+	t.Run("AgentOverThreshold", func(t *testing.T) {
+		var before int64
+		assert.NoError(t, db.Model(&order.Order{}).Count(&before).Error)
+
+		result, err := createOrder(30, false)
+		assert.ErrorIs(t, err, services.ErrForbidden)
+		assert.False(t, result.Success)
+		assert.Equal(t, "Discount requires admin approval", result.Error)
+
+		var after int64
+		assert.NoError(t, db.Model(&order.Order{}).Count(&after).Error)
+		assert.Equal(t, before, after)
+	})
+
+	t.Run("AdminBypassesThreshold", func(t *testing.T) {
+		result, err := createOrder(30, true)
+		assert.NoError(t, err)
+		assert.Equal(t, 70.0, result.FinalTotal)
+	})
+}
+
+// TestOrderItemChangesDiscountApproval tests that agents cannot shrink the total of a discounted order past the approval threshold
+func TestOrderItemChangesDiscountApproval(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+	orderService.Settings.DiscountApprovalPercent = 10
+
+	p1, inv1 := seedProductWithInventory(t, db, "APR-ITEM-1", 10, 2)
+	p2, inv2 := seedProductWithInventory(t, db, "APR-ITEM-2", 10, 2)
+	for _, p := range []*product.Product{p1, p2} {
+		assert.NoError(t, db.Create(&product.Price{ProductID: p.ID, Price: 50, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+	}
+
+	// A discount of exactly 10% of the 200 total needs no approval
+	createdBy := uuid.New()
+	result, err := orderService.CreateOrder(order.PaymentCash,
+		[]services.OrderItemInfo{{InventoryID: inv1.ID, Quantity: 2}, {InventoryID: inv2.ID, Quantity: 2}},
+		20, nil, "Promotion", &createdBy, "", "", "", "", "", "John Doe", "", "", "", false)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	stored, err := orderService.GetOrderByID(result.OrderID)
+	if !assert.NoError(t, err) || !assert.Len(t, stored.Items, 2) {
+		return
+	}
+	first, second := stored.Items[0], stored.Items[1]
+
+	t.Run("AgentUpdateOverThreshold", func(t *testing.T) {
+		err := orderService.UpdateOrderItem(first.ID, 1, false)
+		assert.ErrorIs(t, err, services.ErrForbidden)
+	})
+
+	t.Run("AgentDeleteOverThreshold", func(t *testing.T) {
+		err := orderService.DeleteOrderItem(second.ID, false)
+		assert.ErrorIs(t, err, services.ErrForbidden)
+	})
+
+	t.Run("Unchanged", func(t *testing.T) {
+		stored, err := orderService.GetOrderByID(result.OrderID)
+		assert.NoError(t, err)
+		assert.Len(t, stored.Items, 2)
+		assert.Equal(t, 200.0, stored.TotalAmount)
+		assert.Equal(t, 180.0, stored.FinalTotalAmount)
+	})
+
+	t.Run("AdminBypassesThreshold", func(t *testing.T) {
+		assert.NoError(t, orderService.UpdateOrderItem(first.ID, 1, true))
+
+		stored, err := orderService.GetOrderByID(result.OrderID)
+		assert.NoError(t, err)
+		assert.Equal(t, 150.0, stored.TotalAmount)
+		assert.Equal(t, 130.0, stored.FinalTotalAmount)
+	})
+}
+
+// TestUpdateOrderDetailsKeepsDiscount tests that updating other details leaves the discount and final total untouched
+func TestUpdateOrderDetailsKeepsDiscount(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	orderService := services.NewOrderService(db, nil, nil, nil)
+
+	o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	assert.NoError(t, db.Model(o).Updates(map[string]interface{}{
+		"discount_amount":    20,
+		"discount_reason":    "VIP customer",
+		"final_total_amount": 80,
+	}).Error)
+
+	result, err := orderService.UpdateOrderDetails(o.ID, "", "", nil, nil, nil,
+		"", "", "", "", "", "", "", "0987654321", false)
+	assert.NoError(t, err)
+	assert.Equal(t, 80.0, result.FinalTotal)
+
+	updated, err := orderService.GetOrderByID(o.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "+84987654321", updated.CustomerPhone)
+	assert.Equal(t, 20.0, updated.DiscountAmount)
+	assert.Equal(t, "VIP customer", updated.DiscountReason)
+	assert.Equal(t, 80.0, updated.FinalTotalAmount)
+
+	// Sending only a reason changes the reason but not the amounts
+	reason := "Loyal customer"
+	_, err = orderService.UpdateOrderDetails(o.ID, "", "", nil, nil, &reason,
+		"", "", "", "", "", "", "", "", false)
+	assert.NoError(t, err)
+
+	updated, err = orderService.GetOrderByID(o.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Loyal customer", updated.DiscountReason)
+	assert.Equal(t, 20.0, updated.DiscountAmount)
+	assert.Equal(t, 80.0, updated.FinalTotalAmount)
+
+	// An explicit zero removes the discount
+	zero := 0.0
+	result, err = orderService.UpdateOrderDetails(o.ID, "", "", &zero, nil, nil,
+		"", "", "", "", "", "", "", "", false)
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, result.FinalTotal)
+}
+
+// TestBuildOrderTrail tests merging order, shipment and notification events chronologically
+func TestBuildOrderTrail(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	orderID := uuid.New()
+
+	o := &order.Order{Notes: "Call before delivery", OrderStatus: order.OrderDelivering}
+	o.ID = orderID
+	o.CreatedAt = start
+	o.UpdatedAt = start.Add(30 * time.Minute)
+	o.Shipments = []order.Shipment{{OrderID: orderID, Carrier: "GHN", TrackingNumber: "GHN001"}}
+	o.Shipments[0].CreatedAt = start.Add(2 * time.Hour)
+	o.Shipments[0].UpdatedAt = start.Add(2 * time.Hour)
+
+	orderNotification := func(event, message string, at time.Time, extra notification.Metadata) notification.Notification {
+		metadata := notification.Metadata{"order_id": orderID.String(), "event": event}
+		for k, v := range extra {
+			metadata[k] = v
+		}
+		n := notification.Notification{Title: "Order Update", Message: message, Metadata: metadata}
+		n.CreatedAt = at
+		return n
+	}
+
+	statusChange := orderNotification("delivering", "Order is delivering", start.Add(3*time.Hour),
+		notification.Metadata{"old_status": "picked", "new_status": "delivering"})
+	notifications := []notification.Notification{
+		orderNotification("created", "New order received", start, nil),
+		orderNotification("details_updated", "Order details updated", start.Add(time.Hour), nil),
+		statusChange,
+		// Order notifications are sent to every admin, so the same event appears twice
+		statusChange,
+	}
+
+	trail := services.BuildOrderTrail(o, notifications)
+
+	sources := make([]string, len(trail))
+	for i, entry := range trail {
+		sources[i] = entry.Source
+	}
+	assert.Equal(t, []string{
+		services.TrailSourceOrder,
+		services.TrailSourceNotification,
+		services.TrailSourceNote,
+		services.TrailSourceNotification,
+		services.TrailSourceShipment,
+		services.TrailSourceStatus,
+	}, sources)
+
+	for i := 1; i < len(trail); i++ {
+		assert.False(t, trail[i].Timestamp.Before(trail[i-1].Timestamp), "trail must be chronological")
+	}
+	assert.Equal(t, "details_updated", trail[3].Event)
+	assert.Equal(t, "delivering", trail[5].Event)
+}
+
+// TestSoldCountTracksOrderLifecycle tests that product sold counts follow order creation and cancellation
+func TestSoldCountTracksOrderLifecycle(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+
+	p, inv := seedProductWithInventory(t, db, "POP-001", 10, 2)
+	assert.NoError(t, db.Create(&product.Price{ProductID: p.ID, Price: 50, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+
+	soldCount := func() int {
+		var stored product.Product
+		assert.NoError(t, db.First(&stored, "id = ?", p.ID).Error)
+		return stored.SoldCount
+	}
+
+	createdBy := uuid.New()
+	result, err := orderService.CreateOrder(order.PaymentCash,
+		[]services.OrderItemInfo{{InventoryID: inv.ID, Quantity: 3}},
+		0, nil, "", &createdBy, "", "", "", "", "", "John Doe", "", "", "", true)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, soldCount())
+
+	// Item edits on an open order keep the counter in sync
+	created, err := orderService.GetOrderByID(result.OrderID)
+	assert.NoError(t, err)
+	assert.NoError(t, orderService.UpdateOrderItem(created.Items[0].ID, 5, false))
+	assert.Equal(t, 5, soldCount())
+
+	_, err = orderService.UpdateOrderStatus(result.OrderID, order.OrderCanceled)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, soldCount())
+
+	o, err := orderService.GetOrderByID(result.OrderID)
+	assert.NoError(t, err)
+	assert.False(t, o.SalesCounted)
+
+	// Releasing the inventory again must not touch the counter
+	assert.NoError(t, orderService.ReleaseOrderInventory(result.OrderID))
+	assert.Equal(t, 0, soldCount())
+
+	// Shipping an order keeps its sales counted
+	result, err = orderService.CreateOrder(order.PaymentCash,
+		[]services.OrderItemInfo{{InventoryID: inv.ID, Quantity: 2}},
+		0, nil, "", &createdBy, "", "", "", "", "", "John Doe", "", "", "", true)
+	assert.NoError(t, err)
+	_, err = orderService.UpdateOrderStatus(result.OrderID, order.OrderPacked)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, soldCount())
+}
+
+// TestFailedCancellationKeepsSoldCounts tests that the sold counts taken back before a cancellation
+// fails are counted again, so the counter stays in sync with the order
+func TestFailedCancellationKeepsSoldCounts(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+
+	counted, countedInv := seedProductWithInventory(t, db, "POP-OK", 10, 2)
+	_, removedInv := seedProductWithInventory(t, db, "POP-GONE", 10, 2)
+	assert.NoError(t, db.Model(counted).Update("sold_count", 3).Error)
+
+	o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	assert.NoError(t, db.Model(o).Update("sales_counted", true).Error)
+	assert.NoError(t, db.Create(&order.OrderItem{OrderID: o.ID, InventoryID: countedInv.ID, Quantity: 3, PriceAtOrder: 100}).Error)
+	assert.NoError(t, db.Create(&order.OrderItem{OrderID: o.ID, InventoryID: removedInv.ID, Quantity: 1, PriceAtOrder: 100}).Error)
+
+	// The second item's sold count cannot be taken back once its variant is gone
+	assert.NoError(t, db.Delete(removedInv).Error)
+
+	_, err := orderService.UpdateOrderStatus(o.ID, order.OrderCanceled)
+	assert.Error(t, err)
+
+	var stored product.Product
+	assert.NoError(t, db.First(&stored, "id = ?", counted.ID).Error)
+	assert.Equal(t, 3, stored.SoldCount)
+
+	kept, err := orderService.GetOrderByID(o.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, order.OrderShipmentRequested, kept.OrderStatus)
+	assert.True(t, kept.SalesCounted)
+}
+
+// TestReturnOrderItems tests that a partial return releases exactly the returned units and
+// that marking the order as returned only releases the rest
+func TestReturnOrderItems(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+
+	p, inv := seedProductWithInventory(t, db, "RETURN-001", 10, 0)
+	assert.NoError(t, db.Create(&product.Price{ProductID: p.ID, Price: 100, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+
+	stock := func() int {
+		t.Helper()
+		current, err := productService.GetInventoryByID(inv.ID)
+		assert.NoError(t, err)
+		return current.Quantity
+	}
+
+	createdBy := uuid.New()
+	result, err := orderService.CreateOrder(order.PaymentCash,
+		[]services.OrderItemInfo{{InventoryID: inv.ID, Quantity: 5}},
+		0, nil, "", &createdBy, "", "", "", "", "", "John Doe", "", "", "", true)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, stock())
+
+	created, err := orderService.GetOrderByID(result.OrderID)
+	assert.NoError(t, err)
+	itemID := created.Items[0].ID
+
+	// Items cannot be returned before the order is delivered
+	_, err = orderService.ReturnOrderItems(result.OrderID, []services.ItemReturn{{OrderItemID: itemID, Quantity: 2}})
+	assert.ErrorIs(t, err, services.ErrValidation)
+
+	_, err = orderService.UpdateOrderStatus(result.OrderID, order.OrderDelivered)
+	assert.NoError(t, err)
+
+	_, err = orderService.ReturnOrderItems(result.OrderID, []services.ItemReturn{{OrderItemID: itemID, Quantity: 2}})
+	assert.NoError(t, err)
+	assert.Equal(t, 7, stock())
+
+	returned, err := orderService.GetOrderByID(result.OrderID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, returned.Items[0].ReturnedQuantity)
+	assert.Equal(t, 3, returned.Items[0].ReturnableQuantity())
+
+	var stored product.Product
+	assert.NoError(t, db.First(&stored, "id = ?", p.ID).Error)
+	assert.Equal(t, 3, stored.SoldCount)
+
+	// More units than remain returnable are rejected, also when split across entries
+	_, err = orderService.ReturnOrderItems(result.OrderID, []services.ItemReturn{
+		{OrderItemID: itemID, Quantity: 2}, {OrderItemID: itemID, Quantity: 2},
+	})
+	assert.ErrorIs(t, err, services.ErrValidation)
+	assert.Equal(t, 7, stock())
+
+	_, err = orderService.ReturnOrderItems(result.OrderID, []services.ItemReturn{{OrderItemID: uuid.New(), Quantity: 1}})
+	assert.ErrorIs(t, err, services.ErrNotFound)
+
+	// Returning the whole order only releases the units not returned yet
+	_, err = orderService.UpdateOrderStatus(result.OrderID, order.OrderReturnProcessing)
+	assert.NoError(t, err)
+	_, err = orderService.UpdateOrderStatus(result.OrderID, order.OrderReturned)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, stock())
+}
+
+// TestRepriceOrder tests that repricing updates item prices and totals to the current prices
+func TestRepriceOrder(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+
+	p, inv := seedProductWithInventory(t, db, "RPC-001", 10, 2)
+	assert.NoError(t, db.Create(&product.Price{ProductID: p.ID, Price: 40, Currency: "VND", StartDate: time.Now().Add(-2 * time.Hour)}).Error)
+
+	o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	assert.NoError(t, db.Model(o).Updates(map[string]interface{}{"total_amount": 0, "final_total_amount": 0, "discount_amount": 10}).Error)
+	assert.NoError(t, orderService.AddOrderItem(o.ID, inv.ID, 2, false))
+
+	// A newer price takes effect after the item was added
+	assert.NoError(t, db.Create(&product.Price{ProductID: p.ID, Price: 60, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+
+	result, err := orderService.RepriceOrder(o.ID)
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, 120.0, result.Total)
+	assert.Equal(t, 110.0, result.FinalTotal)
+
+	repriced, err := orderService.GetOrderByID(o.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, repriced.Items, 1) {
+		assert.Equal(t, 60.0, repriced.Items[0].PriceAtOrder)
+	}
+	assert.Equal(t, 120.0, repriced.TotalAmount)
+	assert.Equal(t, 110.0, repriced.FinalTotalAmount)
+
+	// Orders that left the warehouse keep their prices
+	shipped := seedOrder(t, db, order.OrderDelivering, nil)
+	_, err = orderService.RepriceOrder(shipped.ID)
+	assert.ErrorIs(t, err, services.ErrValidation)
+}
+
+// TestCreateOrderUsesPriceTiers tests that order lines are charged the quantity break they reach
+func TestCreateOrderUsesPriceTiers(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+
+	p, inv := seedProductWithInventory(t, db, "TIER-001", 200, 2)
+	price := &product.Price{ProductID: p.ID, Price: 100, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}
+	assert.NoError(t, db.Create(price).Error)
+	_, err := productService.CreatePriceTier(price.ID, 100, 80)
+	assert.NoError(t, err)
+
+	// Tiers must undercut the base price and use distinct minimum quantities
+	_, err = productService.CreatePriceTier(price.ID, 50, 120)
+	assert.ErrorIs(t, err, services.ErrValidation)
+	_, err = productService.CreatePriceTier(price.ID, 100, 75)
+	assert.ErrorIs(t, err, services.ErrConflict)
+
+	createdBy := uuid.New()
+	unitPrice := func(quantity int) float64 {
+		t.Helper()
+		result, err := orderService.CreateOrder(order.PaymentCash,
+			[]services.OrderItemInfo{{InventoryID: inv.ID, Quantity: quantity}},
+			0, nil, "", &createdBy, "", "", "", "", "", "John Doe", "", "", "", true)
+		if !assert.NoError(t, err) {
+			return 0
+		}
+		created, err := orderService.GetOrderByID(result.OrderID)
+		assert.NoError(t, err)
+		if !assert.Len(t, created.Items, 1) {
+			return 0
+		}
+		assert.Equal(t, created.Items[0].PriceAtOrder*float64(quantity), created.TotalAmount)
+		return created.Items[0].PriceAtOrder
+	}
+
+	assert.Equal(t, 100.0, unitPrice(1))
+	assert.Equal(t, 80.0, unitPrice(100))
+
+	// Items added to an open order are priced by their own quantity
+	o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	assert.NoError(t, orderService.AddOrderItem(o.ID, inv.ID, 1, false))
+	assert.NoError(t, orderService.AddOrderItem(o.ID, inv.ID, 100, false))
+	updated, err := orderService.GetOrderByID(o.ID)
+	assert.NoError(t, err)
+	prices := make([]float64, len(updated.Items))
+	for i, item := range updated.Items {
+		prices[i] = item.PriceAtOrder
+	}
+	assert.ElementsMatch(t, []float64{100, 80}, prices)
+}
+
+// TestCreateOrderConvertsCurrency tests that item prices are converted to the order currency
+// and that the listed price is kept on the item
+func TestCreateOrderConvertsCurrency(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	provider := &stubRateProvider{rates: map[[2]string]float64{{"USD", "VND"}: 25000}}
+	orderService := services.NewOrderService(db, productService, nil, nil)
+	orderService.CurrencyService = services.NewCurrencyService(provider)
+
+	usdProduct, usdInv := seedProductWithInventory(t, db, "USD-001", 10, 2)
+	assert.NoError(t, db.Create(&product.Price{ProductID: usdProduct.ID, Price: 12.5, Currency: "USD", StartDate: time.Now().Add(-time.Hour)}).Error)
+	vndProduct, vndInv := seedProductWithInventory(t, db, "VND-001", 10, 2)
+	assert.NoError(t, db.Create(&product.Price{ProductID: vndProduct.ID, Price: 150000, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+
+	createdBy := uuid.New()
+	result, err := orderService.CreateOrder(order.PaymentCash,
+		[]services.OrderItemInfo{{InventoryID: usdInv.ID, Quantity: 2}, {InventoryID: vndInv.ID, Quantity: 1}},
+		0, nil, "", &createdBy, "", "", "", "", "", "John Doe", "", "", "", true)
+	assert.NoError(t, err)
+
+	created, err := orderService.GetOrderByID(result.OrderID)
+	assert.NoError(t, err)
+	assert.Equal(t, "VND", created.Currency)
+	assert.Equal(t, 2*312500.0+150000, created.TotalAmount)
+
+	items := make(map[uuid.UUID]order.OrderItem)
+	for _, item := range created.Items {
+		items[item.InventoryID] = item
+	}
+	assert.Equal(t, 312500.0, items[usdInv.ID].PriceAtOrder)
+	assert.Equal(t, 12.5, items[usdInv.ID].OriginalPrice)
+	assert.Equal(t, "USD", items[usdInv.ID].OriginalCurrency)
+
+	// Prices already in the order currency are kept as they are without a rate lookup
+	assert.Equal(t, 150000.0, items[vndInv.ID].PriceAtOrder)
+	assert.Equal(t, 150000.0, items[vndInv.ID].OriginalPrice)
+	assert.Equal(t, "VND", items[vndInv.ID].OriginalCurrency)
+	assert.Equal(t, 1, provider.calls)
+
+	// Prices in a currency without a rate cannot be ordered
+	eurProduct, eurInv := seedProductWithInventory(t, db, "EUR-001", 10, 2)
+	assert.NoError(t, db.Create(&product.Price{ProductID: eurProduct.ID, Price: 10, Currency: "EUR", StartDate: time.Now().Add(-time.Hour)}).Error)
+	_, err = orderService.CreateOrder(order.PaymentCash,
+		[]services.OrderItemInfo{{InventoryID: eurInv.ID, Quantity: 1}},
+		0, nil, "", &createdBy, "", "", "", "", "", "John Doe", "", "", "", true)
+	assert.ErrorIs(t, err, services.ErrValidation)
+}
+
+// TestResolveOrderDeleteMode tests the default delete mode for each order status
+func TestResolveOrderDeleteMode(t *testing.T) {
+	tests := []struct {
+		status order.OrderStatus
+		mode   services.OrderDeleteMode
+		want   services.OrderDeleteMode
+	}{
+		{order.OrderShipmentRequested, "", services.OrderDeletePurge},
+		{order.OrderCanceled, "", services.OrderDeletePurge},
+		{order.OrderPacked, "", services.OrderDeleteCancel},
+		{order.OrderDelivered, "", services.OrderDeleteCancel},
+		{order.OrderShipmentRequested, services.OrderDeleteCancel, services.OrderDeleteCancel},
+		{order.OrderPacked, services.OrderDeletePurge, services.OrderDeletePurge},
+	}
+
+	for _, tt := range tests {
+		mode, err := services.ResolveOrderDeleteMode(tt.status, tt.mode)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, mode, "status %s, mode %q", tt.status, tt.mode)
+	}
+
+	_, err := services.ResolveOrderDeleteMode(order.OrderPacked, "archive")
+	assert.ErrorIs(t, err, services.ErrValidation)
+}
+
+// TestRemoveOrder tests canceling versus purging orders depending on their status
+func TestRemoveOrder(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	orderService := services.NewOrderService(db, nil, nil, nil)
+
+	t.Run("PendingOrderIsPurgedByDefault", func(t *testing.T) {
+		o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+
+		result, err := orderService.RemoveOrder(o.ID, "", services.StatusChange{})
+		assert.NoError(t, err)
+		assert.True(t, result.Success)
+
+		_, err = orderService.GetOrderByID(o.ID)
+		assert.ErrorIs(t, err, services.ErrNotFound)
+	})
+
+	t.Run("PendingOrderCanBeCanceled", func(t *testing.T) {
+		o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+
+		actor := uuid.New()
+		result, err := orderService.RemoveOrder(o.ID, services.OrderDeleteCancel, services.StatusChange{ChangedBy: &actor})
+		assert.NoError(t, err)
+		assert.Equal(t, "Order canceled successfully", result.Message)
+
+		canceled, err := orderService.GetOrderByID(o.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, order.OrderCanceled, canceled.OrderStatus)
+
+		history, err := orderService.GetOrderStatusHistory(o.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, history, 1) && assert.NotNil(t, history[0].ChangedBy) {
+			assert.Equal(t, actor, *history[0].ChangedBy)
+		}
+	})
+
+	t.Run("OrderInProgressIsCanceledByDefault", func(t *testing.T) {
+		o := seedOrder(t, db, order.OrderPacked, nil)
+
+		_, err := orderService.RemoveOrder(o.ID, "", services.StatusChange{})
+		assert.NoError(t, err)
+
+		canceled, err := orderService.GetOrderByID(o.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, order.OrderCanceled, canceled.OrderStatus)
+	})
+
+	t.Run("OrderInProgressCannotBePurged", func(t *testing.T) {
+		o := seedOrder(t, db, order.OrderPacked, nil)
+
+		_, err := orderService.RemoveOrder(o.ID, services.OrderDeletePurge, services.StatusChange{})
+		assert.ErrorIs(t, err, services.ErrValidation)
+
+		kept, err := orderService.GetOrderByID(o.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, order.OrderPacked, kept.OrderStatus)
+	})
+
+	t.Run("DeliveringOrderCannotBeCanceled", func(t *testing.T) {
+		o := seedOrder(t, db, order.OrderDelivering, nil)
+
+		_, err := orderService.RemoveOrder(o.ID, "", services.StatusChange{})
+		assert.ErrorIs(t, err, services.ErrValidation)
+	})
+
+	t.Run("CanceledOrderIsPurgedByDefault", func(t *testing.T) {
+		o := seedOrder(t, db, order.OrderCanceled, nil)
+
+		_, err := orderService.RemoveOrder(o.ID, "", services.StatusChange{})
+		assert.NoError(t, err)
+
+		_, err = orderService.GetOrderByID(o.ID)
+		assert.ErrorIs(t, err, services.ErrNotFound)
+	})
+}
+
+// TestCalculateOrderPricing tests the subtotal, discount split and final total of an order
+func TestCalculateOrderPricing(t *testing.T) {
+	t.Run("ProportionalSplit", func(t *testing.T) {
+		pricing := services.CalculateOrderPricing([]services.OrderLinePricing{
+			{Quantity: 2, UnitPrice: 50},
+			{Quantity: 1, UnitPrice: 100},
+			{Quantity: 3, UnitPrice: 33.33},
+		}, 30)
+
+		assert.Equal(t, 299.99, pricing.Subtotal)
+		assert.Equal(t, 269.99, pricing.FinalTotal)
+		assert.Equal(t, 0.0, pricing.TaxAmount)
+
+		shares := 0.0
+		for _, line := range pricing.Lines {
+			shares += line.DiscountShare
+			assert.InDelta(t, line.LineTotal-line.DiscountShare, line.FinalTotal, 0.001)
+		}
+		assert.InDelta(t, 30.0, shares, 0.001)
+		assert.Equal(t, 99.99, pricing.Lines[2].LineTotal)
+		assert.Equal(t, 10.0, pricing.Lines[0].DiscountShare)
+	})
+
+	t.Run("DiscountExceedsSubtotal", func(t *testing.T) {
+		pricing := services.CalculateOrderPricing([]services.OrderLinePricing{{Quantity: 1, UnitPrice: 40}}, 50)
+		assert.Equal(t, 50.0, pricing.DiscountAmount)
+		assert.Equal(t, 0.0, pricing.FinalTotal)
+		assert.Equal(t, 40.0, pricing.Lines[0].DiscountShare)
+	})
+
+	t.Run("FreeItems", func(t *testing.T) {
+		pricing := services.CalculateOrderPricing([]services.OrderLinePricing{
+			{Quantity: 1, UnitPrice: 0},
+			{Quantity: 1, UnitPrice: 0},
+		}, 10)
+		assert.Equal(t, 0.0, pricing.FinalTotal)
+		assert.Equal(t, 0.0, pricing.Lines[0].DiscountShare)
+		assert.Equal(t, 0.0, pricing.Lines[1].DiscountShare)
+	})
+}
+
+// TestPercentageDiscount tests percentage and fixed discounts and that only one discount mode is used
+func TestPercentageDiscount(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+
+	p, inv := seedProductWithInventory(t, db, "PCT-001", 20, 2)
+	assert.NoError(t, db.Create(&product.Price{ProductID: p.ID, Price: 49.99, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+
+	items := []services.OrderItemInfo{{InventoryID: inv.ID, Quantity: 2}}
+	createdBy := uuid.New()
+	tenPercent := 10.0
+
+	createOrder := func(discountAmount float64, discountPercent *float64) (*services.OrderResult, error) {
+		return orderService.CreateOrder(order.PaymentCash, items, discountAmount, discountPercent, "Promotion",
+			&createdBy, "", "", "", "", "", "John Doe", "", "", "", true)
+	}
+
+	t.Run("Percentage", func(t *testing.T) {
+		result, err := createOrder(0, &tenPercent)
+		assert.NoError(t, err)
+
+		created, err := orderService.GetOrderByID(result.OrderID)
+		assert.NoError(t, err)
+		assert.Equal(t, 99.98, created.TotalAmount)
+		assert.Equal(t, 10.0, created.DiscountAmount)
+		if assert.NotNil(t, created.DiscountPercent) {
+			assert.Equal(t, 10.0, *created.DiscountPercent)
+		}
+		assert.Equal(t, 89.98, created.FinalTotalAmount)
+
+		// The percentage follows the order total when items change
+		assert.NoError(t, orderService.UpdateOrderItem(created.Items[0].ID, 4, false))
+		updated, err := orderService.GetOrderByID(result.OrderID)
+		assert.NoError(t, err)
+		assert.Equal(t, 20.0, updated.DiscountAmount)
+		assert.Equal(t, 179.96, updated.FinalTotalAmount)
+	})
+
+	t.Run("Fixed", func(t *testing.T) {
+		result, err := createOrder(15, nil)
+		assert.NoError(t, err)
+
+		created, err := orderService.GetOrderByID(result.OrderID)
+		assert.NoError(t, err)
+		assert.Equal(t, 15.0, created.DiscountAmount)
+		assert.Nil(t, created.DiscountPercent)
+		assert.Equal(t, 84.98, created.FinalTotalAmount)
+
+		// Switching to a percentage on update computes the amount from the current total
+		twentyPercent := 20.0
+		reason := "Promotion"
+		_, err = orderService.UpdateOrderDetails(created.ID, "", "", nil, &twentyPercent, &reason,
+			"", "", "", "", "", "", "", "", true)
+		assert.NoError(t, err)
+
+		updated, err := orderService.GetOrderByID(created.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 20.0, updated.DiscountAmount)
+		if assert.NotNil(t, updated.DiscountPercent) {
+			assert.Equal(t, 20.0, *updated.DiscountPercent)
+		}
+		assert.Equal(t, 79.98, updated.FinalTotalAmount)
+	})
+
+	t.Run("OnlyOneMode", func(t *testing.T) {
+		_, err := createOrder(5, &tenPercent)
+		assert.ErrorIs(t, err, services.ErrValidation)
+
+		tooLarge := 120.0
+		_, err = createOrder(0, &tooLarge)
+		assert.ErrorIs(t, err, services.ErrValidation)
+	})
+}
+
+// TestDiscountExceedsOrderTotal tests that discounts larger than the order total are rejected instead of clamped
+func TestDiscountExceedsOrderTotal(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+
+	p, inv := seedProductWithInventory(t, db, "OVR-001", 10, 2)
+	assert.NoError(t, db.Create(&product.Price{ProductID: p.ID, Price: 50, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+
+	items := []services.OrderItemInfo{{InventoryID: inv.ID, Quantity: 2}}
+	createdBy := uuid.New()
+	createOrder := func(discountAmount float64, discountPercent *float64) (*services.OrderResult, error) {
+		return orderService.CreateOrder(order.PaymentCash, items, discountAmount, discountPercent, "Promotion",
+			&createdBy, "", "", "", "", "", "John Doe", "", "", "", true)
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		result, err := createOrder(150, nil)
+		assert.ErrorIs(t, err, services.ErrValidation)
+		assert.ErrorContains(t, err, "discount exceeds order total")
+		assert.False(t, result.Success)
+
+		// Nothing is kept of the rejected order
+		current, err := productService.GetInventoryByID(inv.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 10, current.Quantity)
+
+		// A discount of the whole total is allowed
+		result, err = createOrder(100, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 0.0, result.FinalTotal)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		result, err := createOrder(0, nil)
+		assert.NoError(t, err)
+
+		tooLarge := 120.0
+		_, err = orderService.UpdateOrderDetails(result.OrderID, "", "", &tooLarge, nil, nil,
+			"", "", "", "", "", "", "", "", true)
+		assert.ErrorIs(t, err, services.ErrValidation)
+
+		// A percentage is converted to an amount of the current total
+		quarter := 25.0
+		updated, err := orderService.UpdateOrderDetails(result.OrderID, "", "", nil, &quarter, nil,
+			"", "", "", "", "", "", "", "", true)
+		assert.NoError(t, err)
+		assert.Equal(t, 25.0, updated.DiscountAmount)
+		assert.Equal(t, 75.0, updated.FinalTotal)
+	})
+
+	t.Run("Preview", func(t *testing.T) {
+		_, err := orderService.PreviewOrderDiscount(items, 100.01, nil, "")
+		assert.ErrorIs(t, err, services.ErrValidation)
+	})
+}
+
+// TestPreviewOrderDiscountMatchesCreateOrder tests that the discount preview computes the same totals as creating the order
+func TestPreviewOrderDiscountMatchesCreateOrder(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+	orderService.Settings.DiscountApprovalPercent = 10
+
+	shirt, shirtInv := seedProductWithInventory(t, db, "PVW-001", 10, 2)
+	pants, pantsInv := seedProductWithInventory(t, db, "PVW-002", 10, 2)
+	assert.NoError(t, db.Create(&product.Price{ProductID: shirt.ID, Price: 33.33, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+	assert.NoError(t, db.Create(&product.Price{ProductID: pants.ID, Price: 120, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+
+	items := []services.OrderItemInfo{
+		{InventoryID: shirtInv.ID, Quantity: 3},
+		{InventoryID: pantsInv.ID, Quantity: 1},
+	}
+
+	preview, err := orderService.PreviewOrderDiscount(items, 25, nil, "Loyalty discount")
+	assert.NoError(t, err)
+	assert.Equal(t, 219.99, preview.Subtotal)
+	assert.Equal(t, 194.99, preview.FinalTotal)
+	assert.Equal(t, "Loyalty discount", preview.DiscountReason)
+	assert.True(t, preview.RequiresApproval)
+	if assert.Len(t, preview.Lines, 2) {
+		assert.Equal(t, "PVW-001", preview.Lines[0].SKU)
+		assert.Equal(t, 99.99, preview.Lines[0].LineTotal)
+	}
+
+	// Previewing does not persist anything
+	var count int64
+	assert.NoError(t, db.Model(&order.Order{}).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+
+	createdBy := uuid.New()
+	result, err := orderService.CreateOrder(order.PaymentCash, items, 25, nil, "Loyalty discount",
+		&createdBy, "", "", "", "", "", "John Doe", "", "", "", true)
+	assert.NoError(t, err)
+
+	created, err := orderService.GetOrderByID(result.OrderID)
+	assert.NoError(t, err)
+	assert.Equal(t, preview.Subtotal, created.TotalAmount)
+	assert.Equal(t, preview.DiscountAmount, created.DiscountAmount)
+	assert.Equal(t, preview.FinalTotal, created.FinalTotalAmount)
+
+	_, err = orderService.PreviewOrderDiscount([]services.OrderItemInfo{{InventoryID: uuid.New(), Quantity: 1}}, 0, nil, "")
+	assert.ErrorIs(t, err, services.ErrNotFound)
+
+	_, err = orderService.PreviewOrderDiscount(items, -1, nil, "")
+	assert.ErrorIs(t, err, services.ErrValidation)
+}
+
+// TestRecordDeliveryProof tests capturing a delivery proof and requiring it before delivery
+func TestRecordDeliveryProof(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	uploadService, err := upload.NewService(upload.NewConfig(t.TempDir()))
+	if err != nil {
+		t.Fatalf("failed to create upload service: %v", err)
+	}
+	orderService := services.NewOrderService(db, nil, nil, nil)
+	orderService.UploadService = uploadService
+	orderService.Settings.RequireDeliveryProof = true
+
+	agentID := uuid.New()
+	pngHeader := "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+
+	t.Run("ProofRequired", func(t *testing.T) {
+		o := seedOrder(t, db, order.OrderDelivering, nil)
+
+		_, err := orderService.UpdateOrderStatus(o.ID, order.OrderDelivered)
+		assert.ErrorIs(t, err, services.ErrValidation)
+
+		stored, err := orderService.GetOrderByID(o.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, order.OrderDelivering, stored.OrderStatus)
+	})
+
+	t.Run("ProofCaptured", func(t *testing.T) {
+		o := seedOrder(t, db, order.OrderDelivering, nil)
+		deliveredAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+		file := newFileHeaders(t, [2]string{"signature.png", pngHeader})[0]
+		delivered, err := orderService.RecordDeliveryProof(o.ID, file, " Jane Doe ", deliveredAt, agentID)
+		assert.NoError(t, err)
+		assert.Equal(t, order.OrderDelivered, delivered.OrderStatus)
+		assert.True(t, delivered.HasDeliveryProof())
+		assert.Equal(t, "Jane Doe", delivered.DeliveryRecipientName)
+		if assert.NotNil(t, delivered.DeliveredAt) {
+			assert.True(t, deliveredAt.Equal(*delivered.DeliveredAt))
+		}
+	})
+
+	t.Run("RejectedImage", func(t *testing.T) {
+		o := seedOrder(t, db, order.OrderDelivering, nil)
+
+		file := newFileHeaders(t, [2]string{"notes.txt", "this is not an image"})[0]
+		_, err := orderService.RecordDeliveryProof(o.ID, file, "Jane Doe", time.Time{}, agentID)
+		assert.ErrorIs(t, err, services.ErrValidation)
+
+		stored, err := orderService.GetOrderByID(o.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, order.OrderDelivering, stored.OrderStatus)
+		assert.False(t, stored.HasDeliveryProof())
+	})
+
+	t.Run("InvalidTransition", func(t *testing.T) {
+		o := seedOrder(t, db, order.OrderCanceled, nil)
+
+		file := newFileHeaders(t, [2]string{"signature.png", pngHeader})[0]
+		_, err := orderService.RecordDeliveryProof(o.ID, file, "Jane Doe", time.Time{}, agentID)
+		assert.ErrorIs(t, err, services.ErrValidation)
+	})
+}
+
+// TestOrderFlowWithNotificationDBDown tests that order and product operations succeed while the notification database is unavailable
+func TestOrderFlowWithNotificationDBDown(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	downDB := testutil.ClosedTestDB(t)
+
+	for name, notificationService := range map[string]*services.NotificationService{
+		"FailingDatabase": services.NewNotificationService(downDB, downDB, nil, nil),
+		// Missing repositories make every notification call panic
+		"PanickingService": {},
+	} {
+		t.Run(name, func(t *testing.T) {
+			productService := services.NewProductService(db, notificationService, nil)
+			orderService := services.NewOrderService(db, productService, nil, notificationService)
+
+			productResult, err := productService.CreateProduct("Shirt", "", "DOWN-"+name, "Shirts", "")
+			assert.NoError(t, err)
+			assert.True(t, productResult.Success)
+
+			inv := &product.Inventory{ProductID: productResult.ProductID, Size: "M", Color: "Red", Quantity: 10, LowStockThreshold: 2}
+			assert.NoError(t, db.Create(inv).Error)
+			assert.NoError(t, db.Create(&product.Price{ProductID: productResult.ProductID, Price: 100, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+
+			createdBy := uuid.New()
+			result, err := orderService.CreateOrder(order.PaymentCash,
+				[]services.OrderItemInfo{{InventoryID: inv.ID, Quantity: 1}}, 0, nil, "",
+				&createdBy, "", "", "", "", "", "John Doe", "", "", "", true)
+			assert.NoError(t, err)
+			if !assert.True(t, result.Success) {
+				return
+			}
+
+			// The order was committed despite the failed notification
+			created, err := orderService.GetOrderByID(result.OrderID)
+			assert.NoError(t, err)
+			assert.Len(t, created.Items, 1)
+
+			_, err = orderService.UpdateOrderStatus(result.OrderID, order.OrderPacked)
+			assert.NoError(t, err)
+
+			// The trail is built from the order alone
+			trail, err := orderService.GetOrderTrail(result.OrderID)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, trail)
+		})
+	}
+}
+
+// TestValidateShippingAddress tests that every missing contact and address field is reported
+func TestValidateShippingAddress(t *testing.T) {
+	party := services.ShippingParty{
+		Name:     "YBDS Store",
+		Phone:    "0912345678",
+		Address:  "12 Nguyen Trai",
+		Ward:     "Ben Thanh",
+		District: "District 1",
+		City:     "Ho Chi Minh",
+	}
+	assert.NoError(t, services.ValidateShippingAddress(party, "sender"))
+
+	party.Phone = ""
+	party.Ward = " "
+	err := services.ValidateShippingAddress(party, "sender")
+	assert.ErrorIs(t, err, services.ErrValidation)
+	assert.ErrorContains(t, err, "sender is missing phone, ward")
+}
+
+// TestGetShippingLabel tests that the shipping label reflects the order's items, address and shipment
+func TestGetShippingLabel(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+	orderService.Settings.ShippingSender = services.ShippingParty{
+		Name:     "YBDS Store",
+		Phone:    "0912345678",
+		Address:  "12 Nguyen Trai",
+		Ward:     "Ben Thanh",
+		District: "District 1",
+		City:     "Ho Chi Minh",
+		Country:  "Vietnam",
+	}
+
+	shirt, shirtInv := seedProductWithInventory(t, db, "LBL-001", 10, 2)
+	pants, pantsInv := seedProductWithInventory(t, db, "LBL-002", 10, 2)
+	assert.NoError(t, db.Create(&product.Price{ProductID: shirt.ID, Price: 100, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+	assert.NoError(t, db.Create(&product.Price{ProductID: pants.ID, Price: 200, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+
+	createdBy := uuid.New()
+	result, err := orderService.CreateOrder(order.PaymentCOD, []services.OrderItemInfo{
+		{InventoryID: shirtInv.ID, Quantity: 2},
+		{InventoryID: pantsInv.ID, Quantity: 1},
+	}, 0, nil, "", &createdBy, "34 Le Loi", "Phuong 7", "Quan 3", "Ho Chi Minh", "Vietnam",
+		"Tran Thi Lan", "", "0987654321", "Call before delivery", true)
+	assert.NoError(t, err)
+
+	weight := 800.0
+	length, width, height := 30.0, 20.0, 10.0
+	assert.NoError(t, orderService.UpdateShipment(result.OrderID, services.ShipmentDetails{
+		Carrier: "GHN", Weight: &weight, Length: &length, Width: &width, Height: &height,
+	}))
+
+	label, err := orderService.GetShippingLabel(result.OrderID)
+	assert.NoError(t, err)
+	assert.Equal(t, "YBDS Store", label.Sender.Name)
+	assert.Equal(t, "Tran Thi Lan", label.Recipient.Name)
+	assert.Equal(t, "0987654321", label.Recipient.Phone)
+	assert.Equal(t, "34 Le Loi", label.Recipient.Address)
+	assert.Equal(t, "Quan 3", label.Recipient.District)
+	assert.Equal(t, "GHN", label.Carrier)
+	assert.Equal(t, 800.0, label.Weight)
+	assert.Equal(t, 1200.0, label.ChargeableWeight)
+	// A COD order without a COD amount on the shipment collects the final total
+	assert.Equal(t, 400.0, label.CODAmount)
+	assert.Equal(t, 3, label.TotalQuantity)
+	assert.Equal(t, "Call before delivery", label.Notes)
+	if assert.Len(t, label.Items, 2) {
+		skus := []string{label.Items[0].SKU, label.Items[1].SKU}
+		assert.ElementsMatch(t, []string{"LBL-001", "LBL-002"}, skus)
+	}
+	assert.Contains(t, label.ContentsSummary, "2 x Product LBL-001")
+	assert.Contains(t, label.ContentsSummary, "1 x Product LBL-002")
+
+	// An incomplete recipient address is rejected
+	assert.NoError(t, db.Model(&order.Order{}).Where("id = ?", result.OrderID).Update("shipping_ward", "").Error)
+	_, err = orderService.GetShippingLabel(result.OrderID)
+	assert.ErrorIs(t, err, services.ErrValidation)
+
+	_, err = orderService.GetShippingLabel(uuid.New())
+	assert.ErrorIs(t, err, services.ErrNotFound)
+}
+
+// TestUpdateOrderStatusByTrackingNumberUnmapped tests that unmapped GHN statuses leave the order status
+// unchanged and are recorded once in the order notes
+func TestUpdateOrderStatusByTrackingNumberUnmapped(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	orderService := services.NewOrderService(db, nil, nil, nil)
+
+	o := seedOrder(t, db, order.OrderPacked, nil)
+	assert.NoError(t, db.Model(o).Update("notes", "Fragile").Error)
+	assert.NoError(t, db.Create(&order.Shipment{OrderID: o.ID, Carrier: "GHN", TrackingNumber: "GHN123"}).Error)
+
+	for i := 0; i < 2; i++ {
+		update, err := orderService.UpdateOrderStatusByTrackingNumber("GHN123", "lost")
+		assert.NoError(t, err)
+		assert.True(t, update.Unmapped)
+		assert.False(t, update.Applied)
+	}
+
+	var stored order.Order
+	assert.NoError(t, db.First(&stored, "id = ?", o.ID).Error)
+	assert.Equal(t, order.OrderPacked, stored.OrderStatus)
+	assert.Equal(t, "Fragile\nGHN status \"lost\" is not mapped to an order status", stored.Notes)
+
+	// Overrides map the status without a code change
+	orderService.Settings.GHNStatusMapper = ghn.NewStatusMapper().WithOverride("lost", order.OrderCanceled)
+	update, err := orderService.UpdateOrderStatusByTrackingNumber("GHN123", "lost")
+	assert.NoError(t, err)
+	assert.False(t, update.Unmapped)
+	assert.True(t, update.Applied)
+	assert.Equal(t, order.OrderCanceled, update.TargetStatus)
+}
+
+// seedOrderListing seeds a page of orders created by an agent, each with items of distinct priced products
+func seedOrderListing(t testing.TB, db *gorm.DB, orders, itemsPerOrder int) *account.User {
+	t.Helper()
+
+	agent := seedUser(t, db, "listing-agent", account.RoleAgent, true)
+	for i := 0; i < orders; i++ {
+		inventories := make([]*product.Inventory, itemsPerOrder)
+		for j := range inventories {
+			p, inv := seedProductWithInventory(t, db, fmt.Sprintf("LIST-%03d-%d", i, j), 10, 2)
+			price := &product.Price{ProductID: p.ID, Price: 100, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}
+			if err := db.Create(price).Error; err != nil {
+				t.Fatalf("failed to seed price: %v", err)
+			}
+			inventories[j] = inv
+		}
+
+		o := seedOrderWithItems(t, db, order.OrderShipmentRequested, inventories...)
+		if err := db.Model(o).Update("created_by", agent.ID).Error; err != nil {
+			t.Fatalf("failed to assign order: %v", err)
+		}
+	}
+
+	return agent
+}
+
+// TestGetOrdersWithDetails tests that listed orders carry their inventories, products, prices and creator
+func TestGetOrdersWithDetails(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, services.NewUserService(db, nil), nil)
+
+	agent := seedOrderListing(t, db, 3, 2)
+
+	orders, total, err := orderService.GetOrdersWithDetails(1, 10, map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	if !assert.Len(t, orders, 3) {
+		return
+	}
+
+	for _, o := range orders {
+		assert.Equal(t, agent.Username, o.CreatorName)
+		if !assert.Len(t, o.Items, 2) {
+			continue
+		}
+		for _, item := range o.Items {
+			if assert.NotNil(t, item.Inventory) && assert.NotNil(t, item.Product) && assert.NotNil(t, item.Price) {
+				assert.Equal(t, item.Item.InventoryID, item.Inventory.ID)
+				assert.Equal(t, item.Inventory.ProductID, item.Product.ID)
+				assert.Equal(t, item.Product.ID, item.Price.ProductID)
+				assert.Equal(t, "VND", item.Price.Currency)
+			}
+		}
+	}
+
+	// Items whose product has been deleted are listed without product details
+	deleted := orders[0].Items[0]
+	assert.NoError(t, db.Delete(&product.Product{}, "id = ?", deleted.Product.ID).Error)
+	orders, _, err = orderService.GetOrdersWithDetails(1, 10, map[string]interface{}{})
+	assert.NoError(t, err)
+	for _, o := range orders {
+		for _, item := range o.Items {
+			if item.Item.ID == deleted.Item.ID {
+				assert.Nil(t, item.Inventory)
+				assert.Nil(t, item.Product)
+				assert.Nil(t, item.Price)
+			}
+		}
+	}
+}
+
+// BenchmarkGetOrdersWithDetails compares the queries needed to list a page of orders with per-item
+// lookups against batch loading; run it with -benchmem and TEST_DATABASE_URL set to see queries/op
+func BenchmarkGetOrdersWithDetails(b *testing.B) {
+	db := testutil.SetupTestDB(b)
+	productService := services.NewProductService(db, nil, nil)
+	userService := services.NewUserService(db, nil)
+	orderService := services.NewOrderService(db, productService, userService, nil)
+
+	seedOrderListing(b, db, 10, 3)
+	counter := testutil.CountQueries(b, db)
+
+	b.Run("PerItemLookups", func(b *testing.B) {
+		counter.Reset()
+		for i := 0; i < b.N; i++ {
+			orders, _, err := orderService.GetAllOrders(1, 10, map[string]interface{}{})
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, o := range orders {
+				if o.CreatedBy != nil {
+					_, _ = userService.GetUserByID(*o.CreatedBy)
+				}
+				for _, item := range o.Items {
+					inventory, err := productService.GetInventoryByID(item.InventoryID)
+					if err != nil {
+						continue
+					}
+					if p, err := productService.GetProductByID(inventory.ProductID); err == nil {
+						_, _ = productService.GetCurrentPrice(p.ID)
+					}
+				}
+			}
+		}
+		b.ReportMetric(float64(counter.Count())/float64(b.N), "queries/op")
+	})
+
+	b.Run("BatchLoaded", func(b *testing.B) {
+		counter.Reset()
+		for i := 0; i < b.N; i++ {
+			if _, _, err := orderService.GetOrdersWithDetails(1, 10, map[string]interface{}{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(counter.Count())/float64(b.N), "queries/op")
+	})
+}
+
+// TestOrderStatusHistory tests that every status change is recorded in order with the acting user
+func TestOrderStatusHistory(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	orderService := services.NewOrderService(db, nil, nil, nil)
+	o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+
+	packer, picker, shipper := uuid.New(), uuid.New(), uuid.New()
+	changes := []struct {
+		status order.OrderStatus
+		actor  uuid.UUID
+	}{
+		{order.OrderPacked, packer},
+		{order.OrderPicked, picker},
+		{order.OrderDelivering, shipper},
+	}
+	for _, change := range changes {
+		actor := change.actor
+		_, err := orderService.ChangeOrderStatus(o.ID, change.status, services.StatusChange{ChangedBy: &actor, Note: " handed over "})
+		assert.NoError(t, err)
+	}
+
+	history, err := orderService.GetOrderStatusHistory(o.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 3) {
+		from := order.OrderShipmentRequested
+		for i, change := range changes {
+			assert.Equal(t, from, history[i].FromStatus)
+			assert.Equal(t, change.status, history[i].ToStatus)
+			if assert.NotNil(t, history[i].ChangedBy) {
+				assert.Equal(t, change.actor, *history[i].ChangedBy)
+			}
+			assert.Equal(t, "handed over", history[i].Note)
+			from = change.status
+		}
+	}
+
+	_, err = orderService.GetOrderStatusHistory(uuid.New())
+	assert.ErrorIs(t, err, services.ErrNotFound)
+}
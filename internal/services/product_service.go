@@ -1,7 +1,12 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"mime/multipart"
@@ -20,6 +25,7 @@ type ProductService struct {
 	ProductImageRepo    *repositories.ProductImageRepository
 	NotificationService *NotificationService
 	UploadService       *upload.Service
+	PriceSelection      product.PriceSelection
 }
 
 // NewProductService creates a new instance of ProductService
@@ -30,9 +36,41 @@ func NewProductService(db *gorm.DB, notificationService *NotificationService, up
 		ProductImageRepo:    repositories.NewProductImageRepository(db),
 		NotificationService: notificationService,
 		UploadService:       uploadService,
+		PriceSelection:      product.PriceSelectionLatestStart,
 	}
 }
 
+// WithContext returns a copy of the service whose product queries run with the given context,
+// so they are aborted when the context is canceled or its deadline passes
+func (s *ProductService) WithContext(ctx context.Context) *ProductService {
+	clone := *s
+	clone.DB = s.DB.WithContext(ctx)
+	clone.ProductRepo = s.ProductRepo.WithContext(ctx)
+	clone.ProductImageRepo = s.ProductImageRepo.WithContext(ctx)
+	return &clone
+}
+
+// notifyProduct sends a product notification without affecting the operation that triggered it.
+// Notifications are stored in their own database, so an error or even a panic while sending
+// one is logged and returned but never fails the product change.
+func (s *ProductService) notifyProduct(productID uuid.UUID, productName, event string, metadata map[string]interface{}) (err error) {
+	if s.NotificationService == nil {
+		return fmt.Errorf("notification service is not configured")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while sending notification: %v", r)
+		}
+		if err != nil {
+			log.Printf("Failed to send %s notification for product %s: %v", event, productID, err)
+		}
+	}()
+
+	_, err = s.NotificationService.CreateProductNotification(productID, productName, event, metadata)
+	return err
+}
+
 // ProductResult represents the result of a product operation
 type ProductResult struct {
 	Success   bool
@@ -48,6 +86,22 @@ func (s *ProductService) GetProductByID(id uuid.UUID) (*product.Product, error)
 	return s.ProductRepo.GetProductByID(id)
 }
 
+// GetProductsByIDs retrieves the non-deleted products with the given IDs together with their images
+func (s *ProductService) GetProductsByIDs(ids []uuid.UUID) ([]product.Product, error) {
+	return s.ProductRepo.GetProductsByIDs(ids)
+}
+
+// GetProductIncludingDeleted retrieves a product by ID even when it has been deleted
+func (s *ProductService) GetProductIncludingDeleted(id uuid.UUID) (*product.Product, error) {
+	return s.ProductRepo.GetProductIncludingDeleted(id)
+}
+
+// GetProductsByIDsIncludingDeleted retrieves the products with the given IDs together with their
+// images, including deleted ones
+func (s *ProductService) GetProductsByIDsIncludingDeleted(ids []uuid.UUID) ([]product.Product, error) {
+	return s.ProductRepo.GetProductsByIDsIncludingDeleted(ids)
+}
+
 // GetProductBySKU retrieves a product by SKU
 func (s *ProductService) GetProductBySKU(sku string) (*product.Product, error) {
 	return s.ProductRepo.GetProductBySKU(sku)
@@ -121,7 +175,7 @@ func (s *ProductService) CreateProduct(name, description, sku, category, imageUR
 			"sku":          p.SKU,
 			"category":     p.Category,
 		}
-		s.NotificationService.CreateProductNotification(p.ID, p.Name, "created", metadata)
+		s.notifyProduct(p.ID, p.Name, "created", metadata)
 	}
 
 	return &ProductResult{
@@ -188,7 +242,7 @@ func (s *ProductService) UpdateProduct(id uuid.UUID, name, description, sku, cat
 			"sku":          p.SKU,
 			"category":     p.Category,
 		}
-		s.NotificationService.CreateProductNotification(p.ID, p.Name, "updated", metadata)
+		s.notifyProduct(p.ID, p.Name, "updated", metadata)
 	}
 
 	return &ProductResult{
@@ -229,7 +283,7 @@ func (s *ProductService) DeleteProduct(id uuid.UUID) (*ProductResult, error) {
 			"sku":          p.SKU,
 			"category":     p.Category,
 		}
-		s.NotificationService.CreateProductNotification(p.ID, p.Name, "deleted", metadata)
+		s.notifyProduct(p.ID, p.Name, "deleted", metadata)
 	}
 
 	return &ProductResult{
@@ -241,6 +295,53 @@ func (s *ProductService) DeleteProduct(id uuid.UUID) (*ProductResult, error) {
 	}, nil
 }
 
+// RestoreProduct restores a deleted product, making it visible and orderable again
+func (s *ProductService) RestoreProduct(id uuid.UUID) (*ProductResult, error) {
+	p, err := s.ProductRepo.GetProductIncludingDeleted(id)
+	if err != nil {
+		return &ProductResult{
+			Success: false,
+			Message: "Product restore failed",
+			Error:   "Product not found",
+		}, notFoundError("product", err)
+	}
+	if !p.DeletedAt.Valid {
+		err := conflictError("product is not deleted")
+		return &ProductResult{
+			Success: false,
+			Message: "Product restore failed",
+			Error:   err.Error(),
+		}, err
+	}
+
+	if err := s.ProductRepo.RestoreProduct(id); err != nil {
+		return &ProductResult{
+			Success: false,
+			Message: "Product restore failed",
+			Error:   "Error restoring product",
+		}, notFoundError("product", err)
+	}
+
+	// Send notification
+	if s.NotificationService != nil {
+		metadata := map[string]interface{}{
+			"product_id":   p.ID.String(),
+			"product_name": p.Name,
+			"sku":          p.SKU,
+			"category":     p.Category,
+		}
+		s.notifyProduct(p.ID, p.Name, "restored", metadata)
+	}
+
+	return &ProductResult{
+		Success:   true,
+		Message:   "Product restored successfully",
+		ProductID: p.ID,
+		Name:      p.Name,
+		SKU:       p.SKU,
+	}, nil
+}
+
 // InventoryResult represents the result of an inventory operation
 type InventoryResult struct {
 	Success     bool
@@ -251,11 +352,41 @@ type InventoryResult struct {
 	Quantity    int
 }
 
+// StockEvaluation represents the stock level evaluation of a single inventory
+type StockEvaluation struct {
+	InventoryID       uuid.UUID
+	Size              string
+	Color             string
+	Quantity          int
+	LowStockThreshold int
+	Event             string
+	Notified          bool
+}
+
+// StockEvaluationResult represents the result of evaluating the stock levels of a product
+type StockEvaluationResult struct {
+	Success     bool
+	Message     string
+	Error       string
+	ProductID   uuid.UUID
+	Evaluations []StockEvaluation
+}
+
 // GetInventoryByID retrieves an inventory by ID
 func (s *ProductService) GetInventoryByID(id uuid.UUID) (*product.Inventory, error) {
 	return s.ProductRepo.GetInventoryByID(id)
 }
 
+// GetInventoryIncludingDeleted retrieves an inventory by ID even when it or its product has been deleted
+func (s *ProductService) GetInventoryIncludingDeleted(id uuid.UUID) (*product.Inventory, error) {
+	return s.ProductRepo.GetInventoryIncludingDeleted(id)
+}
+
+// GetInventoriesByIDs retrieves the inventories with the given IDs, including deleted ones
+func (s *ProductService) GetInventoriesByIDs(ids []uuid.UUID) ([]product.Inventory, error) {
+	return s.ProductRepo.GetInventoriesByIDs(ids)
+}
+
 // GetInventoriesByProductID retrieves all inventories for a product
 func (s *ProductService) GetInventoriesByProductID(productID uuid.UUID) ([]product.Inventory, error) {
 	return s.ProductRepo.GetInventoriesByProductID(productID)
@@ -263,6 +394,10 @@ func (s *ProductService) GetInventoriesByProductID(productID uuid.UUID) ([]produ
 
 // CheckInventoryAvailability checks if there is enough inventory for the given quantity
 func (s *ProductService) CheckInventoryAvailability(inventoryID uuid.UUID, quantity int) (bool, error) {
+	if quantity < 1 {
+		return false, validationError("quantity must be at least 1")
+	}
+
 	inventory, err := s.ProductRepo.GetInventoryByID(inventoryID)
 	if err != nil {
 		return false, err
@@ -270,8 +405,26 @@ func (s *ProductService) CheckInventoryAvailability(inventoryID uuid.UUID, quant
 	return inventory.Quantity >= quantity, nil
 }
 
-// CreateInventory creates a new inventory
-func (s *ProductService) CreateInventory(productID uuid.UUID, size, color string, quantity int, location string) (*InventoryResult, error) {
+// Causes of a stock change reported in the metadata of stock alert notifications
+const (
+	StockCauseOrder            = "order"
+	StockCauseManualAdjustment = "manual_adjustment"
+	StockCauseTransfer         = "transfer"
+	StockCauseRestock          = "restock"
+)
+
+// withStockCause adds the cause of a stock change and the acting user, if known, to stock alert metadata
+func withStockCause(metadata map[string]interface{}, cause string, actorID *uuid.UUID) map[string]interface{} {
+	metadata["cause"] = cause
+	if actorID != nil {
+		metadata["actor_id"] = actorID.String()
+	}
+	return metadata
+}
+
+// CreateInventory creates a new inventory. A nil lowStockThreshold uses product.DefaultLowStockThreshold.
+// actorID is the user creating it, if known.
+func (s *ProductService) CreateInventory(productID uuid.UUID, size, color string, quantity int, location string, lowStockThreshold *int, actorID *uuid.UUID) (*InventoryResult, error) {
 	// Validate input
 	if productID == uuid.Nil {
 		return &InventoryResult{
@@ -281,6 +434,18 @@ func (s *ProductService) CreateInventory(productID uuid.UUID, size, color string
 		}, fmt.Errorf("product ID is required")
 	}
 
+	threshold := product.DefaultLowStockThreshold
+	if lowStockThreshold != nil {
+		if *lowStockThreshold < 0 {
+			return &InventoryResult{
+				Success: false,
+				Message: "Inventory creation failed",
+				Error:   "Low stock threshold cannot be negative",
+			}, validationError("low stock threshold cannot be negative")
+		}
+		threshold = *lowStockThreshold
+	}
+
 	// Check if product exists
 	p, err := s.ProductRepo.GetProductByID(productID)
 	if err != nil {
@@ -291,13 +456,40 @@ func (s *ProductService) CreateInventory(productID uuid.UUID, size, color string
 		}, err
 	}
 
+	// Check the size and color against the variant options of the product
+	sizeOption, err := s.resolveVariantOption(productID, product.AttributeSize, size)
+	if err != nil {
+		return &InventoryResult{
+			Success: false,
+			Message: "Inventory creation failed",
+			Error:   err.Error(),
+		}, err
+	}
+	colorOption, err := s.resolveVariantOption(productID, product.AttributeColor, color)
+	if err != nil {
+		return &InventoryResult{
+			Success: false,
+			Message: "Inventory creation failed",
+			Error:   err.Error(),
+		}, err
+	}
+
 	// Create inventory
 	inventory := &product.Inventory{
-		ProductID: productID,
-		Size:      size,
-		Color:     color,
-		Quantity:  quantity,
-		Location:  location,
+		ProductID:         productID,
+		Size:              size,
+		Color:             color,
+		Quantity:          quantity,
+		Location:          location,
+		LowStockThreshold: threshold,
+	}
+	if sizeOption != nil {
+		inventory.Size = sizeOption.Value
+		inventory.SizeOptionID = &sizeOption.ID
+	}
+	if colorOption != nil {
+		inventory.Color = colorOption.Value
+		inventory.ColorOptionID = &colorOption.ID
 	}
 
 	// Save inventory
@@ -309,23 +501,24 @@ func (s *ProductService) CreateInventory(productID uuid.UUID, size, color string
 		}, err
 	}
 
-	// Send notification if quantity is low
-	if s.NotificationService != nil && quantity <= 5 {
-		metadata := map[string]interface{}{
-			"product_id":   p.ID.String(),
-			"product_name": p.Name,
-			"inventory_id": inventory.ID.String(),
-			"quantity":     quantity,
-			"size":         size,
-			"color":        color,
-		}
+	// Record the initial stock
+	if quantity > 0 {
+		s.recordInventoryTransaction(inventory.ID, quantity, inventory.Quantity, product.TransactionInbound, product.ReasonPurchase, "Initial stock", actorID)
+	}
 
-		event := "low_stock"
-		if quantity == 0 {
-			event = "out_of_stock"
+	// Send notification if quantity is at or below the threshold of the inventory
+	if event := stockAlertEvent(quantity, threshold); s.NotificationService != nil && event != "" {
+		metadata := map[string]interface{}{
+			"product_id":          p.ID.String(),
+			"product_name":        p.Name,
+			"inventory_id":        inventory.ID.String(),
+			"quantity":            quantity,
+			"size":                inventory.Size,
+			"color":               inventory.Color,
+			"low_stock_threshold": threshold,
 		}
 
-		s.NotificationService.CreateProductNotification(p.ID, p.Name, event, metadata)
+		s.notifyProduct(p.ID, p.Name, event, withStockCause(metadata, StockCauseRestock, actorID))
 	}
 
 	return &InventoryResult{
@@ -337,8 +530,89 @@ func (s *ProductService) CreateInventory(productID uuid.UUID, size, color string
 	}, nil
 }
 
-// UpdateInventory updates an existing inventory
-func (s *ProductService) UpdateInventory(id uuid.UUID, size, color string, quantity *int, location string) (*InventoryResult, error) {
+// EvaluateStock checks every inventory of a product against its low stock threshold and
+// raises low/out-of-stock alerts for those that are currently low. Inventories that already
+// have an unread alert for the same event are not alerted again.
+func (s *ProductService) EvaluateStock(productID uuid.UUID) (*StockEvaluationResult, error) {
+	p, err := s.ProductRepo.GetProductByID(productID)
+	if err != nil {
+		return &StockEvaluationResult{
+			Success: false,
+			Message: "Stock evaluation failed",
+			Error:   "Product not found",
+		}, err
+	}
+
+	result := &StockEvaluationResult{
+		ProductID:   p.ID,
+		Evaluations: make([]StockEvaluation, 0, len(p.Inventory)),
+	}
+
+	notified := 0
+	for _, inv := range p.Inventory {
+		evaluation := StockEvaluation{
+			InventoryID:       inv.ID,
+			Size:              inv.Size,
+			Color:             inv.Color,
+			Quantity:          inv.Quantity,
+			LowStockThreshold: inv.LowStockThreshold,
+			Event:             stockAlertEvent(inv.Quantity, inv.LowStockThreshold),
+		}
+
+		if evaluation.Event != "" && s.NotificationService != nil {
+			pending, err := s.NotificationService.HasUnreadStockAlert(inv.ID, evaluation.Event)
+			if err != nil {
+				fmt.Printf("Error checking existing stock alerts for inventory %s: %v\n", inv.ID, err)
+			}
+
+			if err == nil && !pending {
+				metadata := map[string]interface{}{
+					"product_id":          p.ID.String(),
+					"product_name":        p.Name,
+					"inventory_id":        inv.ID.String(),
+					"quantity":            inv.Quantity,
+					"low_stock_threshold": inv.LowStockThreshold,
+					"size":                inv.Size,
+					"color":               inv.Color,
+				}
+
+				if err := s.notifyProduct(p.ID, p.Name, evaluation.Event, metadata); err == nil {
+					evaluation.Notified = true
+					notified++
+				}
+			}
+		}
+
+		result.Evaluations = append(result.Evaluations, evaluation)
+	}
+
+	result.Success = true
+	result.Message = fmt.Sprintf("Stock evaluated for %d inventories, %d alerts sent", len(result.Evaluations), notified)
+	return result, nil
+}
+
+// stockAlertEvent returns the stock alert event for a quantity, or an empty string if stock is sufficient
+func stockAlertEvent(quantity, threshold int) string {
+	if quantity <= 0 {
+		return "out_of_stock"
+	}
+	if quantity <= threshold {
+		return "low_stock"
+	}
+	return ""
+}
+
+// UpdateInventory updates an existing inventory. Nil quantity and lowStockThreshold leave them unchanged.
+// actorID is the user making the change, if known.
+func (s *ProductService) UpdateInventory(id uuid.UUID, size, color string, quantity *int, location string, lowStockThreshold *int, actorID *uuid.UUID) (*InventoryResult, error) {
+	if lowStockThreshold != nil && *lowStockThreshold < 0 {
+		return &InventoryResult{
+			Success: false,
+			Message: "Inventory update failed",
+			Error:   "Low stock threshold cannot be negative",
+		}, validationError("low stock threshold cannot be negative")
+	}
+
 	// Get the inventory
 	inventory, err := s.ProductRepo.GetInventoryByID(id)
 	if err != nil {
@@ -359,15 +633,38 @@ func (s *ProductService) UpdateInventory(id uuid.UUID, size, color string, quant
 		}, err
 	}
 
-	// Update fields if provided
+	// Update fields if provided, checking the size and color against the variant options of the product
 	if size != "" {
-		inventory.Size = size
+		option, err := s.resolveVariantOption(p.ID, product.AttributeSize, size)
+		if err != nil {
+			return &InventoryResult{
+				Success: false,
+				Message: "Inventory update failed",
+				Error:   err.Error(),
+			}, err
+		}
+		inventory.Size, inventory.SizeOptionID = size, nil
+		if option != nil {
+			inventory.Size, inventory.SizeOptionID = option.Value, &option.ID
+		}
 	}
 	if color != "" {
-		inventory.Color = color
+		option, err := s.resolveVariantOption(p.ID, product.AttributeColor, color)
+		if err != nil {
+			return &InventoryResult{
+				Success: false,
+				Message: "Inventory update failed",
+				Error:   err.Error(),
+			}, err
+		}
+		inventory.Color, inventory.ColorOptionID = color, nil
+		if option != nil {
+			inventory.Color, inventory.ColorOptionID = option.Value, &option.ID
+		}
 	}
 
 	oldQuantity := inventory.Quantity
+	oldEvent := stockAlertEvent(oldQuantity, inventory.LowStockThreshold)
 
 	if quantity != nil {
 		inventory.Quantity = *quantity
@@ -375,6 +672,9 @@ func (s *ProductService) UpdateInventory(id uuid.UUID, size, color string, quant
 	if location != "" {
 		inventory.Location = location
 	}
+	if lowStockThreshold != nil {
+		inventory.LowStockThreshold = *lowStockThreshold
+	}
 
 	// Save inventory
 	if err := s.ProductRepo.UpdateInventory(inventory); err != nil {
@@ -385,25 +685,27 @@ func (s *ProductService) UpdateInventory(id uuid.UUID, size, color string, quant
 		}, err
 	}
 
+	// Record the stock change
+	if delta := inventory.Quantity - oldQuantity; delta != 0 {
+		s.recordInventoryTransaction(inventory.ID, delta, inventory.Quantity, product.TransactionAdjustment, product.ReasonStockCount, "Inventory quantity updated", actorID)
+	}
+
 	// Send notification if quantity changed to low or zero
 	if s.NotificationService != nil && quantity != nil {
-		// Check if quantity changed significantly
-		if (oldQuantity > 5 && *quantity <= 5) || (oldQuantity > 0 && *quantity == 0) {
+		// Check if quantity dropped to or below the threshold of the inventory, or ran out
+		event := stockAlertEvent(*quantity, inventory.LowStockThreshold)
+		if oldQuantity > 0 && event != "" && event != oldEvent {
 			metadata := map[string]interface{}{
-				"product_id":   p.ID.String(),
-				"product_name": p.Name,
-				"inventory_id": inventory.ID.String(),
-				"quantity":     *quantity,
-				"size":         inventory.Size,
-				"color":        inventory.Color,
-			}
-
-			event := "low_stock"
-			if *quantity == 0 {
-				event = "out_of_stock"
+				"product_id":          p.ID.String(),
+				"product_name":        p.Name,
+				"inventory_id":        inventory.ID.String(),
+				"quantity":            *quantity,
+				"size":                inventory.Size,
+				"color":               inventory.Color,
+				"low_stock_threshold": inventory.LowStockThreshold,
 			}
 
-			s.NotificationService.CreateProductNotification(p.ID, p.Name, event, metadata)
+			s.notifyProduct(p.ID, p.Name, event, withStockCause(metadata, StockCauseManualAdjustment, actorID))
 		} else if oldQuantity == 0 && *quantity > 0 {
 			// Back in stock notification
 			metadata := map[string]interface{}{
@@ -415,7 +717,7 @@ func (s *ProductService) UpdateInventory(id uuid.UUID, size, color string, quant
 				"color":        inventory.Color,
 			}
 
-			s.NotificationService.CreateProductNotification(p.ID, p.Name, "back_in_stock", metadata)
+			s.notifyProduct(p.ID, p.Name, "back_in_stock", withStockCause(metadata, StockCauseManualAdjustment, actorID))
 		}
 	}
 
@@ -458,6 +760,96 @@ func (s *ProductService) DeleteInventory(id uuid.UUID) (*InventoryResult, error)
 	}, nil
 }
 
+// GetVariantOptions retrieves the variant options of a product
+func (s *ProductService) GetVariantOptions(productID uuid.UUID) ([]product.VariantOption, error) {
+	if _, err := s.ProductRepo.GetProductByID(productID); err != nil {
+		return nil, notFoundError("product", err)
+	}
+	return s.ProductRepo.GetVariantOptions(productID)
+}
+
+// CreateVariantOption defines a value the product allows for a variant attribute. Values are compared
+// case-insensitively, so "red" is rejected when "Red" is already defined.
+func (s *ProductService) CreateVariantOption(productID uuid.UUID, attribute product.VariantAttribute, value string) (*product.VariantOption, error) {
+	if !attribute.IsValid() {
+		return nil, validationError(fmt.Sprintf("unsupported variant attribute %q", attribute))
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, validationError("variant option value is required")
+	}
+
+	options, err := s.GetVariantOptions(productID)
+	if err != nil {
+		return nil, err
+	}
+	for _, option := range options {
+		if option.Attribute == attribute && strings.EqualFold(option.Value, value) {
+			return nil, conflictError(fmt.Sprintf("%s %q is already defined as %q", attribute, value, option.Value))
+		}
+	}
+
+	option := &product.VariantOption{
+		ProductID: productID,
+		Attribute: attribute,
+		Value:     value,
+	}
+	if err := s.ProductRepo.CreateVariantOption(option); err != nil {
+		return nil, err
+	}
+	return option, nil
+}
+
+// DeleteVariantOption deletes a variant option of a product. Options still used by an inventory
+// cannot be deleted.
+func (s *ProductService) DeleteVariantOption(productID, optionID uuid.UUID) error {
+	option, err := s.ProductRepo.GetVariantOptionByID(optionID)
+	if err != nil {
+		return notFoundError("variant option", err)
+	}
+	if option.ProductID != productID {
+		return fmt.Errorf("variant option %w", ErrNotFound)
+	}
+
+	used, err := s.ProductRepo.CountInventoriesWithOption(optionID)
+	if err != nil {
+		return err
+	}
+	if used > 0 {
+		return conflictError(fmt.Sprintf("%s %q is used by %d inventories", option.Attribute, option.Value, used))
+	}
+
+	return s.ProductRepo.DeleteVariantOption(optionID)
+}
+
+// resolveVariantOption matches a size or color of an inventory against the options the product
+// defines for the attribute and returns the matching option, or nil if the product defines none.
+// An empty value is accepted as "not set"; any other value must match an option case-insensitively.
+func (s *ProductService) resolveVariantOption(productID uuid.UUID, attribute product.VariantAttribute, value string) (*product.VariantOption, error) {
+	options, err := s.ProductRepo.GetVariantOptions(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	value = strings.TrimSpace(value)
+	var allowed []string
+	for i := range options {
+		if options[i].Attribute != attribute {
+			continue
+		}
+		if strings.EqualFold(options[i].Value, value) {
+			return &options[i], nil
+		}
+		allowed = append(allowed, options[i].Value)
+	}
+
+	if len(allowed) == 0 || value == "" {
+		return nil, nil
+	}
+	return nil, validationError(fmt.Sprintf("%s %q is not defined for the product, allowed: %s", attribute, value, strings.Join(allowed, ", ")))
+}
+
 // PriceResult represents the result of a price operation
 type PriceResult struct {
 	Success   bool
@@ -481,7 +873,170 @@ func (s *ProductService) GetPricesByProductID(productID uuid.UUID) ([]product.Pr
 
 // GetCurrentPrice retrieves the current valid price for a product
 func (s *ProductService) GetCurrentPrice(productID uuid.UUID) (*product.Price, error) {
-	return s.ProductRepo.GetCurrentPrice(productID)
+	return s.GetPriceAt(productID, time.Now())
+}
+
+// GetCurrentPrices retrieves the current price of each of the given products in one query, keyed by
+// product ID. Products without a current price, or whose valid prices cannot be told apart under
+// the PriceSelection, are left out.
+func (s *ProductService) GetCurrentPrices(productIDs []uuid.UUID) (map[uuid.UUID]*product.Price, error) {
+	at := time.Now()
+	prices, err := s.ProductRepo.GetPricesAtByProductIDs(productIDs, at)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prices come grouped by product, newest first within each product
+	byProduct := make(map[uuid.UUID][]product.Price)
+	for _, price := range prices {
+		byProduct[price.ProductID] = append(byProduct[price.ProductID], price)
+	}
+
+	current := make(map[uuid.UUID]*product.Price, len(byProduct))
+	for productID, valid := range byProduct {
+		if len(valid) > 1 {
+			log.Printf("Warning: product %s has %d prices valid at %s, selecting by %q",
+				productID, len(valid), at.Format(time.RFC3339), s.PriceSelection)
+		}
+		if price, err := product.SelectPrice(valid, s.PriceSelection); err == nil {
+			current[productID] = price
+		}
+	}
+
+	return current, nil
+}
+
+// GetPriceAt retrieves the price of a product that is valid at the given time.
+// When several prices are valid at once, PriceSelection decides which one is used.
+func (s *ProductService) GetPriceAt(productID uuid.UUID, at time.Time) (*product.Price, error) {
+	prices, err := s.ProductRepo.GetPricesAt(productID, at)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(prices) > 1 {
+		log.Printf("Warning: product %s has %d prices valid at %s, selecting by %q",
+			productID, len(prices), at.Format(time.RFC3339), s.PriceSelection)
+	}
+
+	price, err := product.SelectPrice(prices, s.PriceSelection)
+	if errors.Is(err, product.ErrMultiplePrices) {
+		return nil, conflictError(fmt.Sprintf("product has %d prices valid at the same time", len(prices)))
+	}
+	return price, err
+}
+
+// GetUnitPriceForQuantity retrieves the unit price charged when ordering quantity units of a product:
+// the best quantity break of the current price the quantity reaches, or the current price itself
+func (s *ProductService) GetUnitPriceForQuantity(productID uuid.UUID, quantity int) (float64, error) {
+	_, unitPrice, err := s.GetPriceForQuantity(productID, quantity)
+	return unitPrice, err
+}
+
+// GetPriceForQuantity retrieves the current price of a product together with the unit price charged
+// when ordering quantity units of it. The unit price is in the currency of the price.
+func (s *ProductService) GetPriceForQuantity(productID uuid.UUID, quantity int) (*product.Price, float64, error) {
+	price, err := s.GetCurrentPrice(productID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tiers, err := s.ProductRepo.GetPriceTiers(price.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return price, product.UnitPriceForQuantity(*price, tiers, quantity), nil
+}
+
+// GetPriceTiers retrieves the quantity breaks of a price, ordered by minimum quantity
+func (s *ProductService) GetPriceTiers(priceID uuid.UUID) ([]product.PriceTier, error) {
+	if _, err := s.ProductRepo.GetPriceByID(priceID); err != nil {
+		return nil, notFoundError("price", err)
+	}
+	return s.ProductRepo.GetPriceTiers(priceID)
+}
+
+// CreatePriceTier adds a quantity break to a price: lines of at least minQuantity units are charged
+// unitPrice per unit, which must be below the base price
+func (s *ProductService) CreatePriceTier(priceID uuid.UUID, minQuantity int, unitPrice float64) (*product.PriceTier, error) {
+	price, err := s.ProductRepo.GetPriceByID(priceID)
+	if err != nil {
+		return nil, notFoundError("price", err)
+	}
+
+	tier := &product.PriceTier{
+		PriceID:     priceID,
+		MinQuantity: minQuantity,
+		UnitPrice:   unitPrice,
+	}
+	if err := s.validatePriceTier(price, tier); err != nil {
+		return nil, err
+	}
+
+	if err := s.ProductRepo.CreatePriceTier(tier); err != nil {
+		return nil, err
+	}
+	return tier, nil
+}
+
+// UpdatePriceTier updates the minimum quantity and unit price of a quantity break; nil values are
+// left unchanged
+func (s *ProductService) UpdatePriceTier(id uuid.UUID, minQuantity *int, unitPrice *float64) (*product.PriceTier, error) {
+	tier, err := s.ProductRepo.GetPriceTierByID(id)
+	if err != nil {
+		return nil, notFoundError("price tier", err)
+	}
+	price, err := s.ProductRepo.GetPriceByID(tier.PriceID)
+	if err != nil {
+		return nil, notFoundError("price", err)
+	}
+
+	if minQuantity != nil {
+		tier.MinQuantity = *minQuantity
+	}
+	if unitPrice != nil {
+		tier.UnitPrice = *unitPrice
+	}
+	if err := s.validatePriceTier(price, tier); err != nil {
+		return nil, err
+	}
+
+	if err := s.ProductRepo.UpdatePriceTier(tier); err != nil {
+		return nil, err
+	}
+	return tier, nil
+}
+
+// DeletePriceTier deletes a quantity break of a price
+func (s *ProductService) DeletePriceTier(id uuid.UUID) error {
+	if _, err := s.ProductRepo.GetPriceTierByID(id); err != nil {
+		return notFoundError("price tier", err)
+	}
+	return s.ProductRepo.DeletePriceTier(id)
+}
+
+// validatePriceTier checks a quantity break against its price and the other breaks of the price
+func (s *ProductService) validatePriceTier(price *product.Price, tier *product.PriceTier) error {
+	if tier.MinQuantity < 2 {
+		return validationError("minimum quantity must be at least 2")
+	}
+	if tier.UnitPrice <= 0 {
+		return validationError("unit price must be greater than zero")
+	}
+	if tier.UnitPrice >= price.Price {
+		return validationError(fmt.Sprintf("unit price must be below the base price of %.2f", price.Price))
+	}
+
+	tiers, err := s.ProductRepo.GetPriceTiers(price.ID)
+	if err != nil {
+		return err
+	}
+	for _, other := range tiers {
+		if other.ID != tier.ID && other.MinQuantity == tier.MinQuantity {
+			return conflictError(fmt.Sprintf("price already has a tier from %d units", tier.MinQuantity))
+		}
+	}
+	return nil
 }
 
 // CreatePrice creates a new price
@@ -624,30 +1179,219 @@ func (s *ProductService) DeletePrice(id uuid.UUID) (*PriceResult, error) {
 	}, nil
 }
 
-// ReserveInventory reduces the inventory quantity by the given amount
-func (s *ProductService) ReserveInventory(inventoryID uuid.UUID, quantity int) error {
-	inventory, err := s.ProductRepo.GetInventoryByID(inventoryID)
+// ReserveInventory reduces the inventory quantity by the given amount for an order
+// and records the reservation in the inventory transactions. The stock is checked and
+// reduced atomically; an ErrConflict is returned when not enough is left. A low/out-of-stock
+// alert is raised when the reservation brings the inventory to a lower stock level.
+func (s *ProductService) ReserveInventory(inventoryID uuid.UUID, quantity int, orderID uuid.UUID) error {
+	inventory, reserved, err := s.ProductRepo.ReserveInventoryQuantity(inventoryID, quantity, &orderID, "order")
 	if err != nil {
 		return err
 	}
+	if !reserved {
+		return conflictError("not enough inventory")
+	}
+
+	remaining := inventory.Quantity - quantity
+	event := stockAlertEvent(remaining, inventory.LowStockThreshold)
+	if s.NotificationService != nil && event != "" && event != stockAlertEvent(inventory.Quantity, inventory.LowStockThreshold) {
+		if p, err := s.ProductRepo.GetProductByID(inventory.ProductID); err == nil {
+			metadata := map[string]interface{}{
+				"product_id":          p.ID.String(),
+				"product_name":        p.Name,
+				"inventory_id":        inventory.ID.String(),
+				"quantity":            remaining,
+				"low_stock_threshold": inventory.LowStockThreshold,
+				"size":                inventory.Size,
+				"color":               inventory.Color,
+				"reference_type":      "order",
+				"reference_id":        orderID.String(),
+			}
+			s.notifyProduct(p.ID, p.Name, event, withStockCause(metadata, StockCauseOrder, nil))
+		}
+	}
+
+	return nil
+}
+
+// TransferInventory moves quantity units from one inventory to another of the same product variant,
+// e.g. between warehouse locations. Both stock changes and the transfer record are written in one
+// transaction; the transfer is rejected and nothing changes when the source holds fewer units.
+// actorID is the user making the transfer, if known.
+func (s *ProductService) TransferInventory(fromInventoryID, toInventoryID uuid.UUID, quantity int, notes string, actorID *uuid.UUID) (*product.InventoryTransfer, error) {
+	if quantity <= 0 {
+		return nil, validationError("quantity must be greater than zero")
+	}
+	if fromInventoryID == toInventoryID {
+		return nil, validationError("source and destination inventories must differ")
+	}
+
+	from, err := s.ProductRepo.GetInventoryByID(fromInventoryID)
+	if err != nil {
+		return nil, notFoundError("source inventory", err)
+	}
+	to, err := s.ProductRepo.GetInventoryByID(toInventoryID)
+	if err != nil {
+		return nil, notFoundError("destination inventory", err)
+	}
+	if from.ProductID != to.ProductID || from.Size != to.Size || from.Color != to.Color {
+		return nil, validationError("stock can only be transferred between inventories of the same product, size and color")
+	}
+
+	transfer := &product.InventoryTransfer{
+		FromInventoryID: fromInventoryID,
+		ToInventoryID:   toInventoryID,
+		Quantity:        quantity,
+		Notes:           strings.TrimSpace(notes),
+	}
+	transfer.CreatedBy = actorID
 
-	if inventory.Quantity < quantity {
-		return fmt.Errorf("not enough inventory")
+	source, _, transferred, err := s.ProductRepo.TransferInventoryQuantity(transfer)
+	if err != nil {
+		return nil, notFoundError("inventory", err)
+	}
+	if !transferred {
+		return nil, conflictError(fmt.Sprintf("not enough inventory: %d units available at the source", source.Quantity))
+	}
+
+	// Alert when the transfer leaves the source low on stock
+	remaining := source.Quantity - quantity
+	event := stockAlertEvent(remaining, source.LowStockThreshold)
+	if s.NotificationService != nil && event != "" && event != stockAlertEvent(source.Quantity, source.LowStockThreshold) {
+		if p, err := s.ProductRepo.GetProductByID(source.ProductID); err == nil {
+			metadata := map[string]interface{}{
+				"product_id":          p.ID.String(),
+				"product_name":        p.Name,
+				"inventory_id":        source.ID.String(),
+				"quantity":            remaining,
+				"low_stock_threshold": source.LowStockThreshold,
+				"size":                source.Size,
+				"color":               source.Color,
+				"reference_type":      "transfer",
+				"reference_id":        transfer.ID.String(),
+			}
+			s.notifyProduct(p.ID, p.Name, event, withStockCause(metadata, StockCauseTransfer, actorID))
+		}
+	}
+
+	return transfer, nil
+}
+
+// ReleaseInventory increases the inventory quantity by the given amount for an order
+// and records the release in the inventory transactions
+func (s *ProductService) ReleaseInventory(inventoryID uuid.UUID, quantity int, orderID uuid.UUID) error {
+	return s.ProductRepo.UpdateInventoryQuantity(inventoryID, quantity,
+		product.TransactionRelease, product.ReasonOrderCancellation, &orderID, "order", "")
+}
+
+// ReturnInventory increases the inventory quantity by the units of an order returned to the
+// warehouse and records the return in the inventory transactions
+func (s *ProductService) ReturnInventory(inventoryID uuid.UUID, quantity int, orderID uuid.UUID) error {
+	return s.ProductRepo.UpdateInventoryQuantity(inventoryID, quantity,
+		product.TransactionRelease, product.ReasonReturn, &orderID, "order", "")
+}
+
+// GetRestocks retrieves the stock increases of an inventory that were not caused by orders, newest first
+func (s *ProductService) GetRestocks(inventoryID uuid.UUID) ([]product.InventoryTransaction, error) {
+	transactions, err := s.ProductRepo.GetRestockTransactions(inventoryID)
+	if err != nil {
+		return nil, notFoundError("inventory", err)
+	}
+	return transactions, nil
+}
+
+// GetMovements retrieves every stock change of an inventory with the balance it left, newest first
+func (s *ProductService) GetMovements(inventoryID uuid.UUID) ([]product.InventoryTransaction, error) {
+	transactions, err := s.ProductRepo.GetInventoryTransactionsByInventoryID(inventoryID)
+	if err != nil {
+		return nil, notFoundError("inventory", err)
 	}
+	return transactions, nil
+}
 
-	inventory.Quantity -= quantity
-	return s.ProductRepo.UpdateInventory(inventory)
+// recordInventoryTransaction records a stock change that has already been applied to an inventory,
+// leaving it at balance. Failures are logged so that the inventory update itself is not rolled back.
+func (s *ProductService) recordInventoryTransaction(inventoryID uuid.UUID, quantity, balance int, txType product.TransactionType, reason product.TransactionReason, notes string, actorID *uuid.UUID) {
+	transaction := &product.InventoryTransaction{
+		InventoryID: inventoryID,
+		Quantity:    quantity,
+		Type:        txType,
+		Reason:      reason,
+		Notes:       notes,
+		Balance:     &balance,
+	}
+	transaction.CreatedBy = actorID
+	if err := s.ProductRepo.CreateInventoryTransaction(transaction); err != nil {
+		log.Printf("Failed to record inventory transaction for inventory %s: %v", inventoryID, err)
+	}
 }
 
-// ReleaseInventory increases the inventory quantity by the given amount
-func (s *ProductService) ReleaseInventory(inventoryID uuid.UUID, quantity int) error {
+// AdjustSoldCount adds delta units to the sold count of the product an inventory belongs to.
+// A negative delta reverses previously counted sales.
+func (s *ProductService) AdjustSoldCount(inventoryID uuid.UUID, delta int) error {
 	inventory, err := s.ProductRepo.GetInventoryByID(inventoryID)
 	if err != nil {
 		return err
 	}
 
-	inventory.Quantity += quantity
-	return s.ProductRepo.UpdateInventory(inventory)
+	return s.ProductRepo.AdjustSoldCount(inventory.ProductID, delta)
+}
+
+// GetFeaturedProducts retrieves the featured products in their featured order
+func (s *ProductService) GetFeaturedProducts() ([]product.Product, error) {
+	return s.ProductRepo.GetFeaturedProducts()
+}
+
+// SetProductFeatured adds a product to the end of the featured list or removes it from the list
+func (s *ProductService) SetProductFeatured(id uuid.UUID, featured bool) (*product.Product, error) {
+	if err := s.ProductRepo.SetFeatured(id, featured); err != nil {
+		return nil, notFoundError("product", err)
+	}
+
+	p, err := s.ProductRepo.GetProductByID(id)
+	if err != nil {
+		return nil, notFoundError("product", err)
+	}
+	return p, nil
+}
+
+// ReorderFeaturedProducts sets the featured order to the order of productIDs. Every product
+// must be featured and appear once; featured products left out keep their relative order
+// after the listed ones.
+func (s *ProductService) ReorderFeaturedProducts(productIDs []uuid.UUID) error {
+	if len(productIDs) == 0 {
+		return validationError("at least one product is required")
+	}
+
+	featured, err := s.ProductRepo.GetFeaturedProducts()
+	if err != nil {
+		return err
+	}
+
+	isFeatured := make(map[uuid.UUID]bool, len(featured))
+	for _, p := range featured {
+		isFeatured[p.ID] = true
+	}
+
+	listed := make(map[uuid.UUID]bool, len(productIDs))
+	for _, id := range productIDs {
+		if !isFeatured[id] {
+			return validationError(fmt.Sprintf("product %s is not featured", id))
+		}
+		if listed[id] {
+			return validationError(fmt.Sprintf("product %s is listed more than once", id))
+		}
+		listed[id] = true
+	}
+
+	ordered := append([]uuid.UUID{}, productIDs...)
+	for _, p := range featured {
+		if !listed[p.ID] {
+			ordered = append(ordered, p.ID)
+		}
+	}
+
+	return s.ProductRepo.ReorderFeaturedProducts(ordered)
 }
 
 // ProductImageResult represents the result of a product image operation
@@ -691,7 +1435,7 @@ func (s *ProductService) UploadProductImage(productID uuid.UUID, fileHeader *mul
 			Success: false,
 			Message: "Image upload failed",
 			Error:   "Product not found",
-		}, err
+		}, notFoundError("product", err)
 	}
 
 	subDir := "product-images"
@@ -729,11 +1473,13 @@ func (s *ProductService) UploadProductImage(productID uuid.UUID, fileHeader *mul
 
 	// Create the product image record
 	productImage := &product.ProductImage{
-		ProductID: productID,
-		URL:       uploadResult.URL,
-		Filename:  uploadResult.Filename,
-		IsPrimary: isPrimary,
-		SortOrder: sortOrder,
+		ProductID:    productID,
+		URL:          uploadResult.URL,
+		ThumbnailURL: uploadResult.VariantURL(upload.VariantThumbnail),
+		MediumURL:    uploadResult.VariantURL(upload.VariantMedium),
+		Filename:     uploadResult.Filename,
+		IsPrimary:    isPrimary,
+		SortOrder:    sortOrder,
 	}
 
 	// If this is the first image or marked as primary, make it the primary image
@@ -994,38 +1740,49 @@ func (s *ProductService) DeleteProductImage(imageID, productID uuid.UUID) (*Prod
 	}, nil
 }
 
-// GetPrimaryImageURL retrieves the URL of the primary image for a product
+// GetPrimaryImageURL retrieves the URL of the primary image for a product. When the product has
+// no image rows it falls back to the product ImageURL, which may point to an external image.
 func (s *ProductService) GetPrimaryImageURL(productID uuid.UUID) string {
 	images, err := s.ProductImageRepo.GetImagesByProductID(productID)
-	if err != nil || len(images) == 0 {
+	if err != nil {
 		return ""
 	}
-
-	// Find the primary image
-	for _, img := range images {
-		if img.IsPrimary {
-			return img.URL
-		}
-	}
-
-	// If no primary image is set, return the first image
 	if len(images) > 0 {
-		return images[0].URL
+		return product.PrimaryImageURL(images, "")
 	}
 
-	return ""
+	p, err := s.ProductRepo.GetProductByID(productID)
+	if err != nil {
+		return ""
+	}
+	return p.PrimaryImageURL()
 }
 
 // MultipleProductImageResult represents the result of uploading multiple product images
 type MultipleProductImageResult struct {
-	Success   bool                  `json:"success"`
-	Message   string                `json:"message"`
-	Error     string                `json:"error,omitempty"`
-	ProductID uuid.UUID             `json:"product_id"`
-	Images    []*ProductImageResult `json:"images"`
+	Success   bool                      `json:"success"`
+	Message   string                    `json:"message"`
+	Error     string                    `json:"error,omitempty"`
+	ProductID uuid.UUID                 `json:"product_id"`
+	Images    []*ProductImageResult     `json:"images"`
+	Files     []*ProductImageFileResult `json:"files"`
+	Failed    int                       `json:"failed"`
+}
+
+// ProductImageFileResult represents the outcome of a single file of a multiple image upload
+type ProductImageFileResult struct {
+	Index     int        `json:"index"`
+	Filename  string     `json:"filename"`
+	Success   bool       `json:"success"`
+	Error     string     `json:"error,omitempty"`
+	ImageID   *uuid.UUID `json:"image_id,omitempty"`
+	URL       string     `json:"url,omitempty"`
+	IsPrimary bool       `json:"is_primary"`
 }
 
-// UploadMultipleProductImages uploads multiple images for a product
+// UploadMultipleProductImages uploads multiple images for a product. Files are processed
+// independently: a rejected file is reported in Files and the remaining files are still
+// uploaded. An error is returned only when no file could be processed.
 func (s *ProductService) UploadMultipleProductImages(productID uuid.UUID, fileHeaders []*multipart.FileHeader, makePrimaryIdx int) (*MultipleProductImageResult, error) {
 	// Validate input
 	if productID == uuid.Nil {
@@ -1033,7 +1790,7 @@ func (s *ProductService) UploadMultipleProductImages(productID uuid.UUID, fileHe
 			Success: false,
 			Message: "Image upload failed",
 			Error:   "Product ID is required",
-		}, fmt.Errorf("product ID is required")
+		}, validationError("product ID is required")
 	}
 
 	if len(fileHeaders) == 0 {
@@ -1041,7 +1798,7 @@ func (s *ProductService) UploadMultipleProductImages(productID uuid.UUID, fileHe
 			Success: false,
 			Message: "Image upload failed",
 			Error:   "No files provided",
-		}, fmt.Errorf("no files provided")
+		}, validationError("no files provided")
 	}
 
 	// Check if product exists
@@ -1051,7 +1808,7 @@ func (s *ProductService) UploadMultipleProductImages(productID uuid.UUID, fileHe
 			Success: false,
 			Message: "Image upload failed",
 			Error:   "Product not found",
-		}, err
+		}, notFoundError("product", err)
 	}
 
 	// Get existing images to determine if this is the first upload
@@ -1064,117 +1821,109 @@ func (s *ProductService) UploadMultipleProductImages(productID uuid.UUID, fileHe
 		}, err
 	}
 
-	// No existing images means the first one will be primary by default
-	if len(existingImages) == 0 && makePrimaryIdx < 0 {
-		makePrimaryIdx = 0
-	}
-
-	// Use the subdirectory "dev" for the product images
 	subDir := "product-images"
 
-	// Use the batch upload feature of the upload service for efficiency
-	uploadResult, err := s.UploadService.UploadMultiple(fileHeaders, subDir)
-	if err != nil {
-		return &MultipleProductImageResult{
-			Success: false,
-			Message: "Image upload failed",
-			Error:   err.Error(),
-		}, err
-	}
-
-	// Process each uploaded file and create the database records
-	imageResults := make([]*ProductImageResult, 0, len(uploadResult.Files))
-
 	// Get the current highest sort order
 	sortOrder := 0
-	if len(existingImages) > 0 {
-		// Find the highest sort order
-		for _, img := range existingImages {
-			if img.SortOrder > sortOrder {
-				sortOrder = img.SortOrder
-			}
+	for _, img := range existingImages {
+		if img.SortOrder > sortOrder {
+			sortOrder = img.SortOrder
 		}
 	}
 
-	// If we need to set any file as primary, reset all existing primary flags
-	if makePrimaryIdx >= 0 {
-		// Reset all other images to not primary
-		if len(existingImages) > 0 {
-			for _, img := range existingImages {
-				if img.IsPrimary {
-					img.IsPrimary = false
-					if err := s.ProductImageRepo.UpdateImage(&img); err != nil {
-						// Log error but continue
-						fmt.Printf("Error updating existing image primary flag: %v\n", err)
-					}
-				}
+	// Upload each file on its own so that a rejected file does not abort the others
+	fileResults := make([]*ProductImageFileResult, len(fileHeaders))
+	imageResults := make([]*ProductImageResult, 0, len(fileHeaders))
+	var primaryResult *ProductImageResult
+	var firstErr error
+	for i, fileHeader := range fileHeaders {
+		fileResult := &ProductImageFileResult{Index: i, Filename: fileHeader.Filename}
+		fileResults[i] = fileResult
+
+		uploadedFile, err := s.UploadService.Upload(fileHeader, subDir)
+		if err != nil {
+			fileResult.Error = err.Error()
+			if firstErr == nil {
+				firstErr = err
 			}
+			continue
 		}
-	}
 
-	// Store the product image that should be set as primary
-	var primaryImage *product.ProductImage
-
-	// Create product image records for each uploaded file
-	for i, uploadedFile := range uploadResult.Files {
-		sortOrder++ // Increment for each new image
-
-		// Determine if this image should be primary
-		isPrimary := i == makePrimaryIdx
-
-		// Create the product image record
 		productImage := &product.ProductImage{
-			ProductID: productID,
-			URL:       uploadedFile.URL,
-			Filename:  uploadedFile.Filename,
-			IsPrimary: isPrimary,
-			SortOrder: sortOrder,
+			ProductID:    productID,
+			URL:          uploadedFile.URL,
+			ThumbnailURL: uploadedFile.VariantURL(upload.VariantThumbnail),
+			MediumURL:    uploadedFile.VariantURL(upload.VariantMedium),
+			Filename:     uploadedFile.Filename,
+			SortOrder:    sortOrder + 1,
 		}
-
-		// Save the product image record
 		if err := s.ProductImageRepo.CreateImage(productImage); err != nil {
-			// Log error but continue with other images
-			fmt.Printf("Error saving image record: %v\n", err)
+			log.Printf("Error saving image record for %s: %v", fileHeader.Filename, err)
+			_ = s.UploadService.Delete(uploadedFile.Filename)
+			fileResult.Error = "Error saving image record"
+			if firstErr == nil {
+				firstErr = err
+			}
 			continue
 		}
+		sortOrder++
 
-		// If this is a primary image, keep it for updating the product later
-		if isPrimary {
-			primaryImage = productImage
-		}
+		fileResult.Success = true
+		fileResult.ImageID = &productImage.ID
+		fileResult.URL = productImage.URL
 
-		// Add to results
-		imageResults = append(imageResults, &ProductImageResult{
+		imageResult := &ProductImageResult{
 			Success:   true,
 			Message:   "Image uploaded successfully",
 			ImageID:   productImage.ID,
 			ProductID: productID,
 			URL:       productImage.URL,
 			Filename:  productImage.Filename,
-			IsPrimary: productImage.IsPrimary,
 			SortOrder: productImage.SortOrder,
-		})
-	}
+		}
+		imageResults = append(imageResults, imageResult)
 
-	// If we have a primary image, update the product's main image URL
-	if primaryImage != nil {
-		product, err := s.ProductRepo.GetProductByID(productID)
-		if err == nil {
-			product.ImageURL = primaryImage.URL
-			if err := s.ProductRepo.UpdateProduct(product); err != nil {
-				// Log error but continue
-				fmt.Printf("Error updating product main image: %v\n", err)
-			}
+		if i == makePrimaryIdx {
+			primaryResult = imageResult
 		}
 	}
 
+	failed := len(fileHeaders) - len(imageResults)
 	if len(imageResults) == 0 {
 		return &MultipleProductImageResult{
 			Success:   false,
 			Message:   "All image uploads failed",
 			Error:     "Failed to process any uploaded images",
 			ProductID: productID,
-		}, fmt.Errorf("failed to process any uploaded images")
+			Files:     fileResults,
+			Failed:    failed,
+		}, fmt.Errorf("failed to process any uploaded images: %w", firstErr)
+	}
+
+	// A product without images gets its first uploaded image as primary, even when the
+	// requested primary file was rejected
+	if primaryResult == nil && len(existingImages) == 0 {
+		primaryResult = imageResults[0]
+	}
+
+	if primaryResult != nil {
+		if err := s.ProductImageRepo.SetPrimaryImage(primaryResult.ImageID, productID); err != nil {
+			log.Printf("Error setting primary image: %v", err)
+		} else {
+			primaryResult.IsPrimary = true
+			for _, fileResult := range fileResults {
+				fileResult.IsPrimary = fileResult.ImageID != nil && *fileResult.ImageID == primaryResult.ImageID
+			}
+
+			// Update the product's main image URL
+			product, err := s.ProductRepo.GetProductByID(productID)
+			if err == nil {
+				product.ImageURL = primaryResult.URL
+				if err := s.ProductRepo.UpdateProduct(product); err != nil {
+					log.Printf("Error updating product main image: %v", err)
+				}
+			}
+		}
 	}
 
 	return &MultipleProductImageResult{
@@ -1182,5 +1931,139 @@ func (s *ProductService) UploadMultipleProductImages(productID uuid.UUID, fileHe
 		Message:   fmt.Sprintf("Successfully processed %d out of %d images", len(imageResults), len(fileHeaders)),
 		ProductID: productID,
 		Images:    imageResults,
+		Files:     fileResults,
+		Failed:    failed,
+	}, nil
+}
+
+// BulkProductImageFileResult represents the outcome of a single file of a bulk image upload
+type BulkProductImageFileResult struct {
+	Index     int        `json:"index"`
+	Filename  string     `json:"filename"`
+	SKU       string     `json:"sku"`
+	ProductID *uuid.UUID `json:"product_id,omitempty"`
+	Success   bool       `json:"success"`
+	Error     string     `json:"error,omitempty"`
+	ImageID   *uuid.UUID `json:"image_id,omitempty"`
+	URL       string     `json:"url,omitempty"`
+	IsPrimary bool       `json:"is_primary"`
+}
+
+// BulkProductImageResult represents the result of uploading images for several products at once
+type BulkProductImageResult struct {
+	Success  bool                          `json:"success"`
+	Message  string                        `json:"message"`
+	Error    string                        `json:"error,omitempty"`
+	Files    []*BulkProductImageFileResult `json:"files"`
+	Products int                           `json:"products"`
+	Uploaded int                           `json:"uploaded"`
+	Failed   int                           `json:"failed"`
+}
+
+// ImageFileSKU returns the SKU an image file is mapped to. Files missing from the mapping
+// are matched by their name without extension, e.g. SHIRT-001.jpg belongs to SHIRT-001.
+func ImageFileSKU(filename string, mapping map[string]string) string {
+	if sku, ok := mapping[filename]; ok {
+		return strings.TrimSpace(sku)
+	}
+	return strings.TrimSuffix(filename, filepath.Ext(filename))
+}
+
+// BulkUploadProductImages attaches images to several products resolved by SKU. The files of each
+// product are uploaded together, so a product without images gets the first accepted file as its
+// primary image. Files are processed independently: a file whose SKU matches no product or that
+// is rejected by the upload is reported in Files. An error is returned only when no file could be processed.
+func (s *ProductService) BulkUploadProductImages(fileHeaders []*multipart.FileHeader, mapping map[string]string) (*BulkProductImageResult, error) {
+	if len(fileHeaders) == 0 {
+		return &BulkProductImageResult{
+			Success: false,
+			Message: "Image upload failed",
+			Error:   "No files provided",
+		}, fmt.Errorf("no files provided")
+	}
+
+	// Group the files by product, keeping the upload order
+	fileResults := make([]*BulkProductImageFileResult, len(fileHeaders))
+	productIDs := make(map[string]uuid.UUID)
+	var productOrder []uuid.UUID
+	productFiles := make(map[uuid.UUID][]int)
+	for i, fileHeader := range fileHeaders {
+		sku := ImageFileSKU(fileHeader.Filename, mapping)
+		fileResult := &BulkProductImageFileResult{Index: i, Filename: fileHeader.Filename, SKU: sku}
+		fileResults[i] = fileResult
+
+		if sku == "" {
+			fileResult.Error = "No SKU mapped to this file"
+			continue
+		}
+
+		productID, found := productIDs[sku]
+		if !found {
+			p, err := s.ProductRepo.GetProductBySKU(sku)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					fileResult.Error = fmt.Sprintf("No product found with SKU %s", sku)
+				} else {
+					fileResult.Error = "Error retrieving product"
+				}
+				continue
+			}
+			productID = p.ID
+			productIDs[sku] = productID
+			productOrder = append(productOrder, productID)
+		}
+
+		fileResult.ProductID = &productID
+		productFiles[productID] = append(productFiles[productID], i)
+	}
+
+	uploaded := 0
+	for _, productID := range productOrder {
+		indexes := productFiles[productID]
+		headers := make([]*multipart.FileHeader, len(indexes))
+		for i, index := range indexes {
+			headers[i] = fileHeaders[index]
+		}
+
+		result, err := s.UploadMultipleProductImages(productID, headers, -1)
+		if result == nil || len(result.Files) != len(indexes) {
+			for _, index := range indexes {
+				fileResults[index].Error = err.Error()
+			}
+			continue
+		}
+
+		for i, index := range indexes {
+			fileResult := fileResults[index]
+			fileResult.Success = result.Files[i].Success
+			fileResult.Error = result.Files[i].Error
+			fileResult.ImageID = result.Files[i].ImageID
+			fileResult.URL = result.Files[i].URL
+			fileResult.IsPrimary = result.Files[i].IsPrimary
+			if fileResult.Success {
+				uploaded++
+			}
+		}
+	}
+
+	failed := len(fileHeaders) - uploaded
+	if uploaded == 0 {
+		return &BulkProductImageResult{
+			Success:  false,
+			Message:  "All image uploads failed",
+			Error:    "Failed to process any uploaded images",
+			Files:    fileResults,
+			Products: len(productOrder),
+			Failed:   failed,
+		}, fmt.Errorf("failed to process any uploaded images")
+	}
+
+	return &BulkProductImageResult{
+		Success:  true,
+		Message:  fmt.Sprintf("Successfully processed %d out of %d images for %d products", uploaded, len(fileHeaders), len(productOrder)),
+		Files:    fileResults,
+		Products: len(productOrder),
+		Uploaded: uploaded,
+		Failed:   failed,
 	}, nil
 }
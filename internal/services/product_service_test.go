@@ -1,13 +1,21 @@
 package services_test
 
 import (
+	"bytes"
+	"mime/multipart"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/models/account"
+	"github.com/ybds/internal/models/notification"
+	"github.com/ybds/internal/models/order"
 	"github.com/ybds/internal/models/product"
 	"github.com/ybds/internal/services"
+	"github.com/ybds/internal/testutil"
+	"github.com/ybds/pkg/upload"
+	"gorm.io/gorm"
 )
 
 // TestProductService tests the ProductService functionality
@@ -160,3 +168,771 @@ func TestReserveInventory(t *testing.T) {
 func TestReleaseInventory(t *testing.T) {
 	t.Skip("Skipping integration test that requires a database")
 }
+
+// seedProductWithInventory creates a product with a single inventory
+func seedProductWithInventory(t testing.TB, db *gorm.DB, sku string, quantity, threshold int) (*product.Product, *product.Inventory) {
+	t.Helper()
+
+	p := &product.Product{Name: "Product " + sku, SKU: sku, Category: "Shirts"}
+	if err := db.Create(p).Error; err != nil {
+		t.Fatalf("failed to seed product: %v", err)
+	}
+
+	inv := &product.Inventory{
+		ProductID:         p.ID,
+		Size:              "M",
+		Color:             "Red",
+		Quantity:          quantity,
+		LowStockThreshold: threshold,
+	}
+	if err := db.Create(inv).Error; err != nil {
+		t.Fatalf("failed to seed inventory: %v", err)
+	}
+
+	return p, inv
+}
+
+// countStockAlerts counts the notifications raised for an inventory and event
+func countStockAlerts(t *testing.T, db *gorm.DB, inventoryID uuid.UUID, event string) int64 {
+	t.Helper()
+
+	var count int64
+	if err := db.Model(&notification.Notification{}).
+		Where("metadata->>'inventory_id' = ? AND metadata->>'event' = ?", inventoryID.String(), event).
+		Count(&count).Error; err != nil {
+		t.Fatalf("failed to count stock alerts: %v", err)
+	}
+	return count
+}
+
+// TestEvaluateStock tests that re-evaluating stock alerts already-low inventories without duplicates
+func TestEvaluateStock(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	seedUser(t, db, "admin", account.RoleAdmin, true)
+
+	notificationService := services.NewNotificationService(db, db, nil, nil)
+	productService := services.NewProductService(db, notificationService, nil)
+
+	p, lowInventory := seedProductWithInventory(t, db, "EVAL-001", 2, 5)
+
+	result, err := productService.EvaluateStock(p.ID)
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Len(t, result.Evaluations, 1)
+	assert.Equal(t, "low_stock", result.Evaluations[0].Event)
+	assert.True(t, result.Evaluations[0].Notified)
+	assert.Equal(t, int64(1), countStockAlerts(t, db, lowInventory.ID, "low_stock"))
+
+	// The unread alert suppresses a second one
+	result, err = productService.EvaluateStock(p.ID)
+	assert.NoError(t, err)
+	assert.False(t, result.Evaluations[0].Notified)
+	assert.Equal(t, int64(1), countStockAlerts(t, db, lowInventory.ID, "low_stock"))
+
+	// Sufficient stock raises nothing
+	healthy, healthyInventory := seedProductWithInventory(t, db, "EVAL-002", 20, 5)
+	result, err = productService.EvaluateStock(healthy.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Evaluations[0].Event)
+	assert.Equal(t, int64(0), countStockAlerts(t, db, healthyInventory.ID, "low_stock"))
+}
+
+// TestStockAlertCause tests that stock alerts record what moved the stock and who moved it
+func TestStockAlertCause(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	seedUser(t, db, "admin", account.RoleAdmin, true)
+
+	notificationService := services.NewNotificationService(db, db, nil, nil)
+	productService := services.NewProductService(db, notificationService, nil)
+
+	stockAlert := func(inventoryID uuid.UUID, event string) notification.Metadata {
+		t.Helper()
+		var n notification.Notification
+		assert.NoError(t, db.Where("metadata->>'inventory_id' = ? AND metadata->>'event' = ?", inventoryID.String(), event).
+			First(&n).Error)
+		return n.Metadata
+	}
+
+	t.Run("ManualAdjustment", func(t *testing.T) {
+		_, inv := seedProductWithInventory(t, db, "CAUSE-001", 20, 5)
+		actorID := uuid.New()
+		quantity := 3
+		_, err := productService.UpdateInventory(inv.ID, "", "", &quantity, "", nil, &actorID)
+		assert.NoError(t, err)
+
+		metadata := stockAlert(inv.ID, "low_stock")
+		assert.Equal(t, services.StockCauseManualAdjustment, metadata["cause"])
+		assert.Equal(t, actorID.String(), metadata["actor_id"])
+
+		// The stock movement is attributed to the actor too
+		var transaction product.InventoryTransaction
+		assert.NoError(t, db.Where("inventory_id = ?", inv.ID).First(&transaction).Error)
+		if assert.NotNil(t, transaction.CreatedBy) {
+			assert.Equal(t, actorID, *transaction.CreatedBy)
+		}
+	})
+
+	t.Run("Order", func(t *testing.T) {
+		_, inv := seedProductWithInventory(t, db, "CAUSE-002", 6, 5)
+		orderID := uuid.New()
+		assert.NoError(t, productService.ReserveInventory(inv.ID, 6, orderID))
+
+		metadata := stockAlert(inv.ID, "out_of_stock")
+		assert.Equal(t, services.StockCauseOrder, metadata["cause"])
+		assert.Equal(t, orderID.String(), metadata["reference_id"])
+		assert.NotContains(t, metadata, "actor_id")
+	})
+}
+
+// TestUpdateInventoryToZero tests that setting the quantity to exactly zero raises an out of stock alert
+func TestUpdateInventoryToZero(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	seedUser(t, db, "admin", account.RoleAdmin, true)
+
+	notificationService := services.NewNotificationService(db, db, nil, nil)
+	productService := services.NewProductService(db, notificationService, nil)
+
+	_, inv := seedProductWithInventory(t, db, "ZERO-001", 3, 5)
+
+	// Leaving the quantity out changes nothing and raises nothing
+	_, err := productService.UpdateInventory(inv.ID, "", "", nil, "C3", nil, nil)
+	assert.NoError(t, err)
+	updated, err := productService.GetInventoryByID(inv.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, updated.Quantity)
+	assert.Equal(t, int64(0), countStockAlerts(t, db, inv.ID, "out_of_stock"))
+
+	zero := 0
+	_, err = productService.UpdateInventory(inv.ID, "", "", &zero, "", nil, nil)
+	assert.NoError(t, err)
+	updated, err = productService.GetInventoryByID(inv.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, updated.Quantity)
+	assert.Equal(t, int64(1), countStockAlerts(t, db, inv.ID, "out_of_stock"))
+}
+
+// TestCustomLowStockThreshold tests that stock alerts use the threshold of the inventory
+func TestCustomLowStockThreshold(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	seedUser(t, db, "admin", account.RoleAdmin, true)
+
+	notificationService := services.NewNotificationService(db, db, nil, nil)
+	productService := services.NewProductService(db, notificationService, nil)
+
+	p, _ := seedProductWithInventory(t, db, "THRESHOLD-001", 0, 5)
+
+	// Creating with stock at a custom threshold of 12 alerts right away
+	threshold := 12
+	created, err := productService.CreateInventory(p.ID, "L", "Blue", 12, "", &threshold, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), countStockAlerts(t, db, created.InventoryID, "low_stock"))
+
+	// Creating below the default threshold but above a custom one raises nothing
+	lower := 2
+	quiet, err := productService.CreateInventory(p.ID, "XL", "Blue", 4, "", &lower, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), countStockAlerts(t, db, quiet.InventoryID, "low_stock"))
+
+	// Dropping to 8 crosses the custom threshold of 10, which the default of 5 would not
+	_, inv := seedProductWithInventory(t, db, "THRESHOLD-002", 20, 5)
+	custom := 10
+	_, err = productService.UpdateInventory(inv.ID, "", "", nil, "", &custom, nil)
+	assert.NoError(t, err)
+	quantity := 8
+	_, err = productService.UpdateInventory(inv.ID, "", "", &quantity, "", nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), countStockAlerts(t, db, inv.ID, "low_stock"))
+
+	updated, err := productService.GetInventoryByID(inv.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, updated.LowStockThreshold)
+
+	negative := -1
+	_, err = productService.UpdateInventory(inv.ID, "", "", nil, "", &negative, nil)
+	assert.ErrorIs(t, err, services.ErrValidation)
+}
+
+// TestTransferInventory tests that stock moves atomically between inventories and that a transfer
+// exceeding the source stock changes nothing
+func TestTransferInventory(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+
+	p, source := seedProductWithInventory(t, db, "MOVE-001", 10, 2)
+	destination := &product.Inventory{ProductID: p.ID, Size: "M", Color: "Red", Quantity: 3, Location: "Warehouse B"}
+	assert.NoError(t, db.Create(destination).Error)
+
+	stock := func(inv *product.Inventory) int {
+		t.Helper()
+		current, err := productService.GetInventoryByID(inv.ID)
+		assert.NoError(t, err)
+		return current.Quantity
+	}
+	transfers := func() int64 {
+		t.Helper()
+		var count int64
+		assert.NoError(t, db.Model(&product.InventoryTransfer{}).Count(&count).Error)
+		return count
+	}
+
+	t.Run("Succeeds", func(t *testing.T) {
+		actorID := uuid.New()
+		transfer, err := productService.TransferInventory(source.ID, destination.ID, 4, "Rebalance", &actorID)
+		assert.NoError(t, err)
+		assert.Equal(t, 6, stock(source))
+		assert.Equal(t, 7, stock(destination))
+		assert.Equal(t, int64(1), transfers())
+		if assert.NotNil(t, transfer.CreatedBy) {
+			assert.Equal(t, actorID, *transfer.CreatedBy)
+		}
+
+		// Both sides are recorded in the ledger against the transfer
+		var movements []product.InventoryTransaction
+		assert.NoError(t, db.Where("reference_id = ?", transfer.ID).Order("quantity").Find(&movements).Error)
+		if assert.Len(t, movements, 2) {
+			assert.Equal(t, source.ID, movements[0].InventoryID)
+			assert.Equal(t, -4, movements[0].Quantity)
+			assert.Equal(t, destination.ID, movements[1].InventoryID)
+			assert.Equal(t, 4, movements[1].Quantity)
+			assert.Equal(t, product.TransactionTransfer, movements[1].Type)
+		}
+	})
+
+	t.Run("InsufficientStock", func(t *testing.T) {
+		_, err := productService.TransferInventory(source.ID, destination.ID, 7, "", nil)
+		assert.ErrorIs(t, err, services.ErrConflict)
+		assert.Equal(t, 6, stock(source))
+		assert.Equal(t, 7, stock(destination))
+		assert.Equal(t, int64(1), transfers())
+	})
+
+	t.Run("DifferentVariant", func(t *testing.T) {
+		other := &product.Inventory{ProductID: p.ID, Size: "L", Color: "Red", Quantity: 0}
+		assert.NoError(t, db.Create(other).Error)
+
+		_, err := productService.TransferInventory(source.ID, other.ID, 1, "", nil)
+		assert.ErrorIs(t, err, services.ErrValidation)
+		assert.Equal(t, 6, stock(source))
+	})
+}
+
+// TestVariantOptions tests that inventories of a product with defined variant options must use one of
+// them, matched case-insensitively, while attributes without options accept any value
+func TestVariantOptions(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+
+	p, _ := seedProductWithInventory(t, db, "VARIANT-001", 10, 5)
+	red, err := productService.CreateVariantOption(p.ID, product.AttributeColor, "Red")
+	assert.NoError(t, err)
+	_, err = productService.CreateVariantOption(p.ID, product.AttributeColor, "red")
+	assert.ErrorIs(t, err, services.ErrConflict)
+
+	t.Run("RejectsUndefinedOption", func(t *testing.T) {
+		result, err := productService.CreateInventory(p.ID, "M", "Blue", 5, "", nil, nil)
+		assert.ErrorIs(t, err, services.ErrValidation)
+		assert.False(t, result.Success)
+
+		var count int64
+		assert.NoError(t, db.Model(&product.Inventory{}).Where("product_id = ? AND color = ?", p.ID, "Blue").Count(&count).Error)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("AcceptsDefinedOption", func(t *testing.T) {
+		result, err := productService.CreateInventory(p.ID, "L", "red", 5, "", nil, nil)
+		assert.NoError(t, err)
+
+		inv, err := productService.GetInventoryByID(result.InventoryID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Red", inv.Color)
+		if assert.NotNil(t, inv.ColorOptionID) {
+			assert.Equal(t, red.ID, *inv.ColorOptionID)
+		}
+		// No sizes are defined, so any size is accepted without an option
+		assert.Equal(t, "L", inv.Size)
+		assert.Nil(t, inv.SizeOptionID)
+
+		// The option is in use and cannot be deleted
+		assert.ErrorIs(t, productService.DeleteVariantOption(p.ID, red.ID), services.ErrConflict)
+	})
+
+	t.Run("RejectsUndefinedOptionOnUpdate", func(t *testing.T) {
+		result, err := productService.CreateInventory(p.ID, "S", "Red", 5, "", nil, nil)
+		assert.NoError(t, err)
+
+		_, err = productService.UpdateInventory(result.InventoryID, "", "Green", nil, "", nil, nil)
+		assert.ErrorIs(t, err, services.ErrValidation)
+	})
+}
+
+// TestGetMovements tests that order reservations and releases are recorded with the running balance
+// of the inventory
+func TestGetMovements(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+
+	_, inv := seedProductWithInventory(t, db, "MOVEMENT-001", 10, 2)
+	orderID := uuid.New()
+
+	assert.NoError(t, productService.ReserveInventory(inv.ID, 3, orderID))
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, productService.ReleaseInventory(inv.ID, 2, orderID))
+
+	movements, err := productService.GetMovements(inv.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, movements, 2) {
+		// Newest first
+		release, reserve := movements[0], movements[1]
+
+		assert.Equal(t, -3, reserve.Quantity)
+		assert.Equal(t, product.TransactionReservation, reserve.Type)
+		if assert.NotNil(t, reserve.Balance) {
+			assert.Equal(t, 7, *reserve.Balance)
+		}
+
+		assert.Equal(t, 2, release.Quantity)
+		assert.Equal(t, product.TransactionRelease, release.Type)
+		if assert.NotNil(t, release.Balance) {
+			assert.Equal(t, 9, *release.Balance)
+		}
+		if assert.NotNil(t, release.ReferenceID) {
+			assert.Equal(t, orderID, *release.ReferenceID)
+		}
+	}
+
+	_, err = productService.GetMovements(uuid.New())
+	assert.ErrorIs(t, err, services.ErrNotFound)
+}
+
+// TestGetRestocks tests that the restock history only contains stock increases not caused by orders
+func TestGetRestocks(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+
+	p, _ := seedProductWithInventory(t, db, "RST-001", 0, 2)
+	created, err := productService.CreateInventory(p.ID, "L", "Blue", 10, "Warehouse A", nil, nil)
+	assert.NoError(t, err)
+	inventoryID := created.InventoryID
+
+	setQuantity := func(quantity int) {
+		_, err := productService.UpdateInventory(inventoryID, "", "", &quantity, "", nil, nil)
+		assert.NoError(t, err)
+	}
+	setQuantity(15) // restock of 5
+	setQuantity(12) // stock count correction of -3
+
+	// Order-driven movements are not restocks
+	orderID := uuid.New()
+	assert.NoError(t, productService.ReserveInventory(inventoryID, 2, orderID))
+	assert.NoError(t, productService.ReleaseInventory(inventoryID, 2, orderID))
+
+	restocks, err := productService.GetRestocks(inventoryID)
+	assert.NoError(t, err)
+	if assert.Len(t, restocks, 2) {
+		assert.Equal(t, 5, restocks[0].Quantity)
+		assert.Equal(t, product.TransactionAdjustment, restocks[0].Type)
+		assert.Equal(t, 10, restocks[1].Quantity)
+		assert.Equal(t, product.TransactionInbound, restocks[1].Type)
+	}
+
+	inventory, err := productService.GetInventoryByID(inventoryID)
+	assert.NoError(t, err)
+	assert.Equal(t, 12, inventory.Quantity)
+
+	_, err = productService.GetRestocks(uuid.New())
+	assert.ErrorIs(t, err, services.ErrNotFound)
+}
+
+// newFileHeaders builds multipart file headers holding the given files, in order
+func newFileHeaders(t *testing.T, files ...[2]string) []*multipart.FileHeader {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, file := range files {
+		part, err := writer.CreateFormFile("files", file[0])
+		if err != nil {
+			t.Fatalf("failed to create form file: %v", err)
+		}
+		part.Write([]byte(file[1]))
+	}
+	writer.Close()
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("failed to read multipart form: %v", err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+
+	return form.File["files"]
+}
+
+// TestUploadMultipleProductImagesReportsEachFile tests that a rejected file is reported without aborting the other uploads
+func TestUploadMultipleProductImagesReportsEachFile(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	uploadService, err := upload.NewService(upload.NewConfig(t.TempDir()))
+	if err != nil {
+		t.Fatalf("failed to create upload service: %v", err)
+	}
+	productService := services.NewProductService(db, nil, uploadService)
+
+	p, _ := seedProductWithInventory(t, db, "IMG-001", 10, 2)
+
+	pngHeader := "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+	fileHeaders := newFileHeaders(t,
+		[2]string{"notes.txt", "this is not an image"},
+		[2]string{"front.png", pngHeader},
+	)
+
+	// The rejected file was requested as primary, so the product falls back to the uploaded one
+	result, err := productService.UploadMultipleProductImages(p.ID, fileHeaders, 0)
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, 1, result.Failed)
+	assert.Len(t, result.Images, 1)
+
+	if assert.Len(t, result.Files, 2) {
+		rejected, uploaded := result.Files[0], result.Files[1]
+
+		assert.Equal(t, "notes.txt", rejected.Filename)
+		assert.False(t, rejected.Success)
+		assert.Contains(t, rejected.Error, "not allowed")
+		assert.Nil(t, rejected.ImageID)
+
+		assert.Equal(t, "front.png", uploaded.Filename)
+		assert.True(t, uploaded.Success)
+		assert.Empty(t, uploaded.Error)
+		if assert.NotNil(t, uploaded.ImageID) {
+			assert.Equal(t, result.Images[0].ImageID, *uploaded.ImageID)
+		}
+		assert.NotEmpty(t, uploaded.URL)
+		assert.True(t, uploaded.IsPrimary)
+	}
+
+	images, err := productService.GetProductImages(p.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, images, 1) {
+		assert.True(t, images[0].IsPrimary)
+	}
+
+	// Every file rejected
+	result, err = productService.UploadMultipleProductImages(p.ID, newFileHeaders(t, [2]string{"notes.txt", "still not an image"}), -1)
+	assert.ErrorIs(t, err, upload.ErrFileTypeNotAllowed)
+	assert.False(t, result.Success)
+	if assert.Len(t, result.Files, 1) {
+		assert.False(t, result.Files[0].Success)
+	}
+}
+
+// TestFeaturedProducts tests featuring, unfeaturing and reordering products
+func TestFeaturedProducts(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+
+	first, _ := seedProductWithInventory(t, db, "FTR-001", 10, 2)
+	second, _ := seedProductWithInventory(t, db, "FTR-002", 10, 2)
+	third, _ := seedProductWithInventory(t, db, "FTR-003", 10, 2)
+	seedProductWithInventory(t, db, "FTR-004", 10, 2)
+
+	featuredIDs := func() []uuid.UUID {
+		products, err := productService.GetFeaturedProducts()
+		assert.NoError(t, err)
+		ids := make([]uuid.UUID, len(products))
+		for i, p := range products {
+			ids[i] = p.ID
+		}
+		return ids
+	}
+
+	// Featured products are appended in the order they were featured
+	for _, p := range []*product.Product{second, first, third} {
+		featured, err := productService.SetProductFeatured(p.ID, true)
+		assert.NoError(t, err)
+		assert.True(t, featured.IsFeatured)
+	}
+	assert.Equal(t, []uuid.UUID{second.ID, first.ID, third.ID}, featuredIDs())
+
+	// Featuring again keeps the position
+	_, err := productService.SetProductFeatured(second.ID, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{second.ID, first.ID, third.ID}, featuredIDs())
+
+	// Unlisted featured products keep their relative order after the listed ones
+	assert.NoError(t, productService.ReorderFeaturedProducts([]uuid.UUID{third.ID}))
+	assert.Equal(t, []uuid.UUID{third.ID, second.ID, first.ID}, featuredIDs())
+
+	assert.NoError(t, productService.ReorderFeaturedProducts([]uuid.UUID{first.ID, second.ID, third.ID}))
+	assert.Equal(t, []uuid.UUID{first.ID, second.ID, third.ID}, featuredIDs())
+
+	unfeatured, err := productService.SetProductFeatured(second.ID, false)
+	assert.NoError(t, err)
+	assert.False(t, unfeatured.IsFeatured)
+	assert.Equal(t, 0, unfeatured.FeaturedOrder)
+	assert.Equal(t, []uuid.UUID{first.ID, third.ID}, featuredIDs())
+
+	// Only featured products can be reordered, each once
+	assert.ErrorIs(t, productService.ReorderFeaturedProducts([]uuid.UUID{second.ID}), services.ErrValidation)
+	assert.ErrorIs(t, productService.ReorderFeaturedProducts([]uuid.UUID{first.ID, first.ID}), services.ErrValidation)
+
+	_, err = productService.SetProductFeatured(uuid.New(), true)
+	assert.ErrorIs(t, err, services.ErrNotFound)
+}
+
+// TestPrimaryImageURL tests choosing the image shown for a product
+func TestPrimaryImageURL(t *testing.T) {
+	external := "https://cdn.example.com/shirt.jpg"
+
+	t.Run("PrimaryImage", func(t *testing.T) {
+		p := product.Product{ImageURL: external, Images: []product.ProductImage{
+			{URL: "/uploads/first.jpg", SortOrder: 0},
+			{URL: "/uploads/primary.jpg", SortOrder: 1, IsPrimary: true},
+		}}
+		assert.Equal(t, "/uploads/primary.jpg", p.PrimaryImageURL())
+	})
+
+	t.Run("FirstImageBySortOrder", func(t *testing.T) {
+		p := product.Product{ImageURL: external, Images: []product.ProductImage{
+			{URL: "/uploads/second.jpg", SortOrder: 2},
+			{URL: "/uploads/first.jpg", SortOrder: 1},
+		}}
+		assert.Equal(t, "/uploads/first.jpg", p.PrimaryImageURL())
+	})
+
+	t.Run("NoImagesFallsBackToImageURL", func(t *testing.T) {
+		p := product.Product{ImageURL: external}
+		assert.Equal(t, external, p.PrimaryImageURL())
+	})
+}
+
+// TestGetPrimaryImageURL tests the primary image lookup with and without image rows
+func TestGetPrimaryImageURL(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+
+	t.Run("NoImagesButImageURL", func(t *testing.T) {
+		p, _ := seedProductWithInventory(t, db, "IMG-001", 10, 2)
+		assert.NoError(t, db.Model(p).Update("image_url", "https://cdn.example.com/shirt.jpg").Error)
+
+		assert.Equal(t, "https://cdn.example.com/shirt.jpg", productService.GetPrimaryImageURL(p.ID))
+	})
+
+	t.Run("PrimaryImage", func(t *testing.T) {
+		p, _ := seedProductWithInventory(t, db, "IMG-002", 10, 2)
+		assert.NoError(t, db.Model(p).Update("image_url", "https://cdn.example.com/old.jpg").Error)
+		assert.NoError(t, db.Create(&product.ProductImage{ProductID: p.ID, URL: "/uploads/a.jpg", Filename: "a.jpg", SortOrder: 0}).Error)
+		assert.NoError(t, db.Create(&product.ProductImage{ProductID: p.ID, URL: "/uploads/b.jpg", Filename: "b.jpg", SortOrder: 1, IsPrimary: true}).Error)
+
+		assert.Equal(t, "/uploads/b.jpg", productService.GetPrimaryImageURL(p.ID))
+	})
+
+	t.Run("MissingProduct", func(t *testing.T) {
+		assert.Equal(t, "", productService.GetPrimaryImageURL(uuid.New()))
+	})
+}
+
+// TestSelectPrice tests each policy for choosing between prices valid at the same time
+func TestSelectPrice(t *testing.T) {
+	now := time.Now()
+	// Ordered newest start date first, as returned by the repository
+	prices := []product.Price{
+		{Price: 120, StartDate: now.Add(-time.Hour)},
+		{Price: 90, StartDate: now.Add(-48 * time.Hour)},
+		{Price: 100, StartDate: now.Add(-72 * time.Hour)},
+	}
+
+	t.Run("LatestStart", func(t *testing.T) {
+		price, err := product.SelectPrice(prices, product.PriceSelectionLatestStart)
+		assert.NoError(t, err)
+		assert.Equal(t, 120.0, price.Price)
+	})
+
+	t.Run("Lowest", func(t *testing.T) {
+		price, err := product.SelectPrice(prices, product.PriceSelectionLowest)
+		assert.NoError(t, err)
+		assert.Equal(t, 90.0, price.Price)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := product.SelectPrice(prices, product.PriceSelectionError)
+		assert.ErrorIs(t, err, product.ErrMultiplePrices)
+
+		// A single valid price is never ambiguous
+		price, err := product.SelectPrice(prices[:1], product.PriceSelectionError)
+		assert.NoError(t, err)
+		assert.Equal(t, 120.0, price.Price)
+	})
+
+	t.Run("UnknownFallsBackToLatestStart", func(t *testing.T) {
+		assert.False(t, product.PriceSelection("highest").IsValid())
+		price, err := product.SelectPrice(prices, "highest")
+		assert.NoError(t, err)
+		assert.Equal(t, 120.0, price.Price)
+	})
+
+	t.Run("NoPrices", func(t *testing.T) {
+		_, err := product.SelectPrice(nil, product.PriceSelectionLowest)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+// TestUnitPriceForQuantity tests that the highest quantity break a quantity reaches sets its unit price
+func TestUnitPriceForQuantity(t *testing.T) {
+	price := product.Price{Price: 100}
+	price.ID = uuid.New()
+	tiers := []product.PriceTier{
+		{PriceID: price.ID, MinQuantity: 100, UnitPrice: 70},
+		{PriceID: price.ID, MinQuantity: 10, UnitPrice: 90},
+		// Tiers of other prices are ignored
+		{PriceID: uuid.New(), MinQuantity: 5, UnitPrice: 50},
+	}
+
+	assert.Equal(t, 100.0, product.UnitPriceForQuantity(price, tiers, 1))
+	assert.Equal(t, 100.0, product.UnitPriceForQuantity(price, tiers, 9))
+	assert.Equal(t, 90.0, product.UnitPriceForQuantity(price, tiers, 10))
+	assert.Equal(t, 90.0, product.UnitPriceForQuantity(price, tiers, 99))
+	assert.Equal(t, 70.0, product.UnitPriceForQuantity(price, tiers, 250))
+	assert.Equal(t, 100.0, product.UnitPriceForQuantity(price, nil, 250))
+}
+
+// TestGetCurrentPriceSelection tests the current price of a product with overlapping prices under each policy
+func TestGetCurrentPriceSelection(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+
+	p, _ := seedProductWithInventory(t, db, "PRICE-001", 10, 2)
+	now := time.Now()
+	for _, price := range []*product.Price{
+		{ProductID: p.ID, Price: 90, Currency: "VND", StartDate: now.Add(-72 * time.Hour)},
+		{ProductID: p.ID, Price: 120, Currency: "VND", StartDate: now.Add(-time.Hour)},
+	} {
+		assert.NoError(t, db.Create(price).Error)
+	}
+
+	productService.PriceSelection = product.PriceSelectionLatestStart
+	price, err := productService.GetCurrentPrice(p.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 120.0, price.Price)
+
+	productService.PriceSelection = product.PriceSelectionLowest
+	price, err = productService.GetCurrentPrice(p.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 90.0, price.Price)
+
+	productService.PriceSelection = product.PriceSelectionError
+	_, err = productService.GetCurrentPrice(p.ID)
+	assert.ErrorIs(t, err, services.ErrConflict)
+}
+
+// TestBulkUploadProductImages tests attaching images to two products resolved by SKU
+func TestBulkUploadProductImages(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	uploadService, err := upload.NewService(upload.NewConfig(t.TempDir()))
+	if err != nil {
+		t.Fatalf("failed to create upload service: %v", err)
+	}
+	productService := services.NewProductService(db, nil, uploadService)
+
+	shirt, _ := seedProductWithInventory(t, db, "SHIRT-001", 10, 2)
+	pants, _ := seedProductWithInventory(t, db, "PANTS-001", 10, 2)
+
+	pngHeader := "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+	fileHeaders := newFileHeaders(t,
+		[2]string{"front.png", pngHeader},
+		[2]string{"PANTS-001.png", pngHeader},
+		[2]string{"UNKNOWN-001.png", pngHeader},
+	)
+
+	// front.png is mapped explicitly, PANTS-001.png is matched by its name
+	result, err := productService.BulkUploadProductImages(fileHeaders, map[string]string{"front.png": "SHIRT-001"})
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, 2, result.Products)
+	assert.Equal(t, 2, result.Uploaded)
+	assert.Equal(t, 1, result.Failed)
+
+	if assert.Len(t, result.Files, 3) {
+		for i, p := range []*product.Product{shirt, pants} {
+			file := result.Files[i]
+			assert.True(t, file.Success)
+			assert.Equal(t, p.SKU, file.SKU)
+			if assert.NotNil(t, file.ProductID) {
+				assert.Equal(t, p.ID, *file.ProductID)
+			}
+			assert.True(t, file.IsPrimary)
+		}
+
+		unknown := result.Files[2]
+		assert.False(t, unknown.Success)
+		assert.Nil(t, unknown.ProductID)
+		assert.Contains(t, unknown.Error, "UNKNOWN-001")
+	}
+
+	for _, p := range []*product.Product{shirt, pants} {
+		images, err := productService.GetProductImages(p.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, images, 1) {
+			assert.True(t, images[0].IsPrimary)
+		}
+	}
+}
+
+// TestDeleteAndRestoreProduct tests that deleted products are hidden by default, still named by past orders and can be restored
+func TestDeleteAndRestoreProduct(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+
+	p, inv := seedProductWithInventory(t, db, "DEL-001", 10, 2)
+	seedProductWithInventory(t, db, "DEL-002", 10, 2)
+	assert.NoError(t, db.Create(&product.Price{ProductID: p.ID, Price: 100, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+	createdBy := uuid.New()
+	created, err := orderService.CreateOrder(order.PaymentCash, []services.OrderItemInfo{{InventoryID: inv.ID, Quantity: 1}},
+		0, nil, "", &createdBy, "", "", "", "", "", "John Doe", "", "", "", true)
+	assert.NoError(t, err)
+
+	// Restoring a product that is not deleted conflicts
+	_, err = productService.RestoreProduct(p.ID)
+	assert.ErrorIs(t, err, services.ErrConflict)
+
+	_, err = productService.DeleteProduct(p.ID)
+	assert.NoError(t, err)
+
+	// Deleted products are hidden by default
+	_, err = productService.GetProductByID(p.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	products, total, err := productService.GetAllProducts(1, 10, map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, products, 1) {
+		assert.Equal(t, "DEL-002", products[0].SKU)
+	}
+
+	products, total, err = productService.GetAllProducts(1, 10, map[string]interface{}{"include_deleted": true})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, products, 2)
+
+	// Past orders still name the deleted product
+	orders, _, err := orderService.GetOrdersWithDetails(1, 10, map[string]interface{}{})
+	assert.NoError(t, err)
+	if assert.Len(t, orders, 1) && assert.Len(t, orders[0].Items, 1) && assert.NotNil(t, orders[0].Items[0].Product) {
+		assert.Equal(t, created.OrderID, orders[0].Order.ID)
+		assert.Equal(t, "Product DEL-001", orders[0].Items[0].Product.Name)
+	}
+	deleted, err := productService.GetProductIncludingDeleted(p.ID)
+	assert.NoError(t, err)
+	assert.True(t, deleted.DeletedAt.Valid)
+
+	result, err := productService.RestoreProduct(p.ID)
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+
+	restored, err := productService.GetProductByID(p.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "DEL-001", restored.SKU)
+
+	_, err = productService.RestoreProduct(uuid.New())
+	assert.ErrorIs(t, err, services.ErrNotFound)
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models/order"
+	"github.com/ybds/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// MaxPromoCodeLength is the largest number of characters a promo code may have
+const MaxPromoCodeLength = 50
+
+// PromoService handles the management of promo codes
+type PromoService struct {
+	DB        *gorm.DB
+	PromoRepo *repositories.PromoRepository
+}
+
+// NewPromoService creates a new instance of PromoService
+func NewPromoService(db *gorm.DB) *PromoService {
+	return &PromoService{
+		DB:        db,
+		PromoRepo: repositories.NewPromoRepository(db),
+	}
+}
+
+// PromoCodeDetails holds the editable fields of a promo code. A zero StartDate means now;
+// a nil EndDate keeps the code valid indefinitely and a MaxUses of 0 allows unlimited uses.
+type PromoCodeDetails struct {
+	Code           string
+	Type           order.PromoType
+	Value          float64
+	StartDate      time.Time
+	EndDate        *time.Time
+	MaxUses        int
+	MinOrderAmount float64
+	Description    string
+}
+
+// normalizePromoCode trims a promo code and converts it to upper case, so codes match case-insensitively
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// validate normalizes the details and checks that they describe a usable promo code
+func (d *PromoCodeDetails) validate() error {
+	d.Code = normalizePromoCode(d.Code)
+	if d.Code == "" {
+		return validationError("code is required")
+	}
+	if len(d.Code) > MaxPromoCodeLength {
+		return validationError(fmt.Sprintf("code must be at most %d characters", MaxPromoCodeLength))
+	}
+	for _, r := range d.Code {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return validationError("code may only contain letters, digits, dashes and underscores")
+		}
+	}
+
+	if !d.Type.IsValid() {
+		return validationError(fmt.Sprintf("type must be %s or %s", order.PromoPercent, order.PromoFixed))
+	}
+	if d.Value <= 0 {
+		return validationError("value must be greater than 0")
+	}
+	if d.Type == order.PromoPercent && d.Value > 100 {
+		return validationError("a percent value must be at most 100")
+	}
+
+	if d.StartDate.IsZero() {
+		d.StartDate = time.Now()
+	}
+	if d.EndDate != nil && !d.EndDate.After(d.StartDate) {
+		return validationError("end date must be after the start date")
+	}
+	if d.MaxUses < 0 {
+		return validationError("max uses cannot be negative")
+	}
+	if d.MinOrderAmount < 0 {
+		return validationError("minimum order amount cannot be negative")
+	}
+	return nil
+}
+
+// apply copies the details onto a promo code
+func (d PromoCodeDetails) apply(promo *order.PromoCode) {
+	promo.Code = d.Code
+	promo.Type = d.Type
+	promo.Value = d.Value
+	promo.StartDate = d.StartDate
+	promo.EndDate = d.EndDate
+	promo.MaxUses = d.MaxUses
+	promo.MinOrderAmount = d.MinOrderAmount
+	promo.Description = d.Description
+}
+
+// checkCodeAvailable checks that no other promo code uses the code
+func (s *PromoService) checkCodeAvailable(code string, id uuid.UUID) error {
+	existing, err := s.PromoRepo.GetPromoCodeByCode(code)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.ID != id {
+		return conflictError(fmt.Sprintf("promo code %s already exists", code))
+	}
+	return nil
+}
+
+// CreatePromoCode creates a promo code
+func (s *PromoService) CreatePromoCode(details PromoCodeDetails, createdBy *uuid.UUID) (*order.PromoCode, error) {
+	if err := details.validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkCodeAvailable(details.Code, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	promo := &order.PromoCode{}
+	details.apply(promo)
+	promo.CreatedBy = createdBy
+	if err := s.PromoRepo.CreatePromoCode(promo); err != nil {
+		return nil, err
+	}
+	return promo, nil
+}
+
+// GetPromoCodeByID retrieves a promo code by ID
+func (s *PromoService) GetPromoCodeByID(id uuid.UUID) (*order.PromoCode, error) {
+	promo, err := s.PromoRepo.GetPromoCodeByID(id)
+	if err != nil {
+		return nil, notFoundError("promo code", err)
+	}
+	return promo, nil
+}
+
+// GetPromoCodes lists the promo codes, newest first
+func (s *PromoService) GetPromoCodes(page, pageSize int) ([]order.PromoCode, int64, error) {
+	return s.PromoRepo.GetPromoCodes(page, pageSize)
+}
+
+// UpdatePromoCode replaces the details of a promo code. Its used count is kept, so lowering
+// MaxUses below it exhausts the code.
+func (s *PromoService) UpdatePromoCode(id uuid.UUID, details PromoCodeDetails, updatedBy *uuid.UUID) (*order.PromoCode, error) {
+	promo, err := s.GetPromoCodeByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := details.validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkCodeAvailable(details.Code, id); err != nil {
+		return nil, err
+	}
+
+	details.apply(promo)
+	promo.UpdatedBy = updatedBy
+	if err := s.PromoRepo.UpdatePromoCode(promo); err != nil {
+		return nil, err
+	}
+	return s.GetPromoCodeByID(id)
+}
+
+// DeletePromoCode deletes a promo code. Orders it was applied to keep their discount.
+func (s *PromoService) DeletePromoCode(id uuid.UUID) error {
+	if _, err := s.GetPromoCodeByID(id); err != nil {
+		return err
+	}
+	return s.PromoRepo.DeletePromoCode(id)
+}
@@ -0,0 +1,117 @@
+package services_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/models/order"
+	"github.com/ybds/internal/services"
+	"github.com/ybds/internal/testutil"
+)
+
+// TestApplyPromoCode tests applying percent and fixed promo codes and the checks on their use
+func TestApplyPromoCode(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	promoService := services.NewPromoService(db)
+	orderService := services.NewOrderService(db, nil, nil, nil)
+
+	percent, err := promoService.CreatePromoCode(services.PromoCodeDetails{Code: "summer10", Type: order.PromoPercent, Value: 10}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "SUMMER10", percent.Code)
+
+	// Codes match case-insensitively and a percent code takes its share of the total
+	o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	result, err := orderService.ApplyPromoCode(o.ID, " Summer10 ")
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, result.DiscountAmount)
+	assert.Equal(t, 90.0, result.FinalTotal)
+
+	updated, err := orderService.GetOrderByID(o.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "SUMMER10", updated.PromoCode)
+	if assert.NotNil(t, updated.PromoCodeID) {
+		assert.Equal(t, percent.ID, *updated.PromoCodeID)
+	}
+	used, err := promoService.GetPromoCodeByID(percent.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, used.UsedCount)
+
+	// An order only takes one promo code
+	_, err = orderService.ApplyPromoCode(o.ID, "SUMMER10")
+	assert.ErrorIs(t, err, services.ErrConflict)
+
+	// The order total must reach the minimum order amount
+	_, err = promoService.CreatePromoCode(services.PromoCodeDetails{Code: "BIG50", Type: order.PromoFixed, Value: 50, MinOrderAmount: 500}, nil)
+	assert.NoError(t, err)
+	o = seedOrder(t, db, order.OrderShipmentRequested, nil)
+	_, err = orderService.ApplyPromoCode(o.ID, "BIG50")
+	assert.ErrorIs(t, err, services.ErrValidation)
+
+	// Expired and not yet started codes are rejected
+	ended := time.Now().Add(-time.Hour)
+	_, err = promoService.CreatePromoCode(services.PromoCodeDetails{Code: "EXPIRED", Type: order.PromoFixed, Value: 20,
+		StartDate: time.Now().Add(-48 * time.Hour), EndDate: &ended}, nil)
+	assert.NoError(t, err)
+	_, err = orderService.ApplyPromoCode(o.ID, "EXPIRED")
+	assert.ErrorIs(t, err, services.ErrValidation)
+
+	_, err = promoService.CreatePromoCode(services.PromoCodeDetails{Code: "LATER", Type: order.PromoFixed, Value: 20,
+		StartDate: time.Now().Add(time.Hour)}, nil)
+	assert.NoError(t, err)
+	_, err = orderService.ApplyPromoCode(o.ID, "LATER")
+	assert.ErrorIs(t, err, services.ErrValidation)
+
+	// A fixed code takes its value off the total
+	_, err = promoService.CreatePromoCode(services.PromoCodeDetails{Code: "FLAT20", Type: order.PromoFixed, Value: 20}, nil)
+	assert.NoError(t, err)
+	result, err = orderService.ApplyPromoCode(o.ID, "FLAT20")
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0, result.DiscountAmount)
+	assert.Equal(t, 80.0, result.FinalTotal)
+
+	_, err = orderService.ApplyPromoCode(o.ID, "UNKNOWN")
+	assert.ErrorIs(t, err, services.ErrNotFound)
+
+	// Duplicate codes are rejected
+	_, err = promoService.CreatePromoCode(services.PromoCodeDetails{Code: "Flat20", Type: order.PromoFixed, Value: 5}, nil)
+	assert.ErrorIs(t, err, services.ErrConflict)
+}
+
+// TestApplyPromoCodeConcurrently tests that a single-use promo code is only redeemed once by concurrent orders
+func TestApplyPromoCodeConcurrently(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	promoService := services.NewPromoService(db)
+	orderService := services.NewOrderService(db, nil, nil, nil)
+
+	promo, err := promoService.CreatePromoCode(services.PromoCodeDetails{Code: "ONCE", Type: order.PromoFixed, Value: 10, MaxUses: 1}, nil)
+	assert.NoError(t, err)
+
+	const orders = 5
+	var wg sync.WaitGroup
+	errs := make([]error, orders)
+	for i := 0; i < orders; i++ {
+		o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = orderService.ApplyPromoCode(o.ID, "ONCE")
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+		} else {
+			assert.ErrorIs(t, err, services.ErrConflict)
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+
+	used, err := promoService.GetPromoCodeByID(promo.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, used.UsedCount)
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models/product"
+	"github.com/ybds/internal/repositories"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultRelatedProductsLimit is the number of related products returned when no limit is given
+	DefaultRelatedProductsLimit = 10
+	// MaxRelatedProductsLimit is the maximum number of related products returned
+	MaxRelatedProductsLimit = 50
+)
+
+// RecommendationService handles product recommendations derived from order history
+type RecommendationService struct {
+	OrderRepo   *repositories.OrderRepository
+	ProductRepo *repositories.ProductRepository
+}
+
+// NewRecommendationService creates a new instance of RecommendationService
+func NewRecommendationService(orderDB, productDB *gorm.DB) *RecommendationService {
+	return &RecommendationService{
+		OrderRepo:   repositories.NewOrderRepository(orderDB),
+		ProductRepo: repositories.NewProductRepository(productDB),
+	}
+}
+
+// RelatedProduct is a product frequently bought together with another product
+type RelatedProduct struct {
+	Product product.Product
+	// OrderCount is the number of non-canceled orders containing both products
+	OrderCount int
+}
+
+// GetRelatedProducts returns the products bought in the same non-canceled orders as the given
+// product, ranked by the number of orders they share with it and then by name. At most limit
+// products are returned.
+func (s *RecommendationService) GetRelatedProducts(productID uuid.UUID, limit int) ([]RelatedProduct, error) {
+	if limit <= 0 {
+		limit = DefaultRelatedProductsLimit
+	}
+	if limit > MaxRelatedProductsLimit {
+		limit = MaxRelatedProductsLimit
+	}
+
+	inventories, err := s.ProductRepo.GetInventoriesByProductID(productID)
+	if err != nil {
+		return nil, notFoundError("product", err)
+	}
+
+	inventoryIDs := make([]uuid.UUID, len(inventories))
+	for i, inv := range inventories {
+		inventoryIDs[i] = inv.ID
+	}
+
+	items, err := s.OrderRepo.GetCoPurchasedItems(inventoryIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve the product of each co-purchased inventory
+	coPurchasedIDs := make([]uuid.UUID, 0, len(items))
+	seen := make(map[uuid.UUID]bool, len(items))
+	for _, item := range items {
+		if !seen[item.InventoryID] {
+			seen[item.InventoryID] = true
+			coPurchasedIDs = append(coPurchasedIDs, item.InventoryID)
+		}
+	}
+	coPurchased, err := s.ProductRepo.GetInventoriesByIDs(coPurchasedIDs)
+	if err != nil {
+		return nil, err
+	}
+	productOf := make(map[uuid.UUID]uuid.UUID, len(coPurchased))
+	for _, inv := range coPurchased {
+		productOf[inv.ID] = inv.ProductID
+	}
+
+	// Count the distinct orders per product; several variants of a product in one order count once
+	orders := make(map[uuid.UUID]map[uuid.UUID]bool)
+	for _, item := range items {
+		relatedID, ok := productOf[item.InventoryID]
+		if !ok || relatedID == productID {
+			continue
+		}
+		if orders[relatedID] == nil {
+			orders[relatedID] = make(map[uuid.UUID]bool)
+		}
+		orders[relatedID][item.OrderID] = true
+	}
+
+	relatedIDs := make([]uuid.UUID, 0, len(orders))
+	for relatedID := range orders {
+		relatedIDs = append(relatedIDs, relatedID)
+	}
+	products, err := s.ProductRepo.GetProductsByIDs(relatedIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	related := make([]RelatedProduct, len(products))
+	for i, p := range products {
+		related[i] = RelatedProduct{Product: p, OrderCount: len(orders[p.ID])}
+	}
+	sort.Slice(related, func(i, j int) bool {
+		if related[i].OrderCount != related[j].OrderCount {
+			return related[i].OrderCount > related[j].OrderCount
+		}
+		return related[i].Product.Name < related[j].Product.Name
+	})
+
+	if len(related) > limit {
+		related = related[:limit]
+	}
+	return related, nil
+}
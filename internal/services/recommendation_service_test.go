@@ -0,0 +1,72 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/models/order"
+	"github.com/ybds/internal/models/product"
+	"github.com/ybds/internal/services"
+	"github.com/ybds/internal/testutil"
+	"gorm.io/gorm"
+)
+
+// seedOrderWithItems seeds an order in the given status with one item per inventory
+func seedOrderWithItems(t testing.TB, db *gorm.DB, status order.OrderStatus, inventories ...*product.Inventory) *order.Order {
+	t.Helper()
+
+	o := seedOrder(t, db, status, nil)
+	for _, inv := range inventories {
+		item := &order.OrderItem{OrderID: o.ID, InventoryID: inv.ID, Quantity: 1, PriceAtOrder: 100}
+		if err := db.Create(item).Error; err != nil {
+			t.Fatalf("failed to seed order item: %v", err)
+		}
+	}
+
+	return o
+}
+
+// TestGetRelatedProducts tests that co-purchased products are ranked by the number of shared orders
+func TestGetRelatedProducts(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	recommendationService := services.NewRecommendationService(db, db)
+
+	shirt, shirtM := seedProductWithInventory(t, db, "SHIRT-001", 10, 2)
+	shirtL := &product.Inventory{ProductID: shirt.ID, Size: "L", Color: "Red", Quantity: 10}
+	assert.NoError(t, db.Create(shirtL).Error)
+	belt, beltInv := seedProductWithInventory(t, db, "BELT-001", 10, 2)
+	socks, socksInv := seedProductWithInventory(t, db, "SOCKS-001", 10, 2)
+	hat, hatInv := seedProductWithInventory(t, db, "HAT-001", 10, 2)
+	_, unrelatedInv := seedProductWithInventory(t, db, "SCARF-001", 10, 2)
+
+	// Socks share three orders with the shirt, the belt two and the hat only a canceled one
+	seedOrderWithItems(t, db, order.OrderDelivered, shirtM, socksInv, beltInv)
+	seedOrderWithItems(t, db, order.OrderShipmentRequested, shirtL, socksInv, beltInv)
+	seedOrderWithItems(t, db, order.OrderPacked, shirtM, shirtL, socksInv)
+	seedOrderWithItems(t, db, order.OrderCanceled, shirtM, hatInv)
+	seedOrderWithItems(t, db, order.OrderDelivered, beltInv, unrelatedInv)
+
+	related, err := recommendationService.GetRelatedProducts(shirt.ID, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, related, 2) {
+		assert.Equal(t, socks.ID, related[0].Product.ID)
+		assert.Equal(t, 3, related[0].OrderCount)
+		assert.Equal(t, belt.ID, related[1].Product.ID)
+		assert.Equal(t, 2, related[1].OrderCount)
+	}
+	for _, r := range related {
+		assert.NotEqual(t, shirt.ID, r.Product.ID)
+		assert.NotEqual(t, hat.ID, r.Product.ID)
+	}
+
+	// The limit keeps the best ranked products
+	related, err = recommendationService.GetRelatedProducts(shirt.ID, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, related, 1) {
+		assert.Equal(t, socks.ID, related[0].Product.ID)
+	}
+
+	_, err = recommendationService.GetRelatedProducts(uuid.New(), 10)
+	assert.ErrorIs(t, err, services.ErrNotFound)
+}
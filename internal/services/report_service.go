@@ -0,0 +1,436 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models/order"
+	"github.com/ybds/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// DefaultReorderMultiplier is the multiple of the low stock threshold that a reorder should restore stock to
+const DefaultReorderMultiplier = 2
+
+// ReportService handles reporting and analytics business logic
+type ReportService struct {
+	OrderDB           *gorm.DB
+	ProductDB         *gorm.DB
+	OrderRepo         *repositories.OrderRepository
+	ProductRepo       *repositories.ProductRepository
+	ReorderMultiplier int
+}
+
+// NewReportService creates a new instance of ReportService
+func NewReportService(orderDB, productDB *gorm.DB, reorderMultiplier int) *ReportService {
+	if reorderMultiplier <= 0 {
+		reorderMultiplier = DefaultReorderMultiplier
+	}
+
+	return &ReportService{
+		OrderDB:           orderDB,
+		ProductDB:         productDB,
+		OrderRepo:         repositories.NewOrderRepository(orderDB),
+		ProductRepo:       repositories.NewProductRepository(productDB),
+		ReorderMultiplier: reorderMultiplier,
+	}
+}
+
+// LowStockItem represents an inventory at or below its low stock threshold with a reorder suggestion
+type LowStockItem struct {
+	InventoryID       uuid.UUID
+	ProductID         uuid.UUID
+	ProductName       string
+	SKU               string
+	Category          string
+	Size              string
+	Color             string
+	Location          string
+	Quantity          int
+	LowStockThreshold int
+	SuggestedQuantity int
+}
+
+// GetLowStockReport lists all inventories at or below their low stock threshold
+func (s *ReportService) GetLowStockReport() ([]LowStockItem, error) {
+	inventories, err := s.ProductRepo.GetLowStockInventories()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]LowStockItem, len(inventories))
+	for i, inv := range inventories {
+		items[i] = LowStockItem{
+			InventoryID:       inv.InventoryID,
+			ProductID:         inv.ProductID,
+			ProductName:       inv.ProductName,
+			SKU:               inv.SKU,
+			Category:          inv.Category,
+			Size:              inv.Size,
+			Color:             inv.Color,
+			Location:          inv.Location,
+			Quantity:          inv.Quantity,
+			LowStockThreshold: inv.LowStockThreshold,
+			SuggestedQuantity: SuggestReorderQuantity(inv.LowStockThreshold, inv.Quantity, s.ReorderMultiplier),
+		}
+	}
+
+	return items, nil
+}
+
+// PaymentMethodRevenue represents the revenue collected through a payment method
+type PaymentMethodRevenue struct {
+	PaymentMethod order.PaymentMethod
+	OrderCount    int64
+	TotalRevenue  float64
+}
+
+// GetRevenueByPaymentMethod returns the revenue of non-canceled orders grouped by payment method
+func (s *ReportService) GetRevenueByPaymentMethod(fromDate, toDate *time.Time) ([]PaymentMethodRevenue, error) {
+	rows, err := s.OrderRepo.GetRevenueByPaymentMethod(fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	revenues := make([]PaymentMethodRevenue, len(rows))
+	for i, row := range rows {
+		revenues[i] = PaymentMethodRevenue{
+			PaymentMethod: row.PaymentMethod,
+			OrderCount:    row.OrderCount,
+			TotalRevenue:  row.TotalRevenue,
+		}
+	}
+
+	return revenues, nil
+}
+
+// SalesGroupBy is the period the sales summary is grouped by
+type SalesGroupBy string
+
+const (
+	// SalesGroupByDay groups the sales summary by calendar day
+	SalesGroupByDay SalesGroupBy = "day"
+	// SalesGroupByWeek groups the sales summary by ISO week, starting on Monday
+	SalesGroupByWeek SalesGroupBy = "week"
+	// SalesGroupByMonth groups the sales summary by calendar month
+	SalesGroupByMonth SalesGroupBy = "month"
+)
+
+// IsValid checks if the grouping is one of the supported periods
+func (g SalesGroupBy) IsValid() bool {
+	switch g {
+	case SalesGroupByDay, SalesGroupByWeek, SalesGroupByMonth:
+		return true
+	}
+	return false
+}
+
+// SalesBucket represents the sales of the orders created in one period
+type SalesBucket struct {
+	PeriodStart time.Time
+	// OrderCount and the totals cover the non-canceled orders only
+	OrderCount    int64
+	GrossTotal    float64
+	TotalDiscount float64
+	NetTotal      float64
+	CanceledCount int64
+}
+
+// SalesSummary represents the sales of a date range grouped by period, with the overall totals
+type SalesSummary struct {
+	GroupBy       SalesGroupBy
+	Buckets       []SalesBucket
+	OrderCount    int64
+	GrossTotal    float64
+	TotalDiscount float64
+	NetTotal      float64
+	CanceledCount int64
+}
+
+// GetSalesSummary returns the order count, gross total, discount and net total of the orders created
+// between the two dates, grouped by day, week or month in chronological order. Canceled orders do not
+// count towards the revenue and are counted separately. Periods without orders are left out.
+func (s *ReportService) GetSalesSummary(fromDate, toDate *time.Time, groupBy SalesGroupBy) (*SalesSummary, error) {
+	if groupBy == "" {
+		groupBy = SalesGroupByDay
+	}
+	if !groupBy.IsValid() {
+		return nil, validationError(fmt.Sprintf("group_by must be one of %s, %s or %s", SalesGroupByDay, SalesGroupByWeek, SalesGroupByMonth))
+	}
+	if fromDate != nil && toDate != nil && fromDate.After(*toDate) {
+		return nil, validationError("from must not be after to")
+	}
+
+	rows, err := s.OrderRepo.GetSalesSummary(string(groupBy), fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &SalesSummary{GroupBy: groupBy, Buckets: make([]SalesBucket, len(rows))}
+	for i, row := range rows {
+		summary.Buckets[i] = SalesBucket{
+			PeriodStart:   row.Period,
+			OrderCount:    row.OrderCount,
+			GrossTotal:    row.GrossTotal,
+			TotalDiscount: row.TotalDiscount,
+			NetTotal:      row.NetTotal,
+			CanceledCount: row.CanceledCount,
+		}
+		summary.OrderCount += row.OrderCount
+		summary.GrossTotal += row.GrossTotal
+		summary.TotalDiscount += row.TotalDiscount
+		summary.NetTotal += row.NetTotal
+		summary.CanceledCount += row.CanceledCount
+	}
+
+	return summary, nil
+}
+
+const (
+	// DefaultTopProductsLimit is the number of products in the top products report when no limit is given
+	DefaultTopProductsLimit = 10
+	// MaxTopProductsLimit is the maximum number of products in the top products report
+	MaxTopProductsLimit = 100
+)
+
+// TopProductsSort is the measure the top products report is ranked by
+type TopProductsSort string
+
+const (
+	// TopProductsByQuantity ranks products by the number of units sold
+	TopProductsByQuantity TopProductsSort = "quantity"
+	// TopProductsByRevenue ranks products by the revenue at the order prices
+	TopProductsByRevenue TopProductsSort = "revenue"
+)
+
+// topProductsExcludedStatuses are the statuses of orders that do not count as sales
+var topProductsExcludedStatuses = []order.OrderStatus{order.OrderCanceled, order.OrderReturned}
+
+// TopProduct represents the sales of a product in the top products report
+type TopProduct struct {
+	ProductID uuid.UUID
+	Name      string
+	SKU       string
+	UnitsSold int64
+	Revenue   float64
+}
+
+// GetTopProducts returns the best selling products of the orders created between the two dates,
+// ranked by units sold or revenue and then by name. Order items are resolved to their product
+// through the inventory; returned units and canceled or returned orders are not counted. At most
+// limit products are returned.
+func (s *ReportService) GetTopProducts(fromDate, toDate *time.Time, sortBy TopProductsSort, limit int) ([]TopProduct, error) {
+	if sortBy == "" {
+		sortBy = TopProductsByQuantity
+	}
+	if sortBy != TopProductsByQuantity && sortBy != TopProductsByRevenue {
+		return nil, validationError(fmt.Sprintf("sort must be %s or %s", TopProductsByQuantity, TopProductsByRevenue))
+	}
+	if fromDate != nil && toDate != nil && fromDate.After(*toDate) {
+		return nil, validationError("from must not be after to")
+	}
+	if limit <= 0 {
+		limit = DefaultTopProductsLimit
+	}
+	if limit > MaxTopProductsLimit {
+		limit = MaxTopProductsLimit
+	}
+
+	sales, err := s.OrderRepo.GetInventorySales(fromDate, toDate, topProductsExcludedStatuses)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve the product of each sold inventory; orders and products may live in different databases
+	inventoryIDs := make([]uuid.UUID, len(sales))
+	for i, row := range sales {
+		inventoryIDs[i] = row.InventoryID
+	}
+	inventories, err := s.ProductRepo.GetInventoriesByIDs(inventoryIDs)
+	if err != nil {
+		return nil, err
+	}
+	productOf := make(map[uuid.UUID]uuid.UUID, len(inventories))
+	for _, inv := range inventories {
+		productOf[inv.ID] = inv.ProductID
+	}
+
+	// Add up the variants of each product
+	totals := make(map[uuid.UUID]*TopProduct)
+	for _, row := range sales {
+		productID, ok := productOf[row.InventoryID]
+		if !ok || row.UnitsSold <= 0 {
+			continue
+		}
+		total, ok := totals[productID]
+		if !ok {
+			total = &TopProduct{ProductID: productID}
+			totals[productID] = total
+		}
+		total.UnitsSold += row.UnitsSold
+		total.Revenue += row.Revenue
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(totals))
+	for productID := range totals {
+		productIDs = append(productIDs, productID)
+	}
+	// Products deleted since they were sold are still reported by name
+	products, err := s.ProductRepo.GetProductsByIDsIncludingDeleted(productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	top := make([]TopProduct, len(products))
+	for i, p := range products {
+		top[i] = *totals[p.ID]
+		top[i].Name = p.Name
+		top[i].SKU = p.SKU
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if sortBy == TopProductsByRevenue && top[i].Revenue != top[j].Revenue {
+			return top[i].Revenue > top[j].Revenue
+		}
+		if top[i].UnitsSold != top[j].UnitsSold {
+			return top[i].UnitsSold > top[j].UnitsSold
+		}
+		if top[i].Revenue != top[j].Revenue {
+			return top[i].Revenue > top[j].Revenue
+		}
+		return top[i].Name < top[j].Name
+	})
+
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return top, nil
+}
+
+// DefaultShipmentSLAHours is the number of hours an order may wait for a shipment before breaching the SLA
+const DefaultShipmentSLAHours = 24
+
+// ShipmentSLABreach represents an order that has waited longer than the SLA for a shipment
+type ShipmentSLABreach struct {
+	OrderID       uuid.UUID
+	OrderNumber   string
+	CustomerName  string
+	CustomerPhone string
+	Status        order.OrderStatus
+	CreatedBy     *uuid.UUID
+	WaitingSince  time.Time
+	HoursWaiting  float64
+}
+
+// GetShipmentSLABreaches lists orders awaiting shipment that have had no tracking number for more than slaHours
+func (s *ReportService) GetShipmentSLABreaches(slaHours int) ([]ShipmentSLABreach, error) {
+	if slaHours <= 0 {
+		slaHours = DefaultShipmentSLAHours
+	}
+
+	now := time.Now()
+	cutoff := now.Add(-time.Duration(slaHours) * time.Hour)
+
+	rows, err := s.OrderRepo.GetOrdersAwaitingShipment([]order.OrderStatus{order.OrderShipmentRequested}, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	breaches := make([]ShipmentSLABreach, len(rows))
+	for i, row := range rows {
+		breaches[i] = ShipmentSLABreach{
+			OrderID:       row.OrderID,
+			OrderNumber:   row.OrderNumber,
+			CustomerName:  row.CustomerName,
+			CustomerPhone: row.CustomerPhone,
+			Status:        row.OrderStatus,
+			CreatedBy:     row.CreatedBy,
+			WaitingSince:  row.WaitingSince,
+			HoursWaiting:  now.Sub(row.WaitingSince).Hours(),
+		}
+	}
+
+	return breaches, nil
+}
+
+// MaxLowStockTrendDays is the longest date range, in days, covered by a low stock trend
+const MaxLowStockTrendDays = 366
+
+// LowStockTrendDay represents the number of inventories that were low on stock at the end of a day
+type LowStockTrendDay struct {
+	Date          time.Time
+	LowStockCount int64
+}
+
+// GetLowStockTrend returns the daily number of inventories at or below their low stock threshold
+// between two dates, inclusive. The stock of past days is derived from the inventory transaction
+// ledger and compared with the current thresholds.
+func (s *ReportService) GetLowStockTrend(fromDate, toDate time.Time) ([]LowStockTrendDay, error) {
+	fromDay := time.Date(fromDate.Year(), fromDate.Month(), fromDate.Day(), 0, 0, 0, 0, fromDate.Location())
+	toDay := time.Date(toDate.Year(), toDate.Month(), toDate.Day(), 0, 0, 0, 0, toDate.Location())
+
+	if fromDay.After(toDay) {
+		return nil, validationError("from_date must not be after to_date")
+	}
+	if days := int(toDay.Sub(fromDay).Hours()/24) + 1; days > MaxLowStockTrendDays {
+		return nil, validationError(fmt.Sprintf("the date range must not exceed %d days", MaxLowStockTrendDays))
+	}
+
+	rows, err := s.ProductRepo.GetLowStockCountsByDay(fromDay, toDay)
+	if err != nil {
+		return nil, err
+	}
+
+	// The repository returns one row per day in order; the dates are taken from the
+	// requested range so they are not shifted by the database time zone
+	trend := make([]LowStockTrendDay, len(rows))
+	for i, row := range rows {
+		trend[i] = LowStockTrendDay{
+			Date:          fromDay.AddDate(0, 0, i),
+			LowStockCount: row.LowStockCount,
+		}
+	}
+
+	return trend, nil
+}
+
+// InventoryValueReport represents the value of the on-hand stock at current prices
+type InventoryValueReport struct {
+	TotalValue    float64
+	TotalQuantity int64
+	ExcludedCount int64
+	Categories    []repositories.CategoryInventoryValue
+}
+
+// GetInventoryValue returns the total value of the on-hand stock (quantity * current price) with a
+// per-category breakdown. Inventories of products without a current price are excluded and counted.
+func (s *ReportService) GetInventoryValue() (*InventoryValueReport, error) {
+	categories, err := s.ProductRepo.GetInventoryValueByCategory(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	report := &InventoryValueReport{Categories: categories}
+	for _, category := range categories {
+		report.TotalValue += category.TotalValue
+		report.TotalQuantity += category.Quantity
+		report.ExcludedCount += category.ExcludedCount
+	}
+
+	return report, nil
+}
+
+// SuggestReorderQuantity returns how many units to reorder so that stock is restored
+// to threshold*multiplier. It never returns a negative quantity.
+func SuggestReorderQuantity(threshold, current, multiplier int) int {
+	if current < 0 {
+		current = 0
+	}
+
+	suggested := threshold*multiplier - current
+	if suggested < 0 {
+		return 0
+	}
+	return suggested
+}
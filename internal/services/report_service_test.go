@@ -0,0 +1,331 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/models/order"
+	"github.com/ybds/internal/models/product"
+	"github.com/ybds/internal/services"
+	"github.com/ybds/internal/testutil"
+)
+
+// TestSuggestReorderQuantity tests the reorder suggestion formula
+func TestSuggestReorderQuantity(t *testing.T) {
+	assert.Equal(t, 8, services.SuggestReorderQuantity(5, 2, 2))
+	assert.Equal(t, 10, services.SuggestReorderQuantity(5, 0, 2))
+	assert.Equal(t, 15, services.SuggestReorderQuantity(5, 0, 3))
+	assert.Equal(t, 0, services.SuggestReorderQuantity(5, 12, 2))
+	assert.Equal(t, 10, services.SuggestReorderQuantity(5, -3, 2))
+}
+
+// TestGetLowStockReport tests that inventories at or below their own threshold are reported with a reorder suggestion
+func TestGetLowStockReport(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	reportService := services.NewReportService(db, db, 0)
+
+	p, low := seedProductWithInventory(t, db, "LOW-001", 8, 10)
+	_, healthy := seedProductWithInventory(t, db, "LOW-002", 8, 5)
+
+	items, err := reportService.GetLowStockReport()
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, low.ID, items[0].InventoryID)
+		assert.Equal(t, p.Name, items[0].ProductName)
+		assert.Equal(t, "LOW-001", items[0].SKU)
+		assert.Equal(t, 10, items[0].LowStockThreshold)
+		assert.Equal(t, 12, items[0].SuggestedQuantity)
+		assert.NotEqual(t, healthy.ID, items[0].InventoryID)
+	}
+}
+
+// TestGetShipmentSLABreaches tests that only orders waiting longer than the SLA without a tracking number are reported
+func TestGetShipmentSLABreaches(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	reportService := services.NewReportService(db, db, 0)
+
+	age := func(o *order.Order, d time.Duration) {
+		t.Helper()
+		assert.NoError(t, db.Model(o).UpdateColumn("created_at", time.Now().Add(-d)).Error)
+	}
+
+	// Just outside the 24h SLA window without a shipment: breached
+	breached := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	age(breached, 25*time.Hour)
+
+	// Shipment exists but has no tracking number yet: breached
+	emptyTracking := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	age(emptyTracking, 30*time.Hour)
+	assert.NoError(t, db.Create(&order.Shipment{OrderID: emptyTracking.ID, Carrier: "GHN"}).Error)
+
+	// Just inside the SLA window: not breached yet
+	inside := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	age(inside, 23*time.Hour)
+
+	// Old but already has a tracking number: not breached
+	tracked := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	age(tracked, 48*time.Hour)
+	assert.NoError(t, db.Create(&order.Shipment{OrderID: tracked.ID, TrackingNumber: "GHN123", Carrier: "GHN"}).Error)
+
+	// Old but already picked up by the carrier: not breached
+	picked := seedOrder(t, db, order.OrderPicked, nil)
+	age(picked, 48*time.Hour)
+
+	breaches, err := reportService.GetShipmentSLABreaches(24)
+	assert.NoError(t, err)
+	if assert.Len(t, breaches, 2) {
+		// Oldest first
+		assert.Equal(t, emptyTracking.ID, breaches[0].OrderID)
+		assert.Equal(t, breached.ID, breaches[1].OrderID)
+		assert.GreaterOrEqual(t, breaches[1].HoursWaiting, 25.0)
+	}
+}
+
+// TestGetLowStockTrend tests the daily low stock counts rebuilt from the inventory transaction ledger
+func TestGetLowStockTrend(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	reportService := services.NewReportService(db, db, 0)
+
+	today := time.Now().UTC()
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time {
+		return today.AddDate(0, 0, -days).Add(12 * time.Hour)
+	}
+
+	// Both inventories have a threshold of 5; only the first one moves
+	_, sold := seedProductWithInventory(t, db, "TREND-001", 2, 5)
+	_, steady := seedProductWithInventory(t, db, "TREND-002", 50, 5)
+	for _, inv := range []*product.Inventory{sold, steady} {
+		assert.NoError(t, db.Model(inv).UpdateColumn("created_at", daysAgo(10)).Error)
+	}
+
+	ledger := []struct {
+		quantity int
+		at       time.Time
+	}{
+		{-8, daysAgo(2)}, // 10 -> 2: low from two days ago
+		{6, daysAgo(1)},  // 2 -> 8: back above the threshold yesterday
+		{-6, daysAgo(0)}, // 8 -> 2: low again today
+	}
+	for _, entry := range ledger {
+		tx := &product.InventoryTransaction{InventoryID: sold.ID, Quantity: entry.quantity, Type: product.TransactionAdjustment, Reason: product.ReasonStockCount}
+		assert.NoError(t, db.Create(tx).Error)
+		assert.NoError(t, db.Model(tx).UpdateColumn("created_at", entry.at).Error)
+	}
+
+	trend, err := reportService.GetLowStockTrend(today.AddDate(0, 0, -3), today)
+	assert.NoError(t, err)
+	if assert.Len(t, trend, 4) {
+		expected := []int64{0, 1, 0, 1}
+		for i, day := range trend {
+			assert.Equal(t, today.AddDate(0, 0, i-3), day.Date)
+			assert.Equal(t, expected[i], day.LowStockCount, "day %d", i)
+		}
+	}
+
+	_, err = reportService.GetLowStockTrend(today, today.AddDate(0, 0, -1))
+	assert.ErrorIs(t, err, services.ErrValidation)
+
+	_, err = reportService.GetLowStockTrend(today.AddDate(-2, 0, 0), today)
+	assert.ErrorIs(t, err, services.ErrValidation)
+}
+
+// TestGetInventoryValue tests the on-hand stock value at current prices and the exclusion of unpriced stock
+func TestGetInventoryValue(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	reportService := services.NewReportService(db, db, 0)
+
+	now := time.Now()
+	yesterday := now.Add(-24 * time.Hour)
+	price := func(p *product.Product, amount float64, start time.Time, end *time.Time) {
+		t.Helper()
+		assert.NoError(t, db.Create(&product.Price{ProductID: p.ID, Price: amount, Currency: "VND", StartDate: start, EndDate: end}).Error)
+	}
+
+	// Priced shirt: only the current price counts, not the expired one
+	shirt, _ := seedProductWithInventory(t, db, "VALUE-001", 10, 2)
+	price(shirt, 50, now.Add(-48*time.Hour), &yesterday)
+	price(shirt, 100, yesterday, nil)
+
+	// Shirt without any price: excluded
+	seedProductWithInventory(t, db, "VALUE-002", 3, 2)
+
+	// Shirt whose only price has expired: excluded
+	expired, _ := seedProductWithInventory(t, db, "VALUE-003", 5, 2)
+	price(expired, 80, now.Add(-48*time.Hour), &yesterday)
+
+	// Pants with two variants sharing the product price
+	pants, _ := seedProductWithInventory(t, db, "VALUE-004", 2, 2)
+	assert.NoError(t, db.Model(pants).Update("category", "Pants").Error)
+	assert.NoError(t, db.Create(&product.Inventory{ProductID: pants.ID, Size: "L", Color: "Red", Quantity: 4}).Error)
+	price(pants, 250, yesterday, nil)
+
+	// Deleted inventories are ignored
+	_, deleted := seedProductWithInventory(t, db, "VALUE-005", 100, 2)
+	assert.NoError(t, db.Delete(deleted).Error)
+
+	report, err := reportService.GetInventoryValue()
+	assert.NoError(t, err)
+	assert.Equal(t, 2500.0, report.TotalValue)
+	assert.Equal(t, int64(16), report.TotalQuantity)
+	assert.Equal(t, int64(2), report.ExcludedCount)
+	if assert.Len(t, report.Categories, 2) {
+		assert.Equal(t, "Pants", report.Categories[0].Category)
+		assert.Equal(t, 1500.0, report.Categories[0].TotalValue)
+		assert.Equal(t, int64(0), report.Categories[0].ExcludedCount)
+		assert.Equal(t, "Shirts", report.Categories[1].Category)
+		assert.Equal(t, 1000.0, report.Categories[1].TotalValue)
+		assert.Equal(t, int64(10), report.Categories[1].Quantity)
+		assert.Equal(t, int64(2), report.Categories[1].ExcludedCount)
+	}
+}
+
+// TestGetSalesSummary tests that orders are aggregated per period in chronological order with
+// canceled orders counted separately from the revenue
+func TestGetSalesSummary(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	reportService := services.NewReportService(db, db, 0)
+
+	sale := func(total, discount float64, status order.OrderStatus, createdAt time.Time) {
+		t.Helper()
+		o := seedOrder(t, db, status, nil)
+		assert.NoError(t, db.Model(o).UpdateColumns(map[string]interface{}{
+			"total_amount":       total,
+			"discount_amount":    discount,
+			"final_total_amount": total - discount,
+			"created_at":         createdAt,
+		}).Error)
+	}
+
+	// Noon keeps every order on its calendar day whatever the database time zone
+	day := func(d int) time.Time { return time.Date(2024, time.March, d, 12, 0, 0, 0, time.UTC) }
+	sale(200, 0, order.OrderDelivered, day(5))
+	sale(100, 10, order.OrderShipmentRequested, day(4))
+	sale(50, 0, order.OrderCanceled, day(4))
+	sale(300, 30, order.OrderPacked, day(12))
+	// Outside the requested range
+	sale(1000, 0, order.OrderDelivered, day(20))
+
+	from := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, time.March, 15, 23, 59, 59, 0, time.UTC)
+
+	t.Run("ByDay", func(t *testing.T) {
+		summary, err := reportService.GetSalesSummary(&from, &to, services.SalesGroupByDay)
+		assert.NoError(t, err)
+		if !assert.Len(t, summary.Buckets, 3) {
+			return
+		}
+
+		first := summary.Buckets[0]
+		assert.Equal(t, "2024-03-04", first.PeriodStart.Format("2006-01-02"))
+		assert.Equal(t, int64(1), first.OrderCount)
+		assert.Equal(t, 100.0, first.GrossTotal)
+		assert.Equal(t, 10.0, first.TotalDiscount)
+		assert.Equal(t, 90.0, first.NetTotal)
+		assert.Equal(t, int64(1), first.CanceledCount)
+
+		assert.Equal(t, "2024-03-05", summary.Buckets[1].PeriodStart.Format("2006-01-02"))
+		assert.Equal(t, 200.0, summary.Buckets[1].NetTotal)
+		assert.Equal(t, "2024-03-12", summary.Buckets[2].PeriodStart.Format("2006-01-02"))
+		assert.Equal(t, 270.0, summary.Buckets[2].NetTotal)
+
+		assert.Equal(t, int64(3), summary.OrderCount)
+		assert.Equal(t, 600.0, summary.GrossTotal)
+		assert.Equal(t, 40.0, summary.TotalDiscount)
+		assert.Equal(t, 560.0, summary.NetTotal)
+		assert.Equal(t, int64(1), summary.CanceledCount)
+	})
+
+	t.Run("ByWeek", func(t *testing.T) {
+		summary, err := reportService.GetSalesSummary(&from, &to, services.SalesGroupByWeek)
+		assert.NoError(t, err)
+		if assert.Len(t, summary.Buckets, 2) {
+			// Weeks start on Monday, March 4th and 11th
+			assert.Equal(t, "2024-03-04", summary.Buckets[0].PeriodStart.Format("2006-01-02"))
+			assert.Equal(t, int64(2), summary.Buckets[0].OrderCount)
+			assert.Equal(t, 290.0, summary.Buckets[0].NetTotal)
+			assert.Equal(t, "2024-03-11", summary.Buckets[1].PeriodStart.Format("2006-01-02"))
+			assert.Equal(t, int64(1), summary.Buckets[1].OrderCount)
+		}
+	})
+
+	t.Run("ByMonth", func(t *testing.T) {
+		summary, err := reportService.GetSalesSummary(nil, nil, services.SalesGroupByMonth)
+		assert.NoError(t, err)
+		if assert.Len(t, summary.Buckets, 1) {
+			assert.Equal(t, int64(4), summary.Buckets[0].OrderCount)
+			assert.Equal(t, 1560.0, summary.Buckets[0].NetTotal)
+			assert.Equal(t, int64(1), summary.Buckets[0].CanceledCount)
+		}
+	})
+
+	t.Run("InvalidGrouping", func(t *testing.T) {
+		_, err := reportService.GetSalesSummary(&from, &to, "year")
+		assert.ErrorIs(t, err, services.ErrValidation)
+	})
+}
+
+// TestGetTopProducts tests that sales are resolved to products through their inventories and ranked
+// by quantity or revenue without canceled or returned orders
+func TestGetTopProducts(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	reportService := services.NewReportService(db, db, 0)
+
+	shirt, shirtM := seedProductWithInventory(t, db, "SHIRT-001", 10, 2)
+	shirtL := &product.Inventory{ProductID: shirt.ID, Size: "L", Color: "Red", Quantity: 10}
+	assert.NoError(t, db.Create(shirtL).Error)
+	watch, watchInv := seedProductWithInventory(t, db, "WATCH-001", 10, 2)
+	socks, socksInv := seedProductWithInventory(t, db, "SOCKS-001", 10, 2)
+
+	item := func(o *order.Order, inv *product.Inventory, quantity, returned int, price float64) {
+		t.Helper()
+		assert.NoError(t, db.Create(&order.OrderItem{OrderID: o.ID, InventoryID: inv.ID, Quantity: quantity,
+			ReturnedQuantity: returned, PriceAtOrder: price}).Error)
+	}
+
+	delivered := seedOrder(t, db, order.OrderDelivered, nil)
+	item(delivered, shirtM, 3, 0, 100)
+	item(delivered, watchInv, 1, 0, 1000)
+	packed := seedOrder(t, db, order.OrderPacked, nil)
+	item(packed, shirtL, 2, 0, 100)
+	partlyReturned := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	item(partlyReturned, socksInv, 4, 1, 20)
+
+	// Canceled and returned orders do not count
+	item(seedOrder(t, db, order.OrderCanceled, nil), socksInv, 10, 0, 20)
+	item(seedOrder(t, db, order.OrderReturned, nil), watchInv, 5, 0, 1000)
+
+	top, err := reportService.GetTopProducts(nil, nil, services.TopProductsByQuantity, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, top, 3) {
+		assert.Equal(t, shirt.ID, top[0].ProductID)
+		assert.Equal(t, "SHIRT-001", top[0].SKU)
+		assert.Equal(t, int64(5), top[0].UnitsSold)
+		assert.Equal(t, 500.0, top[0].Revenue)
+		assert.Equal(t, socks.ID, top[1].ProductID)
+		assert.Equal(t, int64(3), top[1].UnitsSold)
+		assert.Equal(t, 60.0, top[1].Revenue)
+		assert.Equal(t, watch.ID, top[2].ProductID)
+		assert.Equal(t, int64(1), top[2].UnitsSold)
+	}
+
+	top, err = reportService.GetTopProducts(nil, nil, services.TopProductsByRevenue, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, top, 3) {
+		assert.Equal(t, watch.ID, top[0].ProductID)
+		assert.Equal(t, 1000.0, top[0].Revenue)
+		assert.Equal(t, shirt.ID, top[1].ProductID)
+		assert.Equal(t, socks.ID, top[2].ProductID)
+	}
+
+	// The limit keeps the best ranked products
+	top, err = reportService.GetTopProducts(nil, nil, services.TopProductsByQuantity, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, top, 1) {
+		assert.Equal(t, shirt.ID, top[0].ProductID)
+	}
+
+	_, err = reportService.GetTopProducts(nil, nil, "margin", 10)
+	assert.ErrorIs(t, err, services.ErrValidation)
+}
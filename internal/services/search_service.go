@@ -0,0 +1,69 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/ybds/internal/models/order"
+	"github.com/ybds/internal/models/product"
+	"github.com/ybds/internal/repositories"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultSearchLimit is the number of results returned per section when no limit is given
+	DefaultSearchLimit = 5
+	// MaxSearchLimit is the maximum number of results returned per section
+	MaxSearchLimit = 20
+)
+
+// SearchService handles searching across orders and products
+type SearchService struct {
+	OrderRepo   *repositories.OrderRepository
+	ProductRepo *repositories.ProductRepository
+}
+
+// NewSearchService creates a new instance of SearchService
+func NewSearchService(orderDB, productDB *gorm.DB) *SearchService {
+	return &SearchService{
+		OrderRepo:   repositories.NewOrderRepository(orderDB),
+		ProductRepo: repositories.NewProductRepository(productDB),
+	}
+}
+
+// SearchResult holds the matches of a search, one section per resource type
+type SearchResult struct {
+	Query    string
+	Orders   []order.Order
+	Products []product.Product
+}
+
+// Search finds orders by number, phone or customer name and products by name or SKU.
+// Each section is limited to limit results.
+func (s *SearchService) Search(query string, limit int) (*SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, validationError("search query is required")
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	orders, err := s.OrderRepo.SearchOrders(query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := s.ProductRepo.SearchProducts(query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SearchResult{
+		Query:    query,
+		Orders:   orders,
+		Products: products,
+	}, nil
+}
@@ -0,0 +1,46 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/models/order"
+	"github.com/ybds/internal/services"
+	"github.com/ybds/internal/testutil"
+)
+
+// TestSearchRequiresQuery tests that an empty query is rejected as a validation error
+func TestSearchRequiresQuery(t *testing.T) {
+	searchService := services.NewSearchService(nil, nil)
+
+	_, err := searchService.Search("   ", 5)
+	assert.ErrorIs(t, err, services.ErrValidation)
+}
+
+// TestSearch tests that a query matching both an order and a product populates both sections
+func TestSearch(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	searchService := services.NewSearchService(db, db)
+
+	matchingOrder := seedOrder(t, db, order.OrderShipmentRequested, nil)
+	assert.NoError(t, db.Model(matchingOrder).Update("customer_name", "Tran Thi Lan").Error)
+	seedOrder(t, db, order.OrderShipmentRequested, nil)
+
+	matchingProduct, _ := seedProductWithInventory(t, db, "LAN-001", 10, 2)
+	seedProductWithInventory(t, db, "OTHER-001", 10, 2)
+
+	result, err := searchService.Search("lan", 5)
+	assert.NoError(t, err)
+	if assert.Len(t, result.Orders, 1) {
+		assert.Equal(t, matchingOrder.ID, result.Orders[0].ID)
+	}
+	if assert.Len(t, result.Products, 1) {
+		assert.Equal(t, matchingProduct.ID, result.Products[0].ID)
+	}
+
+	// Wildcards in the query are matched literally
+	result, err = searchService.Search("%", 5)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Orders)
+	assert.Empty(t, result.Products)
+}
@@ -43,6 +43,11 @@ func (s *UserService) GetUserByID(id uuid.UUID) (*account.User, error) {
 	return s.UserRepo.GetUserByID(id)
 }
 
+// GetUsersByIDs retrieves the users with the given IDs
+func (s *UserService) GetUsersByIDs(ids []uuid.UUID) ([]account.User, error) {
+	return s.UserRepo.GetUsersByIDs(ids)
+}
+
 // GetUserByUsernameOrEmail retrieves a user by username or email
 func (s *UserService) GetUserByUsernameOrEmail(usernameOrEmail string) (*account.User, error) {
 	var user account.User
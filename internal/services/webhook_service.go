@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ybds/internal/models/order"
+	"github.com/ybds/internal/repositories"
+	"github.com/ybds/pkg/webhook"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultWebhookMaxAttempts is how many times a delivery is attempted before it is marked as failed
+	DefaultWebhookMaxAttempts = 5
+	// DefaultWebhookRetryBackoff is the delay before the first retry; it doubles with every failed attempt
+	DefaultWebhookRetryBackoff = 30 * time.Second
+	// MaxWebhookRetryDelay is the longest delay between two attempts of a delivery
+	MaxWebhookRetryDelay = time.Hour
+	// webhookRetryBatchSize is the largest number of deliveries retried in one run of the retry worker
+	webhookRetryBatchSize = 100
+)
+
+// WebhookSettings holds the configurable behavior of the WebhookService
+type WebhookSettings struct {
+	// MaxAttempts is how many times a delivery is attempted before it is marked as failed
+	MaxAttempts int
+	// RetryBackoff is the delay before the first retry; it doubles with every failed attempt
+	RetryBackoff time.Duration
+	// Timeout is how long a single delivery attempt may take
+	Timeout time.Duration
+}
+
+// WithDefaults returns a copy of the settings with unset values replaced by their defaults
+func (s WebhookSettings) WithDefaults() WebhookSettings {
+	if s.MaxAttempts <= 0 {
+		s.MaxAttempts = DefaultWebhookMaxAttempts
+	}
+	if s.RetryBackoff <= 0 {
+		s.RetryBackoff = DefaultWebhookRetryBackoff
+	}
+	if s.Timeout <= 0 {
+		s.Timeout = webhook.DefaultTimeout
+	}
+	return s
+}
+
+// WebhookService handles the delivery of order events to external systems
+type WebhookService struct {
+	DB          *gorm.DB
+	WebhookRepo *repositories.WebhookRepository
+	Client      *webhook.Client
+	Settings    WebhookSettings
+
+	// inFlight tracks the deliveries attempted in the background
+	inFlight sync.WaitGroup
+}
+
+// NewWebhookService creates a new instance of WebhookService
+func NewWebhookService(db *gorm.DB, settings WebhookSettings) *WebhookService {
+	settings = settings.WithDefaults()
+	return &WebhookService{
+		DB:          db,
+		WebhookRepo: repositories.NewWebhookRepository(db),
+		Client:      webhook.NewClient(settings.Timeout),
+		Settings:    settings,
+	}
+}
+
+// WebhookRetryDelay returns how long to wait before retrying a delivery that has failed the given
+// number of attempts: the backoff doubled for every attempt after the first, capped at MaxWebhookRetryDelay
+func WebhookRetryDelay(backoff time.Duration, attempts int) time.Duration {
+	return retryDelay(backoff, attempts, MaxWebhookRetryDelay)
+}
+
+// retryDelay returns the backoff doubled for every attempt after the first, capped at maxDelay
+func retryDelay(backoff time.Duration, attempts int, maxDelay time.Duration) time.Duration {
+	delay := backoff
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// webhookPayload is the JSON body sent to webhook endpoints
+type webhookPayload struct {
+	Event     order.WebhookEvent     `json:"event"`
+	CreatedAt time.Time              `json:"created_at"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// Dispatch records a delivery of the event for every active endpoint subscribed to it and attempts
+// the deliveries in the background. Deliveries that fail are retried by the retry worker.
+func (s *WebhookService) Dispatch(event order.WebhookEvent, orderID *uuid.UUID, data map[string]interface{}) error {
+	endpoints, err := s.WebhookRepo.GetActiveEndpoints()
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	for _, endpoint := range endpoints {
+		if !endpoint.Subscribes(event) {
+			continue
+		}
+
+		if payload == nil {
+			payload, err = json.Marshal(webhookPayload{Event: event, CreatedAt: time.Now(), Data: data})
+			if err != nil {
+				return err
+			}
+		}
+
+		// The first attempt runs right away; the retry worker only picks the delivery up if that
+		// attempt never completes, e.g. because the server stopped
+		nextAttemptAt := time.Now().Add(s.Settings.RetryBackoff)
+		delivery := &order.WebhookDelivery{
+			EndpointID:    endpoint.ID,
+			Event:         event,
+			OrderID:       orderID,
+			Payload:       string(payload),
+			Status:        order.DeliveryPending,
+			NextAttemptAt: &nextAttemptAt,
+		}
+		if err := s.WebhookRepo.CreateDelivery(delivery); err != nil {
+			return err
+		}
+
+		s.inFlight.Add(1)
+		go func(endpoint order.WebhookEndpoint, delivery *order.WebhookDelivery) {
+			defer s.inFlight.Done()
+			if err := s.attemptDelivery(&endpoint, delivery); err != nil {
+				log.Printf("Failed to record %s webhook delivery %s: %v", delivery.Event, delivery.ID, err)
+			}
+		}(endpoint, delivery)
+	}
+
+	return nil
+}
+
+// Wait blocks until the deliveries attempted in the background have completed
+func (s *WebhookService) Wait() {
+	s.inFlight.Wait()
+}
+
+// attemptDelivery sends a delivery to its endpoint and records the outcome. A failed attempt is
+// scheduled for a retry until the maximum number of attempts is reached.
+func (s *WebhookService) attemptDelivery(endpoint *order.WebhookEndpoint, delivery *order.WebhookDelivery) error {
+	result, sendErr := s.Client.Send(endpoint.URL, endpoint.Secret, string(delivery.Event), delivery.ID.String(), []byte(delivery.Payload))
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = 0
+	delivery.ResponseBody = ""
+	if result != nil {
+		delivery.ResponseStatus = result.StatusCode
+		delivery.ResponseBody = result.Body
+	}
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = order.DeliverySucceeded
+		delivery.NextAttemptAt = nil
+		delivery.LastError = ""
+	case delivery.Attempts >= s.Settings.MaxAttempts:
+		delivery.Status = order.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		delivery.LastError = sendErr.Error()
+	default:
+		nextAttemptAt := now.Add(WebhookRetryDelay(s.Settings.RetryBackoff, delivery.Attempts))
+		delivery.NextAttemptAt = &nextAttemptAt
+		delivery.LastError = sendErr.Error()
+	}
+
+	return s.WebhookRepo.UpdateDelivery(delivery)
+}
+
+// RetryDueDeliveries attempts the pending deliveries whose retry is due and returns how many were attempted.
+// Deliveries of endpoints that have been deleted are marked as failed.
+func (s *WebhookService) RetryDueDeliveries() (int, error) {
+	deliveries, err := s.WebhookRepo.GetDueDeliveries(time.Now(), webhookRetryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		endpoint, err := s.WebhookRepo.GetEndpointByID(delivery.EndpointID)
+		if err != nil {
+			delivery.Status = order.DeliveryFailed
+			delivery.NextAttemptAt = nil
+			delivery.LastError = "webhook endpoint no longer exists"
+			if err := s.WebhookRepo.UpdateDelivery(delivery); err != nil {
+				return i, err
+			}
+			continue
+		}
+
+		if err := s.attemptDelivery(endpoint, delivery); err != nil {
+			return i, err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// RunRetryWorker periodically retries the pending deliveries whose retry is due until the context
+// is canceled. A non-positive interval disables the worker.
+func (s *WebhookService) RunRetryWorker(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		log.Println("Webhook retry worker is disabled")
+		return
+	}
+
+	log.Printf("Webhook retry worker started (interval: %s)", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Webhook retry worker stopped")
+			return
+		case <-ticker.C:
+			retried, err := s.RetryDueDeliveries()
+			if err != nil {
+				log.Printf("Error retrying webhook deliveries: %v", err)
+				continue
+			}
+			if retried > 0 {
+				log.Printf("Retried %d webhook deliveries", retried)
+			}
+		}
+	}
+}
+
+// CreateEndpoint registers an external URL that receives the given order events. When no secret
+// is given a random one is generated; it is returned once on the created endpoint.
+func (s *WebhookService) CreateEndpoint(rawURL, secret string, events []order.WebhookEvent, description string) (*order.WebhookEndpoint, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, validationError("url must be an absolute http or https URL")
+	}
+
+	if len(events) == 0 {
+		return nil, validationError("at least one event is required")
+	}
+	names := make([]string, 0, len(events))
+	for _, event := range events {
+		if !event.IsValid() {
+			return nil, validationError(fmt.Sprintf("unknown webhook event %s; must be one of %s and %s",
+				event, order.WebhookOrderCreated, order.WebhookOrderStatusChanged))
+		}
+		names = append(names, string(event))
+	}
+
+	if secret == "" {
+		if secret, err = generateWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	endpoint := &order.WebhookEndpoint{
+		URL:         parsed.String(),
+		Secret:      secret,
+		Events:      strings.Join(names, ","),
+		Description: description,
+		Active:      true,
+	}
+	if err := s.WebhookRepo.CreateEndpoint(endpoint); err != nil {
+		return nil, err
+	}
+
+	return endpoint, nil
+}
+
+// generateWebhookSecret returns a random 32-byte secret, hex encoded
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// GetEndpoints lists all webhook endpoints
+func (s *WebhookService) GetEndpoints() ([]order.WebhookEndpoint, error) {
+	return s.WebhookRepo.GetEndpoints()
+}
+
+// DeleteEndpoint removes a webhook endpoint; its pending deliveries are no longer retried
+func (s *WebhookService) DeleteEndpoint(id uuid.UUID) error {
+	if _, err := s.WebhookRepo.GetEndpointByID(id); err != nil {
+		return notFoundError("webhook endpoint", err)
+	}
+	return s.WebhookRepo.DeleteEndpoint(id)
+}
+
+// GetDeliveries lists the deliveries of a webhook endpoint, newest first
+func (s *WebhookService) GetDeliveries(endpointID uuid.UUID, page, pageSize int) ([]order.WebhookDelivery, int64, error) {
+	if _, err := s.WebhookRepo.GetEndpointByID(endpointID); err != nil {
+		return nil, 0, notFoundError("webhook endpoint", err)
+	}
+	return s.WebhookRepo.GetDeliveriesByEndpointID(endpointID, page, pageSize)
+}
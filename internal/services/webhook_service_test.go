@@ -0,0 +1,159 @@
+package services_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/models/order"
+	"github.com/ybds/internal/models/product"
+	"github.com/ybds/internal/services"
+	"github.com/ybds/internal/testutil"
+	"github.com/ybds/pkg/webhook"
+)
+
+// TestWebhookRetryDelay tests that the retry delay doubles with every attempt up to the maximum
+func TestWebhookRetryDelay(t *testing.T) {
+	assert.Equal(t, 30*time.Second, services.WebhookRetryDelay(30*time.Second, 1))
+	assert.Equal(t, 60*time.Second, services.WebhookRetryDelay(30*time.Second, 2))
+	assert.Equal(t, 120*time.Second, services.WebhookRetryDelay(30*time.Second, 3))
+	assert.Equal(t, services.MaxWebhookRetryDelay, services.WebhookRetryDelay(30*time.Second, 20))
+}
+
+// TestWebhookDispatchedOnOrderCreation tests that creating an order delivers a signed order.created webhook
+func TestWebhookDispatchedOnOrderCreation(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	var mu sync.Mutex
+	var received []*http.Request
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, r)
+		bodies = append(bodies, body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhookService := services.NewWebhookService(db, services.WebhookSettings{})
+	endpoint, err := webhookService.CreateEndpoint(server.URL, "secret", []order.WebhookEvent{order.WebhookOrderCreated}, "")
+	assert.NoError(t, err)
+
+	productService := services.NewProductService(db, nil, nil)
+	orderService := services.NewOrderService(db, productService, nil, nil)
+	orderService.WebhookService = webhookService
+
+	p, inv := seedProductWithInventory(t, db, "HOOK-001", 10, 2)
+	assert.NoError(t, db.Create(&product.Price{ProductID: p.ID, Price: 100, Currency: "VND", StartDate: time.Now().Add(-time.Hour)}).Error)
+	createdBy := uuid.New()
+	result, err := orderService.CreateOrder(order.PaymentCash, []services.OrderItemInfo{{InventoryID: inv.ID, Quantity: 1}}, 0, nil, "",
+		&createdBy, "", "", "", "", "", "John Doe", "", "0912345678", "", true)
+	assert.NoError(t, err)
+
+	webhookService.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !assert.Len(t, received, 1) {
+		return
+	}
+	r := received[0]
+	assert.Equal(t, string(order.WebhookOrderCreated), r.Header.Get(webhook.HeaderEvent))
+	timestamp, err := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+	assert.NoError(t, err)
+	assert.True(t, webhook.Verify("secret", timestamp, bodies[0], r.Header.Get(webhook.HeaderSignature)))
+
+	var payload struct {
+		Event string                 `json:"event"`
+		Data  map[string]interface{} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(bodies[0], &payload))
+	assert.Equal(t, string(order.WebhookOrderCreated), payload.Event)
+	assert.Equal(t, result.OrderID.String(), payload.Data["order_id"])
+
+	deliveries, total, err := webhookService.GetDeliveries(endpoint.ID, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, deliveries, 1) {
+		assert.Equal(t, order.DeliverySucceeded, deliveries[0].Status)
+		assert.Equal(t, 1, deliveries[0].Attempts)
+		assert.Equal(t, http.StatusOK, deliveries[0].ResponseStatus)
+		assert.Nil(t, deliveries[0].NextAttemptAt)
+	}
+}
+
+// TestWebhookRetriedOnFailure tests that a failed delivery is scheduled for a retry and retried once due
+func TestWebhookRetriedOnFailure(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	var healthy atomic.Bool
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	webhookService := services.NewWebhookService(db, services.WebhookSettings{MaxAttempts: 3})
+	endpoint, err := webhookService.CreateEndpoint(server.URL, "", []order.WebhookEvent{order.WebhookOrderStatusChanged}, "")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, endpoint.Secret)
+
+	orderService := services.NewOrderService(db, nil, nil, nil)
+	orderService.WebhookService = webhookService
+	o := seedOrder(t, db, order.OrderShipmentRequested, nil)
+
+	_, err = orderService.UpdateOrderStatus(o.ID, order.OrderPacked)
+	assert.NoError(t, err)
+	webhookService.Wait()
+
+	deliveries, _, err := webhookService.GetDeliveries(endpoint.ID, 1, 10)
+	assert.NoError(t, err)
+	if !assert.Len(t, deliveries, 1) {
+		return
+	}
+	delivery := deliveries[0]
+	assert.Equal(t, order.DeliveryPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, delivery.ResponseStatus)
+	assert.NotEmpty(t, delivery.LastError)
+	if assert.NotNil(t, delivery.NextAttemptAt) {
+		assert.True(t, delivery.NextAttemptAt.After(time.Now()))
+	}
+
+	// Nothing is retried before the retry is due
+	retried, err := webhookService.RetryDueDeliveries()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, retried)
+
+	// Once due, the delivery is retried and succeeds against the recovered server
+	healthy.Store(true)
+	assert.NoError(t, db.Model(&order.WebhookDelivery{}).Where("id = ?", delivery.ID).
+		Update("next_attempt_at", time.Now().Add(-time.Minute)).Error)
+
+	retried, err = webhookService.RetryDueDeliveries()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, retried)
+	assert.Equal(t, int32(2), requests.Load())
+
+	retriedDelivery, err := webhookService.WebhookRepo.GetDeliveryByID(delivery.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, order.DeliverySucceeded, retriedDelivery.Status)
+	assert.Equal(t, 2, retriedDelivery.Attempts)
+	assert.Equal(t, http.StatusNoContent, retriedDelivery.ResponseStatus)
+	assert.Empty(t, retriedDelivery.LastError)
+}
@@ -0,0 +1,132 @@
+package testutil
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ybds/internal/database"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// TestDatabaseURLEnv is the environment variable holding the DSN of the database used by integration tests
+const TestDatabaseURLEnv = "TEST_DATABASE_URL"
+
+// SetupTestDB connects to the integration test database and migrates all models.
+// The test is skipped when TEST_DATABASE_URL is not set. All tables are truncated
+// when the test finishes, so the database must be dedicated to tests.
+func SetupTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv(TestDatabaseURLEnv)
+	if dsn == "" {
+		t.Skipf("Skipping integration test: %s is not set", TestDatabaseURLEnv)
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+
+	if err := database.InitDatabase(db); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+
+	truncateAllTables(t, db)
+	t.Cleanup(func() {
+		truncateAllTables(t, db)
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	return db
+}
+
+// ClosedTestDB returns a connection to the integration test database that is already closed,
+// so every query fails as if the database were unreachable. The test is skipped when
+// TEST_DATABASE_URL is not set.
+func ClosedTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv(TestDatabaseURLEnv)
+	if dsn == "" {
+		t.Skipf("Skipping integration test: %s is not set", TestDatabaseURLEnv)
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get test database connection: %v", err)
+	}
+	sqlDB.Close()
+
+	return db
+}
+
+// truncateAllTables removes all rows from every table in the current schema
+func truncateAllTables(t testing.TB, db *gorm.DB) {
+	t.Helper()
+
+	var tables []string
+	if err := db.Raw("SELECT tablename FROM pg_tables WHERE schemaname = current_schema()").
+		Scan(&tables).Error; err != nil {
+		t.Fatalf("failed to list test tables: %v", err)
+	}
+	if len(tables) == 0 {
+		return
+	}
+
+	quoted := make([]string, len(tables))
+	for i, table := range tables {
+		quoted[i] = fmt.Sprintf("%q", table)
+	}
+
+	if err := db.Exec("TRUNCATE TABLE " + strings.Join(quoted, ", ") + " CASCADE").Error; err != nil {
+		t.Fatalf("failed to truncate test tables: %v", err)
+	}
+}
+
+// QueryCounter counts the queries run through a database connection
+type QueryCounter struct {
+	count atomic.Int64
+}
+
+// CountQueries registers callbacks on the connection that count every query it runs, including
+// preloads and raw row queries
+func CountQueries(t testing.TB, db *gorm.DB) *QueryCounter {
+	t.Helper()
+
+	counter := &QueryCounter{}
+	increment := func(*gorm.DB) { counter.count.Add(1) }
+	if err := db.Callback().Query().Before("gorm:query").Register("testutil:count_queries", increment); err != nil {
+		t.Fatalf("failed to register query counter: %v", err)
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register("testutil:count_rows", increment); err != nil {
+		t.Fatalf("failed to register row query counter: %v", err)
+	}
+
+	return counter
+}
+
+// Count returns the number of queries run since the counter was created or last reset
+func (c *QueryCounter) Count() int64 {
+	return c.count.Load()
+}
+
+// Reset sets the number of queries back to zero
+func (c *QueryCounter) Reset() {
+	c.count.Store(0)
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// RoundMoney rounds a monetary amount to two decimal places
+func RoundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// currencyFormat describes how amounts of a currency are written
+type currencyFormat struct {
+	Symbol   string
+	Decimals int
+	Prefix   bool
+}
+
+// currencyFormats lists the currencies with a known symbol and precision
+var currencyFormats = map[string]currencyFormat{
+	"VND": {Symbol: "₫", Decimals: 0},
+	"USD": {Symbol: "$", Decimals: 2, Prefix: true},
+	"EUR": {Symbol: "€", Decimals: 2},
+	"JPY": {Symbol: "¥", Decimals: 0, Prefix: true},
+}
+
+// commaDecimalLanguages lists the locale languages that group digits with "." and use "," as decimal mark
+var commaDecimalLanguages = map[string]bool{
+	"vi": true,
+	"id": true,
+	"de": true,
+	"es": true,
+	"it": true,
+}
+
+// FormatMoney formats an amount in the given ISO 4217 currency for display, e.g. "150,000 ₫" or
+// "$1,234.50". The locale (e.g. "en-US" or "vi-VN") picks the digit grouping and decimal mark;
+// the currency picks the symbol and the number of decimals. Unknown currencies are written with
+// two decimals followed by their code.
+func FormatMoney(amount float64, currency, locale string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	format, ok := currencyFormats[currency]
+	if !ok {
+		format = currencyFormat{Symbol: currency, Decimals: 2}
+	}
+
+	groupSeparator, decimalMark := ",", "."
+	language := strings.ToLower(strings.SplitN(strings.ReplaceAll(locale, "_", "-"), "-", 2)[0])
+	if commaDecimalLanguages[language] {
+		groupSeparator, decimalMark = ".", ","
+	}
+
+	digits := strconv.FormatFloat(math.Abs(amount), 'f', format.Decimals, 64)
+	integer, fraction, _ := strings.Cut(digits, ".")
+
+	var number strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			number.WriteString(groupSeparator)
+		}
+		number.WriteRune(digit)
+	}
+	if fraction != "" {
+		number.WriteString(decimalMark + fraction)
+	}
+
+	text := number.String()
+	if format.Symbol != "" {
+		if format.Prefix {
+			text = format.Symbol + text
+		} else {
+			text = text + " " + format.Symbol
+		}
+	}
+
+	// Amounts that round to zero are not written as negative
+	if amount < 0 && strings.Trim(digits, "0.") != "" {
+		text = "-" + text
+	}
+	return text
+}
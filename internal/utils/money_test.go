@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFormatMoney tests currency formatting for zero-decimal and two-decimal currencies
+func TestFormatMoney(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   float64
+		currency string
+		locale   string
+		expected string
+	}{
+		{"VND has no decimals", 150000, "VND", "en-US", "150,000 ₫"},
+		{"VND rounds fractions", 149999.6, "VND", "en-US", "150,000 ₫"},
+		{"VND with Vietnamese grouping", 1250000, "VND", "vi-VN", "1.250.000 ₫"},
+		{"USD has two decimals", 1234.5, "USD", "en-US", "$1,234.50"},
+		{"USD below a thousand", 9.99, "usd", "en-US", "$9.99"},
+		{"USD with Vietnamese separators", 1234.5, "USD", "vi_VN", "$1.234,50"},
+		{"negative amount", -2500, "USD", "en-US", "-$2,500.00"},
+		{"negative amount rounding to zero", -0.2, "VND", "en-US", "0 ₫"},
+		{"unknown currency", 1000, "THB", "en-US", "1,000.00 THB"},
+		{"empty locale", 1000000, "VND", "", "1,000,000 ₫"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, FormatMoney(tt.amount, tt.currency, tt.locale))
+		})
+	}
+}
@@ -19,9 +19,18 @@ type Config struct {
 	ProductDB      DatabaseConfig
 	Server         ServerConfig
 	JWT            JWTConfig
+	Auth           AuthConfig
 	Upload         UploadConfig
 	Telegram       TelegramConfig
+	SMS            SMSConfig
+	Email          EmailConfig
 	AWS            AWSConfig
+	Inventory      InventoryConfig
+	Pricing        PricingConfig
+	Notification   NotificationConfig
+	Shipping       ShippingConfig
+	Order          OrderConfig
+	Webhook        WebhookConfig
 }
 
 // DatabaseConfig holds all database related configuration
@@ -36,25 +45,56 @@ type DatabaseConfig struct {
 
 // ServerConfig holds all server related configuration
 type ServerConfig struct {
-	Port string
-	Env  string
+	Port           string
+	Env            string
+	RequestTimeout string
 }
 
 // JWTConfig holds all JWT related configuration
 type JWTConfig struct {
 	Secret string
+	// Expiry is the lifetime of access tokens
 	Expiry string
+	// RefreshExpiry is the lifetime of refresh tokens
+	RefreshExpiry string
+}
+
+// AuthConfig holds all authentication related configuration
+type AuthConfig struct {
+	// PasswordResetExpiry is how long a password reset token stays valid
+	PasswordResetExpiry string
 }
 
 // UploadConfig holds all upload related configuration
 type UploadConfig struct {
 	Dir       string
 	MaxSizeMB int
+	Storage   string
 }
 
 // TelegramConfig holds all Telegram related configuration
 type TelegramConfig struct {
-	BotToken string
+	BotToken      string
+	ChatDiscovery bool
+}
+
+// SMSConfig holds all SMS related configuration. Messages are sent through Twilio;
+// SMS notifications are disabled when no account SID is set.
+type SMSConfig struct {
+	TwilioAccountSID string
+	TwilioAuthToken  string
+	FromNumber       string
+}
+
+// EmailConfig holds all email related configuration. Email notifications are only sent
+// when Enabled is set.
+type EmailConfig struct {
+	Enabled      bool
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	From         string
 }
 
 // AWSConfig holds all AWS related configuration
@@ -64,6 +104,73 @@ type AWSConfig struct {
 	Region    string
 	Bucket    string
 	Prefix    string
+	Endpoint  string
+	BaseURL   string
+}
+
+// InventoryConfig holds all inventory related configuration
+type InventoryConfig struct {
+	ReorderMultiplier int
+}
+
+// PricingConfig holds all pricing related configuration
+type PricingConfig struct {
+	MultipleCurrentPrices string
+	ExchangeRates         string
+	ExchangeRatesURL      string
+	ExchangeRatesTTL      string
+}
+
+// NotificationConfig holds all notification related configuration
+type NotificationConfig struct {
+	RetentionDays    int
+	CleanupInterval  string
+	RetryMaxAttempts int
+	RetryBackoff     string
+	RetryInterval    string
+}
+
+// WebhookConfig holds all outbound webhook related configuration
+type WebhookConfig struct {
+	MaxAttempts   int
+	RetryBackoff  string
+	RetryInterval string
+	Timeout       string
+}
+
+// ShippingConfig holds all shipping related configuration
+type ShippingConfig struct {
+	VolumetricDivisor float64
+	SenderName        string
+	SenderPhone       string
+	SenderAddress     string
+	SenderWard        string
+	SenderDistrict    string
+	SenderCity        string
+	SenderCountry     string
+	// GHNWebhookSecret is the token GHN sends in the X-GHN-Token header of its webhooks
+	GHNWebhookSecret string
+	// GHNStatusOverrides remaps GHN statuses as a comma-separated list of ghn_status:order_status
+	GHNStatusOverrides string
+}
+
+// OrderConfig holds all order related configuration
+type OrderConfig struct {
+	DiscountApprovalAmount  float64
+	DiscountApprovalPercent float64
+	NumberPrefix            string
+	NumberReset             string
+	AgentTargetStatuses     string
+	CheckAvailableToPromise bool
+	ReserveOnCreate         bool
+	BulkStatusMaxOrders     int
+	RequireDeliveryProof    bool
+	DiscountReasonRequired  bool
+	DiscountReasonMaxLength int
+	AdminEditHandedOver     bool
+	Currency                string
+	CurrencyLocale          string
+	HoldSweepInterval       string
 }
 
 // LoadConfig loads the configuration from .env file and environment variables
@@ -116,19 +223,39 @@ func LoadConfig() (*Config, error) {
 			SSLMode:  v.GetString("db.ssl_mode"),
 		},
 		Server: ServerConfig{
-			Port: v.GetString("server.port"),
-			Env:  v.GetString("env"),
+			Port:           v.GetString("server.port"),
+			Env:            v.GetString("env"),
+			RequestTimeout: v.GetString("server.request_timeout"),
 		},
 		JWT: JWTConfig{
-			Secret: v.GetString("jwt.secret"),
-			Expiry: v.GetString("jwt.expiry"),
+			Secret:        v.GetString("jwt.secret"),
+			Expiry:        v.GetString("jwt.expiry"),
+			RefreshExpiry: v.GetString("jwt.refresh_expiry"),
+		},
+		Auth: AuthConfig{
+			PasswordResetExpiry: v.GetString("auth.password_reset_expiry"),
 		},
 		Upload: UploadConfig{
 			Dir:       v.GetString("upload.dir"),
 			MaxSizeMB: v.GetInt("upload.max_size"),
+			Storage:   v.GetString("upload.storage"),
 		},
 		Telegram: TelegramConfig{
-			BotToken: v.GetString("telegram.bot_token"),
+			BotToken:      v.GetString("telegram.bot_token"),
+			ChatDiscovery: v.GetBool("telegram.chat_discovery"),
+		},
+		SMS: SMSConfig{
+			TwilioAccountSID: v.GetString("sms.twilio_account_sid"),
+			TwilioAuthToken:  v.GetString("sms.twilio_auth_token"),
+			FromNumber:       v.GetString("sms.from_number"),
+		},
+		Email: EmailConfig{
+			Enabled:      v.GetBool("email.enabled"),
+			SMTPHost:     v.GetString("email.smtp_host"),
+			SMTPPort:     v.GetInt("email.smtp_port"),
+			SMTPUsername: v.GetString("email.smtp_username"),
+			SMTPPassword: v.GetString("email.smtp_password"),
+			From:         v.GetString("email.from"),
 		},
 		AWS: AWSConfig{
 			AccessKey: v.GetString("aws.access_key"),
@@ -136,6 +263,59 @@ func LoadConfig() (*Config, error) {
 			Region:    v.GetString("aws.region"),
 			Bucket:    v.GetString("aws.bucket"),
 			Prefix:    v.GetString("aws.prefix"),
+			Endpoint:  v.GetString("aws.endpoint"),
+			BaseURL:   v.GetString("aws.base_url"),
+		},
+		Inventory: InventoryConfig{
+			ReorderMultiplier: v.GetInt("inventory.reorder_multiplier"),
+		},
+		Pricing: PricingConfig{
+			MultipleCurrentPrices: v.GetString("pricing.multiple_current_prices"),
+			ExchangeRates:         v.GetString("pricing.exchange_rates"),
+			ExchangeRatesURL:      v.GetString("pricing.exchange_rates_url"),
+			ExchangeRatesTTL:      v.GetString("pricing.exchange_rates_ttl"),
+		},
+		Notification: NotificationConfig{
+			RetentionDays:    v.GetInt("notification.retention_days"),
+			CleanupInterval:  v.GetString("notification.cleanup_interval"),
+			RetryMaxAttempts: v.GetInt("notification.retry_max_attempts"),
+			RetryBackoff:     v.GetString("notification.retry_backoff"),
+			RetryInterval:    v.GetString("notification.retry_interval"),
+		},
+		Shipping: ShippingConfig{
+			VolumetricDivisor:  v.GetFloat64("shipping.volumetric_divisor"),
+			SenderName:         v.GetString("shipping.sender.name"),
+			SenderPhone:        v.GetString("shipping.sender.phone"),
+			SenderAddress:      v.GetString("shipping.sender.address"),
+			SenderWard:         v.GetString("shipping.sender.ward"),
+			SenderDistrict:     v.GetString("shipping.sender.district"),
+			SenderCity:         v.GetString("shipping.sender.city"),
+			SenderCountry:      v.GetString("shipping.sender.country"),
+			GHNWebhookSecret:   v.GetString("shipping.ghn_webhook_secret"),
+			GHNStatusOverrides: v.GetString("shipping.ghn_status_overrides"),
+		},
+		Order: OrderConfig{
+			DiscountApprovalAmount:  v.GetFloat64("order.discount_approval_amount"),
+			DiscountApprovalPercent: v.GetFloat64("order.discount_approval_percent"),
+			NumberPrefix:            v.GetString("order.number_prefix"),
+			NumberReset:             v.GetString("order.number_reset"),
+			AgentTargetStatuses:     v.GetString("order.agent_target_statuses"),
+			CheckAvailableToPromise: v.GetBool("order.check_available_to_promise"),
+			ReserveOnCreate:         v.GetBool("order.reserve_on_create"),
+			BulkStatusMaxOrders:     v.GetInt("order.bulk_status_max_orders"),
+			RequireDeliveryProof:    v.GetBool("order.require_delivery_proof"),
+			DiscountReasonRequired:  v.GetBool("order.discount_reason_required"),
+			DiscountReasonMaxLength: v.GetInt("order.discount_reason_max_length"),
+			AdminEditHandedOver:     v.GetBool("order.admin_edit_handed_over"),
+			Currency:                v.GetString("order.currency"),
+			CurrencyLocale:          v.GetString("order.currency_locale"),
+			HoldSweepInterval:       v.GetString("order.hold_sweep_interval"),
+		},
+		Webhook: WebhookConfig{
+			MaxAttempts:   v.GetInt("webhook.max_attempts"),
+			RetryBackoff:  v.GetString("webhook.retry_backoff"),
+			RetryInterval: v.GetString("webhook.retry_interval"),
+			Timeout:       v.GetString("webhook.timeout"),
 		},
 	}
 
@@ -162,14 +342,70 @@ func setDefaults(v *viper.Viper) {
 
 	// Server defaults
 	v.SetDefault("server.port", "3000")
+	v.SetDefault("server.request_timeout", "30s")
 	v.SetDefault("env", "development")
 
 	// JWT defaults
 	v.SetDefault("jwt.expiry", "24h")
+	v.SetDefault("jwt.refresh_expiry", "720h")
+
+	// Auth defaults
+	v.SetDefault("auth.password_reset_expiry", "1h")
 
 	// Upload defaults
 	v.SetDefault("upload.dir", "./uploads")
 	v.SetDefault("upload.max_size", 10) // 10MB
+	v.SetDefault("upload.storage", "")  // local or s3; s3 when AWS is configured if empty
+
+	// Telegram defaults
+	v.SetDefault("telegram.chat_discovery", true) // Disable when the bot is driven by a webhook
+
+	// Email defaults
+	v.SetDefault("email.enabled", false)
+	v.SetDefault("email.smtp_port", 587)
+
+	// Inventory defaults
+	v.SetDefault("inventory.reorder_multiplier", 2) // Reorder up to twice the low stock threshold
+
+	// Pricing defaults
+	v.SetDefault("pricing.multiple_current_prices", "latest_start") // latest_start, lowest or error
+	v.SetDefault("pricing.exchange_rates", "")                      // e.g. USD=25400,EUR=27500, quoted in the order currency
+	v.SetDefault("pricing.exchange_rates_url", "")                  // e.g. https://open.er-api.com/v6/latest/%s; overrides exchange_rates
+	v.SetDefault("pricing.exchange_rates_ttl", "1h")
+
+	// Notification defaults
+	v.SetDefault("notification.retention_days", 30) // 0 disables the cleanup
+	v.SetDefault("notification.cleanup_interval", "24h")
+	v.SetDefault("notification.retry_max_attempts", 5) // Including the first attempt
+	v.SetDefault("notification.retry_backoff", "30s")  // Doubles with every failed attempt
+	v.SetDefault("notification.retry_interval", "1m")  // 0 disables the retry worker
+
+	// Shipping defaults
+	v.SetDefault("shipping.volumetric_divisor", 5000) // cm³ per kg, as used by GHN
+	v.SetDefault("shipping.sender.country", "Vietnam")
+
+	// Order defaults
+	v.SetDefault("order.discount_approval_amount", 0)  // 0 disables the limit
+	v.SetDefault("order.discount_approval_percent", 0) // 0 disables the limit
+	v.SetDefault("order.number_prefix", "YB")
+	v.SetDefault("order.number_reset", "daily") // daily, monthly, yearly or never
+	v.SetDefault("order.agent_target_statuses", "packed,canceled")
+	v.SetDefault("order.check_available_to_promise", false)
+	v.SetDefault("order.reserve_on_create", true) // false takes the stock only when orders are packed
+	v.SetDefault("order.bulk_status_max_orders", 200)
+	v.SetDefault("order.require_delivery_proof", false)
+	v.SetDefault("order.discount_reason_required", false)
+	v.SetDefault("order.discount_reason_max_length", 255) // At most the size of the discount_reason column
+	v.SetDefault("order.admin_edit_handed_over", false)
+	v.SetDefault("order.currency", "VND")           // Used when the item prices have no currency
+	v.SetDefault("order.currency_locale", "en-US")  // Digit grouping of formatted amounts, e.g. vi-VN
+	v.SetDefault("order.hold_sweep_interval", "1m") // How often expired inventory holds are released
+
+	// Webhook defaults
+	v.SetDefault("webhook.max_attempts", 5)
+	v.SetDefault("webhook.retry_backoff", "30s")  // Doubles with every failed attempt
+	v.SetDefault("webhook.retry_interval", "15s") // How often due retries are attempted
+	v.SetDefault("webhook.timeout", "10s")
 
 	// Map environment variables to viper keys
 	mapEnvToConfig(v)
@@ -190,18 +426,38 @@ func mapEnvToConfig(v *viper.Viper) {
 
 	// Server mapping
 	v.BindEnv("server.port", "SERVER_PORT")
+	v.BindEnv("server.request_timeout", "REQUEST_TIMEOUT")
 	v.BindEnv("env", "ENV")
 
 	// JWT mapping
 	v.BindEnv("jwt.secret", "JWT_SECRET")
 	v.BindEnv("jwt.expiry", "JWT_EXPIRY")
+	v.BindEnv("jwt.refresh_expiry", "JWT_REFRESH_EXPIRY")
+
+	// Auth mapping
+	v.BindEnv("auth.password_reset_expiry", "PASSWORD_RESET_EXPIRY")
 
 	// Upload mapping
 	v.BindEnv("upload.dir", "UPLOAD_DIR")
-	v.BindEnv("upload.max_size", "MAX_UPLOAD_SIZE")
+	v.BindEnv("upload.max_size", "MAX_UPLOAD_SIZE", "UPLOAD_MAX_SIZE_MB")
+	v.BindEnv("upload.storage", "UPLOAD_STORAGE")
 
 	// Telegram mapping
 	v.BindEnv("telegram.bot_token", "TELEGRAM_BOT_TOKEN")
+	v.BindEnv("telegram.chat_discovery", "TELEGRAM_CHAT_DISCOVERY")
+
+	// SMS mapping
+	v.BindEnv("sms.twilio_account_sid", "SMS_TWILIO_ACCOUNT_SID")
+	v.BindEnv("sms.twilio_auth_token", "SMS_TWILIO_AUTH_TOKEN")
+	v.BindEnv("sms.from_number", "SMS_FROM_NUMBER")
+
+	// Email mapping
+	v.BindEnv("email.enabled", "EMAIL_ENABLED")
+	v.BindEnv("email.smtp_host", "EMAIL_SMTP_HOST")
+	v.BindEnv("email.smtp_port", "EMAIL_SMTP_PORT")
+	v.BindEnv("email.smtp_username", "EMAIL_SMTP_USERNAME")
+	v.BindEnv("email.smtp_password", "EMAIL_SMTP_PASSWORD")
+	v.BindEnv("email.from", "EMAIL_FROM")
 
 	// AWS mapping
 	v.BindEnv("aws.access_key", "AWS_ACCESS_KEY_ID")
@@ -209,6 +465,59 @@ func mapEnvToConfig(v *viper.Viper) {
 	v.BindEnv("aws.region", "AWS_REGION")
 	v.BindEnv("aws.bucket", "AWS_BUCKET_NAME")
 	v.BindEnv("aws.prefix", "AWS_S3_PREFIX")
+	v.BindEnv("aws.endpoint", "AWS_S3_ENDPOINT")
+	v.BindEnv("aws.base_url", "AWS_S3_BASE_URL")
+
+	// Inventory mapping
+	v.BindEnv("inventory.reorder_multiplier", "INVENTORY_REORDER_MULTIPLIER")
+
+	// Pricing mapping
+	v.BindEnv("pricing.multiple_current_prices", "PRICING_MULTIPLE_CURRENT_PRICES")
+	v.BindEnv("pricing.exchange_rates", "PRICING_EXCHANGE_RATES")
+	v.BindEnv("pricing.exchange_rates_url", "PRICING_EXCHANGE_RATES_URL")
+	v.BindEnv("pricing.exchange_rates_ttl", "PRICING_EXCHANGE_RATES_TTL")
+
+	// Notification mapping
+	v.BindEnv("notification.retention_days", "NOTIFICATION_RETENTION_DAYS")
+	v.BindEnv("notification.cleanup_interval", "NOTIFICATION_CLEANUP_INTERVAL")
+	v.BindEnv("notification.retry_max_attempts", "NOTIFICATION_RETRY_MAX_ATTEMPTS")
+	v.BindEnv("notification.retry_backoff", "NOTIFICATION_RETRY_BACKOFF")
+	v.BindEnv("notification.retry_interval", "NOTIFICATION_RETRY_INTERVAL")
+
+	// Shipping mapping
+	v.BindEnv("shipping.volumetric_divisor", "SHIPPING_VOLUMETRIC_DIVISOR")
+	v.BindEnv("shipping.sender.name", "SHIPPING_SENDER_NAME")
+	v.BindEnv("shipping.sender.phone", "SHIPPING_SENDER_PHONE")
+	v.BindEnv("shipping.sender.address", "SHIPPING_SENDER_ADDRESS")
+	v.BindEnv("shipping.sender.ward", "SHIPPING_SENDER_WARD")
+	v.BindEnv("shipping.sender.district", "SHIPPING_SENDER_DISTRICT")
+	v.BindEnv("shipping.sender.city", "SHIPPING_SENDER_CITY")
+	v.BindEnv("shipping.sender.country", "SHIPPING_SENDER_COUNTRY")
+	v.BindEnv("shipping.ghn_webhook_secret", "GHN_WEBHOOK_SECRET")
+	v.BindEnv("shipping.ghn_status_overrides", "GHN_STATUS_OVERRIDES")
+
+	// Order mapping
+	v.BindEnv("order.discount_approval_amount", "ORDER_DISCOUNT_APPROVAL_AMOUNT")
+	v.BindEnv("order.discount_approval_percent", "ORDER_DISCOUNT_APPROVAL_PERCENT")
+	v.BindEnv("order.number_prefix", "ORDER_NUMBER_PREFIX")
+	v.BindEnv("order.number_reset", "ORDER_NUMBER_RESET")
+	v.BindEnv("order.agent_target_statuses", "ORDER_AGENT_TARGET_STATUSES")
+	v.BindEnv("order.check_available_to_promise", "ORDER_CHECK_AVAILABLE_TO_PROMISE")
+	v.BindEnv("order.reserve_on_create", "ORDER_RESERVE_ON_CREATE")
+	v.BindEnv("order.bulk_status_max_orders", "ORDER_BULK_STATUS_MAX_ORDERS")
+	v.BindEnv("order.require_delivery_proof", "ORDER_REQUIRE_DELIVERY_PROOF")
+	v.BindEnv("order.discount_reason_required", "ORDER_DISCOUNT_REASON_REQUIRED")
+	v.BindEnv("order.discount_reason_max_length", "ORDER_DISCOUNT_REASON_MAX_LENGTH")
+	v.BindEnv("order.admin_edit_handed_over", "ORDER_ADMIN_EDIT_HANDED_OVER")
+	v.BindEnv("order.currency", "ORDER_CURRENCY")
+	v.BindEnv("order.currency_locale", "ORDER_CURRENCY_LOCALE")
+	v.BindEnv("order.hold_sweep_interval", "ORDER_HOLD_SWEEP_INTERVAL")
+
+	// Webhook mapping
+	v.BindEnv("webhook.max_attempts", "WEBHOOK_MAX_ATTEMPTS")
+	v.BindEnv("webhook.retry_backoff", "WEBHOOK_RETRY_BACKOFF")
+	v.BindEnv("webhook.retry_interval", "WEBHOOK_RETRY_INTERVAL")
+	v.BindEnv("webhook.timeout", "WEBHOOK_TIMEOUT")
 }
 
 // ensureUploadDir ensures that the upload directory exists
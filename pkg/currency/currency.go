@@ -0,0 +1,177 @@
+package currency
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is how long a request to an exchange rate API may take
+const DefaultTimeout = 10 * time.Second
+
+// ErrUnknownCurrency is returned when a provider has no rate for a currency
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// ExchangeRateProvider provides exchange rates between currencies
+type ExchangeRateProvider interface {
+	// Rate returns how many units of the to currency one unit of the from currency is worth.
+	// Currencies are ISO 4217 codes such as VND or USD.
+	Rate(from, to string) (float64, error)
+}
+
+// Normalize returns the ISO 4217 code of a currency in upper case without surrounding spaces
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// StaticRates provides fixed exchange rates quoted against a base currency, e.g. with base VND
+// the rate of USD is how many VND one USD is worth. Rates between two other currencies are
+// derived through the base currency.
+type StaticRates struct {
+	Base  string
+	Rates map[string]float64
+}
+
+// ParseRates parses rates written as comma separated CODE=RATE pairs, e.g. "USD=25400,EUR=27500",
+// quoted against the base currency
+func ParseRates(base, value string) (*StaticRates, error) {
+	rates := &StaticRates{Base: Normalize(base), Rates: make(map[string]float64)}
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		code, rate, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid exchange rate %q: expected CODE=RATE", pair)
+		}
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid exchange rate %q: rate must be a positive number", pair)
+		}
+		rates.Rates[Normalize(code)] = parsed
+	}
+	return rates, nil
+}
+
+// Rate returns the exchange rate between two currencies
+func (s *StaticRates) Rate(from, to string) (float64, error) {
+	fromValue, err := s.value(Normalize(from))
+	if err != nil {
+		return 0, err
+	}
+	toValue, err := s.value(Normalize(to))
+	if err != nil {
+		return 0, err
+	}
+	return fromValue / toValue, nil
+}
+
+// value returns how many units of the base currency one unit of the currency is worth
+func (s *StaticRates) value(code string) (float64, error) {
+	if code == Normalize(s.Base) {
+		return 1, nil
+	}
+	if rate, ok := s.Rates[code]; ok && rate > 0 {
+		return rate, nil
+	}
+	return 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, code)
+}
+
+// HTTPProvider fetches exchange rates from a JSON API. URL contains a %s placeholder for the
+// currency being converted from, e.g. https://open.er-api.com/v6/latest/%s, and must answer with
+// an object whose "rates" field maps currency codes to the worth of one unit of that currency.
+type HTTPProvider struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+// NewHTTPProvider creates a new provider fetching rates from the given URL
+func NewHTTPProvider(url string) *HTTPProvider {
+	return &HTTPProvider{
+		URL:        url,
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// Rate fetches the exchange rate between two currencies
+func (p *HTTPProvider) Rate(from, to string) (float64, error) {
+	from, to = Normalize(from), Normalize(to)
+
+	resp, err := p.HTTPClient.Get(fmt.Sprintf(p.URL, from))
+	if err != nil {
+		return 0, fmt.Errorf("error fetching exchange rates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("exchange rate API returned non-OK status: %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("error decoding exchange rates: %w", err)
+	}
+
+	rate, ok := body.Rates[to]
+	if !ok || rate <= 0 {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, to)
+	}
+	return rate, nil
+}
+
+// cachedRate is a rate kept by CachedProvider with the time it was fetched
+type cachedRate struct {
+	rate      float64
+	fetchedAt time.Time
+}
+
+// CachedProvider keeps the rates of another provider for TTL, so conversions do not call a remote
+// API every time. Failed lookups are not cached. It is safe for concurrent use.
+type CachedProvider struct {
+	Provider ExchangeRateProvider
+	TTL      time.Duration
+
+	mu    sync.Mutex
+	rates map[[2]string]cachedRate
+	now   func() time.Time
+}
+
+// NewCachedProvider creates a provider caching the rates of another provider for ttl
+func NewCachedProvider(provider ExchangeRateProvider, ttl time.Duration) *CachedProvider {
+	return &CachedProvider{
+		Provider: provider,
+		TTL:      ttl,
+		rates:    make(map[[2]string]cachedRate),
+		now:      time.Now,
+	}
+}
+
+// Rate returns the cached exchange rate between two currencies, fetching it when it is missing
+// or older than the TTL
+func (c *CachedProvider) Rate(from, to string) (float64, error) {
+	key := [2]string{Normalize(from), Normalize(to)}
+
+	c.mu.Lock()
+	cached, ok := c.rates[key]
+	c.mu.Unlock()
+	if ok && c.now().Sub(cached.fetchedAt) < c.TTL {
+		return cached.rate, nil
+	}
+
+	rate, err := c.Provider.Rate(key[0], key[1])
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	c.rates[key] = cachedRate{rate: rate, fetchedAt: c.now()}
+	c.mu.Unlock()
+	return rate, nil
+}
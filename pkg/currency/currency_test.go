@@ -0,0 +1,108 @@
+package currency
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStaticRates(t *testing.T) {
+	rates, err := ParseRates("vnd", "USD=25000, eur=27500")
+	if err != nil {
+		t.Fatalf("ParseRates returned an error: %v", err)
+	}
+
+	tests := []struct {
+		from, to string
+		want     float64
+	}{
+		{"USD", "VND", 25000},
+		{"VND", "USD", 1.0 / 25000},
+		{"EUR", "USD", 1.1},
+		{"vnd", "VND", 1},
+	}
+	for _, tt := range tests {
+		got, err := rates.Rate(tt.from, tt.to)
+		if err != nil {
+			t.Errorf("Rate(%s, %s) returned an error: %v", tt.from, tt.to, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Rate(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	if _, err := rates.Rate("JPY", "VND"); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("Expected ErrUnknownCurrency for JPY, got %v", err)
+	}
+
+	for _, invalid := range []string{"USD", "USD=abc", "USD=-1"} {
+		if _, err := ParseRates("VND", invalid); err == nil {
+			t.Errorf("Expected an error parsing %q", invalid)
+		}
+	}
+}
+
+// countingProvider counts the rates it is asked for
+type countingProvider struct {
+	rate  float64
+	calls int
+}
+
+func (p *countingProvider) Rate(from, to string) (float64, error) {
+	p.calls++
+	return p.rate, nil
+}
+
+func TestCachedProvider(t *testing.T) {
+	provider := &countingProvider{rate: 25000}
+	cache := NewCachedProvider(provider, time.Hour)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if rate, err := cache.Rate("usd", "VND"); err != nil || rate != 25000 {
+			t.Fatalf("Rate = %v, %v; want 25000", rate, err)
+		}
+	}
+	if provider.calls != 1 {
+		t.Errorf("Expected 1 provider call within the TTL, got %d", provider.calls)
+	}
+
+	// Rates older than the TTL are fetched again
+	provider.rate = 26000
+	now = now.Add(time.Hour)
+	if rate, _ := cache.Rate("USD", "VND"); rate != 26000 {
+		t.Errorf("Expected the refreshed rate 26000, got %v", rate)
+	}
+	if provider.calls != 2 {
+		t.Errorf("Expected 2 provider calls after the TTL, got %d", provider.calls)
+	}
+}
+
+func TestHTTPProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/latest/USD" {
+			t.Errorf("Expected the rates of USD to be requested, got %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"result": "success", "rates": {"USD": 1, "VND": 25400.5}}`))
+	}))
+	defer server.Close()
+
+	provider := NewHTTPProvider(server.URL + "/latest/%s")
+	rate, err := provider.Rate("usd", "vnd")
+	if err != nil {
+		t.Fatalf("Rate returned an error: %v", err)
+	}
+	if rate != 25400.5 {
+		t.Errorf("Expected rate 25400.5, got %v", rate)
+	}
+
+	if _, err := provider.Rate("USD", "JPY"); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("Expected ErrUnknownCurrency for JPY, got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmailClient sends HTML emails
+type EmailClient interface {
+	// Send sends an HTML email with the subject to the address
+	Send(to, subject, htmlBody string) error
+}
+
+// Transport delivers a raw message through an SMTP server; it matches smtp.SendMail
+type Transport func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+
+// SMTPClient sends emails through an SMTP server
+type SMTPClient struct {
+	Host      string
+	Port      int
+	Username  string
+	Password  string
+	From      string
+	Transport Transport
+}
+
+// NewSMTPClient creates a new SMTP client sending emails from the given address
+func NewSMTPClient(host string, port int, username, password, from string) *SMTPClient {
+	return &SMTPClient{
+		Host:      host,
+		Port:      port,
+		Username:  username,
+		Password:  password,
+		From:      from,
+		Transport: smtp.SendMail,
+	}
+}
+
+// Send sends an HTML email to the address. The server is authenticated against with PLAIN
+// auth when a username is configured.
+func (c *SMTPClient) Send(to, subject, htmlBody string) error {
+	var auth smtp.Auth
+	if c.Username != "" {
+		auth = smtp.PlainAuth("", c.Username, c.Password, c.Host)
+	}
+
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	msg := BuildMessage(c.From, to, subject, htmlBody, time.Now())
+	if err := c.Transport(addr, auth, c.From, []string{to}, msg); err != nil {
+		return fmt.Errorf("error sending email: %w", err)
+	}
+	return nil
+}
+
+// BuildMessage builds a MIME message with an HTML body. The subject is encoded so that
+// non-ASCII characters, e.g. Vietnamese, survive transport.
+func BuildMessage(from, to, subject, htmlBody string, date time.Time) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=\"utf-8\"\r\n")
+	msg.WriteString("\r\n")
+	// SMTP requires CRLF line endings in the body as well
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(htmlBody, "\r\n", "\n"), "\n", "\r\n"))
+	return msg.Bytes()
+}
+
+// notificationTemplate is the HTML layout of notification emails
+var notificationTemplate = template.Must(template.New("notification").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body style="font-family: Arial, sans-serif; color: #333333;">
+<h2>{{.Title}}</h2>
+{{range .Paragraphs}}<p>{{.}}</p>
+{{end}}<hr>
+<p style="font-size: 12px; color: #888888;">This is an automated message from YBDS. Please do not reply.</p>
+</body>
+</html>
+`))
+
+// RenderNotification renders a notification into the subject and HTML body of an email.
+// The title and message are escaped; every line of the message becomes a paragraph.
+func RenderNotification(title, message string) (string, string, error) {
+	var paragraphs []string
+	for _, line := range strings.Split(message, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paragraphs = append(paragraphs, line)
+		}
+	}
+
+	var body bytes.Buffer
+	err := notificationTemplate.Execute(&body, struct {
+		Title      string
+		Paragraphs []string
+	}{
+		Title:      title,
+		Paragraphs: paragraphs,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("error rendering email: %w", err)
+	}
+
+	return title, body.String(), nil
+}
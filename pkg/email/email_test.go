@@ -0,0 +1,102 @@
+package email
+
+import (
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderNotification(t *testing.T) {
+	subject, body, err := RenderNotification("Order <YB-001> shipped", "Your order is on its way.\n\nTrack it with GHN.")
+	if err != nil {
+		t.Fatalf("RenderNotification returned an error: %v", err)
+	}
+
+	if subject != "Order <YB-001> shipped" {
+		t.Errorf("Expected the title as subject, got %q", subject)
+	}
+	if !strings.Contains(body, "<h2>Order &lt;YB-001&gt; shipped</h2>") {
+		t.Errorf("Expected the escaped title in the body, got %s", body)
+	}
+	if !strings.Contains(body, "<p>Your order is on its way.</p>") || !strings.Contains(body, "<p>Track it with GHN.</p>") {
+		t.Errorf("Expected every line of the message as a paragraph, got %s", body)
+	}
+	if strings.Contains(body, "<p></p>") {
+		t.Errorf("Expected blank lines to be skipped, got %s", body)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotMsg []byte
+	var gotAuth smtp.Auth
+
+	client := NewSMTPClient("smtp.example.com", 587, "mailer", "secret", "noreply@example.com")
+	client.Transport = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotAuth, gotFrom, gotTo, gotMsg = addr, auth, from, to, msg
+		return nil
+	}
+
+	subject, body, err := RenderNotification("Đơn hàng đã giao", "Cảm ơn bạn")
+	if err != nil {
+		t.Fatalf("RenderNotification returned an error: %v", err)
+	}
+	if err := client.Send("john@example.com", subject, body); err != nil {
+		t.Fatalf("Send returned an error: %v", err)
+	}
+
+	if gotAddr != "smtp.example.com:587" {
+		t.Errorf("Expected addr smtp.example.com:587, got %s", gotAddr)
+	}
+	if gotAuth == nil {
+		t.Error("Expected PLAIN auth when a username is configured")
+	}
+	if gotFrom != "noreply@example.com" || len(gotTo) != 1 || gotTo[0] != "john@example.com" {
+		t.Errorf("Unexpected envelope from %s to %v", gotFrom, gotTo)
+	}
+
+	msg := string(gotMsg)
+	if !strings.Contains(msg, "Subject: =?utf-8?q?") {
+		t.Errorf("Expected an encoded subject, got %s", msg)
+	}
+	if !strings.Contains(msg, "Content-Type: text/html; charset=\"utf-8\"\r\n") {
+		t.Errorf("Expected an HTML content type, got %s", msg)
+	}
+	if !strings.Contains(msg, "<p>Cảm ơn bạn</p>\r\n") {
+		t.Errorf("Expected the rendered body with CRLF line endings, got %s", msg)
+	}
+}
+
+func TestSendError(t *testing.T) {
+	client := NewSMTPClient("smtp.example.com", 587, "", "", "noreply@example.com")
+	client.Transport = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		if auth != nil {
+			t.Error("Expected no auth without a username")
+		}
+		return errors.New("connection refused")
+	}
+
+	if err := client.Send("john@example.com", "Subject", "<p>Body</p>"); err == nil {
+		t.Error("Send did not return an error when expected")
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	date := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	msg := string(BuildMessage("noreply@example.com", "john@example.com", "Hello", "<p>Hi</p>\n", date))
+
+	want := "From: noreply@example.com\r\n" +
+		"To: john@example.com\r\n" +
+		"Subject: Hello\r\n" +
+		"Date: Thu, 02 Jan 2025 03:04:05 +0000\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/html; charset=\"utf-8\"\r\n" +
+		"\r\n" +
+		"<p>Hi</p>\r\n"
+	if msg != want {
+		t.Errorf("Unexpected message:\n%q\nwant\n%q", msg, want)
+	}
+}
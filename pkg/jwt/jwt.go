@@ -1,6 +1,10 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
@@ -18,8 +22,17 @@ type CustomClaims struct {
 
 // JWTService provides methods to generate, parse and validate JWT tokens
 type JWTService struct {
-	secretKey []byte
-	expiry    time.Duration
+	secretKey     []byte
+	expiry        time.Duration
+	refreshExpiry time.Duration
+}
+
+// RefreshToken is an opaque, long-lived token exchanged for new access tokens. Only the hash
+// of Token is stored; the token itself is handed to the client once.
+type RefreshToken struct {
+	Token     string
+	UserID    string
+	ExpiresAt time.Time
 }
 
 // NewJWTService creates a new JWT service
@@ -33,9 +46,15 @@ func NewJWTService(cfg *config.JWTConfig) (*JWTService, error) {
 		return nil, fmt.Errorf("invalid JWT expiry duration: %w", err)
 	}
 
+	refreshExpiry, err := time.ParseDuration(cfg.RefreshExpiry)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT refresh expiry duration: %w", err)
+	}
+
 	return &JWTService{
-		secretKey: []byte(cfg.Secret),
-		expiry:    expiry,
+		secretKey:     []byte(cfg.Secret),
+		expiry:        expiry,
+		refreshExpiry: refreshExpiry,
 	}, nil
 }
 
@@ -60,6 +79,27 @@ func (s *JWTService) GenerateToken(userID string, roles []string) (string, error
 	return signedToken, nil
 }
 
+// GenerateRefreshToken generates a new random refresh token for the user that expires after the
+// configured refresh expiry
+func (s *JWTService) GenerateRefreshToken(userID string) (*RefreshToken, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	return &RefreshToken{
+		Token:     base64.RawURLEncoding.EncodeToString(raw),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(s.refreshExpiry),
+	}, nil
+}
+
+// HashRefreshToken returns the hex encoded SHA-256 hash under which a refresh token is stored
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // ValidateToken validates the JWT token and returns the claims
 func (s *JWTService) ValidateToken(tokenString string) (*CustomClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
@@ -0,0 +1,84 @@
+package phone
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// CountryCode is the calling code of Vietnam
+const CountryCode = "84"
+
+// ErrInvalid is returned for numbers that are not valid Vietnamese phone numbers
+var ErrInvalid = errors.New("invalid Vietnamese phone number format")
+
+// NationalPattern is the regular expression, in a syntax shared by Go and PostgreSQL, matching
+// Vietnamese phone numbers in national format:
+// - Mobile: 10 digits starting with 03, 05, 07, 08, 09 (e.g., 0912345678)
+// - Older mobile: 11 digits starting with 012, 016, 018, 019 (pre-2018)
+// - Landline: 10-11 digits including the area code (e.g., 02812345678)
+const NationalPattern = `^(0[35789][0-9]{8}|01[2689][0-9]{8}|02[0-9][0-9]{7,8})$`
+
+var nationalPattern = regexp.MustCompile(NationalPattern)
+
+// National converts a phone number to the national format with a leading 0, e.g. 0912345678 for
+// +84 912 345 678. Spaces, dots, dashes and other formatting characters are removed. The number
+// is not validated, so partial or foreign numbers are only stripped of their formatting.
+func National(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if (r >= '0' && r <= '9') || r == '+' {
+			b.WriteRune(r)
+		}
+	}
+
+	digits := b.String()
+	switch {
+	case strings.HasPrefix(digits, "+"+CountryCode):
+		return "0" + strings.TrimPrefix(digits, "+"+CountryCode)
+	case strings.HasPrefix(digits, CountryCode) && (len(digits) == 11 || len(digits) == 12):
+		return "0" + strings.TrimPrefix(digits, CountryCode)
+	default:
+		return digits
+	}
+}
+
+// Normalize converts a Vietnamese phone number written in any of its usual forms, e.g.
+// 0912345678, 091 234 5678, 84912345678 or +84 912 345 678, to the E.164 format +84912345678.
+// ErrInvalid is returned when it is not a valid Vietnamese mobile or landline number.
+func Normalize(phone string) (string, error) {
+	national := National(phone)
+	if !nationalPattern.MatchString(national) {
+		return "", ErrInvalid
+	}
+	return "+" + CountryCode + strings.TrimPrefix(national, "0"), nil
+}
+
+// IsValid reports whether a phone number is a valid Vietnamese mobile or landline number
+func IsValid(phone string) bool {
+	_, err := Normalize(phone)
+	return err == nil
+}
+
+// SearchTerm returns the part of a phone number shared by all the forms it may have been stored
+// in: the national number without its leading 0, e.g. 912345678 for both 0912345678 and
+// +84912345678. Partial numbers are stripped of their formatting and of a leading 0 or 84 too, so
+// 0912 matches +84912345678. Text without any digits is returned unchanged.
+func SearchTerm(phone string) string {
+	national := National(phone)
+	if national == "" {
+		return phone
+	}
+
+	term := national
+	switch {
+	case strings.HasPrefix(term, "0"):
+		term = strings.TrimPrefix(term, "0")
+	case strings.HasPrefix(term, CountryCode):
+		term = strings.TrimPrefix(term, CountryCode)
+	}
+	if term == "" {
+		return national
+	}
+	return term
+}
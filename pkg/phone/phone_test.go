@@ -0,0 +1,85 @@
+package phone
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		// Mobile
+		"0912345678":      "+84912345678",
+		"091 234 5678":    "+84912345678",
+		"0912.345.678":    "+84912345678",
+		"0912-345-678":    "+84912345678",
+		"84912345678":     "+84912345678",
+		"+84912345678":    "+84912345678",
+		"+84 912 345 678": "+84912345678",
+		"(+84) 912345678": "+84912345678",
+		"0387654321":      "+84387654321",
+		// Older 11-digit mobile
+		"01212345678":   "+841212345678",
+		"+841212345678": "+841212345678",
+		// Landline
+		"02812345678":      "+842812345678",
+		"028 1234 5678":    "+842812345678",
+		"842812345678":     "+842812345678",
+		"+84 28 1234 5678": "+842812345678",
+		"0241234567":       "+84241234567",
+	}
+	for input, want := range tests {
+		got, err := Normalize(input)
+		if err != nil {
+			t.Errorf("Normalize(%q) returned an error: %v", input, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	for _, invalid := range []string{"", "12345", "0112345678", "091234567", "09123456789", "+1 415 555 2671", "abc"} {
+		if _, err := Normalize(invalid); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Expected ErrInvalid for %q, got %v", invalid, err)
+		}
+	}
+	if ErrInvalid.Error() != "invalid Vietnamese phone number format" {
+		t.Errorf("Unexpected error message %q", ErrInvalid.Error())
+	}
+}
+
+func TestNational(t *testing.T) {
+	tests := map[string]string{
+		"+84 912 345 678": "0912345678",
+		"84912345678":     "0912345678",
+		"0912-345-678":    "0912345678",
+		"0912":            "0912",
+		"+1 415 555 2671": "+14155552671",
+	}
+	for input, want := range tests {
+		if got := National(input); got != want {
+			t.Errorf("National(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestSearchTerm(t *testing.T) {
+	tests := map[string]string{
+		"0912345678":      "912345678",
+		"+84912345678":    "912345678",
+		"+84 912 345 678": "912345678",
+		"0912 345":        "912345",
+		"0912":            "912",
+		"+84 91":          "91",
+		"8491":            "91",
+		"0":               "0",
+		"84":              "84",
+		"+1 415":          "+1415",
+		"Nguyen":          "Nguyen",
+	}
+	for input, want := range tests {
+		if got := SearchTerm(input); got != want {
+			t.Errorf("SearchTerm(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
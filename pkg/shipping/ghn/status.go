@@ -0,0 +1,88 @@
+// Package ghn maps the shipment statuses reported by GHN (Giao Hang Nhanh) to order statuses.
+package ghn
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ybds/internal/models/order"
+)
+
+// defaultStatuses maps the GHN shipment statuses to order statuses. GHN statuses mapped to an empty
+// status are known but leave the order unchanged, e.g. while the carrier is still picking it up.
+var defaultStatuses = map[string]order.OrderStatus{
+	"ready_to_pick":            "",
+	"picking":                  "",
+	"money_collect_picking":    "",
+	"picked":                   order.OrderPicked,
+	"storing":                  order.OrderPicked,
+	"transporting":             order.OrderPicked,
+	"sorting":                  order.OrderPicked,
+	"delivering":               order.OrderDelivering,
+	"money_collect_delivering": order.OrderDelivering,
+	"delivery_fail":            order.OrderDelivering,
+	"delivered":                order.OrderDelivered,
+	"cancel":                   order.OrderCanceled,
+	"waiting_to_return":        order.OrderReturnProcessing,
+	"return":                   order.OrderReturnProcessing,
+	"return_transporting":      order.OrderReturnProcessing,
+	"return_sorting":           order.OrderReturnProcessing,
+	"returning":                order.OrderReturnProcessing,
+	"return_fail":              order.OrderReturnProcessing,
+	"returned":                 order.OrderReturned,
+}
+
+// StatusMapper maps GHN shipment statuses to order statuses
+type StatusMapper struct {
+	statuses map[string]order.OrderStatus
+}
+
+// NewStatusMapper creates a new StatusMapper with the default GHN status mapping
+func NewStatusMapper() *StatusMapper {
+	statuses := make(map[string]order.OrderStatus, len(defaultStatuses))
+	for ghnStatus, status := range defaultStatuses {
+		statuses[ghnStatus] = status
+	}
+	return &StatusMapper{statuses: statuses}
+}
+
+// WithOverride maps the GHN status to the given order status, replacing its default mapping.
+// An empty order status makes the GHN status leave the order unchanged.
+func (m *StatusMapper) WithOverride(ghnStatus string, internal order.OrderStatus) *StatusMapper {
+	m.statuses[normalize(ghnStatus)] = internal
+	return m
+}
+
+// WithOverrides applies a comma-separated list of ghn_status:order_status overrides, e.g.
+// "delivery_fail:return_processing,lost:canceled". An empty order status, as in "lost:", makes
+// the GHN status leave the order unchanged.
+func (m *StatusMapper) WithOverrides(value string) (*StatusMapper, error) {
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		ghnStatus, internal, found := strings.Cut(part, ":")
+		if !found || strings.TrimSpace(ghnStatus) == "" {
+			return m, fmt.Errorf("invalid GHN status override %q, expected ghn_status:order_status", part)
+		}
+		status := order.OrderStatus(strings.ToLower(strings.TrimSpace(internal)))
+		if status != "" && !status.IsValid() {
+			return m, fmt.Errorf("invalid order status %q in GHN status override %q", status, part)
+		}
+		m.WithOverride(ghnStatus, status)
+	}
+	return m, nil
+}
+
+// Map returns the order status matching a GHN status. ok is false when the GHN status is unmapped;
+// a mapped GHN status may return an empty order status, meaning it leaves the order unchanged.
+func (m *StatusMapper) Map(ghnStatus string) (status order.OrderStatus, ok bool) {
+	status, ok = m.statuses[normalize(ghnStatus)]
+	return status, ok
+}
+
+// normalize returns the GHN status in the lower case form used by the mapping
+func normalize(ghnStatus string) string {
+	return strings.ToLower(strings.TrimSpace(ghnStatus))
+}
@@ -0,0 +1,77 @@
+package ghn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ybds/internal/models/order"
+)
+
+// TestStatusMapperDefaults tests the default mapping of GHN shipment statuses to order statuses
+func TestStatusMapperDefaults(t *testing.T) {
+	mapper := NewStatusMapper()
+
+	status, ok := mapper.Map("picked")
+	assert.True(t, ok)
+	assert.Equal(t, order.OrderPicked, status)
+
+	status, ok = mapper.Map(" Delivered ")
+	assert.True(t, ok)
+	assert.Equal(t, order.OrderDelivered, status)
+
+	status, ok = mapper.Map("cancel")
+	assert.True(t, ok)
+	assert.Equal(t, order.OrderCanceled, status)
+
+	// Statuses before pickup are known but leave the order unchanged
+	for _, ghnStatus := range []string{"ready_to_pick", "picking"} {
+		status, ok = mapper.Map(ghnStatus)
+		assert.True(t, ok, ghnStatus)
+		assert.Empty(t, status, ghnStatus)
+	}
+}
+
+// TestStatusMapperOverride tests that overrides replace the default mapping of a GHN status
+func TestStatusMapperOverride(t *testing.T) {
+	mapper := NewStatusMapper().
+		WithOverride("delivery_fail", order.OrderReturnProcessing).
+		WithOverride("Lost", order.OrderCanceled)
+
+	status, ok := mapper.Map("delivery_fail")
+	assert.True(t, ok)
+	assert.Equal(t, order.OrderReturnProcessing, status)
+
+	status, ok = mapper.Map("lost")
+	assert.True(t, ok)
+	assert.Equal(t, order.OrderCanceled, status)
+
+	// Overrides do not leak into other mappers
+	status, _ = NewStatusMapper().Map("delivery_fail")
+	assert.Equal(t, order.OrderDelivering, status)
+
+	mapper, err := NewStatusMapper().WithOverrides("delivering:picked, ready_to_pick:packed ,picked:")
+	assert.NoError(t, err)
+	status, _ = mapper.Map("delivering")
+	assert.Equal(t, order.OrderPicked, status)
+	status, _ = mapper.Map("ready_to_pick")
+	assert.Equal(t, order.OrderPacked, status)
+	status, ok = mapper.Map("picked")
+	assert.True(t, ok)
+	assert.Empty(t, status)
+
+	_, err = NewStatusMapper().WithOverrides("lost:missing")
+	assert.Error(t, err)
+	_, err = NewStatusMapper().WithOverrides("lost")
+	assert.Error(t, err)
+}
+
+// TestStatusMapperUnmapped tests that statuses missing from the mapping are reported as unmapped
+func TestStatusMapperUnmapped(t *testing.T) {
+	mapper := NewStatusMapper()
+
+	for _, ghnStatus := range []string{"lost", "damage", "unknown", ""} {
+		status, ok := mapper.Map(ghnStatus)
+		assert.False(t, ok, ghnStatus)
+		assert.Empty(t, status, ghnStatus)
+	}
+}
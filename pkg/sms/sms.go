@@ -0,0 +1,82 @@
+package sms
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ybds/pkg/phone"
+)
+
+// Default API URL format for sending messages through Twilio
+var twilioAPIURL = "https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json"
+
+// DefaultTimeout is how long a request to the SMS provider may take
+const DefaultTimeout = 10 * time.Second
+
+// SMSClient sends text messages to phone numbers
+type SMSClient interface {
+	// SendSMS sends the message to the phone number
+	SendSMS(phone, message string) error
+}
+
+// TwilioClient sends text messages through the Twilio Messages API
+type TwilioClient struct {
+	AccountSID string
+	AuthToken  string
+	From       string
+	HTTPClient *http.Client
+}
+
+// NewTwilioClient creates a new Twilio client sending messages from the given phone number
+func NewTwilioClient(accountSID, authToken, from string) *TwilioClient {
+	return &TwilioClient{
+		AccountSID: accountSID,
+		AuthToken:  authToken,
+		From:       from,
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// SendSMS sends a message to a phone number. Vietnamese numbers are converted to the E.164 format
+// Twilio expects; other numbers are only stripped of their formatting and left to Twilio to validate.
+func (c *TwilioClient) SendSMS(number, message string) error {
+	to, err := phone.Normalize(number)
+	if err != nil {
+		to = phone.National(number)
+	}
+
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", c.From)
+	form.Set("Body", message)
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(twilioAPIURL, c.AccountSID), strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.SetBasicAuth(c.AccountSID, c.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errorResponse struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&errorResponse); err == nil && errorResponse.Message != "" {
+			return fmt.Errorf("twilio API error: %s (code: %d)", errorResponse.Message, errorResponse.Code)
+		}
+		return fmt.Errorf("twilio API returned non-OK status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
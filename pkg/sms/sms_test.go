@@ -0,0 +1,79 @@
+package sms
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSendSMS(t *testing.T) {
+	// Create a mock server checking the request sent to Twilio
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected POST request, got %s", r.Method)
+		}
+		if r.URL.Path != "/AC123/Messages.json" {
+			t.Errorf("Expected the account's messages path, got %s", r.URL.Path)
+		}
+
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "AC123" || password != "secret" {
+			t.Errorf("Expected basic auth with the account SID and auth token, got %s:%s", user, password)
+		}
+
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("Failed to parse form: %v", err)
+		}
+		if to := r.PostForm.Get("To"); to != "+84912345678" {
+			t.Errorf("Expected To +84912345678, got %s", to)
+		}
+		if from := r.PostForm.Get("From"); from != "+15005550006" {
+			t.Errorf("Expected From +15005550006, got %s", from)
+		}
+		if body := r.PostForm.Get("Body"); body != "Test message" {
+			t.Errorf("Expected Body Test message, got %s", body)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"sid": "SM123", "status": "queued"}`))
+	}))
+	defer server.Close()
+
+	// Save the real API URL and replace it for the test
+	actualURL := twilioAPIURL
+	twilioAPIURL = server.URL + "/%s/Messages.json"
+	defer func() {
+		twilioAPIURL = actualURL
+	}()
+
+	client := NewTwilioClient("AC123", "secret", "+15005550006")
+	if err := client.SendSMS("091 234 5678", "Test message"); err != nil {
+		t.Errorf("SendSMS returned an error: %v", err)
+	}
+}
+
+func TestSendSMSError(t *testing.T) {
+	// Create a mock server that rejects the number
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code": 21211, "message": "The 'To' number is not a valid phone number."}`))
+	}))
+	defer server.Close()
+
+	actualURL := twilioAPIURL
+	twilioAPIURL = server.URL + "/%s/Messages.json"
+	defer func() {
+		twilioAPIURL = actualURL
+	}()
+
+	client := NewTwilioClient("AC123", "secret", "+15005550006")
+	err := client.SendSMS("123", "Test message")
+	if err == nil {
+		t.Fatal("SendSMS did not return an error when expected")
+	}
+	if want := "twilio API error: The 'To' number is not a valid phone number. (code: 21211)"; err.Error() != want {
+		t.Errorf("Expected error %q, got %q", want, err.Error())
+	}
+}
@@ -5,11 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 )
 
 // Default API URL format for Telegram
 var telegramAPIURL = "https://api.telegram.org/bot%s/sendMessage"
 
+// Default API URL format for fetching the bot's pending updates
+var telegramUpdatesURL = "https://api.telegram.org/bot%s/getUpdates"
+
 // TelegramClient represents a client for the Telegram Bot API
 type TelegramClient struct {
 	BotToken string
@@ -52,3 +58,155 @@ func (c *TelegramClient) SendMessage(chatID int64, message string) error {
 
 	return nil
 }
+
+// User represents a Telegram user or bot
+type User struct {
+	ID        int64  `json:"id"`
+	IsBot     bool   `json:"is_bot"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Username  string `json:"username"`
+}
+
+// FullName returns the first and last name of the user
+func (u User) FullName() string {
+	return strings.TrimSpace(u.FirstName + " " + u.LastName)
+}
+
+// Chat represents a Telegram chat
+type Chat struct {
+	ID        int64  `json:"id"`
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// Message represents a Telegram message
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	From      *User  `json:"from"`
+	Chat      Chat   `json:"chat"`
+	Date      int64  `json:"date"`
+	Text      string `json:"text"`
+}
+
+// ChatMemberUpdated represents a change of the bot's membership in a chat,
+// e.g. when the bot is started, blocked or added to a group
+type ChatMemberUpdated struct {
+	Chat Chat  `json:"chat"`
+	From User  `json:"from"`
+	Date int64 `json:"date"`
+}
+
+// Update represents an incoming update from the Telegram Bot API
+type Update struct {
+	UpdateID      int64              `json:"update_id"`
+	Message       *Message           `json:"message"`
+	EditedMessage *Message           `json:"edited_message"`
+	ChannelPost   *Message           `json:"channel_post"`
+	MyChatMember  *ChatMemberUpdated `json:"my_chat_member"`
+}
+
+// RecentChat summarizes a chat that recently interacted with the bot
+type RecentChat struct {
+	ChatID         int64
+	Type           string
+	Title          string
+	SenderName     string
+	SenderUsername string
+	LastMessage    string
+	LastSeenAt     time.Time
+}
+
+// GetUpdates fetches the updates the bot received in the last 24 hours that were not confirmed yet.
+// It fails while a webhook is set for the bot.
+func (c *TelegramClient) GetUpdates() ([]Update, error) {
+	url := fmt.Sprintf(telegramUpdatesURL, c.BotToken)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching updates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool     `json:"ok"`
+		Description string   `json:"description"`
+		Result      []Update `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("telegram API returned non-OK status: %d", resp.StatusCode)
+		}
+		return nil, fmt.Errorf("error decoding updates: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || !result.OK {
+		return nil, fmt.Errorf("telegram API error: %s (code: %d)", result.Description, resp.StatusCode)
+	}
+
+	return result.Result, nil
+}
+
+// GetRecentChats lists the chats found in the bot's pending updates, one entry per chat
+// with its latest sender and message, most recently seen first
+func (c *TelegramClient) GetRecentChats() ([]RecentChat, error) {
+	updates, err := c.GetUpdates()
+	if err != nil {
+		return nil, err
+	}
+
+	chats := make(map[int64]*RecentChat)
+	for _, update := range updates {
+		var chat Chat
+		var sender *User
+		var text string
+		var date int64
+
+		switch {
+		case update.Message != nil:
+			chat, sender, text, date = update.Message.Chat, update.Message.From, update.Message.Text, update.Message.Date
+		case update.EditedMessage != nil:
+			chat, sender, text, date = update.EditedMessage.Chat, update.EditedMessage.From, update.EditedMessage.Text, update.EditedMessage.Date
+		case update.ChannelPost != nil:
+			chat, sender, text, date = update.ChannelPost.Chat, update.ChannelPost.From, update.ChannelPost.Text, update.ChannelPost.Date
+		case update.MyChatMember != nil:
+			chat, sender, date = update.MyChatMember.Chat, &update.MyChatMember.From, update.MyChatMember.Date
+		default:
+			continue
+		}
+
+		seenAt := time.Unix(date, 0)
+		existing, ok := chats[chat.ID]
+		if ok && existing.LastSeenAt.After(seenAt) {
+			continue
+		}
+
+		recent := &RecentChat{
+			ChatID:      chat.ID,
+			Type:        chat.Type,
+			Title:       chat.Title,
+			LastMessage: text,
+			LastSeenAt:  seenAt,
+		}
+		if recent.Title == "" {
+			recent.Title = strings.TrimSpace(chat.FirstName + " " + chat.LastName)
+		}
+		if sender != nil {
+			recent.SenderName = sender.FullName()
+			recent.SenderUsername = sender.Username
+		}
+		chats[chat.ID] = recent
+	}
+
+	result := make([]RecentChat, 0, len(chats))
+	for _, chat := range chats {
+		result = append(result, *chat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastSeenAt.After(result[j].LastSeenAt)
+	})
+
+	return result, nil
+}
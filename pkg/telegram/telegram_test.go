@@ -68,3 +68,78 @@ func TestSendMessageError(t *testing.T) {
 		t.Error("SendMessage did not return an error when expected")
 	}
 }
+
+func TestGetRecentChats(t *testing.T) {
+	// Create a mock server returning updates from a private chat and a group
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Expected GET request, got %s", r.Method)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"ok": true, "result": [
+			{"update_id": 1, "message": {"message_id": 1, "date": 1700000000, "text": "/start",
+				"from": {"id": 111, "first_name": "Lan", "last_name": "Tran", "username": "lantran"},
+				"chat": {"id": 111, "type": "private", "first_name": "Lan", "last_name": "Tran", "username": "lantran"}}},
+			{"update_id": 2, "message": {"message_id": 2, "date": 1700000100, "text": "hello",
+				"from": {"id": 222, "first_name": "Minh"},
+				"chat": {"id": -1001, "type": "group", "title": "Warehouse"}}},
+			{"update_id": 3, "message": {"message_id": 3, "date": 1700000200, "text": "link me",
+				"from": {"id": 111, "first_name": "Lan", "last_name": "Tran", "username": "lantran"},
+				"chat": {"id": 111, "type": "private", "first_name": "Lan", "last_name": "Tran", "username": "lantran"}}}
+		]}`))
+	}))
+	defer server.Close()
+
+	// Save the real API URL and replace it for the test
+	actualURL := "https://api.telegram.org/bot%s/getUpdates"
+	telegramUpdatesURL = server.URL + "/%s/getUpdates"
+	defer func() {
+		telegramUpdatesURL = actualURL
+	}()
+
+	client := NewClient("test_token")
+
+	chats, err := client.GetRecentChats()
+	if err != nil {
+		t.Fatalf("GetRecentChats returned an error: %v", err)
+	}
+	if len(chats) != 2 {
+		t.Fatalf("Expected 2 chats, got %d", len(chats))
+	}
+
+	// The private chat was seen last and keeps only its latest message
+	if chats[0].ChatID != 111 || chats[0].SenderName != "Lan Tran" || chats[0].SenderUsername != "lantran" {
+		t.Errorf("Unexpected first chat: %+v", chats[0])
+	}
+	if chats[0].LastMessage != "link me" || chats[0].Type != "private" {
+		t.Errorf("Expected the latest private message, got %+v", chats[0])
+	}
+	if chats[1].ChatID != -1001 || chats[1].Title != "Warehouse" || chats[1].SenderName != "Minh" {
+		t.Errorf("Unexpected second chat: %+v", chats[1])
+	}
+}
+
+func TestGetUpdatesError(t *testing.T) {
+	// Create a mock server that rejects polling because a webhook is set
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"ok": false, "error_code": 409, "description": "Conflict: can't use getUpdates method while webhook is active"}`))
+	}))
+	defer server.Close()
+
+	// Save the real API URL and replace it for the test
+	actualURL := "https://api.telegram.org/bot%s/getUpdates"
+	telegramUpdatesURL = server.URL + "/%s/getUpdates"
+	defer func() {
+		telegramUpdatesURL = actualURL
+	}()
+
+	client := NewClient("test_token")
+
+	if _, err := client.GetUpdates(); err == nil {
+		t.Error("GetUpdates did not return an error when expected")
+	}
+}
@@ -3,6 +3,7 @@ package upload
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 )
 
 // StorageType defines the type of storage to use
@@ -34,6 +35,12 @@ type Config struct {
 
 	// S3Config contains AWS S3 configuration
 	S3Config *S3Config
+
+	// Storage overrides the storage selected by StorageType, e.g. to use another object store
+	Storage Storage
+
+	// ImageVariants are the resized copies stored alongside every uploaded image
+	ImageVariants []ImageVariant
 }
 
 // S3Config contains configuration for AWS S3
@@ -43,6 +50,10 @@ type S3Config struct {
 	Region    string
 	Bucket    string
 	Prefix    string
+	// Endpoint is the URL of an S3 compatible object store; empty for AWS S3
+	Endpoint string
+	// BaseURL is the URL files are served from, e.g. a CDN; the bucket URL when empty
+	BaseURL string
 }
 
 // NewConfig creates a new upload configuration with default values
@@ -55,8 +66,9 @@ func NewConfig(baseDir string) *Config {
 			"image/gif":  true,
 			"image/webp": true,
 		},
-		MaxSize:     10, // 10MB default
-		StorageType: StorageTypeLocal,
+		MaxSize:       10, // 10MB default
+		StorageType:   StorageTypeLocal,
+		ImageVariants: DefaultImageVariants(),
 	}
 }
 
@@ -85,6 +97,13 @@ func (c *Config) WithMaxSize(maxSize int64) *Config {
 	return c
 }
 
+// WithImageVariants sets the resized copies generated for uploaded images; none are generated
+// when variants is empty
+func (c *Config) WithImageVariants(variants []ImageVariant) *Config {
+	c.ImageVariants = variants
+	return c
+}
+
 // WithAllowedTypes sets the allowed MIME types
 func (c *Config) WithAllowedTypes(types []string) *Config {
 	c.AllowedTypes = make(map[string]bool)
@@ -103,6 +122,18 @@ func (c *Config) AddAllowedType(mimeType string) *Config {
 	return c
 }
 
+// AllowedTypeList returns the allowed MIME types in alphabetical order
+func (c *Config) AllowedTypeList() []string {
+	types := make([]string, 0, len(c.AllowedTypes))
+	for t, allowed := range c.AllowedTypes {
+		if allowed {
+			types = append(types, t)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
 // GetUploadDir returns the full upload directory path
 func (c *Config) GetUploadDir() string {
 	if c.SubDir != "" {
@@ -0,0 +1,120 @@
+package upload
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	// VariantThumbnail is the name of the small image variant shown in lists
+	VariantThumbnail = "thumbnail"
+	// VariantMedium is the name of the image variant shown on detail pages
+	VariantMedium = "medium"
+)
+
+// JPEGQuality is the quality resized JPEG variants are encoded with
+const JPEGQuality = 85
+
+// MaxImagePixels is the largest image, in pixels, that variants are generated for. Decoding keeps
+// the whole image in memory, so a small file declaring huge dimensions is stored without variants
+// instead of being decoded.
+const MaxImagePixels = 50 * 1000 * 1000
+
+// ImageVariant is a resized copy generated for every uploaded image
+type ImageVariant struct {
+	// Name identifies the variant and is appended to the filename of the original
+	Name string
+	// MaxSize is the largest width and height of the variant in pixels
+	MaxSize int
+}
+
+// DefaultImageVariants returns the variants generated when none are configured
+func DefaultImageVariants() []ImageVariant {
+	return []ImageVariant{
+		{Name: VariantThumbnail, MaxSize: 200},
+		{Name: VariantMedium, MaxSize: 800},
+	}
+}
+
+// resizedImage is an encoded image variant ready to be stored
+type resizedImage struct {
+	variant     ImageVariant
+	data        []byte
+	contentType string
+}
+
+// resizeImage generates the variants of an image. Only JPEG and PNG images are resized; nil is
+// returned for a variant when the image cannot be decoded, has more than MaxImagePixels pixels or
+// is already no larger than the variant, so the original is used in its place.
+func resizeImage(src io.ReadSeeker, contentType string, variants []ImageVariant) ([]*resizedImage, error) {
+	if contentType != "image/jpeg" && contentType != "image/png" {
+		return make([]*resizedImage, len(variants)), nil
+	}
+
+	// Check the dimensions from the header before allocating the decoded image
+	config, _, err := image.DecodeConfig(src)
+	if err != nil || int64(config.Width)*int64(config.Height) > MaxImagePixels {
+		return make([]*resizedImage, len(variants)), nil
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to reset file pointer: %w", err)
+	}
+
+	img, _, err := image.Decode(src)
+	if err != nil {
+		return make([]*resizedImage, len(variants)), nil
+	}
+
+	resized := make([]*resizedImage, len(variants))
+	for i, variant := range variants {
+		bounds := img.Bounds()
+		width, height := fitWithin(bounds.Dx(), bounds.Dy(), variant.MaxSize)
+		if width == bounds.Dx() && height == bounds.Dy() {
+			continue
+		}
+
+		dst := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+
+		var buf bytes.Buffer
+		if contentType == "image/png" {
+			err = png.Encode(&buf, dst)
+		} else {
+			err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: JPEGQuality})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s variant: %w", variant.Name, err)
+		}
+
+		resized[i] = &resizedImage{variant: variant, data: buf.Bytes(), contentType: contentType}
+	}
+
+	return resized, nil
+}
+
+// fitWithin scales width and height down, keeping the aspect ratio, so that neither exceeds
+// maxSize. Images that already fit are not enlarged.
+func fitWithin(width, height, maxSize int) (int, int) {
+	if maxSize <= 0 || (width <= maxSize && height <= maxSize) {
+		return width, height
+	}
+	if width >= height {
+		return maxSize, max(1, height*maxSize/width)
+	}
+	return max(1, width*maxSize/height), maxSize
+}
+
+// variantFilename returns the name a variant of a file is stored under, e.g. photo_thumbnail.jpg
+// for the thumbnail of photo.jpg
+func variantFilename(filename, variant string) string {
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + "_" + variant + ext
+}
@@ -0,0 +1,126 @@
+package upload
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// S3API is the part of the S3 client used by S3Storage
+type S3API interface {
+	PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error)
+	DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error)
+}
+
+// S3Storage stores uploaded files in an S3 bucket or an S3 compatible object store, so every
+// server instance sees the same files. Files are served straight from the bucket or a CDN.
+type S3Storage struct {
+	Client S3API
+	Bucket string
+	// Prefix is prepended to the name of every file to form its object key
+	Prefix string
+	// BaseURL is the URL of the bucket or of the CDN in front of it
+	BaseURL string
+}
+
+// NewS3Storage creates a new S3 storage from the given configuration. Objects are stored under
+// the configured prefix followed by subDir.
+func NewS3Storage(config *S3Config, subDir string) (*S3Storage, error) {
+	awsConfig := &aws.Config{
+		Region: aws.String(config.Region),
+		Credentials: credentials.NewStaticCredentials(
+			config.AccessKey,
+			config.SecretKey,
+			"",
+		),
+	}
+	if config.Endpoint != "" {
+		// Object stores other than AWS are usually addressed by path rather than by subdomain
+		awsConfig.Endpoint = aws.String(config.Endpoint)
+		awsConfig.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %w", err)
+	}
+
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", config.Bucket, config.Region)
+	}
+
+	return &S3Storage{
+		Client:  s3.New(sess),
+		Bucket:  config.Bucket,
+		Prefix:  joinS3Path(config.Prefix, subDir),
+		BaseURL: baseURL,
+	}, nil
+}
+
+// Save uploads the content to the bucket
+func (s *S3Storage) Save(name string, content io.Reader, contentType string) error {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	_, err = s.Client.PutObject(&s3.PutObjectInput{
+		Bucket:               aws.String(s.Bucket),
+		Key:                  aws.String(s.Path(name)),
+		Body:                 bytes.NewReader(data),
+		ContentLength:        aws.Int64(int64(len(data))),
+		ContentType:          aws.String(contentType),
+		ServerSideEncryption: aws.String("AES256"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload file to S3: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a file from the bucket. Deleting a file that does not exist succeeds.
+func (s *S3Storage) Delete(name string) error {
+	_, err := s.Client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.Path(name)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete file from S3: %w", err)
+	}
+	return nil
+}
+
+// Path returns the object key of a file
+func (s *S3Storage) Path(name string) string {
+	return joinS3Path(s.Prefix, name)
+}
+
+// URL returns the URL of a file in the bucket or CDN
+func (s *S3Storage) URL(name string) string {
+	return strings.TrimSuffix(s.BaseURL, "/") + "/" + s.Path(name)
+}
+
+// joinS3Path joins S3 path segments without creating double slashes
+func joinS3Path(segments ...string) string {
+	var result []string
+
+	for _, segment := range segments {
+		if segment == "" {
+			continue
+		}
+		// Trim any leading or trailing slashes
+		trimmed := strings.Trim(segment, "/")
+		if trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+
+	return strings.Join(result, "/")
+}
@@ -0,0 +1,83 @@
+package upload
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// LocalURLPrefix is the URL path locally stored uploads are served under
+const LocalURLPrefix = "/uploads"
+
+// ErrNotExist is returned when deleting a file that is not stored
+var ErrNotExist = errors.New("file does not exist")
+
+// Storage stores uploaded files. Files are identified by their name, which contains no slashes.
+type Storage interface {
+	// Save stores the content under the given name
+	Save(name string, content io.Reader, contentType string) error
+	// Delete removes the file stored under the given name. Stores that can tell return
+	// ErrNotExist when there is no such file.
+	Delete(name string) error
+	// Path returns where the file is kept, i.e. a path on disk or an object key
+	Path(name string) string
+	// URL returns the URL the file is served at
+	URL(name string) string
+}
+
+// LocalStorage stores uploaded files in a directory on the local filesystem. The files are only
+// visible to the server instance that stored them.
+type LocalStorage struct {
+	// Dir is the directory the files are stored in
+	Dir string
+	// BaseURL is the URL path the directory is served under
+	BaseURL string
+}
+
+// NewLocalStorage creates a new local storage, creating its directory if needed
+func NewLocalStorage(dir, baseURL string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	return &LocalStorage{Dir: dir, BaseURL: baseURL}, nil
+}
+
+// Save writes the content to a file in the storage directory
+func (s *LocalStorage) Save(name string, content io.Reader, contentType string) error {
+	dst, err := os.Create(s.Path(name))
+	if err != nil {
+		return fmt.Errorf("failed to create destination file: %w", err)
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, content); err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a file from the storage directory
+func (s *LocalStorage) Delete(name string) error {
+	filePath := s.Path(name)
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return ErrNotExist
+	}
+
+	if err := os.Remove(filePath); err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+// Path returns the path of a file on disk
+func (s *LocalStorage) Path(name string) string {
+	return filepath.Join(s.Dir, name)
+}
+
+// URL returns the URL path a file is served at
+func (s *LocalStorage) URL(name string) string {
+	return path.Join(s.BaseURL, name)
+}
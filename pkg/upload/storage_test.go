@@ -0,0 +1,155 @@
+package upload
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gofiber/fiber/v2"
+)
+
+// memoryS3 is an in-memory S3 client keeping objects by bucket and key
+type memoryS3 struct {
+	mu           sync.Mutex
+	objects      map[string][]byte
+	contentTypes map[string]string
+}
+
+func newMemoryS3() *memoryS3 {
+	return &memoryS3{objects: make(map[string][]byte), contentTypes: make(map[string]string)}
+}
+
+func (m *memoryS3) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	data, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := aws.StringValue(input.Bucket) + "/" + aws.StringValue(input.Key)
+	m.objects[key] = data
+	m.contentTypes[key] = aws.StringValue(input.ContentType)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (m *memoryS3) DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, aws.StringValue(input.Bucket)+"/"+aws.StringValue(input.Key))
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func TestLocalStorage(t *testing.T) {
+	dir := t.TempDir() + "/products"
+	storage, err := NewLocalStorage(dir, "/uploads/products")
+	if err != nil {
+		t.Fatalf("Failed to create local storage: %v", err)
+	}
+
+	if err := storage.Save("photo.jpg", strings.NewReader("content"), "image/jpeg"); err != nil {
+		t.Fatalf("Failed to save file: %v", err)
+	}
+	if data, err := os.ReadFile(dir + "/photo.jpg"); err != nil || string(data) != "content" {
+		t.Errorf("Expected the file to be written to the storage directory, got %q, %v", data, err)
+	}
+	if url := storage.URL("photo.jpg"); url != "/uploads/products/photo.jpg" {
+		t.Errorf("Expected URL /uploads/products/photo.jpg, got %s", url)
+	}
+
+	if err := storage.Delete("photo.jpg"); err != nil {
+		t.Errorf("Failed to delete file: %v", err)
+	}
+	if err := storage.Delete("photo.jpg"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("Expected ErrNotExist deleting a missing file, got %v", err)
+	}
+}
+
+func TestS3Storage(t *testing.T) {
+	client := newMemoryS3()
+	storage := &S3Storage{Client: client, Bucket: "shop", Prefix: "media/products", BaseURL: "https://cdn.example.com/"}
+
+	if err := storage.Save("photo.jpg", strings.NewReader("content"), "image/jpeg"); err != nil {
+		t.Fatalf("Failed to save file: %v", err)
+	}
+	if data := client.objects["shop/media/products/photo.jpg"]; string(data) != "content" {
+		t.Errorf("Expected the object to be stored under the prefix, got %q", data)
+	}
+	if contentType := client.contentTypes["shop/media/products/photo.jpg"]; contentType != "image/jpeg" {
+		t.Errorf("Expected content type image/jpeg, got %s", contentType)
+	}
+	if url := storage.URL("photo.jpg"); url != "https://cdn.example.com/media/products/photo.jpg" {
+		t.Errorf("Expected the CDN URL of the object, got %s", url)
+	}
+
+	if err := storage.Delete("photo.jpg"); err != nil {
+		t.Errorf("Failed to delete file: %v", err)
+	}
+	if len(client.objects) != 0 {
+		t.Errorf("Expected the object to be deleted, %d left", len(client.objects))
+	}
+}
+
+func TestServiceWithS3Storage(t *testing.T) {
+	client := newMemoryS3()
+	config := NewConfig(t.TempDir())
+	config.Storage = &S3Storage{Client: client, Bucket: "shop", BaseURL: "https://shop.s3.ap-southeast-1.amazonaws.com"}
+	service, err := NewService(config)
+	if err != nil {
+		t.Fatalf("Failed to create upload service: %v", err)
+	}
+
+	gif := []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;")
+	result, err := service.Upload(newFileHeader(t, "animation.gif", gif), "product-images")
+	if err != nil {
+		t.Fatalf("Failed to upload file: %v", err)
+	}
+	if _, ok := client.objects["shop/"+result.Filename]; !ok {
+		t.Errorf("Expected the file to be stored in the bucket as %s", result.Filename)
+	}
+	if result.URL != "https://shop.s3.ap-southeast-1.amazonaws.com/"+result.Filename {
+		t.Errorf("Expected the bucket URL of the file, got %s", result.URL)
+	}
+
+	// Files in the bucket are not served by the server
+	app := fiber.New()
+	service.RegisterStaticRoutes(app)
+	if routes := app.GetRoutes(); len(routes) != 0 {
+		t.Errorf("Expected no static routes with S3 storage, got %d", len(routes))
+	}
+
+	if err := service.Delete(result.Filename); err != nil {
+		t.Errorf("Failed to delete file: %v", err)
+	}
+	if len(client.objects) != 0 {
+		t.Errorf("Expected the object to be deleted, %d left", len(client.objects))
+	}
+}
+
+func TestRegisterStaticRoutes(t *testing.T) {
+	config := NewConfig(t.TempDir()).WithSubDir("products")
+	service, err := NewService(config)
+	if err != nil {
+		t.Fatalf("Failed to create upload service: %v", err)
+	}
+
+	result, err := service.Upload(newFileHeader(t, "animation.gif", []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;")), "")
+	if err != nil {
+		t.Fatalf("Failed to upload file: %v", err)
+	}
+
+	app := fiber.New()
+	service.RegisterStaticRoutes(app)
+	resp, err := app.Test(httptest.NewRequest("GET", result.URL, nil))
+	if err != nil {
+		t.Fatalf("Failed to request %s: %v", result.URL, err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Errorf("Expected the uploaded file to be served at %s, got status %d", result.URL, resp.StatusCode)
+	}
+}
@@ -1,16 +1,29 @@
 package upload
 
 import (
+	"bytes"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Upload errors reject a file before it is stored. Use errors.Is to check for them.
+var (
+	// ErrFileTooLarge indicates that the file exceeds the configured maximum size
+	ErrFileTooLarge = errors.New("file too large")
+	// ErrFileTypeNotAllowed indicates that the content of the file is not of an allowed type
+	ErrFileTypeNotAllowed = errors.New("file type not allowed")
 )
 
 // UploadResult represents the result of a file upload
@@ -20,6 +33,17 @@ type UploadResult struct {
 	ContentType string `json:"content_type"`
 	Path        string `json:"path"`
 	URL         string `json:"url"`
+	// Variants are the resized copies of an uploaded image keyed by variant name
+	Variants map[string]*UploadResult `json:"variants,omitempty"`
+}
+
+// VariantURL returns the URL of the named variant of an uploaded image, or the URL of the
+// original when the variant was not generated, e.g. for a GIF or an image smaller than the variant
+func (r *UploadResult) VariantURL(name string) string {
+	if variant, ok := r.Variants[name]; ok {
+		return variant.URL
+	}
+	return r.URL
 }
 
 // MultipleUploadResult represents the result of multiple file uploads
@@ -29,46 +53,61 @@ type MultipleUploadResult struct {
 
 // Service handles file uploads
 type Service struct {
-	config   *Config
-	s3Client *S3Client
+	config  *Config
+	storage Storage
 }
 
-// NewService creates a new upload service
+// NewService creates a new upload service storing files in the storage selected by the
+// configuration
 func NewService(config *Config) (*Service, error) {
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid upload configuration: %w", err)
 	}
 
-	service := &Service{
-		config: config,
-	}
-
-	// Initialize S3 client if using S3 storage
-	if config.StorageType == StorageTypeS3 {
-		s3Client, err := NewS3Client(
-			config.S3Config.AccessKey,
-			config.S3Config.SecretKey,
-			config.S3Config.Region,
-			config.S3Config.Bucket,
-			config.S3Config.Prefix,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to initialize S3 client: %w", err)
-		}
-		service.s3Client = s3Client
-	} else if config.StorageType == StorageTypeLocal {
-		// Ensure upload directory exists for local storage
-		uploadDir := config.GetUploadDir()
-		if err := os.MkdirAll(uploadDir, 0755); err != nil {
-			return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	storage := config.Storage
+	if storage == nil {
+		var err error
+		switch config.StorageType {
+		case StorageTypeS3:
+			storage, err = NewS3Storage(config.S3Config, config.SubDir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to initialize S3 storage: %w", err)
+			}
+		default:
+			storage, err = NewLocalStorage(config.GetUploadDir(), path.Join(LocalURLPrefix, config.SubDir))
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	return service, nil
+	return &Service{
+		config:  config,
+		storage: storage,
+	}, nil
+}
+
+// RegisterStaticRoutes serves locally stored uploads under their URL path. Nothing is registered
+// for other storages, whose URLs point at the bucket or CDN serving the files.
+func (s *Service) RegisterStaticRoutes(router fiber.Router) {
+	local, ok := s.storage.(*LocalStorage)
+	if !ok {
+		return
+	}
+	router.Static(local.BaseURL, local.Dir, fiber.Static{
+		Browse: false,
+	})
 }
 
-// Upload handles a file upload from a multipart form
+// Upload handles a file upload from a multipart form. Files over the maximum size and files whose
+// content, whatever their extension, is not of an allowed type are rejected with ErrFileTooLarge
+// and ErrFileTypeNotAllowed.
 func (s *Service) Upload(file *multipart.FileHeader, subDir string) (*UploadResult, error) {
+	// Validate file size
+	if file.Size > s.config.MaxSize*1024*1024 {
+		return nil, fmt.Errorf("%w: %s exceeds the limit of %d MB", ErrFileTooLarge, file.Filename, s.config.MaxSize)
+	}
+
 	// Open the uploaded file
 	src, err := file.Open()
 	if err != nil {
@@ -78,8 +117,8 @@ func (s *Service) Upload(file *multipart.FileHeader, subDir string) (*UploadResu
 
 	// Read the first 512 bytes to determine the content type
 	buffer := make([]byte, 512)
-	_, err = src.Read(buffer)
-	if err != nil && err != io.EOF {
+	n, err := io.ReadFull(src, buffer)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
 		return nil, fmt.Errorf("failed to read file header: %w", err)
 	}
 
@@ -89,59 +128,75 @@ func (s *Service) Upload(file *multipart.FileHeader, subDir string) (*UploadResu
 		return nil, fmt.Errorf("failed to reset file pointer: %w", err)
 	}
 
-	// Detect content type
-	contentType := http.DetectContentType(buffer)
+	// Detect content type from the content itself; the extension and the declared type can be anything
+	contentType := http.DetectContentType(buffer[:n])
 
 	// Validate file type
 	if !s.config.AllowedTypes[contentType] {
-		return nil, fmt.Errorf("file type %s is not allowed", contentType)
+		return nil, fmt.Errorf("%w: %s is %s, allowed types are %s", ErrFileTypeNotAllowed, file.Filename, contentType, strings.Join(s.config.AllowedTypeList(), ", "))
 	}
 
-	// Validate file size
-	if file.Size > s.config.MaxSize*1024*1024 {
-		return nil, fmt.Errorf("file size exceeds the limit of %d MB", s.config.MaxSize)
+	// Store the file under a unique filename
+	filename := s.generateFilename(file.Filename, subDir)
+	if err := s.storage.Save(filename, src, contentType); err != nil {
+		return nil, err
+	}
+	result := &UploadResult{
+		Filename:    filename,
+		Size:        file.Size,
+		ContentType: contentType,
+		Path:        s.storage.Path(filename),
+		URL:         s.storage.URL(filename),
 	}
 
-	// Based on storage type, upload to either local storage or S3
-	if s.config.StorageType == StorageTypeS3 {
-		return s.s3Client.UploadFile(file, subDir)
+	// Store the resized copies of images alongside the original; the original stands in for
+	// variants that cannot be stored
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to reset file pointer: %w", err)
+	}
+	if err := s.storeImageVariants(result, src, contentType); err != nil {
+		fmt.Printf("Warning: failed to store image variants of %s, using the original: %v\n", result.Filename, err)
 	}
 
-	// Otherwise, upload to local storage
-	return s.uploadToLocalStorage(file, src, contentType, subDir)
+	return result, nil
 }
 
-// uploadToLocalStorage saves the file to the local filesystem
-func (s *Service) uploadToLocalStorage(file *multipart.FileHeader, src io.Reader, contentType, subDir string) (*UploadResult, error) {
-	// Generate a unique filename
-	filename := s.generateFilename(file.Filename, subDir)
-
-	// Create the destination file
-	uploadDir := s.config.GetUploadDir()
-	dst, err := os.Create(filepath.Join(uploadDir, filename))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create destination file: %w", err)
+// storeImageVariants generates the configured variants of an uploaded image and stores each next
+// to the original under the name of the original with the variant name appended
+func (s *Service) storeImageVariants(result *UploadResult, src io.ReadSeeker, contentType string) error {
+	if len(s.config.ImageVariants) == 0 {
+		return nil
 	}
-	defer dst.Close()
 
-	// Copy the file
-	_, err = io.Copy(dst, src)
+	resized, err := resizeImage(src, contentType, s.config.ImageVariants)
 	if err != nil {
-		return nil, fmt.Errorf("failed to save file: %w", err)
+		return err
 	}
 
-	// Construct the URL path
-	urlPath := filepath.Join("/uploads", s.config.SubDir, filename)
-	urlPath = strings.ReplaceAll(urlPath, "\\", "/") // Ensure forward slashes for URLs
+	for _, img := range resized {
+		if img == nil {
+			continue
+		}
 
-	// Return the result
-	return &UploadResult{
-		Filename:    filename,
-		Size:        file.Size,
-		ContentType: contentType,
-		Path:        filepath.Join(uploadDir, filename),
-		URL:         urlPath,
-	}, nil
+		filename := variantFilename(result.Filename, img.variant.Name)
+		if err := s.storage.Save(filename, bytes.NewReader(img.data), img.contentType); err != nil {
+			return fmt.Errorf("failed to save %s variant: %w", img.variant.Name, err)
+		}
+		variant := &UploadResult{
+			Filename:    filename,
+			Size:        int64(len(img.data)),
+			ContentType: img.contentType,
+			Path:        s.storage.Path(filename),
+			URL:         s.storage.URL(filename),
+		}
+
+		if result.Variants == nil {
+			result.Variants = make(map[string]*UploadResult)
+		}
+		result.Variants[img.variant.Name] = variant
+	}
+
+	return nil
 }
 
 // UploadMultiple handles multiple file uploads
@@ -150,16 +205,7 @@ func (s *Service) UploadMultiple(files []*multipart.FileHeader, subDir string) (
 		return nil, fmt.Errorf("no files provided")
 	}
 
-	// If using S3, we can use the batch upload capability
-	if s.config.StorageType == StorageTypeS3 {
-		results, err := s.s3Client.UploadMultipleFiles(files, subDir)
-		if err != nil {
-			return nil, err
-		}
-		return &MultipleUploadResult{Files: results}, nil
-	}
-
-	// Otherwise, upload each file individually to local storage
+	// Upload each file individually so images get their variants
 	results := make([]*UploadResult, 0, len(files))
 	for _, file := range files {
 		result, err := s.Upload(file, subDir)
@@ -179,23 +225,15 @@ func (s *Service) Delete(filename string) error {
 		return fmt.Errorf("invalid filename")
 	}
 
-	// Based on storage type, delete from either local storage or S3
-	if s.config.StorageType == StorageTypeS3 {
-		return s.s3Client.DeleteFile(filename)
-	}
-
-	// Otherwise, delete from local storage
-	// Get the full path
-	path := filepath.Join(s.config.GetUploadDir(), filename)
-
-	// Check if file exists
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return fmt.Errorf("file does not exist")
+	if err := s.storage.Delete(filename); err != nil {
+		return err
 	}
 
-	// Delete the file
-	if err := os.Remove(path); err != nil {
-		return fmt.Errorf("failed to delete file: %w", err)
+	// Delete the resized copies of images too; files without variants have none to delete
+	for _, variant := range s.config.ImageVariants {
+		if err := s.storage.Delete(variantFilename(filename, variant.Name)); err != nil && !errors.Is(err, ErrNotExist) {
+			return fmt.Errorf("failed to delete %s variant: %w", variant.Name, err)
+		}
 	}
 
 	return nil
@@ -212,10 +250,10 @@ func (s *Service) SoftDelete(filename string) error {
 	// Log the deletion request
 	fmt.Printf("Soft delete requested for file: %s (not physically deleted)\n", filename)
 
-	// For S3, this would normally call DeleteFile, but we skip it
+	// For S3, this would normally call Delete, but we skip it
 	// For local storage, check if the file exists but don't delete it
-	if s.config.StorageType == StorageTypeLocal {
-		path := filepath.Join(s.config.GetUploadDir(), filename)
+	if local, ok := s.storage.(*LocalStorage); ok {
+		path := local.Path(filename)
 		if _, err := os.Stat(path); os.IsNotExist(err) {
 			// Log but don't return error since we're not actually deleting
 			fmt.Printf("Note: File %s does not exist in local storage\n", path)
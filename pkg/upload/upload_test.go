@@ -1,11 +1,62 @@
 package upload
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
 	"os"
 	"path/filepath"
 	"testing"
 )
 
+// newFileHeader builds the multipart file header of a form field holding data
+func newFileHeader(t *testing.T, filename string, data []byte) *multipart.FileHeader {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		t.Fatalf("Failed to write form file: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close multipart writer: %v", err)
+	}
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(10 << 20)
+	if err != nil {
+		t.Fatalf("Failed to read multipart form: %v", err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File["file"][0]
+}
+
+// decodePNGSize returns the dimensions of the PNG file at path
+func decodePNGSize(t *testing.T, path string) (int, int) {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	config, err := png.DecodeConfig(f)
+	if err != nil {
+		t.Fatalf("Failed to decode %s: %v", path, err)
+	}
+	return config.Width, config.Height
+}
+
 func TestUploadService(t *testing.T) {
 	// Create a temporary directory for testing
 	tempDir, err := os.MkdirTemp("", "upload-test")
@@ -88,7 +139,161 @@ func TestUploadService(t *testing.T) {
 	})
 }
 
-func TestS3Upload(t *testing.T) {
-	// Skip this test as it requires AWS credentials
-	t.Skip("Skipping S3 tests - requires AWS credentials")
+func TestUploadImageVariants(t *testing.T) {
+	tempDir := t.TempDir()
+	service, err := NewService(NewConfig(tempDir))
+	if err != nil {
+		t.Fatalf("Failed to create upload service: %v", err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 1600, 1000))
+	for x := 0; x < 1600; x++ {
+		img.Set(x, x%1000, color.RGBA{R: 200, A: 255})
+	}
+	var data bytes.Buffer
+	if err := png.Encode(&data, img); err != nil {
+		t.Fatalf("Failed to encode sample image: %v", err)
+	}
+
+	result, err := service.Upload(newFileHeader(t, "sample.png", data.Bytes()), "products")
+	if err != nil {
+		t.Fatalf("Failed to upload image: %v", err)
+	}
+
+	// The original is kept as it was uploaded
+	if w, h := decodePNGSize(t, result.Path); w != 1600 || h != 1000 {
+		t.Errorf("Expected the original to be 1600x1000, got %dx%d", w, h)
+	}
+
+	expected := map[string][2]int{
+		VariantThumbnail: {200, 125},
+		VariantMedium:    {800, 500},
+	}
+	for name, size := range expected {
+		variant, ok := result.Variants[name]
+		if !ok {
+			t.Errorf("Expected a %s variant", name)
+			continue
+		}
+		if filepath.Dir(variant.Path) != filepath.Dir(result.Path) {
+			t.Errorf("Expected the %s variant next to the original, got %s", name, variant.Path)
+		}
+		if w, h := decodePNGSize(t, variant.Path); w != size[0] || h != size[1] {
+			t.Errorf("Expected the %s variant to be %dx%d, got %dx%d", name, size[0], size[1], w, h)
+		}
+		if result.VariantURL(name) != variant.URL || variant.URL == result.URL {
+			t.Errorf("Expected a separate URL for the %s variant, got %s", name, variant.URL)
+		}
+	}
+
+	// Deleting the image deletes its variants too
+	if err := service.Delete(result.Filename); err != nil {
+		t.Fatalf("Failed to delete image: %v", err)
+	}
+	for name, variant := range result.Variants {
+		if _, err := os.Stat(variant.Path); !os.IsNotExist(err) {
+			t.Errorf("Expected the %s variant to be deleted", name)
+		}
+	}
+}
+
+func TestUploadImageVariantsFallback(t *testing.T) {
+	tempDir := t.TempDir()
+	service, err := NewService(NewConfig(tempDir))
+	if err != nil {
+		t.Fatalf("Failed to create upload service: %v", err)
+	}
+
+	// GIFs are not resized and images smaller than a variant are not enlarged
+	gif := []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;")
+	small := new(bytes.Buffer)
+	if err := png.Encode(small, image.NewRGBA(image.Rect(0, 0, 100, 50))); err != nil {
+		t.Fatalf("Failed to encode sample image: %v", err)
+	}
+
+	for filename, data := range map[string][]byte{"sample.gif": gif, "small.png": small.Bytes()} {
+		result, err := service.Upload(newFileHeader(t, filename, data), "")
+		if err != nil {
+			t.Fatalf("Failed to upload %s: %v", filename, err)
+		}
+		if len(result.Variants) != 0 {
+			t.Errorf("Expected no variants of %s, got %d", filename, len(result.Variants))
+		}
+		if result.VariantURL(VariantThumbnail) != result.URL {
+			t.Errorf("Expected the thumbnail of %s to fall back to the original", filename)
+		}
+	}
+}
+
+func TestUploadImageVariantsPixelLimit(t *testing.T) {
+	tempDir := t.TempDir()
+	service, err := NewService(NewConfig(tempDir))
+	if err != nil {
+		t.Fatalf("Failed to create upload service: %v", err)
+	}
+
+	// A tiny PNG whose header declares 20000x20000 pixels, far more than is decoded
+	var data bytes.Buffer
+	if err := png.Encode(&data, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatalf("Failed to encode sample image: %v", err)
+	}
+	bomb := data.Bytes()
+	binary.BigEndian.PutUint32(bomb[16:20], 20000)
+	binary.BigEndian.PutUint32(bomb[20:24], 20000)
+	binary.BigEndian.PutUint32(bomb[29:33], crc32.ChecksumIEEE(bomb[12:29]))
+	if config, err := png.DecodeConfig(bytes.NewReader(bomb)); err != nil || config.Width != 20000 {
+		t.Fatalf("Expected a valid header declaring 20000 pixels wide, got %+v: %v", config, err)
+	}
+
+	result, err := service.Upload(newFileHeader(t, "huge.png", bomb), "")
+	if err != nil {
+		t.Fatalf("Failed to upload image: %v", err)
+	}
+	if len(result.Variants) != 0 {
+		t.Errorf("Expected no variants of an image over the pixel limit, got %d", len(result.Variants))
+	}
+	if result.VariantURL(VariantMedium) != result.URL {
+		t.Errorf("Expected the medium variant to fall back to the original")
+	}
+}
+
+func TestUploadValidation(t *testing.T) {
+	service, err := NewService(NewConfig(t.TempDir()).WithMaxSize(1))
+	if err != nil {
+		t.Fatalf("Failed to create upload service: %v", err)
+	}
+
+	var photo bytes.Buffer
+	if err := jpeg.Encode(&photo, image.NewRGBA(image.Rect(0, 0, 40, 30)), nil); err != nil {
+		t.Fatalf("Failed to encode sample image: %v", err)
+	}
+
+	t.Run("ValidJPEG", func(t *testing.T) {
+		result, err := service.Upload(newFileHeader(t, "photo.jpg", photo.Bytes()), "")
+		if err != nil {
+			t.Fatalf("Failed to upload a valid JPEG: %v", err)
+		}
+		if result.ContentType != "image/jpeg" {
+			t.Errorf("Expected content type image/jpeg, got %s", result.ContentType)
+		}
+		if _, err := os.Stat(result.Path); err != nil {
+			t.Errorf("Expected the JPEG to be stored: %v", err)
+		}
+	})
+
+	t.Run("OversizedFile", func(t *testing.T) {
+		oversized := append(photo.Bytes(), make([]byte, 1024*1024)...)
+		_, err := service.Upload(newFileHeader(t, "large.jpg", oversized), "")
+		if !errors.Is(err, ErrFileTooLarge) {
+			t.Errorf("Expected ErrFileTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("PDFRenamedToJPEG", func(t *testing.T) {
+		pdf := []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n%%EOF\n")
+		_, err := service.Upload(newFileHeader(t, "invoice.jpg", pdf), "")
+		if !errors.Is(err, ErrFileTypeNotAllowed) {
+			t.Errorf("Expected ErrFileTypeNotAllowed, got %v", err)
+		}
+	})
 }
@@ -0,0 +1,78 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSend(t *testing.T) {
+	body := []byte(`{"event":"order.created"}`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected POST request, got %s", r.Method)
+		}
+		if r.Header.Get(HeaderEvent) != "order.created" {
+			t.Errorf("Expected event order.created, got %s", r.Header.Get(HeaderEvent))
+		}
+		if r.Header.Get(HeaderDelivery) != "delivery-1" {
+			t.Errorf("Expected delivery delivery-1, got %s", r.Header.Get(HeaderDelivery))
+		}
+
+		received, _ := io.ReadAll(r.Body)
+		timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if err != nil {
+			t.Fatalf("Invalid timestamp header: %v", err)
+		}
+		if !Verify("secret", timestamp, received, r.Header.Get(HeaderSignature)) {
+			t.Error("Expected a valid signature")
+		}
+		if Verify("other-secret", timestamp, received, r.Header.Get(HeaderSignature)) {
+			t.Error("Expected the signature to depend on the secret")
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	result, err := NewClient(time.Second).Send(server.URL, "secret", "order.created", "delivery-1", body)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", result.StatusCode)
+	}
+}
+
+func TestSendErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("maintenance"))
+	}))
+	defer server.Close()
+
+	result, err := NewClient(time.Second).Send(server.URL, "secret", "order.created", "delivery-1", []byte(`{}`))
+	if err == nil {
+		t.Fatal("Expected an error for a non-2xx response")
+	}
+	if result == nil || result.StatusCode != http.StatusServiceUnavailable || result.Body != "maintenance" {
+		t.Errorf("Expected the 503 response to be returned, got %+v", result)
+	}
+}
+
+func TestSign(t *testing.T) {
+	first := Sign("secret", 1700000000, []byte(`{"a":1}`))
+	if first != Sign("secret", 1700000000, []byte(`{"a":1}`)) {
+		t.Error("Expected signing to be deterministic")
+	}
+	if first == Sign("secret", 1700000001, []byte(`{"a":1}`)) {
+		t.Error("Expected the signature to cover the timestamp")
+	}
+	if first == Sign("secret", 1700000000, []byte(`{"a":2}`)) {
+		t.Error("Expected the signature to cover the body")
+	}
+}
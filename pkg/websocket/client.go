@@ -19,6 +19,7 @@ type Client struct {
 	Roles        []string
 	Topics       map[string]bool
 	LastActivity time.Time
+	ReplaySince  time.Time
 	mu           sync.Mutex
 }
 
@@ -180,9 +181,8 @@ func (c *Client) processMessage(data []byte) {
 		if msg.Topic != "" {
 			// Check if the client is authorized to subscribe to this topic
 			if c.Hub.CanSubscribe(c, msg.Topic) {
-				c.Subscribe(msg.Topic)
-				// Send confirmation
-				c.sendSubscriptionConfirmation(msg.Topic, true)
+				// Send confirmation; subscribing fails if the client is no longer registered
+				c.sendSubscriptionConfirmation(msg.Topic, c.Hub.Subscribe(c.ID, msg.Topic))
 			} else {
 				// Send unauthorized message
 				c.sendSubscriptionConfirmation(msg.Topic, false)
@@ -190,7 +190,7 @@ func (c *Client) processMessage(data []byte) {
 		}
 	case "unsubscribe":
 		if msg.Topic != "" {
-			c.Unsubscribe(msg.Topic)
+			c.Hub.Unsubscribe(c.ID, msg.Topic)
 			// Send confirmation
 			c.sendUnsubscriptionConfirmation(msg.Topic)
 		}
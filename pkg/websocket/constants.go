@@ -21,6 +21,12 @@ const (
 
 	// Buffer size for client send channel
 	sendBufferSize = 256
+
+	// Number of recent messages kept per user for replay to reconnecting clients
+	defaultReplayBufferSize = 100
+
+	// How long messages are kept for replay to reconnecting clients
+	defaultReplayBufferTTL = 5 * time.Minute
 )
 
 var (
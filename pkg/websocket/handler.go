@@ -3,6 +3,8 @@ package websocket
 import (
 	"errors"
 	"log"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
@@ -51,6 +53,11 @@ func (h *Handler) HandleConnection(c *websocket.Conn) {
 	// Create a new client
 	client := NewClient(c, h.hub, userID, rolesInterface)
 
+	// A reconnecting client catches up on the messages it missed
+	if since, ok := c.Locals("since").(time.Time); ok {
+		client.ReplaySince = since
+	}
+
 	// Register the client
 	h.hub.Register <- client
 
@@ -82,6 +89,15 @@ func (h *Handler) Middleware() fiber.Handler {
 			c.Locals("user_id", userID)
 			c.Locals("roles", roles)
 
+			// Store the time of the last message a reconnecting client received
+			if sinceStr := c.Query("since"); sinceStr != "" {
+				since, err := ParseReplaySince(sinceStr)
+				if err != nil {
+					return fiber.NewError(fiber.StatusBadRequest, err.Error())
+				}
+				c.Locals("since", since)
+			}
+
 			// Allow the upgrade
 			return c.Next()
 		}
@@ -90,6 +106,22 @@ func (h *Handler) Middleware() fiber.Handler {
 	}
 }
 
+// ParseReplaySince parses the since query parameter of a reconnecting client, given either as
+// an RFC 3339 timestamp or as Unix milliseconds. Clients typically send the created_at of the
+// last notification they received; replayed messages may include it again, so clients should
+// ignore notifications they already have.
+func ParseReplaySince(value string) (time.Time, error) {
+	if millis, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.UnixMilli(millis), nil
+	}
+
+	since, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, errors.New("since must be an RFC 3339 timestamp or Unix milliseconds")
+	}
+	return since, nil
+}
+
 // RegisterRoutes registers WebSocket routes
 func (h *Handler) RegisterRoutes(app *fiber.App, path string) {
 	// Register the WebSocket route
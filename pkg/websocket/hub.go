@@ -3,6 +3,7 @@ package websocket
 import (
 	"encoding/json"
 	"log"
+	"sort"
 	"sync"
 	"time"
 )
@@ -51,6 +52,28 @@ type Hub struct {
 
 	// Inactive timeout
 	inactiveTimeout time.Duration
+
+	// Recent messages per user ("" holds messages broadcast to all) for replay on reconnect
+	history map[string][]bufferedMessage
+
+	// Maximum number of buffered messages per user
+	historySize int
+
+	// How long buffered messages are kept
+	historyTTL time.Duration
+
+	// Sequence of the last buffered message
+	historySeq uint64
+
+	// Mutex for the message history
+	historyMu sync.Mutex
+}
+
+// bufferedMessage is a message kept for replay to reconnecting clients
+type bufferedMessage struct {
+	seq     uint64
+	sentAt  time.Time
+	message []byte
 }
 
 // NewHub creates a new hub
@@ -66,6 +89,9 @@ func NewHub() *Hub {
 		inactiveTimeout: 30 * time.Minute,
 		topicAuth:       defaultTopicAuth,
 		messageHandler:  defaultMessageHandler,
+		history:         make(map[string][]bufferedMessage),
+		historySize:     defaultReplayBufferSize,
+		historyTTL:      defaultReplayBufferTTL,
 	}
 }
 
@@ -81,6 +107,14 @@ func (h *Hub) WithInactiveTimeout(timeout time.Duration) *Hub {
 	return h
 }
 
+// WithReplayBuffer sets how many recent messages are kept per user, and for how long, to be
+// replayed to reconnecting clients. A size of zero disables the replay buffer.
+func (h *Hub) WithReplayBuffer(size int, ttl time.Duration) *Hub {
+	h.historySize = size
+	h.historyTTL = ttl
+	return h
+}
+
 // WithTopicAuth sets the topic authorization function
 func (h *Hub) WithTopicAuth(authFunc TopicAuthFunc) *Hub {
 	h.topicAuth = authFunc
@@ -110,11 +144,24 @@ func (h *Hub) Run() {
 	}
 }
 
-// registerClient registers a client with the hub
+// registerClient registers a client with the hub. A client reconnecting with a ReplaySince
+// time first receives the buffered messages sent after it; registering and replaying under
+// the hub lock ensures no message sent in between is lost.
 func (h *Hub) registerClient(client *Client) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	h.clients[client.ID] = client
+
+	if !client.ReplaySince.IsZero() {
+		for _, message := range h.missedMessages(client.UserID, client.ReplaySince) {
+			select {
+			case client.Send <- message:
+			default:
+				log.Printf("replay buffer of client %s is full, dropping missed messages", client.ID)
+				return
+			}
+		}
+	}
 }
 
 // unregisterClient unregisters a client from the hub
@@ -163,6 +210,47 @@ func (h *Hub) handleBroadcast(bm *BroadcastMessage) {
 	}
 }
 
+// Subscribe subscribes a registered client to a topic and reports whether the client was found
+func (h *Hub) Subscribe(clientID, topic string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	client, ok := h.clients[clientID]
+	if !ok {
+		return false
+	}
+
+	client.Subscribe(topic)
+	if _, ok := h.topics[topic]; !ok {
+		h.topics[topic] = make(map[string]*Client)
+	}
+	h.topics[topic][clientID] = client
+	return true
+}
+
+// Unsubscribe removes a client from the subscribers of a topic
+func (h *Hub) Unsubscribe(clientID, topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if client, ok := h.clients[clientID]; ok {
+		client.Unsubscribe(topic)
+	}
+
+	if topicClients, ok := h.topics[topic]; ok {
+		delete(topicClients, clientID)
+		// If topic has no subscribers, remove it
+		if len(topicClients) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+}
+
+// BroadcastToTopic broadcasts a message to the clients subscribed to a topic
+func (h *Hub) BroadcastToTopic(topic string, message []byte) {
+	h.broadcastToTopic(topic, message)
+}
+
 // broadcastToTopic broadcasts a message to all subscribers of a topic
 func (h *Hub) broadcastToTopic(topic string, message []byte) {
 	h.mu.RLock()
@@ -184,6 +272,8 @@ func (h *Hub) BroadcastToAll(message []byte) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
+	h.recordMessage("", message)
+
 	for _, client := range h.clients {
 		select {
 		case client.Send <- message:
@@ -198,6 +288,8 @@ func (h *Hub) BroadcastToUser(userID string, message []byte) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
+	h.recordMessage(userID, message)
+
 	for _, client := range h.clients {
 		if client.UserID == userID {
 			select {
@@ -225,6 +317,74 @@ func (h *Hub) BroadcastToRole(role string, message []byte) {
 	}
 }
 
+// recordMessage buffers a message sent to a user, or to all users when userID is empty,
+// for replay to clients that reconnect after missing it
+func (h *Hub) recordMessage(userID string, message []byte) {
+	if h.historySize <= 0 {
+		return
+	}
+
+	h.historyMu.Lock()
+	defer h.historyMu.Unlock()
+
+	h.historySeq++
+	now := time.Now()
+	buffered := append(h.history[userID], bufferedMessage{seq: h.historySeq, sentAt: now, message: message})
+	h.history[userID] = trimHistory(buffered, now.Add(-h.historyTTL), h.historySize)
+}
+
+// missedMessages returns the buffered messages of a user, including those sent to all users,
+// that were sent after since, in the order they were sent
+func (h *Hub) missedMessages(userID string, since time.Time) [][]byte {
+	h.historyMu.Lock()
+	defer h.historyMu.Unlock()
+
+	cutoff := time.Now().Add(-h.historyTTL)
+	var missed []bufferedMessage
+	for _, key := range []string{userID, ""} {
+		for _, buffered := range h.history[key] {
+			if buffered.sentAt.After(since) && buffered.sentAt.After(cutoff) {
+				missed = append(missed, buffered)
+			}
+		}
+	}
+
+	sort.Slice(missed, func(i, j int) bool { return missed[i].seq < missed[j].seq })
+
+	messages := make([][]byte, len(missed))
+	for i, buffered := range missed {
+		messages[i] = buffered.message
+	}
+	return messages
+}
+
+// pruneHistory drops the buffered messages that have expired
+func (h *Hub) pruneHistory() {
+	h.historyMu.Lock()
+	defer h.historyMu.Unlock()
+
+	cutoff := time.Now().Add(-h.historyTTL)
+	for userID, buffered := range h.history {
+		if buffered = trimHistory(buffered, cutoff, h.historySize); len(buffered) == 0 {
+			delete(h.history, userID)
+		} else {
+			h.history[userID] = buffered
+		}
+	}
+}
+
+// trimHistory drops the messages sent before cutoff and keeps at most size of the newest
+func trimHistory(buffered []bufferedMessage, cutoff time.Time, size int) []bufferedMessage {
+	start := 0
+	for start < len(buffered) && !buffered[start].sentAt.After(cutoff) {
+		start++
+	}
+	if len(buffered)-start > size {
+		start = len(buffered) - size
+	}
+	return buffered[start:]
+}
+
 // CanSubscribe checks if a client can subscribe to a topic
 func (h *Hub) CanSubscribe(client *Client, topic string) bool {
 	if h.topicAuth != nil {
@@ -260,6 +420,8 @@ func (h *Hub) cleanupInactiveClients() {
 			}
 		}
 		h.mu.Unlock()
+
+		h.pruneHistory()
 	}
 }
 
@@ -155,3 +155,154 @@ func TestMessageSerialization(t *testing.T) {
 	assert.Equal(t, msg.Topic, decodedMsg.Topic)
 	assert.Equal(t, string(msg.Payload), string(decodedMsg.Payload))
 }
+
+// receive reads the next message sent to a client, failing if none is queued
+func receive(t *testing.T, client *Client) string {
+	t.Helper()
+	select {
+	case message := <-client.Send:
+		return string(message)
+	default:
+		t.Fatal("expected a queued message")
+		return ""
+	}
+}
+
+func TestReconnectReplaysMissedMessages(t *testing.T) {
+	hub := NewHub()
+
+	first := NewClient(nil, hub, "user-1", []string{"admin"})
+	hub.registerClient(first)
+
+	hub.BroadcastToUser("user-1", []byte(`{"id":1}`))
+	assert.Equal(t, `{"id":1}`, receive(t, first))
+	lastSeen := time.Now()
+
+	// Messages sent while the user is disconnected
+	hub.unregisterClient(first)
+	time.Sleep(time.Millisecond)
+	hub.BroadcastToUser("user-1", []byte(`{"id":2}`))
+	hub.BroadcastToUser("user-2", []byte(`{"id":3}`))
+	hub.BroadcastToAll([]byte(`{"id":4}`))
+
+	reconnected := NewClient(nil, hub, "user-1", []string{"admin"})
+	reconnected.ReplaySince = lastSeen
+	hub.registerClient(reconnected)
+
+	assert.Equal(t, `{"id":2}`, receive(t, reconnected))
+	assert.Equal(t, `{"id":4}`, receive(t, reconnected))
+	assert.Empty(t, reconnected.Send)
+
+	// Live messages are delivered once replay is done
+	hub.BroadcastToUser("user-1", []byte(`{"id":5}`))
+	assert.Equal(t, `{"id":5}`, receive(t, reconnected))
+
+	// A fresh connection without a since time gets no replay
+	fresh := NewClient(nil, hub, "user-1", []string{"admin"})
+	hub.registerClient(fresh)
+	assert.Empty(t, fresh.Send)
+}
+
+func TestReplayBufferLimits(t *testing.T) {
+	hub := NewHub().WithReplayBuffer(2, time.Minute)
+	since := time.Now()
+	time.Sleep(time.Millisecond)
+
+	for _, message := range []string{`{"id":1}`, `{"id":2}`, `{"id":3}`} {
+		hub.BroadcastToUser("user-1", []byte(message))
+	}
+
+	// Only the newest messages fit in the buffer
+	client := NewClient(nil, hub, "user-1", nil)
+	client.ReplaySince = since
+	hub.registerClient(client)
+	assert.Equal(t, `{"id":2}`, receive(t, client))
+	assert.Equal(t, `{"id":3}`, receive(t, client))
+	assert.Empty(t, client.Send)
+
+	// Expired messages are not replayed
+	hub = NewHub().WithReplayBuffer(10, time.Millisecond)
+	hub.BroadcastToUser("user-1", []byte(`{"id":1}`))
+	time.Sleep(5 * time.Millisecond)
+	hub.pruneHistory()
+	assert.Empty(t, hub.history)
+
+	client = NewClient(nil, hub, "user-1", nil)
+	client.ReplaySince = since
+	hub.registerClient(client)
+	assert.Empty(t, client.Send)
+}
+
+func TestParseReplaySince(t *testing.T) {
+	want := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	since, err := ParseReplaySince("2024-01-15T10:30:00Z")
+	assert.NoError(t, err)
+	assert.True(t, want.Equal(since))
+
+	since, err = ParseReplaySince("1705314600000")
+	assert.NoError(t, err)
+	assert.True(t, want.Equal(since))
+
+	_, err = ParseReplaySince("yesterday")
+	assert.Error(t, err)
+}
+
+func TestTopicSubscription(t *testing.T) {
+	hub := NewHub()
+	subscriber := NewClient(nil, hub, "user-1", []string{"admin"})
+	other := NewClient(nil, hub, "user-2", []string{"admin"})
+	hub.registerClient(subscriber)
+	hub.registerClient(other)
+
+	assert.True(t, hub.Subscribe(subscriber.ID, "orders"))
+	assert.True(t, subscriber.IsSubscribed("orders"))
+	assert.False(t, hub.Subscribe("unknown", "orders"))
+
+	// Only the subscribers of the topic receive its messages
+	hub.BroadcastToTopic("orders", []byte(`{"id":1}`))
+	assert.Equal(t, `{"id":1}`, receive(t, subscriber))
+	assert.Empty(t, other.Send)
+
+	hub.BroadcastToTopic("products", []byte(`{"id":2}`))
+	assert.Empty(t, subscriber.Send)
+	assert.Empty(t, other.Send)
+
+	// Unsubscribed clients no longer receive the topic's messages
+	hub.Unsubscribe(subscriber.ID, "orders")
+	assert.False(t, subscriber.IsSubscribed("orders"))
+	assert.NotContains(t, hub.topics, "orders")
+	hub.BroadcastToTopic("orders", []byte(`{"id":3}`))
+	assert.Empty(t, subscriber.Send)
+
+	// Unregistering a client removes its subscriptions
+	assert.True(t, hub.Subscribe(other.ID, "products"))
+	hub.unregisterClient(other)
+	assert.NotContains(t, hub.topics, "products")
+}
+
+func TestSubscribeControlMessage(t *testing.T) {
+	hub := NewHub().WithTopicAuth(func(client *Client, topic string) bool {
+		return client.HasRole("admin")
+	})
+	admin := NewClient(nil, hub, "user-1", []string{"admin"})
+	guest := NewClient(nil, hub, "user-2", []string{"guest"})
+	hub.registerClient(admin)
+	hub.registerClient(guest)
+
+	admin.processMessage([]byte(`{"type":"subscribe","topic":"orders"}`))
+	assert.JSONEq(t, `{"type":"subscription_status","topic":"orders","success":true}`, receive(t, admin))
+
+	// Clients that may not subscribe to the topic are refused and receive nothing
+	guest.processMessage([]byte(`{"type":"subscribe","topic":"orders"}`))
+	assert.JSONEq(t, `{"type":"subscription_status","topic":"orders","success":false}`, receive(t, guest))
+
+	hub.BroadcastToTopic("orders", []byte(`{"id":1}`))
+	assert.Equal(t, `{"id":1}`, receive(t, admin))
+	assert.Empty(t, guest.Send)
+
+	admin.processMessage([]byte(`{"type":"unsubscribe","topic":"orders"}`))
+	assert.JSONEq(t, `{"type":"unsubscribed","topic":"orders"}`, receive(t, admin))
+	hub.BroadcastToTopic("orders", []byte(`{"id":2}`))
+	assert.Empty(t, admin.Send)
+}
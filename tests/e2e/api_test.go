@@ -229,9 +229,9 @@ func TestE2EUserFlow(t *testing.T) {
 			Success bool   `json:"success"`
 			Message string `json:"message"`
 			Data    struct {
-				ProductID string `json:"ProductID"`
-				Name      string `json:"Name"`
-				SKU       string `json:"SKU"`
+				ProductID string `json:"id"`
+				Name      string `json:"name"`
+				SKU       string `json:"sku"`
 			} `json:"data"`
 		}
 
@@ -297,9 +297,9 @@ func TestE2EUserFlow(t *testing.T) {
 			Success bool   `json:"success"`
 			Message string `json:"message"`
 			Data    struct {
-				InventoryID string `json:"InventoryID"`
-				ProductID   string `json:"ProductID"`
-				Quantity    int    `json:"Quantity"`
+				InventoryID string `json:"id"`
+				ProductID   string `json:"product_id"`
+				Quantity    int    `json:"quantity"`
 			} `json:"data"`
 		}
 
@@ -333,10 +333,10 @@ func TestE2EUserFlow(t *testing.T) {
 			Success bool   `json:"success"`
 			Message string `json:"message"`
 			Data    struct {
-				PriceID   string  `json:"PriceID"`
-				ProductID string  `json:"ProductID"`
-				Price     float64 `json:"Price"`
-				Currency  string  `json:"Currency"`
+				PriceID   string  `json:"id"`
+				ProductID string  `json:"product_id"`
+				Price     float64 `json:"price"`
+				Currency  string  `json:"currency"`
 			} `json:"data"`
 		}
 
@@ -360,21 +360,19 @@ func TestE2EUserFlow(t *testing.T) {
 		var getResp struct {
 			Success bool   `json:"success"`
 			Message string `json:"message"`
-			Data    struct {
-				Products []struct {
-					ID string `json:"id"`
-				} `json:"products"`
-				Total      int64 `json:"total"`
-				Page       int   `json:"page"`
-				PageSize   int   `json:"page_size"`
-				TotalPages int   `json:"total_pages"`
+			Data    []struct {
+				ID string `json:"id"`
 			} `json:"data"`
+			Total      int64 `json:"total"`
+			Page       int   `json:"page"`
+			PageSize   int   `json:"page_size"`
+			TotalPages int64 `json:"total_pages"`
 		}
 
 		err = json.NewDecoder(resp.Body).Decode(&getResp)
 		require.NoError(t, err, "Should decode response JSON")
 		assert.True(t, getResp.Success, "Response should indicate success")
-		assert.Greater(t, getResp.Data.Total, int64(0), "Should have at least one product")
+		assert.Greater(t, getResp.Total, int64(0), "Should have at least one product")
 	})
 
 	// Test create order